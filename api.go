@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hack_interview/internal/msg"
+)
+
+// REST API только для чтения поверх хранилища состояния. Поля ответов те
+// же, что в list --json и stats --json; задание дополняется отчётом об
+// обработке, если он записан.
+
+func registerAPI(mux *http.ServeMux) {
+	mux.Handle("GET /api/jobs", requireToken(http.HandlerFunc(handleAPIJobs)))
+	mux.Handle("GET /api/jobs/{name...}", requireToken(http.HandlerFunc(handleAPIJob)))
+	mux.Handle("GET /api/sessions", requireToken(http.HandlerFunc(handleAPISessions)))
+	mux.Handle("GET /api/stats", requireToken(http.HandlerFunc(handleAPIStats)))
+}
+
+// apiJobs страница списка заданий
+type apiJobs struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Jobs   []listEntry `json:"jobs"`
+}
+
+// apiJob задание с текстом вопроса и ответом
+type apiJob struct {
+	listEntry
+	Question string  `json:"question,omitempty"`
+	Answer   string  `json:"answer,omitempty"`
+	Report   *Report `json:"report,omitempty"`
+}
+
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 500
+)
+
+// handleAPIJobs список заданий от новых к старым. Параметры: status (через
+// запятую), session, since и until (как в stats), limit и offset.
+func handleAPIJobs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, until, err := statsPeriod(q.Get("since"), q.Get("until"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	limit, offset := apiDefaultLimit, 0
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &limit}, {"offset", &offset}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": p.name + ": ожидается неотрицательное число"})
+				return
+			}
+			*p.dst = n
+		}
+	}
+	limit = min(limit, apiMaxLimit)
+	statuses := make(map[string]bool)
+	for _, s := range strings.Split(q.Get("status"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			statuses[s] = true
+		}
+	}
+	session := q.Get("session")
+
+	refreshState()
+	var jobs []listEntry
+	for _, e := range listEntries() {
+		switch {
+		case len(statuses) > 0 && !statuses[e.Status],
+			session != "" && e.Session != session,
+			since != nil && e.UpdatedAt.Before(*since),
+			until != nil && !e.UpdatedAt.Before(*until):
+			continue
+		}
+		jobs = append(jobs, e)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].UpdatedAt.Equal(jobs[j].UpdatedAt) {
+			return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+		}
+		return jobs[i].Name < jobs[j].Name
+	})
+	page := apiJobs{Total: len(jobs), Offset: offset, Jobs: []listEntry{}}
+	if offset < len(jobs) {
+		page.Jobs = jobs[offset:min(offset+limit, len(jobs))]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func handleAPIJob(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	refreshState()
+	fs, ok := state.Get(name)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "задание не найдено"})
+		return
+	}
+	job := apiJob{listEntry: listEntry{Name: name, FileState: fs}}
+	job.Question, _ = uiQuestion(fs)
+	if fs.Output != "" {
+		if data, err := os.ReadFile(fs.Output); err == nil {
+			job.Answer = string(data)
+		}
+	}
+	src := Job{Name: name, Path: filepath.Join(config.InputDir, name), Group: fs.Group}
+	if report, err := readReport(jobReportPath(src, fs.Output)); err == nil {
+		job.Report = &report
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func handleAPISessions(w http.ResponseWriter, r *http.Request) {
+	refreshState()
+	sessions := buildStats(state.Snapshot(), nil, nil).Sessions
+	if sessions == nil {
+		sessions = []statsSession{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func handleAPIStats(w http.ResponseWriter, r *http.Request) {
+	since, until, err := statsPeriod(r.URL.Query().Get("since"), r.URL.Query().Get("until"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	refreshState()
+	writeJSON(w, http.StatusOK, buildStats(state.Snapshot(), since, until))
+}
+
+func refreshState() {
+	if err := state.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+	}
+}
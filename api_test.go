@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apiEnv окружение с API и записями состояния: a.png и b.png готовы в
+// сессии s1, c.png неудачен в s2; a.png обработан минуту назад, c.png —
+// десять минут, b.png — два часа. Во входной директории лежит ещё не
+// обработанный new.png.
+func apiEnv(t *testing.T) http.Handler {
+	t.Helper()
+	testEnv(t, "server:\n  api: true\n  token: secret\n")
+	out := filepath.Join(config.OutputDir, "a.md")
+	os.WriteFile(out, []byte("## Ответ\n\nЛёгкий поток.\n"), 0644)
+	os.WriteFile(filepath.Join(config.OutputDir, "a.ocr.txt"), []byte("Что такое горутина?\n"), 0644)
+	for name, fs := range map[string]FileState{
+		"a.png": {Status: statusDone, Output: out, Session: "s1", TokensIn: 21, TokensOut: 12},
+		"b.png": {Status: statusDone, Session: "s1"},
+		"c.png": {Status: statusFailed, Session: "s2", LastError: "HTTP 503"},
+	} {
+		if err := state.Set(name, fs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := state.(*jsonStore).Update(func(files map[string]*FileState) {
+		now := time.Now()
+		files["a.png"].UpdatedAt = now.Add(-time.Minute)
+		files["c.png"].UpdatedAt = now.Add(-10 * time.Minute)
+		files["b.png"].UpdatedAt = now.Add(-2 * time.Hour)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeInput(t, "new.png")
+
+	mux := http.NewServeMux()
+	registerAPI(mux)
+	return mux
+}
+
+// apiGet выполняет запрос с токеном и разбирает JSON-ответ в v
+func apiGet(t *testing.T, h http.Handler, target string, v any) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s: %v\n%s", target, err, rec.Body)
+		}
+	}
+	return rec.Code
+}
+
+func TestAPIRequiresToken(t *testing.T) {
+	h := apiEnv(t)
+	for _, target := range []string{"/api/jobs", "/api/jobs/a.png", "/api/sessions", "/api/stats"} {
+		for _, auth := range []string{"", "Bearer wrong", "secret"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized || strings.Contains(rec.Body.String(), "горутина") {
+				t.Errorf("%s с %q: HTTP %d %s", target, auth, rec.Code, rec.Body)
+			}
+		}
+	}
+}
+
+func TestAPIJobsFilters(t *testing.T) {
+	h := apiEnv(t)
+	names := func(page apiJobs) string {
+		var out []string
+		for _, j := range page.Jobs {
+			out = append(out, j.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	for _, tc := range []struct {
+		query string
+		total int
+		want  string
+	}{
+		// От новых к старым, с ещё не обработанными файлами
+		{"", 4, "new.png,a.png,c.png,b.png"},
+		{"?status=done", 2, "a.png,b.png"},
+		{"?status=failed,%20pending", 2, "new.png,c.png"},
+		{"?session=s1", 2, "a.png,b.png"},
+		{"?session=s1&status=failed", 0, ""},
+		{"?since=1h", 3, "new.png,a.png,c.png"},
+		{"?until=1h", 1, "b.png"},
+		{"?limit=2", 4, "new.png,a.png"},
+		{"?limit=2&offset=2", 4, "c.png,b.png"},
+		{"?offset=10", 4, ""},
+	} {
+		var page apiJobs
+		if code := apiGet(t, h, "/api/jobs"+tc.query, &page); code != http.StatusOK {
+			t.Errorf("%s: HTTP %d", tc.query, code)
+			continue
+		}
+		if page.Total != tc.total || names(page) != tc.want || page.Jobs == nil {
+			t.Errorf("%s: всего %d, задания %q; want %d, %q", tc.query, page.Total, names(page), tc.total, tc.want)
+		}
+	}
+
+	for _, query := range []string{"?limit=-1", "?offset=x", "?since=вчера"} {
+		var body map[string]string
+		if code := apiGet(t, h, "/api/jobs"+query, &body); code != http.StatusBadRequest || body["error"] == "" {
+			t.Errorf("%s: HTTP %d %v", query, code, body)
+		}
+	}
+}
+
+func TestAPIJob(t *testing.T) {
+	h := apiEnv(t)
+	var raw map[string]any
+	if code := apiGet(t, h, "/api/jobs/a.png", &raw); code != http.StatusOK {
+		t.Fatalf("HTTP %d", code)
+	}
+	// Поля те же, что в list --json
+	for _, field := range []string{"name", "status", "output", "session", "tokensIn", "tokensOut", "updatedAt", "question", "answer"} {
+		if _, ok := raw[field]; !ok {
+			t.Errorf("нет поля %q: %v", field, raw)
+		}
+	}
+	if raw["question"] != "Что такое горутина?" || raw["answer"] != "## Ответ\n\nЛёгкий поток.\n" || raw["tokensIn"] != 21.0 {
+		t.Errorf("задание %v", raw)
+	}
+
+	var body map[string]string
+	if code := apiGet(t, h, "/api/jobs/missing.png", &body); code != http.StatusNotFound || body["error"] == "" {
+		t.Errorf("неизвестное задание: HTTP %d %v", code, body)
+	}
+}
+
+func TestAPISessionsAndStats(t *testing.T) {
+	h := apiEnv(t)
+	var sessions []statsSession
+	if code := apiGet(t, h, "/api/sessions", &sessions); code != http.StatusOK || len(sessions) != 2 {
+		t.Fatalf("HTTP %d, сессии %+v", code, sessions)
+	}
+	bySession := map[string]statsSession{}
+	for _, s := range sessions {
+		bySession[s.Session] = s
+	}
+	if s1 := bySession["s1"]; s1.Files != 2 || s1.Done != 2 || s1.Tokens.Input != 21 || s1.Tokens.Output != 12 {
+		t.Errorf("s1: %+v", s1)
+	}
+	if bySession["s2"].Failed != 1 {
+		t.Errorf("s2: %+v", bySession["s2"])
+	}
+
+	var stats statsReport
+	if code := apiGet(t, h, "/api/stats?since=1h", &stats); code != http.StatusOK {
+		t.Fatalf("HTTP %d", code)
+	}
+	// Тот же отчёт, что stats --json за тот же период
+	since, until, _ := statsPeriod("1h", "")
+	want := buildStats(state.Snapshot(), since, until)
+	if stats.Files != want.Files || stats.Files != 2 || stats.ByStatus["done"] != 1 || stats.ByStatus["failed"] != 1 {
+		t.Errorf("отчёт %+v, want %+v", stats, want)
+	}
+	if code := apiGet(t, h, "/api/stats?until=x", nil); code != http.StatusBadRequest {
+		t.Errorf("некорректный until: HTTP %d", code)
+	}
+}
+
+// Загрузка принимает PNG, JPEG и GIF по содержимому, а ошибка для другого
+// типа называет все три
+func TestSaveUploadTypes(t *testing.T) {
+	testEnv(t, "")
+	for data, ext := range map[string]string{
+		"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR": ".png",
+		"\xff\xd8\xff\xe0\x00\x10JFIF\x00":    ".jpg",
+		"GIF89a\x01\x00\x01\x00\x00\x00\x00":  ".gif",
+	} {
+		id, err := saveUpload([]byte(data), 1<<20)
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		if !exists(filepath.Join(config.InputDir, uploadName(id)+ext)) {
+			t.Errorf("%s: файл не сохранён", ext)
+		}
+	}
+	_, err := saveUpload([]byte("просто текст"), 1<<20)
+	if !errors.Is(err, errUploadType) || !strings.Contains(err.Error(), "PNG, JPEG и GIF") {
+		t.Errorf("текст: %v", err)
+	}
+	if _, err := saveUpload([]byte("GIF89a\x01\x00\x01\x00"), 4); !errors.Is(err, errUploadTooLarge) {
+		t.Errorf("большой файл: %v", err)
+	}
+}
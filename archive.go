@@ -0,0 +1,308 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Архив сессии для передачи целиком: стенограмма, ответы с файлами рядом
+// (перевод, код, тесты), отчёты, распознанный текст и по желанию исходные
+// снимки в OutputDir/archives/session-<id>.zip с manifest.json в корне.
+// Архив детерминирован: файлы в нём отсортированы по пути, время изменения
+// у всех одно, поэтому повторная упаковка того же даёт тот же zip.
+
+// archiveTime время изменения всех файлов архива: начало эпохи DOS
+var archiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Виды файлов архива
+const (
+	archiveTranscript = "transcript"
+	archiveAnswer     = "answer"
+	archiveReport     = "report"
+	archiveOCR        = "ocr"
+	archiveScreenshot = "screenshot"
+)
+
+// archiveManifest manifest.json архива
+type archiveManifest struct {
+	Session    string          `json:"session"`
+	Anonymized bool            `json:"anonymized"`
+	Files      []archiveMember `json:"files"`
+	// Missing файлы, которые должны были попасть в архив, но не прочитаны
+	Missing []archiveMember `json:"missing,omitempty"`
+}
+
+type archiveMember struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int    `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	source string
+	data   []byte
+}
+
+// runArchive упаковывает сессию в zip
+func runArchive(args []string) int {
+	fset := flag.NewFlagSet("archive", flag.ContinueOnError)
+	session := fset.String("session", "", "идентификатор сессии (по умолчанию последняя)")
+	screenshots := fset.Bool("screenshots", false, "добавить исходные снимки")
+	anonymize := fset.Bool("anonymize", false, "скрыть почту, телефоны и ключи в текстовых файлах")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+
+	id := *session
+	if id == "" {
+		id = latestSession()
+		if id == "" {
+			fmt.Fprintln(os.Stderr, msg.T("archive.no-sessions"))
+			return 1
+		}
+	}
+	if *anonymize && *screenshots {
+		slog.Warn(msg.T("archive.screenshots-raw"))
+	}
+	members := sessionMembers(id, *screenshots)
+	if members == nil {
+		fmt.Fprintf(os.Stderr, msg.T("archive.unknown-session"), id)
+		return 1
+	}
+	path, manifest, err := writeArchive(id, members, *anonymize)
+	if err != nil {
+		slog.Error(msg.T("archive.failed"), "session", id, "error", err)
+		return 1
+	}
+	fmt.Printf(msg.T("archive.done"), path, len(manifest.Files), len(manifest.Missing))
+	return 0
+}
+
+// latestSession последняя сессия в SessionsDir: идентификатор начинается
+// со времени начала, поэтому последняя идёт последней и по имени
+func latestSession() string {
+	entries, err := os.ReadDir(config.SessionsDir)
+	if err != nil {
+		return ""
+	}
+	latest := ""
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() > latest {
+			latest = e.Name()
+		}
+	}
+	return latest
+}
+
+// sessionMembers файлы сессии id без содержимого или nil, если сессии нет
+// ни в SessionsDir, ни в состоянии
+func sessionMembers(id string, screenshots bool) []archiveMember {
+	if id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return nil
+	}
+	var members []archiveMember
+	add := func(kind, dir, source, name string) {
+		for _, m := range members {
+			if m.source == source {
+				return
+			}
+		}
+		members = append(members, archiveMember{Path: path.Join(dir, filepath.ToSlash(name)), Kind: kind, source: source})
+	}
+	// inOutput путь относительно OutputDir или имя файла для пути вне её
+	inOutput := func(p string) string {
+		if rel, err := filepath.Rel(absPath(config.OutputDir), absPath(p)); err == nil && within(absPath(p), absPath(config.OutputDir)) {
+			return rel
+		}
+		return filepath.Base(p)
+	}
+
+	dir := filepath.Join(config.SessionsDir, id)
+	_, err := os.Stat(dir)
+	found := err == nil
+	add(archiveTranscript, "", filepath.Join(dir, "transcript.md"), "transcript.md")
+
+	files := state.Snapshot()
+	names := make([]string, 0, len(files))
+	for name, fs := range files {
+		if fs.Session == id {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		found = true
+		fs := files[name]
+		input := filepath.Join(config.InputDir, name)
+		job := Job{Name: name, Path: input, Group: fs.Group}
+		if fs.Output != "" {
+			add(archiveAnswer, "answers", fs.Output, inOutput(fs.Output))
+			for _, f := range resultFiles(fs.Output) {
+				switch {
+				case strings.HasSuffix(f, reportSuffix):
+					add(archiveReport, "reports", f, inOutput(f))
+				case strings.HasSuffix(f, ".ocr.txt"):
+					add(archiveOCR, "ocr", f, inOutput(f))
+				default:
+					add(archiveAnswer, "answers", f, inOutput(f))
+				}
+			}
+			ocr := filepath.Join(filepath.Dir(fs.Output), requestName(apppipeline.Request{Path: input, Name: fs.Group})+".ocr.txt")
+			add(archiveOCR, "ocr", ocr, inOutput(ocr))
+		}
+		report := jobReportPath(job, fs.Output)
+		add(archiveReport, "reports", report, inOutput(report))
+		if screenshots {
+			src := input
+			if fs.MovedTo != "" {
+				src = fs.MovedTo
+			}
+			add(archiveScreenshot, "screenshots", src, name)
+		}
+	}
+	if !found {
+		return nil
+	}
+	return members
+}
+
+// writeArchive читает файлы members и записывает архив через временный
+// файл. Файл, который не удалось прочитать, попадает в manifest.Missing.
+func writeArchive(id string, members []archiveMember, anonymize bool) (string, archiveManifest, error) {
+	manifest := archiveManifest{Session: id, Anonymized: anonymize}
+	for _, m := range members {
+		data, err := readArchiveSource(m.source)
+		if err != nil {
+			m.Error = err.Error()
+			if anonymize {
+				m.Error = anonymizeText(m.Error)
+			}
+			manifest.Missing = append(manifest.Missing, m)
+			continue
+		}
+		if anonymize && m.Kind != archiveScreenshot && utf8.Valid(data) {
+			data = []byte(anonymizeText(string(data)))
+		}
+		sum := sha256.Sum256(data)
+		m.data, m.Size, m.SHA256 = data, len(data), hex.EncodeToString(sum[:])
+		manifest.Files = append(manifest.Files, m)
+	}
+	byPath := func(list []archiveMember) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	}
+	byPath(manifest.Files)
+	byPath(manifest.Missing)
+	uniquePaths(manifest.Files)
+
+	dir := filepath.Join(config.OutputDir, "archives")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", manifest, err
+	}
+	name := "session-" + id
+	if anonymize {
+		name += "-anonymized"
+	}
+	out := filepath.Join(dir, name+".zip")
+	tmp, err := os.CreateTemp(dir, ".archive-*")
+	if err != nil {
+		return "", manifest, err
+	}
+	defer os.Remove(tmp.Name())
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		tmp.Close()
+		return "", manifest, err
+	}
+	zw := zip.NewWriter(tmp)
+	err = writeZipFile(zw, "manifest.json", append(data, '\n'))
+	for _, m := range manifest.Files {
+		if err != nil {
+			break
+		}
+		err = writeZipFile(zw, m.Path, m.data)
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", manifest, err
+	}
+	return out, manifest, os.Rename(tmp.Name(), out)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// uniquePaths добавляет -2, -3... к повторяющимся путям отсортированного
+// списка: разные файлы могут дать одно имя, например снимки из разных
+// поддиректорий с одним именем результата вне OutputDir
+func uniquePaths(list []archiveMember) {
+	seen := make(map[string]bool, len(list))
+	for i := range list {
+		p := list[i].Path
+		ext := path.Ext(p)
+		for n := 2; seen[p]; n++ {
+			p = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(list[i].Path, ext), n, ext)
+		}
+		seen[p] = true
+		list[i].Path = p
+	}
+}
+
+// readArchiveSource содержимое файла; имя внешнего источника (s3://,
+// imap://) локального файла не имеет
+func readArchiveSource(source string) ([]byte, error) {
+	if strings.Contains(source, "://") {
+		return nil, errors.New("файл из внешнего источника, локальной копии нет")
+	}
+	data, err := os.ReadFile(source)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New("файла нет")
+	}
+	return data, err
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	// phonePattern номера в международном формате или с кодом в скобках:
+	// голые длинные числа в коде и выводе программ не трогаются
+	phonePattern = regexp.MustCompile(`(\+\d[\d\s().-]{7,}\d|\(\d{3,5}\)\s?\d[\d\s-]{4,}\d)`)
+)
+
+// anonymizeText скрывает в тексте адреса почты, телефоны и ключи из
+// конфигурации
+func anonymizeText(s string) string {
+	s = redactSecrets(s)
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	return phonePattern.ReplaceAllString(s, "[phone]")
+}
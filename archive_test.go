@@ -0,0 +1,164 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// answerWithCode ответ с кодом: длинные числа и выражения в скобках в нём
+// не похожи на телефоны и не скрываются
+const answerWithCode = "Ответ для ivan.petrov@example.com:\n\n```go\nconst mod = 1000000007\n" +
+	"ts := int64(1718000000123)\nfmt.Println((a + b) % mod, x-1)\n```\n"
+
+// archiveEnv сессия s1 из двух снимков: у task.png есть ответ, код,
+// распознанный текст и снимок, у second.png ответ записан в состояние,
+// но удалён
+func archiveEnv(t *testing.T) []archiveMember {
+	t.Helper()
+	testEnv(t, "")
+	config.GeminiAPIKey = "AIza-archive-key-7d3f"
+	writePNGInput(t, "task.png")
+	for name, text := range map[string]string{
+		filepath.Join(config.SessionsDir, "s1", "transcript.md"): "Кандидат: ivan.petrov@example.com, +7 (916) 123-45-67\n",
+		filepath.Join(config.OutputDir, "task.md"):               answerWithCode,
+		filepath.Join(config.OutputDir, "task_test.go"):          "package main\n",
+		filepath.Join(config.OutputDir, "task.ocr.txt"):          "key=AIza-archive-key-7d3f тел. (495) 123-45-67\n",
+	} {
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := os.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := state.(*jsonStore).Update(func(files map[string]*FileState) {
+		files["task.png"] = &FileState{Status: statusDone, Session: "s1", Output: filepath.Join(config.OutputDir, "task.md")}
+		files["second.png"] = &FileState{Status: statusDone, Session: "s1", Output: filepath.Join(config.OutputDir, "second.md")}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := sessionMembers("s1", true)
+	if members == nil {
+		t.Fatal("сессия s1 не найдена")
+	}
+	return members
+}
+
+// readZip содержимое файлов архива по пути внутри него
+func readZip(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = data
+	}
+	return files
+}
+
+// Повторная упаковка даёт тот же zip, manifest.json описывает каждый файл
+// архива, а удалённый ответ попадает в missing
+func TestWriteArchive(t *testing.T) {
+	members := archiveEnv(t)
+	path, manifest, err := writeArchive("s1", members, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := writeArchive("s1", sessionMembers("s1", true), false); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(path); !bytes.Equal(first, again) {
+		t.Error("повторная упаковка дала другой zip")
+	}
+
+	files := readZip(t, path)
+	var zipped archiveManifest
+	if err := json.Unmarshal(files["manifest.json"], &zipped); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(zipped.Files)+1 {
+		t.Errorf("в архиве %d файлов, в manifest.json %d", len(files), len(zipped.Files))
+	}
+	for _, m := range zipped.Files {
+		data, ok := files[m.Path]
+		sum := sha256.Sum256(data)
+		if !ok || m.Size != len(data) || m.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: в архиве %v, %d байт, manifest %d байт %s", m.Path, ok, len(data), m.Size, m.SHA256)
+		}
+	}
+	for _, want := range []string{"transcript.md", "answers/task.md", "answers/task_test.go", "ocr/task.ocr.txt", "screenshots/task.png"} {
+		if _, ok := files[want]; !ok {
+			t.Errorf("в архиве нет %s", want)
+		}
+	}
+
+	missing := map[string]string{}
+	for _, m := range zipped.Missing {
+		missing[m.Path] = m.Error
+	}
+	if missing["answers/second.md"] != "файла нет" || len(zipped.Missing) != len(manifest.Missing) {
+		t.Errorf("missing: %v", missing)
+	}
+}
+
+// --anonymize скрывает почту, телефоны и ключи из конфигурации в текстовых
+// файлах, не трогая код и снимки, и пишет отдельный архив
+func TestWriteArchiveAnonymize(t *testing.T) {
+	members := archiveEnv(t)
+	plain, _, err := writeArchive("s1", members, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, manifest, err := writeArchive("s1", members, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "session-s1-anonymized.zip" || path == plain || !manifest.Anonymized {
+		t.Errorf("архив %s, anonymized %v", path, manifest.Anonymized)
+	}
+	files, original := readZip(t, path), readZip(t, plain)
+
+	if got := string(files["transcript.md"]); got != "Кандидат: [email], [phone]\n" {
+		t.Errorf("стенограмма %q", got)
+	}
+	if got := string(files["ocr/task.ocr.txt"]); got != "key="+redacted+" тел. [phone]\n" {
+		t.Errorf("распознанный текст %q", got)
+	}
+	answer := string(files["answers/task.md"])
+	if want := strings.Replace(answerWithCode, "ivan.petrov@example.com", "[email]", 1); answer != want {
+		t.Errorf("ответ:\n%s\nwant\n%s", answer, want)
+	}
+	if !bytes.Equal(files["screenshots/task.png"], original["screenshots/task.png"]) {
+		t.Error("снимок изменён")
+	}
+	for name, data := range files {
+		for _, leak := range []string{"ivan.petrov", "123-45-67", config.GeminiAPIKey} {
+			if bytes.Contains(data, []byte(leak)) {
+				t.Errorf("%s: осталось %q", name, leak)
+			}
+		}
+	}
+}
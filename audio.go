@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/go-resty/resty/v2"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/speech"
+)
+
+// newTranscriber бэкенд распознавания речи из конфигурации; nil — аудио
+// не обрабатывается
+func newTranscriber(cfg Config, client *resty.Client) speech.Transcriber {
+	splitter := speech.Splitter{FFmpeg: cfg.Audio.FFmpeg, Seconds: cfg.Audio.ChunkSeconds}
+	switch cfg.Audio.Backend {
+	case appconfig.AudioOpenAI:
+		return &speech.OpenAI{
+			APIKey:   cfg.Audio.APIKey,
+			BaseURL:  cfg.Audio.BaseURL,
+			Headers:  cfg.Audio.Headers,
+			Model:    cfg.Audio.Model,
+			Language: cfg.Audio.Language,
+			Client:   client,
+			Timeout:  cfg.RequestTimeout,
+			Splitter: splitter,
+		}
+	case appconfig.AudioWhisperCPP:
+		return &speech.WhisperCPP{
+			Binary:   cfg.Audio.Binary,
+			Model:    cfg.Audio.Model,
+			Language: cfg.Audio.Language,
+			Splitter: splitter,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"hack_interview/internal/breaker"
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/ocr"
+)
+
+// breakers цепи провайдеров по имени из metrics; пусто, если
+// breaker.threshold 0
+var breakers = map[string]*breaker.Breaker{}
+
+func newBreaker(cfg Config, provider string) *breaker.Breaker {
+	if *cfg.Breaker.Threshold == 0 {
+		return nil
+	}
+	b := &breaker.Breaker{
+		Name:      provider,
+		Threshold: *cfg.Breaker.Threshold,
+		Cooldown:  cfg.Breaker.Cooldown,
+		OnChange:  logBreakerChange,
+	}
+	breakers[provider] = b
+	return b
+}
+
+func logBreakerChange(provider string, from, to breaker.State) {
+	metrics.BreakerState(provider, to.String(), int(to))
+	switch to {
+	case breaker.Open:
+		slog.Warn(msg.T("breaker.open"), "provider", provider, "from", from.String(), "cooldown", config.Breaker.Cooldown)
+	case breaker.HalfOpen:
+		slog.Info(msg.T("breaker.half-open"), "provider", provider)
+	default:
+		slog.Info(msg.T("breaker.closed"), "provider", provider)
+	}
+}
+
+// breakerOutcome итог запроса для цепи: ошибка, которую имеет смысл
+// повторять, — отказ провайдера, остальные (нет текста, блокировка,
+// неверный ключ) — ответ, пусть и неудачный для файла
+func breakerOutcome(ctx context.Context, err error) breaker.Outcome {
+	switch {
+	case err == nil:
+		return breaker.Success
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		return breaker.Ignored
+	case errs.Retryable(err):
+		return breaker.Failure
+	}
+	return breaker.Success
+}
+
+// guarded выполняет call через цепь b. При разомкнутой цепи в режиме wait
+// ждёт пробного запроса или замыкания, в режиме fail вызывает fallback, а
+// без него сразу возвращает CircuitOpenError.
+func guarded(ctx context.Context, b *breaker.Breaker, call, fallback func() (string, error)) (string, error) {
+	done := b.Allow()
+	if done == nil {
+		switch {
+		case config.Breaker.Mode == appconfig.BreakerWait:
+			var err error
+			if done, err = b.Wait(ctx); err != nil {
+				return "", err
+			}
+		case fallback != nil:
+			return fallback()
+		default:
+			_, until := b.State()
+			return "", &errs.CircuitOpenError{Provider: b.Name, Until: until}
+		}
+	}
+	text, err := call()
+	done(breakerOutcome(ctx, err))
+	return text, err
+}
+
+// breakerOCR OCR через цепь провайдера
+type breakerOCR struct {
+	b *breaker.Breaker
+	// fallback запасной адрес для режима fail; может быть nil
+	fallback ocr.Provider
+	next     ocr.Provider
+}
+
+func guardOCR(b *breaker.Breaker, next, fallback ocr.Provider) ocr.Provider {
+	if b == nil {
+		return next
+	}
+	return breakerOCR{b: b, next: next, fallback: fallback}
+}
+
+func (p breakerOCR) ExtractText(ctx context.Context, path string) (string, error) {
+	call := func() (string, error) { return p.next.ExtractText(ctx, path) }
+	var fallback func() (string, error)
+	if p.fallback != nil {
+		fallback = func() (string, error) {
+			slog.Debug(msg.T("breaker.ocr-fallback"), "path", path)
+			return p.fallback.ExtractText(ctx, path)
+		}
+	}
+	return guarded(ctx, p.b, call, fallback)
+}
+
+// breakerLLM модель через цепь провайдера
+type breakerLLM struct {
+	b        *breaker.Breaker
+	fallback llm.Answerer
+	next     llm.Answerer
+}
+
+func guardLLM(b *breaker.Breaker, next, fallback llm.Answerer) llm.Answerer {
+	if b == nil {
+		return next
+	}
+	return breakerLLM{b: b, next: next, fallback: fallback}
+}
+
+func (a breakerLLM) Answer(ctx context.Context, req llm.Request) (string, error) {
+	call := func() (string, error) { return a.next.Answer(ctx, req) }
+	var fallback func() (string, error)
+	if a.fallback != nil {
+		fallback = func() (string, error) {
+			slog.Debug(msg.T("breaker.llm-fallback"), "model", config.Breaker.GeminiFallback.Name)
+			return a.fallback.Answer(ctx, req)
+		}
+	}
+	return guarded(ctx, a.b, call, fallback)
+}
+
+// blockedProvider провайдер с разомкнутой цепью в режиме wait: пока
+// пауза не истекла, задания обработчикам не выдаются
+func blockedProvider() string {
+	if config.Breaker.Mode != appconfig.BreakerWait {
+		return ""
+	}
+	for _, name := range breakerNames() {
+		if breakers[name].Blocked() {
+			return name
+		}
+	}
+	return ""
+}
+
+func breakerNames() []string {
+	names := make([]string, 0, len(breakers))
+	for name := range breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// breakerStatus состояние цепи для команды status
+type breakerStatus struct {
+	Provider string     `json:"provider"`
+	State    string     `json:"state"`
+	RetryAt  *time.Time `json:"retryAt,omitempty"`
+}
+
+func collectBreakers() []breakerStatus {
+	var out []breakerStatus
+	for _, name := range breakerNames() {
+		st, until := breakers[name].State()
+		s := breakerStatus{Provider: name, State: st.String()}
+		if !until.IsZero() {
+			s.RetryAt = &until
+		}
+		out = append(out, s)
+	}
+	return out
+}
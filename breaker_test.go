@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"hack_interview/internal/breaker"
+	"hack_interview/internal/errs"
+)
+
+// Цепь размыкают только сбои провайдера; окончательные ошибки файла и
+// отмена запроса её не трогают
+func TestBreakerOutcome(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want breaker.Outcome
+	}{
+		{"успех", context.Background(), nil, breaker.Success},
+		{"5xx", context.Background(), errs.FromStatus("gemini", http.StatusBadGateway, ""), breaker.Failure},
+		{"квота", context.Background(), &errs.QuotaExceededError{Provider: "gemini"}, breaker.Failure},
+		{"таймаут", context.Background(), errs.FromTransport(context.DeadlineExceeded), breaker.Failure},
+		{"ключ", context.Background(), errs.FromStatus("gemini", http.StatusUnauthorized, ""), breaker.Success},
+		{"нет текста", context.Background(), fmt.Errorf("ocr: %w", errs.ErrNoText), breaker.Success},
+		{"блокировка", context.Background(), &errs.BlockedError{Reason: "SAFETY"}, breaker.Success},
+		{"отмена контекста", canceled, errs.FromStatus("gemini", http.StatusBadGateway, ""), breaker.Ignored},
+		{"отменённый запрос", context.Background(), fmt.Errorf("post: %w", context.Canceled), breaker.Ignored},
+	} {
+		if got := breakerOutcome(tc.ctx, tc.err); got != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
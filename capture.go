@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// captureBounds вычисляет прямоугольник захвата внутри границ монитора
+func captureBounds(display image.Rectangle, region *CaptureRect) (image.Rectangle, error) {
+	if region == nil {
+		return display, nil
+	}
+	r := image.Rect(region.X, region.Y, region.X+region.Width, region.Y+region.Height).Add(display.Min)
+	r = r.Intersect(display)
+	if r.Empty() {
+		return r, fmt.Errorf("область захвата %+v вне границ монитора %v", *region, display)
+	}
+	return r, nil
+}
+
+// autoCaptureSource источник кадров периодического захвата
+const autoCaptureSource = "auto"
+
+// submitCapture кодирует снимок в PNG и кладёт его во входную директорию,
+// при необходимости сохраняя копию в SaveDir.
+func submitCapture(img image.Image, source string) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.png", source, time.Now().Format("20060102-150405.000"))
+	dest, err := writeInputFile(name, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if config.Capture.SaveDir != "" {
+		if err := os.MkdirAll(config.Capture.SaveDir, os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(config.Capture.SaveDir, name), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	// Звуковой сигнал терминала подтверждает, что снимок сделан; кадры
+	// периодического захвата снимаются без участия пользователя
+	if source != autoCaptureSource {
+		fmt.Fprint(os.Stderr, "\a")
+	}
+	slog.Info(msg.T("capture.saved"), "file", dest, "source", source)
+	return nil
+}
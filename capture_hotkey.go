@@ -0,0 +1,119 @@
+//go:build capture
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/kbinani/screenshot"
+	"golang.design/x/hotkey"
+	"golang.design/x/hotkey/mainthread"
+
+	"hack_interview/internal/msg"
+)
+
+func init() {
+	// На macOS регистрация горячих клавиш возможна только из главного потока
+	runMain = mainthread.Init
+}
+
+// parseHotkey разбирает сочетание вида "ctrl+shift+s"
+func parseHotkey(s string) ([]hotkey.Modifier, hotkey.Key, error) {
+	parts := strings.Split(strings.ToLower(strings.ReplaceAll(s, " ", "")), "+")
+	if len(parts) < 2 {
+		return nil, 0, fmt.Errorf("сочетание %q должно содержать модификатор и клавишу", s)
+	}
+
+	var mods []hotkey.Modifier
+	for _, p := range parts[:len(parts)-1] {
+		m, ok := hotkeyModifiers[p]
+		if !ok {
+			return nil, 0, fmt.Errorf("неизвестный модификатор %q в %q", p, s)
+		}
+		mods = append(mods, m)
+	}
+
+	key, ok := hotkeyKeys[parts[len(parts)-1]]
+	if !ok {
+		return nil, 0, fmt.Errorf("неизвестная клавиша %q в %q", parts[len(parts)-1], s)
+	}
+	return mods, key, nil
+}
+
+var hotkeyKeys = map[string]hotkey.Key{
+	"a": hotkey.KeyA, "b": hotkey.KeyB, "c": hotkey.KeyC, "d": hotkey.KeyD,
+	"e": hotkey.KeyE, "f": hotkey.KeyF, "g": hotkey.KeyG, "h": hotkey.KeyH,
+	"i": hotkey.KeyI, "j": hotkey.KeyJ, "k": hotkey.KeyK, "l": hotkey.KeyL,
+	"m": hotkey.KeyM, "n": hotkey.KeyN, "o": hotkey.KeyO, "p": hotkey.KeyP,
+	"q": hotkey.KeyQ, "r": hotkey.KeyR, "s": hotkey.KeyS, "t": hotkey.KeyT,
+	"u": hotkey.KeyU, "v": hotkey.KeyV, "w": hotkey.KeyW, "x": hotkey.KeyX,
+	"y": hotkey.KeyY, "z": hotkey.KeyZ,
+	"0": hotkey.Key0, "1": hotkey.Key1, "2": hotkey.Key2, "3": hotkey.Key3,
+	"4": hotkey.Key4, "5": hotkey.Key5, "6": hotkey.Key6, "7": hotkey.Key7,
+	"8": hotkey.Key8, "9": hotkey.Key9,
+	"f1": hotkey.KeyF1, "f2": hotkey.KeyF2, "f3": hotkey.KeyF3, "f4": hotkey.KeyF4,
+	"f5": hotkey.KeyF5, "f6": hotkey.KeyF6, "f7": hotkey.KeyF7, "f8": hotkey.KeyF8,
+	"f9": hotkey.KeyF9, "f10": hotkey.KeyF10, "f11": hotkey.KeyF11, "f12": hotkey.KeyF12,
+	"space": hotkey.KeySpace,
+}
+
+// startHotkeyCapture регистрирует глобальное сочетание клавиш и по нажатию
+// снимает выбранный монитор, окно или область во входную директорию. Сочетание
+// снимается с регистрации при отмене ctx.
+func startHotkeyCapture(ctx context.Context) error {
+	mods, key, err := parseHotkey(config.Capture.Hotkey)
+	if err != nil {
+		return err
+	}
+
+	n := screenshot.NumActiveDisplays()
+	if n == 0 {
+		return fmt.Errorf("не найдено ни одного монитора")
+	}
+	if config.Capture.Display < 0 || config.Capture.Display >= n {
+		return fmt.Errorf("монитор %d не найден, доступно: %d", config.Capture.Display, n)
+	}
+
+	hk := hotkey.New(mods, key)
+	if err := hk.Register(); err != nil {
+		return fmt.Errorf("не удалось зарегистрировать %s: %w", config.Capture.Hotkey, err)
+	}
+	slog.Info(msg.T("capture.hotkey"), "hotkey", config.Capture.Hotkey, "display", config.Capture.Display, "displays", n)
+
+	go func() {
+		defer func() {
+			if err := hk.Unregister(); err != nil {
+				slog.Warn(msg.T("capture.hotkey-unregister-failed"), "hotkey", config.Capture.Hotkey, "error", err)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hk.Keydown():
+				if err := captureDisplay(); errors.Is(err, errWindowGone) {
+					slog.Warn(msg.T("capture.skipped-no-window"), "window", config.Capture.Window)
+				} else if err != nil {
+					slog.Error(msg.T("capture.failed"), "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func captureDisplay() error {
+	bounds, err := captureTarget()
+	if err != nil {
+		return err
+	}
+	img, err := screenshot.CaptureRect(bounds)
+	if err != nil {
+		return err
+	}
+	return submitCapture(img, "capture")
+}
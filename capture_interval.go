@@ -0,0 +1,74 @@
+//go:build capture
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kbinani/screenshot"
+
+	"hack_interview/internal/msg"
+)
+
+// startIntervalCapture раз в capture.interval снимает монитор, окно или
+// область и отправляет в конвейер только заметно изменившиеся кадры
+func startIntervalCapture(ctx context.Context) error {
+	n := screenshot.NumActiveDisplays()
+	if n == 0 {
+		return fmt.Errorf("не найдено ни одного монитора")
+	}
+	if config.Capture.Display < 0 || config.Capture.Display >= n {
+		return fmt.Errorf("монитор %d не найден, доступно: %d", config.Capture.Display, n)
+	}
+	slog.Info(msg.T("capture.interval"), "interval", config.Capture.Interval,
+		"similarity", config.Capture.Similarity, "minGap", config.Capture.MinGap, "maxPerHour", config.Capture.MaxPerHour)
+
+	go func() {
+		f := newFrameFilter(config.Capture.Similarity, config.Capture.MinGap, config.Capture.MaxPerHour)
+		ticker := time.NewTicker(config.Capture.Interval)
+		defer ticker.Stop()
+		windowGone := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if pause.Paused() {
+					continue
+				}
+				bounds, err := captureTarget()
+				if errors.Is(err, errWindowGone) {
+					if !windowGone {
+						slog.Warn(msg.T("capture.interval-no-window"), "window", config.Capture.Window)
+						windowGone = true
+					}
+					continue
+				}
+				if err != nil {
+					slog.Error(msg.T("capture.failed"), "error", err)
+					continue
+				}
+				if windowGone {
+					slog.Info(msg.T("capture.window-found"), "window", config.Capture.Window)
+					windowGone = false
+				}
+				img, err := screenshot.CaptureRect(bounds)
+				if err != nil {
+					slog.Error(msg.T("capture.failed"), "error", err)
+					continue
+				}
+				if !f.Accept(imageHash(img), now) {
+					continue
+				}
+				if err := submitCapture(img, autoCaptureSource); err != nil {
+					slog.Error(msg.T("capture.save-failed"), "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
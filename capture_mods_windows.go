@@ -0,0 +1,12 @@
+//go:build capture
+
+package main
+
+import "golang.design/x/hotkey"
+
+var hotkeyModifiers = map[string]hotkey.Modifier{
+	"ctrl":  hotkey.ModCtrl,
+	"shift": hotkey.ModShift,
+	"alt":   hotkey.ModAlt,
+	"win":   hotkey.ModWin,
+}
@@ -0,0 +1,22 @@
+//go:build !capture
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+var errCaptureUnsupported = errors.New("программа собрана без поддержки захвата экрана (нужен -tags capture)")
+
+func startHotkeyCapture(ctx context.Context) error {
+	return errCaptureUnsupported
+}
+
+func startIntervalCapture(ctx context.Context) error {
+	return errCaptureUnsupported
+}
+
+func pickWindow() error {
+	return errCaptureUnsupported
+}
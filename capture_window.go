@@ -0,0 +1,84 @@
+//go:build capture
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kbinani/screenshot"
+
+	"hack_interview/internal/msg"
+)
+
+// windowInfo окно верхнего уровня и его границы в координатах экрана
+type windowInfo struct {
+	Title  string
+	Bounds image.Rectangle
+}
+
+// errWindowGone окно для захвата не найдено: закрыто, свернуто или
+// сменило заголовок
+var errWindowGone = errors.New("окно для захвата не найдено")
+
+// findWindow первое видимое окно, заголовок которого содержит substr без
+// учёта регистра
+func findWindow(substr string) (windowInfo, error) {
+	list, err := listWindows()
+	if err != nil {
+		return windowInfo{}, err
+	}
+	needle := strings.ToLower(substr)
+	for _, w := range list {
+		if strings.Contains(strings.ToLower(w.Title), needle) && !w.Bounds.Empty() {
+			return w, nil
+		}
+	}
+	return windowInfo{}, fmt.Errorf("%w: %q", errWindowGone, substr)
+}
+
+// captureTarget прямоугольник следующего снимка: окно из capture.window
+// или монитор capture.display. Область region отсчитывается от угла окна
+// или монитора. Пропавшее окно не заменяется целым экраном — снимок
+// пропускается с ошибкой errWindowGone.
+func captureTarget() (image.Rectangle, error) {
+	if config.Capture.Window == "" {
+		return captureBounds(screenshot.GetDisplayBounds(config.Capture.Display), config.Capture.Region)
+	}
+	w, err := findWindow(config.Capture.Window)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return captureBounds(w.Bounds, config.Capture.Region)
+}
+
+// pickWindow показывает список окон и спрашивает номер в терминале.
+// Выбранный заголовок целиком становится capture.window.
+func pickWindow() error {
+	list, err := listWindows()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("не найдено ни одного окна")
+	}
+	for i, w := range list {
+		fmt.Fprintf(os.Stderr, "%3d  %s  (%dx%d)\n", i+1, w.Title, w.Bounds.Dx(), w.Bounds.Dy())
+	}
+	fmt.Fprint(os.Stderr, msg.T("capture.pick-prompt"))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("выбор окна: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(list) {
+		return fmt.Errorf("нет окна с номером %q", strings.TrimSpace(line))
+	}
+	config.Capture.Window = list[n-1].Title
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build capture
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// windowsScript печатает по строке на окно: x, y, ширина, высота и
+// заголовок через табуляцию. Нужен доступ к универсальному доступу для
+// терминала.
+const windowsScript = `set out to ""
+tell application "System Events"
+	repeat with p in (processes whose visible is true)
+		repeat with w in windows of p
+			try
+				set {x, y} to position of w
+				set {ww, hh} to size of w
+				set out to out & x & tab & y & tab & ww & tab & hh & tab & (name of p) & " — " & (name of w) & linefeed
+			end try
+		end repeat
+	end repeat
+end tell
+return out`
+
+// listWindows окна видимых программ через System Events
+func listWindows() ([]windowInfo, error) {
+	out, err := exec.Command("osascript", "-e", windowsScript).Output()
+	if err != nil {
+		return nil, fmt.Errorf("список окон (проверьте доступ к универсальному доступу): %w", err)
+	}
+	var list []windowInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.SplitN(line, "\t", 5)
+		if len(f) < 5 {
+			continue
+		}
+		var n [4]int
+		ok := true
+		for i := range n {
+			if n[i], err = strconv.Atoi(strings.TrimSpace(f[i])); err != nil {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		list = append(list, windowInfo{
+			Title:  strings.TrimSpace(f[4]),
+			Bounds: image.Rect(n[0], n[1], n[0]+n[2], n[1]+n[3]),
+		})
+	}
+	return list, nil
+}
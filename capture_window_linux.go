@@ -0,0 +1,43 @@
+//go:build capture
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// listWindows окна X11 по выводу wmctrl -lG: идентификатор, рабочий стол,
+// x, y, ширина, высота, машина и заголовок. Под Wayland окна других
+// программ недоступны.
+func listWindows() ([]windowInfo, error) {
+	out, err := exec.Command("wmctrl", "-lG").Output()
+	if err != nil {
+		return nil, fmt.Errorf("список окон (нужен wmctrl и X11): %w", err)
+	}
+	var list []windowInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 8 || f[1] == "-1" {
+			continue
+		}
+		var n [4]int
+		ok := true
+		for i := range n {
+			if n[i], err = strconv.Atoi(f[2+i]); err != nil {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		list = append(list, windowInfo{
+			Title:  strings.Join(f[7:], " "),
+			Bounds: image.Rect(n[0], n[1], n[0]+n[2], n[1]+n[3]),
+		})
+	}
+	return list, nil
+}
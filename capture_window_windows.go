@@ -0,0 +1,42 @@
+//go:build capture
+
+package main
+
+import (
+	"image"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetWindowTextW = windows.NewLazySystemDLL("user32.dll").NewProc("GetWindowTextW")
+
+// listWindows видимые окна верхнего уровня с заголовком. Границы берутся
+// через DWM без невидимой рамки.
+func listWindows() ([]windowInfo, error) {
+	var list []windowInfo
+	cb := syscall.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+		if !windows.IsWindowVisible(hwnd) {
+			return 1
+		}
+		buf := make([]uint16, 512)
+		n, _, _ := procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+		if n == 0 {
+			return 1
+		}
+		var r windows.Rect
+		if err := windows.DwmGetWindowAttribute(hwnd, windows.DWMWA_EXTENDED_FRAME_BOUNDS, unsafe.Pointer(&r), uint32(unsafe.Sizeof(r))); err != nil {
+			return 1
+		}
+		list = append(list, windowInfo{
+			Title:  windows.UTF16ToString(buf[:n]),
+			Bounds: image.Rect(int(r.Left), int(r.Top), int(r.Right), int(r.Bottom)),
+		})
+		return 1
+	})
+	if err := windows.EnumWindows(cb, nil); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+var errClipboardUnavailable = errors.New("буфер обмена недоступен")
+
+// clipboardCommand подбирает внешнюю утилиту, печатающую изображение из
+// буфера обмена в stdout в формате PNG.
+func clipboardCommand() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("pngpaste"); err == nil {
+			return []string{"pngpaste", "-"}, nil
+		}
+		return nil, fmt.Errorf("%w: установите pngpaste (brew install pngpaste)", errClipboardUnavailable)
+	case "windows":
+		script := `Add-Type -AssemblyName System.Windows.Forms; Add-Type -AssemblyName System.Drawing; ` +
+			`$img = [Windows.Forms.Clipboard]::GetImage(); if ($img) { $ms = New-Object IO.MemoryStream; ` +
+			`$img.Save($ms, [Drawing.Imaging.ImageFormat]::Png); $out = [Console]::OpenStandardOutput(); ` +
+			`$out.Write($ms.ToArray(), 0, $ms.Length) }`
+		return []string{"powershell", "-NoProfile", "-STA", "-Command", script}, nil
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			if _, err := exec.LookPath("wl-paste"); err == nil {
+				return []string{"wl-paste", "--no-newline", "--type", "image/png"}, nil
+			}
+		}
+		if os.Getenv("DISPLAY") != "" {
+			if _, err := exec.LookPath("xclip"); err == nil {
+				return []string{"xclip", "-selection", "clipboard", "-t", "image/png", "-o"}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: нет графической сессии или утилит wl-paste/xclip", errClipboardUnavailable)
+	}
+}
+
+// writeClipboardText копирует текст в буфер обмена
+func writeClipboardText(data []byte) error {
+	var argv []string
+	switch runtime.GOOS {
+	case "darwin":
+		argv = []string{"pbcopy"}
+	case "windows":
+		argv = []string{"clip"}
+	default:
+		switch {
+		case os.Getenv("WAYLAND_DISPLAY") != "":
+			argv = []string{"wl-copy"}
+		case os.Getenv("DISPLAY") != "":
+			argv = []string{"xclip", "-selection", "clipboard"}
+		default:
+			return fmt.Errorf("%w: нет графической сессии", errClipboardUnavailable)
+		}
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s: %v", errClipboardUnavailable, argv[0], err)
+	}
+	return nil
+}
+
+// readClipboardImage возвращает PNG из буфера обмена или nil, если в нём
+// нет изображения
+func readClipboardImage(ctx context.Context, argv []string) []byte {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).Output()
+	if err != nil || !bytes.HasPrefix(out, pngMagic) {
+		return nil
+	}
+	return out
+}
+
+// watchClipboard опрашивает буфер обмена и сохраняет новые изображения во
+// входную директорию, откуда их забирает обычный наблюдатель. Имя файла
+// строится по хешу содержимого, поэтому одно и то же изображение не будет
+// обработано дважды, в том числе после перезапуска.
+func watchClipboard(ctx context.Context) {
+	argv, err := clipboardCommand()
+	if err != nil {
+		slog.Warn(msg.T("clipboard.disabled"), "error", err)
+		return
+	}
+	slog.Info(msg.T("clipboard.watching"), "tool", argv[0], "interval", config.ClipboardInterval)
+
+	var last string
+	ticker := time.NewTicker(config.ClipboardInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		img := readClipboardImage(ctx, argv)
+		if img == nil {
+			continue
+		}
+		sum := sha256.Sum256(img)
+		hash := hex.EncodeToString(sum[:])[:16]
+		if hash == last {
+			continue
+		}
+		last = hash
+
+		if err := saveClipboardImage(hash, img); err != nil {
+			slog.Error(msg.T("clipboard.save-failed"), "error", err)
+		}
+	}
+}
+
+func saveClipboardImage(hash string, img []byte) error {
+	name := "clipboard-" + hash + ".png"
+	if _, err := os.Stat(filepath.Join(config.InputDir, name)); err == nil {
+		return nil
+	}
+	if _, seen := state.Get(name); seen {
+		return nil
+	}
+
+	dest, err := writeInputFile(name, img)
+	if err != nil {
+		return err
+	}
+	slog.Info(msg.T("clipboard.image"), "file", dest)
+	return nil
+}
@@ -0,0 +1,199 @@
+// Команда jobsctl пример клиента gRPC-сервиса заданий.
+//
+//	jobsctl -addr 127.0.0.1:9090 -token T submit -watch screen.png
+//	jobsctl -addr 127.0.0.1:9090 -token T get <id>
+//	jobsctl -addr 127.0.0.1:9090 -token T watch <id>
+//	jobsctl -addr 127.0.0.1:9090 -token T list -status done,failed
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"hack_interview/internal/jobspb"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9090", "адрес gRPC-сервиса")
+	token := flag.String("token", os.Getenv("HACK_INTERVIEW_GRPC_TOKEN"), "токен (по умолчанию HACK_INTERVIEW_GRPC_TOKEN)")
+	ca := flag.String("ca", "", "сертификат CA для проверки сервера")
+	plain := flag.Bool("insecure", false, "подключаться без TLS")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Использование: jobsctl [флаги] submit [-watch] <файл> | get <id> | watch <id> | list [-status s] [-session s] [-limit n]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	creds, err := transportCredentials(*ca, *plain)
+	if err != nil {
+		fail(err)
+	}
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		fail(err)
+	}
+	defer conn.Close()
+	client := jobspb.NewJobsClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+*token)
+
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "submit":
+		err = submit(ctx, client, args)
+	case "get":
+		err = get(ctx, client, args)
+	case "watch":
+		if len(args) != 1 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		err = watch(ctx, client, args[0])
+	case "list":
+		err = list(ctx, client, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func transportCredentials(ca string, plain bool) (credentials.TransportCredentials, error) {
+	if plain {
+		return insecure.NewCredentials(), nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca != "" {
+		pem, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: сертификаты не найдены", ca)
+		}
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+func submit(ctx context.Context, client jobspb.JobsClient, args []string) error {
+	fset := flag.NewFlagSet("submit", flag.ExitOnError)
+	follow := fset.Bool("watch", false, "дождаться ответа")
+	fset.Parse(args)
+	if fset.NArg() != 1 {
+		return errors.New("укажите файл")
+	}
+	data, err := os.ReadFile(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	resp, err := client.SubmitImage(ctx, &jobspb.SubmitImageRequest{Image: data})
+	if err != nil {
+		return err
+	}
+	fmt.Println(resp.GetId())
+	if *follow {
+		return watch(ctx, client, resp.GetId())
+	}
+	return nil
+}
+
+func get(ctx context.Context, client jobspb.JobsClient, args []string) error {
+	if len(args) != 1 {
+		return errors.New("укажите идентификатор задания")
+	}
+	job, err := client.GetJob(ctx, &jobspb.GetJobRequest{Id: args[0]})
+	if err != nil {
+		return err
+	}
+	printJob(job)
+	return nil
+}
+
+func watch(ctx context.Context, client jobspb.JobsClient, id string) error {
+	stream, err := client.WatchJob(ctx, &jobspb.WatchJobRequest{Id: id})
+	if err != nil {
+		return err
+	}
+	var last *jobspb.Job
+	for {
+		job, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, time.Now().Format("15:04:05"), job.GetStatus())
+		last = job
+	}
+	if last != nil {
+		printJob(last)
+	}
+	return nil
+}
+
+func list(ctx context.Context, client jobspb.JobsClient, args []string) error {
+	fset := flag.NewFlagSet("list", flag.ExitOnError)
+	statuses := fset.String("status", "", "статусы через запятую")
+	session := fset.String("session", "", "идентификатор сессии")
+	limit := fset.Int("limit", 0, "размер страницы")
+	offset := fset.Int("offset", 0, "смещение")
+	fset.Parse(args)
+
+	req := &jobspb.ListJobsRequest{Session: *session, Limit: int32(*limit), Offset: int32(*offset)}
+	for _, s := range strings.Split(*statuses, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			req.Status = append(req.Status, s)
+		}
+	}
+	resp, err := client.ListJobs(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, job := range resp.GetJobs() {
+		updated := "-"
+		if ms := job.GetUpdatedAtUnixMs(); ms > 0 {
+			updated = time.UnixMilli(ms).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", job.GetName(), job.GetStatus(), updated, job.GetLastError())
+	}
+	fmt.Fprintf(os.Stderr, "всего: %d\n", resp.GetTotal())
+	return nil
+}
+
+func printJob(job *jobspb.Job) {
+	fmt.Printf("Задание %s (%s), статус %s, попыток %d\n", job.GetId(), job.GetName(), job.GetStatus(), job.GetAttempts())
+	if job.GetLastError() != "" {
+		fmt.Println("Ошибка:", job.GetLastError())
+	}
+	if job.GetQuestion() != "" {
+		fmt.Printf("\n%s\n", job.GetQuestion())
+	}
+	if job.GetAnswer() != "" {
+		fmt.Printf("\n%s\n", job.GetAnswer())
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "jobsctl:", err)
+	os.Exit(1)
+}
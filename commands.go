@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// runCommand выполняет подкоманду и возвращает код выхода
+func runCommand(name string, args []string) int {
+	switch name {
+	case "run":
+		return runOnce()
+	case "process":
+		return runProcess(args)
+	case "reprocess":
+		return runReprocess(args)
+	case "status":
+		return runStatus(args)
+	case "list":
+		return runList(args)
+	case "stats":
+		return runStats(args)
+	case "reset":
+		return runReset(args)
+	case "cleanup":
+		return runCleanup(args)
+	case "translate":
+		return runTranslate(args)
+	case "export":
+		return runExport(args)
+	case "archive":
+		return runArchive(args)
+	case "new-session":
+		return runNewSession()
+	case "doctor":
+		return runDoctor(args)
+	case "config":
+		return runConfigCommand(args)
+	case "install-service":
+		return runInstallService(args)
+	case "uninstall-service":
+		return runUninstallService(args)
+	case "update":
+		return runUpdate(args)
+	case "version":
+		fmt.Println(version)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, msg.T("command.unknown"), name)
+		fmt.Fprintln(os.Stderr, msg.T("usage"))
+		return 2
+	}
+}
+
+// runStatus опрашивает запущенный экземпляр через сокет состояния
+func runStatus(args []string) int {
+	fset := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := fset.Bool("json", false, "вывести ответ в JSON")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	loadConfig()
+	report, err := queryStatus(config.StatusSocket)
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return 0
+	}
+	mode := msg.T("status.running")
+	if report.Paused {
+		mode = msg.T("status.paused")
+	}
+	fmt.Printf("PID:          %d (%s)\n", report.PID, mode)
+	fmt.Printf(msg.T("status.uptime"), report.Uptime)
+	fmt.Printf(msg.T("status.queue"), report.QueueDepth, report.QueueCap)
+	if report.QueueDeferred > 0 {
+		fmt.Printf(msg.T("status.queue-deferred"), report.QueueDeferred)
+	}
+	fmt.Printf(msg.T("status.processed"), report.Processed, report.Failed, report.Skipped)
+	if report.LastError != "" {
+		fmt.Printf(msg.T("status.last-error"), report.LastError)
+	}
+	for _, b := range report.Breakers {
+		switch {
+		case b.RetryAt != nil:
+			fmt.Printf(msg.T("status.circuit-retry"), b.Provider, b.State, b.RetryAt.Local().Format("15:04:05"))
+		case b.State != "closed":
+			fmt.Printf(msg.T("status.circuit"), b.Provider, b.State)
+		}
+	}
+	return 0
+}
+
+// runNewSession начинает новую сессию в запущенном экземпляре
+func runNewSession() int {
+	loadConfig()
+	id, err := requestNewSession(config.StatusSocket)
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+	fmt.Println(msg.T("session.started"), id)
+	return 0
+}
+
+// statusPending файл ждёт во входной директории и ещё не попал в состояние
+const statusPending = "pending"
+
+// listEntry строка вывода команды list
+type listEntry struct {
+	Name string `json:"name"`
+	FileState
+}
+
+// runList печатает записи состояния и ещё не обработанные файлы входной
+// директории. Состояние читается под той же блокировкой, что использует
+// reprocess, поэтому команда безопасна и при запущенном наблюдателе.
+func runList(args []string) int {
+	fset := flag.NewFlagSet("list", flag.ContinueOnError)
+	failedOnly := fset.Bool("failed", false, "только файлы с исчерпанными попытками (то же, что --status failed)")
+	statusFlag := fset.String("status", "", "только файлы с указанными статусами через запятую")
+	today := fset.Bool("today", false, "только файлы, обновлённые сегодня")
+	asJSON := fset.Bool("json", false, "вывести записи в JSON")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	statuses := make(map[string]bool)
+	for _, s := range strings.Split(*statusFlag, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			statuses[s] = true
+		}
+	}
+	if *failedOnly {
+		statuses[statusFailed] = true
+	}
+
+	loadConfig()
+	prepareDirs()
+
+	entries := listEntries()
+	y, m, d := time.Now().Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	shown := entries[:0]
+	for _, e := range entries {
+		if len(statuses) > 0 && !statuses[e.Status] || *today && e.UpdatedAt.Before(midnight) {
+			continue
+		}
+		shown = append(shown, e)
+	}
+	sort.Slice(shown, func(i, j int) bool { return shown[i].Name < shown[j].Name })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(shown); err != nil {
+			slog.Error(msg.T("output.failed"), "error", err)
+			return 1
+		}
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, msg.T("list.header"))
+	for _, e := range shown {
+		next := "-"
+		if e.Status == statusRetry {
+			next = e.NextRetry.Local().Format("01-02 15:04:05")
+		}
+		updated := "-"
+		if !e.UpdatedAt.IsZero() {
+			updated = e.UpdatedAt.Local().Format("01-02 15:04:05")
+		}
+		output := e.Output
+		if output == "" {
+			output = "-"
+		}
+		lastError := strings.Join(strings.Fields(e.LastError), " ")
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", e.Name, e.Status, e.Attempts, updated, next, output, truncateRunes(lastError, 60))
+	}
+	w.Flush()
+	return 0
+}
+
+// listEntries записи состояния и ждущие во входной директории файлы
+func listEntries() []listEntry {
+	files := state.Snapshot()
+	entries := make([]listEntry, 0, len(files))
+	for name, fs := range files {
+		entries = append(entries, listEntry{Name: name, FileState: fs})
+	}
+	if inputs, err := os.ReadDir(config.InputDir); err == nil {
+		for _, e := range inputs {
+			if _, tracked := files[e.Name()]; tracked || e.IsDir() || !acceptFile(e.Name()) {
+				continue
+			}
+			fs := FileState{Status: statusPending}
+			if fi, err := e.Info(); err == nil {
+				fs.UpdatedAt = fi.ModTime()
+			}
+			entries = append(entries, listEntry{Name: e.Name(), FileState: fs})
+		}
+	}
+	return entries
+}
+
+// runReset удаляет записи о файлах из состояния; запущенный наблюдатель
+// подхватит их при следующем сканировании.
+func runReset(args []string) int {
+	fset := flag.NewFlagSet("reset", flag.ContinueOnError)
+	failed := fset.Bool("failed", false, "сбросить все файлы с исчерпанными попытками")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	names := fset.Args()
+	if len(names) == 0 && !*failed {
+		fmt.Fprintln(os.Stderr, msg.T("reset.usage"))
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+
+	if *failed {
+		for name, fs := range state.Snapshot() {
+			if fs.Status == statusFailed {
+				names = append(names, name)
+			}
+		}
+	}
+	if err := state.Reset(names); err != nil {
+		slog.Error(msg.T("state.save-failed"), "error", err)
+		return 1
+	}
+	fmt.Printf(msg.T("reset.done"), len(names))
+	return 0
+}
+
+// runProcess обрабатывает указанные файлы через processFile и печатает пути
+// результатов. Состояние не меняется, если не указан --record.
+func runProcess(args []string) int {
+	fset := flag.NewFlagSet("process", flag.ContinueOnError)
+	dryRun := fset.Bool("dry-run", false, "только показать, что было бы сделано")
+	output := fset.String("output", "", "директория для результатов")
+	record := fset.Bool("record", false, "записать итог в состояние")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	if fset.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, msg.T("process.usage"))
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+	if *output != "" && !*dryRun {
+		if err := os.MkdirAll(*output, os.ModePerm); err != nil {
+			slog.Error(err.Error())
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if fset.NArg() == 1 && fset.Arg(0) == "-" {
+		return processStdin(ctx, apppipeline.Request{Prompt: config.PROMPT, OutputDir: *output, DryRun: *dryRun})
+	}
+
+	targets, err := expandTargets(fset.Args())
+	if err != nil {
+		slog.Error(err.Error())
+		return 2
+	}
+	if !*dryRun {
+		startPublishing()
+		defer publishing.Close()
+	}
+
+	var outputs []string
+	failed := 0
+	for _, t := range targets {
+		tm := newStageTimings()
+		out, err := processFile(ctx, apppipeline.Request{Path: t.Path, Prompt: config.PROMPT, OutputDir: *output, DryRun: *dryRun, Timings: tm})
+		switch {
+		case *record && t.Tracked && !*dryRun:
+			recordResult(Job{Name: t.Name, Path: t.Path, Timings: tm}, out, err)
+		case err != nil:
+			slog.Error(err.Error())
+		}
+		if err != nil {
+			failed++
+		} else {
+			outputs = append(outputs, out)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	for _, out := range outputs {
+		fmt.Println(out)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runReprocess принудительно обрабатывает указанные файлы заново: сбрасывает
+// их записи в состоянии и сохраняет результат под новым именем (-v2, -v3...),
+// не трогая прежний. Работает и при запущенном наблюдателе — состояние
+// меняется под блокировкой.
+func runReprocess(args []string) int {
+	fset := flag.NewFlagSet("reprocess", flag.ContinueOnError)
+	style := fset.String("style", "", "стиль ответа вместо заданного в конфигурации")
+	force := fset.Bool("force", false, "отправить модели даже слишком короткий текст")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	if fset.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, msg.T("reprocess.usage"))
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+	if _, ok := config.StyleByName(*style); !ok {
+		slog.Error(msg.T("style.unknown"), "style", *style)
+		return 2
+	}
+
+	targets, err := expandTargets(fset.Args())
+	if err != nil {
+		slog.Error(err.Error())
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startPublishing()
+	defer publishing.Close()
+
+	failed := 0
+	for _, t := range targets {
+		if t.Tracked {
+			if err := state.Set(t.Name, FileState{Status: statusProcessing, Owner: ownInstance()}); err != nil {
+				slog.Error(msg.T("state.save-failed"), "error", err)
+			}
+		}
+
+		tm := newStageTimings()
+		out, err := processFile(ctx, apppipeline.Request{
+			Path:      t.Path,
+			Prompt:    config.PROMPT,
+			Versioned: true,
+			Style:     *style,
+			ReuseOCR:  config.ReprocessReuseOCR,
+			Force:     *force,
+			Timings:   tm,
+		})
+		if err != nil {
+			failed++
+		}
+		if t.Tracked {
+			recordResult(Job{Name: t.Name, Path: t.Path, Timings: tm}, out, err)
+		} else if err != nil {
+			slog.Error(err.Error())
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// target файл, выбранный для повторной обработки
+type target struct {
+	Path    string
+	Name    string // ключ в состоянии
+	Tracked bool
+}
+
+// expandTargets раскрывает пути и шаблоны. Имя без директории, которого нет
+// в текущей директории, ищется во входной директории, а затем среди файлов,
+// перенесённых в errorsDir.
+func expandTargets(args []string) ([]target, error) {
+	var targets []target
+	for _, arg := range args {
+		matches, err := doublestar.FilepathGlob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный шаблон %q: %w", arg, err)
+		}
+		if len(matches) == 0 && !strings.ContainsRune(arg, filepath.Separator) {
+			matches, _ = doublestar.FilepathGlob(filepath.Join(config.InputDir, arg))
+		}
+		for _, m := range matches {
+			name, tracked := stateName(m)
+			targets = append(targets, target{Path: m, Name: name, Tracked: tracked})
+		}
+		if len(matches) == 0 {
+			moved := movedTargets(arg)
+			if len(moved) == 0 {
+				return nil, fmt.Errorf("файлы не найдены: %s", arg)
+			}
+			targets = append(targets, moved...)
+		}
+	}
+	return targets, nil
+}
+
+// movedTargets ищет в состоянии файлы, перенесённые в errorsDir, чьё
+// исходное имя подходит под шаблон
+func movedTargets(pattern string) []target {
+	var targets []target
+	for name, fs := range state.Snapshot() {
+		if fs.MovedTo == "" {
+			continue
+		}
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			targets = append(targets, target{Path: fs.MovedTo, Name: name, Tracked: true})
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets
+}
+
+// stateName возвращает ключ файла в состоянии, если файл лежит во входной
+// директории
+func stateName(path string) (string, bool) {
+	absIn, err1 := filepath.Abs(config.InputDir)
+	absPath, err2 := filepath.Abs(path)
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absIn, absPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
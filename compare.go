@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// compareMarker метка в имени файла, включающая сравнение моделей:
+// q17+compare.png
+const compareMarker = "compare"
+
+// defaultDisagreementsPrompt промпт раздела «## Расхождения»
+const defaultDisagreementsPrompt = "Ниже ответы разных моделей на один вопрос с собеседования. " +
+	"Кратко, списком в Markdown, перечисли, в чём они расходятся по существу: решение, " +
+	"сложность, факты, крайние случаи. Укажи, какой вариант вероятнее верен и почему. " +
+	"Если существенных расхождений нет, ответь одной строкой «Существенных расхождений нет.»"
+
+// compareModel модель, участвующая в сравнении
+type compareModel struct {
+	Name string
+	LLM  llm.Answerer
+}
+
+// compareUsageKey ключ контекста, в который OnUsage модели сравнения пишет
+// расход токенов её запроса
+type compareUsageKey struct{}
+
+type compareUsage struct {
+	in, out int
+}
+
+func compareUsageFrom(ctx context.Context) *compareUsage {
+	u, _ := ctx.Value(compareUsageKey{}).(*compareUsage)
+	return u
+}
+
+// compareEnabled сообщает, что файл обрабатывается в режиме сравнения
+func compareEnabled(path string) bool {
+	if len(config.Compare.Models) == 0 {
+		return false
+	}
+	if _, marker := splitPromptMarker(output.Name(path)); marker == compareMarker {
+		return true
+	}
+	rel, ok := stateName(path)
+	if !ok {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range config.Compare.Dirs {
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compareResult ответ одной модели сравнения
+type compareResult struct {
+	Name    string
+	Answer  string
+	Err     error
+	Latency time.Duration
+	Usage   compareUsage
+}
+
+// compareAnswers параллельно задаёт вопрос всем моделям сравнения и
+// собирает ответы в один Markdown. Ошибка возвращается, только если не
+// ответила ни одна модель.
+func (p *Pipeline) compareAnswers(ctx context.Context, logger *slog.Logger, req llm.Request, question string) (string, error) {
+	results := make([]compareResult, len(p.Compare))
+	var wg sync.WaitGroup
+	for i, m := range p.Compare {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			usage := &compareUsage{}
+			start := time.Now()
+			answer, err := m.LLM.Answer(context.WithValue(ctx, compareUsageKey{}, usage), req)
+			providers.Record(err)
+			results[i] = compareResult{Name: m.Name, Answer: answer, Err: err, Latency: time.Since(start), Usage: *usage}
+		}()
+	}
+	wg.Wait()
+
+	var sections, answered []string
+	var failures []error
+	for _, r := range results {
+		meta := fmt.Sprintf("> %v · токенов: запрос %d, ответ %d", r.Latency.Round(time.Millisecond), r.Usage.in, r.Usage.out)
+		if r.Err != nil {
+			logger.Warn(msg.T("compare.model-failed"), "model", r.Name, "error", r.Err)
+			failures = append(failures, fmt.Errorf("%s: %w", r.Name, r.Err))
+			// Ошибка попадает в результат, а в адресе запроса может быть ключ
+			sections = append(sections, fmt.Sprintf("## %s\n\n%s\n\n> Ошибка: %s", r.Name, meta, redactSecrets(r.Err.Error())))
+			continue
+		}
+		logger.Info(msg.T("compare.model-answered"), "model", r.Name, "latency", r.Latency.Round(time.Millisecond))
+		answer := strings.TrimSpace(demoteHeadings(r.Answer))
+		sections = append(sections, fmt.Sprintf("## %s\n\n%s\n\n%s", r.Name, meta, answer))
+		answered = append(answered, fmt.Sprintf("Ответ %s:\n%s", r.Name, r.Answer))
+	}
+	if len(answered) == 0 {
+		return "", errors.Join(failures...)
+	}
+
+	if config.Compare.Disagreements && len(answered) > 1 {
+		prompt := defaultDisagreementsPrompt + "\n\nВопрос:\n" + question + "\n\n" + strings.Join(answered, "\n\n")
+		note, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+		providers.Record(err)
+		if err != nil {
+			logger.Warn(msg.T("compare.disagreements-failed"), "error", err)
+		} else {
+			sections = append(sections, "## Расхождения\n\n"+strings.TrimSpace(demoteHeadings(note)))
+		}
+	}
+	return strings.Join(sections, "\n\n") + "\n", nil
+}
+
+// demoteHeadings опускает заголовки ответа на два уровня, чтобы они не
+// спорили с заголовками моделей; блоки кода не трогаются
+func demoteHeadings(answer string) string {
+	lines := strings.Split(answer, "\n")
+	in := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			in = !in
+		case !in && strings.HasPrefix(line, "#"):
+			lines[i] = "##" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
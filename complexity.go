@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// Режимы раздела о сложности
+const (
+	complexityAuto   = "auto"
+	complexityAlways = "always"
+	complexityOff    = "off"
+)
+
+// complexityTail сколько последних символов ответа просматривается в
+// поисках оценки сложности: модель обычно пишет её в конце
+const complexityTail = 1500
+
+var bigORe = regexp.MustCompile(`O\([^)]*\)`)
+
+// hasComplexity сообщает, обсуждается ли в конце ответа сложность: хотя бы
+// две оценки O(...) — отдельно времени и памяти
+func hasComplexity(answer string) bool {
+	tail := answer
+	if len(tail) > complexityTail {
+		tail = tail[len(tail)-complexityTail:]
+	}
+	return len(bigORe.FindAllString(tail, -1)) >= 2
+}
+
+// withComplexity дополняет ответ с кодом разделом «## Complexity». Модели
+// отправляется только код из ответа, а не исходный вопрос, поэтому решение
+// не переписывается заново. Ошибка запроса оставляет ответ как есть.
+func (p *Pipeline) withComplexity(ctx context.Context, logger *slog.Logger, answer string) string {
+	mode := config.Complexity
+	if mode == complexityOff {
+		return answer
+	}
+	var code []string
+	for _, b := range output.CodeBlocks(answer) {
+		if !strings.EqualFold(b.Lang, "sql") {
+			code = append(code, "```"+b.Lang+"\n"+b.Code+"\n```")
+		}
+	}
+	if len(code) == 0 {
+		return answer
+	}
+	if mode == complexityAuto && hasComplexity(answer) {
+		return answer
+	}
+
+	prompt := "Оцени сложность решения ниже по времени и по памяти в нотации O(...) " +
+		"с одним-двумя предложениями обоснования. Не переписывай и не объясняй решение.\n\n" +
+		strings.Join(code, "\n\n")
+	resp, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt, MaxOutputTokens: 300})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("complexity.failed"), "error", err)
+		return answer
+	}
+	return strings.TrimRight(answer, "\n") + "\n\n## Complexity\n\n" + strings.TrimSpace(resp) + "\n"
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+)
+
+// Типы конфигурации определены в internal/config; псевдонимы оставляют
+// привычные имена в остальном коде
+type (
+	Config        = appconfig.Config
+	CaptureConfig = appconfig.CaptureConfig
+	CaptureRect   = appconfig.CaptureRect
+	ServerConfig  = appconfig.ServerConfig
+	S3Config      = appconfig.S3Config
+	IMAPConfig    = appconfig.IMAPConfig
+)
+
+var config Config
+
+// flagCassette режим кассеты из --cassette, важнее cassette.mode
+var flagCassette string
+
+// configPath файл конфигурации относительно рабочей директории
+var configPath = "config.yml"
+
+// useConfigFile переходит в директорию файла из --config, чтобы
+// относительные пути в нём (inputDir, outputDir, .env) отсчитывались от
+// неё, как при запуске рядом с config.yml. envFile из командной строки
+// остаётся относительно исходной директории.
+func useConfigFile(path, envFile string) string {
+	if path == "" {
+		return envFile
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		fatal(msg.T("config.path-failed"), "error", err)
+	}
+	if envFile != "" {
+		if envFile, err = filepath.Abs(envFile); err != nil {
+			fatal(msg.T("envfile.path-failed"), "error", err)
+		}
+	}
+	if err := os.Chdir(filepath.Dir(abs)); err != nil {
+		fatal(msg.T("config.chdir-failed"), "error", err)
+	}
+	configPath = filepath.Base(abs)
+	return envFile
+}
+
+// loadEnvFiles задаёт переменные окружения из --env-file и .env рядом с
+// config.yml до его разбора: уже заданные переменные не меняются, поэтому
+// окружение важнее --env-file, а он — .env
+func loadEnvFiles(envFile string) {
+	if envFile != "" {
+		if err := appconfig.LoadEnvFile(envFile, false); err != nil {
+			fatal(msg.T("envfile.failed"), "error", err)
+		}
+	}
+	if err := appconfig.LoadEnvFile(".env", true); err != nil {
+		fatal(msg.T("envfile.failed"), "error", err)
+	}
+}
+
+// loadConfig загружает config.yml и настраивает логирование; при ошибке
+// завершает процесс
+func loadConfig() {
+	c, err := appconfig.Load(configPath)
+	if err != nil {
+		fatal(msg.T("config.failed"), "error", err)
+	}
+	config = c
+	msg.SetLanguage(config.Language)
+	if err := setupLogging(); err != nil {
+		fatal(msg.T("logging.failed"), "error", err)
+	}
+	if flagCassette != "" {
+		config.Cassette.Mode = flagCassette
+		if err := config.Validate(); err != nil {
+			fatal(msg.T("config.failed"), "error", err)
+		}
+	}
+	debugHTTP.Store(flagDebugHTTP || config.DebugHTTP)
+	p, err := newPipeline(config)
+	if err != nil {
+		fatal(msg.T("http.setup-failed"), "error", err)
+	}
+	pipeline = p
+	if config.RunExamples {
+		slog.Warn(msg.T("examples.enabled"))
+	}
+	if config.Cassette.Mode != "" {
+		slog.Info(msg.T("cassette.enabled"), "mode", config.Cassette.Mode, "dir", config.Cassette.Dir)
+	}
+}
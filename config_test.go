@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// --config переходит в директорию файла: .env берётся рядом с ним,
+// --env-file — относительно исходной директории и важнее .env, а
+// настоящее окружение важнее обоих
+func TestLoadEnvFilesNextToConfig(t *testing.T) {
+	for _, name := range []string{"HI_TEST_ENV", "HI_TEST_FILE", "HI_TEST_DOTENV"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("HI_TEST_ENV", "env")
+	wd, _ := os.Getwd()
+	oldPath := configPath
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		configPath = oldPath
+	})
+
+	root := t.TempDir()
+	confDir := filepath.Join(root, "conf")
+	os.MkdirAll(confDir, 0755)
+	os.WriteFile(filepath.Join(confDir, "interview.yml"), nil, 0644)
+	os.WriteFile(filepath.Join(confDir, ".env"), []byte("HI_TEST_ENV=dotenv\nHI_TEST_FILE=dotenv\nHI_TEST_DOTENV=dotenv\n"), 0644)
+	os.WriteFile(filepath.Join(root, "extra.env"), []byte("HI_TEST_ENV=extra\nHI_TEST_FILE=extra\n"), 0644)
+	// .env в исходной директории не читается
+	os.WriteFile(filepath.Join(root, ".env"), []byte("HI_TEST_DOTENV=wrong\n"), 0644)
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+
+	loadEnvFiles(useConfigFile(filepath.Join("conf", "interview.yml"), "extra.env"))
+	if configPath != "interview.yml" {
+		t.Errorf("configPath = %q", configPath)
+	}
+	for name, want := range map[string]string{"HI_TEST_ENV": "env", "HI_TEST_FILE": "extra", "HI_TEST_DOTENV": "dotenv"} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// writePIDFile записывает PID текущего процесса. Файл от упавшего запуска
+// перезаписывается, а при живом процессе запуск отменяется.
+func writePIDFile(path string) error {
+	if data, err := os.ReadFile(path); err == nil {
+		pid, perr := strconv.Atoi(strings.TrimSpace(string(data)))
+		if perr == nil && pid != os.Getpid() && processAlive(pid) {
+			return fmt.Errorf("уже запущен экземпляр с PID %d (%s)", pid, path)
+		}
+		slog.Warn(msg.T("pidfile.stale"), "path", path)
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// removePIDFile удаляет PID-файл, если он принадлежит текущему процессу
+func removePIDFile(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if strings.TrimSpace(string(data)) == strconv.Itoa(os.Getpid()) {
+		os.Remove(path)
+	}
+}
+
+// StatusReport ответ запущенного экземпляра на запрос status
+type StatusReport struct {
+	PID        int     `json:"pid"`
+	Uptime     string  `json:"uptime"`
+	UptimeSec  float64 `json:"uptimeSeconds"`
+	QueueDepth int     `json:"queueDepth"`
+	QueueCap   int     `json:"queueCapacity"`
+	// QueueDeferred файлы, не поместившиеся в очередь при последнем
+	// сканировании
+	QueueDeferred int    `json:"queueDeferred,omitempty"`
+	Paused        bool   `json:"paused"`
+	Processed     int    `json:"processed"`
+	Failed        int    `json:"failed"`
+	Skipped       int    `json:"skipped"`
+	LastError     string `json:"lastError,omitempty"`
+	// Breakers цепи провайдеров
+	Breakers []breakerStatus `json:"breakers,omitempty"`
+}
+
+// statusRequest запрос по сокету состояния: одна строка JSON
+type statusRequest struct {
+	Cmd string `json:"cmd"`
+}
+
+func collectStatus(q *Queue) StatusReport {
+	totals.mu.Lock()
+	defer totals.mu.Unlock()
+	uptime := time.Since(totals.Started)
+	return StatusReport{
+		PID:           os.Getpid(),
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSec:     uptime.Seconds(),
+		QueueDepth:    q.Len(),
+		QueueCap:      q.Cap(),
+		QueueDeferred: q.Deferred(),
+		Paused:        pause.Paused(),
+		Processed:     totals.Processed,
+		Failed:        totals.Failed,
+		Skipped:       totals.Skipped,
+		LastError:     totals.LastError,
+		Breakers:      collectBreakers(),
+	}
+}
+
+// startStatusServer отвечает на запросы status через unix-сокет
+func startStatusServer(ctx context.Context, q *Queue) error {
+	path := config.StatusSocket
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("сокет %s уже используется другим экземпляром", path)
+	}
+	os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+		os.Remove(path)
+	}()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				slog.Error(msg.T("status.socket-failed"), "error", err)
+				continue
+			}
+			go serveStatus(conn, q)
+		}
+	}()
+	return nil
+}
+
+func serveStatus(conn net.Conn, q *Queue) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var req statusRequest
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err == nil {
+		err = json.Unmarshal(line, &req)
+	}
+	enc := json.NewEncoder(conn)
+	switch {
+	case err != nil:
+	case req.Cmd == "status":
+		enc.Encode(collectStatus(q))
+		return
+	case req.Cmd == "new-session":
+		if transcripts == nil {
+			enc.Encode(map[string]string{"error": "стенограмма сессий выключена"})
+		} else {
+			enc.Encode(map[string]string{"session": transcripts.NewSession()})
+		}
+		return
+	}
+	enc.Encode(map[string]string{"error": "ожидается {\"cmd\":\"status\"} или {\"cmd\":\"new-session\"}"})
+}
+
+// queryStatus запрашивает состояние у запущенного экземпляра
+func queryStatus(path string) (StatusReport, error) {
+	var report StatusReport
+	err := querySocket(path, "status", &report)
+	return report, err
+}
+
+// requestNewSession просит запущенный экземпляр начать новую сессию и
+// возвращает её идентификатор
+func requestNewSession(path string) (string, error) {
+	var resp struct {
+		Session string `json:"session"`
+		Error   string `json:"error"`
+	}
+	if err := querySocket(path, "new-session", &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Session, nil
+}
+
+func querySocket(path, cmd string, resp any) error {
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return fmt.Errorf("экземпляр не запущен или недоступен (%s): %w", path, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(statusRequest{Cmd: cmd}); err != nil {
+		return err
+	}
+	return json.NewDecoder(conn).Decode(resp)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+)
+
+// Дампы запросов к провайдерам (--debug-http или debugHTTP в config.yml).
+// Каждый запрос и ответ пишется в OutputDir/.debug/ в файл
+// <изображение>-a<попытка>-<провайдер>-<время>.txt. Ключи API и заголовки
+// авторизации заменяются на [REDACTED] до записи на диск.
+
+// debugHTTPMaxBody сколько байт тела запроса и ответа попадает в дамп
+const debugHTTPMaxBody = 64 << 10
+
+const redacted = "[REDACTED]"
+
+var (
+	flagDebugHTTP bool
+	debugHTTP     atomic.Bool
+)
+
+// sensitiveHeaders заголовки, значения которых не попадают в дамп
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Apikey", "X-Goog-Api-Key"}
+
+type dumpKey struct{}
+
+type dumpInfo struct {
+	Name    string
+	Attempt int
+}
+
+// withDumpInfo привязывает к контексту имя изображения и номер попытки,
+// чтобы дампы запросов можно было сопоставить с файлом
+func withDumpInfo(ctx context.Context, name string, attempt int) context.Context {
+	return context.WithValue(ctx, dumpKey{}, dumpInfo{Name: name, Attempt: attempt})
+}
+
+func dumpInfoFrom(ctx context.Context) (dumpInfo, bool) {
+	info, ok := ctx.Value(dumpKey{}).(dumpInfo)
+	return info, ok
+}
+
+// attachHTTPDump добавляет клиенту провайдера запись дампов, пока включён
+// debugHTTP, и сводки запросов в лог при -vv
+func attachHTTPDump(c *resty.Client, provider string) {
+	c.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		if traceHTTP {
+			logHTTPTrace(provider, resp.Request, resp, nil)
+		}
+		if debugHTTP.Load() {
+			writeHTTPDump(provider, resp.Request, resp, nil)
+		}
+		return nil
+	})
+	c.OnError(func(req *resty.Request, err error) {
+		if traceHTTP {
+			logHTTPTrace(provider, req, nil, err)
+		}
+		if debugHTTP.Load() {
+			writeHTTPDump(provider, req, nil, err)
+		}
+	})
+}
+
+// logHTTPTrace одна строка debug на запрос: без тел и заголовков, ключи в
+// адресе скрыты
+func logHTTPTrace(provider string, req *resty.Request, resp *resty.Response, reqErr error) {
+	args := []any{"provider", provider, "method", req.Method, "url", redactURL(req.URL), "attempt", req.Attempt}
+	if info, ok := dumpInfoFrom(req.Context()); ok {
+		args = append(args, "file", info.Name)
+	}
+	if reqErr != nil {
+		args = append(args, "error", redactSecrets(reqErr.Error()))
+	} else {
+		args = append(args, "status", resp.StatusCode(), "duration", resp.Time().Round(time.Millisecond), "bytes", len(resp.Body()))
+	}
+	slog.Debug(msg.T("http.trace"), args...)
+}
+
+func writeHTTPDump(provider string, req *resty.Request, resp *resty.Response, reqErr error) {
+	info, ok := dumpInfoFrom(req.Context())
+	if !ok {
+		info = dumpInfo{Name: "unknown", Attempt: 1}
+	}
+	dir := filepath.Join(config.OutputDir, ".debug")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		slog.Warn(msg.T("debughttp.write-failed"), "error", err)
+		return
+	}
+	name := fmt.Sprintf("%s-a%d-%s-%s.txt", info.Name, info.Attempt, provider, time.Now().Format("150405.000000"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, redactURL(req.URL))
+	header := req.Header
+	if req.RawRequest != nil {
+		header = req.RawRequest.Header
+	}
+	writeHeaders(&b, header)
+	b.WriteString("\n")
+	b.WriteString(capBody(requestBody(req)))
+	b.WriteString("\n\n")
+
+	switch {
+	case reqErr != nil:
+		fmt.Fprintf(&b, "ERROR %v\n", reqErr)
+	case resp != nil:
+		fmt.Fprintf(&b, "%s\n", resp.Status())
+		writeHeaders(&b, resp.Header())
+		b.WriteString("\n")
+		b.WriteString(capBody(string(resp.Body())))
+		b.WriteString("\n")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(redactSecrets(b.String())), 0600); err != nil {
+		slog.Warn(msg.T("debughttp.write-failed"), "error", err)
+	}
+}
+
+func requestBody(req *resty.Request) string {
+	switch body := req.Body.(type) {
+	case nil:
+		return req.FormData.Encode()
+	case []byte:
+		return string(body)
+	case string:
+		return body
+	default:
+		return fmt.Sprintf("%T", body)
+	}
+}
+
+func capBody(s string) string {
+	if len(s) > debugHTTPMaxBody {
+		return s[:debugHTTPMaxBody] + fmt.Sprintf("\n… обрезано, всего %d байт", len(s))
+	}
+	return s
+}
+
+func writeHeaders(b *strings.Builder, h http.Header) {
+	h = redactHeaders(h)
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s: %s\n", k, strings.Join(h[k], ", "))
+	}
+}
+
+// configuredHeaders дополнительные заголовки провайдеров из конфигурации
+// (headers у ocr, gemini, audio, резервных провайдеров и моделей compare):
+// в них обычно токены шлюзов, поэтому их значения тоже скрываются
+func configuredHeaders() []map[string]string {
+	headers := []map[string]string{
+		config.OCR.Headers, config.Gemini.Headers, config.Audio.Headers,
+		config.Breaker.OCRFallback.Headers, config.Breaker.GeminiFallback.Headers,
+	}
+	for _, m := range config.Compare.Models {
+		headers = append(headers, m.Headers)
+	}
+	return headers
+}
+
+// redactHeaders копия заголовков без значений авторизации и заголовков из
+// конфигурации
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	names := append([]string(nil), sensitiveHeaders...)
+	for _, hs := range configuredHeaders() {
+		for k := range hs {
+			names = append(names, k)
+		}
+	}
+	for _, k := range names {
+		if _, ok := out[http.CanonicalHeaderKey(k)]; ok {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+// redactURL скрывает ключ, переданный параметром адреса
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactSecrets(raw)
+	}
+	q := u.Query()
+	for _, k := range []string{"key", "apikey", "api_key"} {
+		if q.Has(k) {
+			q.Set(k, redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// redactSecrets последний рубеж: ключи из конфигурации не должны попасть в
+// дамп ни в каком виде, в том числе внутри тела
+func redactSecrets(s string) string {
+	secrets := []string{config.OCRAPIKey, config.GeminiAPIKey, config.Audio.APIKey, config.Notion.Token, config.Email.Password, config.Discord.WebhookURL, config.Breaker.GeminiFallback.APIKey}
+	for _, m := range config.Compare.Models {
+		secrets = append(secrets, m.APIKey)
+	}
+	for _, hs := range configuredHeaders() {
+		for _, v := range hs {
+			secrets = append(secrets, v)
+		}
+	}
+	for _, secret := range secrets {
+		if len(secret) >= 4 {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	return s
+}
+
+// watchDebugHTTP перечитывает config.yml при изменении и применяет
+// debugHTTP без перезапуска. Остальные настройки вступают в силу только
+// после перезапуска.
+func watchDebugHTTP(ctx context.Context, path string) {
+	var modTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		modTime = fi.ModTime()
+	}
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = fi.ModTime()
+		c, err := appconfig.Load(path)
+		if err != nil {
+			slog.Warn(msg.T("config.reload-failed"), "error", err)
+			continue
+		}
+		enabled := flagDebugHTTP || c.DebugHTTP
+		if debugHTTP.Swap(enabled) != enabled {
+			slog.Info(msg.T("debughttp.toggled"), "enabled", enabled)
+		}
+	}
+}
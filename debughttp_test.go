@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+
+	appconfig "hack_interview/internal/config"
+)
+
+func TestRedactHeadersConfigured(t *testing.T) {
+	old := config
+	t.Cleanup(func() { config = old })
+	config.OCR.Headers = map[string]string{"X-Gateway-Token": "gw-ocr-token"}
+	config.Compare.Models = []appconfig.CompareModel{{ProviderConfig: appconfig.ProviderConfig{Headers: map[string]string{"x-team": "team-secret"}}}}
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Gateway-Token", "gw-ocr-token")
+	h.Set("X-Team", "team-secret")
+	h.Set("Content-Type", "application/json")
+	got := redactHeaders(h)
+	for _, k := range []string{"Authorization", "X-Gateway-Token", "X-Team"} {
+		if got.Get(k) != redacted {
+			t.Errorf("%s = %q", k, got.Get(k))
+		}
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q", got.Get("Content-Type"))
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("исходные заголовки изменены")
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	old := config
+	t.Cleanup(func() { config = old })
+	config.GeminiAPIKey = "gemini-key-123"
+	config.Audio.Headers = map[string]string{"X-Audio": "audio-header-456"}
+	config.Breaker.GeminiFallback.APIKey = "fallback-key-789"
+
+	got := redactSecrets("key=gemini-key-123 h=audio-header-456 f=fallback-key-789 short=abc")
+	for _, secret := range []string{"gemini-key-123", "audio-header-456", "fallback-key-789"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("в %q остался %q", got, secret)
+		}
+	}
+	if !strings.Contains(got, "short=abc") {
+		t.Errorf("лишнее скрыто: %q", got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	got := redactURL("https://example.com/v1?key=abc&model=m")
+	if strings.Contains(got, "abc") || !strings.Contains(got, "model=m") {
+		t.Errorf("redactURL = %q", got)
+	}
+}
+
+func TestHTTPDumpHasNoSecrets(t *testing.T) {
+	old := config
+	t.Cleanup(func() {
+		config = old
+		debugHTTP.Store(false)
+	})
+	config.OutputDir = t.TempDir()
+	config.OCRAPIKey = "ocr-api-key"
+	config.Gemini.Headers = map[string]string{"X-Proxy-Auth": "proxy-token"}
+	debugHTTP.Store(true)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo", r.Header.Get("X-Proxy-Auth"))
+		w.Write([]byte(`{"echo": "ocr-api-key"}`))
+	}))
+	defer srv.Close()
+
+	c := resty.New()
+	attachHTTPDump(c, "gemini")
+	_, err := c.R().
+		SetContext(withDumpInfo(context.Background(), "task.png", 1)).
+		SetHeader("X-Proxy-Auth", "proxy-token").
+		SetHeader("Apikey", "ocr-api-key").
+		SetFormData(map[string]string{"apikey": "ocr-api-key"}).
+		Post(srv.URL + "/?key=ocr-api-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(config.OutputDir, ".debug", "task.png-a1-gemini-*.txt"))
+	if len(files) != 1 {
+		t.Fatalf("дампы: %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"ocr-api-key", "proxy-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("в дампе %q:\n%s", secret, data)
+		}
+	}
+}
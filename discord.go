@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+
+	"hack_interview/internal/discord"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/output"
+	"hack_interview/internal/transport"
+)
+
+// discordColor цвет карточки ответа
+const discordColor = 0x5865F2
+
+// discordPublisher карточка с ответом в канале Discord; продолжение
+// длинного ответа уходит следующими сообщениями
+type discordPublisher struct {
+	hook *discord.Webhook
+}
+
+func newDiscordPublisher(url string) (*discordPublisher, error) {
+	client, err := transport.New(config.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	attachHTTPDump(client, metrics.ProviderDiscord)
+	return &discordPublisher{hook: &discord.Webhook{URL: url, Client: client, Timeout: config.RequestTimeout}}, nil
+}
+
+func (d *discordPublisher) Name() string { return "discord" }
+
+func (d *discordPublisher) Publish(ctx context.Context, r publishedResult) (string, error) {
+	chunks := output.SplitMessage(r.Markdown, discord.MaxDescription, discord.MaxContent)
+	if len(chunks) == 0 {
+		chunks = []string{"(пустой ответ)"}
+	}
+	title := truncateRunes(questionTitle(r.Question, r.Name), discord.MaxTitle)
+	err := d.hook.Send(ctx, discord.Message{Embeds: []discord.Embed{{Title: title, Description: chunks[0], Color: discordColor}}})
+	if err != nil {
+		return "", err
+	}
+	for _, c := range chunks[1:] {
+		if err := d.hook.Send(ctx, discord.Message{Content: c}); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/ocr"
+	"hack_interview/internal/speech"
+)
+
+// Итоги проверок doctor
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorProbeTimeout срок одного пробного запроса к провайдеру
+const doctorProbeTimeout = 30 * time.Second
+
+// doctorCheck итог одной проверки; Hint подсказывает, что исправить
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Hint   string
+}
+
+// runDoctor проверяет конфигурацию, директории, состояние, провайдеры и
+// внешние программы. Код выхода 1, если хоть одна проверка не прошла.
+func runDoctor(args []string) int {
+	fset := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	offline := fset.Bool("offline", false, "не отправлять пробные запросы провайдерам")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := appconfig.Load(configPath)
+	if err != nil {
+		printDoctor(doctorCheck{Name: "config", Status: doctorFail, Detail: err.Error(),
+			Hint: "исправьте config.yml; рядом с программой должен лежать config.yml"})
+		return 1
+	}
+	config = c
+	msg.SetLanguage(config.Language)
+	checks := []doctorCheck{{Name: "config", Status: doctorOK, Detail: configPath}}
+	checks = append(checks, doctorDirs()...)
+	checks = append(checks, doctorState())
+	checks = append(checks, doctorTools()...)
+	for _, ch := range checks {
+		printDoctor(ch)
+	}
+
+	if *offline {
+		fmt.Println(msg.T("doctor.offline"))
+	} else {
+		fmt.Println(msg.T("doctor.probes"))
+		for _, ch := range doctorProviders() {
+			printDoctor(ch)
+			checks = append(checks, ch)
+		}
+	}
+
+	failed := 0
+	for _, ch := range checks {
+		if ch.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf(msg.T("doctor.failed"), failed)
+		return 1
+	}
+	fmt.Println(msg.T("doctor.ok"))
+	return 0
+}
+
+func printDoctor(ch doctorCheck) {
+	fmt.Printf("%-5s %-16s %s\n", ch.Status, ch.Name, ch.Detail)
+	if ch.Hint != "" && ch.Status != doctorOK {
+		fmt.Printf("%-22s → %s\n", "", ch.Hint)
+	}
+}
+
+// doctorDirs входная директория читается, в директорию результатов и
+// директорию ошибок можно писать
+func doctorDirs() []doctorCheck {
+	in := doctorCheck{Name: "inputDir", Status: doctorOK, Detail: config.InputDir}
+	if _, err := os.ReadDir(config.InputDir); err != nil {
+		in.Status, in.Detail = doctorFail, err.Error()
+		in.Hint = "создайте директорию или поправьте inputDir"
+	}
+	checks := []doctorCheck{in}
+	for _, d := range []struct{ name, path string }{{"outputDir", config.OutputDir}, {"errorsDir", config.ErrorsDir}} {
+		ch := doctorCheck{Name: d.name, Status: doctorOK, Detail: d.path}
+		if _, err := os.Stat(d.path); os.IsNotExist(err) {
+			ch.Status, ch.Detail = doctorWarn, d.path+" не существует, будет создана"
+			checks = append(checks, ch)
+			continue
+		}
+		if err := writable(d.path); err != nil {
+			ch.Status, ch.Detail = doctorFail, err.Error()
+			ch.Hint = "проверьте права на запись в " + d.path
+		}
+		checks = append(checks, ch)
+	}
+	return checks
+}
+
+func writable(dir string) error {
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// doctorState состояние и счётчики квот читаются
+func doctorState() doctorCheck {
+	ch := doctorCheck{Name: "state", Status: doctorOK, Detail: config.StateBackend}
+	if _, err := os.Stat(config.OutputDir); os.IsNotExist(err) {
+		ch.Status, ch.Detail = doctorWarn, "директории результатов нет, состояние будет создано при запуске"
+		return ch
+	}
+	if err := loadState(); err != nil {
+		ch.Status, ch.Detail = doctorFail, err.Error()
+		ch.Hint = "переименуйте повреждённый файл состояния (stateFile/stateDB) — файлы обработаются заново"
+		return ch
+	}
+	ch.Detail = fmt.Sprintf("%s, записей: %d", config.StateBackend, len(state.Snapshot()))
+	state.Close()
+	if err := loadQuota(config.Quota); err != nil {
+		ch.Status, ch.Detail = doctorFail, "счётчики квот: "+err.Error()
+		ch.Hint = "удалите " + config.Quota.File + ", счётчики начнутся с нуля"
+	}
+	return ch
+}
+
+// doctorTools внешние программы для включённых возможностей
+func doctorTools() []doctorCheck {
+	var checks []doctorCheck
+	if config.ClipboardWatch {
+		ch := doctorCheck{Name: "clipboard", Status: doctorOK}
+		argv, err := clipboardCommand()
+		if err == nil {
+			_, err = exec.LookPath(argv[0])
+		}
+		if err != nil {
+			ch.Status, ch.Detail = doctorFail, err.Error()
+			ch.Hint = "установите утилиту буфера обмена или выключите clipboardWatch"
+		} else {
+			ch.Detail = argv[0]
+		}
+		checks = append(checks, ch)
+	}
+	if config.Audio.Backend != "" {
+		ch := doctorCheck{Name: "ffmpeg", Status: doctorOK, Detail: "нарезка длинных записей"}
+		if !(speech.Splitter{FFmpeg: config.Audio.FFmpeg}).Available() {
+			ch.Status, ch.Detail = doctorWarn, "ffmpeg не найден: длинные записи не обработаются"
+			ch.Hint = "установите ffmpeg или укажите audio.ffmpeg"
+		}
+		checks = append(checks, ch)
+		if config.Audio.Backend == appconfig.AudioWhisperCPP {
+			checks = append(checks, doctorBinary("whisper.cpp", config.Audio.Binary, "укажите audio.binary"))
+		}
+	}
+	if config.Git.Enabled {
+		checks = append(checks, doctorBinary("git", "git", "установите git или выключите git.enabled"))
+	}
+	if config.GoCheck || config.RunExamples {
+		checks = append(checks, doctorBinary("go", "go", "установите Go или выключите goCheck и runExamples"))
+	}
+	return checks
+}
+
+func doctorBinary(name, bin, hint string) doctorCheck {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return doctorCheck{Name: name, Status: doctorFail, Detail: err.Error(), Hint: hint}
+	}
+	return doctorCheck{Name: name, Status: doctorOK, Detail: path}
+}
+
+// doctorProviders отправляет по минимальному запросу каждому провайдеру.
+// Кассета и цепи провайдеров не используются: проверяется живой API.
+func doctorProviders() []doctorCheck {
+	cfg := config
+	cfg.Cassette.Mode = ""
+	off := 0
+	cfg.Breaker.Threshold = &off
+	p, err := newPipeline(cfg)
+	if err != nil {
+		return []doctorCheck{{Name: "http", Status: doctorFail, Detail: err.Error(), Hint: "проверьте http.proxy"}}
+	}
+
+	var checks []doctorCheck
+	if cfg.OCRProvider == appconfig.ProviderMock {
+		checks = append(checks, doctorMock("ocr"))
+	} else {
+		checks = append(checks, doctorOCR(p.OCR))
+	}
+	if cfg.LLMProvider == appconfig.ProviderMock {
+		return append(checks, doctorMock("gemini"))
+	}
+	checks = append(checks, doctorLLM("gemini", p.LLM, true))
+	if p.Verifier != nil {
+		checks = append(checks, doctorLLM("verify.model", p.Verifier, false))
+	}
+	for _, m := range p.Compare {
+		checks = append(checks, doctorLLM("compare:"+m.Name, m.LLM, false))
+	}
+	return checks
+}
+
+// doctorMock провайдер mock сети не касается: проверяется только
+// директория заготовок
+func doctorMock(name string) doctorCheck {
+	ch := doctorCheck{Name: name, Status: doctorOK, Detail: "mock, заготовки из " + config.Mock.Fixtures}
+	if _, err := os.Stat(config.Mock.Fixtures); err != nil {
+		ch.Status, ch.Detail = doctorWarn, "mock без заготовок: все ответы — mock.text и mock.answer"
+	}
+	return ch
+}
+
+func doctorOCR(provider ocr.Provider) doctorCheck {
+	ch := doctorCheck{Name: "ocr", Status: doctorOK}
+	dir, err := os.MkdirTemp("", "hack_interview-doctor-")
+	if err != nil {
+		ch.Status, ch.Detail = doctorFail, err.Error()
+		return ch
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "probe.png")
+	if err := writeProbeImage(path); err != nil {
+		ch.Status, ch.Detail = doctorFail, err.Error()
+		return ch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	text, err := provider.ExtractText(ctx, path)
+	switch {
+	case errors.Is(err, errs.ErrNoText):
+		ch.Status, ch.Detail = doctorWarn, "ключ принят, но текст пробного снимка не распознан"
+	case err != nil:
+		ch.Status, ch.Detail = doctorFail, err.Error()
+		ch.Hint = providerHint(err, "OCR_API_KEY", config.OCRBaseURL())
+	case !strings.Contains(strings.ToUpper(text), probeText):
+		ch.Status, ch.Detail = doctorWarn, fmt.Sprintf("распознано %q вместо %s", strings.TrimSpace(text), probeText)
+	default:
+		ch.Detail = fmt.Sprintf("ответ за %v", time.Since(start).Round(time.Millisecond))
+	}
+	ch.Detail += fmt.Sprintf(" (%s, тариф %s)", config.OCRBaseURL(), config.OCRPlan)
+	return ch
+}
+
+// doctorLLM проверяет модель запросом из одного слова; required — без
+// модели программа не работает, иначе ошибка только предупреждение
+func doctorLLM(name string, model llm.Answerer, required bool) doctorCheck {
+	ch := doctorCheck{Name: name, Status: doctorOK}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	_, err := model.Answer(ctx, llm.Request{Prompt: "ping", MaxOutputTokens: 16})
+	if err != nil {
+		ch.Status, ch.Detail = doctorFail, err.Error()
+		if !required {
+			ch.Status = doctorWarn
+		}
+		ch.Hint = providerHint(err, "GEMINI_API_KEY", config.Gemini.BaseURL)
+		return ch
+	}
+	ch.Detail = fmt.Sprintf("ответ за %v", time.Since(start).Round(time.Millisecond))
+	return ch
+}
+
+// providerHint подсказка по виду ошибки провайдера
+func providerHint(err error, keyName, baseURL string) string {
+	var quota *errs.QuotaExceededError
+	var blocked *errs.BlockedError
+	switch {
+	case errors.Is(err, errs.ErrAuth):
+		return "ключ не принят: проверьте " + keyName + " и срок его действия"
+	case errors.As(err, &quota):
+		return "исчерпана квота или превышен лимит запросов: дождитесь сброса или смените ключ"
+	case errors.As(err, &blocked):
+		return "провайдер отвечает, но заблокировал пробный запрос; обычно это не мешает работе"
+	case errors.Is(err, errs.ErrTimeout):
+		return "провайдер не ответил вовремя: проверьте сеть и http.proxy"
+	}
+	if baseURL != "" {
+		return "проверьте сеть, http.proxy и адрес " + baseURL
+	}
+	return "проверьте сеть и http.proxy"
+}
+
+// probeText текст пробного снимка OCR
+const probeText = "HELLO"
+
+// probeGlyphs буквы probeText точками 5×7
+var probeGlyphs = map[rune][7]string{
+	'H': {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'E': {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'L': {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'O': {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+}
+
+// writeProbeImage рисует probeText крупными буквами: OCR.space
+// распознаёт их увереннее мелкого шрифта, а снимок остаётся крошечным
+func writeProbeImage(path string) error {
+	const scale, gap, margin = 8, 2, 16
+	width := margin*2 + len(probeText)*(5+gap)*scale
+	height := margin*2 + 7*scale
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i, r := range probeText {
+		x0 := margin + i*(5+gap)*scale
+		for row, line := range probeGlyphs[r] {
+			for col, dot := range line {
+				if dot != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						img.SetGray(x0+col*scale+dx, margin+row*scale+dy, color.Gray{})
+					}
+				}
+			}
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// e2eQuestion текст, который «распознаёт» подставной OCR.space
+const e2eQuestion = "Чем горутина отличается от потока операционной системы?"
+
+// e2eServers подставные OCR.space и Gemini API: ответы в формате настоящих
+// API и записанные запросы
+type e2eServers struct {
+	mu     sync.Mutex
+	ocr    []*http.Request
+	forms  []url.Values
+	gemini []*http.Request
+	bodies [][]byte
+}
+
+func newE2EServers(t *testing.T) (*e2eServers, string, string) {
+	t.Helper()
+	s := &e2eServers{}
+	ocrSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		s.ocr = append(s.ocr, r)
+		s.forms = append(s.forms, r.PostForm)
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{
+			"ParsedResults":         []map[string]any{{"ParsedText": e2eQuestion + "\r\n", "FileParseExitCode": 1}},
+			"OCRExitCode":           1,
+			"IsErroredOnProcessing": false,
+		})
+	}))
+	t.Cleanup(ocrSrv.Close)
+	geminiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.gemini = append(s.gemini, r)
+		s.bodies = append(s.bodies, body)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "Горутина легче потока: её планирует рантайм Go."}], "role": "model"},
+			"finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 21, "candidatesTokenCount": 12}}`))
+	}))
+	t.Cleanup(geminiSrv.Close)
+	return s, ocrSrv.URL, geminiSrv.URL
+}
+
+// writePNGInput создаёт во входной директории настоящий PNG: перед OCR
+// снимок декодируется
+func writePNGInput(t *testing.T, name string) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	img.Set(1, 1, color.Black)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(config.InputDir, name), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Снимок проходит весь путь наблюдения: сканирование входной директории,
+// очередь, обработчик, OCR.space и Gemini по HTTP, сохранение ответа и
+// запись итога в состояние
+func TestEndToEndHTTP(t *testing.T) {
+	t.Setenv("OCR_API_KEY", "ocr-test-key")
+	t.Setenv("GEMINI_API_KEY", "gemini-test-key")
+	srv, ocrURL, geminiURL := newE2EServers(t)
+	loadTestEnv(t, "ocr:\n  baseURL: "+ocrURL+"\n  allowInsecure: true\n"+
+		"gemini:\n  baseURL: "+geminiURL+"\n  allowInsecure: true\n")
+	writePNGInput(t, "task.png")
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	fs, ok := state.Get("task.png")
+	if !ok || fs.Status != statusDone {
+		t.Fatalf("состояние %+v", fs)
+	}
+	if fs.Output != filepath.Join("out", "task.md") {
+		t.Errorf("результат %q", fs.Output)
+	}
+	md, err := os.ReadFile(fs.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "Горутина легче потока") {
+		t.Errorf("в результате нет ответа:\n%s", md)
+	}
+	if ocrText, _ := os.ReadFile(filepath.Join("out", "task.ocr.txt")); strings.TrimSpace(string(ocrText)) != e2eQuestion {
+		t.Errorf("распознанный текст %q", ocrText)
+	}
+
+	if len(srv.ocr) != 1 || len(srv.gemini) != 1 {
+		t.Fatalf("запросов OCR %d, Gemini %d", len(srv.ocr), len(srv.gemini))
+	}
+	o, form := srv.ocr[0], srv.forms[0]
+	if o.URL.Path != "/parse/image" || o.Header.Get("apikey") != "ocr-test-key" {
+		t.Errorf("OCR: %s apikey=%q", o.URL.Path, o.Header.Get("apikey"))
+	}
+	if form.Get("language") != "rus" || !strings.HasPrefix(form.Get("base64Image"), "data:image/png;base64,") {
+		t.Errorf("OCR: форма %v", form)
+	}
+
+	g := srv.gemini[0]
+	if g.URL.Path != "/v1beta/models/gemini-2.0-flash:generateContent" || g.URL.Query().Get("key") != "gemini-test-key" {
+		t.Errorf("Gemini: %s", g.URL)
+	}
+	var body struct {
+		Contents []struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(srv.bodies[0], &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Contents) != 1 || len(body.Contents[0].Parts) != 1 ||
+		!strings.HasPrefix(body.Contents[0].Parts[0].Text, "Ответь:\n"+e2eQuestion) {
+		t.Errorf("Gemini: тело %s", srv.bodies[0])
+	}
+}
+
+// Повторы запроса к Gemini (10 раз с растущей паузой) дольше fileTimeout:
+// срок файла прерывает их, файл помечается неудачным из-за таймаута, а
+// распознанный текст остаётся
+func TestFileTimeoutCutsRetries(t *testing.T) {
+	t.Setenv("OCR_API_KEY", "ocr-test-key")
+	t.Setenv("GEMINI_API_KEY", "gemini-test-key")
+	srv, ocrURL, _ := newE2EServers(t)
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		srv.mu.Lock()
+		srv.gemini = append(srv.gemini, r)
+		srv.mu.Unlock()
+		http.Error(w, `{"error": {"code": 503, "status": "UNAVAILABLE"}}`, http.StatusServiceUnavailable)
+	}))
+	defer gemini.Close()
+	loadTestEnv(t, "ocr:\n  baseURL: "+ocrURL+"\n  allowInsecure: true\n"+
+		"gemini:\n  baseURL: "+gemini.URL+"\n  allowInsecure: true\n"+
+		"fileTimeout: 400ms\nmaxAttempts: 1\nhttp:\n  retries: 10\n  retryWait: 100ms\n")
+	writePNGInput(t, "task.png")
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fs, _ := state.Get("task.png")
+	if fs.Status != statusFailed || !strings.Contains(fs.LastError, "превышено время обработки файла") {
+		t.Errorf("состояние %s: %s", fs.Status, fs.LastError)
+	}
+	// Без срока файла повторы заняли бы больше 10 секунд
+	if elapsed > 3*time.Second {
+		t.Errorf("обработка заняла %v при fileTimeout 400ms", elapsed)
+	}
+	srv.mu.Lock()
+	attempts := len(srv.gemini)
+	srv.mu.Unlock()
+	if attempts < 2 || attempts > 10 {
+		t.Errorf("запросов к Gemini %d, want повторы, прерванные сроком", attempts)
+	}
+	if ocrText, err := os.ReadFile(filepath.Join("out", "task.ocr.txt")); err != nil || strings.TrimSpace(string(ocrText)) != e2eQuestion {
+		t.Errorf("распознанный текст %q, %v", ocrText, err)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message/mail"
+
+	appconfig "hack_interview/internal/config"
+)
+
+// flagEmailTest отправляет пробное письмо при запуске
+var flagEmailTest bool
+
+// emailAttempts попыток отправки письма; между ними пауза растёт вдвое
+const emailAttempts = 3
+
+// emailPublisher письмо с ответом на каждый вопрос
+type emailPublisher struct {
+	cfg appconfig.EmailConfig
+}
+
+func (e emailPublisher) Name() string { return "email" }
+
+func (e emailPublisher) Publish(ctx context.Context, r publishedResult) (string, error) {
+	msg, err := e.message("[hack_interview] "+questionTitle(r.Question, r.Name), r.Markdown, r.Source)
+	if err != nil {
+		return "", err
+	}
+	delay := 5 * time.Second
+	for attempt := 1; ; attempt++ {
+		err = e.send(ctx, msg)
+		var proto *textproto.Error
+		// 5xx кроме временных 421/45x — ошибка настройки, повтор не поможет
+		permanent := errors.As(err, &proto) && proto.Code >= 500
+		if err == nil || permanent || attempt == emailAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(e.cfg.To, ", "), nil
+}
+
+// Preflight отправляет пробное письмо, чтобы ошибки входа и проверки
+// сертификата были видны сразу при запуске
+func (e emailPublisher) Preflight(ctx context.Context) error {
+	msg, err := e.message("[hack_interview] проверка отправки", "Это пробное письмо hack_interview: ответы будут приходить на этот адрес.", "")
+	if err != nil {
+		return err
+	}
+	return e.send(ctx, msg)
+}
+
+// message письмо: текст ответа и HTML-версия из Markdown, а при
+// email.attach — исходный файл, если он не больше email.maxAttachmentSize
+func (e emailPublisher) message(subject, body, attachment string) ([]byte, error) {
+	from, err := mail.ParseAddress(e.cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("email.from: %w", err)
+	}
+	var to []*mail.Address
+	for _, addr := range e.cfg.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("email.to: %w", err)
+		}
+		to = append(to, a)
+	}
+	var h mail.Header
+	h.SetDate(time.Now())
+	h.SetAddressList("From", []*mail.Address{from})
+	h.SetAddressList("To", to)
+	h.SetSubject(subject)
+	if err := h.GenerateMessageID(); err != nil {
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := markdown.Convert([]byte(body), &html); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	mw, err := mail.CreateWriter(&buf, h)
+	if err != nil {
+		return nil, err
+	}
+	tw, err := mw.CreateInline()
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range []struct{ typ, text string }{
+		{"text/plain", body},
+		{"text/html", html.String()},
+	} {
+		var ph mail.InlineHeader
+		ph.SetContentType(part.typ, map[string]string{"charset": "utf-8"})
+		w, err := tw.CreatePart(ph)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.text)); err != nil {
+			return nil, err
+		}
+		w.Close()
+	}
+	tw.Close()
+
+	if e.cfg.Attach && attachment != "" {
+		if err := attachFile(mw, attachment, e.cfg.MaxAttachmentSize); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// attachFile прикладывает файл, если он не больше limit; больший
+// пропускается без ошибки
+func attachFile(mw *mail.Writer, path string, limit int64) error {
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() > limit {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	typ := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if typ == "" {
+		typ = "application/octet-stream"
+	}
+	var ah mail.AttachmentHeader
+	ah.SetContentType(typ, nil)
+	ah.SetFilename(filepath.Base(path))
+	w, err := mw.CreateAttachment(ah)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// send отправляет письмо через email.addr: сразу по TLS, с STARTTLS или
+// (tls: none) без шифрования
+func (e emailPublisher) send(ctx context.Context, msg []byte) error {
+	host, _, err := net.SplitHostPort(e.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("email.addr: %w", err)
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if e.cfg.TLS == appconfig.EmailTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", e.cfg.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", e.cfg.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if e.cfg.TLS == appconfig.EmailSTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP-сервер %s не поддерживает STARTTLS", host)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, host)); err != nil {
+			return fmt.Errorf("ошибка входа на SMTP-сервер: %w", err)
+		}
+	}
+	from, _ := mail.ParseAddress(e.cfg.From)
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range e.cfg.To {
+		to, _ := mail.ParseAddress(addr)
+		if err := c.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
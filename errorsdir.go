@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// moveToErrors переносит окончательно не обработанный файл в ErrorsDir и
+// пишет рядом <имя>.error.txt с причиной. Возвращает новый путь файла.
+func moveToErrors(path string, fs FileState, procErr error) (string, error) {
+	if err := os.MkdirAll(config.ErrorsDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	dest := path
+	if !inDir(path, config.ErrorsDir) {
+		var err error
+		dest, err = freePath(config.ErrorsDir, filepath.Base(path))
+		if err != nil {
+			return "", err
+		}
+		if err := moveFile(path, dest); err != nil {
+			return "", err
+		}
+	}
+
+	reason := fmt.Sprintf("Время: %s\nПопыток: %d\nИсходный путь: %s\n\nОшибка:\n%s\n",
+		time.Now().Format(time.RFC3339), fs.Attempts, path, errorChain(procErr))
+	if err := os.WriteFile(dest+".error.txt", []byte(reason), 0644); err != nil {
+		return dest, err
+	}
+	return dest, nil
+}
+
+// errorChain разворачивает цепочку обёрнутых ошибок, по строке на уровень
+func errorChain(err error) string {
+	var lines []string
+	for i := 0; err != nil; i++ {
+		lines = append(lines, strings.Repeat("  ", i)+err.Error())
+		err = errors.Unwrap(err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// freePath подбирает в dir имя, не совпадающее с существующими файлами:
+// name, name-1, name-2...
+func freePath(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	p := filepath.Join(dir, name)
+	for i := 1; i < 10000; i++ {
+		if _, err := os.Lstat(p); os.IsNotExist(err) {
+			return p, nil
+		}
+		p = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, i, ext))
+	}
+	return "", fmt.Errorf("не удалось подобрать свободное имя для %s в %s", name, dir)
+}
+
+// moveFile переименовывает файл, а между файловыми системами копирует его
+// с fsync и удаляет исходный только после успешной записи копии.
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	var linkErr *os.LinkError
+	if !errors.As(err, &linkErr) || !errors.Is(linkErr.Err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
+
+func inDir(path, dir string) bool {
+	absPath, err1 := filepath.Abs(path)
+	absDir, err2 := filepath.Abs(dir)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
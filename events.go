@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Виды событий конвейера
+const (
+	EventQueued = "queued" // файл поставлен в очередь
+	EventStage  = "stage"  // обработчик перешёл к этапу (OCR, LLM, idle)
+	EventDone   = "done"   // итог обработки записан в состояние
+)
+
+// Event событие конвейера обработки. Headless-режим и TUI получают одни и
+// те же события через bus.
+type Event struct {
+	Kind   string
+	Time   time.Time
+	Name   string
+	Path   string
+	Worker int
+	Stage  string
+	// Для EventDone: итоговое состояние файла и ошибка последней попытки
+	State FileState
+	Err   error
+}
+
+// eventBus рассылает события подписчикам. Медленный подписчик теряет
+// события, а не задерживает обработку.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+var bus = &eventBus{subs: make(map[chan Event]struct{})}
+
+// Subscribe возвращает канал событий и функцию отписки
+func (b *eventBus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() {
+		b.mu.Lock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+		b.mu.Unlock()
+	}
+}
+
+func (b *eventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+)
+
+// Запуск кода из ответа на примерах из условия (runExamples). Код написан
+// моделью и выполняется на этой машине, поэтому режим выключен по
+// умолчанию, а в логе и в результате явно отмечается, что код запускался.
+// Ограничения: временная директория, которая удаляется после запуска,
+// окружение без ключей и с недоступным прокси вместо сети, срок
+// runExamplesTimeout на всё выполнение и не больше goCheckMaxOutput байт
+// вывода.
+
+// exampleCase пример из условия в виде вызова решения и ожидаемого значения
+type exampleCase struct {
+	Call     string `json:"call"`
+	Expected string `json:"expected"`
+}
+
+// fencedJSONRe JSON-массив или объект в блоке кода ответа модели
+var fencedJSONRe = regexp.MustCompile("(?s)```(?:json)?\\s*([\\[{].*?[\\]}])\\s*```")
+
+// parseExamples достаёт JSON-массив примеров из ответа модели
+func parseExamples(resp string) ([]exampleCase, error) {
+	raw := strings.TrimSpace(resp)
+	if m := fencedJSONRe.FindStringSubmatch(resp); m != nil {
+		raw = m[1]
+	}
+	var cases []exampleCase
+	if err := json.Unmarshal([]byte(raw), &cases); err != nil {
+		return nil, fmt.Errorf("некорректный JSON примеров: %w", err)
+	}
+	valid := cases[:0]
+	for _, c := range cases {
+		if strings.TrimSpace(c.Call) != "" && strings.TrimSpace(c.Expected) != "" {
+			valid = append(valid, c)
+		}
+	}
+	return valid, nil
+}
+
+// examplesHarness main, который вызывает решение на каждом примере и
+// печатает PASS/FAIL/PANIC <номер>. Паника одного примера не мешает
+// остальным.
+func examplesHarness(cases []exampleCase) string {
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"reflect\"\n)\n\n")
+	b.WriteString("func check(n int, got, want func() any) {\n")
+	b.WriteString("\tdefer func() {\n\t\tif r := recover(); r != nil {\n\t\t\tfmt.Printf(\"PANIC %d %v\\n\", n, r)\n\t\t}\n\t}()\n")
+	b.WriteString("\tg, w := got(), want()\n")
+	b.WriteString("\tif reflect.DeepEqual(g, w) {\n\t\tfmt.Printf(\"PASS %d\\n\", n)\n\t} else {\n\t\tfmt.Printf(\"FAIL %d got=%v want=%v\\n\", n, g, w)\n\t}\n}\n\n")
+	b.WriteString("func main() {\n")
+	for i, c := range cases {
+		fmt.Fprintf(&b, "\tcheck(%d, func() any { return %s }, func() any { return %s })\n", i+1, c.Call, c.Expected)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// exampleEnv окружение запуска: только PATH и временный HOME, сетевые
+// запросы уходят в недоступный прокси
+func exampleEnv(home string) []string {
+	return []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + home,
+		"TMPDIR=" + home,
+		"HTTP_PROXY=http://127.0.0.1:9",
+		"HTTPS_PROXY=http://127.0.0.1:9",
+		"NO_PROXY=",
+	}
+}
+
+// runExamples собирает решение с обвязкой и выполняет её
+func runExamples(ctx context.Context, code string, cases []exampleCase) (string, error) {
+	sb, err := newGoSandbox()
+	if err != nil {
+		return "", err
+	}
+	defer sb.Close()
+
+	src := goSource(code)
+	if !mainPkgRe.MatchString(src) {
+		return "", errors.New("решение не в package main, запуск не поддерживается")
+	}
+	src = mainFuncRe.ReplaceAllString(src, "func solutionMain()")
+	if err := sb.WriteFile("main.go", src); err != nil {
+		return "", err
+	}
+	if err := sb.WriteFile("harness.go", examplesHarness(cases)); err != nil {
+		return "", err
+	}
+	bin := filepath.Join(sb.dir, "solution.bin")
+	if out, err := sb.Go(ctx, goCheckTimeout, goBuildEnv(), "build", "-o", bin, "."); err != nil {
+		return out, fmt.Errorf("сборка: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, config.RunExamplesTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, bin)
+	cmd.Dir = sb.dir
+	cmd.Env = exampleEnv(sb.dir)
+	isolateCommand(cmd)
+	out := &cappedBuffer{max: goCheckMaxOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return out.String(), fmt.Errorf("превышено время выполнения %v", config.RunExamplesTimeout)
+	}
+	return out.String(), err
+}
+
+// cappedBuffer сохраняет не больше max байт, остальное отбрасывает
+type cappedBuffer struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.max - len(c.buf); room > 0 {
+		if len(p) > room {
+			c.buf = append(c.buf, p[:room]...)
+			c.truncated = true
+		} else {
+			c.buf = append(c.buf, p...)
+		}
+	} else if len(p) > 0 {
+		c.truncated = true
+	}
+	return len(p), nil
+}
+
+func (c *cappedBuffer) String() string {
+	if c.truncated {
+		return string(c.buf) + "\n… вывод обрезан"
+	}
+	return string(c.buf)
+}
+
+var exampleLineRe = regexp.MustCompile(`(?m)^(PASS|FAIL|PANIC) (\d+)(.*)$`)
+
+// checkExamples просит модель выписать примеры из условия, запускает на них
+// код Go code и добавляет раздел «## Examples» с итогом по каждому примеру.
+// code берётся из checkGoCode, а не из всех блоков ответа: после
+// исправления в ответе есть и исходный, и исправленный код. Любая ошибка
+// отражается в разделе и не делает ответ неудачным.
+func (p *Pipeline) checkExamples(ctx context.Context, logger *slog.Logger, question, answer, code string) string {
+	if !config.RunExamples || code == "" {
+		return answer
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		logger.Debug(msg.T("examples.no-go"))
+		return answer
+	}
+
+	prompt := "Выпиши примеры входных и выходных данных из условия задачи ниже как JSON-массив " +
+		`[{"call": "...", "expected": "..."}], где call — выражение Go, вызывающее решение ниже ` +
+		"с аргументами примера и возвращающее одно значение, а expected — выражение Go с ожидаемым " +
+		"значением того же типа. Если примеров нет, ответь []. Ответь только JSON.\n\nУсловие:\n" +
+		question + "\n\nРешение:\n```go\n" + code + "\n```"
+	resp, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("examples.failed"), "error", err)
+		return answer
+	}
+	cases, err := parseExamples(resp)
+	if err != nil {
+		logger.Warn(msg.T("examples.parse-failed"), "error", err)
+		return answer
+	}
+	if len(cases) == 0 {
+		logger.Debug(msg.T("examples.none"))
+		return answer
+	}
+
+	logger.Warn(msg.T("examples.running"), "examples", len(cases))
+	start := time.Now()
+	out, runErr := runExamples(ctx, code, cases)
+	return appendExamples(answer, cases, out, runErr, time.Since(start))
+}
+
+func appendExamples(answer string, cases []exampleCase, out string, runErr error, took time.Duration) string {
+	results := make(map[string]string)
+	for _, m := range exampleLineRe.FindAllStringSubmatch(out, -1) {
+		results[m[2]] = m[1] + m[3]
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(answer, "\n"))
+	b.WriteString("\n\n## Examples\n\n")
+	b.WriteString("> ⚠ Код из ответа модели был выполнен на этой машине (runExamples).\n\n")
+	passed := 0
+	for i, c := range cases {
+		res, ok := results[fmt.Sprint(i+1)]
+		if !ok {
+			res = "NOT RUN"
+		}
+		if strings.HasPrefix(res, "PASS") {
+			passed++
+		}
+		fmt.Fprintf(&b, "- `%s` → `%s`: %s\n", c.Call, c.Expected, res)
+	}
+	fmt.Fprintf(&b, "\nПройдено %d из %d за %v\n", passed, len(cases), took.Round(time.Millisecond))
+	if runErr != nil {
+		fmt.Fprintf(&b, "\nОшибка: %v\n", runErr)
+		if rest := strings.TrimSpace(exampleLineRe.ReplaceAllString(out, "")); rest != "" {
+			b.WriteString("\n```\n" + rest + "\n```\n")
+		}
+	}
+	return b.String()
+}
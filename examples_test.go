@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/mock"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+func requireGo(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("сборка кода в short-режиме пропускается")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go не найден")
+	}
+}
+
+func TestAppendExamplesReportsFailures(t *testing.T) {
+	cases := []exampleCase{
+		{Call: "sum(1, 2)", Expected: "3"},
+		{Call: "sum(2, 2)", Expected: "5"},
+		{Call: "sum(0, 0)", Expected: "0"},
+	}
+	out := "PASS 1\nFAIL 2 got=4 want=5\nexit status 2\n"
+	got := appendExamples("ответ\n", cases, out, errors.New("exit status 2"), 1500*time.Millisecond)
+
+	for _, want := range []string{
+		"ответ\n\n## Examples\n",
+		"- `sum(1, 2)` → `3`: PASS\n",
+		"- `sum(2, 2)` → `5`: FAIL got=4 want=5\n",
+		"- `sum(0, 0)` → `0`: NOT RUN\n",
+		"Пройдено 1 из 3 за 1.5s",
+		"Ошибка: exit status 2",
+		"```\nexit status 2\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("нет %q в\n%s", want, got)
+		}
+	}
+}
+
+func TestParseExamples(t *testing.T) {
+	resp := "Вот примеры:\n```json\n[{\"call\": \"f(1)\", \"expected\": \"2\"}, {\"call\": \"\", \"expected\": \"1\"}]\n```"
+	cases, err := parseExamples(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 1 || cases[0].Call != "f(1)" {
+		t.Errorf("cases = %+v", cases)
+	}
+	if _, err := parseExamples("не JSON"); err == nil {
+		t.Error("нет ошибки для некорректного JSON")
+	}
+}
+
+func TestRunExamplesTimeout(t *testing.T) {
+	requireGo(t)
+	old := config
+	t.Cleanup(func() { config = old })
+	config.RunExamplesTimeout = 300 * time.Millisecond
+
+	code := "package main\n\nfunc spin(n int) int {\n\tfor {\n\t\tn++\n\t}\n}\n"
+	out, err := runExamples(context.Background(), code, []exampleCase{{Call: "spin(1)", Expected: "0"}})
+	if err == nil || !strings.Contains(err.Error(), "превышено время выполнения") {
+		t.Fatalf("err = %v, out = %q", err, out)
+	}
+}
+
+func TestRunExamplesBuildFailure(t *testing.T) {
+	requireGo(t)
+	out, err := runExamples(context.Background(), "package main\n\nfunc f() int { return \"x\" }\n", []exampleCase{{Call: "f()", Expected: "1"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "сборка:") {
+		t.Fatalf("err = %v", err)
+	}
+	if !strings.Contains(out, "main.go") {
+		t.Errorf("в выводе нет ошибки компилятора: %q", out)
+	}
+}
+
+func TestGoBuildEnvDropsSecrets(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "secret-gemini")
+	t.Setenv("OCR_API_KEY", "secret-ocr")
+	for _, kv := range goBuildEnv() {
+		if strings.Contains(kv, "secret-") {
+			t.Errorf("в окружении сборки %q", kv)
+		}
+	}
+}
+
+// Ответ «fixed after retry» содержит и сломанный, и исправленный блок; на
+// примерах проверяется только исправленный
+func TestCheckGoCodeReturnsFixedCode(t *testing.T) {
+	requireGo(t)
+	old := config
+	t.Cleanup(func() { config = old })
+	config.GoCheck = true
+
+	broken := "func f() int { return \"x\" }"
+	fixed := "package main\n\nfunc f() int { return 1 }"
+	p := &Pipeline{Pipeline: &apppipeline.Pipeline{LLM: &mock.LLM{Text: "```go\n" + fixed + "\n```"}}}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	answer, code := p.checkGoCode(context.Background(), logger, "```go\n"+broken+"\n```")
+	if !strings.Contains(answer, "fixed after retry") {
+		t.Fatalf("answer = %q", answer)
+	}
+	if code != fixed {
+		t.Errorf("code = %q, want %q", code, fixed)
+	}
+}
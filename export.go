@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"hack_interview/internal/msg"
+)
+
+// ankiNote карточка: вопрос, ответ в HTML и метки
+type ankiNote struct {
+	// Output путь результата
+	Output   string
+	Question string
+	Answer   string
+	Tags     []string
+	Updated  time.Time
+}
+
+// runExport выгружает обработанные вопросы и ответы: anki — TSV для
+// импорта в Anki (Файл → Импорт) с HTML на обороте, gdoc — документ Google
+// Docs (gdocs в config.yml).
+func runExport(args []string) int {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fset.String("format", "anki", "формат выгрузки: anki или gdoc")
+	out := fset.String("output", "", "файл результата, - для stdout (по умолчанию OutputDir/anki.tsv)")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "anki" && *format != "gdoc" {
+		fmt.Fprintf(os.Stderr, msg.T("export.unknown-format"), *format)
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+
+	notes := collectNotes()
+	if *format == "gdoc" {
+		if config.GDocs.Credentials == "" {
+			fmt.Fprintln(os.Stderr, msg.T("export.gdocs-no-credentials"))
+			return 2
+		}
+		url, n, err := exportGDoc(context.Background(), notes)
+		if err != nil {
+			slog.Error(msg.T("export.gdocs-failed"), "written", n, "error", err)
+			return 1
+		}
+		fmt.Printf(msg.T("export.gdocs-done"), n, url)
+		return 0
+	}
+	path := *out
+	if path == "" {
+		path = filepath.Join(config.OutputDir, "anki.tsv")
+	}
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			slog.Error(err.Error())
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeAnkiTSV(w, notes); err != nil {
+		slog.Error(msg.T("export.failed"), "error", err)
+		return 1
+	}
+	if path != "-" {
+		fmt.Printf(msg.T("export.anki-done"), len(notes), path)
+	}
+	return 0
+}
+
+var questionSpaceRe = regexp.MustCompile(`\s+`)
+
+// collectNotes собирает карточки из успешно обработанных файлов состояния.
+// Вопрос берётся из <имя>.ocr.txt рядом с результатом. Одинаковые вопросы
+// из разных сессий сводятся в одну карточку с самым новым ответом.
+func collectNotes() []ankiNote {
+	byQuestion := make(map[string]ankiNote)
+	for _, fs := range state.Snapshot() {
+		if fs.Status != statusDone || fs.Output == "" {
+			continue
+		}
+		answer, err := os.ReadFile(fs.Output)
+		if err != nil {
+			continue
+		}
+		question, err := os.ReadFile(filepath.Join(filepath.Dir(fs.Output), ocrTextName(fs.Output)))
+		if err != nil || strings.TrimSpace(string(question)) == "" {
+			continue
+		}
+		q := strings.TrimSpace(string(question))
+		key := strings.ToLower(questionSpaceRe.ReplaceAllString(q, " "))
+		if prev, ok := byQuestion[key]; ok && prev.Updated.After(fs.UpdatedAt) {
+			continue
+		}
+		label := kindLabel(classifyText(q))
+		byQuestion[key] = ankiNote{
+			Output:   fs.Output,
+			Question: q,
+			Answer:   string(answer),
+			Tags:     []string{"hack_interview", "session::" + fs.UpdatedAt.Format("2006-01-02"), "type::" + label},
+			Updated:  fs.UpdatedAt,
+		}
+	}
+	notes := make([]ankiNote, 0, len(byQuestion))
+	for _, n := range byQuestion {
+		notes = append(notes, n)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Updated.Before(notes[j].Updated) })
+	return notes
+}
+
+// ocrTextName имя файла распознанного текста для версионного результата:
+// name-v2.md → name.ocr.txt
+func ocrTextName(output string) string {
+	name := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	return versionSuffixRe.ReplaceAllString(name, "") + ".ocr.txt"
+}
+
+var versionSuffixRe = regexp.MustCompile(`-v\d+$`)
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// writeAnkiTSV пишет карточки в формате импорта Anki: заголовки #, поля
+// через табуляцию, многострочные поля в кавычках
+func writeAnkiTSV(w io.Writer, notes []ankiNote) error {
+	io.WriteString(w, "#separator:tab\n#html:true\n#tags column:3\n")
+	cw := csv.NewWriter(w)
+	cw.Comma = '\t'
+	for _, n := range notes {
+		var back bytes.Buffer
+		if err := markdown.Convert([]byte(n.Answer), &back); err != nil {
+			return err
+		}
+		front := strings.ReplaceAll(html.EscapeString(n.Question), "\n", "<br>")
+		if err := cw.Write([]string{front, back.String(), strings.Join(n.Tags, " ")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
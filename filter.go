@@ -0,0 +1,102 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/speech"
+)
+
+// supportedExtensions расширения файлов, которые отправляются на OCR
+var supportedExtensions = []string{".png", ".jpg", ".jpeg", ".heic", ".heif", ".gif"}
+
+// hasSupportedExtension принимает изображения, а при настроенном
+// audio.backend и аудиофайлы
+func hasSupportedExtension(name string) bool {
+	for _, ext := range supportedExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return true
+		}
+	}
+	return config.Audio.Backend != "" && speech.IsAudio(name)
+}
+
+// matchPatterns применяет шаблоны к имени файла относительно InputDir.
+// Exclude имеет приоритет; пустой include пропускает всё.
+func matchPatterns(name string, include, exclude []string) (bool, string) {
+	for _, p := range exclude {
+		if ok, _ := doublestar.Match(p, name); ok {
+			return false, "exclude " + p
+		}
+	}
+	if len(include) == 0 {
+		return true, ""
+	}
+	for _, p := range include {
+		if ok, _ := doublestar.Match(p, name); ok {
+			return true, "include " + p
+		}
+	}
+	return false, "не подходит ни под один include"
+}
+
+// filterLogged имена, решение по которым уже записано в лог, чтобы каждое
+// сканирование не повторяло одни и те же строки.
+var filterLogged sync.Map
+
+// acceptFile решает, нужно ли обрабатывать файл: фильтр по расширению
+// дополняется шаблонами include/exclude из конфигурации.
+func acceptFile(name string) bool {
+	if !hasSupportedExtension(name) {
+		return false
+	}
+
+	ok, reason := matchPatterns(name, config.Include, config.Exclude)
+	if _, seen := filterLogged.LoadOrStore(name, true); !seen && reason != "" {
+		if ok {
+			slog.Debug(msg.T("filter.accepted"), "file", name, "reason", reason)
+		} else {
+			slog.Debug(msg.T("filter.skipped"), "file", name, "reason", reason)
+		}
+	}
+	return ok
+}
+
+// Порядок обработки найденных файлов
+const (
+	sortByMtime = appconfig.SortByMtime
+	sortByName  = appconfig.SortByName
+)
+
+// sortFiles упорядочивает файлы для постановки в очередь: по времени
+// изменения (от старых к новым) или по имени. Время из будущего, например
+// у файлов, скопированных с машины с другими часами, приравнивается к now,
+// а при равном времени порядок определяет имя.
+func sortFiles(files []os.FileInfo, by string, now time.Time) {
+	if by == sortByName {
+		sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+		return
+	}
+
+	mtime := func(fi os.FileInfo) time.Time {
+		if t := fi.ModTime(); t.Before(now) {
+			return t
+		}
+		return now
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		ti, tj := mtime(files[i]), mtime(files[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i].Name() < files[j].Name()
+	})
+}
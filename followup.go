@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"hack_interview/internal/llm"
+)
+
+// Вопрос-продолжение отправляется модели вместе с предыдущими вопросами и
+// ответами. Продолжением считается снимок:
+//   - с префиксом имени f_ (f_no_memory.png);
+//   - с файлом-меткой рядом: <снимок>.followup;
+//   - при followUpWindow > 0 — сделанный не позже этого срока после
+//     предыдущего.
+//
+// Префикс n_ или метка <снимок>.new отменяют продолжение, если окно
+// ошиблось.
+const (
+	followUpPrefix    = "f_"
+	newQuestionPrefix = "n_"
+)
+
+// maxHistoryTurns сколько предыдущих вопросов попадает в беседу
+const maxHistoryTurns = 5
+
+// conversationTurn последний обработанный вопрос
+type conversationTurn struct {
+	Output  string
+	Taken   time.Time
+	History []llm.Turn
+}
+
+type conversation struct {
+	mu   sync.Mutex
+	last *conversationTurn
+}
+
+var dialog = &conversation{}
+
+// imageTime время снимка: время изменения файла
+func imageTime(path string) time.Time {
+	if fi, err := os.Stat(path); err == nil {
+		return fi.ModTime()
+	}
+	return time.Now()
+}
+
+func markerExists(path, ext string) bool {
+	_, err := os.Stat(path + ext)
+	return err == nil
+}
+
+// FollowUp возвращает предыдущий вопрос, если снимок — его продолжение
+func (c *conversation) FollowUp(path string) *conversationTurn {
+	c.mu.Lock()
+	last := c.last
+	c.mu.Unlock()
+	if last == nil {
+		return nil
+	}
+	base := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(base, newQuestionPrefix), markerExists(path, ".new"):
+		return nil
+	case strings.HasPrefix(base, followUpPrefix), markerExists(path, ".followup"):
+		return last
+	case config.FollowUpWindow > 0:
+		if d := imageTime(path).Sub(last.Taken); d >= 0 && d <= config.FollowUpWindow {
+			return last
+		}
+	}
+	return nil
+}
+
+// Record запоминает обработанный вопрос как предыдущий для следующих
+func (c *conversation) Record(path, output string, history []llm.Turn, turn llm.Turn) {
+	history = append(append([]llm.Turn(nil), history...), turn)
+	if len(history) > maxHistoryTurns {
+		history = history[len(history)-maxHistoryTurns:]
+	}
+	c.mu.Lock()
+	c.last = &conversationTurn{Output: output, Taken: imageTime(path), History: history}
+	c.mu.Unlock()
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/gdocs"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/transport"
+)
+
+// Запись ответов в Google Docs. Каждый ответ — одна запись документа: при
+// публикации по ходу работы у каждой сессии свой документ («Сессия <id>»),
+// у export --format gdoc — общий, если gdocs.documentId не задан.
+//
+// Ход записи хранится в OutputDir/.gdocs.json: какой документ у сессии и
+// сколько batchUpdate каждой записи выполнено. После сбоя запись
+// доделывается с того же места, а уже записанные ответы не повторяются.
+
+// gdocsEntry ход записи одного ответа
+type gdocsEntry struct {
+	// Start позиция вставки, Length длина текста в UTF-16
+	Start  int `json:"start"`
+	Length int `json:"length"`
+	// Batches выполнено batchUpdate из Total
+	Batches int `json:"batches"`
+	Total   int `json:"total"`
+}
+
+type gdocsState struct {
+	// Docs документ по ключу: session:<id> или export
+	Docs map[string]string `json:"docs"`
+	// Entries записи документа по пути результата
+	Entries map[string]map[string]*gdocsEntry `json:"entries"`
+}
+
+// gdocsWriter пишет записи в документы; методы не вызываются параллельно
+type gdocsWriter struct {
+	client *gdocs.Client
+	cfg    appconfig.GDocsConfig
+	path   string
+	mu     sync.Mutex
+	state  gdocsState
+}
+
+func newGDocsWriter(cfg appconfig.GDocsConfig) (*gdocsWriter, error) {
+	creds, err := gdocs.LoadCredentials(cfg.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	client, err := transport.New(config.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	attachHTTPDump(client, metrics.ProviderGDocs)
+	creds.Client = client
+	w := &gdocsWriter{
+		client: &gdocs.Client{Auth: creds, BaseURL: cfg.BaseURL, Client: client, Timeout: config.RequestTimeout},
+		cfg:    cfg,
+		path:   filepath.Join(config.OutputDir, ".gdocs.json"),
+	}
+	data, err := os.ReadFile(w.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &w.state); err != nil {
+			return nil, fmt.Errorf("ошибка разбора %s: %w", w.path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	if w.state.Docs == nil {
+		w.state.Docs = make(map[string]string)
+	}
+	if w.state.Entries == nil {
+		w.state.Entries = make(map[string]map[string]*gdocsEntry)
+	}
+	return w, nil
+}
+
+// document документ для ключа: gdocs.documentId или созданный ранее, а
+// если его нет — новый с заголовком title
+func (w *gdocsWriter) document(ctx context.Context, docKey, title string) (string, error) {
+	if w.cfg.DocumentID != "" {
+		return w.cfg.DocumentID, nil
+	}
+	if id := w.state.Docs[docKey]; id != "" {
+		return id, nil
+	}
+	id, err := w.client.Create(ctx, title)
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания документа: %w", err)
+	}
+	w.state.Docs[docKey] = id
+	slog.Info(msg.T("gdocs.created"), "url", gdocs.URL(id))
+	return id, w.save()
+}
+
+// Append дописывает запись key в документ ключа docKey и возвращает адрес
+// документа. Записанная ранее запись не повторяется, прерванная
+// доделывается.
+func (w *gdocsWriter) Append(ctx context.Context, docKey, docTitle, key string, e gdocs.Entry) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id, err := w.document(ctx, docKey, docTitle)
+	if err != nil {
+		return "", err
+	}
+	entries := w.state.Entries[id]
+	if entries == nil {
+		entries = make(map[string]*gdocsEntry)
+		w.state.Entries[id] = entries
+	}
+	rec := entries[key]
+	if rec != nil && rec.Batches >= rec.Total {
+		return gdocs.URL(id), nil
+	}
+
+	end, err := w.client.EndIndex(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case rec == nil:
+	case rec.Batches == 0 && end == rec.Start+1+e.Length():
+		// ответ на вставку текста потерян, но она выполнена
+		rec.Batches = 1
+	case rec.Batches > 0 && end != rec.Start+1+e.Length():
+		slog.Warn(msg.T("gdocs.changed"), "url", gdocs.URL(id), "output", key)
+		rec.Batches = rec.Total
+		return gdocs.URL(id), w.save()
+	}
+	if rec == nil || rec.Batches == 0 {
+		// текст вставляется в последний, пустой абзац документа
+		rec = &gdocsEntry{Start: end - 1, Length: e.Length()}
+		entries[key] = rec
+	}
+	reqs := e.Requests(rec.Start)
+	rec.Total = (len(reqs) + gdocs.MaxBatch - 1) / gdocs.MaxBatch
+	if err := w.save(); err != nil {
+		return "", err
+	}
+	for rec.Batches < rec.Total {
+		from := rec.Batches * gdocs.MaxBatch
+		to := min(from+gdocs.MaxBatch, len(reqs))
+		if err := w.client.BatchUpdate(ctx, id, reqs[from:to]); err != nil {
+			return "", err
+		}
+		rec.Batches++
+		if err := w.save(); err != nil {
+			return "", err
+		}
+	}
+	return gdocs.URL(id), nil
+}
+
+// save сохраняет ход записи через временный файл
+func (w *gdocsWriter) save() error {
+	data, err := json.MarshalIndent(w.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(w.path, data)
+}
+
+// gdocsPublisher публикует ответы в документ сессии
+type gdocsPublisher struct {
+	w *gdocsWriter
+}
+
+func (g gdocsPublisher) Name() string { return "gdocs" }
+
+func (g gdocsPublisher) Publish(ctx context.Context, r publishedResult) (string, error) {
+	entry := gdocs.Render(questionTitle(r.Question, r.Name), r.Question, r.Markdown)
+	return g.w.Append(ctx, "session:"+r.Session, "Сессия "+r.Session, r.Output, entry)
+}
+
+// exportGDoc дописывает в документ export ответы, которых в нём ещё нет
+func exportGDoc(ctx context.Context, notes []ankiNote) (string, int, error) {
+	w, err := newGDocsWriter(config.GDocs)
+	if err != nil {
+		return "", 0, err
+	}
+	var url string
+	cnt := 0
+	for _, n := range notes {
+		url, err = w.Append(ctx, "export", "hack_interview: ответы", n.Output, gdocs.Render(questionTitle(n.Question, n.Output), n.Question, n.Answer))
+		if err != nil {
+			return url, cnt, err
+		}
+		cnt++
+	}
+	return url, cnt, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+)
+
+// Коммит результатов в git-репозиторий OutputDir. Коммитятся только файлы
+// результатов (git commit -- <пути>), поэтому посторонние изменения, в том
+// числе уже добавленные в индекс, в коммит не попадают. Любая ошибка git
+// только пишется в лог.
+
+// gitMessageData данные шаблона git.message
+type gitMessageData struct {
+	Count   int
+	Session string
+	Time    time.Time
+}
+
+type gitCommitter struct {
+	dir  string
+	cfg  appconfig.GitConfig
+	tmpl *template.Template
+
+	mu      sync.Mutex
+	pending []string
+	count   int
+	session string
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newGitCommitter nil, если OutputDir не в git-репозитории, git не
+// установлен или в нём не задано имя и почта автора
+func newGitCommitter(cfg appconfig.GitConfig) *gitCommitter {
+	g := &gitCommitter{dir: config.OutputDir, cfg: cfg}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if out, err := g.git(ctx, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		slog.Warn(msg.T("git.not-repo"), "dir", g.dir, "error", err)
+		return nil
+	}
+	for _, key := range []string{"user.name", "user.email"} {
+		if out, _ := g.git(ctx, "config", key); out == "" {
+			slog.Warn(msg.T("git.no-author"), "missing", key)
+			return nil
+		}
+	}
+	g.tmpl = template.Must(template.New("message").Parse(cfg.Message))
+	if cfg.Interval > 0 {
+		g.stop = make(chan struct{})
+		g.done = make(chan struct{})
+		go g.run()
+	}
+	return g
+}
+
+func (g *gitCommitter) Name() string { return "git" }
+
+// Publish добавляет файлы результата к следующему коммиту; без
+// git.interval коммит создаётся сразу
+func (g *gitCommitter) Publish(ctx context.Context, r publishedResult) (string, error) {
+	g.mu.Lock()
+	g.pending = append(g.pending, resultFiles(r.Output)...)
+	g.count++
+	g.session = r.Session
+	g.mu.Unlock()
+	if g.cfg.Interval > 0 {
+		return "", nil
+	}
+	return g.commit(ctx)
+}
+
+func (g *gitCommitter) run() {
+	defer close(g.done)
+	ticker := time.NewTicker(g.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stop:
+			return
+		case <-ticker.C:
+			g.commitLogged()
+		}
+	}
+}
+
+// Flush коммитит накопленное при завершении
+func (g *gitCommitter) Flush() {
+	if g.stop != nil {
+		close(g.stop)
+		<-g.done
+	}
+	g.commitLogged()
+}
+
+func (g *gitCommitter) commitLogged() {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	hash, err := g.commit(ctx)
+	if err != nil {
+		slog.Warn(msg.T("git.commit-failed"), "error", err)
+	} else if hash != "" {
+		slog.Info(msg.T("git.committed"), "commit", hash)
+	}
+}
+
+// commit коммитит накопленные файлы и, при git.push, отправляет коммит.
+// Возвращает сокращённый хеш; "" — коммитить нечего.
+func (g *gitCommitter) commit(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	paths, count, session := g.pending, g.count, g.session
+	g.pending, g.count = nil, 0
+	g.mu.Unlock()
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	var msg bytes.Buffer
+	if err := g.tmpl.Execute(&msg, gitMessageData{Count: count, Session: session, Time: time.Now()}); err != nil {
+		return "", fmt.Errorf("шаблон git.message: %w", err)
+	}
+	args := append([]string{"add", "--"}, paths...)
+	if _, err := g.git(ctx, args...); err != nil {
+		return "", err
+	}
+	args = append([]string{"diff", "--cached", "--quiet", "--"}, paths...)
+	if _, err := g.git(ctx, args...); err == nil {
+		return "", nil
+	}
+	args = append([]string{"commit", "--quiet", "-m", msg.String(), "--"}, paths...)
+	if _, err := g.git(ctx, args...); err != nil {
+		return "", err
+	}
+	hash, err := g.git(ctx, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if g.cfg.Push {
+		if _, err := g.git(ctx, "push", "--quiet"); err != nil {
+			return hash, fmt.Errorf("коммит %s создан, но не отправлен: %w", hash, err)
+		}
+	}
+	return hash, nil
+}
+
+// git выполняет команду в OutputDir и возвращает её вывод без пробелов по
+// краям; в ошибке — вывод git
+func (g *gitCommitter) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resultFiles файлы результата: <имя>.md и файлы рядом с тем же именем
+// (<имя>.ocr.txt, код, тесты)
+func resultFiles(output string) []string {
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	var files []string
+	for _, pattern := range []string{base + ".*", base + "_test.*"} {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		files = []string{output}
+	}
+	return files
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// Итог проверки кода
+const (
+	checkClean   = "clean"
+	checkFixed   = "fixed"
+	checkFailing = "failing"
+)
+
+// goCheckTimeout срок одного запуска go build или go vet
+const goCheckTimeout = time.Minute
+
+// goCheckMaxOutput сколько байт вывода компилятора сохраняется
+const goCheckMaxOutput = 8 << 10
+
+var (
+	packageRe  = regexp.MustCompile(`(?m)^package\s+\w+`)
+	mainPkgRe  = regexp.MustCompile(`(?m)^package\s+main\b`)
+	mainFuncRe = regexp.MustCompile(`(?m)^func\s+main\s*\(\s*\)`)
+)
+
+// goSandbox временный модуль для проверки кода из ответа. Модули не
+// скачиваются (GOPROXY=off), используется только локальный тулчейн.
+type goSandbox struct {
+	dir string
+}
+
+func newGoSandbox() (*goSandbox, error) {
+	dir, err := os.MkdirTemp("", "hack_interview-go-*")
+	if err != nil {
+		return nil, err
+	}
+	mod := "module solution\n\ngo 1.22\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &goSandbox{dir: dir}, nil
+}
+
+func (s *goSandbox) Close() {
+	os.RemoveAll(s.dir)
+}
+
+// WriteFile записывает файл модуля
+func (s *goSandbox) WriteFile(name, data string) error {
+	return os.WriteFile(filepath.Join(s.dir, name), []byte(data), 0644)
+}
+
+// Go выполняет команду go в модуле и возвращает её вывод, обрезанный до
+// goCheckMaxOutput
+func (s *goSandbox) Go(ctx context.Context, timeout time.Duration, env []string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = s.dir
+	cmd.Env = append(env, "GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "GOWORK=off", "GO111MODULE=on")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("go %s: превышено время %v", args[0], timeout)
+	}
+	text := out.String()
+	if len(text) > goCheckMaxOutput {
+		text = text[:goCheckMaxOutput] + "\n…"
+	}
+	return strings.ReplaceAll(text, s.dir+string(filepath.Separator), ""), err
+}
+
+// goBuildEnv окружение go build и go vet: только то, что нужно тулчейну
+// (пути, кэш, временная директория). Ключи API и прочие переменные
+// процесса в сборку не попадают.
+func goBuildEnv() []string {
+	var env []string
+	for _, name := range []string{
+		"PATH", "HOME", "USERPROFILE", "LOCALAPPDATA", "APPDATA", "SystemRoot",
+		"TMPDIR", "TMP", "TEMP", "XDG_CACHE_HOME",
+		"GOROOT", "GOPATH", "GOCACHE", "GOMODCACHE", "GOOS", "GOARCH",
+	} {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// goSource превращает фрагмент из ответа в компилируемый файл: добавляет
+// package main и пустой main, если их нет
+func goSource(code string) string {
+	if !packageRe.MatchString(code) {
+		code = "package main\n\n" + code
+	}
+	if mainPkgRe.MatchString(code) && !mainFuncRe.MatchString(code) {
+		code += "\n\nfunc main() {}\n"
+	}
+	return code
+}
+
+// compileGo собирает и проверяет код через go build и go vet; возвращает
+// вывод первой неудачной команды
+func compileGo(ctx context.Context, code string) (string, error) {
+	sb, err := newGoSandbox()
+	if err != nil {
+		return "", err
+	}
+	defer sb.Close()
+	if err := sb.WriteFile("main.go", goSource(code)); err != nil {
+		return "", err
+	}
+	for _, args := range [][]string{{"build", "-o", os.DevNull, "./..."}, {"vet", "./..."}} {
+		if out, err := sb.Go(ctx, goCheckTimeout, goBuildEnv(), args...); err != nil {
+			return out, err
+		}
+	}
+	return "", nil
+}
+
+// checkGoCode проверяет, компилируется ли код Go из ответа, и при ошибке
+// один раз просит модель исправить его. К ответу добавляется раздел
+// «## Compile check» с итогом: clean, fixed (с исправленным кодом) или
+// failing (с ошибками компилятора). Вторым значением возвращается код,
+// который дальше проверяется на примерах: исправленный, если исправление
+// собралось, иначе исходный.
+func (p *Pipeline) checkGoCode(ctx context.Context, logger *slog.Logger, answer string) (string, string) {
+	code := output.ExtractCode(answer, "go")
+	if !config.GoCheck || code == "" {
+		return answer, code
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		logger.Debug(msg.T("gocheck.no-go"))
+		return answer, code
+	}
+
+	out, err := compileGo(ctx, code)
+	if err == nil {
+		logger.Info(msg.T("gocheck.ok"))
+		return appendCheck(answer, checkClean, "", ""), code
+	}
+	if out == "" {
+		logger.Warn(msg.T("gocheck.failed"), "error", err)
+		return answer, code
+	}
+
+	prompt := "Этот код на Go не компилируется. Исправь ошибки, не меняя решение, " +
+		"и ответь одним блоком ```go с полным исправленным кодом.\n\nОшибки:\n```\n" + out +
+		"```\n\nКод:\n```go\n" + code + "\n```"
+	resp, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+	providers.Record(err)
+	fixed := ""
+	if err == nil {
+		fixed = output.ExtractCode(resp, "go")
+	}
+	if fixed != "" {
+		fixOut, err := compileGo(ctx, fixed)
+		if err == nil {
+			logger.Info(msg.T("gocheck.fixed"))
+			return appendCheck(answer, checkFixed, fixed, ""), fixed
+		}
+		if fixOut != "" {
+			out = fixOut
+		}
+	}
+	logger.Warn(msg.T("gocheck.broken"))
+	return appendCheck(answer, checkFailing, "", out), code
+}
+
+func appendCheck(answer, status, fixed, compileErrors string) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(answer, "\n"))
+	b.WriteString("\n\n## Compile check\n\n")
+	switch status {
+	case checkClean:
+		b.WriteString("clean: go build и go vet без ошибок\n")
+	case checkFixed:
+		b.WriteString("fixed after retry: исходный код не компилировался, исправленная версия:\n\n```go\n" + fixed + "\n```\n")
+	case checkFailing:
+		b.WriteString("still failing:\n\n```\n" + strings.TrimRight(compileErrors, "\n") + "\n```\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/output"
+)
+
+// go test . -run Golden -update перезаписывает testdata/*.golden
+var update = flag.Bool("update", false, "перезаписать эталоны testdata/*.golden")
+
+// goldenResults ответы, которые проходят через все форматы публикации:
+// вложенная разметка со вставкой HTML, не-ASCII текст, огромный блок кода
+// и пустой ответ
+var goldenResults = []struct {
+	name string
+	r    output.Result
+}{
+	{"markdown", output.Result{
+		Source:   "in/slice.png",
+		Question: "Чем слайс отличается от массива?\nПриведите пример.",
+		Answer: "## Answer\n\nСлайс — **ссылка** на участок массива: `ptr`, `len`, `cap`.\n\n" +
+			"| | массив | слайс |\n|---|---|---|\n| размер | в типе | динамический |\n\n" +
+			"<script>alert(1)</script> и [ссылка](javascript:alert(1)).\n\n" +
+			"- [ ] задача\n- [x] сделано\n\n```go\ns := append([]int{}, 1)\n```\n",
+	}},
+	{"unicode", output.Result{
+		Source:   "in/юникод.png",
+		Question: "Сколько байт в «héllo, 世界 👋»?\tТабуляция и \"кавычки\"",
+		Answer:   "`len(\"héllo, 世界 👋\")` — 18 байт; e\u0301 — два кода. RTL: שלום, مرحبا.\n",
+	}},
+	{"huge-code", output.Result{
+		Source:   "in/huge.png",
+		Question: "Напишите генератор таблицы",
+		Answer:   "```go\n" + hugeCode() + "\n```\n",
+	}},
+	{"empty", output.Result{
+		Source:   "in/empty.png",
+		Question: "Что на снимке?",
+	}},
+}
+
+// hugeCode двести строк кода и одна строка в 4 КБ
+func hugeCode() string {
+	var b strings.Builder
+	b.WriteString("var table = []string{\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "\t\"row-%03d\",\n", i)
+	}
+	b.WriteString("}\n\nconst long = \"" + strings.Repeat("0123456789abcdef", 256) + "\"")
+	return b.String()
+}
+
+func TestPublishedFormatsGolden(t *testing.T) {
+	at := time.Date(2026, 3, 14, 9, 26, 0, 0, time.UTC)
+	for _, c := range goldenResults {
+		md := output.RenderMarkdown(c.r)
+		t.Run(c.name+"/obsidian", func(t *testing.T) {
+			note := renderObsidianNote(publishedResult{
+				Name:     c.name,
+				Output:   filepath.Join("out", c.name+".md"),
+				Source:   c.r.Source,
+				Markdown: string(md),
+				Question: c.r.Question,
+				Kind:     "Coding",
+				Session:  "Собеседование 14.03",
+				Time:     at,
+			})
+			checkGolden(t, c.name+".obsidian", note)
+		})
+		t.Run(c.name+"/html", func(t *testing.T) {
+			html, err := renderAnswerHTML(md)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, c.name+".html", []byte(html))
+		})
+		t.Run(c.name+"/anki", func(t *testing.T) {
+			var b bytes.Buffer
+			note := ankiNote{Question: c.r.Question, Answer: string(md), Tags: []string{"hack_interview", "type::coding"}}
+			if err := writeAnkiTSV(&b, []ankiNote{note}); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, c.name+".anki", b.Bytes())
+		})
+	}
+}
+
+// checkGolden сравнивает got с testdata/<name>.golden или, с -update,
+// перезаписывает эталон
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (go test -update создаст эталон)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s не совпадает с эталоном:\n%s", path, firstDiff(want, got))
+	}
+}
+
+// firstDiff первая различающаяся строка эталона и вывода
+func firstDiff(want, got []byte) string {
+	w, g := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl || i >= len(w) || i >= len(g) {
+			return fmt.Sprintf("строка %d:\n  эталон: %.200q\n  вывод:  %.200q", i+1, wl, gl)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// groupFileName файл во входной директории, пока он есть, все новые
+// снимки собираются в одну группу — один вопрос на нескольких экранах
+const groupFileName = "GROUP"
+
+// partRe часть вопроса по имени: task-1.png, task-2.png
+var partRe = regexp.MustCompile(`^(.+)-([1-9])$`)
+
+// toggleGroupKey ключ группы, собираемой по файлу GROUP
+const toggleGroupKey = "\x00" + groupFileName
+
+// pendingGroup части одного вопроса, ожидающие остальных
+type pendingGroup struct {
+	name  string         // имя результата
+	parts map[string]int // имя файла → порядковый номер части
+	last  time.Time      // когда пришла последняя новая часть
+}
+
+// grouper собирает снимки одного длинного вопроса: по именам с суффиксами
+// -1, -2 ... (group.suffix) или все, что пришло, пока есть файл GROUP.
+// Группа обрабатывается, когда новых частей нет дольше group.wait или
+// когда удалён файл GROUP.
+type grouper struct {
+	mu     sync.Mutex
+	groups map[string]*pendingGroup
+	held   map[string]string // имя файла → ключ группы
+	// flush отдаёт группы сразу, не дожидаясь group.wait: режим run
+	// обрабатывает то, что уже лежит в директории
+	flush bool
+}
+
+var groups = &grouper{groups: make(map[string]*pendingGroup), held: make(map[string]string)}
+
+// groupToggleActive сообщает, есть ли во входной директории файл GROUP
+func groupToggleActive() bool {
+	_, err := os.Stat(filepath.Join(config.InputDir, groupFileName))
+	return err == nil
+}
+
+// Hold придерживает файл, если он часть группы. Возвращает false для
+// обычного файла, который можно сразу ставить в очередь.
+func (g *grouper) Hold(name string, toggle bool, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.held[name]; ok {
+		return true
+	}
+
+	key, part, groupName := "", 0, ""
+	switch base := output.Name(name); {
+	case toggle:
+		key, groupName = toggleGroupKey, base
+		if pg, ok := g.groups[key]; ok {
+			part = len(pg.parts) + 1
+		}
+	case config.Group.Suffix && partRe.MatchString(base):
+		m := partRe.FindStringSubmatch(base)
+		key, groupName = m[1], m[1]
+		part, _ = strconv.Atoi(m[2])
+	default:
+		return false
+	}
+
+	pg, ok := g.groups[key]
+	if !ok {
+		pg = &pendingGroup{name: groupName, parts: make(map[string]int)}
+		g.groups[key] = pg
+		slog.Info(msg.T("group.waiting"), "group", groupName, "wait", config.Group.Wait)
+	}
+	pg.parts[name] = part
+	pg.last = now
+	g.held[name] = key
+	return true
+}
+
+// Ready ставит в очередь собранные группы. Группа по суффиксам без первой
+// части или из одного файла обрабатывается как обычные файлы. Группа,
+// не поместившаяся в очередь, остаётся до следующего сканирования.
+func (g *grouper) Ready(q *Queue, toggle bool, now time.Time) (deferred int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key, pg := range g.groups {
+		wait := now.Sub(pg.last) >= config.Group.Wait
+		if key == toggleGroupKey && !toggle {
+			wait = true
+		}
+		if !wait && !g.flush {
+			continue
+		}
+		for _, job := range pg.jobs(key) {
+			if !q.TryPush(job) {
+				deferred++
+				continue
+			}
+			delete(pg.parts, job.Name)
+			delete(g.held, job.Name)
+			for _, m := range job.Members {
+				delete(pg.parts, m)
+				delete(g.held, m)
+			}
+		}
+		if len(pg.parts) == 0 {
+			delete(g.groups, key)
+		}
+	}
+	return deferred
+}
+
+// jobs задания группы: одно на всю группу или по одному на файл, если
+// группа не сложилась
+func (pg *pendingGroup) jobs(key string) []Job {
+	names := make([]string, 0, len(pg.parts))
+	for name := range pg.parts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return pg.parts[names[i]] < pg.parts[names[j]] })
+
+	single := len(names) < 2 || (key != toggleGroupKey && pg.parts[names[0]] != 1)
+	if single {
+		if len(names) > 0 && key != toggleGroupKey {
+			slog.Warn(msg.T("group.incomplete"), "group", pg.name, "files", len(names))
+		}
+		jobs := make([]Job, len(names))
+		for i, name := range names {
+			jobs[i] = Job{Name: name, Path: filepath.Join(config.InputDir, name)}
+		}
+		return jobs
+	}
+	job := Job{Name: names[0], Path: filepath.Join(config.InputDir, names[0]), Group: pg.name}
+	for _, name := range names[1:] {
+		job.Members = append(job.Members, name)
+		job.Parts = append(job.Parts, filepath.Join(config.InputDir, name))
+	}
+	slog.Info(msg.T("group.merged"), "group", pg.name, "files", len(names))
+	return []Job{job}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fileOCR текст снимка по имени файла
+type fileOCR map[string]string
+
+func (o fileOCR) ExtractText(_ context.Context, path string) (string, error) {
+	return o[filepath.Base(path)], nil
+}
+
+func newTestGrouper() *grouper {
+	return &grouper{groups: make(map[string]*pendingGroup), held: make(map[string]string)}
+}
+
+// popJobs забирает из очереди всё, что в ней есть
+func popJobs(q *Queue) []Job {
+	var jobs []Job
+	for q.Len() > 0 {
+		jobs = append(jobs, <-q.jobs)
+	}
+	return jobs
+}
+
+// Части по суффиксам ждут group.wait после последней пришедшей: опоздавшая
+// вторая часть продлевает ожидание, а задание собирается по номерам частей,
+// а не по порядку прихода
+func TestGrouperSuffixWaitsForLatePart(t *testing.T) {
+	testEnv(t, "group:\n  suffix: true\n  wait: 30s\n")
+	g, q := newTestGrouper(), newQueue(4)
+	t0 := time.Now()
+
+	if !g.Hold("task-1.png", false, t0) || !g.Hold("task-3.png", false, t0) {
+		t.Fatal("части не придержаны")
+	}
+	if g.Hold("other.png", false, t0) {
+		t.Error("обычный файл придержан")
+	}
+	g.Ready(q, false, t0.Add(20*time.Second))
+	// Вторая часть пришла на 30 секунд позже первой
+	g.Hold("task-2.png", false, t0.Add(30*time.Second))
+	g.Ready(q, false, t0.Add(45*time.Second))
+	if q.Len() != 0 {
+		t.Fatalf("группа отправлена до конца ожидания: %+v", popJobs(q))
+	}
+
+	g.Ready(q, false, t0.Add(61*time.Second))
+	jobs := popJobs(q)
+	if len(jobs) != 1 {
+		t.Fatalf("заданий %d, want 1", len(jobs))
+	}
+	job := jobs[0]
+	if job.Name != "task-1.png" || job.Group != "task" || strings.Join(job.Members, ",") != "task-2.png,task-3.png" ||
+		job.Parts[0] != filepath.Join(config.InputDir, "task-2.png") {
+		t.Errorf("задание %+v", job)
+	}
+	for _, name := range []string{"task-1.png", "task-2.png", "task-3.png"} {
+		if !q.Has(name) {
+			t.Errorf("%s не отмечен занятым", name)
+		}
+	}
+	if len(g.groups) != 0 || len(g.held) != 0 {
+		t.Errorf("группа не снята: %v %v", g.groups, g.held)
+	}
+}
+
+// Без первой части или из одного файла группа не складывается: файлы
+// обрабатываются отдельно
+func TestGrouperIncomplete(t *testing.T) {
+	testEnv(t, "group:\n  suffix: true\n  wait: 30s\n")
+	g, q := newTestGrouper(), newQueue(4)
+	t0 := time.Now()
+	g.Hold("a-2.png", false, t0)
+	g.Hold("a-3.png", false, t0)
+	g.Hold("b-1.png", false, t0)
+	g.Ready(q, false, t0.Add(time.Minute))
+
+	jobs := popJobs(q)
+	if len(jobs) != 3 {
+		t.Fatalf("заданий %d, want 3: %+v", len(jobs), jobs)
+	}
+	for _, job := range jobs {
+		if job.Group != "" || len(job.Members) != 0 {
+			t.Errorf("неполная группа объединена: %+v", job)
+		}
+	}
+}
+
+// Без group.suffix имена с -1, -2 не группируются
+func TestGrouperSuffixOff(t *testing.T) {
+	testEnv(t, "")
+	if newTestGrouper().Hold("task-1.png", false, time.Now()) {
+		t.Error("файл придержан без group.suffix")
+	}
+}
+
+// Пока есть файл GROUP, снимки собираются в порядке прихода; удаление
+// GROUP отправляет группу, не дожидаясь group.wait
+func TestGrouperToggle(t *testing.T) {
+	testEnv(t, "group:\n  wait: 30s\n")
+	g, q := newTestGrouper(), newQueue(4)
+	t0 := time.Now()
+	g.Hold("b.png", true, t0)
+	g.Hold("a.png", true, t0.Add(time.Second))
+	g.Ready(q, true, t0.Add(2*time.Second))
+	if q.Len() != 0 {
+		t.Fatal("группа отправлена, пока есть GROUP")
+	}
+
+	g.Ready(q, false, t0.Add(3*time.Second))
+	jobs := popJobs(q)
+	if len(jobs) != 1 || jobs[0].Name != "b.png" || jobs[0].Group != "b" || strings.Join(jobs[0].Members, ",") != "a.png" {
+		t.Errorf("задания %+v", jobs)
+	}
+}
+
+// Группа, не поместившаяся в очередь, ждёт следующего сканирования
+func TestGrouperQueueFull(t *testing.T) {
+	testEnv(t, "group:\n  suffix: true\n  wait: 1s\n")
+	g, q := newTestGrouper(), newQueue(1)
+	q.TryPush(Job{Name: "busy.png"})
+	t0 := time.Now()
+	g.Hold("t-1.png", false, t0)
+	g.Hold("t-2.png", false, t0)
+	if deferred := g.Ready(q, false, t0.Add(time.Minute)); deferred != 1 {
+		t.Errorf("отложено %d, want 1", deferred)
+	}
+	popJobs(q)
+	if deferred := g.Ready(q, false, t0.Add(time.Minute)); deferred != 0 || q.Len() != 1 {
+		t.Errorf("отложено %d, в очереди %d", deferred, q.Len())
+	}
+}
+
+// Группа отвечается одним запросом к модели с текстом частей по порядку,
+// и каждая часть отмечена в состоянии как вошедшая в группу
+func TestGroupProcessedTogether(t *testing.T) {
+	testEnv(t, "group:\n  suffix: true\n")
+	groups.flush = true
+	pipeline.OCR = fileOCR{"task-1.png": "Дан массив чисел.", "task-2.png": "Найдите сумму подмассива."}
+	counter := &countingLLM{next: pipeline.LLM}
+	pipeline.LLM = counter
+	writeInput(t, "task-2.png", "task-1.png")
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	if len(counter.prompts) != 1 {
+		t.Fatalf("запросов к модели %d, want 1", len(counter.prompts))
+	}
+	prompt := counter.prompts[0]
+	if i, j := strings.Index(prompt, "Дан массив"), strings.Index(prompt, "Найдите сумму"); i < 0 || j < i {
+		t.Errorf("части не по порядку:\n%s", prompt)
+	}
+	out := filepath.Join(config.OutputDir, "task.md")
+	if _, err := os.Stat(out); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"task-1.png", "task-2.png"} {
+		fs, _ := state.Get(name)
+		if fs.Status != statusDone || fs.Group != "task" || fs.Output != out {
+			t.Errorf("%s: %s, группа %q, результат %q", name, fs.Status, fs.Group, fs.Output)
+		}
+	}
+}
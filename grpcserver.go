@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"hack_interview/internal/jobspb"
+	"hack_interview/internal/msg"
+)
+
+// watchPoll как часто WatchJob перечитывает состояние: события шины
+// медленному подписчику не доставляются, и опрос гарантирует, что итог
+// задания не будет пропущен
+var watchPoll = 2 * time.Second
+
+// startGRPCServer запускает gRPC-сервис заданий и останавливает его при
+// отмене ctx. Снимки попадают во входную директорию и обрабатываются
+// наблюдателем; сведения о заданиях берутся из общего состояния.
+func startGRPCServer(ctx context.Context) error {
+	srv, err := newGRPCServer(ctx)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", config.GRPC.Listen)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			slog.Error(msg.T("grpc.failed"), "error", err)
+		}
+	}()
+	slog.Info(msg.T("grpc.started"), "addr", config.GRPC.Listen)
+
+	go func() {
+		<-ctx.Done()
+		stopped := make(chan struct{})
+		go func() {
+			srv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			srv.Stop()
+		}
+	}()
+	return nil
+}
+
+// newGRPCServer сервер заданий с проверкой токена, пределом размера
+// сообщения и TLS из настроек grpc
+func newGRPCServer(ctx context.Context) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(grpcAuthUnary),
+		grpc.StreamInterceptor(grpcAuthStream),
+		// Запас на остальные поля SubmitImageRequest
+		grpc.MaxRecvMsgSize(int(config.GRPC.MaxImageSize) + 64<<10),
+	}
+	if config.GRPC.TLSCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(config.GRPC.TLSCert, config.GRPC.TLSKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	} else {
+		slog.Warn(msg.T("grpc.no-tls"), "addr", config.GRPC.Listen)
+	}
+
+	srv := grpc.NewServer(opts...)
+	jobspb.RegisterJobsServer(srv, &jobsServer{ctx: ctx})
+	return srv, nil
+}
+
+// grpcAuthorize сверяет токен из метаданных за постоянное время
+func grpcAuthorize(ctx context.Context) error {
+	want := []byte("Bearer " + config.GRPC.Token)
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, got := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(got), want) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "неверный или отсутствующий токен")
+}
+
+func grpcAuthUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := grpcAuthorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func grpcAuthStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := grpcAuthorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// jobsServer реализация jobspb.JobsServer
+type jobsServer struct {
+	jobspb.UnimplementedJobsServer
+	// ctx завершает потоки WatchJob при остановке
+	ctx context.Context
+}
+
+func (s *jobsServer) SubmitImage(ctx context.Context, req *jobspb.SubmitImageRequest) (*jobspb.SubmitImageResponse, error) {
+	id, err := saveUpload(req.GetImage(), config.GRPC.MaxImageSize)
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, errUploadType):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	slog.Info(msg.T("grpc.accepted"), "job", id)
+	return &jobspb.SubmitImageResponse{Id: id}, nil
+}
+
+func (s *jobsServer) GetJob(ctx context.Context, req *jobspb.GetJobRequest) (*jobspb.Job, error) {
+	job, ok := uploadJob(req.GetId())
+	if !ok {
+		return nil, status.Error(codes.NotFound, "задание не найдено")
+	}
+	return job, nil
+}
+
+// WatchJob отправляет состояние задания при смене статуса. Промежуточные
+// состояния схлопываются: клиенту приходит последнее, а не каждое событие,
+// поэтому медленный клиент не копит очередь.
+func (s *jobsServer) WatchJob(req *jobspb.WatchJobRequest, stream jobspb.Jobs_WatchJobServer) error {
+	id := req.GetId()
+	events, unsubscribe := bus.Subscribe(16)
+	defer unsubscribe()
+	poll := time.NewTicker(watchPoll)
+	defer poll.Stop()
+
+	last := ""
+	for {
+		job, ok := uploadJob(id)
+		if !ok {
+			return status.Error(codes.NotFound, "задание не найдено")
+		}
+		if job.Status != last {
+			if err := stream.Send(job); err != nil {
+				return err
+			}
+			last = job.Status
+		}
+		switch job.Status {
+		case statusDone, statusFailed, statusSkipped:
+			return nil
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return status.Error(codes.Unavailable, "сервис остановлен")
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-poll.C:
+		case e := <-events:
+			if !strings.HasPrefix(e.Name, uploadName(id)) {
+				continue
+			}
+		}
+		// Накопившиеся события всё равно сводятся к одному перечитыванию
+		for drained := false; !drained; {
+			select {
+			case <-events:
+			default:
+				drained = true
+			}
+		}
+	}
+}
+
+func (s *jobsServer) ListJobs(ctx context.Context, req *jobspb.ListJobsRequest) (*jobspb.ListJobsResponse, error) {
+	statuses := make(map[string]bool)
+	for _, st := range req.GetStatus() {
+		statuses[st] = true
+	}
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = apiDefaultLimit
+	}
+	limit = min(limit, apiMaxLimit)
+	offset := max(int(req.GetOffset()), 0)
+
+	refreshState()
+	var entries []listEntry
+	for _, e := range listEntries() {
+		if len(statuses) > 0 && !statuses[e.Status] || req.GetSession() != "" && e.Session != req.GetSession() {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].UpdatedAt.Equal(entries[j].UpdatedAt) {
+			return entries[i].UpdatedAt.After(entries[j].UpdatedAt)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	resp := &jobspb.ListJobsResponse{Total: int32(len(entries))}
+	if offset < len(entries) {
+		for _, e := range entries[offset:min(offset+limit, len(entries))] {
+			resp.Jobs = append(resp.Jobs, jobMessage(e, false))
+		}
+	}
+	return resp, nil
+}
+
+// uploadJob задание SubmitImage: запись из состояния или pending, пока
+// файл ждёт во входной директории
+func uploadJob(id string) (*jobspb.Job, bool) {
+	if id == "" {
+		return nil, false
+	}
+	name, fs, ok := findUpload(id)
+	if !ok {
+		if uploadPending(id) {
+			return &jobspb.Job{Id: id, Status: statusPending}, true
+		}
+		return nil, false
+	}
+	return jobMessage(listEntry{Name: name, FileState: fs}, true), true
+}
+
+// jobMessage запись состояния в сообщении Job; withText добавляет вопрос
+// и ответ
+func jobMessage(e listEntry, withText bool) *jobspb.Job {
+	job := &jobspb.Job{
+		Name:      e.Name,
+		Status:    e.Status,
+		Attempts:  int32(e.Attempts),
+		LastError: e.LastError,
+		Output:    e.Output,
+		TimingsMs: e.TimingsMs,
+		TokensIn:  int32(e.TokensIn),
+		TokensOut: int32(e.TokensOut),
+		Session:   e.Session,
+	}
+	if id, ok := strings.CutPrefix(e.Name, uploadName("")); ok {
+		job.Id = strings.TrimSuffix(id, filepath.Ext(id))
+	}
+	if !e.UpdatedAt.IsZero() {
+		job.UpdatedAtUnixMs = e.UpdatedAt.UnixMilli()
+	}
+	if withText {
+		job.Question, _ = uiQuestion(e.FileState)
+		if e.Status == statusDone && e.Output != "" {
+			if data, err := os.ReadFile(e.Output); err == nil {
+				job.Answer = string(data)
+			}
+		}
+	}
+	return job
+}
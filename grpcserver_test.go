@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"hack_interview/internal/jobspb"
+)
+
+// pngData начало PNG: тип загрузки определяется по нему
+const pngData = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+// grpcEnv сервис заданий с токеном secret и пределом изображения 1 КиБ на
+// соединении в памяти
+func grpcEnv(t *testing.T) jobspb.JobsClient {
+	t.Helper()
+	testEnv(t, "grpc:\n  token: secret\n  maxImageSize: 1024\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	srv, err := newGRPCServer(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		srv.Stop()
+	})
+	return jobspb.NewJobsClient(conn)
+}
+
+// withToken контекст запроса с заголовком authorization
+func withToken(token string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	return ctx, cancel
+}
+
+// Без токена или с чужим токеном запросы и потоки отклоняются
+func TestGRPCRequiresToken(t *testing.T) {
+	client := grpcEnv(t)
+	for _, token := range []string{"", "wrong", "secret "} {
+		ctx, cancel := withToken(token)
+		_, err := client.SubmitImage(ctx, &jobspb.SubmitImageRequest{Image: []byte(pngData)})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("SubmitImage с токеном %q: %v", token, err)
+		}
+		stream, err := client.WatchJob(ctx, &jobspb.WatchJobRequest{Id: "x"})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("WatchJob с токеном %q: %v", token, err)
+		}
+		cancel()
+	}
+	matches, _ := filepath.Glob(filepath.Join(config.InputDir, "upload-*"))
+	if len(matches) != 0 {
+		t.Errorf("без токена сохранены %v", matches)
+	}
+}
+
+// Изображение больше grpc.maxImageSize отклоняется до сохранения, а не
+// PNG, JPEG и GIF — как неверный аргумент
+func TestGRPCSubmitLimits(t *testing.T) {
+	client := grpcEnv(t)
+	ctx, cancel := withToken("secret")
+	defer cancel()
+	for _, tc := range []struct {
+		name  string
+		image []byte
+		code  codes.Code
+	}{
+		// Больше предела, но в пределах сообщения: отказ saveUpload
+		{"больше предела", []byte(pngData + strings.Repeat("x", 2048)), codes.ResourceExhausted},
+		// Больше самого сообщения: отказ gRPC
+		{"больше сообщения", []byte(pngData + strings.Repeat("x", 128<<10)), codes.ResourceExhausted},
+		{"не изображение", []byte("просто текст"), codes.InvalidArgument},
+	} {
+		if _, err := client.SubmitImage(ctx, &jobspb.SubmitImageRequest{Image: tc.image}); status.Code(err) != tc.code {
+			t.Errorf("%s: %v, want %v", tc.name, err, tc.code)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(config.InputDir, "upload-*"))
+	if len(matches) != 0 {
+		t.Errorf("сохранены %v", matches)
+	}
+}
+
+// WatchJob присылает pending, затем done и завершает поток, даже если
+// событие об итоге до него не дошло: итог находит опрос состояния
+func TestGRPCWatchJobPolls(t *testing.T) {
+	old := watchPoll
+	watchPoll = 20 * time.Millisecond
+	t.Cleanup(func() { watchPoll = old })
+	client := grpcEnv(t)
+	ctx, cancel := withToken("secret")
+	defer cancel()
+
+	resp, err := client.SubmitImage(ctx, &jobspb.SubmitImageRequest{Image: []byte(pngData)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.WatchJob(ctx, &jobspb.WatchJobRequest{Id: resp.GetId()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := stream.Recv()
+	if err != nil || job.GetStatus() != statusPending || job.GetId() != resp.GetId() {
+		t.Fatalf("первое сообщение %v, %v", job, err)
+	}
+
+	// Итог записывается в состояние без события в шине, как если бы
+	// медленный подписчик его потерял
+	out := filepath.Join(config.OutputDir, "answer.md")
+	if err := state.Set(uploadName(resp.GetId())+".png", FileState{Status: statusDone, Output: out}); err != nil {
+		t.Fatal(err)
+	}
+	if job, err = stream.Recv(); err != nil || job.GetStatus() != statusDone || job.GetId() != resp.GetId() {
+		t.Fatalf("второе сообщение %v, %v", job, err)
+	}
+	if job, err := stream.Recv(); err != io.EOF {
+		t.Errorf("поток не завершён: %v, %v", job, err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lastTick время последнего прохода наблюдателя (UnixNano)
+var lastTick atomic.Int64
+
+func markTick() {
+	lastTick.Store(time.Now().UnixNano())
+}
+
+// providerWindow сколько последних обращений к API учитывается в /readyz
+const providerWindow = 20
+
+// providerHealth скользящее окно результатов обращений к API
+type providerHealth struct {
+	mu      sync.Mutex
+	results []bool
+	last    time.Time
+}
+
+var providers = &providerHealth{}
+
+func (p *providerHealth) Record(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = append(p.results, err == nil)
+	if len(p.results) > providerWindow {
+		p.results = p.results[len(p.results)-providerWindow:]
+	}
+	p.last = time.Now()
+}
+
+// ErrorRate доля ошибок в окне и число учтённых обращений
+func (p *providerHealth) ErrorRate() (float64, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.results) == 0 {
+		return 0, 0
+	}
+	failed := 0
+	for _, ok := range p.results {
+		if !ok {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(p.results)), len(p.results)
+}
+
+type healthCheck struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+func writeHealth(w http.ResponseWriter, checks []healthCheck) {
+	resp := healthResponse{Status: "ok", Checks: checks}
+	code := http.StatusOK
+	for _, c := range checks {
+		if !c.OK {
+			resp.Status = "fail"
+			code = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, resp)
+}
+
+// handleHealthz процесс жив и наблюдатель недавно делал проход
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	tick := time.Unix(0, lastTick.Load())
+	c := healthCheck{Name: "watcher", OK: time.Since(tick) <= config.Server.HealthStaleAfter}
+	if !c.OK {
+		c.Error = "наблюдатель не делал проход с " + tick.Format(time.RFC3339)
+	}
+	writeHealth(w, []healthCheck{c})
+}
+
+// handleReadyz конфигурация загружена, входная директория читается, доля
+// ошибок последних обращений к API не превышает порог, цепи провайдеров
+// не разомкнуты
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := []healthCheck{{Name: "config", OK: true}}
+
+	in := healthCheck{Name: "inputDir", OK: true}
+	if f, err := os.Open(config.InputDir); err != nil {
+		in.OK, in.Error = false, err.Error()
+	} else {
+		f.Close()
+	}
+	checks = append(checks, in)
+
+	prov := healthCheck{Name: "providers", OK: true}
+	if rate, n := providers.ErrorRate(); n > 0 && rate > config.Server.MaxErrorRate {
+		prov.OK = false
+		prov.Error = "доля ошибок API превышает порог"
+	}
+	checks = append(checks, prov)
+
+	for _, b := range collectBreakers() {
+		if b.State == "open" {
+			checks = append(checks, healthCheck{Name: "breaker:" + b.Provider, Error: "цепь провайдера разомкнута"})
+		}
+	}
+
+	writeHealth(w, checks)
+}
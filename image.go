@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/imageprep"
+	"hack_interview/internal/msg"
+)
+
+// Подготовка снимка перед OCR: HEIC переводится в JPEG, GIF — в PNG по
+// первому кадру, повёрнутый JPEG приводится к нормальному виду по EXIF,
+// затем при image.trim срезаются однотонные поля, при image.invert тёмный
+// снимок инвертируется, а слишком высокий распознаётся полосами. Сами
+// преобразования — в internal/imageprep.
+
+// extractImage готовит снимок path и распознаёт его текст
+func (m mediaProvider) extractImage(ctx context.Context, path string) (string, error) {
+	file := path
+	if imageprep.IsHEIC(path) {
+		jpeg, cleanup, err := imageprep.HEICToJPEG(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("перевод HEIC в JPEG: %w", err)
+		}
+		defer cleanup()
+		path = jpeg
+	}
+	if imageprep.IsGIF(path) {
+		png, frames, cleanup, err := imageprep.GIFToPNG(path)
+		if err != nil {
+			return "", fmt.Errorf("перевод GIF в PNG: %w", err)
+		}
+		defer cleanup()
+		if frames > 1 {
+			slog.Debug(msg.T("image.gif-first-frame"), "file", file, "frames", frames)
+		}
+		path = png
+	}
+	oriented, cleanup, err := imageprep.Orient(path)
+	if err != nil {
+		return "", fmt.Errorf("поворот снимка по EXIF: %w", err)
+	}
+	defer cleanup()
+	if m.trim != nil {
+		// Без обрезки OCR всё равно справится: ошибка только в лог
+		if trimmed, cleanup, err := imageprep.Trim(oriented, *m.trim); err != nil {
+			slog.Warn(msg.T("image.trim-failed"), "file", file, "error", err)
+		} else {
+			defer cleanup()
+			oriented = trimmed
+		}
+	}
+	inverted, cleanup := m.invertDark(ctx, file, oriented)
+	defer cleanup()
+	text, err := m.extractStrips(ctx, file, inverted)
+	if err == nil {
+		timingsFrom(ctx).UseProvider(stageOCR, m.imageName, "")
+	}
+	return text, err
+}
+
+// invertDark инвертирует снимок prepared файла file по режиму image.invert
+// для его директории и записывает решение в лог и отчёт. Как и при
+// обрезке, ошибка только пишется в лог, а OCR получает снимок как есть.
+func (m mediaProvider) invertDark(ctx context.Context, file, prepared string) (string, func()) {
+	mode := m.invert.Mode
+	if dirMode, ok := matchDirs(file, m.invert.Dirs); ok {
+		mode = dirMode
+	}
+	if mode == appconfig.InvertOff {
+		return prepared, func() {}
+	}
+	out, d, cleanup, err := imageprep.Invert(prepared, imageprep.InvertOptions{
+		Always:    mode == appconfig.InvertAlways,
+		Threshold: m.invert.Threshold,
+	})
+	if err != nil {
+		slog.Warn(msg.T("image.invert-failed"), "file", file, "error", err)
+		return prepared, func() {}
+	}
+	attrs := []any{"file", file, "mode", mode, "luminance", d.Luminance, "threshold", m.invert.Threshold}
+	if d.Inverted {
+		slog.Info(msg.T("image.inverted"), attrs...)
+	} else {
+		slog.Debug(msg.T("image.not-inverted"), attrs...)
+	}
+	timingsFrom(ctx).AddInvert(ReportInvert{
+		File:      file,
+		Mode:      mode,
+		Threshold: m.invert.Threshold,
+		Luminance: d.Luminance,
+		Inverted:  d.Inverted,
+	})
+	return out, cleanup
+}
+
+// extractStrips распознаёт снимок prepared, при m.split — по полосам, и
+// склеивает их текст. Полоса с ошибкой пропускается с предупреждением;
+// ошибка возвращается, только если не распознана ни одна.
+func (m mediaProvider) extractStrips(ctx context.Context, path, prepared string) (string, error) {
+	if m.split == nil {
+		return m.image.ExtractText(ctx, prepared)
+	}
+	strips, cleanup, err := imageprep.Split(prepared, *m.split)
+	if err != nil {
+		slog.Warn(msg.T("image.split-failed"), "file", path, "error", err)
+		return m.image.ExtractText(ctx, prepared)
+	}
+	defer cleanup()
+	if len(strips) == 1 {
+		return m.image.ExtractText(ctx, strips[0])
+	}
+	slog.Debug(msg.T("image.split"), "file", path, "strips", len(strips))
+
+	var text string
+	var firstErr error
+	recognized := 0
+	joined := false // предыдущая полоса распознана и склеивается с текущей
+	for i, strip := range strips {
+		t, err := m.image.ExtractText(ctx, strip)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			slog.Warn(msg.T("image.strip-failed"), "file", path, "strip", i+1, "strips", len(strips), "error", err)
+			joined = false
+			continue
+		}
+		switch {
+		case joined:
+			text = imageprep.Stitch(text, t)
+		case text != "":
+			text = strings.TrimSpace(text) + "\n\n" + strings.TrimSpace(t)
+		default:
+			text = t
+		}
+		joined = true
+		recognized++
+	}
+	if recognized == 0 {
+		return "", firstErr
+	}
+	return text, nil
+}
+
+// splitOptions параметры нарезки высоких снимков или nil, если она
+// выключена
+func splitOptions(cfg appconfig.ImageSplitConfig) *imageprep.SplitOptions {
+	if cfg.MaxHeight <= 0 {
+		return nil
+	}
+	return &imageprep.SplitOptions{MaxHeight: cfg.MaxHeight, Overlap: cfg.Overlap, MaxStrips: cfg.MaxStrips}
+}
+
+// trimOptions параметры обрезки полей или nil, если она выключена
+func trimOptions(cfg appconfig.TrimConfig) *imageprep.TrimOptions {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &imageprep.TrimOptions{Tolerance: cfg.Tolerance, MinSize: cfg.MinSize}
+}
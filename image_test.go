@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/imageprep"
+	"hack_interview/internal/msg"
+)
+
+// stripOCR текст полосы по её номеру в имени tall-<n>.png; номера из fail
+// не распознаются
+type stripOCR struct {
+	text map[string]string
+	fail map[string]bool
+}
+
+func (o stripOCR) ExtractText(_ context.Context, path string) (string, error) {
+	n := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "tall-"), ".png")
+	if o.fail[n] {
+		return "", errors.New("E500: сбой полосы " + n)
+	}
+	return o.text[n], nil
+}
+
+// writeTall сохраняет пустой PNG 20×1000
+func writeTall(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 1000))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tall.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Три полосы: соседние склеиваются без повтора перекрытия, а после
+// пропущенной полосы текст продолжается с новой строки
+func TestExtractStripsDegrades(t *testing.T) {
+	path := writeTall(t)
+	text := map[string]string{
+		"1": "Дан массив чисел nums\nНайдите два числа",
+		"2": "Найдите два числа\nсумма которых равна k",
+		"3": "Верните их индексы",
+	}
+	for _, tc := range []struct {
+		name string
+		fail string
+		want string
+	}{
+		{"все полосы", "", "Дан массив чисел nums\nНайдите два числа\nсумма которых равна k\nВерните их индексы"},
+		{"сбой средней", "2", "Дан массив чисел nums\nНайдите два числа\n\nВерните их индексы"},
+		{"сбой первой", "1", "Найдите два числа\nсумма которых равна k\nВерните их индексы"},
+	} {
+		log := captureLog(t)
+		m := mediaProvider{
+			image: stripOCR{text: text, fail: map[string]bool{tc.fail: true}},
+			split: &imageprep.SplitOptions{MaxHeight: 400, Overlap: 50},
+		}
+		got, err := m.extractImage(context.Background(), path)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: %q, %v\nwant %q", tc.name, got, err, tc.want)
+		}
+		if warned := strings.Contains(log.String(), "strip="+tc.fail+" strips=3"); warned != (tc.fail != "") {
+			t.Errorf("%s: предупреждение о полосе:\n%s", tc.name, log)
+		}
+	}
+
+	// Ни одной распознанной полосы — ошибка первой
+	m := mediaProvider{
+		image: stripOCR{fail: map[string]bool{"1": true, "2": true, "3": true}},
+		split: &imageprep.SplitOptions{MaxHeight: 400, Overlap: 50},
+	}
+	if _, err := m.extractImage(context.Background(), path); err == nil || !strings.Contains(err.Error(), "полосы 1") {
+		t.Errorf("все полосы со сбоем: %v", err)
+	}
+}
+
+// Число полос ограничено split.maxStrips
+func TestExtractStripsCap(t *testing.T) {
+	path := writeTall(t)
+	var seen []string
+	m := mediaProvider{
+		image: ocrFunc(func(p string) (string, error) {
+			seen = append(seen, filepath.Base(p))
+			return "", nil
+		}),
+		split: &imageprep.SplitOptions{MaxHeight: 100, Overlap: 10, MaxStrips: 4},
+	}
+	if _, err := m.extractImage(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 4 {
+		t.Errorf("распознаны полосы %q, want 4", seen)
+	}
+}
+
+// ocrFunc OCR из функции
+type ocrFunc func(path string) (string, error)
+
+func (f ocrFunc) ExtractText(_ context.Context, path string) (string, error) {
+	return f(path)
+}
+
+// Анимированный GIF распознаётся по первому кадру с отметкой числа кадров
+// в логе, а битый завершается обычной ошибкой файла
+func TestGIFInputs(t *testing.T) {
+	animated, err := os.ReadFile(filepath.Join("internal", "imageprep", "testdata", "animated.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEnv(t, "")
+	buf := captureLog(t)
+	for name, data := range map[string][]byte{"anim.gif": animated, "broken.gif": animated[:len(animated)/2]} {
+		if err := os.WriteFile(filepath.Join(config.InputDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pipeline.OCR = mediaProvider{image: fileOCR{"anim.png": "Что такое горутина?"}}
+
+	ctx, stop := context.WithCancel(context.Background())
+	q := newQueue(config.QueueSize)
+	scanDirectory(ctx, q)
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(ctx)
+	stop()
+	wg.Wait()
+
+	if fs, _ := state.Get("anim.gif"); fs.Status != statusDone {
+		t.Errorf("anim.gif: %s %s", fs.Status, fs.LastError)
+	}
+	if !strings.Contains(buf.String(), msg.T("image.gif-first-frame")) || !strings.Contains(buf.String(), "frames=3") {
+		t.Errorf("в логе нет числа кадров:\n%s", buf)
+	}
+	// Как и любая ошибка файла — повтор по расписанию, пока не исчерпаны попытки
+	if fs, _ := state.Get("broken.gif"); fs.Status != statusRetry || fs.Attempts != 1 || !strings.Contains(fs.LastError, "перевод GIF в PNG") {
+		t.Errorf("broken.gif: %s %s", fs.Status, fs.LastError)
+	}
+}
+
+// Режим инверсии берётся из image.invert.dirs для директории файла, а
+// решение с яркостью и порогом пишется в лог и замеры для отчёта
+func TestInvertDark(t *testing.T) {
+	fixtures := map[string][]byte{}
+	for _, name := range []string{"light.png", "dark.png"} {
+		data, err := os.ReadFile(filepath.Join("internal", "imageprep", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[name] = data
+	}
+	testEnv(t, "")
+	m := mediaProvider{invert: appconfig.InvertConfig{
+		Mode:      appconfig.InvertAuto,
+		Threshold: 100,
+		Dirs:      map[string]string{"scans/**": appconfig.InvertAlways, "ide/**": appconfig.InvertOff},
+	}}
+	for _, tc := range []struct {
+		file     string
+		fixture  string
+		inverted bool
+		recorded bool
+	}{
+		{"light.png", "light.png", false, true},
+		{"dark.png", "dark.png", true, true},
+		{"scans/light.png", "light.png", true, true},
+		{"ide/dark.png", "dark.png", false, false},
+	} {
+		file := filepath.Join(config.InputDir, tc.file)
+		os.MkdirAll(filepath.Dir(file), 0755)
+		if err := os.WriteFile(file, fixtures[tc.fixture], 0644); err != nil {
+			t.Fatal(err)
+		}
+		log := captureLog(t)
+		timings := newStageTimings()
+		out, cleanup := m.invertDark(withTimings(context.Background(), timings), file, file)
+		if (out != file) != tc.inverted {
+			t.Errorf("%s: инверсия %v, want %v", tc.file, out != file, tc.inverted)
+		}
+		cleanup()
+		if !tc.recorded {
+			if len(timings.inverts) != 0 || log.Len() != 0 {
+				t.Errorf("%s: режим off записан: %+v\n%s", tc.file, timings.inverts, log)
+			}
+			continue
+		}
+		if len(timings.inverts) != 1 || timings.inverts[0].Inverted != tc.inverted || timings.inverts[0].Threshold != 100 {
+			t.Errorf("%s: в отчёте %+v", tc.file, timings.inverts)
+		}
+		want := msg.T("image.not-inverted")
+		if tc.inverted {
+			want = msg.T("image.inverted")
+		}
+		if !strings.Contains(log.String(), want) || !strings.Contains(log.String(), "threshold=100") {
+			t.Errorf("%s: лог:\n%s", tc.file, log)
+		}
+	}
+}
+
+// Решение об инверсии попадает в отчёт рядом с ответом
+func TestInvertReport(t *testing.T) {
+	dark, err := os.ReadFile(filepath.Join("internal", "imageprep", "testdata", "dark.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEnv(t, "image:\n  invert:\n    mode: auto\n")
+	if err := os.WriteFile(filepath.Join(config.InputDir, "dark.png"), dark, 0644); err != nil {
+		t.Fatal(err)
+	}
+	pipeline.OCR = mediaProvider{image: fileOCR{"dark.png": "Что такое горутина?"}, invert: config.Image.Invert}
+
+	ctx, stop := context.WithCancel(context.Background())
+	q := newQueue(config.QueueSize)
+	scanDirectory(ctx, q)
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(ctx)
+	stop()
+	wg.Wait()
+
+	fs, _ := state.Get("dark.png")
+	r, err := readReport(jobReportPath(Job{Name: "dark.png", Path: filepath.Join(config.InputDir, "dark.png")}, fs.Output))
+	if err != nil {
+		t.Fatalf("%s %s: %v", fs.Status, fs.LastError, err)
+	}
+	if len(r.Inverts) != 1 || !r.Inverts[0].Inverted || r.Inverts[0].Luminance != 30 || r.Inverts[0].Mode != appconfig.InvertAuto {
+		t.Errorf("в отчёте %+v", r.Inverts)
+	}
+}
+
+// ocrCall что получил OCR: путь и размер снимка
+type ocrCall struct {
+	path          string
+	width, height int
+}
+
+// OCR получает снимок, повёрнутый по EXIF, а снимок без поворота — как
+// есть, без перекодирования
+func TestOrientBeforeOCR(t *testing.T) {
+	fixtures := map[string][]byte{}
+	for _, name := range []string{"orient-1.jpg", "orient-6.jpg"} {
+		data, err := os.ReadFile(filepath.Join("internal", "imageprep", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures[name] = data
+	}
+	testEnv(t, "")
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join(config.InputDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	calls := map[string]ocrCall{}
+	pipeline.OCR = mediaProvider{image: ocrFunc(func(path string) (string, error) {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		cfg, _, err := image.DecodeConfig(f)
+		if err != nil {
+			return "", err
+		}
+		calls[filepath.Base(path)] = ocrCall{path, cfg.Width, cfg.Height}
+		return "Что такое горутина?", nil
+	})}
+
+	ctx, stop := context.WithCancel(context.Background())
+	q := newQueue(config.QueueSize)
+	scanDirectory(ctx, q)
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(ctx)
+	stop()
+	wg.Wait()
+
+	if c := calls["orient-6.jpg"]; c.width != 20 || c.height != 40 || absPath(c.path) == absPath(filepath.Join(config.InputDir, "orient-6.jpg")) {
+		t.Errorf("orientation 6: OCR получил %+v, want повёрнутую копию 20x40", c)
+	}
+	if c := calls["orient-1.jpg"]; c.width != 40 || c.height != 20 || absPath(c.path) != absPath(filepath.Join(config.InputDir, "orient-1.jpg")) {
+		t.Errorf("orientation 1: OCR получил %+v, want исходный файл 40x20", c)
+	}
+}
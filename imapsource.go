@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+
+	"hack_interview/internal/msg"
+)
+
+// imapAttachmentTypes поддерживаемые типы вложений
+var imapAttachmentTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+// imapSource опрашивает непрочитанные письма, извлекает вложения и ставит
+// их в очередь. Письмо помечается прочитанным (или переносится) только
+// после того, как по всем его вложениям записан ответ.
+type imapSource struct {
+	cfg IMAPConfig
+
+	mu sync.Mutex
+	// remaining число необработанных вложений по UID письма
+	remaining map[uint32]int
+	// failed письма, у которых хотя бы одно вложение не обработано
+	failed    map[uint32]bool
+	completed []uint32
+	// skipped письма без подходящих вложений, чтобы не скачивать их снова
+	skipped map[uint32]bool
+}
+
+func newIMAPSource() *imapSource {
+	return &imapSource{
+		cfg:       config.IMAP,
+		remaining: make(map[uint32]int),
+		failed:    make(map[uint32]bool),
+		skipped:   make(map[uint32]bool),
+	}
+}
+
+// Run держит соединение и переподключается с нарастающей задержкой
+func (s *imapSource) Run(ctx context.Context, q *Queue) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := s.session(ctx, q)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn(msg.T("imap.lost"), "addr", s.cfg.Addr, "error", err, "retryIn", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 5*time.Minute {
+			backoff = 5 * time.Minute
+		}
+	}
+}
+
+func (s *imapSource) session(ctx context.Context, q *Queue) error {
+	host := s.cfg.Addr
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	c, err := client.DialTLS(s.cfg.Addr, &tls.Config{ServerName: host})
+	if err != nil {
+		return err
+	}
+	defer c.Logout()
+
+	if err := c.Login(s.cfg.Username, s.cfg.Password); err != nil {
+		return err
+	}
+	if _, err := c.Select(s.cfg.Folder, false); err != nil {
+		return err
+	}
+	slog.Info(msg.T("imap.watching"), "addr", s.cfg.Addr, "folder", s.cfg.Folder)
+
+	for {
+		if err := s.flushCompleted(c); err != nil {
+			return err
+		}
+		if err := s.poll(ctx, c, q); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.LoggedOut():
+			return fmt.Errorf("сервер закрыл соединение")
+		case <-time.After(s.cfg.Interval):
+		}
+	}
+}
+
+func (s *imapSource) poll(ctx context.Context, c *client.Client, q *Queue) error {
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	if s.cfg.From != "" {
+		criteria.Header.Add("From", s.cfg.From)
+	}
+	if s.cfg.Subject != "" {
+		criteria.Header.Add("Subject", s.cfg.Subject)
+	}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return err
+	}
+
+	seqset := new(imap.SeqSet)
+	s.mu.Lock()
+	for _, uid := range uids {
+		if !s.skipped[uid] && s.remaining[uid] == 0 {
+			seqset.AddNum(uid)
+		}
+	}
+	s.mu.Unlock()
+	if seqset.Empty() {
+		return nil
+	}
+
+	// PEEK не ставит флаг \Seen при чтении письма
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.UidFetch(seqset, []imap.FetchItem{section.FetchItem(), imap.FetchUid}, messages)
+	}()
+
+	for msg := range messages {
+		body := msg.GetBody(section)
+		if body == nil {
+			continue
+		}
+		s.handleMessage(msg.Uid, body, q)
+	}
+	return <-done
+}
+
+// handleMessage извлекает вложения письма во временную директорию
+func (s *imapSource) handleMessage(uid uint32, body io.Reader, q *Queue) {
+	mr, err := mail.CreateReader(body)
+	if err != nil {
+		slog.Error(msg.T("imap.parse-failed"), "uid", uid, "error", err)
+		return
+	}
+
+	var jobs []Job
+	for i := 1; ; i++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			slog.Error(msg.T("imap.parse-failed"), "uid", uid, "error", err)
+			break
+		}
+
+		var ct, filename string
+		switch h := part.Header.(type) {
+		case *mail.AttachmentHeader:
+			ct, _, _ = h.ContentType()
+			filename, _ = h.Filename()
+		case *mail.InlineHeader:
+			ct, _, _ = h.ContentType()
+		}
+		ext, ok := imapAttachmentTypes[ct]
+		if !ok {
+			continue
+		}
+		if filename == "" {
+			filename = fmt.Sprintf("attachment-%d%s", i, ext)
+		}
+		filename = filepath.Base(filename)
+
+		local, err := s.saveAttachment(uid, filename, part.Body)
+		if err != nil {
+			slog.Warn(msg.T("imap.attachment-skipped"), "uid", uid, "attachment", filename, "error", err)
+			continue
+		}
+		name := fmt.Sprintf("imap://%s/%d/%s", s.cfg.Folder, uid, filename)
+		jobs = append(jobs, Job{Name: name, Path: local, Temp: true, OnDone: s.onDone(uid)})
+	}
+
+	if len(jobs) == 0 {
+		slog.Debug(msg.T("imap.message-skipped"), "uid", uid)
+		s.mu.Lock()
+		s.skipped[uid] = true
+		s.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	queued := 0
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range jobs {
+		if fs, ok := state.Get(job.Name); ok && fs.Status == statusDone {
+			// Ответ уже записан в прошлый раз, письмо не успели отметить
+			os.RemoveAll(filepath.Dir(job.Path))
+			continue
+		}
+		if !state.Eligible(job.Name, now) || !q.TryPush(job) {
+			// Письмо останется непрочитанным и будет разобрано снова
+			os.RemoveAll(filepath.Dir(job.Path))
+			s.failed[uid] = true
+			continue
+		}
+		s.remaining[uid]++
+		queued++
+	}
+	if queued == 0 {
+		if !s.failed[uid] {
+			s.completed = append(s.completed, uid)
+		}
+		delete(s.failed, uid)
+	}
+}
+
+func (s *imapSource) saveAttachment(uid uint32, filename string, r io.Reader) (string, error) {
+	dir, err := os.MkdirTemp("", fmt.Sprintf("hack_interview-imap-%d-*", uid))
+	if err != nil {
+		return "", err
+	}
+	local := filepath.Join(dir, filename)
+	f, err := os.Create(local)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, s.cfg.MaxAttachmentSize+1))
+	f.Close()
+	if err == nil && n > s.cfg.MaxAttachmentSize {
+		err = fmt.Errorf("размер больше %d байт", s.cfg.MaxAttachmentSize)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return local, nil
+}
+
+// onDone учитывает завершение вложения; письмо считается обработанным,
+// когда по всем вложениям записан ответ
+func (s *imapSource) onDone(uid uint32) func(FileState) {
+	return func(fs FileState) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.remaining[uid]--
+		if fs.Status != statusDone {
+			s.failed[uid] = true
+		}
+		if s.remaining[uid] > 0 {
+			return
+		}
+		delete(s.remaining, uid)
+		if s.failed[uid] {
+			// Письмо остаётся непрочитанным; повторная попытка начнётся
+			// с нового разбора
+			delete(s.failed, uid)
+			return
+		}
+		s.completed = append(s.completed, uid)
+	}
+}
+
+// flushCompleted помечает обработанные письма прочитанными или переносит их
+func (s *imapSource) flushCompleted(c *client.Client) error {
+	s.mu.Lock()
+	uids := s.completed
+	s.completed = nil
+	s.mu.Unlock()
+	if len(uids) == 0 {
+		return nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+	var err error
+	if s.cfg.ProcessedFolder != "" {
+		err = c.UidMove(seqset, s.cfg.ProcessedFolder)
+	} else {
+		err = c.UidStore(seqset, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil)
+	}
+	if err != nil {
+		// Вернуть письма, чтобы отметить их после переподключения
+		s.mu.Lock()
+		s.completed = append(s.completed, uids...)
+		s.mu.Unlock()
+	}
+	return err
+}
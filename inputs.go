@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeInputFile кладёт данные во входную директорию под именем name.
+// Запись идёт через временный файл без поддерживаемого расширения, чтобы
+// наблюдатель не взял недописанное изображение.
+func writeInputFile(name string, data []byte) (string, error) {
+	dest := filepath.Join(config.InputDir, name)
+
+	tmp, err := os.CreateTemp(config.InputDir, ".incoming-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
@@ -0,0 +1,210 @@
+// Package breaker размыкает цепь к провайдеру после серии ошибок подряд,
+// чтобы при отказе API файлы не проходили каждый весь цикл повторов.
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State состояние цепи
+type State int
+
+const (
+	// Closed запросы проходят, ошибки подряд считаются
+	Closed State = iota
+	// Open запросы не проходят до конца паузы
+	Open
+	// HalfOpen пауза истекла: проходит один пробный запрос, его итог
+	// замыкает или снова размыкает цепь
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Outcome итог запроса для цепи
+type Outcome int
+
+const (
+	// Success провайдер ответил; сбрасывает счётчик ошибок
+	Success Outcome = iota
+	// Failure ошибка, которую имеет смысл повторять: сеть, 5xx, 429,
+	// таймаут
+	Failure
+	// Ignored запрос отменён и о провайдере ничего не говорит
+	Ignored
+)
+
+// Breaker цепь одного провайдера; безопасна для одновременного
+// использования обработчиками
+type Breaker struct {
+	// Name имя провайдера для OnChange
+	Name string
+	// Threshold число ошибок подряд, размыкающее цепь
+	Threshold int
+	// Cooldown пауза разомкнутой цепи перед пробным запросом
+	Cooldown time.Duration
+	// OnChange вызывается при смене состояния вне блокировки
+	OnChange func(name string, from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// probing пробный запрос полуоткрытой цепи ещё выполняется
+	probing bool
+	// changed закрывается и заменяется при каждой смене состояния и
+	// завершении пробного запроса, будя ожидающих в Wait
+	changed chan struct{}
+}
+
+// Allow разрешает запрос и возвращает функцию, которой вызывающий обязан
+// сообщить его итог; nil — запрос не разрешён. В полуоткрытой цепи
+// разрешение получает только один пробный запрос.
+func (b *Breaker) Allow() func(Outcome) {
+	b.mu.Lock()
+	done, from, to := b.allowLocked()
+	b.mu.Unlock()
+	b.notify(from, to)
+	return done
+}
+
+func (b *Breaker) allowLocked() (done func(Outcome), from, to State) {
+	from = b.state
+	switch b.state {
+	case Closed:
+		return b.done, from, from
+	case Open:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return nil, from, from
+		}
+		b.setLocked(HalfOpen)
+	}
+	if b.probing {
+		return nil, from, b.state
+	}
+	b.probing = true
+	return b.probeDone, from, b.state
+}
+
+// done итог обычного запроса
+func (b *Breaker) done(outcome Outcome) {
+	b.mu.Lock()
+	from := b.state
+	switch outcome {
+	case Success:
+		b.failures = 0
+	case Failure:
+		b.failures++
+		if b.state == Closed && b.Threshold > 0 && b.failures >= b.Threshold {
+			b.openedAt = time.Now()
+			b.setLocked(Open)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// probeDone итог пробного запроса: он замыкает или снова размыкает цепь
+func (b *Breaker) probeDone(outcome Outcome) {
+	b.mu.Lock()
+	from := b.state
+	b.probing = false
+	switch outcome {
+	case Success:
+		b.failures = 0
+		b.setLocked(Closed)
+	case Failure:
+		b.openedAt = time.Now()
+		b.setLocked(Open)
+	default:
+		// Проба не удалась по сторонней причине: пусть пробует другой
+		b.wakeLocked()
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// Wait ждёт разрешения, как Allow. Возвращает ошибку ctx, если он
+// отменён раньше.
+func (b *Breaker) Wait(ctx context.Context) (func(Outcome), error) {
+	for {
+		b.mu.Lock()
+		done, from, to := b.allowLocked()
+		var changed chan struct{}
+		var timer <-chan time.Time
+		if done == nil {
+			changed = b.changedLocked()
+			if b.state == Open {
+				timer = time.After(b.Cooldown - time.Since(b.openedAt))
+			}
+		}
+		b.mu.Unlock()
+		b.notify(from, to)
+		if done != nil {
+			return done, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		case <-timer:
+		}
+	}
+}
+
+// State текущее состояние и, для разомкнутой цепи, время пробного запроса
+func (b *Breaker) State() (State, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open {
+		return b.state, b.openedAt.Add(b.Cooldown)
+	}
+	return b.state, time.Time{}
+}
+
+// Blocked сообщает, что цепь разомкнута и пауза ещё не истекла
+func (b *Breaker) Blocked() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == Open && time.Since(b.openedAt) < b.Cooldown
+}
+
+func (b *Breaker) setLocked(s State) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	b.wakeLocked()
+}
+
+func (b *Breaker) wakeLocked() {
+	if b.changed != nil {
+		close(b.changed)
+		b.changed = nil
+	}
+}
+
+func (b *Breaker) changedLocked() chan struct{} {
+	if b.changed == nil {
+		b.changed = make(chan struct{})
+	}
+	return b.changed
+}
+
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.OnChange != nil {
+		b.OnChange(b.Name, from, to)
+	}
+}
@@ -0,0 +1,171 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const cooldown = 30 * time.Millisecond
+
+// trip размыкает цепь b ошибками подряд и ждёт конца паузы
+func trip(t *testing.T, b *Breaker) {
+	t.Helper()
+	for i := 0; i < b.Threshold; i++ {
+		done := b.Allow()
+		if done == nil {
+			t.Fatal("запрос не разрешён в замкнутой цепи")
+		}
+		done(Failure)
+	}
+	if s, _ := b.State(); s != Open {
+		t.Fatalf("состояние %v, want open", s)
+	}
+	time.Sleep(cooldown + 5*time.Millisecond)
+}
+
+// Одновременные ошибки размыкают цепь ровно один раз
+func TestConcurrentFailuresTripOnce(t *testing.T) {
+	var opened, changes atomic.Int32
+	b := &Breaker{Threshold: 5, Cooldown: time.Minute, OnChange: func(_ string, from, to State) {
+		changes.Add(1)
+		if from == Closed && to == Open {
+			opened.Add(1)
+		}
+	}}
+	const n = 100
+	dones := make([]func(Outcome), n)
+	for i := range dones {
+		dones[i] = b.Allow()
+	}
+	var wg sync.WaitGroup
+	for _, done := range dones {
+		wg.Add(1)
+		go func(done func(Outcome)) {
+			defer wg.Done()
+			done(Failure)
+		}(done)
+	}
+	wg.Wait()
+	if opened.Load() != 1 || changes.Load() != 1 {
+		t.Errorf("размыканий %d, смен состояния %d, want 1 и 1", opened.Load(), changes.Load())
+	}
+	if !b.Blocked() || b.Allow() != nil {
+		t.Error("разомкнутая цепь пропускает запросы")
+	}
+}
+
+// В полуоткрытой цепи пробный запрос получает ровно один из многих
+// одновременных Allow и Wait
+func TestHalfOpenSingleProbe(t *testing.T) {
+	b := &Breaker{Threshold: 2, Cooldown: cooldown}
+	trip(t, b)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cooldown/2)
+	defer cancel()
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			if b.Allow() != nil {
+				granted.Add(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			if done, err := b.Wait(ctx); err == nil && done != nil {
+				granted.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if granted.Load() != 1 {
+		t.Errorf("пробных запросов %d, want 1", granted.Load())
+	}
+	if s, _ := b.State(); s != HalfOpen {
+		t.Errorf("состояние %v, want half-open", s)
+	}
+}
+
+// Ожидающие в Wait остаются заблокированы после неудачной пробы, а после
+// удачной просыпаются все
+func TestWaitWakesOnProbeSuccess(t *testing.T) {
+	b := &Breaker{Threshold: 1, Cooldown: cooldown}
+	trip(t, b)
+	probe := b.Allow()
+	if probe == nil {
+		t.Fatal("нет пробного запроса")
+	}
+
+	const n = 10
+	got := make(chan func(Outcome), n)
+	for i := 0; i < n; i++ {
+		go func() {
+			done, err := b.Wait(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			got <- done
+		}()
+	}
+	select {
+	case <-got:
+		t.Fatal("Wait вернулся во время пробы")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Неудачная проба снова размыкает цепь на целую паузу
+	probe(Failure)
+	select {
+	case <-got:
+		t.Fatal("Wait вернулся после неудачной пробы")
+	case <-time.After(cooldown / 2):
+	}
+
+	// После паузы один из ожидающих получает новую пробу, её успех
+	// замыкает цепь и будит остальных
+	var next func(Outcome)
+	select {
+	case next = <-got:
+	case <-time.After(time.Second):
+		t.Fatal("новая проба не выдана после паузы")
+	}
+	if s, _ := b.State(); s != HalfOpen {
+		t.Fatalf("состояние %v, want half-open", s)
+	}
+	next(Success)
+	for i := 1; i < n; i++ {
+		select {
+		case done := <-got:
+			done(Success)
+		case <-time.After(time.Second):
+			t.Fatalf("проснулись %d из %d", i, n-1)
+		}
+	}
+	if s, _ := b.State(); s != Closed {
+		t.Errorf("состояние %v, want closed", s)
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	b := &Breaker{Threshold: 1, Cooldown: time.Minute}
+	b.Allow()(Failure)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	done, err := b.Wait(ctx)
+	if done != nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait: %v, %v", done != nil, err)
+	}
+}
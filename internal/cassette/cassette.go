@@ -0,0 +1,200 @@
+// Package cassette записывает ответы провайдеров и воспроизводит их без
+// сети: для разработки промптов и вывода офлайн и для демонстраций.
+//
+// Запись ищется по хешу запроса. Для OCR это содержимое изображения (путь
+// не учитывается), для модели — промпт, из которого убраны изменчивые
+// части: даты, время и лишние пробелы, вместе с параметрами генерации.
+package cassette
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+	"hack_interview/internal/ocr"
+)
+
+// Режимы работы
+const (
+	ModeOff    = ""
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Cassette директория с записанными ответами
+type Cassette struct {
+	Dir  string
+	Mode string
+}
+
+// entry одна запись на диске
+type entry struct {
+	Kind     string    `json:"kind"`
+	Request  string    `json:"request"`
+	Response string    `json:"response"`
+	Recorded time.Time `json:"recorded"`
+}
+
+var (
+	timestampRe = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?\b`)
+	clockRe     = regexp.MustCompile(`\b\d{1,2}:\d{2}(?::\d{2})?\b`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+)
+
+// Normalize убирает из запроса части, которые меняются от запуска к
+// запуску и не влияют на ответ
+func Normalize(s string) string {
+	s = timestampRe.ReplaceAllString(s, "<ts>")
+	s = clockRe.ReplaceAllString(s, "<time>")
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+// Key ключ записи для вида запроса и его нормализованного содержимого
+func Key(kind string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(kind))
+	h.Write([]byte{0})
+	h.Write(data)
+	return kind + "-" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func (c *Cassette) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+func (c *Cassette) load(key string) (entry, error) {
+	var e entry
+	data, err := os.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return e, fmt.Errorf("%w: %s", errs.ErrReplayMiss, key)
+	}
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, fmt.Errorf("повреждённая запись %s: %w", key, err)
+	}
+	return e, nil
+}
+
+func (c *Cassette) save(key string, e entry) error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(key))
+}
+
+// OCR оборачивает провайдера распознавания
+func (c *Cassette) OCR(p ocr.Provider) ocr.Provider {
+	if c.Mode == ModeOff {
+		return p
+	}
+	return &ocrProvider{c: c, next: p}
+}
+
+// LLM оборачивает модель
+func (c *Cassette) LLM(a llm.Answerer) llm.Answerer {
+	if c.Mode == ModeOff {
+		return a
+	}
+	return &answerer{c: c, next: a}
+}
+
+type ocrProvider struct {
+	c    *Cassette
+	next ocr.Provider
+}
+
+func (o *ocrProvider) ExtractText(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	key := Key("ocr", data)
+	return o.c.do(key, "ocr", filepath.Base(path), func() (string, error) {
+		return o.next.ExtractText(ctx, path)
+	})
+}
+
+// ModelLLM оборачивает модель, отвечающую на те же запросы, что и другие
+// модели кассеты: имя model входит в ключ записи, чтобы их ответы не
+// смешивались
+func (c *Cassette) ModelLLM(model string, a llm.Answerer) llm.Answerer {
+	if c.Mode == ModeOff {
+		return a
+	}
+	return &answerer{c: c, next: a, model: model}
+}
+
+type answerer struct {
+	c     *Cassette
+	next  llm.Answerer
+	model string
+}
+
+func (a *answerer) Answer(ctx context.Context, req llm.Request) (string, error) {
+	var parts []string
+	for _, t := range req.History {
+		parts = append(parts, Normalize(t.Prompt), Normalize(t.Answer))
+	}
+	norm := strings.Join(append(parts, Normalize(req.Prompt)), "\n---\n")
+	if req.MaxOutputTokens > 0 || req.Temperature != nil {
+		temp := "-"
+		if req.Temperature != nil {
+			temp = fmt.Sprint(*req.Temperature)
+		}
+		norm += fmt.Sprintf("\n[maxOutputTokens=%d temperature=%s]", req.MaxOutputTokens, temp)
+	}
+	for _, img := range req.Images {
+		data, err := os.ReadFile(img.Path)
+		if err != nil {
+			return "", err
+		}
+		norm += "\n[image=" + Key("image", data) + "]"
+	}
+	if a.model != "" {
+		norm += "\n[model=" + a.model + "]"
+	}
+	key := Key("llm", []byte(norm))
+	return a.c.do(key, "llm", norm, func() (string, error) {
+		return a.next.Answer(ctx, req)
+	})
+}
+
+// do отдаёт запись в режиме воспроизведения или выполняет запрос и
+// записывает успешный ответ. Ошибки не записываются: при воспроизведении
+// им соответствует промах.
+func (c *Cassette) do(key, kind, request string, call func() (string, error)) (string, error) {
+	if c.Mode == ModeReplay {
+		e, err := c.load(key)
+		if err != nil {
+			return "", err
+		}
+		return e.Response, nil
+	}
+	resp, err := call()
+	if err != nil {
+		return "", err
+	}
+	if err := c.save(key, entry{Kind: kind, Request: request, Response: resp, Recorded: time.Now()}); err != nil {
+		return "", fmt.Errorf("ошибка записи кассеты: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,185 @@
+package cassette
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"Сегодня 2026-03-14, вопрос", "Сегодня <ts>, вопрос"},
+		{"at 2026-03-14T09:26:53Z done", "at <ts> done"},
+		{"at 2026-03-14T09:26:53.123456+03:00 done", "at <ts> done"},
+		{"at 2026-03-14 09:26 done", "at <ts> done"},
+		{"at 2026-03-14T09:26:53+0300", "at <ts>"},
+		{"в 9:05 и в 21:17:03", "в <time> и в <time>"},
+		{"  много\n\n\tпробелов  \r\n", "много пробелов"},
+		// Числа, похожие на время или дату только частично, остаются
+		{"O(n^2) за 1000 шагов, 12345:678", "O(n^2) за 1000 шагов, 12345:678"},
+		{"версия 1.22.0", "версия 1.22.0"},
+	} {
+		if got := Normalize(tc.in); got != tc.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	a := Key("llm", []byte("вопрос"))
+	if a != Key("llm", []byte("вопрос")) {
+		t.Error("ключ не детерминирован")
+	}
+	if len(a) != len("llm-")+16 || a[:4] != "llm-" {
+		t.Errorf("ключ %q", a)
+	}
+	// Вид запроса отделён от данных: "ocr"+"x" не совпадает с "oc"+"rx"
+	if Key("ocr", []byte("x")) == Key("oc", []byte("rx")) || Key("ocr", []byte("вопрос")) == a {
+		t.Error("ключи разных видов совпали")
+	}
+}
+
+// fakeLLM отвечает по порядку и считает вызовы
+type fakeLLM struct {
+	calls int
+	err   error
+}
+
+func (f *fakeLLM) Answer(_ context.Context, req llm.Request) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	return "ответ на " + req.Prompt, nil
+}
+
+func temp(v float64) *float64 { return &v }
+
+// Запросы, отличающиеся только временем, датой и пробелами, получают
+// одну запись, а всё, что влияет на ответ, — разные
+func TestAnswerKeyNormalization(t *testing.T) {
+	c := &Cassette{Dir: t.TempDir(), Mode: ModeRecord}
+	next := &fakeLLM{}
+	a := c.LLM(next)
+	ask := func(req llm.Request) {
+		t.Helper()
+		if _, err := a.Answer(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	same := []llm.Request{
+		{Prompt: "Сейчас 2026-03-14T09:26:53Z. Вопрос: что такое слайс?"},
+		{Prompt: "Сейчас 2026-10-01T18:00:00+03:00.  Вопрос:\nчто такое слайс?"},
+	}
+	for _, r := range same {
+		ask(r)
+	}
+	if n := entries(t, c.Dir); n != 1 {
+		t.Fatalf("записей %d после запросов, отличающихся временем", n)
+	}
+
+	img := filepath.Join(t.TempDir(), "shot.png")
+	os.WriteFile(img, []byte("png"), 0644)
+	different := []llm.Request{
+		{Prompt: "Вопрос: что такое map?"},
+		{Prompt: "Вопрос: что такое слайс?", MaxOutputTokens: 100},
+		{Prompt: "Вопрос: что такое слайс?", Temperature: temp(0.2)},
+		{Prompt: "Вопрос: что такое слайс?", History: []llm.Turn{{Prompt: "Привет", Answer: "Здравствуйте"}}},
+		{Prompt: "Вопрос: что такое слайс?", Images: []llm.Image{{Path: img}}},
+	}
+	for _, r := range different {
+		ask(r)
+	}
+	if n := entries(t, c.Dir); n != 1+len(different) {
+		t.Errorf("записей %d, want %d", n, 1+len(different))
+	}
+
+	// Другая модель кассеты не получает чужой ответ
+	if _, err := c.ModelLLM("verifier", next).Answer(context.Background(), same[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n := entries(t, c.Dir); n != 2+len(different) {
+		t.Errorf("ответ проверяющей модели смешан с основной: записей %d", n)
+	}
+}
+
+func entries(t *testing.T, dir string) int {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(files)
+}
+
+// Записанный ответ воспроизводится без вызова провайдера и для запроса
+// с другим временем; промах и ошибки провайдера не записываются
+func TestRecordReplay(t *testing.T) {
+	dir := t.TempDir()
+	rec := &Cassette{Dir: dir, Mode: ModeRecord}
+	if _, err := rec.LLM(&fakeLLM{}).Answer(context.Background(), llm.Request{Prompt: "в 10:00 вопрос"}); err != nil {
+		t.Fatal(err)
+	}
+	failing := &fakeLLM{err: errors.New("503")}
+	if _, err := rec.LLM(failing).Answer(context.Background(), llm.Request{Prompt: "другой"}); err == nil {
+		t.Fatal("ошибка провайдера потеряна")
+	}
+	if n := entries(t, dir); n != 1 {
+		t.Errorf("записей %d, ошибка записана", n)
+	}
+
+	next := &fakeLLM{}
+	play := (&Cassette{Dir: dir, Mode: ModeReplay}).LLM(next)
+	got, err := play.Answer(context.Background(), llm.Request{Prompt: "в 23:59 вопрос"})
+	if err != nil || got != "ответ на в 10:00 вопрос" || next.calls != 0 {
+		t.Errorf("воспроизведение %q, %v, вызовов %d", got, err, next.calls)
+	}
+	if _, err := play.Answer(context.Background(), llm.Request{Prompt: "другой"}); !errors.Is(err, errs.ErrReplayMiss) || next.calls != 0 {
+		t.Errorf("промах: %v, вызовов %d", err, next.calls)
+	}
+}
+
+// fakeOCR текст по содержимому файла
+type fakeOCR struct{ calls int }
+
+func (f *fakeOCR) ExtractText(_ context.Context, path string) (string, error) {
+	f.calls++
+	data, err := os.ReadFile(path)
+	return "текст " + string(data), err
+}
+
+// Ключ OCR — содержимое изображения: переименованный снимок находится
+func TestOCRKeyIgnoresPath(t *testing.T) {
+	dir := t.TempDir()
+	in := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(in, name)
+		os.WriteFile(p, []byte(data), 0644)
+		return p
+	}
+	rec := (&Cassette{Dir: dir, Mode: ModeRecord}).OCR(&fakeOCR{})
+	if _, err := rec.ExtractText(context.Background(), write("a.png", "A")); err != nil {
+		t.Fatal(err)
+	}
+	next := &fakeOCR{}
+	play := (&Cassette{Dir: dir, Mode: ModeReplay}).OCR(next)
+	if got, err := play.ExtractText(context.Background(), write("renamed.png", "A")); err != nil || got != "текст A" {
+		t.Errorf("воспроизведение %q, %v", got, err)
+	}
+	if _, err := play.ExtractText(context.Background(), write("a2.png", "B")); !errors.Is(err, errs.ErrReplayMiss) || next.calls != 0 {
+		t.Errorf("другое содержимое: %v, вызовов %d", err, next.calls)
+	}
+}
+
+func TestModeOffPassesThrough(t *testing.T) {
+	next := &fakeLLM{}
+	c := &Cassette{Dir: t.TempDir()}
+	if a := c.LLM(next); a != llm.Answerer(next) {
+		t.Error("LLM без режима обёрнут")
+	}
+}
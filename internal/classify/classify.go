@@ -0,0 +1,170 @@
+// Package classify определяет вид вопроса по распознанному тексту, чтобы
+// выбрать для него подходящий промпт и формат ответа.
+package classify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kind вид вопроса
+type Kind string
+
+const (
+	// KindGeneric вопрос без особой обработки
+	KindGeneric Kind = ""
+	// KindSQL задача на написание SQL-запроса
+	KindSQL Kind = "sql"
+	// KindDesign вопрос по проектированию системы
+	KindDesign Kind = "design"
+	// KindBehavioral поведенческий вопрос об опыте кандидата
+	KindBehavioral Kind = "behavioral"
+)
+
+// sqlKeywords конструкции, характерные для текста SQL-задачи. Отдельные
+// FROM, WHERE и упоминание самого SQL в обычном тексте не учитываются:
+// «храним пользователей в SQL-базе, спроектируйте API» — не SQL-задача.
+var sqlKeywords = []string{
+	"join", "left join", "inner join", "group by", "order by",
+	"having", "partition by", "over (", "insert into", "create table",
+	"with recursive", "count(*)", "distinct",
+}
+
+var (
+	// sqlLabelRe явная метка вида в начале текста: [sql] или SQL:
+	sqlLabelRe = regexp.MustCompile(`(?i)^\s*(\[sql\]|sql:)`)
+	// selectFrom SELECT ... FROM заглавными: в обычной речи «select ...
+	// from» встречается, а заглавными пишут только запрос
+	selectFrom  = regexp.MustCompile(`(?s)\bSELECT\b.+\bFROM\b`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+	wordBoundRe = regexp.MustCompile(`^\w|\w$`)
+)
+
+// Rules настройки распознавания одного вида вопроса
+type Rules struct {
+	// Threshold сколько очков нужно, чтобы отнести текст к виду; 0 —
+	// только по явной метке
+	Threshold int
+	// Keywords дополнительные ключевые слова, по очку за каждое найденное
+	Keywords []string
+}
+
+// Options настройки распознавания всех видов
+type Options struct {
+	SQL        Rules
+	Design     Rules
+	Behavioral Rules
+}
+
+// designKeywords признаки вопроса по проектированию системы
+var designKeywords = []string{
+	"system design", "design a system", "спроектиру", "проектирование системы",
+	"архитектур", "масштабир", "scalab", "high availability", "отказоустойчив",
+	"load balancer", "балансировщик", "sharding", "шардир", "replication", "репликац",
+	"rps", "qps", "millions of users", "миллион пользователей", "url shortener",
+	"rate limiter", "cdn", "message queue", "очередь сообщений",
+}
+
+var designLabelRe = regexp.MustCompile(`(?i)^\s*(\[design\]|design:)`)
+
+// DesignScore очко за каждое различное ключевое слово проектирования.
+// Слова, заканчивающиеся на середине (спроектиру, архитектур), ищутся как
+// начало слова.
+func DesignScore(text string, extra []string) int {
+	lower := spaceRe.ReplaceAllString(strings.ToLower(text), " ")
+	score := 0
+	for _, kw := range append(designKeywords, extra...) {
+		kw = strings.ToLower(kw)
+		if containsPrefix(lower, kw) {
+			score++
+		}
+	}
+	return score
+}
+
+// behavioralKeywords формулировки поведенческих вопросов
+var behavioralKeywords = []string{
+	"tell me about a time", "tell me about yourself", "describe a situation",
+	"give me an example", "conflict", "teammate", "disagree", "mistake", "failure",
+	"weakness", "proud", "leadership", "difficult", "deadline",
+	"расскажите о случае", "расскажи о случае", "расскажите о себе", "опишите ситуацию",
+	"приведите пример", "конфликт", "коллег", "разноглас", "ошиб", "провал",
+	"слабые стороны", "гордит", "лидерств", "сложн", "дедлайн",
+}
+
+var behavioralLabelRe = regexp.MustCompile(`(?i)^\s*(\[behavioral\]|behavioral:)`)
+
+// BehavioralScore очко за каждую различную формулировку поведенческого
+// вопроса; как и в DesignScore, слова ищутся с начала слова
+func BehavioralScore(text string, extra []string) int {
+	lower := spaceRe.ReplaceAllString(strings.ToLower(text), " ")
+	score := 0
+	for _, kw := range append(behavioralKeywords, extra...) {
+		if containsPrefix(lower, strings.ToLower(kw)) {
+			score++
+		}
+	}
+	return score
+}
+
+// containsPrefix ищет kw с начала слова
+func containsPrefix(text, kw string) bool {
+	pattern := regexp.QuoteMeta(kw)
+	if wordBoundRe.MatchString(kw[:1]) {
+		pattern = `(?:^|[^\p{L}\p{N}_])` + pattern
+	}
+	return regexp.MustCompile(pattern).MatchString(text)
+}
+
+// SQLScore считает очки SQL-задачи: по одному за каждое различное
+// ключевое слово и два за конструкцию SELECT ... FROM
+func SQLScore(text string, extra []string) int {
+	lower := spaceRe.ReplaceAllString(strings.ToLower(text), " ")
+	score := 0
+	for _, kw := range append(sqlKeywords, extra...) {
+		if containsWord(lower, strings.ToLower(kw)) {
+			score++
+		}
+	}
+	if selectFrom.MatchString(text) {
+		score += 2
+	}
+	return score
+}
+
+// containsWord ищет kw целым словом, чтобы «joined» не считалось JOIN
+func containsWord(text, kw string) bool {
+	if kw == "" {
+		return false
+	}
+	pattern := regexp.QuoteMeta(kw)
+	if wordBoundRe.MatchString(kw[:1]) {
+		pattern = `\b` + pattern
+	}
+	if wordBoundRe.MatchString(kw[len(kw)-1:]) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(pattern).MatchString(text)
+}
+
+// Classify определяет вид вопроса. Явная метка важнее очков; SQL-задача
+// проверяется раньше проектирования, так как в ней тоже бывают таблицы и
+// «спроектируйте схему», а поведенческий вопрос — последним: «сложный»
+// и «ошибка» встречаются и в технических задачах.
+func Classify(text string, opts Options) Kind {
+	switch {
+	case sqlLabelRe.MatchString(text):
+		return KindSQL
+	case designLabelRe.MatchString(text):
+		return KindDesign
+	case behavioralLabelRe.MatchString(text):
+		return KindBehavioral
+	case opts.SQL.Threshold > 0 && SQLScore(text, opts.SQL.Keywords) >= opts.SQL.Threshold:
+		return KindSQL
+	case opts.Design.Threshold > 0 && DesignScore(text, opts.Design.Keywords) >= opts.Design.Threshold:
+		return KindDesign
+	case opts.Behavioral.Threshold > 0 && BehavioralScore(text, opts.Behavioral.Keywords) >= opts.Behavioral.Threshold:
+		return KindBehavioral
+	}
+	return KindGeneric
+}
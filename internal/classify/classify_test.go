@@ -0,0 +1,93 @@
+package classify
+
+import "testing"
+
+// defaults пороги конфигурации по умолчанию
+var defaults = Options{
+	SQL:        Rules{Threshold: 2},
+	Design:     Rules{Threshold: 2},
+	Behavioral: Rules{Threshold: 3},
+}
+
+func TestSQLScore(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int
+	}{
+		{"SELECT name FROM users", 2},
+		{"select name from users", 0},
+		{"Выведите отделы: SELECT d.name, COUNT(*) FROM emp e JOIN dept d ON ... GROUP BY d.name HAVING COUNT(*) > 5", 2 + 4},
+		{"Найдите вторую зарплату с помощью ROW_NUMBER() OVER (PARTITION BY dept ORDER BY salary)", 3},
+		// «joined» и «ordered» — не JOIN и ORDER BY
+		{"Anna joined the team and ordered by priority", 0},
+		{"LEFT JOIN orders", 2},
+	} {
+		if got := SQLScore(tc.text, nil); got != tc.want {
+			t.Errorf("SQLScore(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+	if got := SQLScore("Посчитайте через window function", []string{"window function"}); got != 1 {
+		t.Errorf("дополнительное слово: %d", got)
+	}
+}
+
+// Пограничные тексты: упоминание SQL или таблиц ещё не делает вопрос
+// SQL-задачей, а одно ключевое слово не дотягивает до порога
+func TestClassifySQLBorderline(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want Kind
+	}{
+		{"We store users in a SQL database, design an API", KindGeneric},
+		{"We store users in a SQL database, design an API with sharding and replication", KindDesign},
+		{"Мы храним заказы в PostgreSQL. Напишите функцию на Go, которая возвращает заказ по id.", KindGeneric},
+		{"Which is faster in SQL: a subquery or a JOIN?", KindGeneric},
+		{"Таблица employees(id, name, salary, dept_id). Выведите сотрудников с зарплатой выше средней по отделу, ORDER BY salary.", KindGeneric},
+		{"Таблица employees(id, name, salary, dept_id). Для каждого отдела выведите среднюю зарплату: GROUP BY dept_id, ORDER BY средней.", KindSQL},
+		{"Напишите запрос:\nSELECT *\nFROM orders o\nгде сумма больше 100", KindSQL},
+		{"[sql] Найдите дубликаты email", KindSQL},
+		{"SQL: второй по величине оклад", KindSQL},
+		// Метка только в начале текста
+		{"Вопрос про ORM. sql: не нужен", KindGeneric},
+		// SQL-задача со «спроектируйте схему» остаётся SQL-задачей
+		{"Спроектируйте схему и напишите CREATE TABLE users и запрос с JOIN по orders", KindSQL},
+	} {
+		if got := Classify(tc.text, defaults); got != tc.want {
+			t.Errorf("Classify(%q) = %q (SQL %d, design %d), want %q",
+				tc.text, got, SQLScore(tc.text, nil), DesignScore(tc.text, nil), tc.want)
+		}
+	}
+}
+
+// Порог настраивается: 0 — только явная метка, 1 — любое ключевое слово
+func TestClassifyThreshold(t *testing.T) {
+	text := "Which is faster in SQL: a subquery or a JOIN?"
+	if got := Classify(text, Options{SQL: Rules{Threshold: 1}}); got != KindSQL {
+		t.Errorf("порог 1: %q", got)
+	}
+	if got := Classify("SELECT a, b FROM t GROUP BY a", Options{}); got != KindGeneric {
+		t.Errorf("порог 0: %q", got)
+	}
+	if got := Classify("[sql] что угодно", Options{}); got != KindSQL {
+		t.Errorf("метка при пороге 0: %q", got)
+	}
+}
+
+func TestClassifyOtherKinds(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want Kind
+	}{
+		{"Design a URL shortener for millions of users", KindDesign},
+		{"Расскажите о случае, когда у вас был конфликт с коллегой", KindBehavioral},
+		// Одно «сложный» в технической задаче — не поведенческий вопрос
+		{"Какова сложность поиска в map? Приведите пример.", KindGeneric},
+		{"[behavioral] Почему вы уходите?", KindBehavioral},
+		{"design: чат", KindDesign},
+		{"Развернуть связный список", KindGeneric},
+	} {
+		if got := Classify(tc.text, defaults); got != tc.want {
+			t.Errorf("Classify(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
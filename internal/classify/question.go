@@ -0,0 +1,92 @@
+package classify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// interrogatives вопросительные слова и обороты
+var interrogatives = []string{
+	"how", "what", "why", "which", "when", "where", "can you", "could you", "is it", "does",
+	"как", "что", "почему", "зачем", "какой", "какая", "какие", "чем", "сколько", "где", "когда",
+	"можно ли", "верно ли",
+}
+
+// taskVerbs глаголы постановки задачи; ищутся в начале предложения или
+// строки, чтобы «I will implement» в переписке не считалось заданием
+var taskVerbs = []string{
+	"implement", "design", "write", "given", "find", "return", "explain", "describe",
+	"create", "build", "compute", "determine", "optimize", "reverse", "sort", "merge",
+	"check", "count", "print", "convert", "validate", "remove", "calculate",
+	"реализуйте", "реализуй", "напишите", "напиши", "спроектируйте", "найдите", "найди",
+	"дан", "дана", "дано", "даны", "объясните", "объясни", "опишите", "расскажите",
+	"определите", "вычислите", "оптимизируйте", "разверните", "отсортируйте",
+	"проверьте", "посчитайте", "выведите", "удалите", "объедините",
+}
+
+var (
+	codeTokenRe = regexp.MustCompile(`\bfunc\b|\bdef\b|\bclass\b|\breturn\b|=>|->|:=|\w\(\w*\)|\[\]\w|\{\s*$|\bint\b|\bstring\b|\bnull\b|\bnil\b`)
+	// clockRe время вида 10:30 — много таких меток у чатов и календарей
+	clockRe         = regexp.MustCompile(`\b\d{1,2}:\d{2}\b`)
+	sentenceStartRe = regexp.MustCompile(`(?m)(?:^|[.!?]\s+)\s*(\p{L}+)`)
+)
+
+// QuestionScore оценивает, похож ли текст на вопрос собеседования, и
+// перечисляет причины оценки. Вопросительный знак — 2 очка, вопросительное
+// слово — 1 (не больше 2), глагол задания в начале предложения — 2 (не
+// больше 4), признаки кода — 1 или 2 при трёх и больше; много меток
+// времени (переписка, календарь) — минус 2.
+func QuestionScore(text string) (int, []string) {
+	lower := spaceRe.ReplaceAllString(strings.ToLower(text), " ")
+	score := 0
+	var reasons []string
+
+	if strings.ContainsAny(text, "?？") {
+		score += 2
+		reasons = append(reasons, "вопросительный знак")
+	}
+
+	n := 0
+	for _, w := range interrogatives {
+		if n < 2 && containsWholeWord(lower, w) {
+			n++
+			score++
+			reasons = append(reasons, "вопросительное слово «"+w+"»")
+		}
+	}
+
+	starts := make(map[string]bool)
+	for _, m := range sentenceStartRe.FindAllStringSubmatch(strings.ToLower(text), -1) {
+		starts[m[1]] = true
+	}
+	n = 0
+	for _, v := range taskVerbs {
+		if n < 2 && starts[v] {
+			n++
+			score += 2
+			reasons = append(reasons, "глагол задания «"+v+"»")
+		}
+	}
+
+	switch c := len(codeTokenRe.FindAllString(text, -1)); {
+	case c >= 3:
+		score += 2
+		reasons = append(reasons, "код")
+	case c > 0:
+		score++
+		reasons = append(reasons, "признаки кода")
+	}
+
+	if len(clockRe.FindAllString(text, -1)) >= 3 {
+		score -= 2
+		reasons = append(reasons, "много меток времени")
+	}
+	return score, reasons
+}
+
+// containsWholeWord ищет w целым словом с учётом кириллицы: \b в regexp
+// знает только ASCII, и «что» иначе нашлось бы в «чтобы»
+func containsWholeWord(text, w string) bool {
+	const sep = `[^\p{L}\p{N}_]`
+	return regexp.MustCompile(`(?:^|` + sep + `)` + regexp.QuoteMeta(w) + `(?:$|` + sep + `)`).MatchString(text)
+}
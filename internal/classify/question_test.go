@@ -0,0 +1,77 @@
+package classify
+
+import (
+	"strings"
+	"testing"
+)
+
+// questionThreshold порог questionFilter по умолчанию
+const questionThreshold = 2
+
+// Корпус распознанных снимков: задачи собеседования набирают порог, а
+// редактор, переписка, календарь и меню — нет
+func TestQuestionScoreCorpus(t *testing.T) {
+	positive := []string{
+		"Reverse a linked list",
+		"Что такое горутина?",
+		"Implement an LRU cache with O(1) get and put.",
+		"Given an array of integers nums and an integer target, return indices of the two numbers such that they add up to target.",
+		"Напишите функцию, которая проверяет, является ли строка палиндромом",
+		"Дан массив чисел. Найдите максимальную сумму подмассива.",
+		"Design a URL shortener for millions of users",
+		"Спроектируйте сервис уведомлений",
+		"Чем отличается процесс от потока?",
+		"How does garbage collection work in Go",
+		"func main() {\n\tch := make(chan int)\n\tclose(ch)\n\tch <- 1\n}\nЧто выведет программа?",
+		"Расскажите о случае, когда вы не согласились с решением руководителя",
+		"Объясните разницу между TCP и UDP",
+	}
+	negative := []string{
+		"File Edit Selection View Go Run Terminal Help",
+		"main.go — hack_interview — Visual Studio Code",
+		"Иван 10:02\nпривет, созвонимся после обеда\nМария 10:05\nда, давай в 14:00\nИван 10:06\nок",
+		"Пн 9:00 Стендап\nПн 11:30 Ревью дизайна\nВт 15:00 1:1 с руководителем",
+		"Сохранено. Все изменения синхронизированы",
+		"I will implement this tomorrow, thanks for the review",
+		"Загрузка... 45%",
+		"Trash",
+		"",
+	}
+	for _, text := range positive {
+		if score, reasons := QuestionScore(text); score < questionThreshold {
+			t.Errorf("вопрос %q: оценка %d (%s)", text, score, strings.Join(reasons, ", "))
+		}
+	}
+	for _, text := range negative {
+		if score, reasons := QuestionScore(text); score >= questionThreshold {
+			t.Errorf("не вопрос %q: оценка %d (%s)", text, score, strings.Join(reasons, ", "))
+		}
+	}
+}
+
+// Точные очки каждого признака
+func TestQuestionScore(t *testing.T) {
+	for _, tc := range []struct {
+		text    string
+		score   int
+		reasons []string
+	}{
+		{"?", 2, []string{"вопросительный знак"}},
+		// Не больше двух вопросительных слов, в порядке списка
+		{"почему, что и как", 2, []string{"вопросительное слово «как»", "вопросительное слово «что»"}},
+		// «что» не находится внутри «чтобы», и одного слова мало для порога
+		{"чтобы", 0, nil},
+		{"Чем отличается процесс от потока", 1, []string{"вопросительное слово «чем»"}},
+		{"Implement it. Sort it. Merge it.", 4, []string{"глагол задания «implement»", "глагол задания «sort»"}},
+		// Глагол задания не в начале предложения не считается
+		{"we implement", 0, nil},
+		{"x := f(a)", 1, []string{"признаки кода"}},
+		{"func f() int { return nil }", 2, []string{"код"}},
+		{"9:00 10:00 11:00", -2, []string{"много меток времени"}},
+	} {
+		score, reasons := QuestionScore(tc.text)
+		if score != tc.score || strings.Join(reasons, "|") != strings.Join(tc.reasons, "|") {
+			t.Errorf("QuestionScore(%q) = %d %q, want %d %q", tc.text, score, reasons, tc.score, tc.reasons)
+		}
+	}
+}
@@ -0,0 +1,1563 @@
+// Package config загружает и проверяет config.yml.
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v2"
+
+	"hack_interview/internal/msg"
+	"hack_interview/internal/secret"
+	"hack_interview/internal/transport"
+)
+
+// Порядок обработки найденных файлов
+const (
+	SortByMtime = "mtime"
+	SortByName  = "name"
+)
+
+// Хранилища состояния
+const (
+	StateJSON   = "json"
+	StateSQLite = "sqlite"
+)
+
+// Config структура для загрузки конфигурации из YAML
+type Config struct {
+	InputDir  string `yaml:"inputDir"`
+	OutputDir string `yaml:"outputDir"`
+	// OCRAPIKey и GeminiAPIKey заменяются переменными окружения OCR_API_KEY
+	// и GEMINI_API_KEY, если те заданы (в том числе из .env). Эти и другие
+	// ключи и пароли можно хранить зашифрованными: значение enc:v1:... из
+	// команды config encrypt-key, или в хранилище ОС:
+	// keychain:<служба>/<запись> (config set-secret)
+	OCRAPIKey    string `yaml:"OCR_API_KEY"`
+	GeminiAPIKey string `yaml:"GEMINI_API_KEY"`
+	PROMPT       string `yaml:"PROMPT"`
+	// Prompts библиотека именованных промптов. Метка после + или # в конце
+	// имени файла (q17+teach.png, q17#teach.png) заменяет для этого файла
+	// PROMPT и промпт вида вопроса; метка в имя результата не входит
+	// (q17.md). Суффикс с неизвестным именем меткой не считается и
+	// остаётся в имени результата (task#1.png → task#1.md).
+	Prompts map[string]string `yaml:"prompts"`
+	// Style стиль ответа по умолчанию; StyleDirs задаёт стиль для файлов,
+	// чей путь относительно InputDir подходит под шаблон (первое совпадение
+	// в порядке шаблонов по алфавиту). Styles добавляет свои стили или
+	// переопределяет встроенные (brief, detailed, code-only, hints).
+	Style     string            `yaml:"style"`
+	StyleDirs map[string]string `yaml:"styleDirs"`
+	Styles    map[string]Style  `yaml:"styles"`
+	// Complexity раздел «## Complexity» в ответах с кодом: auto (по
+	// умолчанию) дозапрашивает оценку сложности, если модель её не дала,
+	// always — всегда, off — никогда
+	Complexity string `yaml:"complexity"`
+	// GoCheck собирает код Go из ответа (go build, go vet) во временном
+	// модуле и при ошибке один раз просит модель исправить его; нужен go в
+	// PATH, модули не скачиваются
+	GoCheck bool `yaml:"goCheck"`
+	// RunExamples ВЫПОЛНЯЕТ код Go из ответа модели на примерах из условия
+	// и добавляет итог по каждому примеру. Код запускается на этой машине
+	// во временной директории без ключей в окружении, но без настоящей
+	// изоляции — включайте, только понимая риск. RunExamplesTimeout срок
+	// выполнения (по умолчанию 10s).
+	RunExamples        bool          `yaml:"runExamples"`
+	RunExamplesTimeout time.Duration `yaml:"runExamplesTimeout"`
+	// FollowUpWindow снимок, сделанный не позже этого срока после
+	// предыдущего, считается продолжением вопроса (0 — выключено); также
+	// продолжение задаётся префиксом f_ или меткой <снимок>.followup, а
+	// отменяется префиксом n_ или меткой <снимок>.new
+	FollowUpWindow time.Duration `yaml:"followUpWindow"`
+	// Summary добавляет первой строкой результата жирную фразу «скажи это
+	// первым» (отдельный короткий запрос; при ошибке ответ сохраняется без
+	// неё)
+	Summary bool `yaml:"summary"`
+	// ReprocessReuseOCR команда reprocess берёт распознанный текст из
+	// <имя>.ocr.txt прошлой обработки вместо нового запроса OCR, например
+	// чтобы после style: hints получить полный ответ: reprocess --style
+	// detailed
+	ReprocessReuseOCR bool `yaml:"reprocessReuseOCR"`
+	// Notion публикация результатов в базу данных Notion
+	Notion NotionConfig `yaml:"notion"`
+	// Discord карточка с каждым ответом в канале Discord
+	Discord DiscordConfig `yaml:"discord"`
+	// Email письмо с каждым ответом
+	Email EmailConfig `yaml:"email"`
+	// Git коммиты результатов в git-репозиторий OutputDir
+	Git GitConfig `yaml:"git"`
+	// Obsidian копии ответов в хранилище Obsidian
+	Obsidian ObsidianConfig `yaml:"obsidian"`
+	// GDocs запись ответов в Google Docs
+	GDocs GDocsConfig `yaml:"gdocs"`
+	// Verify проверка ответа отдельным запросом к модели
+	Verify VerifyConfig `yaml:"verify"`
+	// Compare сравнение ответов нескольких моделей на один вопрос
+	Compare CompareConfig `yaml:"compare"`
+	// Tests генерация модульных тестов к коду ответа
+	Tests TestsConfig `yaml:"tests"`
+	// CodeLanguage язык решения или список языков: при нескольких модель
+	// даёт раздел «## Solution (<язык>)» на каждый, а код каждого
+	// сохраняется в <имя>.<ext>; maxOutputTokens стиля умножается на их
+	// число
+	CodeLanguage StringList `yaml:"codeLanguage"`
+	// SQL отдельный промпт для задач на SQL-запросы
+	SQL KindConfig `yaml:"sql"`
+	// Design промпт для вопросов по проектированию систем
+	Design KindConfig `yaml:"design"`
+	// Behavioral промпт для поведенческих вопросов в формате STAR
+	Behavioral KindConfig `yaml:"behavioral"`
+	// MinText порог распознанного текста: снимок рабочего стола или
+	// пустого слайда с текстом короче Chars символов (по умолчанию 6) или
+	// меньше Words слов (по умолчанию 2) пропускается без запроса к модели.
+	// 0 отключает проверку; reprocess --force обходит порог.
+	MinText MinTextConfig `yaml:"minText"`
+	// Group объединение снимков одного длинного вопроса
+	Group GroupConfig `yaml:"group"`
+	// SplitQuestions разделение нескольких вопросов с одного снимка
+	SplitQuestions SplitQuestionsConfig `yaml:"splitQuestions"`
+	// QuestionFilter оценка «похожести на вопрос» перед запросом к модели
+	QuestionFilter QuestionFilterConfig `yaml:"questionFilter"`
+	// KnownProblems распознавание известных задач с LeetCode
+	KnownProblems KnownProblemsConfig `yaml:"knownProblems"`
+	// PersonalContext свободное описание опыта кандидата: с ним ответы на
+	// поведенческие вопросы строятся на его реальных проектах
+	PersonalContext string `yaml:"personalContext"`
+
+	// StateFile путь к файлу состояния, по умолчанию OutputDir/.state.json
+	StateFile string `yaml:"stateFile"`
+	// StateBackend хранилище состояния: json (stateFile) или sqlite (stateDB).
+	// База SQLite при первом открытии импортирует существующий stateFile.
+	StateBackend string `yaml:"stateBackend"`
+	// StateDB путь к базе SQLite, по умолчанию OutputDir/.state.db
+	StateDB string `yaml:"stateDB"`
+	// ShutdownTimeout сколько ждать завершения текущей обработки после сигнала
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// FileTimeout общий срок обработки одного файла, RequestTimeout — срок
+	// одного запроса к API
+	FileTimeout    time.Duration `yaml:"fileTimeout"`
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// Workers число параллельных обработчиков
+	Workers int `yaml:"workers"`
+	// Stages отдельные обработчики распознавания и ответа модели вместо
+	// Workers
+	Stages StagesConfig `yaml:"stages"`
+	// QueueSize ёмкость очереди между наблюдателем и обработчиками
+	QueueSize int `yaml:"queueSize"`
+	// MaxFileAge файлы старше этого возраста пропускаются; 0 — без ограничения
+	MaxFileAge time.Duration `yaml:"maxFileAge"`
+	// SortBy порядок обработки найденных файлов: mtime (по умолчанию) или name
+	SortBy string `yaml:"sortBy"`
+	// Include и Exclude шаблоны имён файлов в стиле doublestar
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// MaxAttempts число попыток обработки файла до признания его ошибочным
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryDelay задержка перед первой повторной попыткой, далее удваивается
+	RetryDelay time.Duration `yaml:"retryDelay"`
+	// InputDirGracePeriod сколько входная директория может быть недоступна,
+	// прежде чем наблюдение завершится с ошибкой; по умолчанию 5m
+	InputDirGracePeriod time.Duration `yaml:"inputDirGracePeriod"`
+	// ErrorsDir куда переносятся файлы с исчерпанными попытками
+	ErrorsDir string `yaml:"errorsDir"`
+	// ClipboardWatch включает наблюдение за изображениями в буфере обмена
+	ClipboardWatch    bool          `yaml:"clipboardWatch"`
+	ClipboardInterval time.Duration `yaml:"clipboardInterval"`
+	// Capture встроенный захват экрана по горячей клавише и периодический
+	Capture CaptureConfig `yaml:"capture"`
+	// Server необязательный HTTP-сервер для загрузки изображений
+	Server ServerConfig `yaml:"server"`
+	// GRPC необязательный gRPC-сервис заданий
+	GRPC GRPCConfig `yaml:"grpc"`
+	// S3 необязательный источник изображений из бакета
+	S3 S3Config `yaml:"s3"`
+	// IMAP необязательный источник вложений из почты
+	IMAP IMAPConfig `yaml:"imap"`
+	// OCR и Gemini адреса и заголовки провайдеров, например для работы
+	// через шлюз
+	OCR    ProviderConfig `yaml:"ocr"`
+	Gemini GeminiConfig   `yaml:"gemini"`
+	// OCRProvider и LLMProvider провайдеры распознавания и ответов:
+	// ocrspace и gemini (по умолчанию) или mock — заготовленные ответы из
+	// mock.fixtures без сети и ключей, для демонстраций и разработки
+	OCRProvider string     `yaml:"ocrProvider"`
+	LLMProvider string     `yaml:"llmProvider"`
+	Mock        MockConfig `yaml:"mock"`
+	// OCRPlan тариф OCR.space: free (по умолчанию) или pro. От тарифа
+	// зависят адрес API по умолчанию (api.ocr.space или apipro1.ocr.space)
+	// и предельный размер файла (1 МБ или 5 МБ). OCREndpoint другой адрес
+	// OCR.space, например https://apipro2.ocr.space; допускаются только
+	// хосты ocr.space, шлюз задаётся в ocr.baseURL, и тот важнее обоих.
+	OCRPlan     string `yaml:"ocrPlan"`
+	OCREndpoint string `yaml:"ocrEndpoint"`
+	// Audio распознавание речи в аудиофайлах
+	Audio AudioConfig `yaml:"audio"`
+	// Image подготовка снимков перед OCR
+	Image ImageConfig `yaml:"image"`
+	// HTTP соединения, прокси и повторы запросов к API
+	HTTP transport.Options `yaml:"http"`
+	// Quota дневные лимиты провайдеров
+	Quota QuotaConfig `yaml:"quota"`
+	// Breaker размыкание цепи к провайдеру после серии ошибок подряд
+	Breaker BreakerConfig `yaml:"breaker"`
+	// Cassette запись ответов провайдеров и их воспроизведение без сети
+	Cassette CassetteConfig `yaml:"cassette"`
+	// Retention сроки хранения результатов и дампов запросов
+	Retention RetentionConfig `yaml:"retention"`
+	// Transcript стенограмма сессии в SessionsDir/<id>/transcript.md, по
+	// умолчанию включена; SessionsDir по умолчанию OutputDir/sessions
+	Transcript  *bool  `yaml:"transcript"`
+	SessionsDir string `yaml:"sessionsDir"`
+	// StatusSocket unix-сокет для команды status, по умолчанию
+	// OutputDir/.status.sock
+	StatusSocket string `yaml:"statusSocket"`
+	// Debug включает подробный вывод; то же, что logLevel: debug
+	Debug bool `yaml:"debug"`
+	// DebugHTTP пишет дампы запросов к провайдерам в OutputDir/.debug;
+	// применяется без перезапуска при изменении config.yml
+	DebugHTTP bool `yaml:"debugHTTP"`
+	// Language язык логов и сообщений в терминале: ru (по умолчанию) или en;
+	// сообщения без перевода выводятся на английском
+	Language string `yaml:"language"`
+	// LogLevel уровень логирования: debug, info (по умолчанию), warn, error
+	LogLevel string `yaml:"logLevel"`
+	// LogFormat формат логов: text (по умолчанию) или json
+	LogFormat string `yaml:"logFormat"`
+	// LogFile дублирует логи в файл; при достижении LogMaxSize (МБ, по
+	// умолчанию 100) файл ротируется, хранится не более LogMaxBackups старых
+	// файлов не старше LogMaxAge (0 — без ограничения)
+	LogFile       string        `yaml:"logFile"`
+	LogMaxSize    int           `yaml:"logMaxSize"`
+	LogMaxBackups int           `yaml:"logMaxBackups"`
+	LogMaxAge     time.Duration `yaml:"logMaxAge"`
+	// StatusLine строка состояния в терминале, по умолчанию включена; без
+	// терминала или при statusLine: false состояние пишется в лог раз в
+	// StatusInterval (по умолчанию 30s)
+	StatusLine     *bool         `yaml:"statusLine"`
+	StatusInterval time.Duration `yaml:"statusInterval"`
+}
+
+// CaptureConfig встроенный захват экрана. Доступен только в сборке с
+// -tags capture, так как требует платформенных библиотек.
+type CaptureConfig struct {
+	// Hotkey глобальное сочетание клавиш, например "ctrl+shift+s"
+	Hotkey string `yaml:"hotkey"`
+	// Display номер монитора, 0 — основной
+	Display int `yaml:"display"`
+	// Window захватывать только окно, заголовок которого содержит эту
+	// строку; PickWindow — выбрать окно из списка при запуске. Снимается
+	// прямоугольник окна на экране, поэтому перекрывающие окна попадают в
+	// снимок. Пока окна нет, снимки пропускаются с предупреждением, а не
+	// делаются с целого экрана. Поддерживаются X11 (нужен wmctrl), macOS
+	// и Windows.
+	Window     string `yaml:"window"`
+	PickWindow bool   `yaml:"pickWindow"`
+	// Region область захвата относительно левого верхнего угла монитора
+	// или окна
+	Region *CaptureRect `yaml:"region"`
+	// SaveDir дополнительно сохраняет копии снимков для архива
+	SaveDir string `yaml:"saveDir"`
+	// Interval включает периодический захват той же области; 0 — выключен.
+	// В конвейер попадают только кадры, которые отличаются от последнего
+	// отправленного больше чем на Similarity бит перцептивного хеша из 64
+	// (по умолчанию 6) и держатся хотя бы два снимка подряд, не чаще MinGap
+	// (по умолчанию 30s) и не больше MaxPerHour (по умолчанию 30)
+	Interval   time.Duration `yaml:"interval"`
+	Similarity int           `yaml:"similarity"`
+	MinGap     time.Duration `yaml:"minGap"`
+	MaxPerHour int           `yaml:"maxPerHour"`
+}
+
+type CaptureRect struct {
+	X      int `yaml:"x"`
+	Y      int `yaml:"y"`
+	Width  int `yaml:"width"`
+	Height int `yaml:"height"`
+}
+
+// StringList список строк, который в YAML можно задать и одной строкой
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var one string
+	if err := unmarshal(&one); err == nil {
+		*l = StringList{one}
+		return nil
+	}
+	var many []string
+	if err := unmarshal(&many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// Style пресет ответа: дополнение промпта, параметры генерации и вывод
+type Style struct {
+	// Prompt указание модели, добавляется после текста вопроса
+	Prompt string `yaml:"prompt"`
+	// MaxOutputTokens и Temperature параметры генерации; 0 и nil — по
+	// умолчанию модели
+	MaxOutputTokens int      `yaml:"maxOutputTokens"`
+	Temperature     *float64 `yaml:"temperature"`
+	// CodeOnly оставляет в результате только блоки кода
+	CodeOnly bool `yaml:"codeOnly"`
+	// Hints прячет разделы «### …» ответа в раскрывающиеся блоки
+	// <details>, чтобы открывать подсказки по одной
+	Hints bool `yaml:"hints"`
+}
+
+// BuiltinStyles стили, доступные без настройки
+var BuiltinStyles = map[string]Style{
+	"brief": {
+		Prompt:          "Ответь очень кратко: не больше двух-трёх предложений, код — только если без него не обойтись.",
+		MaxOutputTokens: 256,
+	},
+	"detailed": {
+		Prompt: "Ответь подробно: разбери подход, крайние случаи, сложность по времени и памяти и возможные альтернативы.",
+	},
+	"hints": {
+		Prompt: "Не давай готового решения и полного кода. Дай ровно три подсказки, от слабой к сильной, " +
+			"разделами «### Подсказка 1», «### Подсказка 2», «### Подсказка 3», а затем раздел " +
+			"«### Техника» с названием приёма, на котором строится решение.",
+		Hints: true,
+	},
+	"code-only": {
+		Prompt:   "Ответь только кодом в блоке с указанием языка, без пояснений вне кода.",
+		CodeOnly: true,
+	},
+}
+
+// StyleByName стиль по имени: из Styles или встроенный. Пустое имя —
+// стиль без изменений.
+func (c *Config) StyleByName(name string) (Style, bool) {
+	if name == "" {
+		return Style{}, true
+	}
+	if s, ok := c.Styles[name]; ok {
+		return s, true
+	}
+	s, ok := BuiltinStyles[name]
+	return s, ok
+}
+
+// NotionConfig на каждый вопрос создаёт страницу в базе DatabaseID от
+// имени интеграции Token (база должна быть открыта интеграции). Ответ
+// переводится в блоки Notion, свойства заполняются по именам из Properties.
+// Публикация идёт в фоне после сохранения <имя>.md и на него не влияет.
+type NotionConfig struct {
+	Token      string `yaml:"token"`
+	DatabaseID string `yaml:"databaseId"`
+	// BaseURL адрес API; пустой — https://api.notion.com
+	BaseURL string `yaml:"baseURL"`
+	// Session значение свойства сессии, например компания; пустое —
+	// идентификатор текущей сессии
+	Session string `yaml:"session"`
+	// Status значение свойства статуса новой страницы (по умолчанию New)
+	Status     string           `yaml:"status"`
+	Properties NotionProperties `yaml:"properties"`
+}
+
+// NotionProperties имена свойств базы: Title — заголовок, Date — дата,
+// Session, Kind и Status — свойства типа select. Значение "-" — свойство
+// не заполняется.
+type NotionProperties struct {
+	Title   string `yaml:"title"`
+	Date    string `yaml:"date"`
+	Session string `yaml:"session"`
+	Kind    string `yaml:"kind"`
+	Status  string `yaml:"status"`
+}
+
+// DiscordConfig вебхук канала Discord; переменная окружения
+// DISCORD_WEBHOOK_URL, если задана, важнее WebhookURL. Ответ длиннее
+// лимитов Discord продолжается следующими сообщениями, блоки кода не
+// разрываются.
+type DiscordConfig struct {
+	WebhookURL string `yaml:"webhookURL"`
+}
+
+// Шифрование соединения с SMTP-сервером
+const (
+	EmailSTARTTLS = "starttls"
+	EmailTLS      = "tls"
+	EmailNoTLS    = "none"
+)
+
+// EmailConfig отправка каждого ответа письмом через SMTP-сервер Addr
+// (host:port): текст и HTML-версия из Markdown, при Attach — исходный
+// снимок, если он не больше MaxAttachmentSize (по умолчанию 5 МБ). TLS —
+// starttls (по умолчанию), tls (обычно порт 465) или none. Неудачная
+// отправка повторяется с паузой, затем пишется в лог; --email-test
+// проверяет вход и сертификат пробным письмом при запуске.
+type EmailConfig struct {
+	Addr              string     `yaml:"addr"`
+	TLS               string     `yaml:"tls"`
+	Username          string     `yaml:"username"`
+	Password          string     `yaml:"password"`
+	From              string     `yaml:"from"`
+	To                StringList `yaml:"to"`
+	Attach            bool       `yaml:"attach"`
+	MaxAttachmentSize int64      `yaml:"maxAttachmentSize"`
+}
+
+// GitConfig коммитит файлы результатов, если OutputDir — git-репозиторий:
+// после каждого файла или, при Interval, накопленное раз в Interval и при
+// завершении. Message — шаблон text/template с полями .Count (число
+// вопросов), .Session и .Time. Push отправляет коммит в remote по
+// умолчанию. Ошибки git, в том числе без настроенного user.name и
+// user.email, только пишутся в лог.
+type GitConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	Message  string        `yaml:"message"`
+	Push     bool          `yaml:"push"`
+}
+
+// StagesConfig делит обработку на этапы: OCR обработчиков распознают
+// файлы и передают текст LLM обработчикам модели, которые получают и
+// сохраняют ответ. Пока модель отвечает, распознаются следующие файлы, а
+// число запросов к каждому провайдеру ограничено своим этапом. Между
+// этапами ждут не больше LLM распознанных файлов: дальше распознавание
+// останавливается, пока модель не освободится. Время ожидания модели в
+// fileTimeout не входит. Итоги, стенограмма и публикация идут в порядке
+// очереди, а не получения ответов. Если задан один из этапов, у другого
+// один обработчик; без обоих файл целиком обрабатывает один из Workers.
+type StagesConfig struct {
+	OCR int `yaml:"ocr"`
+	LLM int `yaml:"llm"`
+}
+
+// Enabled сообщает, включено ли деление на этапы
+func (c StagesConfig) Enabled() bool {
+	return c.OCR > 0 || c.LLM > 0
+}
+
+// RetentionConfig сроки хранения файлов в OutputDir и SessionsDir:
+// файлы старше срока удаляются при запуске и далее раз в сутки, командой
+// cleanup — вручную. Outputs — результаты и стенограммы, Debug — дампы
+// запросов в OutputDir/.debug; 0 — без ограничения. Вместе с результатами
+// удаляются записи состояния о файлах, которых уже нет во входной
+// директории. Trash переносит файлы в OutputDir/.trash, откуда они
+// удаляются через TrashKeep (по умолчанию 168h). Сессия с файлом pinned в
+// SessionsDir/<id> не удаляется вместе с результатами её вопросов.
+type RetentionConfig struct {
+	Outputs   time.Duration `yaml:"outputs"`
+	Debug     time.Duration `yaml:"debug"`
+	Trash     bool          `yaml:"trash"`
+	TrashKeep time.Duration `yaml:"trashKeep"`
+}
+
+// Enabled сообщает, задан ли хотя бы один срок хранения
+func (c RetentionConfig) Enabled() bool {
+	return c.Outputs > 0 || c.Debug > 0
+}
+
+// ObsidianConfig копия каждого ответа в <Vault>/<Folder> (по умолчанию
+// папка hack_interview) под именем из заголовка вопроса, с тегами
+// interview, вида вопроса и сессии во front matter и ссылкой на заметку
+// сессии session-<id>, в которой перечислены все её вопросы. Результат в
+// OutputDir сохраняется как обычно.
+type ObsidianConfig struct {
+	Vault  string `yaml:"vault"`
+	Folder string `yaml:"folder"`
+}
+
+// GDocsConfig доступ к Google Docs. Credentials — JSON-ключ сервисного
+// аккаунта или пользователя (authorized_user, с refresh_token). Документы
+// сервисного аккаунта видны только ему, поэтому для него удобнее создать
+// документ самому, открыть его адресу client_email и указать DocumentID.
+// При Publish каждый ответ дописывается по ходу работы (без DocumentID — в
+// документ сессии), export --format gdoc выгружает ещё не записанные.
+type GDocsConfig struct {
+	Credentials string `yaml:"credentials"`
+	DocumentID  string `yaml:"documentId"`
+	Publish     bool   `yaml:"publish"`
+	// BaseURL адрес Docs API; пустой — https://docs.googleapis.com
+	BaseURL string `yaml:"baseURL"`
+}
+
+// VerifyConfig после ответа отдельным запросом просит модель найти в нём
+// ошибки, непокрытые крайние случаи и фактические неточности; итог
+// выводится разделом «## Review» в конце результата. При Regenerate и
+// найденных замечаниях ответ один раз запрашивается заново с ними, и новый
+// ответ проверяется ещё раз. Model — модель проверки (по умолчанию та же,
+// что отвечает). Примерно удваивает время и расход запросов, поэтому
+// выключено по умолчанию; --no-verify отключает на один запуск.
+type VerifyConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Model      string `yaml:"model"`
+	Regenerate bool   `yaml:"regenerate"`
+	// Prompt заменяет промпт проверки; вопрос и ответ добавляются после него
+	Prompt string `yaml:"prompt"`
+}
+
+// CompareConfig режим сравнения: запрос параллельно отправляется моделям
+// Models, ответы выводятся друг под другом разделами «## <имя>» со
+// временем ответа и расходом токенов. Ошибка одной модели не мешает
+// остальным: вместо её ответа выводится ошибка. Каждая модель — отдельный
+// платный запрос, поэтому режим включается только для файлов, чей путь
+// относительно InputDir подходит под шаблон Dirs, и для файлов с меткой
+// +compare в имени (q17+compare.png). Disagreements добавляет раздел
+// «## Расхождения» одним дополнительным запросом к основной модели.
+type CompareConfig struct {
+	Models        []CompareModel `yaml:"models"`
+	Dirs          []string       `yaml:"dirs"`
+	Disagreements bool           `yaml:"disagreements"`
+}
+
+// CompareModel модель Gemini API или совместимого шлюза для сравнения
+type CompareModel struct {
+	// Name заголовок раздела ответа; по умолчанию Model
+	Name  string `yaml:"name"`
+	Model string `yaml:"model"`
+	// APIKey ключ; пустой — GEMINI_API_KEY
+	APIKey         string `yaml:"apiKey"`
+	ProviderConfig `yaml:",inline"`
+	KeyInHeader    bool `yaml:"keyInHeader"`
+}
+
+// TestsConfig после основного ответа отдельным запросом к модели получает
+// тесты для кода на Language (по умолчанию go) и сохраняет код в
+// <имя>.<ext>, а тесты в <имя>_test.<ext>. Удваивает расход запросов,
+// поэтому выключено по умолчанию; --no-tests отключает на один запуск.
+type TestsConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Language string `yaml:"language"`
+	// Prompt заменяет промпт по умолчанию; код добавляется после него
+	Prompt string `yaml:"prompt"`
+}
+
+// KindConfig распознавание вида вопроса и его промпт. Вид задаётся меткой
+// в начале текста ([sql]/SQL:, [design]/design:) или набором Threshold
+// очков по ключевым словам (0 — только по метке); Keywords добавляет свои
+// слова.
+//
+// sql: по умолчанию 2 очка; очко за JOIN, GROUP BY, PARTITION BY и т. п. и
+// два за SELECT ... FROM. Код из блоков ```sql сохраняется в <имя>.sql.
+//
+// design: по умолчанию 2 очка; очко за «архитектура», «масштабирование»,
+// «load balancer» и т. п. Ответ строится по разделам с диаграммой Mermaid;
+// некорректная диаграмма заменяется списком компонентов.
+//
+// behavioral: по умолчанию 3 очка; очко за «tell me about a time»,
+// «конфликт», «коллег» и т. п. Ответ — пункты Situation/Task/Action/Result
+// и два вероятных уточняющих вопроса; код из него не извлекается и не
+// проверяется.
+type KindConfig struct {
+	Threshold *int     `yaml:"threshold"`
+	Keywords  []string `yaml:"keywords"`
+	Prompt    string   `yaml:"prompt"`
+}
+
+// AudioConfig распознавание речи в файлах .wav, .mp3 и .m4a. Текст записи
+// отправляется модели как распознанный текст изображения и сохраняется в
+// <имя>.ocr.txt. Backend: openai (Whisper API, ключ APIKey) или
+// whisper.cpp (Binary, по умолчанию whisper-cli, и обязательный путь к
+// модели Model); пустой — аудиофайлы не обрабатываются. Записи режутся
+// ffmpeg на части по ChunkSeconds (по умолчанию 600): для whisper.cpp
+// всегда, для Whisper API — если файл больше 25 МБ.
+type AudioConfig struct {
+	Backend  string            `yaml:"backend"`
+	APIKey   string            `yaml:"apiKey"`
+	BaseURL  string            `yaml:"baseURL"`
+	Headers  map[string]string `yaml:"headers"`
+	Binary   string            `yaml:"binary"`
+	Model    string            `yaml:"model"`
+	FFmpeg   string            `yaml:"ffmpeg"`
+	Language string            `yaml:"language"`
+	// ChunkSeconds длина части записи в секундах
+	ChunkSeconds int `yaml:"chunkSeconds"`
+}
+
+// Бэкенды распознавания речи
+const (
+	AudioOpenAI     = "openai"
+	AudioWhisperCPP = "whisper.cpp"
+)
+
+// ImageConfig подготовка снимков перед OCR; исходные файлы не меняются
+type ImageConfig struct {
+	// Trim срезает однотонные поля вокруг содержимого, например фон
+	// вокруг модального окна на большом мониторе
+	Trim TrimConfig `yaml:"trim"`
+	// Split режет очень высокие снимки, например прокрученную страницу
+	// целиком, на полосы для OCR
+	Split ImageSplitConfig `yaml:"split"`
+	// Invert инвертирует тёмные снимки, например IDE с тёмной темой
+	Invert InvertConfig `yaml:"invert"`
+}
+
+// TrimConfig обрезка полей. Поле — строки и столбцы у края, все пиксели
+// которых отличаются от первого не больше Tolerance по каждому каналу
+// (0-255, по умолчанию 12). Если содержимое после обрезки уже или ниже
+// MinSize пикселей (по умолчанию 64), снимок почти пустой и не обрезается.
+type TrimConfig struct {
+	Enabled   bool `yaml:"enabled"`
+	Tolerance int  `yaml:"tolerance"`
+	MinSize   int  `yaml:"minSize"`
+}
+
+// ImageSplitConfig нарезка высоких снимков. Снимок выше MaxHeight пикселей
+// (0 — не резать) распознаётся полосами этой высоты, соседние полосы
+// перекрываются на Overlap пикселей (по умолчанию 150), чтобы строка на
+// границе целиком попала в одну из них; повтор из перекрытия убирается при
+// склейке текста. Полос не больше MaxStrips (по умолчанию 8): если нужно
+// больше, полосы делаются выше. Ошибка OCR одной полосы — предупреждение,
+// текст собирается из остальных.
+type ImageSplitConfig struct {
+	MaxHeight int `yaml:"maxHeight"`
+	Overlap   int `yaml:"overlap"`
+	MaxStrips int `yaml:"maxStrips"`
+}
+
+// InvertConfig инверсия тёмных снимков перед OCR: снимок переводится в
+// оттенки серого и инвертируется. Mode: off (по умолчанию) — никогда,
+// auto — если медиана яркости ниже Threshold (0-255, по умолчанию 100),
+// always — всегда. Dirs задаёт режим для файлов, чей путь относительно
+// InputDir подходит под шаблон, как styleDirs. Решение пишется в лог и в
+// отчёт об обработке.
+type InvertConfig struct {
+	Mode      string            `yaml:"mode"`
+	Threshold int               `yaml:"threshold"`
+	Dirs      map[string]string `yaml:"dirs"`
+}
+
+// Режимы инверсии снимков
+const (
+	InvertAuto   = "auto"
+	InvertAlways = "always"
+	InvertOff    = "off"
+)
+
+// MinTextConfig минимальный размер текста вопроса
+type MinTextConfig struct {
+	Chars *int `yaml:"chars"`
+	Words *int `yaml:"words"`
+}
+
+// GroupConfig объединение нескольких снимков одного вопроса в один
+// запрос: текст частей склеивается по порядку, ответ один на всю группу.
+// Группу составляют файлы, пришедшие, пока во входной директории есть
+// файл GROUP, а при Suffix — и файлы с общим началом имени и суффиксами
+// -1 ... -9 (task-1.png, task-2.png; без части -1 файлы обрабатываются
+// отдельно). Группа ждёт следующую часть Wait (по умолчанию 45s) после
+// последней пришедшей; удаление GROUP отправляет группу сразу.
+type GroupConfig struct {
+	Suffix bool          `yaml:"suffix"`
+	Wait   time.Duration `yaml:"wait"`
+}
+
+// SplitQuestionsConfig разделение снимка с несколькими независимыми
+// вопросами: отдельный короткий запрос к модели возвращает их по одному, и
+// каждый получает свой ответ <имя>-q1.md, <имя>-q2.md ... Если модель не
+// уверена, частей больше Max (по умолчанию 5) или они заметно короче
+// текста, он обрабатывается целиком.
+type SplitQuestionsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Max     int  `yaml:"max"`
+}
+
+// QuestionFilterConfig отсев текста, не похожего на вопрос: редактор,
+// переписка, календарь. Очки дают вопросительный знак, вопросительные
+// слова, глаголы задания в начале предложения (implement, напишите, дан)
+// и признаки кода; много меток времени отнимает очки. Mode: off (по
+// умолчанию) — не проверять, warn — писать в лог оценку ниже Threshold
+// (по умолчанию 2), но отправлять модели, чтобы подобрать порог на своих
+// снимках, skip — пропускать такие файлы. reprocess --force обходит и
+// этот фильтр.
+type QuestionFilterConfig struct {
+	Mode      string `yaml:"mode"`
+	Threshold *int   `yaml:"threshold"`
+}
+
+// BreakerConfig после Threshold (по умолчанию 5; 0 — выключено) ошибок
+// подряд, которые имеет смысл повторять (сеть, 5xx, 429, таймаут), цепь к
+// OCR.space или Gemini размыкается на Cooldown (по умолчанию 1m). Mode
+// wait (по умолчанию) — выдача заданий приостанавливается до конца паузы,
+// fail — запросы сразу уходят запасному провайдеру (ocrFallback,
+// geminiFallback), а без него завершаются ошибкой, и файл ждёт повтора.
+// По истечении паузы один пробный запрос проверяет, восстановился ли
+// провайдер: успех замыкает цепь, ошибка размыкает её снова.
+type BreakerConfig struct {
+	Threshold *int          `yaml:"threshold"`
+	Cooldown  time.Duration `yaml:"cooldown"`
+	Mode      string        `yaml:"mode"`
+	// OCRFallback другой адрес OCR.space, например резервный сервер
+	OCRFallback ProviderConfig `yaml:"ocrFallback"`
+	// GeminiFallback другая модель или шлюз Gemini API
+	GeminiFallback CompareModel `yaml:"geminiFallback"`
+}
+
+// Режимы Breaker
+const (
+	BreakerWait = "wait"
+	BreakerFail = "fail"
+)
+
+// Провайдеры OCRProvider и LLMProvider
+const (
+	ProviderOCRSpace = "ocrspace"
+	ProviderGemini   = "gemini"
+	ProviderMock     = "mock"
+)
+
+// Тарифы OCR.space
+const (
+	OCRPlanFree = "free"
+	OCRPlanPro  = "pro"
+)
+
+// ocrPlans адрес API по умолчанию и предельный размер файла тарифа
+var ocrPlans = map[string]struct {
+	endpoint string
+	maxSize  int64
+}{
+	OCRPlanFree: {"https://api.ocr.space", 1 << 20},
+	OCRPlanPro:  {"https://apipro1.ocr.space", 5 << 20},
+}
+
+// OCRBaseURL адрес OCR.space: ocr.baseURL, затем ocrEndpoint, затем адрес
+// тарифа
+func (c *Config) OCRBaseURL() string {
+	switch {
+	case c.OCR.BaseURL != "":
+		return c.OCR.BaseURL
+	case c.OCREndpoint != "":
+		return c.OCREndpoint
+	}
+	return ocrPlans[c.OCRPlan].endpoint
+}
+
+// OCRMaxFileSize предельный размер файла для OCR.space по тарифу
+func (c *Config) OCRMaxFileSize() int64 {
+	return ocrPlans[c.OCRPlan].maxSize
+}
+
+// validateOCREndpoint ocrEndpoint — https-адрес на хосте ocr.space,
+// подходящий тарифу
+func (c *Config) validateOCREndpoint() error {
+	if _, ok := ocrPlans[c.OCRPlan]; !ok {
+		return fmt.Errorf("ocrPlan может быть %s или %s, а не %q", OCRPlanFree, OCRPlanPro, c.OCRPlan)
+	}
+	if c.OCREndpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(c.OCREndpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("ocrEndpoint: нужен адрес https://..., а не %q", c.OCREndpoint)
+	}
+	host := u.Hostname()
+	if host != "ocr.space" && !strings.HasSuffix(host, ".ocr.space") {
+		return fmt.Errorf("ocrEndpoint: %q не адрес OCR.space; адрес шлюза задаётся в ocr.baseURL", c.OCREndpoint)
+	}
+	if c.OCRPlan == OCRPlanFree && strings.HasPrefix(host, "apipro") {
+		return fmt.Errorf("ocrEndpoint %s только для тарифа pro: задайте ocrPlan: pro", host)
+	}
+	return nil
+}
+
+// MockConfig заготовленные ответы провайдеров mock. В Fixtures (по
+// умолчанию fixtures) лежат <имя>.ocr.txt — распознанный текст и <имя>.md —
+// ответ модели, где имя — имя входного файла без расширения и метки
+// промпта; для файлов без своей заготовки берутся default.ocr.txt и
+// default.md, а без них — Text и Answer. OCRLatency (по умолчанию 700ms) и
+// LLMLatency (по умолчанию 1.5s) имитируют время ответа API, разброс ±25%.
+type MockConfig struct {
+	Fixtures   string        `yaml:"fixtures"`
+	OCRLatency time.Duration `yaml:"ocrLatency"`
+	LLMLatency time.Duration `yaml:"llmLatency"`
+	Text       string        `yaml:"text"`
+	Answer     string        `yaml:"answer"`
+}
+
+// Режимы QuestionFilter
+const (
+	QuestionFilterOff  = "off"
+	QuestionFilterWarn = "warn"
+	QuestionFilterSkip = "skip"
+)
+
+// KnownProblemsConfig распознавание известных задач. Встроенный набор
+// (LRU Cache, Two Sum, Merge Intervals и др.) дополняется задачами из File
+// в том же формате: name, number, approach, phrases, keywords. Совпадение
+// с уверенностью от Threshold (по умолчанию 0.5, то есть 3 очка из 6:
+// фраза условия даёт 2, слово — 1) добавляет в начало ответа
+// заметку «Похоже на: ...» с процентом уверенности и подсказывает подход
+// модели; сам ответ не заменяется. Enabled по умолчанию включено.
+type KnownProblemsConfig struct {
+	Enabled   *bool   `yaml:"enabled"`
+	File      string  `yaml:"file"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+// DefaultSQLPrompt промпт SQL-задач по умолчанию
+const DefaultSQLPrompt = "Это задача на SQL. Напиши запрос, который требуется, в блоке ```sql. " +
+	"Затем кратко объясни логику соединений, группировок и оконных функций " +
+	"и покажи ожидаемый результат на примере таблицы из условия. Задача"
+
+// DefaultDesignPrompt промпт вопросов по проектированию по умолчанию
+const DefaultDesignPrompt = "Это вопрос по проектированию системы. Ответь разделами: «## Требования» " +
+	"(функциональные и нефункциональные), «## Оценка нагрузки» (RPS, объём данных, трафик), " +
+	"«## Архитектура» с диаграммой в блоке ```mermaid (graph TD, узлы вида id[Название]), " +
+	"«## Модель данных», «## Компромиссы». Вопрос"
+
+// DefaultBehavioralPrompt промпт поведенческих вопросов по умолчанию
+const DefaultBehavioralPrompt = "Это поведенческий вопрос на собеседовании, код не нужен. Дай тезисы " +
+	"ответа по STAR списками: «## Situation», «## Task», «## Action», «## Result» — от первого лица, " +
+	"конкретно, с цифрами результата. В конце раздел «## Уточняющие вопросы» с двумя вопросами, " +
+	"которые вероятнее всего задаст интервьюер, и коротким тезисом ответа на каждый. Вопрос"
+
+// QuotaConfig учёт расхода запросов и токенов по дням. Счётчики хранятся
+// в File (по умолчанию OutputDir/.quota.json) и переживают перезапуск.
+// При достижении доли WarnAt (по умолчанию 0.8) любого лимита пишется
+// предупреждение, а при Stop обработка приостанавливается до сброса
+// лимита.
+type QuotaConfig struct {
+	File     string     `yaml:"file"`
+	WarnAt   float64    `yaml:"warnAt"`
+	Stop     bool       `yaml:"stop"`
+	OCRSpace QuotaLimit `yaml:"ocrspace"`
+	Gemini   QuotaLimit `yaml:"gemini"`
+}
+
+// QuotaLimit лимиты одного провайдера; 0 — без ограничения
+type QuotaLimit struct {
+	DailyRequests int `yaml:"dailyRequests"`
+	DailyTokens   int `yaml:"dailyTokens"`
+	// ResetZone часовой пояс, в полночь которого провайдер сбрасывает
+	// счётчики: UTC для OCR.space, America/Los_Angeles для Gemini
+	ResetZone string `yaml:"resetZone"`
+}
+
+// CassetteConfig режим record пишет ответы провайдеров в Dir, replay
+// отдаёт их оттуда без запросов к API и завершается ошибкой, если записи
+// нет
+type CassetteConfig struct {
+	Mode string `yaml:"mode"`
+	// Dir директория записей, по умолчанию cassettes
+	Dir string `yaml:"dir"`
+}
+
+// ServerConfig необязательный HTTP-сервер; выключен, пока не задан Listen
+type ServerConfig struct {
+	Listen string `yaml:"listen"`
+	// Token общий секрет, ожидается в заголовке Authorization: Bearer <token>
+	Token string `yaml:"token"`
+	// Upload включает POST /submit и GET /result/{id}
+	Upload bool `yaml:"upload"`
+	// MaxUploadSize предельный размер загружаемого изображения в байтах
+	MaxUploadSize int64 `yaml:"maxUploadSize"`
+	// Health включает /healthz и /readyz (без токена, для мониторинга)
+	Health bool `yaml:"health"`
+	// HealthStaleAfter через сколько без прохода наблюдателя /healthz
+	// сообщает о сбое
+	HealthStaleAfter time.Duration `yaml:"healthStaleAfter"`
+	// Metrics включает /metrics в формате Prometheus (без токена)
+	Metrics bool `yaml:"metrics"`
+	// MaxErrorRate допустимая доля ошибок последних обращений к API для /readyz
+	MaxErrorRate float64 `yaml:"maxErrorRate"`
+	// UI включает веб-интерфейс /ui/ для просмотра ответов (с токеном)
+	UI bool `yaml:"ui"`
+	// API включает REST API только для чтения /api/... (с токеном)
+	API bool `yaml:"api"`
+}
+
+// GRPCConfig gRPC-сервис заданий (internal/jobspb/jobs.proto)
+type GRPCConfig struct {
+	// Listen адрес сервиса; пустой — сервис выключен
+	Listen string `yaml:"listen"`
+	// Token общий секрет, ожидается в метаданных authorization: Bearer <token>
+	Token string `yaml:"token"`
+	// TLSCert и TLSKey сертификат и ключ сервера в PEM; без них соединение
+	// не шифруется
+	TLSCert string `yaml:"tlsCert"`
+	TLSKey  string `yaml:"tlsKey"`
+	// MaxImageSize предельный размер изображения в SubmitImage в байтах
+	MaxImageSize int64 `yaml:"maxImageSize"`
+}
+
+// S3Config источник изображений из бакета S3 или совместимого хранилища
+type S3Config struct {
+	Bucket string `yaml:"bucket"`
+	Prefix string `yaml:"prefix"`
+	Region string `yaml:"region"`
+	// Endpoint адрес S3-совместимого хранилища (MinIO и т. п.)
+	Endpoint     string `yaml:"endpoint"`
+	UsePathStyle bool   `yaml:"usePathStyle"`
+	// AccessKeyID и SecretAccessKey; если не заданы, используется
+	// стандартная цепочка учётных данных AWS
+	AccessKeyID     string        `yaml:"accessKeyId"`
+	SecretAccessKey string        `yaml:"secretAccessKey"`
+	Interval        time.Duration `yaml:"interval"`
+}
+
+// IMAPConfig источник вложений из почтового ящика. Соединение только по TLS.
+type IMAPConfig struct {
+	// Addr адрес сервера host:port, например imap.gmail.com:993
+	Addr     string `yaml:"addr"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Folder   string `yaml:"folder"`
+	// From и Subject дополнительные условия поиска писем
+	From    string `yaml:"from"`
+	Subject string `yaml:"subject"`
+	// ProcessedFolder куда переносить письма после записи ответа;
+	// если не задан, письма помечаются прочитанными
+	ProcessedFolder   string        `yaml:"processedFolder"`
+	MaxAttachmentSize int64         `yaml:"maxAttachmentSize"`
+	Interval          time.Duration `yaml:"interval"`
+}
+
+// ProviderConfig адрес и заголовки запросов к провайдеру
+type ProviderConfig struct {
+	// BaseURL адрес API; по умолчанию публичный адрес провайдера
+	BaseURL string            `yaml:"baseURL"`
+	Headers map[string]string `yaml:"headers"`
+	// AllowInsecure разрешает BaseURL со схемой http
+	AllowInsecure bool `yaml:"allowInsecure"`
+}
+
+func (p ProviderConfig) validate(name string) error {
+	if p.BaseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(p.BaseURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s.baseURL: некорректный адрес %q", name, p.BaseURL)
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && p.AllowInsecure:
+	case u.Scheme == "http":
+		return fmt.Errorf("%s.baseURL: адрес без https допускается только с allowInsecure: true", name)
+	default:
+		return fmt.Errorf("%s.baseURL: неподдерживаемая схема %q", name, u.Scheme)
+	}
+	return nil
+}
+
+// GeminiConfig настройки Gemini API
+type GeminiConfig struct {
+	ProviderConfig `yaml:",inline"`
+	// Model модель ответов; пустая — gemini-2.0-flash
+	Model string `yaml:"model"`
+	// KeyInHeader передаёт ключ в заголовке x-goog-api-key, а не в адресе:
+	// шлюзы часто пишут адреса запросов в журналы
+	KeyInHeader bool `yaml:"keyInHeader"`
+	// Price цена токенов для оценки расходов в команде stats
+	Price TokenPrice `yaml:"price"`
+	// Multimodal отправляет модели вместе с распознанным текстом сам снимок
+	// (PNG, JPEG, WebP, HEIC). Снимки больше InlineLimit байт (по
+	// умолчанию 4 МБ) загружаются через Files API и после ответа
+	// удаляются, если не задан KeepFiles.
+	Multimodal  bool  `yaml:"multimodal"`
+	InlineLimit int64 `yaml:"inlineLimit"`
+	KeepFiles   bool  `yaml:"keepFiles"`
+}
+
+func (c CompareConfig) validate() error {
+	if len(c.Models) == 1 {
+		return fmt.Errorf("compare.models: для сравнения нужно хотя бы две модели")
+	}
+	if len(c.Models) == 0 && len(c.Dirs) > 0 {
+		return fmt.Errorf("compare.dirs: не заданы compare.models")
+	}
+	names := make(map[string]bool)
+	for i, m := range c.Models {
+		if m.Model == "" {
+			return fmt.Errorf("compare.models[%d]: не задана model", i)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("compare.models[%d]: повторяется имя %q", i, m.Name)
+		}
+		names[m.Name] = true
+		if err := m.ProviderConfig.validate(fmt.Sprintf("compare.models[%d]", i)); err != nil {
+			return err
+		}
+	}
+	for _, pattern := range c.Dirs {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("некорректный шаблон compare.dirs: %q", pattern)
+		}
+	}
+	return nil
+}
+
+func (c InvertConfig) validate() error {
+	valid := func(mode string) bool {
+		return mode == InvertAuto || mode == InvertAlways || mode == InvertOff
+	}
+	if !valid(c.Mode) {
+		return fmt.Errorf("image.invert.mode может быть %s, %s или %s, а не %q", InvertAuto, InvertAlways, InvertOff, c.Mode)
+	}
+	if c.Threshold > 255 {
+		return fmt.Errorf("image.invert.threshold должен быть не больше 255, а не %d", c.Threshold)
+	}
+	for pattern, mode := range c.Dirs {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("некорректный шаблон image.invert.dirs: %q", pattern)
+		}
+		if !valid(mode) {
+			return fmt.Errorf("image.invert.dirs[%q] может быть %s, %s или %s, а не %q", pattern, InvertAuto, InvertAlways, InvertOff, mode)
+		}
+	}
+	return nil
+}
+
+// TokenPrice цена миллиона токенов в долларах
+type TokenPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// Load читает файл конфигурации, заполняет значения по умолчанию и
+// проверяет настройки
+func Load(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("ошибка загрузки %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("ошибка разбора YAML: %w", err)
+	}
+	c.applyEnv()
+	if err := c.applyFlags(); err != nil {
+		return c, err
+	}
+	if err := c.resolveSecrets(); err != nil {
+		return c, fmt.Errorf("ошибка в %s: %w", path, err)
+	}
+	c.setDefaults()
+	if err := c.Validate(); err != nil {
+		return c, fmt.Errorf("ошибка в %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Keychain хранилище, из которого берутся значения keychain:<служба>/<запись>
+var Keychain secret.Store = secret.Keyring{}
+
+// Passphrase возвращает парольную фразу зашифрованных ключей (secret);
+// вызывается, только если в файле есть значения enc:v1:. Nil — такие
+// значения считаются ошибкой.
+var Passphrase func() (string, error)
+
+// secrets ключи и пароли, которые можно хранить зашифрованными
+func (c *Config) secrets() map[string]*string {
+	m := map[string]*string{
+		"OCR_API_KEY":                   &c.OCRAPIKey,
+		"GEMINI_API_KEY":                &c.GeminiAPIKey,
+		"audio.apiKey":                  &c.Audio.APIKey,
+		"breaker.geminiFallback.apiKey": &c.Breaker.GeminiFallback.APIKey,
+		"notion.token":                  &c.Notion.Token,
+		"discord.webhookURL":            &c.Discord.WebhookURL,
+		"email.password":                &c.Email.Password,
+		"imap.password":                 &c.IMAP.Password,
+		"s3.secretAccessKey":            &c.S3.SecretAccessKey,
+		"server.token":                  &c.Server.Token,
+		"grpc.token":                    &c.GRPC.Token,
+	}
+	for i := range c.Compare.Models {
+		m[fmt.Sprintf("compare.models[%d].apiKey", i)] = &c.Compare.Models[i].APIKey
+	}
+	return m
+}
+
+// resolveSecrets заменяет ссылки keychain: значениями из хранилища, а
+// зашифрованные значения — расшифрованными; открытые остаются как есть
+func (c *Config) resolveSecrets() error {
+	var passphrase string
+	asked := false
+	for name, v := range c.secrets() {
+		if secret.IsKeychainRef(*v) {
+			value, err := secret.Lookup(Keychain, *v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			*v = value
+		}
+		if !secret.IsEncrypted(*v) {
+			continue
+		}
+		if !asked {
+			if Passphrase == nil {
+				return fmt.Errorf("%s зашифрован, а парольная фраза не задана", name)
+			}
+			p, err := Passphrase()
+			if err != nil {
+				return fmt.Errorf("парольная фраза ключей: %w", err)
+			}
+			passphrase, asked = p, true
+		}
+		plaintext, err := secret.Decrypt(*v, passphrase)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*v = plaintext
+	}
+	return nil
+}
+
+func (c *Config) setDefaults() {
+	if c.StateFile == "" {
+		c.StateFile = filepath.Join(c.OutputDir, ".state.json")
+	}
+	if c.StateBackend == "" {
+		c.StateBackend = StateJSON
+	}
+	if c.Language == "" {
+		c.Language = "ru"
+	}
+	if c.StateDB == "" {
+		c.StateDB = filepath.Join(c.OutputDir, ".state.db")
+	}
+	if c.SessionsDir == "" {
+		c.SessionsDir = filepath.Join(c.OutputDir, "sessions")
+	}
+	if c.StatusSocket == "" {
+		c.StatusSocket = filepath.Join(c.OutputDir, ".status.sock")
+	}
+	if c.Gemini.Price == (TokenPrice{}) {
+		// Платный тариф gemini-2.0-flash
+		c.Gemini.Price = TokenPrice{Input: 0.10, Output: 0.40}
+	}
+	if c.RunExamplesTimeout <= 0 {
+		c.RunExamplesTimeout = 10 * time.Second
+	}
+	if c.Complexity == "" {
+		c.Complexity = "auto"
+	}
+	if c.Email.TLS == "" {
+		c.Email.TLS = EmailSTARTTLS
+	}
+	if c.Email.MaxAttachmentSize == 0 {
+		c.Email.MaxAttachmentSize = 5 << 20
+	}
+	if c.Retention.TrashKeep == 0 {
+		c.Retention.TrashKeep = 7 * 24 * time.Hour
+	}
+	if c.Git.Message == "" {
+		c.Git.Message = "hack_interview: вопросов {{.Count}}, сессия {{.Session}}"
+	}
+	if c.Obsidian.Folder == "" {
+		c.Obsidian.Folder = "hack_interview"
+	}
+	if c.Notion.Status == "" {
+		c.Notion.Status = "New"
+	}
+	for _, p := range []struct {
+		name *string
+		def  string
+	}{
+		{&c.Notion.Properties.Title, "Name"},
+		{&c.Notion.Properties.Date, "Date"},
+		{&c.Notion.Properties.Session, "Session"},
+		{&c.Notion.Properties.Kind, "Type"},
+		{&c.Notion.Properties.Status, "Status"},
+	} {
+		switch *p.name {
+		case "":
+			*p.name = p.def
+		case "-":
+			*p.name = ""
+		}
+	}
+	for i := range c.Compare.Models {
+		if c.Compare.Models[i].Name == "" {
+			c.Compare.Models[i].Name = c.Compare.Models[i].Model
+		}
+	}
+	if c.Tests.Language == "" {
+		c.Tests.Language = "go"
+	}
+	if c.SQL.Threshold == nil {
+		t := 2
+		c.SQL.Threshold = &t
+	}
+	if c.SQL.Prompt == "" {
+		c.SQL.Prompt = DefaultSQLPrompt
+	}
+	if c.Design.Threshold == nil {
+		t := 2
+		c.Design.Threshold = &t
+	}
+	if c.Design.Prompt == "" {
+		c.Design.Prompt = DefaultDesignPrompt
+	}
+	if c.Behavioral.Threshold == nil {
+		t := 3
+		c.Behavioral.Threshold = &t
+	}
+	if c.Behavioral.Prompt == "" {
+		c.Behavioral.Prompt = DefaultBehavioralPrompt
+	}
+	if c.Quota.File == "" {
+		c.Quota.File = filepath.Join(c.OutputDir, ".quota.json")
+	}
+	if c.Audio.ChunkSeconds <= 0 {
+		c.Audio.ChunkSeconds = 600
+	}
+	if c.Audio.Language == "" {
+		c.Audio.Language = "ru"
+	}
+	if c.Image.Trim.Tolerance <= 0 {
+		c.Image.Trim.Tolerance = 12
+	}
+	if c.Image.Trim.MinSize <= 0 {
+		c.Image.Trim.MinSize = 64
+	}
+	if c.Image.Split.Overlap <= 0 {
+		c.Image.Split.Overlap = 150
+	}
+	if c.Image.Split.MaxStrips <= 0 {
+		c.Image.Split.MaxStrips = 8
+	}
+	if c.Image.Invert.Mode == "" {
+		c.Image.Invert.Mode = InvertOff
+	}
+	if c.Image.Invert.Threshold <= 0 {
+		c.Image.Invert.Threshold = 100
+	}
+	if c.MinText.Chars == nil {
+		n := 6
+		c.MinText.Chars = &n
+	}
+	if c.MinText.Words == nil {
+		n := 2
+		c.MinText.Words = &n
+	}
+	if c.SplitQuestions.Max <= 0 {
+		c.SplitQuestions.Max = 5
+	}
+	if c.Group.Wait <= 0 {
+		c.Group.Wait = 45 * time.Second
+	}
+	if c.OCRProvider == "" {
+		c.OCRProvider = ProviderOCRSpace
+	}
+	if c.OCRPlan == "" {
+		c.OCRPlan = OCRPlanFree
+	}
+	if c.LLMProvider == "" {
+		c.LLMProvider = ProviderGemini
+	}
+	if c.Mock.Fixtures == "" {
+		c.Mock.Fixtures = "fixtures"
+	}
+	if c.Mock.OCRLatency <= 0 {
+		c.Mock.OCRLatency = 700 * time.Millisecond
+	}
+	if c.Mock.LLMLatency <= 0 {
+		c.Mock.LLMLatency = 1500 * time.Millisecond
+	}
+	if c.Mock.Text == "" {
+		c.Mock.Text = "Что такое горутина и чем она отличается от потока операционной системы?"
+	}
+	if c.Mock.Answer == "" {
+		c.Mock.Answer = "Горутина — лёгкий поток, которым управляет планировщик Go, а не ОС.\n\n" +
+			"- стек начинается с нескольких КБ и растёт по мере надобности;\n" +
+			"- планировщик распределяет горутины по потокам ОС (модель M:N);\n" +
+			"- создание и переключение намного дешевле, чем у потока.\n"
+	}
+	if c.Breaker.Threshold == nil {
+		n := 5
+		c.Breaker.Threshold = &n
+	}
+	if c.Breaker.Cooldown <= 0 {
+		c.Breaker.Cooldown = time.Minute
+	}
+	if c.Breaker.Mode == "" {
+		c.Breaker.Mode = BreakerWait
+	}
+	if c.Breaker.GeminiFallback.Name == "" {
+		c.Breaker.GeminiFallback.Name = c.Breaker.GeminiFallback.Model
+	}
+	if c.QuestionFilter.Mode == "" {
+		c.QuestionFilter.Mode = QuestionFilterOff
+	}
+	if c.QuestionFilter.Threshold == nil {
+		n := 2
+		c.QuestionFilter.Threshold = &n
+	}
+	if c.KnownProblems.Threshold <= 0 {
+		c.KnownProblems.Threshold = 0.5
+	}
+	if c.Quota.WarnAt <= 0 {
+		c.Quota.WarnAt = 0.8
+	}
+	if c.Quota.OCRSpace.ResetZone == "" {
+		c.Quota.OCRSpace.ResetZone = "UTC"
+	}
+	if c.Quota.Gemini.ResetZone == "" {
+		c.Quota.Gemini.ResetZone = "America/Los_Angeles"
+	}
+	if c.Cassette.Dir == "" {
+		c.Cassette.Dir = "cassettes"
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = 30 * time.Second
+	}
+	if c.FileTimeout <= 0 {
+		c.FileTimeout = 3 * time.Minute
+	}
+	if c.RequestTimeout <= 0 {
+		c.RequestTimeout = time.Minute
+	}
+	if c.StatusInterval <= 0 {
+		c.StatusInterval = 30 * time.Second
+	}
+	if c.Workers <= 0 {
+		c.Workers = 1
+	}
+	if c.Stages.Enabled() && c.Stages.OCR == 0 {
+		c.Stages.OCR = 1
+	}
+	if c.Stages.Enabled() && c.Stages.LLM == 0 {
+		c.Stages.LLM = 1
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 50
+	}
+	if c.Capture.Similarity <= 0 {
+		c.Capture.Similarity = 6
+	}
+	if c.Capture.MinGap <= 0 {
+		c.Capture.MinGap = 30 * time.Second
+	}
+	if c.Capture.MaxPerHour <= 0 {
+		c.Capture.MaxPerHour = 30
+	}
+	if c.ClipboardInterval <= 0 {
+		c.ClipboardInterval = time.Second
+	}
+	if c.S3.Interval <= 0 {
+		c.S3.Interval = 10 * time.Second
+	}
+	if c.IMAP.Folder == "" {
+		c.IMAP.Folder = "INBOX"
+	}
+	if c.IMAP.Interval <= 0 {
+		c.IMAP.Interval = 30 * time.Second
+	}
+	if c.IMAP.MaxAttachmentSize <= 0 {
+		c.IMAP.MaxAttachmentSize = 10 << 20
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 3
+	}
+	if c.InputDirGracePeriod <= 0 {
+		c.InputDirGracePeriod = 5 * time.Minute
+	}
+	if c.ErrorsDir == "" {
+		c.ErrorsDir = filepath.Join(c.InputDir, "errors")
+	}
+	if c.RetryDelay <= 0 {
+		c.RetryDelay = 30 * time.Second
+	}
+	if c.SortBy == "" {
+		c.SortBy = SortByMtime
+	}
+	if c.HTTP.MaxIdleConns <= 0 {
+		c.HTTP.MaxIdleConns = 8
+	}
+	if c.HTTP.IdleConnTimeout <= 0 {
+		c.HTTP.IdleConnTimeout = 90 * time.Second
+	}
+	if c.HTTP.RetryWait <= 0 {
+		c.HTTP.RetryWait = 500 * time.Millisecond
+	}
+	if c.GRPC.MaxImageSize <= 0 {
+		c.GRPC.MaxImageSize = 10 << 20
+	}
+	if c.Server.MaxUploadSize <= 0 {
+		c.Server.MaxUploadSize = 10 << 20
+	}
+	if c.Server.HealthStaleAfter <= 0 {
+		c.Server.HealthStaleAfter = 30 * time.Second
+	}
+	if c.Server.MaxErrorRate <= 0 {
+		c.Server.MaxErrorRate = 0.5
+	}
+}
+
+// Validate проверяет настройки, для которых нет разумного значения по
+// умолчанию
+func (c *Config) Validate() error {
+	switch c.SortBy {
+	case SortByMtime, SortByName:
+	default:
+		return fmt.Errorf("sortBy может быть %s или %s, а не %q", SortByMtime, SortByName, c.SortBy)
+	}
+	switch c.StateBackend {
+	case StateJSON, StateSQLite:
+	default:
+		return fmt.Errorf("stateBackend может быть %s или %s, а не %q", StateJSON, StateSQLite, c.StateBackend)
+	}
+	if !msg.Supported(c.Language) {
+		return fmt.Errorf("language может быть %s, а не %q", strings.Join(msg.Languages(), " или "), c.Language)
+	}
+	if err := validatePatterns(c.Include, c.Exclude); err != nil {
+		return err
+	}
+	if _, ok := c.StyleByName(c.Style); !ok {
+		return fmt.Errorf("неизвестный стиль style: %q", c.Style)
+	}
+	for pattern, name := range c.StyleDirs {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("некорректный шаблон styleDirs: %q", pattern)
+		}
+		if _, ok := c.StyleByName(name); !ok {
+			return fmt.Errorf("неизвестный стиль в styleDirs[%q]: %q", pattern, name)
+		}
+	}
+	if err := c.Compare.validate(); err != nil {
+		return err
+	}
+	if err := c.OCR.validate("ocr"); err != nil {
+		return err
+	}
+	if err := c.validateOCREndpoint(); err != nil {
+		return err
+	}
+	if err := c.Gemini.validate("gemini"); err != nil {
+		return err
+	}
+	if c.Gemini.Price.Input < 0 || c.Gemini.Price.Output < 0 {
+		return fmt.Errorf("gemini.price: цена не может быть отрицательной")
+	}
+	if c.Gemini.InlineLimit < 0 {
+		return fmt.Errorf("gemini.inlineLimit: размер не может быть отрицательным")
+	}
+	if _, err := time.LoadLocation(c.Quota.OCRSpace.ResetZone); err != nil {
+		return fmt.Errorf("quota.ocrspace.resetZone: %w", err)
+	}
+	if _, err := time.LoadLocation(c.Quota.Gemini.ResetZone); err != nil {
+		return fmt.Errorf("quota.gemini.resetZone: %w", err)
+	}
+	switch c.Complexity {
+	case "auto", "always", "off":
+	default:
+		return fmt.Errorf("complexity может быть auto, always или off, а не %q", c.Complexity)
+	}
+	if c.Image.Trim.Tolerance > 255 {
+		return fmt.Errorf("image.trim.tolerance должен быть не больше 255, а не %d", c.Image.Trim.Tolerance)
+	}
+	if s := c.Image.Split; s.MaxHeight > 0 && s.Overlap*2 >= s.MaxHeight {
+		return fmt.Errorf("image.split.overlap должен быть меньше половины maxHeight (%d), а не %d", s.MaxHeight, s.Overlap)
+	}
+	if err := c.Image.Invert.validate(); err != nil {
+		return err
+	}
+	switch c.Audio.Backend {
+	case "":
+	case AudioOpenAI:
+		if c.Audio.APIKey == "" {
+			return fmt.Errorf("для audio.backend %s необходимо задать audio.apiKey", AudioOpenAI)
+		}
+	case AudioWhisperCPP:
+		if c.Audio.Model == "" {
+			return fmt.Errorf("для audio.backend %s необходимо задать audio.model", AudioWhisperCPP)
+		}
+	default:
+		return fmt.Errorf("audio.backend может быть %s или %s, а не %q", AudioOpenAI, AudioWhisperCPP, c.Audio.Backend)
+	}
+	if c.Email.Addr != "" {
+		switch {
+		case c.Email.TLS != EmailSTARTTLS && c.Email.TLS != EmailTLS && c.Email.TLS != EmailNoTLS:
+			return fmt.Errorf("email.tls может быть starttls, tls или none, а не %q", c.Email.TLS)
+		case c.Email.From == "" || len(c.Email.To) == 0:
+			return fmt.Errorf("для отправки писем необходимо задать email.from и email.to")
+		}
+	}
+	if _, err := template.New("message").Parse(c.Git.Message); err != nil {
+		return fmt.Errorf("некорректный шаблон git.message: %w", err)
+	}
+	if (c.Notion.Token == "") != (c.Notion.DatabaseID == "") {
+		return fmt.Errorf("для публикации в Notion нужны и notion.token, и notion.databaseId")
+	}
+	if c.Capture.Interval > 0 && c.Capture.Interval < time.Second {
+		return fmt.Errorf("capture.interval должен быть не меньше 1s, а не %v", c.Capture.Interval)
+	}
+	switch c.QuestionFilter.Mode {
+	case QuestionFilterOff, QuestionFilterWarn, QuestionFilterSkip:
+	default:
+		return fmt.Errorf("questionFilter.mode может быть off, warn или skip, а не %q", c.QuestionFilter.Mode)
+	}
+	if c.KnownProblems.Threshold > 1 {
+		return fmt.Errorf("knownProblems.threshold должен быть от 0 до 1, а не %v", c.KnownProblems.Threshold)
+	}
+	switch c.OCRProvider {
+	case ProviderOCRSpace, ProviderMock:
+	default:
+		return fmt.Errorf("ocrProvider может быть %s или %s, а не %q", ProviderOCRSpace, ProviderMock, c.OCRProvider)
+	}
+	switch c.LLMProvider {
+	case ProviderGemini, ProviderMock:
+	default:
+		return fmt.Errorf("llmProvider может быть %s или %s, а не %q", ProviderGemini, ProviderMock, c.LLMProvider)
+	}
+	switch c.Breaker.Mode {
+	case BreakerWait, BreakerFail:
+	default:
+		return fmt.Errorf("breaker.mode может быть wait или fail, а не %q", c.Breaker.Mode)
+	}
+	if *c.Breaker.Threshold < 0 {
+		return fmt.Errorf("breaker.threshold не может быть отрицательным")
+	}
+	if err := c.Breaker.OCRFallback.validate("breaker.ocrFallback"); err != nil {
+		return err
+	}
+	if err := c.Breaker.GeminiFallback.ProviderConfig.validate("breaker.geminiFallback"); err != nil {
+		return err
+	}
+	if c.Stages.OCR < 0 || c.Stages.LLM < 0 {
+		return fmt.Errorf("stages: число обработчиков не может быть отрицательным")
+	}
+	if c.Retention.Outputs < 0 || c.Retention.Debug < 0 || c.Retention.TrashKeep < 0 {
+		return fmt.Errorf("retention: срок хранения не может быть отрицательным")
+	}
+	switch c.Cassette.Mode {
+	case "", "record", "replay":
+	default:
+		return fmt.Errorf("cassette.mode может быть record или replay, а не %q", c.Cassette.Mode)
+	}
+	if c.Server.Upload && c.Server.Token == "" {
+		return fmt.Errorf("для server.upload необходимо задать server.token")
+	}
+	if c.Server.UI && c.Server.Token == "" {
+		return fmt.Errorf("для server.ui необходимо задать server.token")
+	}
+	if c.Server.API && c.Server.Token == "" {
+		return fmt.Errorf("для server.api необходимо задать server.token")
+	}
+	if c.GRPC.Listen != "" && c.GRPC.Token == "" {
+		return fmt.Errorf("для grpc.listen необходимо задать grpc.token")
+	}
+	if (c.GRPC.TLSCert == "") != (c.GRPC.TLSKey == "") {
+		return fmt.Errorf("grpc.tlsCert и grpc.tlsKey задаются вместе")
+	}
+	return nil
+}
+
+// validatePatterns проверяет синтаксис шаблонов include/exclude
+func validatePatterns(include, exclude []string) error {
+	for _, p := range include {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("некорректный шаблон include: %q", p)
+		}
+	}
+	for _, p := range exclude {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("некорректный шаблон exclude: %q", p)
+		}
+	}
+	return nil
+}
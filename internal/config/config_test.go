@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/secret"
+)
+
+// baseYAML минимальная рабочая конфигурация с провайдерами-заготовками
+const baseYAML = "inputDir: in\noutputDir: out\nPROMPT: Ответь\nocrProvider: mock\nllmProvider: mock\n"
+
+// loadYAML загружает конфигурацию base+yml из временного файла
+func loadYAML(t *testing.T, yml string) (Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(baseYAML+yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+// withPassphrase подменяет Passphrase на время теста и считает вызовы
+func withPassphrase(t *testing.T, pass string) *int {
+	t.Helper()
+	calls := 0
+	old := Passphrase
+	Passphrase = func() (string, error) {
+		calls++
+		return pass, nil
+	}
+	t.Cleanup(func() { Passphrase = old })
+	return &calls
+}
+
+func TestLoadDecryptsSecrets(t *testing.T) {
+	t.Setenv("OCR_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	gemini, err := secret.Encrypt("gemini-key", "фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := secret.Encrypt("server-token", "фраза")
+	calls := withPassphrase(t, "фраза")
+
+	c, err := loadYAML(t, "OCR_API_KEY: plain-ocr-key\nGEMINI_API_KEY: "+gemini+"\nserver:\n  token: "+token+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GeminiAPIKey != "gemini-key" || c.Server.Token != "server-token" {
+		t.Errorf("расшифровано %q, %q", c.GeminiAPIKey, c.Server.Token)
+	}
+	// Открытый ключ остаётся как есть
+	if c.OCRAPIKey != "plain-ocr-key" {
+		t.Errorf("открытый ключ %q", c.OCRAPIKey)
+	}
+	if *calls != 1 {
+		t.Errorf("парольная фраза запрошена %d раз", *calls)
+	}
+
+	// Без зашифрованных значений фраза не нужна
+	*calls = 0
+	if _, err := loadYAML(t, "OCR_API_KEY: plain-ocr-key\n"); err != nil || *calls != 0 {
+		t.Errorf("%v, запросов фразы %d", err, *calls)
+	}
+}
+
+// Ошибка называет поле и отличает неверную фразу от повреждённого значения
+func TestLoadSecretErrors(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	gemini, err := secret.Encrypt("gemini-key", "фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withPassphrase(t, "другая")
+	if _, err := loadYAML(t, "GEMINI_API_KEY: "+gemini+"\n"); !errors.Is(err, secret.ErrWrongPassphrase) || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("неверная фраза: %v", err)
+	}
+
+	withPassphrase(t, "фраза")
+	if _, err := loadYAML(t, "GEMINI_API_KEY: "+gemini[:len(gemini)-6]+"\n"); !errors.Is(err, secret.ErrCorrupted) || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("повреждённое значение: %v", err)
+	}
+
+	Passphrase = nil
+	if _, err := loadYAML(t, "GEMINI_API_KEY: "+gemini+"\n"); err == nil || !strings.Contains(err.Error(), "парольная фраза не задана") {
+		t.Errorf("без фразы: %v", err)
+	}
+}
+
+// keychainMap хранилище ОС в памяти по ссылке <служба>/<запись>
+type keychainMap map[string]string
+
+func (m keychainMap) Get(service, account string) (string, error) {
+	v, ok := m[service+"/"+account]
+	if !ok {
+		return "", secret.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m keychainMap) Set(service, account, value string) error {
+	m[service+"/"+account] = value
+	return nil
+}
+
+// withKeychain подменяет Keychain на время теста
+func withKeychain(t *testing.T, m keychainMap) {
+	t.Helper()
+	old := Keychain
+	Keychain = m
+	t.Cleanup(func() { Keychain = old })
+}
+
+// Ссылки keychain: в полях ключей заменяются значениями из хранилища, а
+// зашифрованное значение в хранилище ещё и расшифровывается
+func TestLoadKeychainSecrets(t *testing.T) {
+	t.Setenv("OCR_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	token, err := secret.Encrypt("server-token", "фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := withPassphrase(t, "фраза")
+	withKeychain(t, keychainMap{
+		"hack_interview/gemini":    "gemini-key",
+		"hack_interview/prod/grpc": "grpc-token",
+		"hack_interview/server":    token,
+	})
+
+	c, err := loadYAML(t, "OCR_API_KEY: plain-ocr-key\nGEMINI_API_KEY: keychain:hack_interview/gemini\n"+
+		"server:\n  token: keychain:hack_interview/server\ngrpc:\n  token: keychain:hack_interview/prod/grpc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GeminiAPIKey != "gemini-key" || c.GRPC.Token != "grpc-token" || c.Server.Token != "server-token" || c.OCRAPIKey != "plain-ocr-key" {
+		t.Errorf("ключи %q, %q, %q, %q", c.GeminiAPIKey, c.GRPC.Token, c.Server.Token, c.OCRAPIKey)
+	}
+	if *calls != 1 {
+		t.Errorf("парольная фраза запрошена %d раз", *calls)
+	}
+
+	// Ошибка называет поле, службу и запись
+	for yml, want := range map[string][]string{
+		"GEMINI_API_KEY: keychain:hack_interview/ocr\n": {"GEMINI_API_KEY", `служба "hack_interview"`, `учётная запись "ocr"`},
+		"notion:\n  token: keychain:notion\n":           {"notion.token", "keychain:notion"},
+	} {
+		_, err := loadYAML(t, yml)
+		if err == nil {
+			t.Errorf("%q: нет ошибки", yml)
+			continue
+		}
+		for _, w := range want {
+			if !strings.Contains(err.Error(), w) {
+				t.Errorf("%q: в ошибке нет %s: %v", yml, w, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// Приоритет значений: переменная настоящего окружения важнее .env, а .env
+// важнее config.yml. LoadEnvFile задаёт только переменные, которых нет в
+// окружении, а Load берёт ключи из envOverrides окружения вместо файла.
+
+// envOverrides переменные окружения, заменяющие поля config.yml
+func (c *Config) envOverrides() map[string]*string {
+	return map[string]*string{
+		"OCR_API_KEY":         &c.OCRAPIKey,
+		"GEMINI_API_KEY":      &c.GeminiAPIKey,
+		"DISCORD_WEBHOOK_URL": &c.Discord.WebhookURL,
+	}
+}
+
+func (c *Config) applyEnv() {
+	for name, v := range c.envOverrides() {
+		if value := os.Getenv(name); value != "" {
+			*v = value
+		}
+	}
+}
+
+// LoadEnvFile читает файл в формате .env и задаёт переменные, которых ещё
+// нет в окружении. Поддерживаются пустые строки, комментарии #, префикс
+// export и значения в двойных (с \n, \t, \", \\) или одинарных (как есть)
+// кавычках; вне кавычек # после пробела начинает комментарий. Отсутствие
+// файла при optional не ошибка.
+func LoadEnvFile(path string, optional bool) error {
+	f, err := os.Open(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		name, value, ok, err := parseEnvLine(sc.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// parseEnvLine разбирает строку .env; ok false — строка пустая или
+// комментарий
+func parseEnvLine(line string) (name, value string, ok bool, err error) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\uFEFF"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	if rest, found := strings.CutPrefix(line, "export"); found && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+		line = strings.TrimSpace(rest)
+	}
+	name, value, found := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !found {
+		return "", "", false, fmt.Errorf("ожидается ИМЯ=значение, а не %q", line)
+	}
+	if !validEnvName(name) {
+		return "", "", false, fmt.Errorf("некорректное имя переменной %q", name)
+	}
+	value, err = parseEnvValue(strings.TrimSpace(value))
+	if err != nil {
+		return "", "", false, err
+	}
+	return name, value, true, nil
+}
+
+func validEnvName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+func parseEnvValue(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	quote := v[0]
+	if quote != '"' && quote != '\'' {
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = v[:i]
+		}
+		return strings.TrimSpace(v), nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(v); i++ {
+		ch := v[i]
+		switch {
+		case ch == quote:
+			if rest := strings.TrimSpace(v[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("лишние символы после закрывающей кавычки: %q", rest)
+			}
+			return b.String(), nil
+		case ch == '\\' && quote == '"' && i+1 < len(v):
+			i++
+			switch v[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(v[i])
+			}
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", fmt.Errorf("нет закрывающей кавычки %c", quote)
+}
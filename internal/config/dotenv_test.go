@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	for _, tc := range []struct {
+		line, name, value string
+		ok                bool
+	}{
+		{"", "", "", false},
+		{"   ", "", "", false},
+		{"# комментарий", "", "", false},
+		{"  # с отступом", "", "", false},
+		{"KEY=value", "KEY", "value", true},
+		{"KEY = value ", "KEY", "value", true},
+		{"export KEY=value", "KEY", "value", true},
+		{"export\tKEY=value", "KEY", "value", true},
+		// exporter — имя, а не префикс export
+		{"exporter=1", "exporter", "1", true},
+		{"KEY=", "KEY", "", true},
+		{"KEY=value # комментарий", "KEY", "value", true},
+		{"KEY=a#b", "KEY", "a#b", true},
+		{`KEY="a b # не комментарий"`, "KEY", "a b # не комментарий", true},
+		{`KEY="строка\nвторая\t\"кавычки\" \\"`, "KEY", "строка\nвторая\t\"кавычки\" \\", true},
+		{`KEY='как есть \n $HOME'`, "KEY", `как есть \n $HOME`, true},
+		{`KEY="value" # комментарий`, "KEY", "value", true},
+		{"KEY=a=b", "KEY", "a=b", true},
+		{"\uFEFFKEY=value", "KEY", "value", true},
+		{"_K1=v", "_K1", "v", true},
+	} {
+		name, value, ok, err := parseEnvLine(tc.line)
+		if err != nil || name != tc.name || value != tc.value || ok != tc.ok {
+			t.Errorf("parseEnvLine(%q) = %q, %q, %v, %v; want %q, %q, %v", tc.line, name, value, ok, err, tc.name, tc.value, tc.ok)
+		}
+	}
+}
+
+func TestParseEnvLineErrors(t *testing.T) {
+	for _, line := range []string{
+		"KEY",
+		"1KEY=v",
+		"MY-KEY=v",
+		"=v",
+		`KEY="без конца`,
+		`KEY='без конца`,
+		`KEY="a" b`,
+	} {
+		if _, _, _, err := parseEnvLine(line); err == nil {
+			t.Errorf("parseEnvLine(%q): нет ошибки", line)
+		}
+	}
+}
+
+// unsetEnv убирает переменные из окружения до конца теста
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func writeFile(t *testing.T, path, data string) string {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Ошибка разбора называет файл и номер строки
+func TestLoadEnvFileErrorLine(t *testing.T) {
+	unsetEnv(t, "A_KEY")
+	path := writeFile(t, filepath.Join(t.TempDir(), ".env"), "# ключи\nA_KEY=1\n\nне переменная\n")
+	err := LoadEnvFile(path, false)
+	if err == nil || !strings.HasPrefix(err.Error(), path+":4: ") {
+		t.Errorf("ошибка %v", err)
+	}
+}
+
+func TestLoadEnvFileMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := LoadEnvFile(path, true); err != nil {
+		t.Errorf("необязательный файл: %v", err)
+	}
+	if err := LoadEnvFile(path, false); err == nil {
+		t.Error("нет ошибки для отсутствующего --env-file")
+	}
+}
+
+// Приоритет: настоящее окружение > .env > config.yml
+func TestEnvPrecedence(t *testing.T) {
+	unsetEnv(t, "OCR_API_KEY", "GEMINI_API_KEY", "DISCORD_WEBHOOK_URL")
+	t.Setenv("GEMINI_API_KEY", "from-env")
+	dir := t.TempDir()
+	env := writeFile(t, filepath.Join(dir, ".env"),
+		"GEMINI_API_KEY=from-dotenv\nexport DISCORD_WEBHOOK_URL=\"https://discord.example/from-dotenv\"\n")
+	if err := LoadEnvFile(env, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("GEMINI_API_KEY"); got != "from-env" {
+		t.Errorf(".env заменил переменную окружения: %q", got)
+	}
+
+	c, err := loadYAML(t, "OCR_API_KEY: from-file\nGEMINI_API_KEY: from-file\ndiscord:\n  webhookURL: https://discord.example/from-file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ field, got, want string }{
+		{"OCR_API_KEY", c.OCRAPIKey, "from-file"},
+		{"GEMINI_API_KEY", c.GeminiAPIKey, "from-env"},
+		{"DISCORD_WEBHOOK_URL", c.Discord.WebhookURL, "https://discord.example/from-dotenv"},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.field, tc.got, tc.want)
+		}
+	}
+}
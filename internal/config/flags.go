@@ -0,0 +1,233 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+
+	"hack_interview/internal/msg"
+)
+
+// Флаги командной строки заменяют поля config.yml на один запуск: у
+// каждого поля флаг с именем из ключа YAML в kebab-case, вложенные разделы
+// через точку (--input-dir, --gemini.model, --breaker.threshold). Приоритет
+// — флаг, затем переменная окружения, затем config.yml. Применяются только
+// флаги, переданные явно: --workers 0 записывает 0 в поле, как workers: 0 в
+// файле (и так же означает значение по умолчанию), а без флага остаётся
+// значение из файла. Строки берутся как есть, остальные значения разбираются как YAML:
+// --include '[a/**, b/**]' (одиночное значение списка можно без скобок),
+// --ocr.headers '{X-Token: t}'.
+
+// configFlag флаг одного поля Config
+type configFlag struct {
+	name    string
+	section string
+	path    []int
+	typ     reflect.Type
+	// value переданное значение; set — флаг был в командной строке
+	value string
+	set   bool
+	// file значение — путь к файлу, поле получает его содержимое
+	file bool
+}
+
+// configFlags флаги, зарегистрированные RegisterFlags, в порядке полей
+var configFlags []*configFlag
+
+// flagAliases короткие имена для частых полей
+var flagAliases = map[string]string{
+	"model": "gemini.model",
+}
+
+// RegisterFlags добавляет в fs флаг для каждого поля Config, кроме уже
+// определённых в fs (их смысл задаёт программа), и --prompt-file —
+// PROMPT из файла. Значения применяет Load.
+func RegisterFlags(fs *flag.FlagSet) {
+	configFlags = nil
+	walkFields(reflect.TypeOf(Config{}), nil, "", "", func(f *configFlag) {
+		if fs.Lookup(f.name) != nil {
+			return
+		}
+		configFlags = append(configFlags, f)
+		fs.Var(f, f.name, "поле "+f.name+" из config.yml")
+	})
+	byName := make(map[string]*configFlag, len(configFlags))
+	for _, f := range configFlags {
+		byName[f.name] = f
+	}
+	for alias, name := range flagAliases {
+		if f := byName[name]; f != nil && fs.Lookup(alias) == nil {
+			fs.Var(f, alias, "то же, что --"+name)
+		}
+	}
+	if f := byName["prompt"]; f != nil && fs.Lookup("prompt-file") == nil {
+		pf := *f
+		pf.name, pf.file = "prompt-file", true
+		configFlags = append(configFlags, &pf)
+		fs.Var(&pf, pf.name, "PROMPT из файла")
+	}
+}
+
+func walkFields(t reflect.Type, path []int, prefix, section string, fn func(*configFlag)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		p := append(append([]int(nil), path...), i)
+		if len(tag) > 1 && tag[1] == "inline" {
+			walkFields(field.Type, p, prefix, section, fn)
+			continue
+		}
+		if tag[0] == "" || tag[0] == "-" || !field.IsExported() {
+			continue
+		}
+		name := prefix + kebab(tag[0])
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			walkFields(field.Type, p, name+".", strings.SplitN(name, ".", 2)[0], fn)
+			continue
+		}
+		fn(&configFlag{name: name, section: section, path: p, typ: field.Type})
+	}
+}
+
+// kebab имя ключа YAML для флага: inputDir — input-dir, baseURL —
+// base-url, OCR_API_KEY — ocr-api-key
+func kebab(s string) string {
+	r := []rune(s)
+	var b strings.Builder
+	for i, c := range r {
+		switch {
+		case c == '_':
+			b.WriteByte('-')
+			continue
+		case unicode.IsUpper(c) && i > 0 && r[i-1] != '_':
+			prevLower := unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1])
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if prevLower || (unicode.IsUpper(r[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+func (f *configFlag) String() string { return f.value }
+
+// IsBoolFlag позволяет писать --goCheck без значения
+func (f *configFlag) IsBoolFlag() bool {
+	return f.typ.Kind() == reflect.Bool || (f.typ.Kind() == reflect.Pointer && f.typ.Elem().Kind() == reflect.Bool)
+}
+
+// Set проверяет значение сразу, чтобы ошибка пришла от разбора флагов
+func (f *configFlag) Set(value string) error {
+	if f.file {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return err
+		}
+		value = string(data)
+	}
+	if err := f.decode(reflect.New(f.typ).Elem(), value); err != nil {
+		return err
+	}
+	f.value, f.set = value, true
+	return nil
+}
+
+func (f *configFlag) decode(dst reflect.Value, value string) error {
+	if f.typ.Kind() == reflect.String {
+		dst.SetString(value)
+		return nil
+	}
+	if f.typ.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		value = "[" + yamlQuote(value) + "]"
+	}
+	if err := yaml.UnmarshalStrict([]byte(value), dst.Addr().Interface()); err != nil {
+		return fmt.Errorf("ожидается %s", typeHint(f.typ))
+	}
+	return nil
+}
+
+func yamlQuote(s string) string {
+	out, _ := yaml.Marshal(s)
+	return strings.TrimSpace(string(out))
+}
+
+// applyFlags переносит в c значения переданных флагов
+func (c *Config) applyFlags() error {
+	root := reflect.ValueOf(c).Elem()
+	for _, f := range configFlags {
+		if !f.set {
+			continue
+		}
+		if err := f.decode(root.FieldByIndex(f.path), f.value); err != nil {
+			return fmt.Errorf("--%s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+func typeHint(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		return "длительность"
+	case t.Kind() == reflect.Pointer:
+		return typeHint(t.Elem())
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true или false"
+	case reflect.Int, reflect.Int64:
+		return "целое число"
+	case reflect.Float64:
+		return "число"
+	case reflect.Slice:
+		return "список"
+	case reflect.Map, reflect.Struct:
+		return "YAML"
+	}
+	return "строка"
+}
+
+// FlagUsage печатает флаги полей config.yml по разделам
+func FlagUsage(w io.Writer) {
+	fmt.Fprintln(w, msg.T("flags.header"))
+	sections := map[string][]string{}
+	var order []string
+	for _, f := range configFlags {
+		if _, ok := sections[f.section]; !ok {
+			order = append(order, f.section)
+		}
+		sections[f.section] = append(sections[f.section], "--"+f.name)
+	}
+	aliases := make([]string, 0, len(flagAliases))
+	for alias, name := range flagAliases {
+		aliases = append(aliases, "--"+alias+" = --"+name)
+	}
+	sort.Strings(aliases)
+	for _, s := range order {
+		title := s
+		if title == "" {
+			title = msg.T("flags.general")
+		}
+		fmt.Fprintf(w, "  %s:\n", title)
+		line := "   "
+		for _, name := range sections[s] {
+			if len(line)+len(name)+1 > 80 {
+				fmt.Fprintln(w, line)
+				line = "   "
+			}
+			line += " " + name
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, msg.T("flags.aliases"), strings.Join(aliases, ", "))
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestKebab(t *testing.T) {
+	for in, want := range map[string]string{
+		"inputDir":      "input-dir",
+		"baseURL":       "base-url",
+		"OCR_API_KEY":   "ocr-api-key",
+		"PROMPT":        "prompt",
+		"maxFileSizeKB": "max-file-size-kb",
+		"s3":            "s3",
+		"HTTPTimeout":   "http-timeout",
+		"goCheck":       "go-check",
+	} {
+		if got := kebab(in); got != want {
+			t.Errorf("kebab(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// parseFlags регистрирует флаги полей в новом наборе с уже определённым
+// программой --config и разбирает args
+func parseFlags(t *testing.T, args ...string) error {
+	t.Helper()
+	old := configFlags
+	t.Cleanup(func() { configFlags = old })
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("config", "", "")
+	RegisterFlags(fs)
+	return fs.Parse(args)
+}
+
+const flagsYAML = "workers: 4\ntranscript: true\nbreaker:\n  threshold: 3\ngemini:\n  model: gemini-2.0-flash\ninclude: ['**/*.png']\n"
+
+// Слияние флагов с файлом: применяются только переданные флаги, в том
+// числе нулевые значения, которые нельзя отличить от отсутствующих по
+// самому полю
+func TestFlagsMerge(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	prompt := filepath.Join(t.TempDir(), "experiment.txt")
+	os.WriteFile(prompt, []byte("Объясни по шагам"), 0644)
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		check func(c Config) bool
+	}{
+		{"без флагов — файл и значения по умолчанию", nil, func(c Config) bool {
+			return c.Workers == 4 && *c.Transcript && *c.Breaker.Threshold == 3 && c.Gemini.Model == "gemini-2.0-flash" &&
+				c.InputDir == "in" && c.Group.Wait > 0
+		}},
+		{"частичная замена", []string{"--input-dir", "shots", "--model", "gemini-1.5-pro"}, func(c Config) bool {
+			return c.InputDir == "shots" && c.Gemini.Model == "gemini-1.5-pro" && c.OutputDir == "out" && c.Workers == 4
+		}},
+		{"--workers 0 значит по умолчанию, а не из файла", []string{"--workers", "0"}, func(c Config) bool {
+			return c.Workers == 1
+		}},
+		{"--breaker.threshold 0 выключает", []string{"--breaker.threshold", "0"}, func(c Config) bool {
+			return *c.Breaker.Threshold == 0
+		}},
+		{"--transcript=false", []string{"--transcript=false"}, func(c Config) bool {
+			return !*c.Transcript
+		}},
+		{"логический флаг без значения", []string{"--go-check"}, func(c Config) bool {
+			return c.GoCheck
+		}},
+		{"--prompt-file", []string{"--prompt-file", prompt}, func(c Config) bool {
+			return c.PROMPT == "Объясни по шагам"
+		}},
+		{"одиночное значение списка", []string{"--include", "a/**"}, func(c Config) bool {
+			return reflect.DeepEqual(c.Include, []string{"a/**"})
+		}},
+		{"список YAML", []string{"--include", "[a/**, 'b c/*.png']"}, func(c Config) bool {
+			return reflect.DeepEqual(c.Include, []string{"a/**", "b c/*.png"})
+		}},
+		{"словарь", []string{"--ocr.headers", "{X-Token: t}"}, func(c Config) bool {
+			return c.OCR.Headers["X-Token"] == "t"
+		}},
+		{"последний флаг важнее", []string{"--workers", "2", "--workers", "3"}, func(c Config) bool {
+			return c.Workers == 3
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := parseFlags(t, tc.args...); err != nil {
+				t.Fatal(err)
+			}
+			c, err := loadYAML(t, flagsYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.check(c) {
+				t.Errorf("%v: workers %d, transcript %v, threshold %v, model %q, include %q, prompt %q",
+					tc.args, c.Workers, *c.Transcript, *c.Breaker.Threshold, c.Gemini.Model, c.Include, c.PROMPT)
+			}
+		})
+	}
+}
+
+// Флаг важнее переменной окружения, она — файла
+func TestFlagOverridesEnv(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "from-env")
+	if err := parseFlags(t, "--gemini-api-key", "from-flag"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadYAML(t, "GEMINI_API_KEY: from-file\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.GeminiAPIKey != "from-flag" {
+		t.Errorf("GEMINI_API_KEY = %q", c.GeminiAPIKey)
+	}
+}
+
+func TestFlagErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--workers", "много"}, "ожидается целое число"},
+		{[]string{"--file-timeout", "5 минут"}, "ожидается длительность"},
+		{[]string{"--transcript=может"}, "ожидается true или false"},
+		{[]string{"--ocr.headers", "[1, 2]"}, "ожидается YAML"},
+		{[]string{"--prompt-file", "нет-такого.txt"}, "нет-такого.txt"},
+		{[]string{"--no-such-field", "1"}, "not defined"},
+	} {
+		if err := parseFlags(t, tc.args...); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: %v, want %q", tc.args, err, tc.want)
+		}
+	}
+}
+
+// Флаги, которые программа уже определила, не заменяются полями, а
+// справка группирует поля по разделам
+func TestRegisterFlagsAndUsage(t *testing.T) {
+	old := configFlags
+	t.Cleanup(func() { configFlags = old })
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("workers", "", "свой смысл")
+	RegisterFlags(fs)
+	for _, f := range configFlags {
+		if f.name == "workers" {
+			t.Error("поле workers заменило флаг программы")
+		}
+	}
+	for _, name := range []string{"input-dir", "gemini.model", "breaker.threshold", "model", "prompt-file"} {
+		if fs.Lookup(name) == nil {
+			t.Errorf("нет флага --%s", name)
+		}
+	}
+
+	var buf bytes.Buffer
+	FlagUsage(&buf)
+	usage := buf.String()
+	general, gemini := strings.Index(usage, "  общие:\n"), strings.Index(usage, "  gemini:\n")
+	if general < 0 || gemini < 0 || !strings.Contains(usage[gemini:], "--gemini.model") || strings.Contains(usage[:gemini], "--gemini.model") {
+		t.Errorf("справка:\n%s", usage)
+	}
+	if !strings.Contains(usage, "--model = --gemini.model") {
+		t.Errorf("нет сокращения в справке:\n%s", usage)
+	}
+	for _, line := range strings.Split(usage, "\n") {
+		if utf8.RuneCountInString(line) > 80 {
+			t.Errorf("длинная строка справки: %q", line)
+		}
+	}
+}
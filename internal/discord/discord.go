@@ -0,0 +1,129 @@
+// Package discord отправляет сообщения в канал Discord через вебхук с
+// учётом ограничения частоты запросов.
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Лимиты длины Discord
+const (
+	MaxContent     = 2000
+	MaxDescription = 4096
+	MaxTitle       = 256
+)
+
+// maxAttempts попыток одного сообщения при ответе 429
+const maxAttempts = 5
+
+// Embed карточка сообщения
+type Embed struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Color       int    `json:"color,omitempty"`
+}
+
+// Message сообщение вебхука: текст или карточки
+type Message struct {
+	Content string  `json:"content,omitempty"`
+	Embeds  []Embed `json:"embeds,omitempty"`
+}
+
+// Webhook вебхук канала. Если Discord сообщил, что лимит запросов
+// исчерпан (X-RateLimit-Remaining: 0), следующий запрос ждёт
+// X-RateLimit-Reset-After; на 429 запрос повторяется после retry_after.
+type Webhook struct {
+	URL string
+	// Client общий клиент; nil — новый клиент на каждый запрос
+	Client *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	reset time.Time
+}
+
+// Send отправляет сообщение
+func (w *Webhook) Send(ctx context.Context, m Message) error {
+	client := w.Client
+	if client == nil {
+		client = resty.New()
+	}
+	for attempt := 1; ; attempt++ {
+		if err := w.wait(ctx); err != nil {
+			return err
+		}
+		reqCtx, cancel := ctx, context.CancelFunc(func() {})
+		if w.Timeout > 0 {
+			reqCtx, cancel = context.WithTimeout(ctx, w.Timeout)
+		}
+		start := time.Now()
+		resp, err := client.R().SetContext(reqCtx).SetBody(m).Post(w.URL)
+		cancel()
+		metrics.ObserveRequest(metrics.ProviderDiscord, time.Since(start), err)
+		if err != nil {
+			return errs.FromTransport(err)
+		}
+		w.noteLimit(resp)
+		if resp.StatusCode() == http.StatusTooManyRequests && attempt < maxAttempts {
+			var body struct {
+				RetryAfter float64 `json:"retry_after"`
+			}
+			delay := time.Second
+			if json.Unmarshal(resp.Body(), &body) == nil && body.RetryAfter > 0 {
+				delay = time.Duration(body.RetryAfter * float64(time.Second))
+			}
+			w.mu.Lock()
+			w.reset = time.Now().Add(delay)
+			w.mu.Unlock()
+			continue
+		}
+		if resp.IsError() {
+			if resp.StatusCode() == http.StatusUnauthorized || resp.StatusCode() == http.StatusNotFound {
+				return fmt.Errorf("%s: %w: вебхук недействителен", metrics.ProviderDiscord, errs.ErrAuth)
+			}
+			return errs.FromStatus(metrics.ProviderDiscord, resp.StatusCode(), resp.String())
+		}
+		return nil
+	}
+}
+
+// noteLimit запоминает, когда можно слать следующий запрос
+func (w *Webhook) noteLimit(resp *resty.Response) {
+	if resp.Header().Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	secs, err := strconv.ParseFloat(resp.Header().Get("X-RateLimit-Reset-After"), 64)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	w.reset = time.Now().Add(time.Duration(secs * float64(time.Second)))
+	w.mu.Unlock()
+}
+
+func (w *Webhook) wait(ctx context.Context) error {
+	w.mu.Lock()
+	d := time.Until(w.reset)
+	w.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"hack_interview/internal/errs"
+)
+
+// response ответ подставного вебхука
+type response struct {
+	code   int
+	header map[string]string
+	body   string
+}
+
+// fakeWebhook отвечает по очереди ответами responses, а дальше 204, и
+// записывает время каждого запроса
+func fakeWebhook(t *testing.T, responses ...response) (*Webhook, func() []time.Time) {
+	t.Helper()
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := len(times)
+		times = append(times, time.Now())
+		mu.Unlock()
+		if n >= len(responses) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resp := responses[n]
+		for k, v := range resp.header {
+			w.Header().Set(k, v)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.code)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(srv.Close)
+	return &Webhook{URL: srv.URL}, func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), times...)
+	}
+}
+
+// После X-RateLimit-Remaining: 0 следующее сообщение ждёт
+// X-RateLimit-Reset-After
+func TestSendWaitsForRateLimitReset(t *testing.T) {
+	w, requests := fakeWebhook(t, response{code: http.StatusNoContent, header: map[string]string{
+		"X-RateLimit-Remaining":   "0",
+		"X-RateLimit-Reset-After": "0.2",
+	}})
+	for i := 0; i < 2; i++ {
+		if err := w.Send(context.Background(), Message{Content: "ответ"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	times := requests()
+	if len(times) != 2 {
+		t.Fatalf("запросов %d", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < 190*time.Millisecond {
+		t.Errorf("второй запрос через %v, want не раньше 200ms", gap)
+	}
+
+	// Ожидание прерывается вместе с ctx
+	w, _ = fakeWebhook(t, response{code: http.StatusNoContent, header: map[string]string{
+		"X-RateLimit-Remaining":   "0",
+		"X-RateLimit-Reset-After": "60",
+	}})
+	if err := w.Send(context.Background(), Message{Content: "ответ"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Send(ctx, Message{Content: "ответ"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send после отмены: %v", err)
+	}
+}
+
+// На 429 сообщение повторяется после retry_after из тела ответа
+func TestSendRetriesAfter429(t *testing.T) {
+	w, requests := fakeWebhook(t, response{code: http.StatusTooManyRequests, body: `{"message":"You are being rate limited.","retry_after":0.15,"global":false}`})
+	if err := w.Send(context.Background(), Message{Content: "ответ"}); err != nil {
+		t.Fatal(err)
+	}
+	times := requests()
+	if len(times) != 2 {
+		t.Fatalf("запросов %d, want 2", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < 140*time.Millisecond {
+		t.Errorf("повтор через %v, want не раньше 150ms", gap)
+	}
+}
+
+// Недействительный вебхук — ошибка авторизации, остальные коды — по
+// общим правилам
+func TestSendErrors(t *testing.T) {
+	for _, tc := range []struct {
+		code int
+		auth bool
+	}{
+		{http.StatusUnauthorized, true},
+		{http.StatusNotFound, true},
+		{http.StatusBadRequest, false},
+		{http.StatusInternalServerError, false},
+	} {
+		w, requests := fakeWebhook(t, response{code: tc.code, body: `{"message":"error"}`})
+		err := w.Send(context.Background(), Message{Content: "ответ"})
+		if err == nil || errors.Is(err, errs.ErrAuth) != tc.auth {
+			t.Errorf("%d: %v", tc.code, err)
+		}
+		if n := len(requests()); n != 1 {
+			t.Errorf("%d: запросов %d", tc.code, n)
+		}
+	}
+}
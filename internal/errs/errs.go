@@ -0,0 +1,115 @@
+// Package errs классифицирует ошибки конвейера, чтобы повторы, запасные
+// провайдеры и обработка неудачных файлов решали по типу ошибки, а не по
+// тексту сообщения.
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrAuth ключ API не принят
+	ErrAuth = errors.New("ошибка авторизации")
+	// ErrNoText на изображении не найден текст
+	ErrNoText = errors.New("текст на изображении не найден")
+	// ErrNoSpeech в аудиозаписи не найдена речь
+	ErrNoSpeech = errors.New("речь в записи не найдена")
+	// ErrMissingTool не установлена внешняя программа, без которой файл не
+	// обработать
+	ErrMissingTool = errors.New("не найдена внешняя программа")
+	// ErrTimeout истёк срок запроса или обработки файла
+	ErrTimeout = errors.New("превышено время ожидания")
+	// ErrPanic обработка файла завершилась паникой
+	ErrPanic = errors.New("паника при обработке")
+	// ErrReplayMiss в режиме воспроизведения нет записанного ответа
+	ErrReplayMiss = errors.New("нет записи для воспроизведения")
+	// ErrFileTooLarge файл больше, чем принимает провайдер
+	ErrFileTooLarge = errors.New("файл слишком большой")
+	// ErrFileUpload не удалось загрузить снимок через Files API
+	ErrFileUpload = errors.New("ошибка загрузки файла")
+	// ErrFileProcessing загруженный файл не стал доступен модели
+	ErrFileProcessing = errors.New("файл не обработан")
+	// ErrFileCleanup не удалось удалить загруженный файл
+	ErrFileCleanup = errors.New("ошибка удаления файла")
+)
+
+// QuotaExceededError исчерпана квота или превышен лимит запросов провайдера
+type QuotaExceededError struct {
+	Provider string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: исчерпана квота запросов", e.Provider)
+}
+
+// CircuitOpenError цепь к провайдеру разомкнута после серии ошибок, запрос
+// не отправлялся
+type CircuitOpenError struct {
+	Provider string
+	Until    time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: провайдер недоступен, цепь разомкнута до %s", e.Provider, e.Until.Format("15:04:05"))
+}
+
+// BlockedError модель отказалась отвечать (фильтр безопасности и т. п.)
+type BlockedError struct {
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("ответ заблокирован моделью: %s", e.Reason)
+}
+
+// StatusError неуспешный HTTP-ответ, не отнесённый к другим видам
+type StatusError struct {
+	Provider string
+	Code     int
+	Body     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Provider, e.Code, e.Body)
+}
+
+// FromStatus классифицирует неуспешный HTTP-ответ провайдера
+func FromStatus(provider string, code int, body string) error {
+	if len(body) > 512 {
+		body = body[:512] + "…"
+	}
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%s: HTTP %d: %s: %w", provider, code, body, ErrAuth)
+	case http.StatusTooManyRequests:
+		return &QuotaExceededError{Provider: provider}
+	}
+	return &StatusError{Provider: provider, Code: code, Body: body}
+}
+
+// FromTransport помечает ошибку запроса как таймаут, если истёк срок
+func FromTransport(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// Retryable сообщает, есть ли смысл повторять обработку: повтор не
+// исправит отказ в авторизации, отсутствие текста или речи, блокировку
+// ответа, панику на том же файле, отсутствие записи при воспроизведении
+// или нужной программы, слишком большой файл
+func Retryable(err error) bool {
+	var blocked *BlockedError
+	switch {
+	case errors.Is(err, ErrAuth), errors.Is(err, ErrNoText), errors.Is(err, ErrNoSpeech),
+		errors.Is(err, ErrPanic), errors.Is(err, ErrReplayMiss), errors.Is(err, ErrMissingTool), errors.Is(err, ErrFileTooLarge),
+		errors.As(err, &blocked):
+		return false
+	}
+	return true
+}
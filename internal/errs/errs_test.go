@@ -0,0 +1,113 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromStatus(t *testing.T) {
+	for _, tc := range []struct {
+		code  int
+		check func(error) bool
+	}{
+		{http.StatusUnauthorized, func(err error) bool { return errors.Is(err, ErrAuth) }},
+		{http.StatusForbidden, func(err error) bool { return errors.Is(err, ErrAuth) }},
+		{http.StatusTooManyRequests, func(err error) bool {
+			var q *QuotaExceededError
+			return errors.As(err, &q) && q.Provider == "gemini"
+		}},
+		{http.StatusBadRequest, func(err error) bool {
+			var s *StatusError
+			return errors.As(err, &s) && s.Code == http.StatusBadRequest && !errors.Is(err, ErrAuth)
+		}},
+		{http.StatusServiceUnavailable, func(err error) bool {
+			var s *StatusError
+			return errors.As(err, &s) && s.Code == http.StatusServiceUnavailable && s.Provider == "gemini"
+		}},
+	} {
+		if err := FromStatus("gemini", tc.code, "тело"); !tc.check(err) {
+			t.Errorf("HTTP %d: %v (%T)", tc.code, err, err)
+		}
+	}
+
+	// Длинное тело ответа обрезается
+	err := FromStatus("ocrspace", http.StatusBadGateway, strings.Repeat("x", 2000))
+	var s *StatusError
+	if !errors.As(err, &s) || len(s.Body) != 512+len("…") || !strings.HasSuffix(s.Body, "…") {
+		t.Errorf("тело %d байт", len(s.Body))
+	}
+	if msg := FromStatus("ocrspace", http.StatusUnauthorized, "bad key").Error(); msg != "ocrspace: HTTP 401: bad key: ошибка авторизации" {
+		t.Errorf("сообщение %q", msg)
+	}
+}
+
+func TestFromTransport(t *testing.T) {
+	deadline := fmt.Errorf("Post \"https://api\": %w", context.DeadlineExceeded)
+	if err := FromTransport(deadline); !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("истёкший срок: %v", err)
+	}
+	// Уже помеченная ошибка не оборачивается повторно
+	marked := FromTransport(deadline)
+	if again := FromTransport(marked); again != marked {
+		t.Errorf("повторная пометка: %v", again)
+	}
+	for _, err := range []error{context.Canceled, errors.New("connection refused")} {
+		if got := FromTransport(err); got != err {
+			t.Errorf("FromTransport(%v) = %v", err, got)
+		}
+	}
+}
+
+// Повтор бесполезен для окончательных ошибок, в том числе обёрнутых, и
+// нужен для сбоев провайдера
+func TestRetryable(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("ошибка OCR (in/a.png): %w", err) }
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"auth", ErrAuth, false},
+		{"auth 401", FromStatus("gemini", http.StatusUnauthorized, ""), false},
+		{"no text", wrap(ErrNoText), false},
+		{"no speech", wrap(ErrNoSpeech), false},
+		{"panic", wrap(ErrPanic), false},
+		{"replay miss", wrap(ErrReplayMiss), false},
+		{"missing tool", wrap(ErrMissingTool), false},
+		{"too large", wrap(fmt.Errorf("%w: 2048 КБ", ErrFileTooLarge)), false},
+		{"blocked", wrap(&BlockedError{Reason: "SAFETY"}), false},
+		{"quota", wrap(&QuotaExceededError{Provider: "ocrspace"}), true},
+		{"circuit open", &CircuitOpenError{Provider: "gemini", Until: time.Now()}, true},
+		{"5xx", wrap(FromStatus("gemini", http.StatusInternalServerError, "")), true},
+		{"timeout", wrap(FromTransport(context.DeadlineExceeded)), true},
+		{"upload", wrap(fmt.Errorf("%w: HTTP 503", ErrFileUpload)), true},
+		{"processing", fmt.Errorf("%w: FAILED", ErrFileProcessing), true},
+		{"network", errors.New("connection reset by peer"), true},
+	} {
+		if got := Retryable(tc.err); got != tc.want {
+			t.Errorf("%s: Retryable(%v) = %v, want %v", tc.name, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestErrorMessages(t *testing.T) {
+	until := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{&QuotaExceededError{Provider: "ocrspace"}, "ocrspace: исчерпана квота запросов"},
+		{&CircuitOpenError{Provider: "gemini", Until: until}, "gemini: провайдер недоступен, цепь разомкнута до 15:04:05"},
+		{&BlockedError{Reason: "RECITATION"}, "ответ заблокирован моделью: RECITATION"},
+		{&StatusError{Provider: "whisper", Code: 502, Body: "bad gateway"}, "whisper: HTTP 502: bad gateway"},
+	} {
+		if got := tc.err.Error(); got != tc.want {
+			t.Errorf("%T: %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package gdocs
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Scope доступ к документам Google Docs
+const Scope = "https://www.googleapis.com/auth/documents"
+
+// defaultTokenURL адрес выдачи токенов Google OAuth
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// Credentials ключ доступа из JSON-файла Google: сервисный аккаунт
+// (type service_account) или пользователь с refresh_token (type
+// authorized_user, как после gcloud auth application-default login).
+// Токен доступа кэшируется и обновляется за минуту до истечения.
+type Credentials struct {
+	Type string `json:"type"`
+	// service_account
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	// authorized_user
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	// TokenURI адрес выдачи токенов; пустой — Google OAuth
+	TokenURI string `json:"token_uri"`
+
+	// Client общий клиент; nil — новый клиент на каждый запрос
+	Client *resty.Client `json:"-"`
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// LoadCredentials читает JSON-файл ключа
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Credentials
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("ошибка разбора %s: %w", path, err)
+	}
+	switch c.Type {
+	case "service_account":
+		if c.ClientEmail == "" || c.PrivateKey == "" {
+			return nil, fmt.Errorf("%s: в ключе сервисного аккаунта нет client_email или private_key", path)
+		}
+	case "authorized_user":
+		if c.ClientID == "" || c.RefreshToken == "" {
+			return nil, fmt.Errorf("%s: в ключе пользователя нет client_id или refresh_token", path)
+		}
+	default:
+		return nil, fmt.Errorf("%s: тип ключа может быть service_account или authorized_user, а не %q", path, c.Type)
+	}
+	return &c, nil
+}
+
+// Token действующий токен доступа
+func (c *Credentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires.Add(-time.Minute)) {
+		return c.token, nil
+	}
+	form, err := c.grant()
+	if err != nil {
+		return "", err
+	}
+	client := c.Client
+	if client == nil {
+		client = resty.New()
+	}
+	tokenURL := c.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	var res struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	start := time.Now()
+	resp, err := client.R().SetContext(ctx).SetFormData(form).SetResult(&res).Post(tokenURL)
+	metrics.ObserveRequest(metrics.ProviderGDocs, time.Since(start), err)
+	if err != nil {
+		return "", errs.FromTransport(err)
+	}
+	if resp.IsError() {
+		if resp.StatusCode() == 400 || resp.StatusCode() == 401 {
+			return "", fmt.Errorf("%s: %w: %s", metrics.ProviderGDocs, errs.ErrAuth, resp.String())
+		}
+		return "", errs.FromStatus(metrics.ProviderGDocs, resp.StatusCode(), resp.String())
+	}
+	if res.AccessToken == "" {
+		return "", errors.New("в ответе Google OAuth нет access_token")
+	}
+	c.token = res.AccessToken
+	c.expires = time.Now().Add(time.Duration(res.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// Invalidate сбрасывает кэш после ответа 401
+func (c *Credentials) Invalidate() {
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+}
+
+// grant параметры запроса токена
+func (c *Credentials) grant() (map[string]string, error) {
+	if c.Type == "authorized_user" {
+		return map[string]string{
+			"grant_type":    "refresh_token",
+			"client_id":     c.ClientID,
+			"client_secret": c.ClientSecret,
+			"refresh_token": c.RefreshToken,
+		}, nil
+	}
+	assertion, err := c.assertion()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"grant_type": "urn:ietf:params:oauth:grant-type:jwt-bearer",
+		"assertion":  assertion,
+	}, nil
+}
+
+// assertion JWT сервисного аккаунта, подписанный RS256
+func (c *Credentials) assertion() (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", errors.New("private_key сервисного аккаунта не в формате PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("ошибка разбора private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("private_key сервисного аккаунта не RSA")
+	}
+	aud := c.TokenURI
+	if aud == "" {
+		aud = defaultTokenURL
+	}
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.ClientEmail,
+		"scope": Scope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
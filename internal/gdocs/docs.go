@@ -0,0 +1,172 @@
+// Package gdocs пишет ответы в документы Google Docs через Docs API:
+// Markdown переводится в запросы batchUpdate (заголовки, обычный текст,
+// моноширинные блоки кода).
+package gdocs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// DefaultURL адрес Docs API
+const DefaultURL = "https://docs.googleapis.com"
+
+// MaxBatch запросов в одном batchUpdate. Запись укладывается в один
+// batchUpdate, если не длиннее MaxBatch запросов, и тогда применяется
+// целиком или не применяется вовсе.
+const MaxBatch = 200
+
+// maxAttempts попыток запроса при 429 и 5xx
+const maxAttempts = 4
+
+// Request один запрос batchUpdate
+type Request map[string]any
+
+// Client клиент Docs API. Запросы идут не чаще одного в Interval: квота
+// записи Docs API — 60 запросов в минуту на пользователя.
+type Client struct {
+	Auth *Credentials
+	// BaseURL адрес API; пустой — DefaultURL
+	BaseURL string
+	// Client общий клиент; nil — новый клиент на каждый запрос
+	Client *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+	// Interval 0 — секунда
+	Interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// URL адрес документа для браузера
+func URL(id string) string {
+	return "https://docs.google.com/document/d/" + id + "/edit"
+}
+
+// Create создаёт пустой документ и возвращает его идентификатор
+func (c *Client) Create(ctx context.Context, title string) (string, error) {
+	var doc struct {
+		DocumentID string `json:"documentId"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/documents", map[string]string{"title": title}, &doc); err != nil {
+		return "", err
+	}
+	return doc.DocumentID, nil
+}
+
+// EndIndex позиция конца тела документа: текст вставляется перед ней
+// (в последний, всегда пустой абзац)
+func (c *Client) EndIndex(ctx context.Context, id string) (int, error) {
+	var doc struct {
+		Body struct {
+			Content []struct {
+				EndIndex int `json:"endIndex"`
+			} `json:"content"`
+		} `json:"body"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/documents/"+id+"?fields=body(content(endIndex))", nil, &doc); err != nil {
+		return 0, err
+	}
+	end := 1
+	for _, el := range doc.Body.Content {
+		end = max(end, el.EndIndex)
+	}
+	return end, nil
+}
+
+// BatchUpdate применяет запросы одним batchUpdate
+func (c *Client) BatchUpdate(ctx context.Context, id string, requests []Request) error {
+	return c.do(ctx, http.MethodPost, "/v1/documents/"+id+":batchUpdate", map[string]any{"requests": requests}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result any) error {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	client := c.Client
+	if client == nil {
+		client = resty.New()
+	}
+	for attempt := 1; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		token, err := c.Auth.Token(ctx)
+		if err != nil {
+			return err
+		}
+		reqCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.Timeout > 0 {
+			reqCtx, cancel = context.WithTimeout(ctx, c.Timeout)
+		}
+		req := client.R().SetContext(reqCtx).SetAuthToken(token)
+		if body != nil {
+			req.SetHeader("Content-Type", "application/json").SetBody(body)
+		}
+		if result != nil {
+			req.SetResult(result)
+		}
+		start := time.Now()
+		resp, err := req.Execute(method, strings.TrimSuffix(baseURL, "/")+path)
+		cancel()
+		metrics.ObserveRequest(metrics.ProviderGDocs, time.Since(start), err)
+		if err != nil {
+			return errs.FromTransport(err)
+		}
+		status := resp.StatusCode()
+		if attempt < maxAttempts {
+			switch {
+			case status == http.StatusUnauthorized:
+				// токен отозван или истёк раньше срока
+				c.Auth.Invalidate()
+				continue
+			case status == http.StatusTooManyRequests || status >= 500:
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Duration(1<<attempt) * time.Second):
+				}
+				continue
+			}
+		}
+		if resp.IsError() {
+			if status == http.StatusUnauthorized || status == http.StatusForbidden {
+				return fmt.Errorf("%s: %w: %s", metrics.ProviderGDocs, errs.ErrAuth, resp.String())
+			}
+			return errs.FromStatus(metrics.ProviderGDocs, status, resp.String())
+		}
+		return nil
+	}
+}
+
+// wait выдерживает интервал между запросами
+func (c *Client) wait(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	c.mu.Lock()
+	next := c.last.Add(interval)
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package gdocs
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// codeFont шрифт блоков кода
+const codeFont = "Courier New"
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numberedRe = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	fenceRe    = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// Entry запись документа: текст и оформление. Позиции в Docs API
+// считаются в кодовых единицах UTF-16 от начала записи.
+type Entry struct {
+	Text   string
+	styles []style
+}
+
+type style struct {
+	start, end int
+	// heading HEADING_1…HEADING_3; code — моноширинный шрифт; italic —
+	// курсив; bullet и numbered — списки
+	heading  string
+	code     bool
+	italic   bool
+	bullet   bool
+	numbered bool
+}
+
+// Render запись для вопроса: заголовок title, вопрос курсивом и ответ из
+// Markdown; вопрос, совпадающий с заголовком, не повторяется. Встроенная
+// разметка (**жирный**, ссылки) остаётся текстом.
+func Render(title, question, markdown string) Entry {
+	var e Entry
+	e.line(title, style{heading: "HEADING_2"})
+	if strings.TrimSpace(question) == title {
+		question = ""
+	}
+	for _, line := range strings.Split(strings.TrimSpace(question), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			e.line(line, style{italic: true})
+		}
+	}
+	blank := false
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if fenceRe.MatchString(line) {
+			for i++; i < len(lines) && !fenceRe.MatchString(lines[i]); i++ {
+				e.line(strings.ReplaceAll(lines[i], "\t", "    "), style{code: true})
+			}
+			blank = false
+			continue
+		}
+		if trimmed == "" {
+			// подряд идущие пустые строки схлопываются в одну
+			if !blank {
+				e.line("", style{})
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		switch {
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			level := min(len(m[1])+1, 3)
+			e.line(m[2], style{heading: "HEADING_" + string(rune('0'+level))})
+		case bulletRe.MatchString(line):
+			e.line(bulletRe.FindStringSubmatch(line)[1], style{bullet: true})
+		case numberedRe.MatchString(line):
+			e.line(numberedRe.FindStringSubmatch(line)[1], style{numbered: true})
+		default:
+			e.line(trimmed, style{})
+		}
+	}
+	return e
+}
+
+// line добавляет абзац с оформлением
+func (e *Entry) line(text string, s style) {
+	s.start = utf16Len(e.Text)
+	e.Text += text + "\n"
+	s.end = utf16Len(e.Text)
+	if s == (style{start: s.start, end: s.end}) {
+		return
+	}
+	// соседние пункты одного списка оформляются одним запросом, иначе
+	// каждый станет отдельным списком
+	if n := len(e.styles); n > 0 && (s.bullet || s.numbered) {
+		prev := &e.styles[n-1]
+		if prev.end == s.start && prev.bullet == s.bullet && prev.numbered == s.numbered {
+			prev.end = s.end
+			return
+		}
+	}
+	e.styles = append(e.styles, s)
+}
+
+// Requests запросы batchUpdate, которые вставляют запись в позицию start.
+// Первый запрос вставляет текст, остальные только оформляют его и
+// повторяются без вреда, поэтому прерванную запись можно доделать, выполнив
+// оставшиеся запросы заново.
+func (e Entry) Requests(start int) []Request {
+	end := start + utf16Len(e.Text)
+	whole := rangeOf(start, end)
+	reqs := []Request{
+		{"insertText": map[string]any{"location": map[string]int{"index": start}, "text": e.Text}},
+		// вставленный текст наследует оформление абзаца, в который попал
+		{"updateParagraphStyle": map[string]any{
+			"range":          whole,
+			"paragraphStyle": map[string]string{"namedStyleType": "NORMAL_TEXT"},
+			"fields":         "namedStyleType",
+		}},
+		{"updateTextStyle": map[string]any{"range": whole, "textStyle": map[string]any{}, "fields": "italic,weightedFontFamily"}},
+		{"deleteParagraphBullets": map[string]any{"range": whole}},
+	}
+	for _, s := range e.styles {
+		r := rangeOf(start+s.start, start+s.end)
+		switch {
+		case s.heading != "":
+			reqs = append(reqs, Request{"updateParagraphStyle": map[string]any{
+				"range":          r,
+				"paragraphStyle": map[string]string{"namedStyleType": s.heading},
+				"fields":         "namedStyleType",
+			}})
+		case s.code:
+			reqs = append(reqs, Request{"updateTextStyle": map[string]any{
+				"range":     r,
+				"textStyle": map[string]any{"weightedFontFamily": map[string]string{"fontFamily": codeFont}},
+				"fields":    "weightedFontFamily",
+			}})
+		case s.italic:
+			reqs = append(reqs, Request{"updateTextStyle": map[string]any{"range": r, "textStyle": map[string]bool{"italic": true}, "fields": "italic"}})
+		case s.bullet, s.numbered:
+			preset := "BULLET_DISC_CIRCLE_SQUARE"
+			if s.numbered {
+				preset = "NUMBERED_DECIMAL_ALPHA_ROMAN"
+			}
+			reqs = append(reqs, Request{"createParagraphBullets": map[string]any{"range": r, "bulletPreset": preset}})
+		}
+	}
+	return reqs
+}
+
+// Length длина записи в кодовых единицах UTF-16
+func (e Entry) Length() int {
+	return utf16Len(e.Text)
+}
+
+func rangeOf(start, end int) map[string]int {
+	return map[string]int{"startIndex": start, "endIndex": end}
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
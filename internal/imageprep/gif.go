@@ -0,0 +1,73 @@
+package imageprep
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsGIF сообщает, GIF ли это, по расширению
+func IsGIF(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gif")
+}
+
+// GIFToPNG переводит первый кадр GIF в PNG во временной директории.
+// Кадр накладывается на фон логического экрана, как его показал бы
+// просмотрщик: прозрачные пиксели и поля вокруг кадра меньшего размера
+// получают цвет фона, белый, если фон не задан или прозрачен. Возвращает
+// путь к PNG, число кадров в файле и функцию удаления.
+func GIFToPNG(path string) (string, int, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	g, err := gif.DecodeAll(f)
+	f.Close()
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(g.Image) == 0 {
+		return "", 0, nil, fmt.Errorf("в GIF нет кадров")
+	}
+	frame := g.Image[0]
+	w, h := g.Config.Width, g.Config.Height
+	if w == 0 || h == 0 {
+		w, h = frame.Bounds().Max.X, frame.Bounds().Max.Y
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(gifBackground(g)), image.Point{}, draw.Src)
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+	base := filepath.Base(path)
+	name := strings.TrimSuffix(base, filepath.Ext(base)) + ".png"
+	out, cleanup, err := writeTemp("gif", name, encodeLike(name, canvas))
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return out, len(g.Image), cleanup, nil
+}
+
+// gifBackground непрозрачный цвет фона логического экрана GIF. В палитре
+// GIF нет прозрачности: фон считается прозрачным, если его индекс —
+// прозрачный цвет первого кадра.
+func gifBackground(g *gif.GIF) color.Color {
+	palette, ok := g.Config.ColorModel.(color.Palette)
+	i := int(g.BackgroundIndex)
+	if !ok || i >= len(palette) {
+		return color.White
+	}
+	if local := g.Image[0].Palette; i < len(local) {
+		if _, _, _, a := local[i].RGBA(); a < 0xffff {
+			return color.White
+		}
+	}
+	if _, _, _, a := palette[i].RGBA(); a < 0xffff {
+		return color.White
+	}
+	return palette[i]
+}
@@ -0,0 +1,119 @@
+package imageprep
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// rgbaAt цвет пикселя как color.RGBA
+func rgbaAt(img image.Image, x, y int) color.RGBA {
+	r, g, b, a := img.At(x, y).RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+}
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	black = color.RGBA{0, 0, 0, 255}
+)
+
+func TestGIFToPNGStatic(t *testing.T) {
+	out, frames, cleanup, err := GIFToPNG(filepath.Join("testdata", "static.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if frames != 1 || filepath.Base(out) != "static.png" {
+		t.Errorf("%q, кадров %d", out, frames)
+	}
+	img := decodeFile(t, out)
+	if b := img.Bounds(); b.Dx() != 6 || b.Dy() != 4 {
+		t.Fatalf("размер %v", b.Size())
+	}
+	if got := rgbaAt(img, 0, 0); got != red {
+		t.Errorf("слева %v", got)
+	}
+	if got := rgbaAt(img, 5, 3); got != blue {
+		t.Errorf("справа %v", got)
+	}
+}
+
+// Первый кадр анимации меньше экрана и с прозрачностью: поля и прозрачные
+// пиксели получают цвет фона, остальные кадры не смешиваются
+func TestGIFToPNGAnimated(t *testing.T) {
+	out, frames, cleanup, err := GIFToPNG(filepath.Join("testdata", "animated.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if frames != 3 {
+		t.Errorf("кадров %d, want 3", frames)
+	}
+	img := decodeFile(t, out)
+	if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 4 {
+		t.Fatalf("размер %v", b.Size())
+	}
+	for _, tc := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{1, 1, black}, // пиксель кадра
+		{2, 2, green}, // прозрачный пиксель кадра
+		{0, 0, green}, // поле вокруг кадра
+		{3, 3, green},
+	} {
+		if got := rgbaAt(img, tc.x, tc.y); got != tc.want {
+			t.Errorf("(%d,%d) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestGIFBackground(t *testing.T) {
+	palette := color.Palette{green, color.RGBA{}}
+	frame := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+	for _, tc := range []struct {
+		name string
+		g    *gif.GIF
+		want color.Color
+	}{
+		{"цвет фона", &gif.GIF{Config: image.Config{ColorModel: palette}, BackgroundIndex: 0}, green},
+		{"прозрачный фон", &gif.GIF{Config: image.Config{ColorModel: palette}, BackgroundIndex: 1}, color.White},
+		{"без глобальной палитры", &gif.GIF{}, color.White},
+		{"индекс вне палитры", &gif.GIF{Config: image.Config{ColorModel: palette}, BackgroundIndex: 7}, color.White},
+	} {
+		tc.g.Image = []*image.Paletted{frame}
+		if got := gifBackground(tc.g); got != tc.want {
+			t.Errorf("%s: %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Фон прозрачен в локальной палитре первого кадра
+	local := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.RGBA{}})
+	g := &gif.GIF{Image: []*image.Paletted{local}, Config: image.Config{ColorModel: palette}}
+	if got := gifBackground(g); got != color.White {
+		t.Errorf("прозрачный в кадре: %v", got)
+	}
+}
+
+func TestGIFToPNGCorrupted(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "animated.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{
+		"truncated.gif": data[:len(data)/2],
+		"text.gif":      []byte("не GIF"),
+	} {
+		if _, _, _, err := GIFToPNG(writeFile(t, name, data)); err == nil {
+			t.Errorf("%s: нет ошибки", name)
+		}
+	}
+	if _, _, _, err := GIFToPNG(filepath.Join("testdata", "missing.gif")); err == nil {
+		t.Error("нет ошибки для отсутствующего файла")
+	}
+}
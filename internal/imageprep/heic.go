@@ -0,0 +1,73 @@
+// Package imageprep готовит изображения к OCR: переводит форматы, которые
+// OCR.space не принимает или распознаёт плохо, в JPEG и PNG, поворачивает
+// снимки по тегу EXIF, срезает поля и режет высокие снимки на полосы.
+package imageprep
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"hack_interview/internal/errs"
+)
+
+// HEICExtensions расширения фотографий HEIC/HEIF, например с iPhone
+var HEICExtensions = []string{".heic", ".heif"}
+
+// IsHEIC сообщает, HEIC ли это, по расширению
+func IsHEIC(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range HEICExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoConverter не найдена ни одна программа для перевода HEIC в JPEG
+var ErrNoConverter = fmt.Errorf("%w: для HEIC нужна одна из программ heif-convert (libheif), magick (ImageMagick) или sips (macOS)", errs.ErrMissingTool)
+
+// heicConverters команды перевода в порядке предпочтения. heif-convert и
+// magick -auto-orient поворачивают снимок по ориентации из файла; sips
+// переносит ориентацию в EXIF результата, её применяет Orient.
+var heicConverters = []struct {
+	bin  string
+	args func(in, out string) []string
+}{
+	{"heif-convert", func(in, out string) []string { return []string{"-q", "92", in, out} }},
+	{"magick", func(in, out string) []string { return []string{in, "-auto-orient", "-quality", "92", out} }},
+	{"sips", func(in, out string) []string { return []string{"-s", "format", "jpeg", in, "--out", out} }},
+}
+
+// HEICToJPEG переводит снимок в JPEG во временной директории первой
+// найденной программой. Возвращает путь к JPEG и функцию удаления.
+func HEICToJPEG(ctx context.Context, path string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "hack_interview-heic-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	base := filepath.Base(path)
+	out := filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base))+".jpg")
+	for _, c := range heicConverters {
+		bin, err := exec.LookPath(c.bin)
+		if err != nil {
+			continue
+		}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, bin, c.args(path, out)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("%s: %w: %s", c.bin, err, strings.TrimSpace(stderr.String()))
+		}
+		return out, cleanup, nil
+	}
+	cleanup()
+	return "", nil, ErrNoConverter
+}
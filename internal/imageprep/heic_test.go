@@ -0,0 +1,84 @@
+package imageprep
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/errs"
+)
+
+func TestIsHEIC(t *testing.T) {
+	for path, want := range map[string]bool{
+		"IMG_0001.HEIC": true,
+		"shot.heif":     true,
+		"shot.heic.png": false,
+		"heic":          false,
+		"shot.jpg":      false,
+	} {
+		if got := IsHEIC(path); got != want {
+			t.Errorf("IsHEIC(%q) = %v", path, got)
+		}
+	}
+}
+
+func TestHEICToJPEGNoConverter(t *testing.T) {
+	t.Setenv("PATH", "")
+	out, cleanup, err := HEICToJPEG(context.Background(), filepath.Join("testdata", "rotated.heic"))
+	if !errors.Is(err, ErrNoConverter) || !errors.Is(err, errs.ErrMissingTool) || out != "" || cleanup != nil {
+		t.Fatalf("%q, %v", out, err)
+	}
+	for _, bin := range []string{"heif-convert", "magick", "sips"} {
+		if !strings.Contains(err.Error(), bin) {
+			t.Errorf("в ошибке нет %s: %v", bin, err)
+		}
+	}
+}
+
+// testdata/rotated.heic — снимок 40×20 с поворотом irot на 90°: после
+// перевода и поворота по EXIF, как в конвейере, JPEG должен быть 20×40.
+// Изображение в нём закодировано JPEG (HEIF, приложение H), а не HEVC,
+// чтобы файл оставался маленьким.
+func TestHEICToJPEG(t *testing.T) {
+	found := false
+	for _, c := range heicConverters {
+		if _, err := exec.LookPath(c.bin); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		t.Skip("не установлены heif-convert, magick и sips")
+	}
+	jpg, cleanup, err := HEICToJPEG(context.Background(), filepath.Join("testdata", "rotated.heic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if filepath.Base(jpg) != "rotated.jpg" {
+		t.Errorf("имя %q", jpg)
+	}
+	// sips не поворачивает сам, а переносит ориентацию в EXIF
+	oriented, cleanupOriented, err := Orient(jpg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupOriented()
+
+	f, err := os.Open(oriented)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || cfg.Width != 20 || cfg.Height != 40 {
+		t.Errorf("%s %dx%d, want jpeg 20x40", format, cfg.Width, cfg.Height)
+	}
+}
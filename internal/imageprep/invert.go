@@ -0,0 +1,106 @@
+package imageprep
+
+import (
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Светлый текст на тёмном фоне, например в IDE с тёмной темой, OCR
+// распознаёт намного хуже тёмного на светлом. Invert переводит такой
+// снимок в оттенки серого и инвертирует его; тёмный снимок определяется
+// по медиане яркости.
+
+// InvertOptions параметры инверсии
+type InvertOptions struct {
+	// Always инвертировать независимо от яркости
+	Always bool
+	// Threshold снимок с медианой яркости ниже (0-255) считается тёмным
+	Threshold int
+}
+
+// InvertDecision решение об инверсии снимка
+type InvertDecision struct {
+	// Luminance медиана яркости 0-255; -1 для формата, который не
+	// инвертируется
+	Luminance int
+	Inverted  bool
+}
+
+// invertSamples сколько пикселей примерно берётся для медианы яркости
+const invertSamples = 100_000
+
+// Invert решает по opts, инвертировать ли PNG или JPEG, и при инверсии
+// сохраняет снимок в оттенках серого во временной директории; исходный
+// файл не меняется. Снимок другого формата возвращается как есть.
+// Возвращает путь для OCR, решение и функцию удаления.
+func Invert(path string, opts InvertOptions) (string, InvertDecision, func(), error) {
+	noop := func() {}
+	d := InvertDecision{Luminance: -1}
+	if !IsPNG(path) && !IsJPEG(path) {
+		return path, d, noop, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", d, nil, err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", d, nil, err
+	}
+	gray := toGray(img)
+	d.Luminance = medianLuminance(gray)
+	d.Inverted = opts.Always || d.Luminance < opts.Threshold
+	if !d.Inverted {
+		return path, d, noop, nil
+	}
+	for i, v := range gray.Pix {
+		gray.Pix[i] = 255 - v
+	}
+	out, cleanup, err := writeTemp("invert", filepath.Base(path), encodeLike(path, gray))
+	if err != nil {
+		return "", d, nil, err
+	}
+	return out, d, cleanup, nil
+}
+
+// toGray копия изображения в оттенках серого с началом в (0, 0); яркость
+// считается как в color.GrayModel
+func toGray(img image.Image) *image.Gray {
+	rgba := toRGBA(img)
+	gray := image.NewGray(rgba.Rect)
+	for i := range gray.Pix {
+		p := rgba.Pix[i*4:][:3]
+		r, g, b := uint32(p[0])*0x101, uint32(p[1])*0x101, uint32(p[2])*0x101
+		gray.Pix[i] = uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+	}
+	return gray
+}
+
+// medianLuminance медиана яркости по равномерной сетке около
+// invertSamples пикселей
+func medianLuminance(gray *image.Gray) int {
+	w, h := gray.Rect.Dx(), gray.Rect.Dy()
+	if w == 0 || h == 0 {
+		return 0
+	}
+	step := max(1, int(math.Sqrt(float64(w*h)/invertSamples)))
+	var hist [256]int
+	n := 0
+	for y := 0; y < h; y += step {
+		for x := 0; x < w; x += step {
+			hist[gray.Pix[gray.PixOffset(x, y)]]++
+			n++
+		}
+	}
+	seen := 0
+	for v, c := range hist {
+		seen += c
+		if seen*2 >= n {
+			return v
+		}
+	}
+	return 255
+}
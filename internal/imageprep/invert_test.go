@@ -0,0 +1,73 @@
+package imageprep
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+// Светлый снимок не инвертируется, тёмный инвертируется в оттенки серого;
+// always инвертирует и светлый
+func TestInvert(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		opts      InvertOptions
+		luminance int
+		inverted  bool
+	}{
+		{"light.png", InvertOptions{Threshold: 100}, 250, false},
+		{"dark.png", InvertOptions{Threshold: 100}, 30, true},
+		{"dark.png", InvertOptions{Threshold: 30}, 30, false},
+		{"dark.png", InvertOptions{Threshold: 31}, 30, true},
+		{"light.png", InvertOptions{Always: true, Threshold: 100}, 250, true},
+	} {
+		path := filepath.Join("testdata", tc.name)
+		out, d, cleanup, err := Invert(path, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if d.Luminance != tc.luminance || d.Inverted != tc.inverted {
+			t.Errorf("%s %+v: %+v, want яркость %d, инверсия %v", tc.name, tc.opts, d, tc.luminance, tc.inverted)
+		}
+		if !tc.inverted {
+			if out != path {
+				t.Errorf("%s: не инвертированный снимок заменён на %q", tc.name, out)
+			}
+			cleanup()
+			continue
+		}
+		src, got := decodeFile(t, path), decodeFile(t, out)
+		if _, ok := got.(*image.Gray); !ok {
+			t.Errorf("%s: инверсия не в оттенках серого: %T", tc.name, got)
+		}
+		for _, p := range []image.Point{{0, 0}, {12, 12}} {
+			want := 255 - color.GrayModel.Convert(src.At(p.X, p.Y)).(color.Gray).Y
+			if y := got.(*image.Gray).GrayAt(p.X, p.Y).Y; y != want {
+				t.Errorf("%s: в %v яркость %d, want %d", tc.name, p, y, want)
+			}
+		}
+		cleanup()
+	}
+
+	if out, d, _, err := Invert("shot.gif", InvertOptions{Always: true}); err != nil || out != "shot.gif" || d.Inverted || d.Luminance != -1 {
+		t.Errorf("GIF: %q, %+v, %v", out, d, err)
+	}
+}
+
+func TestMedianLuminance(t *testing.T) {
+	gray := image.NewGray(image.Rect(0, 0, 10, 10))
+	for i := range gray.Pix {
+		if i < 40 {
+			gray.Pix[i] = 240
+		} else {
+			gray.Pix[i] = 20
+		}
+	}
+	if got := medianLuminance(gray); got != 20 {
+		t.Errorf("медиана %d, want 20", got)
+	}
+	if got := medianLuminance(image.NewGray(image.Rectangle{})); got != 0 {
+		t.Errorf("пустой снимок: %d", got)
+	}
+}
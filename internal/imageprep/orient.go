@@ -0,0 +1,251 @@
+package imageprep
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Телефон пишет снимок экрана как есть, а поворот — тегом Orientation в
+// EXIF. OCR тег не читает, поэтому снимок поворачивается заранее. HEIC
+// проходит здесь уже после перевода в JPEG: heif-convert и magick поворот
+// применяют сами и сбрасывают тег, sips переносит его в EXIF результата.
+
+// JPEGExtensions расширения снимков, у которых читается EXIF
+var JPEGExtensions = []string{".jpg", ".jpeg"}
+
+// IsJPEG сообщает, JPEG ли это, по расширению
+func IsJPEG(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range JPEGExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// tagOrientation тег Orientation в IFD0
+const tagOrientation = 0x0112
+
+// Orientation значение тега Orientation от 1 до 8. Читаются только
+// заголовки JPEG до начала данных; снимок без EXIF, с повреждённым EXIF или
+// не JPEG считается неповёрнутым (1).
+func Orientation(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 1
+	}
+	defer f.Close()
+	exif, err := readEXIF(bufio.NewReader(f))
+	if err != nil || exif == nil {
+		return 1
+	}
+	if o := exifOrientation(exif); o >= 1 && o <= 8 {
+		return o
+	}
+	return 1
+}
+
+// readEXIF содержимое сегмента APP1 с EXIF после "Exif\0\0" или nil
+func readEXIF(r *bufio.Reader) ([]byte, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil, fmt.Errorf("не JPEG")
+	}
+	for {
+		marker, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if marker != 0xFF {
+			return nil, fmt.Errorf("повреждённый заголовок JPEG")
+		}
+		kind, err := r.ReadByte()
+		for err == nil && kind == 0xFF {
+			kind, err = r.ReadByte()
+		}
+		if err != nil {
+			return nil, err
+		}
+		// SOS и EOI: дальше данные изображения, EXIF уже не встретится
+		if kind == 0xDA || kind == 0xD9 {
+			return nil, nil
+		}
+		var size [2]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, err
+		}
+		n := int(binary.BigEndian.Uint16(size[:])) - 2
+		if n < 0 {
+			return nil, fmt.Errorf("повреждённый заголовок JPEG")
+		}
+		if kind != 0xE1 {
+			if _, err := r.Discard(n); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		seg := make([]byte, n)
+		if _, err := io.ReadFull(r, seg); err != nil {
+			return nil, err
+		}
+		if exif, ok := bytes.CutPrefix(seg, []byte("Exif\x00\x00")); ok {
+			return exif, nil
+		}
+	}
+}
+
+// exifOrientation тег Orientation из TIFF-заголовка EXIF или 0
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == tagOrientation {
+			// SHORT лежит в начале поля значения
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// Orient поворачивает и отражает JPEG по тегу Orientation и сохраняет
+// результат во временной директории; исходный файл не меняется. Снимок без
+// поворота возвращается как есть без декодирования. Возвращает путь для OCR
+// и функцию удаления.
+func Orient(path string) (string, func(), error) {
+	noop := func() {}
+	if !IsJPEG(path) {
+		return path, noop, nil
+	}
+	o := Orientation(path)
+	if o == 1 {
+		return path, noop, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	img, err := jpeg.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", nil, err
+	}
+	return writeTemp("orient", filepath.Base(path), func(w io.Writer) error {
+		return jpeg.Encode(w, applyOrientation(img, o), &jpeg.Options{Quality: jpegQuality})
+	})
+}
+
+// applyOrientation приводит изображение к нормальному виду для тега o
+func applyOrientation(img image.Image, o int) image.Image {
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+
+	// pos место пикселя (x, y) исходника в результате
+	var pos func(x, y int) (int, int)
+	dw, dh := w, h
+	switch o {
+	case 2:
+		pos = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3:
+		pos = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4:
+		pos = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5:
+		dw, dh = h, w
+		pos = func(x, y int) (int, int) { return y, x }
+	case 6:
+		dw, dh = h, w
+		pos = func(x, y int) (int, int) { return h - 1 - y, x }
+	case 7:
+		dw, dh = h, w
+		pos = func(x, y int) (int, int) { return h - 1 - y, w - 1 - x }
+	case 8:
+		dw, dh = h, w
+		pos = func(x, y int) (int, int) { return y, w - 1 - x }
+	default:
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := pos(x, y)
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+	return dst
+}
+
+// toRGBA копия изображения в RGBA с началом координат в (0, 0)
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// jpegQuality качество JPEG после обработки снимка
+const jpegQuality = 92
+
+// encodeLike кодирует img в формате файла path: PNG или JPEG
+func encodeLike(path string, img image.Image) func(io.Writer) error {
+	return func(w io.Writer) error {
+		if IsJPEG(path) {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+		}
+		return png.Encode(w, img)
+	}
+}
+
+// writeTemp записывает изображение encode в файл name во временной
+// директории. Возвращает путь и функцию удаления.
+func writeTemp(step, name string, encode func(io.Writer) error) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "hack_interview-"+step+"-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	out := filepath.Join(dir, name)
+	f, err := os.Create(out)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	err = encode(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return out, cleanup, nil
+}
@@ -0,0 +1,191 @@
+package imageprep
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writePNG сохраняет img как PNG во временной директории теста
+func writePNG(t *testing.T, name string, img image.Image) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return writeFile(t, name, buf.Bytes())
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// decodeFile декодирует PNG, JPEG или GIF
+func decodeFile(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// exifSegment сегмент APP1 с одним тегом Orientation
+func exifSegment(order binary.ByteOrder, o int) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.BigEndian {
+		copy(tiff, "MM")
+	} else {
+		copy(tiff, "II")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], tagOrientation)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(o))
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// jpegWithOrientation JPEG из img с тегом Orientation o сразу после SOI
+func jpegWithOrientation(t *testing.T, img image.Image, o int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(binary.BigEndian, o)...)
+	return append(out, data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for o := 1; o <= 8; o++ {
+		path := writeFile(t, "shot.jpg", jpegWithOrientation(t, img, o))
+		if got := Orientation(path); got != o {
+			t.Errorf("Orientation = %d, want %d", got, o)
+		}
+	}
+	if got := exifOrientation(exifSegment(binary.LittleEndian, 6)[10:]); got != 6 {
+		t.Errorf("little endian: %d", got)
+	}
+
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, img, nil)
+	for name, data := range map[string][]byte{
+		"no-exif.jpg": plain.Bytes(),
+		"broken.jpg":  append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00}, "Exif"...),
+		"not.jpg":     []byte("GIF89a"),
+	} {
+		if got := Orientation(writeFile(t, name, data)); got != 1 {
+			t.Errorf("%s: Orientation = %d, want 1", name, got)
+		}
+	}
+}
+
+// Углы исходника 3×2 после приведения к нормальному виду
+func TestApplyOrientation(t *testing.T) {
+	const w, h = 3, 2
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	topLeft := color.RGBA{255, 0, 0, 255}
+	topRight := color.RGBA{0, 255, 0, 255}
+	src.Set(0, 0, topLeft)
+	src.Set(w-1, 0, topRight)
+
+	for _, tc := range []struct {
+		o           int
+		dw, dh      int
+		left, right image.Point
+	}{
+		{1, w, h, image.Pt(0, 0), image.Pt(w-1, 0)},
+		{2, w, h, image.Pt(w-1, 0), image.Pt(0, 0)},
+		{3, w, h, image.Pt(w-1, h-1), image.Pt(0, h-1)},
+		{4, w, h, image.Pt(0, h-1), image.Pt(w-1, h-1)},
+		{5, h, w, image.Pt(0, 0), image.Pt(0, w-1)},
+		{6, h, w, image.Pt(h-1, 0), image.Pt(h-1, w-1)},
+		{7, h, w, image.Pt(h-1, w-1), image.Pt(h-1, 0)},
+		{8, h, w, image.Pt(0, w-1), image.Pt(0, 0)},
+	} {
+		dst := toRGBA(applyOrientation(src, tc.o))
+		if dst.Rect.Dx() != tc.dw || dst.Rect.Dy() != tc.dh {
+			t.Errorf("o=%d: размер %v, want %dx%d", tc.o, dst.Rect.Size(), tc.dw, tc.dh)
+			continue
+		}
+		if got := dst.RGBAAt(tc.left.X, tc.left.Y); got != topLeft {
+			t.Errorf("o=%d: в %v %v, want левый верхний угол", tc.o, tc.left, got)
+		}
+		if got := dst.RGBAAt(tc.right.X, tc.right.Y); got != topRight {
+			t.Errorf("o=%d: в %v %v, want правый верхний угол", tc.o, tc.right, got)
+		}
+	}
+}
+
+// Левая половина исходника 40×20 белая, правая чёрная; white и black —
+// точки, где после поворота должен оказаться каждый цвет
+func TestOrient(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	for _, tc := range []struct {
+		o            int
+		w, h         int
+		white, black image.Point
+	}{
+		{3, 40, 20, image.Pt(30, 10), image.Pt(10, 10)},
+		{6, 20, 40, image.Pt(10, 5), image.Pt(10, 35)},
+		{8, 20, 40, image.Pt(10, 35), image.Pt(10, 5)},
+	} {
+		path := writeFile(t, "shot.jpg", jpegWithOrientation(t, img, tc.o))
+		out, cleanup, err := Orient(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out == path {
+			t.Fatalf("o=%d: повёрнутый снимок возвращён как есть", tc.o)
+		}
+		got := decodeFile(t, out)
+		cleanup()
+		if b := got.Bounds(); b.Dx() != tc.w || b.Dy() != tc.h {
+			t.Errorf("o=%d: размер %v, want %dx%d", tc.o, b.Size(), tc.w, tc.h)
+			continue
+		}
+		if r, _, _, _ := got.At(tc.white.X, tc.white.Y).RGBA(); r < 0xF000 {
+			t.Errorf("o=%d: в %v не белый: %v", tc.o, tc.white, got.At(tc.white.X, tc.white.Y))
+		}
+		if r, _, _, _ := got.At(tc.black.X, tc.black.Y).RGBA(); r > 0x1000 {
+			t.Errorf("o=%d: в %v не чёрный: %v", tc.o, tc.black, got.At(tc.black.X, tc.black.Y))
+		}
+	}
+
+	plain := writePNG(t, "shot.png", img)
+	if out, cleanup, err := Orient(plain); err != nil || out != plain {
+		t.Errorf("PNG: %q, %v", out, err)
+	} else {
+		cleanup()
+	}
+}
@@ -0,0 +1,169 @@
+package imageprep
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Снимок прокрученной страницы целиком бывает высотой в тысячи пикселей:
+// OCR.space такой отклоняет или распознаёт плохо. Split режет его на
+// горизонтальные полосы с перекрытием, чтобы строка на границе целиком
+// попала хотя бы в одну из них, а Stitch склеивает текст соседних полос,
+// убирая повтор из перекрытия.
+
+// SplitOptions параметры нарезки высоких снимков
+type SplitOptions struct {
+	// MaxHeight снимок выше режется на полосы этой высоты
+	MaxHeight int
+	// Overlap высота перекрытия соседних полос
+	Overlap int
+	// MaxStrips наибольшее число полос: при большем полосы делаются выше
+	MaxStrips int
+}
+
+// Split режет PNG или JPEG выше opts.MaxHeight на полосы во временной
+// директории; исходный файл не меняется. Снимок не выше порога или другого
+// формата возвращается как есть без декодирования. Возвращает пути полос
+// сверху вниз и функцию удаления.
+func Split(path string, opts SplitOptions) ([]string, func(), error) {
+	noop := func() {}
+	if !IsPNG(path) && !IsJPEG(path) {
+		return []string{path}, noop, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	strips := stripBounds(cfg.Width, cfg.Height, opts)
+	if len(strips) < 2 {
+		return []string{path}, noop, nil
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, nil, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	rgba := toRGBA(img)
+
+	var cleanups []func()
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	paths := make([]string, 0, len(strips))
+	for i, r := range strips {
+		name := fmt.Sprintf("%s-%d%s", strings.TrimSuffix(base, ext), i+1, ext)
+		p, c, err := writeTemp("split", name, encodeLike(path, rgba.SubImage(r)))
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		cleanups = append(cleanups, c)
+		paths = append(paths, p)
+	}
+	return paths, cleanup, nil
+}
+
+// stripBounds полосы снимка w×h сверху вниз; одна, если резать не нужно
+func stripBounds(w, h int, opts SplitOptions) []image.Rectangle {
+	if opts.MaxHeight <= 0 || h <= opts.MaxHeight || opts.Overlap >= opts.MaxHeight {
+		return []image.Rectangle{image.Rect(0, 0, w, h)}
+	}
+	height, step := opts.MaxHeight, opts.MaxHeight-opts.Overlap
+	n := ceilDiv(h-opts.Overlap, step)
+	if opts.MaxStrips > 0 && n > opts.MaxStrips {
+		n = opts.MaxStrips
+		step = ceilDiv(h-opts.Overlap, n)
+		height = step + opts.Overlap
+	}
+	strips := make([]image.Rectangle, 0, n)
+	for i := 0; i < n; i++ {
+		y := i * step
+		strips = append(strips, image.Rect(0, y, w, min(y+height, h)))
+	}
+	return strips
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+const (
+	// stitchSlack сколько строк у границы полосы могут быть разрезаны и
+	// распознаны с ошибками: они не участвуют в сравнении
+	stitchSlack = 2
+	// stitchWindow сколько последних строк верхней полосы сравнивается
+	stitchWindow = 40
+	// stitchMinChars совпадение из одной строки короче этого не считается
+	// перекрытием: строки вроде } в коде повторяются и подряд
+	stitchMinChars = 12
+)
+
+// Stitch склеивает текст соседних полос above и below. В конце above ищется
+// самый длинный ряд строк, совпадающий с началом below с точностью до
+// пробелов; строки после него в above и до его конца в below отбрасываются
+// как повтор из перекрытия или разрезанные границей полосы. Без совпадения
+// тексты просто соединяются.
+func Stitch(above, below string) string {
+	if strings.TrimSpace(above) == "" {
+		return below
+	}
+	if strings.TrimSpace(below) == "" {
+		return above
+	}
+	a := strings.Split(strings.TrimRight(above, "\r\n"), "\n")
+	b := strings.Split(strings.TrimLeft(below, "\r\n"), "\n")
+	na, nb := normLines(a), normLines(b)
+
+	bestLen, bestA, bestB := 0, 0, 0
+	for i := max(0, len(a)-stitchWindow); i < len(a); i++ {
+		for j := 0; j <= stitchSlack && j < len(b); j++ {
+			k, chars := 0, 0
+			for i+k < len(a) && j+k < len(b) && na[i+k] == nb[j+k] {
+				chars += len(na[i+k])
+				k++
+			}
+			// Ряд доходит до конца above с точностью до разрезанных строк
+			if k == 0 || i+k < len(a)-stitchSlack || chars == 0 {
+				continue
+			}
+			if k == 1 && chars < stitchMinChars {
+				continue
+			}
+			if k > bestLen {
+				bestLen, bestA, bestB = k, i, j
+			}
+		}
+	}
+	if bestLen == 0 {
+		return strings.TrimRight(above, "\r\n") + "\n" + strings.TrimLeft(below, "\r\n")
+	}
+	lines := append(a[:bestA+bestLen:bestA+bestLen], b[bestB+bestLen:]...)
+	// Склеенный текст с одинаковыми концами строк, даже если OCR вернул CRLF
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normLines строки без концевых \r и с одиночными пробелами
+func normLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.Join(strings.Fields(l), " ")
+	}
+	return out
+}
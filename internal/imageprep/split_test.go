@@ -0,0 +1,119 @@
+package imageprep
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestStripBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		h    int
+		opts SplitOptions
+		want []int // верх и низ каждой полосы
+	}{
+		{"не выше порога", 400, SplitOptions{MaxHeight: 400, Overlap: 50}, []int{0, 400}},
+		{"выключено", 8000, SplitOptions{}, []int{0, 8000}},
+		{"перекрытие не меньше полосы", 8000, SplitOptions{MaxHeight: 400, Overlap: 400}, []int{0, 8000}},
+		{"полосы с перекрытием", 1000, SplitOptions{MaxHeight: 400, Overlap: 50},
+			[]int{0, 400, 350, 750, 700, 1000}},
+		{"ровно на границе", 750, SplitOptions{MaxHeight: 400, Overlap: 50}, []int{0, 400, 350, 750}},
+		{"полосы выше при ограничении", 1000, SplitOptions{MaxHeight: 400, Overlap: 50, MaxStrips: 2},
+			[]int{0, 525, 475, 1000}},
+		{"ограничение не мешает", 1000, SplitOptions{MaxHeight: 400, Overlap: 50, MaxStrips: 5},
+			[]int{0, 400, 350, 750, 700, 1000}},
+	} {
+		var got []int
+		for _, r := range stripBounds(1200, tc.h, tc.opts) {
+			if r.Dx() != 1200 {
+				t.Errorf("%s: ширина полосы %d", tc.name, r.Dx())
+			}
+			got = append(got, r.Min.Y, r.Max.Y)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Снимок 1200×8000 при любом ограничении покрыт полосами целиком, соседние
+// перекрываются не меньше чем на Overlap, а полос не больше MaxStrips
+func TestStripBoundsCover(t *testing.T) {
+	const h = 8000
+	for _, maxStrips := range []int{0, 1, 3, 7, 100} {
+		opts := SplitOptions{MaxHeight: 1000, Overlap: 120, MaxStrips: maxStrips}
+		strips := stripBounds(1200, h, opts)
+		if maxStrips > 0 && len(strips) > maxStrips {
+			t.Errorf("maxStrips %d: %d полос", maxStrips, len(strips))
+		}
+		if strips[0].Min.Y != 0 || strips[len(strips)-1].Max.Y != h {
+			t.Errorf("maxStrips %d: покрыто %d-%d", maxStrips, strips[0].Min.Y, strips[len(strips)-1].Max.Y)
+		}
+		for i := 1; i < len(strips); i++ {
+			if overlap := strips[i-1].Max.Y - strips[i].Min.Y; overlap < opts.Overlap {
+				t.Errorf("maxStrips %d: перекрытие полос %d и %d — %d", maxStrips, i, i+1, overlap)
+			}
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tall := writePNG(t, "tall.png", image.NewRGBA(image.Rect(0, 0, 30, 1000)))
+	strips, cleanup, err := Split(tall, SplitOptions{MaxHeight: 400, Overlap: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if len(strips) != 3 {
+		t.Fatalf("полосы %q", strips)
+	}
+	for i, want := range []int{400, 400, 300} {
+		if b := decodeFile(t, strips[i]).Bounds(); b.Dx() != 30 || b.Dy() != want {
+			t.Errorf("полоса %d: %v, want 30x%d", i+1, b.Size(), want)
+		}
+	}
+
+	low := writePNG(t, "low.png", image.NewRGBA(image.Rect(0, 0, 30, 300)))
+	if strips, _, err := Split(low, SplitOptions{MaxHeight: 400, Overlap: 50}); err != nil || !reflect.DeepEqual(strips, []string{low}) {
+		t.Errorf("невысокий снимок: %q, %v", strips, err)
+	}
+	if _, _, err := Split(writeFile(t, "broken.png", []byte("не PNG")), SplitOptions{MaxHeight: 400}); err == nil {
+		t.Error("битый PNG нарезан без ошибки")
+	}
+}
+
+func TestStitch(t *testing.T) {
+	for _, tc := range []struct {
+		name, above, below, want string
+	}{
+		{"повтор из перекрытия",
+			"Дан массив чисел\nНайдите два числа\nсумма которых равна k\n",
+			"Найдите два числа\nсумма которых равна k\nи верните их индексы",
+			"Дан массив чисел\nНайдите два числа\nсумма которых равна k\nи верните их индексы"},
+		{"разрезанные границей строки",
+			"func twoSum(nums []int) {\n\tseen := map[int]int{}\n\tfor i, n := range nums {\n\t\tif j, ok := se",
+			"ok := seen[k-n]; ok {\n\tseen := map[int]int{}\n\tfor i, n := range nums {\n\t\treturn j, i",
+			"func twoSum(nums []int) {\n\tseen := map[int]int{}\n\tfor i, n := range nums {\n\t\treturn j, i"},
+		{"пробелы распознаны по-разному",
+			"Условие\nreturn  a +  b\nsum := total value",
+			"return a + b\nsum := total value\nПример",
+			"Условие\nreturn  a +  b\nsum := total value\nПример"},
+		{"короткая строка — не перекрытие",
+			"func f() {\n}\n",
+			"}\nfunc g() {}",
+			"func f() {\n}\n}\nfunc g() {}"},
+		{"совпадение далеко от конца",
+			"общая длинная строка\nA\nB\nC\nD",
+			"общая длинная строка\nE",
+			"общая длинная строка\nA\nB\nC\nD\nобщая длинная строка\nE"},
+		{"без перекрытия", "первая полоса\n\n", "\nвторая полоса", "первая полоса\nвторая полоса"},
+		{"CRLF", "Дан массив чисел\r\nНайдите два числа\r\n", "Найдите два числа\r\nответ", "Дан массив чисел\nНайдите два числа\nответ"},
+		{"пустая верхняя", " \n", "вторая", "вторая"},
+		{"пустая нижняя", "первая", "\n", "первая"},
+	} {
+		if got := Stitch(tc.above, tc.below); got != tc.want {
+			t.Errorf("%s:\n%q\nwant\n%q", tc.name, got, tc.want)
+		}
+	}
+}
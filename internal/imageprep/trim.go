@@ -0,0 +1,108 @@
+package imageprep
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Снимок модального окна на большом мониторе — в основном однотонный фон
+// вокруг. Trim срезает такие поля: с каждого края внутрь снимаются строки
+// и столбцы, все пиксели которых отличаются друг от друга не больше
+// допуска.
+
+// IsPNG сообщает, PNG ли это, по расширению
+func IsPNG(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".png")
+}
+
+// TrimOptions параметры обрезки полей
+type TrimOptions struct {
+	// Tolerance допустимое отличие канала цвета от фона, 0-255
+	Tolerance int
+	// MinSize минимальная ширина и высота содержимого в пикселях: если
+	// после обрезки остаётся меньше, снимок почти пустой и не обрезается
+	MinSize int
+}
+
+// trimPadding сколько пикселей фона оставить вокруг содержимого: OCR
+// хуже читает текст вплотную к краю
+const trimPadding = 4
+
+// Trim срезает однотонные поля PNG или JPEG и сохраняет результат в том же
+// формате во временной директории; исходный файл не меняется. Снимок без
+// полей, почти пустой или другого формата возвращается как есть.
+// Возвращает путь для OCR и функцию удаления.
+func Trim(path string, opts TrimOptions) (string, func(), error) {
+	noop := func() {}
+	if !IsPNG(path) && !IsJPEG(path) {
+		return path, noop, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", nil, err
+	}
+	rgba := toRGBA(img)
+	content, ok := trimBounds(rgba, opts)
+	if !ok {
+		return path, noop, nil
+	}
+	return writeTemp("trim", filepath.Base(path), encodeLike(path, rgba.SubImage(content)))
+}
+
+// trimBounds область содержимого с отступом trimPadding или false, если
+// обрезать нечего или содержимое меньше opts.MinSize
+func trimBounds(img *image.RGBA, opts TrimOptions) (image.Rectangle, bool) {
+	b := img.Rect
+	if b.Empty() {
+		return image.Rectangle{}, false
+	}
+	at := func(x, y int) []uint8 { return img.Pix[img.PixOffset(x, y):][:4] }
+	// uniform все пиксели полосы отличаются от её первого пикселя не больше
+	// допуска
+	uniform := func(x0, y0, x1, y1 int) bool {
+		bg := at(x0, y0)
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				p := at(x, y)
+				for c := 0; c < 4; c++ {
+					if d := int(p[c]) - int(bg[c]); d > opts.Tolerance || -d > opts.Tolerance {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	// Фон у каждого края свой и может меняться по мере обрезки: панель
+	// задач у нижнего края, над ней фон другого цвета
+	top, bottom, left, right := b.Min.Y, b.Max.Y, b.Min.X, b.Max.X
+	for top < bottom && uniform(left, top, right, top+1) {
+		top++
+	}
+	if top == bottom {
+		// однотонный снимок
+		return image.Rectangle{}, false
+	}
+	for bottom > top && uniform(left, bottom-1, right, bottom) {
+		bottom--
+	}
+	for left < right && uniform(left, top, left+1, bottom) {
+		left++
+	}
+	for right > left && uniform(right-1, top, right, bottom) {
+		right--
+	}
+	if right-left < opts.MinSize || bottom-top < opts.MinSize {
+		return image.Rectangle{}, false
+	}
+	content := image.Rect(left-trimPadding, top-trimPadding, right+trimPadding, bottom+trimPadding).Intersect(b)
+	return content, content != b
+}
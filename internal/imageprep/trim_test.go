@@ -0,0 +1,123 @@
+package imageprep
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// bordered снимок w×h с фоном bg и содержимым в прямоугольнике r.
+// Содержимое — шахматка чёрного и серого: сплошной прямоугольник сам
+// однотонный и срезался бы как поле.
+func bordered(w, h int, bg color.Color, r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Rect, image.NewUniform(bg), image.Point{}, draw.Src)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if (x+y)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{128, 128, 128, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestTrimBounds(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	box := image.Rect(40, 30, 60, 40)
+
+	// Шум фона в пределах 6 по каждому каналу
+	noisy := bordered(100, 80, white, box)
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 100; x++ {
+			if !image.Pt(x, y).In(box) {
+				v := uint8(249 + (x*3+y*5)%7)
+				noisy.SetRGBA(x, y, color.RGBA{v, v, v, 255})
+			}
+		}
+	}
+
+	// Панель задач у нижнего края, над ней фон другого цвета
+	taskbar := bordered(100, 80, white, box)
+	draw.Draw(taskbar, image.Rect(0, 70, 100, 80), image.NewUniform(color.RGBA{40, 40, 60, 255}), image.Point{}, draw.Src)
+
+	for _, tc := range []struct {
+		name string
+		img  *image.RGBA
+		opts TrimOptions
+		want image.Rectangle
+		ok   bool
+	}{
+		{"поля вокруг содержимого", bordered(100, 80, white, box), TrimOptions{MinSize: 5},
+			image.Rect(36, 26, 64, 44), true},
+		{"шум в пределах допуска", noisy, TrimOptions{Tolerance: 8, MinSize: 5},
+			image.Rect(36, 26, 64, 44), true},
+		{"шум больше допуска", noisy, TrimOptions{Tolerance: 3, MinSize: 5}, image.Rectangle{}, false},
+		{"свой фон у каждого края", taskbar, TrimOptions{MinSize: 5},
+			image.Rect(36, 26, 64, 44), true},
+		{"отступ не выходит за снимок", bordered(100, 80, white, image.Rect(0, 30, 60, 80)), TrimOptions{MinSize: 5},
+			image.Rect(0, 26, 64, 80), true},
+		{"содержимое меньше minSize", bordered(100, 80, white, image.Rect(50, 40, 52, 42)), TrimOptions{MinSize: 10},
+			image.Rectangle{}, false},
+		{"содержимое ровно minSize", bordered(100, 80, white, image.Rect(50, 40, 60, 50)), TrimOptions{MinSize: 10},
+			image.Rect(46, 36, 64, 54), true},
+		{"однотонный снимок", bordered(100, 80, white, image.Rectangle{}), TrimOptions{}, image.Rectangle{}, false},
+		{"без полей", bordered(100, 80, white, image.Rect(0, 0, 100, 80)), TrimOptions{}, image.Rectangle{}, false},
+		{"поля уже не больше отступа", bordered(100, 80, white, image.Rect(2, 2, 98, 78)), TrimOptions{},
+			image.Rectangle{}, false},
+	} {
+		got, ok := trimBounds(tc.img, tc.opts)
+		if ok != tc.ok || ok && got != tc.want {
+			t.Errorf("%s: %v, %v; want %v, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	white := color.RGBA{255, 255, 255, 255}
+	img := bordered(400, 300, white, image.Rect(150, 100, 250, 160))
+	opts := TrimOptions{Tolerance: 8, MinSize: 20}
+
+	for _, name := range []string{"shot.png", "shot.jpg"} {
+		var path string
+		if IsPNG(name) {
+			path = writePNG(t, name, img)
+		} else {
+			// JPEG искажает цвета у границ: допуск это покрывает
+			path = writeFile(t, name, jpegWithOrientation(t, img, 1))
+		}
+		out, cleanup, err := Trim(path, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out == path {
+			t.Fatalf("%s: снимок с полями не обрезан", name)
+		}
+		got := decodeFile(t, out)
+		if b := got.Bounds(); b.Dx() < 108 || b.Dx() > 112 || b.Dy() < 68 || b.Dy() > 72 {
+			t.Errorf("%s: размер %v, want около 108x68", name, b.Size())
+		}
+		center := image.Pt(got.Bounds().Dx()/2, got.Bounds().Dy()/2)
+		if r, _, _, _ := got.At(center.X, center.Y).RGBA(); r > 0xA000 {
+			t.Errorf("%s: в центре не содержимое: %v", name, got.At(center.X, center.Y))
+		}
+		cleanup()
+	}
+
+	// Почти пустой снимок и неподдерживаемый формат возвращаются как есть
+	blank := writePNG(t, "blank.png", bordered(400, 300, white, image.Rect(200, 150, 203, 153)))
+	if out, cleanup, err := Trim(blank, opts); err != nil || out != blank {
+		t.Errorf("почти пустой: %q, %v", out, err)
+	} else {
+		cleanup()
+	}
+	if out, _, err := Trim("shot.bmp", opts); err != nil || out != "shot.bmp" {
+		t.Errorf("BMP: %q, %v", out, err)
+	}
+	if _, _, err := Trim(writeFile(t, "broken.png", []byte("не PNG")), opts); err == nil {
+		t.Error("битый PNG обрезан без ошибки")
+	}
+}
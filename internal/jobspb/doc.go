@@ -0,0 +1,5 @@
+// Package jobspb описание и сгенерированный код gRPC-сервиса заданий
+// (jobs.proto): сервер в пакете main, клиент — JobsClient.
+package jobspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative jobs.proto
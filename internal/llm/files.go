@@ -0,0 +1,252 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Снимки к запросу (мультимодальный режим) отправляются в теле запроса
+// (inline_data), пока не больше InlineLimit. Более крупные загружаются
+// через Files API: файл передаётся по протоколу resumable, после загрузки
+// ожидается состояние ACTIVE, запрос ссылается на него через file_uri, а
+// после ответа файл удаляется, если не задан KeepFiles.
+
+// DefaultInlineLimit порог InlineLimit по умолчанию: у всего запроса
+// предел 20 МБ, base64 увеличивает снимок на треть, а снимков может быть
+// несколько
+const DefaultInlineLimit = 4 << 20
+
+// defaultFilePoll интервал опроса состояния загруженного файла
+const defaultFilePoll = time.Second
+
+// Image снимок, отправляемый модели вместе с промптом
+type Image struct {
+	Path     string
+	MIMEType string
+}
+
+// ImageMIMEType тип снимка, который принимает Gemini, по расширению или
+// "", если формат не поддерживается
+func ImageMIMEType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	case ".heic":
+		return "image/heic"
+	case ".heif":
+		return "image/heif"
+	}
+	return ""
+}
+
+// Blob данные снимка в теле запроса
+type Blob struct {
+	MIMEType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
+// FileData ссылка на файл, загруженный через Files API
+type FileData struct {
+	MIMEType string `json:"mime_type"`
+	FileURI  string `json:"file_uri"`
+}
+
+// File файл Files API
+type File struct {
+	Name     string `json:"name"`
+	URI      string `json:"uri"`
+	MIMEType string `json:"mimeType"`
+	State    string `json:"state"`
+	Error    *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Состояния файла Files API
+const (
+	FileProcessing = "PROCESSING"
+	FileActive     = "ACTIVE"
+	FileFailed     = "FAILED"
+)
+
+// imageParts части запроса со снимками и загруженные для них файлы,
+// которые нужно удалить после ответа
+func (g *Gemini) imageParts(ctx context.Context, client *resty.Client, images []Image) ([]Part, []File, error) {
+	var parts []Part
+	var uploaded []File
+	limit := g.InlineLimit
+	if limit <= 0 {
+		limit = DefaultInlineLimit
+	}
+	for _, img := range images {
+		fi, err := os.Stat(img.Path)
+		if err != nil {
+			return nil, uploaded, err
+		}
+		if fi.Size() <= limit {
+			data, err := os.ReadFile(img.Path)
+			if err != nil {
+				return nil, uploaded, err
+			}
+			parts = append(parts, Part{InlineData: &Blob{MIMEType: img.MIMEType, Data: base64.StdEncoding.EncodeToString(data)}})
+			continue
+		}
+		f, err := g.upload(ctx, client, img)
+		if err != nil {
+			return nil, uploaded, err
+		}
+		uploaded = append(uploaded, f)
+		if f, err = g.waitActive(ctx, client, f); err != nil {
+			return nil, uploaded, err
+		}
+		parts = append(parts, Part{FileData: &FileData{MIMEType: img.MIMEType, FileURI: f.URI}})
+	}
+	return parts, uploaded, nil
+}
+
+// withKey добавляет ключ к запросу: заголовком или параметром адреса
+func (g *Gemini) withKey(req *resty.Request, endpoint string) (*resty.Request, string) {
+	if g.KeyInHeader {
+		return req.SetHeader("x-goog-api-key", g.APIKey), endpoint
+	}
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return req, endpoint + sep + "key=" + g.APIKey
+}
+
+func (g *Gemini) baseURL() string {
+	if g.BaseURL == "" {
+		return DefaultGeminiURL
+	}
+	return strings.TrimSuffix(g.BaseURL, "/")
+}
+
+// upload загружает снимок по протоколу resumable: первый запрос получает
+// адрес загрузки, второй передаёт содержимое и завершает загрузку
+func (g *Gemini) upload(ctx context.Context, client *resty.Client, img Image) (File, error) {
+	data, err := os.ReadFile(img.Path)
+	if err != nil {
+		return File{}, err
+	}
+	meta, _ := json.Marshal(map[string]any{"file": map[string]string{"display_name": filepath.Base(img.Path)}})
+	req, endpoint := g.withKey(client.R().
+		SetContext(ctx).
+		SetHeaders(g.Headers).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("X-Goog-Upload-Protocol", "resumable").
+		SetHeader("X-Goog-Upload-Command", "start").
+		SetHeader("X-Goog-Upload-Header-Content-Length", strconv.Itoa(len(data))).
+		SetHeader("X-Goog-Upload-Header-Content-Type", img.MIMEType).
+		SetBody(meta), g.baseURL()+"/upload/v1beta/files")
+	resp, err := g.do(req, http.MethodPost, endpoint)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: %w", errs.ErrFileUpload, err)
+	}
+	uploadURL := resp.Header().Get("X-Goog-Upload-URL")
+	if uploadURL == "" {
+		return File{}, fmt.Errorf("%w: в ответе нет X-Goog-Upload-URL", errs.ErrFileUpload)
+	}
+
+	resp, err = g.do(client.R().
+		SetContext(ctx).
+		SetHeaders(g.Headers).
+		SetHeader("X-Goog-Upload-Offset", "0").
+		SetHeader("X-Goog-Upload-Command", "upload, finalize").
+		SetBody(data), http.MethodPost, uploadURL)
+	if err != nil {
+		return File{}, fmt.Errorf("%w: %w", errs.ErrFileUpload, err)
+	}
+	var out struct {
+		File File `json:"file"`
+	}
+	if err := json.Unmarshal(resp.Body(), &out); err != nil || out.File.Name == "" {
+		return File{}, fmt.Errorf("%w: некорректный ответ: %s", errs.ErrFileUpload, resp.String())
+	}
+	return out.File, nil
+}
+
+// waitActive опрашивает состояние файла, пока он не станет ACTIVE
+func (g *Gemini) waitActive(ctx context.Context, client *resty.Client, f File) (File, error) {
+	poll := g.FilePollInterval
+	if poll <= 0 {
+		poll = defaultFilePoll
+	}
+	for f.State != FileActive {
+		if f.State == FileFailed {
+			msg := ""
+			if f.Error != nil {
+				msg = ": " + f.Error.Message
+			}
+			return f, fmt.Errorf("%w: %s в состоянии FAILED%s", errs.ErrFileProcessing, f.Name, msg)
+		}
+		select {
+		case <-ctx.Done():
+			return f, fmt.Errorf("%w: %s: %w", errs.ErrFileProcessing, f.Name, errs.FromTransport(ctx.Err()))
+		case <-time.After(poll):
+		}
+		req, endpoint := g.withKey(client.R().SetContext(ctx).SetHeaders(g.Headers), g.baseURL()+"/v1beta/"+f.Name)
+		resp, err := g.do(req, http.MethodGet, endpoint)
+		if err != nil {
+			return f, fmt.Errorf("%w: %w", errs.ErrFileProcessing, err)
+		}
+		if err := json.Unmarshal(resp.Body(), &f); err != nil {
+			return f, fmt.Errorf("%w: некорректный ответ: %s", errs.ErrFileProcessing, resp.String())
+		}
+	}
+	return f, nil
+}
+
+// deleteFiles удаляет загруженные файлы. Срок ctx запроса к этому моменту
+// мог истечь, поэтому удаление идёт в своём контексте со сроком Timeout.
+func (g *Gemini) deleteFiles(ctx context.Context, client *resty.Client, files []File) error {
+	ctx = context.WithoutCancel(ctx)
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+	var failed []string
+	for _, f := range files {
+		req, endpoint := g.withKey(client.R().SetContext(ctx).SetHeaders(g.Headers), g.baseURL()+"/v1beta/"+f.Name)
+		if _, err := g.do(req, http.MethodDelete, endpoint); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", errs.ErrFileCleanup, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// do выполняет запрос к Files API и классифицирует неуспешный ответ
+func (g *Gemini) do(req *resty.Request, method, endpoint string) (*resty.Response, error) {
+	start := time.Now()
+	resp, err := req.Execute(method, endpoint)
+	metrics.ObserveRequest(metrics.ProviderGemini, time.Since(start), err)
+	if err != nil {
+		return nil, errs.FromTransport(err)
+	}
+	if resp.IsError() {
+		return nil, errs.FromStatus(metrics.ProviderGemini, resp.StatusCode(), resp.String())
+	}
+	return resp, nil
+}
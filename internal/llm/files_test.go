@@ -0,0 +1,319 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"hack_interview/internal/errs"
+)
+
+// recorded запрос, полученный подставным Gemini API
+type recorded struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// filesServer отвечает записанными ответами Gemini API из testdata/files.
+// states — состояния файла, которые возвращают последовательные GET;
+// status — код ответа по "метод путь" вместо записанного ответа.
+type filesServer struct {
+	*httptest.Server
+	t      *testing.T
+	states []string
+	status map[string]int
+
+	mu   sync.Mutex
+	reqs []recorded
+	gets int
+}
+
+func newFilesServer(t *testing.T, states ...string) *filesServer {
+	t.Helper()
+	s := &filesServer{t: t, states: states, status: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "files", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (s *filesServer) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	key := r.Method + " " + r.URL.Path
+	s.mu.Lock()
+	s.reqs = append(s.reqs, recorded{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body})
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if code := s.status[key]; code != 0 {
+		w.WriteHeader(code)
+		w.Write(fixture(s.t, "error-500.json"))
+		return
+	}
+	switch key {
+	case "POST /upload/v1beta/files":
+		w.Header().Set("X-Goog-Upload-URL", s.URL+"/upload/session/1")
+		w.Header().Set("X-Goog-Upload-Status", "active")
+	case "POST /upload/session/1":
+		w.Header().Set("X-Goog-Upload-Status", "final")
+		w.Write(fixture(s.t, "upload.json"))
+	case "GET /v1beta/files/shot-4k0x2":
+		s.mu.Lock()
+		state := s.states[min(s.gets, len(s.states)-1)]
+		s.gets++
+		s.mu.Unlock()
+		w.Write(fixture(s.t, "get-"+state+".json"))
+	case "POST /v1beta/models/gemini-2.0-flash:generateContent":
+		w.Write(fixture(s.t, "generate.json"))
+	case "DELETE /v1beta/files/shot-4k0x2":
+		w.Write([]byte("{}"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// requests запросы в порядке получения
+func (s *filesServer) requests() []recorded {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recorded(nil), s.reqs...)
+}
+
+func (s *filesServer) find(method, path string) []recorded {
+	var out []recorded
+	for _, r := range s.requests() {
+		if r.Method == method && r.Path == path {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// writeShot снимок размером 24 байта
+func writeShot(t *testing.T) (string, []byte) {
+	t.Helper()
+	data := []byte("\x89PNG\r\n\x1a\n0123456789abcdef")
+	path := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func (s *filesServer) gemini() *Gemini {
+	return &Gemini{APIKey: "test-key", BaseURL: s.URL, KeyInHeader: true, FilePollInterval: 1}
+}
+
+// generateParts части текущего сообщения запроса generateContent
+func generateParts(t *testing.T, s *filesServer) []Part {
+	t.Helper()
+	reqs := s.find(http.MethodPost, "/v1beta/models/gemini-2.0-flash:generateContent")
+	if len(reqs) != 1 {
+		t.Fatalf("запросов generateContent: %d", len(reqs))
+	}
+	var body GeminiRequest
+	if err := json.Unmarshal(reqs[0].Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Contents) != 1 {
+		t.Fatalf("contents = %+v", body.Contents)
+	}
+	return body.Contents[0].Parts
+}
+
+func TestAnswerInlineImage(t *testing.T) {
+	s := newFilesServer(t, "active")
+	path, data := writeShot(t)
+	g := s.gemini()
+
+	got, err := g.Answer(context.Background(), Request{Prompt: "Реши задачу", Images: []Image{{Path: path, MIMEType: "image/png"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "На снимке задача о двух суммах." {
+		t.Errorf("ответ %q", got)
+	}
+	parts := generateParts(t, s)
+	if len(parts) != 2 || parts[0].Text != "Реши задачу" || parts[1].InlineData == nil {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if b := parts[1].InlineData; b.MIMEType != "image/png" || b.Data != base64.StdEncoding.EncodeToString(data) {
+		t.Errorf("inline_data = %+v", b)
+	}
+	if n := len(s.requests()); n != 1 {
+		t.Errorf("запросов %d, want только generateContent", n)
+	}
+}
+
+func TestAnswerUploadsLargeImage(t *testing.T) {
+	s := newFilesServer(t, "processing", "active")
+	path, data := writeShot(t)
+	g := s.gemini()
+	g.InlineLimit = 16
+
+	if _, err := g.Answer(context.Background(), Request{Prompt: "Реши задачу", Images: []Image{{Path: path, MIMEType: "image/png"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	for _, r := range s.requests() {
+		order = append(order, r.Method+" "+r.Path)
+		// Адрес сессии загрузки уже авторизован и ключа не требует
+		if r.Path == "/upload/session/1" {
+			continue
+		}
+		if got := r.Header.Get("x-goog-api-key"); got != "test-key" {
+			t.Errorf("%s %s: x-goog-api-key = %q", r.Method, r.Path, got)
+		}
+	}
+	want := []string{
+		"POST /upload/v1beta/files",
+		"POST /upload/session/1",
+		"GET /v1beta/files/shot-4k0x2",
+		"GET /v1beta/files/shot-4k0x2",
+		"POST /v1beta/models/gemini-2.0-flash:generateContent",
+		"DELETE /v1beta/files/shot-4k0x2",
+	}
+	if len(order) != len(want) {
+		t.Fatalf("запросы %q, want %q", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("запросы %q, want %q", order, want)
+		}
+	}
+
+	start := s.requests()[0]
+	for h, v := range map[string]string{
+		"X-Goog-Upload-Protocol":              "resumable",
+		"X-Goog-Upload-Command":               "start",
+		"X-Goog-Upload-Header-Content-Length": "24",
+		"X-Goog-Upload-Header-Content-Type":   "image/png",
+		"Content-Type":                        "application/json",
+	} {
+		if got := start.Header.Get(h); got != v {
+			t.Errorf("start: %s = %q, want %q", h, got, v)
+		}
+	}
+	if string(start.Body) != `{"file":{"display_name":"shot.png"}}` {
+		t.Errorf("start: тело %s", start.Body)
+	}
+	upload := s.requests()[1]
+	if upload.Header.Get("X-Goog-Upload-Command") != "upload, finalize" || upload.Header.Get("X-Goog-Upload-Offset") != "0" {
+		t.Errorf("upload: заголовки %v", upload.Header)
+	}
+	if !bytes.Equal(upload.Body, data) {
+		t.Errorf("upload: тело %q", upload.Body)
+	}
+
+	parts := generateParts(t, s)
+	if len(parts) != 2 || parts[1].FileData == nil || parts[1].InlineData != nil {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if f := parts[1].FileData; f.FileURI != "https://generativelanguage.googleapis.com/v1beta/files/shot-4k0x2" || f.MIMEType != "image/png" {
+		t.Errorf("file_data = %+v", f)
+	}
+}
+
+func TestAnswerKeepFiles(t *testing.T) {
+	s := newFilesServer(t, "active")
+	path, _ := writeShot(t)
+	g := s.gemini()
+	g.InlineLimit = 1
+	g.KeepFiles = true
+
+	if _, err := g.Answer(context.Background(), Request{Prompt: "Реши", Images: []Image{{Path: path, MIMEType: "image/png"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.find(http.MethodDelete, "/v1beta/files/shot-4k0x2")); n != 0 {
+		t.Errorf("DELETE отправлен %d раз при keepFiles", n)
+	}
+}
+
+// Ошибки загрузки, обработки и удаления различимы через errors.Is
+func TestAnswerFileErrors(t *testing.T) {
+	path, _ := writeShot(t)
+	req := Request{Prompt: "Реши", Images: []Image{{Path: path, MIMEType: "image/png"}}}
+	generate := "/v1beta/models/gemini-2.0-flash:generateContent"
+
+	t.Run("start", func(t *testing.T) {
+		s := newFilesServer(t, "active")
+		s.status["POST /upload/v1beta/files"] = http.StatusInternalServerError
+		g := s.gemini()
+		g.InlineLimit = 1
+		_, err := g.Answer(context.Background(), req)
+		var status *errs.StatusError
+		if !errors.Is(err, errs.ErrFileUpload) || !errors.As(err, &status) || status.Code != http.StatusInternalServerError {
+			t.Errorf("err = %v", err)
+		}
+		if len(s.find(http.MethodPost, generate)) != 0 || len(s.find(http.MethodDelete, "/v1beta/files/shot-4k0x2")) != 0 {
+			t.Errorf("запросы после неудачной загрузки: %+v", s.requests())
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		s := newFilesServer(t, "active")
+		s.status["POST /upload/session/1"] = http.StatusServiceUnavailable
+		g := s.gemini()
+		g.InlineLimit = 1
+		if _, err := g.Answer(context.Background(), req); !errors.Is(err, errs.ErrFileUpload) {
+			t.Errorf("err = %v", err)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		s := newFilesServer(t, "processing", "failed")
+		g := s.gemini()
+		g.InlineLimit = 1
+		var cleanup error
+		g.OnFileCleanup = func(_ context.Context, err error) { cleanup = err }
+		_, err := g.Answer(context.Background(), req)
+		if !errors.Is(err, errs.ErrFileProcessing) || errors.Is(err, errs.ErrFileUpload) {
+			t.Errorf("err = %v", err)
+		}
+		if len(s.find(http.MethodPost, generate)) != 0 {
+			t.Error("generateContent отправлен для файла в состоянии FAILED")
+		}
+		// Загруженный файл удаляется и после неудачной обработки
+		if len(s.find(http.MethodDelete, "/v1beta/files/shot-4k0x2")) != 1 || cleanup != nil {
+			t.Errorf("удаление: %+v, %v", s.requests(), cleanup)
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		s := newFilesServer(t, "active")
+		s.status["DELETE /v1beta/files/shot-4k0x2"] = http.StatusInternalServerError
+		g := s.gemini()
+		g.InlineLimit = 1
+		var cleanup error
+		g.OnFileCleanup = func(_ context.Context, err error) { cleanup = err }
+		got, err := g.Answer(context.Background(), req)
+		if err != nil || got == "" {
+			t.Fatalf("ответ %q, err = %v", got, err)
+		}
+		if !errors.Is(cleanup, errs.ErrFileCleanup) {
+			t.Errorf("OnFileCleanup: %v", cleanup)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+// Package llm получает ответы языковой модели.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Answerer отвечает на запрос к модели
+type Answerer interface {
+	Answer(ctx context.Context, req Request) (string, error)
+}
+
+// Request запрос к модели: промпт и необязательные параметры генерации
+type Request struct {
+	Prompt string
+	// MaxOutputTokens ограничивает длину ответа; 0 — по умолчанию модели
+	MaxOutputTokens int
+	// Temperature nil — по умолчанию модели
+	Temperature *float64
+	// History предыдущие вопросы и ответы беседы, от старых к новым
+	History []Turn
+	// Images снимки к промпту (мультимодальный режим)
+	Images []Image
+}
+
+// Turn вопрос и ответ модели из истории беседы
+type Turn struct {
+	Prompt string
+	Answer string
+}
+
+// DefaultGeminiURL адрес Gemini API без пути модели
+const DefaultGeminiURL = "https://generativelanguage.googleapis.com"
+
+// DefaultGeminiModel модель Gemini по умолчанию
+const DefaultGeminiModel = "gemini-2.0-flash"
+
+// Gemini клиент Gemini API
+type Gemini struct {
+	APIKey string
+	// Model модель; пустая — DefaultGeminiModel
+	Model string
+	// BaseURL адрес API; пустой — DefaultGeminiURL
+	BaseURL string
+	// Headers дополнительные заголовки каждого запроса
+	Headers map[string]string
+	// KeyInHeader передаёт ключ в заголовке x-goog-api-key вместо
+	// параметра key в адресе
+	KeyInHeader bool
+	// Client общий клиент провайдера; nil — новый клиент на каждый запрос
+	Client *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+	// OnRequest вызывается после каждого отправленного запроса
+	OnRequest func()
+	// OnTruncated вызывается, если ответ обрезан по MaxOutputTokens
+	// (finishReason MAX_TOKENS); ctx — контекст запроса
+	OnTruncated func(ctx context.Context)
+	// OnUsage получает расход токенов каждого ответа; ctx — контекст
+	// запроса
+	OnUsage func(ctx context.Context, promptTokens, candidatesTokens int)
+	// InlineLimit снимки больше (байт) загружаются через Files API;
+	// 0 — DefaultInlineLimit
+	InlineLimit int64
+	// KeepFiles не удаляет файлы, загруженные через Files API, после ответа
+	KeepFiles bool
+	// FilePollInterval интервал опроса состояния загруженного файла;
+	// 0 — секунда
+	FilePollInterval time.Duration
+	// OnFileCleanup получает ошибку удаления загруженных файлов: ответ к
+	// этому моменту уже получен, поэтому она не делает запрос неудачным
+	OnFileCleanup func(ctx context.Context, err error)
+}
+
+type GeminiRequest struct {
+	Contents         []Content         `json:"contents"`
+	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+}
+
+type Content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []Part `json:"parts"`
+}
+
+type Part struct {
+	Text       string    `json:"text,omitempty"`
+	InlineData *Blob     `json:"inline_data,omitempty"`
+	FileData   *FileData `json:"file_data,omitempty"`
+}
+
+type GeminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (g *Gemini) Answer(ctx context.Context, r Request) (string, error) {
+	client := g.Client
+	if client == nil {
+		client = resty.New()
+	}
+	var requestBody GeminiRequest
+	for _, t := range r.History {
+		requestBody.Contents = append(requestBody.Contents,
+			Content{Role: "user", Parts: []Part{{Text: t.Prompt}}},
+			Content{Role: "model", Parts: []Part{{Text: t.Answer}}})
+	}
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	images, uploaded, err := g.imageParts(ctx, client, r.Images)
+	if len(uploaded) > 0 && !g.KeepFiles {
+		defer func() {
+			if err := g.deleteFiles(ctx, client, uploaded); err != nil && g.OnFileCleanup != nil {
+				g.OnFileCleanup(ctx, err)
+			}
+		}()
+	}
+	if err != nil {
+		return "", err
+	}
+	current := Content{Parts: append([]Part{{Text: r.Prompt}}, images...)}
+	if len(r.History) > 0 {
+		current.Role = "user"
+	}
+	requestBody.Contents = append(requestBody.Contents, current)
+	if r.MaxOutputTokens > 0 || r.Temperature != nil {
+		requestBody.GenerationConfig = &GenerationConfig{MaxOutputTokens: r.MaxOutputTokens, Temperature: r.Temperature}
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	model := g.Model
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+	req, endpoint := g.withKey(client.R().
+		SetContext(ctx).
+		SetHeaders(g.Headers).
+		SetHeader("Content-Type", "application/json").
+		SetBody(jsonData), g.baseURL()+"/v1beta/models/"+model+":generateContent")
+
+	start := time.Now()
+	resp, err := req.Post(endpoint)
+	metrics.ObserveRequest(metrics.ProviderGemini, time.Since(start), err)
+	if g.OnRequest != nil {
+		g.OnRequest()
+	}
+
+	if err != nil {
+		return "", errs.FromTransport(err)
+	}
+	if resp.IsError() {
+		// Неверный ключ Gemini возвращает 400 с причиной API_KEY_INVALID
+		if resp.StatusCode() == http.StatusBadRequest && strings.Contains(resp.String(), "API_KEY_INVALID") {
+			return "", fmt.Errorf("%s: %w", metrics.ProviderGemini, errs.ErrAuth)
+		}
+		return "", errs.FromStatus(metrics.ProviderGemini, resp.StatusCode(), resp.String())
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(resp.Body(), &geminiResp); err != nil {
+		return "", fmt.Errorf("некорректный ответ Gemini API: %w", err)
+	}
+	usage := geminiResp.UsageMetadata
+	metrics.Tokens(metrics.ProviderGemini, usage.PromptTokenCount, usage.CandidatesTokenCount)
+	if g.OnUsage != nil {
+		g.OnUsage(ctx, usage.PromptTokenCount, usage.CandidatesTokenCount)
+	}
+
+	if reason := geminiResp.PromptFeedback.BlockReason; reason != "" {
+		return "", &errs.BlockedError{Reason: reason}
+	}
+	if len(geminiResp.Candidates) > 0 && len(geminiResp.Candidates[0].Content.Parts) > 0 {
+		if geminiResp.Candidates[0].FinishReason == "MAX_TOKENS" && g.OnTruncated != nil {
+			g.OnTruncated(ctx)
+		}
+		return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+	}
+	if len(geminiResp.Candidates) > 0 {
+		switch reason := geminiResp.Candidates[0].FinishReason; reason {
+		case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+			return "", &errs.BlockedError{Reason: reason}
+		}
+	}
+
+	return "", fmt.Errorf("no response from Gemini API")
+}
@@ -0,0 +1,201 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/errs"
+)
+
+// generateServer отвечает на generateContent кодом code и содержимым
+// testdata/generate/fixture и записывает полученные запросы
+func generateServer(t *testing.T, code int, fixture string) (*httptest.Server, *[]recorded) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "generate", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reqs []recorded
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, recorded{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+// sentRequest тело запроса generateContent
+func sentRequest(t *testing.T, r recorded) GeminiRequest {
+	t.Helper()
+	var body GeminiRequest
+	if err := json.Unmarshal(r.Body, &body); err != nil {
+		t.Fatalf("тело запроса %s: %v", r.Body, err)
+	}
+	return body
+}
+
+func TestAnswer(t *testing.T) {
+	srv, reqs := generateServer(t, http.StatusOK, "success.json")
+	var usage [2]int
+	requests := 0
+	g := &Gemini{
+		APIKey:    "gemini-key",
+		BaseURL:   srv.URL + "/",
+		Headers:   map[string]string{"X-Gateway": "gw"},
+		OnRequest: func() { requests++ },
+		OnUsage:   func(_ context.Context, prompt, candidates int) { usage = [2]int{prompt, candidates} },
+		OnTruncated: func(context.Context) {
+			t.Error("OnTruncated для полного ответа")
+		},
+	}
+
+	got, err := g.Answer(context.Background(), Request{Prompt: "Чем слайс отличается от массива?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Слайс — это окно в массив: указатель, длина и ёмкость." {
+		t.Errorf("ответ %q", got)
+	}
+	if requests != 1 || len(*reqs) != 1 || usage != [2]int{42, 17} {
+		t.Fatalf("OnRequest %d, запросов %d, OnUsage %v", requests, len(*reqs), usage)
+	}
+	r := (*reqs)[0]
+	if r.Method != http.MethodPost || r.Path != "/v1beta/models/gemini-2.0-flash:generateContent" {
+		t.Errorf("%s %s", r.Method, r.Path)
+	}
+	if r.Query.Get("key") != "gemini-key" || r.Header.Get("x-goog-api-key") != "" {
+		t.Errorf("ключ: query %v, заголовок %q", r.Query, r.Header.Get("x-goog-api-key"))
+	}
+	if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Gateway") != "gw" {
+		t.Errorf("заголовки %v", r.Header)
+	}
+	// Без истории роль не передаётся, а без параметров генерации нет
+	// generationConfig
+	if string(r.Body) != `{"contents":[{"parts":[{"text":"Чем слайс отличается от массива?"}]}]}` {
+		t.Errorf("тело %s", r.Body)
+	}
+}
+
+func TestAnswerKeyInHeaderAndModel(t *testing.T) {
+	srv, reqs := generateServer(t, http.StatusOK, "success.json")
+	g := &Gemini{APIKey: "gemini-key", BaseURL: srv.URL, KeyInHeader: true, Model: "gemini-2.5-pro"}
+	if _, err := g.Answer(context.Background(), Request{Prompt: "вопрос"}); err != nil {
+		t.Fatal(err)
+	}
+	r := (*reqs)[0]
+	if r.Path != "/v1beta/models/gemini-2.5-pro:generateContent" {
+		t.Errorf("путь %s", r.Path)
+	}
+	if r.Header.Get("x-goog-api-key") != "gemini-key" || r.Query.Has("key") {
+		t.Errorf("ключ: заголовок %q, query %v", r.Header.Get("x-goog-api-key"), r.Query)
+	}
+}
+
+// История передаётся парами user/model перед текущим вопросом, который
+// тогда тоже получает роль user
+func TestAnswerHistoryAndGenerationConfig(t *testing.T) {
+	srv, reqs := generateServer(t, http.StatusOK, "success.json")
+	g := &Gemini{APIKey: "k", BaseURL: srv.URL}
+	temperature := 0.2
+	_, err := g.Answer(context.Background(), Request{
+		Prompt:          "А map?",
+		MaxOutputTokens: 256,
+		Temperature:     &temperature,
+		History:         []Turn{{Prompt: "Что такое слайс?", Answer: "Окно в массив."}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := sentRequest(t, (*reqs)[0])
+	want := []Content{
+		{Role: "user", Parts: []Part{{Text: "Что такое слайс?"}}},
+		{Role: "model", Parts: []Part{{Text: "Окно в массив."}}},
+		{Role: "user", Parts: []Part{{Text: "А map?"}}},
+	}
+	if len(body.Contents) != len(want) {
+		t.Fatalf("contents %+v", body.Contents)
+	}
+	for i, c := range want {
+		got := body.Contents[i]
+		if got.Role != c.Role || len(got.Parts) != 1 || got.Parts[0].Text != c.Parts[0].Text {
+			t.Errorf("contents[%d] = %+v, want %+v", i, got, c)
+		}
+	}
+	gc := body.GenerationConfig
+	if gc == nil || gc.MaxOutputTokens != 256 || gc.Temperature == nil || *gc.Temperature != 0.2 {
+		t.Errorf("generationConfig %+v", gc)
+	}
+}
+
+func TestAnswerTruncated(t *testing.T) {
+	srv, _ := generateServer(t, http.StatusOK, "max-tokens.json")
+	truncated := 0
+	g := &Gemini{APIKey: "k", BaseURL: srv.URL, OnTruncated: func(context.Context) { truncated++ }}
+	got, err := g.Answer(context.Background(), Request{Prompt: "вопрос", MaxOutputTokens: 8})
+	if err != nil || got != "Слайс — это окно" || truncated != 1 {
+		t.Errorf("ответ %q, err %v, OnTruncated %d", got, err, truncated)
+	}
+}
+
+func TestAnswerErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		code    int
+		fixture string
+		check   func(error) bool
+		text    string
+	}{
+		{"блокировка промпта", http.StatusOK, "blocked-prompt.json", func(err error) bool {
+			var b *errs.BlockedError
+			return errors.As(err, &b) && b.Reason == "PROHIBITED_CONTENT"
+		}, ""},
+		{"SAFETY", http.StatusOK, "safety.json", func(err error) bool {
+			var b *errs.BlockedError
+			return errors.As(err, &b) && b.Reason == "SAFETY"
+		}, ""},
+		{"пустой ответ", http.StatusOK, "empty.json", nil, "no response from Gemini API"},
+		{"некорректный JSON", http.StatusOK, "malformed.json", nil, "некорректный ответ Gemini API"},
+		{"неверный ключ", http.StatusBadRequest, "api-key-invalid.json", func(err error) bool { return errors.Is(err, errs.ErrAuth) }, "gemini"},
+		{"400", http.StatusBadRequest, "bad-request.json", func(err error) bool {
+			var s *errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusBadRequest && !errors.Is(err, errs.ErrAuth)
+		}, "contents is not specified"},
+		{"квота", http.StatusTooManyRequests, "quota.json", func(err error) bool {
+			var q *errs.QuotaExceededError
+			return errors.As(err, &q) && q.Provider == "gemini"
+		}, ""},
+		{"500", http.StatusInternalServerError, "bad-request.json", func(err error) bool {
+			var s *errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusInternalServerError
+		}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, reqs := generateServer(t, tc.code, tc.fixture)
+			g := &Gemini{APIKey: "k", BaseURL: srv.URL}
+			got, err := g.Answer(context.Background(), Request{Prompt: "вопрос"})
+			if err == nil {
+				t.Fatalf("нет ошибки, ответ %q", got)
+			}
+			if len(*reqs) != 1 {
+				t.Errorf("запросов %d", len(*reqs))
+			}
+			if tc.check != nil && !tc.check(err) {
+				t.Errorf("err = %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.text) {
+				t.Errorf("err = %v, want %q", err, tc.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package metrics содержит метрики Prometheus. Метки ограничены этапом,
+// провайдером и исходом — имена файлов в метки не попадают.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Этапы обработки файла
+const (
+	StageOCR    = "ocr"
+	StageLLM    = "llm"
+	StageOutput = "output"
+)
+
+// Провайдеры API
+const (
+	ProviderOCRSpace = "ocrspace"
+	ProviderGemini   = "gemini"
+	ProviderWhisper  = "whisper"
+	ProviderNotion   = "notion"
+	ProviderGDocs    = "gdocs"
+	ProviderDiscord  = "discord"
+)
+
+// Registry собственный реестр, чтобы в /metrics были только метрики
+// программы и стандартные метрики процесса
+var Registry = prometheus.NewRegistry()
+
+var (
+	filesProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hack_interview_files_processed_total",
+		Help: "Обработанные файлы по исходу (done, retry, failed, skipped).",
+	}, []string{"outcome"})
+
+	stageFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hack_interview_stage_failures_total",
+		Help: "Ошибки обработки по этапам.",
+	}, []string{"stage"})
+
+	providerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hack_interview_provider_requests_total",
+		Help: "Запросы к API провайдеров по исходу; отражает расход квоты.",
+	}, []string{"provider", "outcome"})
+
+	providerLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "hack_interview_provider_request_duration_seconds",
+		Help:    "Длительность запросов к API провайдеров.",
+		Buckets: []float64{0.25, 0.5, 1, 2, 4, 8, 16, 32, 64},
+	}, []string{"provider"})
+
+	retries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "hack_interview_retries_total",
+		Help: "Назначенные повторные попытки обработки файлов.",
+	})
+
+	queueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hack_interview_queue_depth",
+		Help: "Число файлов в очереди.",
+	})
+
+	queueDeferred = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "hack_interview_queue_deferred",
+		Help: "Файлы, не поместившиеся в очередь при последнем сканировании.",
+	})
+
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hack_interview_breaker_state",
+		Help: "Состояние цепи провайдера: 0 — замкнута, 1 — разомкнута, 2 — пробный запрос.",
+	}, []string{"provider"})
+
+	breakerTransitions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hack_interview_breaker_transitions_total",
+		Help: "Переходы цепи провайдера по новому состоянию (closed, open, half-open).",
+	}, []string{"provider", "state"})
+
+	tokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hack_interview_tokens_total",
+		Help: "Использованные токены LLM по типу (prompt, candidates).",
+	}, []string{"provider", "kind"})
+)
+
+func init() {
+	Registry.MustRegister(
+		filesProcessed, stageFailures, providerRequests, providerLatency,
+		retries, queueDepth, queueDeferred, tokens, breakerState, breakerTransitions,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler обработчик /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// FileOutcome учитывает итог обработки файла
+func FileOutcome(outcome string) {
+	filesProcessed.WithLabelValues(outcome).Inc()
+}
+
+// StageFailed учитывает ошибку на этапе
+func StageFailed(stage string) {
+	stageFailures.WithLabelValues(stage).Inc()
+}
+
+// ObserveRequest учитывает запрос к провайдеру и его длительность
+func ObserveRequest(provider string, d time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	providerRequests.WithLabelValues(provider, outcome).Inc()
+	providerLatency.WithLabelValues(provider).Observe(d.Seconds())
+}
+
+// Retry учитывает назначенную повторную попытку
+func Retry() {
+	retries.Inc()
+}
+
+// SetQueueDepth обновляет глубину очереди
+func SetQueueDepth(n int) {
+	queueDepth.Set(float64(n))
+}
+
+// SetQueueDeferred обновляет число файлов, отложенных из-за заполненной
+// очереди
+func SetQueueDeferred(n int) {
+	queueDeferred.Set(float64(n))
+}
+
+// Tokens учитывает расход токенов
+func Tokens(provider string, prompt, candidates int) {
+	tokens.WithLabelValues(provider, "prompt").Add(float64(prompt))
+	tokens.WithLabelValues(provider, "candidates").Add(float64(candidates))
+}
+
+// BreakerState учитывает переход цепи провайдера в состояние name с
+// числовым значением value
+func BreakerState(provider, name string, value int) {
+	breakerState.WithLabelValues(provider).Set(float64(value))
+	breakerTransitions.WithLabelValues(provider, name).Inc()
+}
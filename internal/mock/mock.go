@@ -0,0 +1,109 @@
+// Package mock провайдеры OCR и модели с заготовленными ответами: для
+// демонстраций и разработки без ключей и сети. Ответы берутся из
+// директории с заготовками по имени входного файла, задержка имитирует
+// настоящий API.
+package mock
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hack_interview/internal/llm"
+)
+
+// DefaultName имя заготовки, которая отдаётся, если для файла своей нет
+const DefaultName = "default"
+
+// Fixtures директория заготовок: <имя>.ocr.txt — распознанный текст,
+// <имя>.md — ответ модели, где имя — имя входного файла без расширения
+type Fixtures struct {
+	Dir string
+}
+
+// Lookup содержимое <name><ext>, иначе default<ext>; false, если нет обеих
+func (f Fixtures) Lookup(name, ext string) (string, bool) {
+	for _, n := range []string{name, DefaultName} {
+		if n == "" || f.Dir == "" {
+			continue
+		}
+		if data, err := os.ReadFile(filepath.Join(f.Dir, n+ext)); err == nil {
+			return string(data), true
+		}
+	}
+	return "", false
+}
+
+// delay ждёт около d (±25%), как отвечал бы настоящий API; отмена ctx
+// прерывает ожидание
+func delay(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	d += time.Duration((rand.Float64() - 0.5) * 0.5 * float64(d))
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// OCR «распознаёт» текст по имени изображения
+type OCR struct {
+	Fixtures Fixtures
+	// Text ответ, если заготовок нет
+	Text    string
+	Latency time.Duration
+}
+
+func (o *OCR) ExtractText(ctx context.Context, imagePath string) (string, error) {
+	if err := delay(ctx, o.Latency); err != nil {
+		return "", err
+	}
+	base := filepath.Base(imagePath)
+	if text, ok := o.Fixtures.Lookup(strings.TrimSuffix(base, filepath.Ext(base)), ".ocr.txt"); ok {
+		return text, nil
+	}
+	return o.Text, nil
+}
+
+// LLM отвечает заготовкой для файла, к которому относится запрос
+type LLM struct {
+	Fixtures Fixtures
+	// Text ответ, если заготовок нет
+	Text    string
+	Latency time.Duration
+	// Name имя входного файла запроса; nil или "" — заготовка по умолчанию
+	Name func(ctx context.Context) string
+	// OnUsage получает примерный расход токенов (четыре символа на токен),
+	// чтобы статистика выглядела как с настоящей моделью
+	OnUsage func(ctx context.Context, promptTokens, candidatesTokens int)
+}
+
+func (m *LLM) Answer(ctx context.Context, req llm.Request) (string, error) {
+	if err := delay(ctx, m.Latency); err != nil {
+		return "", err
+	}
+	name := ""
+	if m.Name != nil {
+		name = m.Name(ctx)
+	}
+	answer, ok := m.Fixtures.Lookup(name, ".md")
+	if !ok {
+		answer = m.Text
+	}
+	if m.OnUsage != nil {
+		prompt := len(req.Prompt)
+		for _, t := range req.History {
+			prompt += len(t.Prompt) + len(t.Answer)
+		}
+		m.OnUsage(ctx, prompt/4+1, len(answer)/4+1)
+	}
+	return answer, nil
+}
@@ -0,0 +1,376 @@
+package msg
+
+// Английский каталог; из него берутся сообщения, которых нет в выбранном языке
+func init() {
+	register("en", map[string]string{
+		"archive.done":                     "Archive: %s (files %d, missing %d)\n",
+		"archive.failed":                   "Failed to write archive",
+		"archive.no-sessions":              "No sessions to archive",
+		"archive.screenshots-raw":          "Screenshots are archived as is: --anonymize redacts text files only",
+		"archive.unknown-session":          "Session %q not found\n",
+		"breaker.closed":                   "Provider recovered, circuit closed",
+		"breaker.half-open":                "Probe request to provider",
+		"breaker.llm-fallback":             "Request to fallback model",
+		"breaker.ocr-fallback":             "Request to fallback OCR provider",
+		"breaker.open":                     "Provider unavailable, circuit opened",
+		"capture.disabled":                 "Screen capture disabled",
+		"capture.disabled-no-window":       "Screen capture disabled: no window selected",
+		"capture.failed":                   "Screen capture failed",
+		"capture.hotkey":                   "Screen capture by hotkey",
+		"capture.hotkey-unregister-failed": "Failed to unregister hotkey",
+		"capture.hourly-limit":             "Periodic capture: hourly frame limit reached",
+		"capture.interval":                 "Periodic screen capture",
+		"capture.interval-disabled":        "Periodic screen capture disabled",
+		"capture.interval-no-window":       "Periodic capture paused: window not found",
+		"capture.pick-prompt":              "Window number to capture: ",
+		"capture.save-failed":              "Failed to save screenshot",
+		"capture.saved":                    "Screenshot saved",
+		"capture.skipped-no-window":        "Screenshot skipped: capture window not found",
+		"capture.window-found":             "Capture window found, capture resumed",
+		"cassette.enabled":                 "API responses go through the cassette",
+		"cleanup.disabled":                 "No retention configured: set retention.outputs or retention.debug in config.yml",
+		"cleanup.done":                     "Files removed: %d, state records: %d\n",
+		"cleanup.would":                    "Would remove files: %d, state records: %d\n",
+		"clipboard.disabled":               "Clipboard watching disabled",
+		"clipboard.image":                  "Image from clipboard",
+		"clipboard.save-failed":            "Failed to save clipboard image",
+		"clipboard.watching":               "Watching clipboard",
+		"code.save-failed":                 "Failed to save code",
+		"code.saved":                       "Code saved",
+		"command.unknown":                  "Unknown command: %s\n",
+		"compare.disagreements-failed":     "Failed to compare model answers",
+		"compare.mode":                     "Model compare mode",
+		"compare.model-answered":           "Compare model answered",
+		"compare.model-failed":             "Compare model did not answer",
+		"complexity.failed":                "Failed to get complexity estimate",
+		"config.chdir-failed":              "Failed to change to the config directory",
+		"config.failed":                    "Configuration error",
+		"config.path-failed":               "Invalid config path",
+		"config.reload-failed":             "Changed config.yml was not applied",
+		"config.usage":                     "Usage: hack_interview config encrypt-key | set-secret <service>/<account>",
+		"debughttp.toggled":                "HTTP dumps toggled",
+		"debughttp.write-failed":           "Failed to write HTTP dump",
+		"dir.read-failed":                  "Failed to read directory",
+		"doctor.failed":                    "\nChecks failed: %d\n",
+		"doctor.offline":                   "\nProviders not checked (--offline)",
+		"doctor.ok":                        "\nAll set",
+		"doctor.probes":                    "\nProvider probes: one minimal request each, uses a little quota",
+		"envfile.failed":                   "Env file error",
+		"envfile.path-failed":              "Invalid env file path",
+		"error.prefix":                     "Error:",
+		"errorsdir.move-failed":            "Failed to move file to errors directory",
+		"errorsdir.moved":                  "File moved to errors directory",
+		"examples.enabled":                 "runExamples is on: code from model answers will run on this machine",
+		"examples.failed":                  "Failed to get examples from the problem statement",
+		"examples.no-go":                   "Go toolchain not found, running examples skipped",
+		"examples.none":                    "No examples in the problem statement",
+		"examples.parse-failed":            "Failed to parse examples",
+		"examples.running":                 "Running model-generated code",
+		"export.anki-done":                 "Cards: %d, file: %s\n",
+		"export.failed":                    "Export failed",
+		"export.gdocs-done":                "Answers: %d, document: %s\n",
+		"export.gdocs-failed":              "Google Docs export failed, running it again will continue from here",
+		"export.gdocs-no-credentials":      "Set gdocs.credentials in config.yml to export to Google Docs",
+		"export.unknown-format":            "Unknown format %q, supported: anki and gdoc\n",
+		"file.attempts-exhausted":          "File not processed, attempts exhausted",
+		"file.cancelled":                   "Processing interrupted",
+		"file.done":                        "Processing finished",
+		"file.dry-run":                     "Dry run: OCR and model request skipped",
+		"file.failed":                      "Processing error",
+		"file.follow-up":                   "Follow-up question",
+		"file.kind":                        "Question kind detected",
+		"file.named-prompt":                "Prompt from file name",
+		"file.ocr-save-failed":             "Failed to save recognized text",
+		"file.panic":                       "Panic while processing file",
+		"file.processing":                  "Processing file",
+		"file.request-save-failed":         "Failed to save request",
+		"file.request-saved":               "Request saved",
+		"file.retry-scheduled":             "Retry scheduled",
+		"file.reused-ocr":                  "Reused previously recognized text",
+		"file.saved":                       "File saved",
+		"file.style":                       "Answer style",
+		"filter.accepted":                  "File accepted by filter",
+		"filter.looks-like-question":       "Text looks like a question",
+		"filter.not-question-sent":         "Text does not look like a question but was sent to the model (questionFilter.mode: warn)",
+		"filter.not-question-skipped":      "Text does not look like a question, model request skipped",
+		"filter.skipped":                   "File skipped by filter",
+		"flags.aliases":                    "  aliases: %s\n",
+		"flags.general":                    "general",
+		"flags.header":                     "\nconfig.yml fields for a single run (a flag beats the environment and the file):",
+		"gdocs.changed":                    "Document changed after an interrupted write, formatting of that entry not finished",
+		"gdocs.created":                    "Google Docs document created",
+		"git.commit-failed":                "Failed to commit results",
+		"git.committed":                    "Results committed",
+		"git.no-author":                    "Git commit author is not set, result commits disabled",
+		"git.not-repo":                     "OutputDir is not in a git repository, result commits disabled",
+		"gocheck.broken":                   "Code does not compile",
+		"gocheck.failed":                   "Failed to check code",
+		"gocheck.fixed":                    "Code fixed after a compile error",
+		"gocheck.no-go":                    "Go toolchain not found, code check skipped",
+		"gocheck.ok":                       "Code compiles",
+		"group.incomplete":                 "Group incomplete, processing parts separately",
+		"group.merged":                     "Question parts merged",
+		"group.waiting":                    "Waiting for the remaining parts of the question",
+		"grpc.accepted":                    "File received over gRPC",
+		"grpc.failed":                      "gRPC service error",
+		"grpc.no-tls":                      "gRPC service runs without TLS",
+		"grpc.start-failed":                "Failed to start gRPC service",
+		"grpc.started":                     "gRPC service started",
+		"http.setup-failed":                "Failed to set up HTTP clients",
+		"http.trace":                       "Provider request",
+		"image.gif-first-frame":            "Animated GIF, only the first frame is recognized",
+		"image.invert-failed":              "Failed to check image for inversion, using the original",
+		"image.inverted":                   "Image inverted before OCR",
+		"image.not-inverted":               "Image not inverted: not dark enough",
+		"image.split":                      "Tall image split into strips for OCR",
+		"image.split-failed":               "Failed to split tall image, recognizing it whole",
+		"image.strip-failed":               "Failed to recognize image strip, text assembled from the other strips",
+		"image.trim-failed":                "Failed to trim image borders, using the original",
+		"imap.attachment-skipped":          "Attachment skipped",
+		"imap.lost":                        "IMAP connection lost",
+		"imap.message-skipped":             "Message without suitable attachments skipped",
+		"imap.parse-failed":                "Failed to parse message",
+		"imap.watching":                    "Watching mailbox",
+		"list.header":                      "FILE\tSTATUS\tATTEMPTS\tUPDATED\tRETRY\tRESULT\tERROR",
+		"llm.file-cleanup-failed":          "Failed to delete an image uploaded via the Files API",
+		"llm.image-unsupported":            "Image format is not supported by the model, sending text only",
+		"llm.truncated":                    "Model response truncated: maxOutputTokens reached",
+		"lock.failed":                      "Failed to create instance lock",
+		"logging.failed":                   "Failed to set up logging",
+		"logging.file-failed":              "Failed to write the log file, logging to stderr only from now on",
+		"notion.http-failed":               "Notion HTTP setup failed, using the default client",
+		"once.start":                       "Processing directory",
+		"output.failed":                    "Output error",
+		"pause.breaker":                    "Processing paused: provider unavailable",
+		"pause.file":                       "Processing paused: pause file found (delete it to continue)",
+		"pause.quota":                      "Processing paused: provider daily limit exhausted",
+		"pause.resumed":                    "Processing resumed",
+		"pause.signal":                     "Processing paused by signal (send it again to resume)",
+		"pidfile.failed":                   "PID file error",
+		"pidfile.stale":                    "Stale PID file found, overwriting",
+		"problems.load-failed":             "Failed to load known problems",
+		"problems.matched":                 "Known problem recognized",
+		"process.usage":                    "Usage: hack_interview process [--dry-run] [--output <dir>] [--record] <path|pattern>...",
+		"publish.discord-disabled":         "Discord publishing disabled",
+		"publish.done":                     "Result published",
+		"publish.email-test-failed":        "Test email not sent",
+		"publish.email-test-sent":          "Test email sent",
+		"publish.failed":                   "Failed to publish result",
+		"publish.gdocs-disabled":           "Google Docs publishing disabled",
+		"publish.queue-full":               "Publish queue is full, result not published",
+		"publish.queued":                   "Result queued for publishing",
+		"publish.timeout":                  "Publishing did not finish in time, rest of the queue dropped",
+		"queue.drained":                    "Queue has room again",
+		"queue.full":                       "Queue is full",
+		"quota.exhausted":                  "Provider daily limit exhausted",
+		"quota.exhausted-paused":           "Provider daily limit exhausted, processing paused until reset",
+		"quota.load-failed":                "Failed to load quota counters",
+		"quota.near-limit":                 "Provider quota nearly exhausted",
+		"quota.remaining":                  "Quota remaining",
+		"quota.save-failed":                "Failed to save quota counters",
+		"recover.restart":                  "Interrupted processing will start over",
+		"recover.resume-llm":               "Interrupted processing will resume from the model request: recognized text was saved",
+		"report.write-failed":              "Failed to write processing report",
+		"reprocess.usage":                  "Usage: hack_interview reprocess [--style <style>] [--force] <path|pattern>...",
+		"reset.done":                       "Records reset: %d\n",
+		"reset.usage":                      "Usage: hack_interview reset <name>... | --failed",
+		"retention.done":                   "Removed expired files",
+		"retention.failed":                 "Cleanup of expired files failed",
+		"retention.remove-failed":          "Failed to remove expired file",
+		"s3.download-failed":               "Failed to download S3 object",
+		"s3.poll-failed":                   "S3 poll failed",
+		"s3.setup-failed":                  "S3 setup failed",
+		"s3.watching":                      "Watching S3",
+		"secrets.key-prompt":               "Key: ",
+		"secrets.new-passphrase-prompt":    "Passphrase: ",
+		"secrets.passphrase-prompt":        "config.yml key passphrase: ",
+		"secrets.repeat-prompt":            "Again: ",
+		"server.accepted":                  "File received over HTTP",
+		"server.save-failed":               "Failed to save uploaded file",
+		"server.start-failed":              "Failed to start HTTP server",
+		"server.started":                   "HTTP server started",
+		"service.failed":                   "Service error",
+		"service.linger":                   "To keep it running without a login session: loginctl enable-linger ",
+		"service.recovery-failed":          "Failed to configure service restart on failure",
+		"service.removed":                  "Removed",
+		"service.started":                  "Service started; log: journalctl --user -u ",
+		"service.step-failed":              "Service step failed",
+		"service.windows-dry-run":          "Service %s, automatic start, restart on failure after 5s:\n  %s %s\n",
+		"service.windows-dry-run-remove":   "Stop and delete service %s\n",
+		"service.windows-installed":        "Service %s installed and started; logs go to logFile from config.yml\n",
+		"service.windows-removed":          "Service %s removed\n",
+		"service.written":                  "Written",
+		"session.started":                  "Session started",
+		"shutdown.forced":                  "Second signal, exiting immediately",
+		"shutdown.signal":                  "Signal received, finishing current work",
+		"shutdown.stages":                  "Time by stage:",
+		"shutdown.timeout":                 "Shutdown timeout expired, cancelling processing",
+		"shutdown.totals":                  "Totals: processed %d, skipped %d, failed %d, uptime %v\n",
+		"shutdown.was-paused":              "Processing was paused; pending files will be picked up on the next run",
+		"split.bad-response":               "Invalid split response, processing text as a whole",
+		"split.done":                       "Text split into separate questions",
+		"split.failed":                     "Failed to split questions, processing text as a whole",
+		"split.too-many":                   "Too many parts, processing text as a whole",
+		"split.too-short":                  "Parts are much shorter than the text, processing it as a whole",
+		"split.unsure":                     "Model is unsure about the split, processing text as a whole",
+		"stages.started":                   "Staged processing: recognition and model answers overlap",
+		"state.close-failed":               "Failed to close state",
+		"state.imported":                   "State imported from JSON",
+		"state.load-failed":                "Failed to load state",
+		"state.read-failed":                "Failed to read state",
+		"state.save-failed":                "Failed to save state",
+		"stats.empty":                      "No files processed in this period",
+		"stats.files":                      "Files: %d (done %d, failed %d, awaiting retry %d, skipped %d)\n",
+		"stats.kinds":                      "Question kinds:",
+		"stats.peak-hours":                 "Peak hours:",
+		"stats.sessions-header":            "SESSION\tSTARTED\tFILES\tDONE\tFAILED\tSKIPPED\tTOKENS\tCOST",
+		"stats.stages-header":              "STAGE\tFILES\tERRORS\tERROR RATE\tMEAN\tP95",
+		"stats.stage-line":                 "%-9s p50 %v, p95 %v (samples: %d)",
+		"stats.tokens":                     "\nTokens: prompt %d, response %d, estimated cost $%.4f\n",
+		"status.circuit":                   "Circuit %s: %s\n",
+		"status.circuit-retry":             "Circuit %s: %s, probe at %s\n",
+		"status.last-error":                "Last error: %s\n",
+		"status.paused":                    "paused",
+		"status.processed":                 "Processed:    %d, failed: %d, skipped: %d\n",
+		"status.queue":                     "Queue:        %d/%d\n",
+		"status.queue-deferred":            "Deferred:     %d\n",
+		"status.running":                   "running",
+		"status.socket-failed":             "Status socket error",
+		"status.unavailable":               "status command unavailable",
+		"status.uptime":                    "Uptime: %s\n",
+		"statusline.line":                  "[%s] queue %d/%d | done %d, failed %d | OCR %d, tokens %d",
+		"statusline.status":                "Status",
+		"style.unknown":                    "Unknown style",
+		"summary.failed":                   "Failed to get short answer",
+		"tests.failed":                     "Failed to get tests",
+		"tests.no-block":                   "Model response has no test block",
+		"tests.no-code":                    "No code in the answer to test",
+		"tests.save-failed":                "Failed to save tests",
+		"tests.saved":                      "Tests saved",
+		"transcript.create-failed":         "Failed to create transcript",
+		"transcript.write-failed":          "Failed to write transcript",
+		"translate.failed":                 "Translation error",
+		"translate.totals":                 "Translated %d, skipped %d, failed %d, tokens %d\n",
+		"translate.usage":                  "Usage: hack_interview translate [--to en] [--force] <file|pattern>... | --all",
+		"tui.copied":                       "answer copied",
+		"tui.counters":                     "%s %d/%d   done %d, failed %d   OCR %d, tokens %d",
+		"tui.failed":                       "Interface error",
+		"tui.help":                         "↑/↓ select  o open  c copy  r reprocess  pgup/pgdn scroll  q quit",
+		"tui.no-answer":                    "no answer",
+		"tui.no-files":                     "no files yet",
+		"tui.no-output":                    "no answer file",
+		"tui.open-failed":                  "failed to open: ",
+		"tui.opened":                       "opened ",
+		"tui.preview-truncated":            "… answer truncated, press o to open the file",
+		"tui.queue":                        "Queue:",
+		"tui.queued":                       "queued: ",
+		"tui.reprocess-local-only":         "reprocessing is only available for local files",
+		"tui.reprocess-next-scan":          "the file will be picked up on the next scan",
+		"tui.workers":                      "Workers: ",
+		"ui.available":                     "Web UI available",
+		"ui.reprocess":                     "Reprocessing requested from web UI",
+		"ui.template-failed":               "Web UI template error",
+		"update.available":                 "Version %s available (installed %s)\n",
+		"update.check-failed":              "Update check failed:",
+		"update.done":                      "Updated to %s\n",
+		"update.failed":                    "Update failed:",
+		"update.latest":                    "Latest version installed (%s)\n",
+		"verify.failed":                    "Failed to verify answer",
+		"verify.issues":                    "Answer check found issues",
+		"verify.ok":                        "Answer check: no issues",
+		"verify.regenerate-failed":         "Failed to fix the answer after the check",
+		"verify.regenerated":               "Answer fixed after the check",
+		"watch.input-available":            "Input directory available again",
+		"watch.input-unavailable":          "Input directory unavailable for too long",
+		"watch.old-skipped":                "Old files skipped",
+		"watch.read-retry":                 "Failed to read directory, retrying",
+		"watch.start":                      "Watching directory",
+
+		"usage": `Usage: hack_interview [flags] [command]
+
+Without a command, watches the input directory.
+
+Flags:
+  --once                      process pending files and exit (same as run)
+  --tui                       full-screen interface: answer list, preview,
+                              workers and queue (logs go to logFile only)
+  --pidfile <file>            write the PID while running
+  --debug-http                write API requests and responses to OutputDir/.debug
+                              (keys are masked; debugHTTP in config.yml)
+  --cassette record|replay    record API responses to cassette.dir or answer
+                              from recordings without network (cassette.mode in config.yml)
+  --no-tests                  do not generate tests for code (tests.enabled)
+  --no-verify                 do not check the answer with a second request (verify.enabled)
+  --email-test                send a test email on startup and exit with an
+                              error if it fails (email in config.yml)
+  --log-level <level>         debug, info, warn or error (logLevel in config.yml)
+  --log-format text|json      log format on stderr (logFormat in config.yml)
+  --quiet                     errors only; the status line stays, turn it off
+                              with --status-line=false
+  -v, -vv                     debug; -vv also summarizes every provider request
+                              (beats logLevel, cannot be used with --log-level)
+  --no-color                  file results in the terminal as symbols without
+                              color (also NO_COLOR); piped logs are unchanged
+  --config <file>             config instead of config.yml; the program changes
+                              to its directory, and relative paths in it, as
+                              well as .env, are resolved from there
+  --env-file <file>           environment variables from a file, in addition to
+                              .env next to config.yml; the environment beats
+                              --env-file, it beats .env, and .env beats config.yml
+                              (OCR_API_KEY, GEMINI_API_KEY, DISCORD_WEBHOOK_URL)
+
+Commands:
+  run                         process pending files and exit
+  process [flags] <path|pattern>... | -
+                              process the given files without watching;
+                              "-" reads an image from stdin and prints the answer
+      --dry-run               only show what would be done
+      --output <dir>          directory for results
+      --record                record the result in the state
+  reprocess [--style <style>] [--force] <path|pattern>...
+                              process files again; --force also sends text
+                              that is too short (minText) to the model
+  status [--json]             state of the running instance
+  new-session                 close the running instance's current session
+                              and start a new transcript
+  list [--status <statuses>] [--today] [--failed] [--json]
+                              show files from the state and those waiting in the
+                              input directory (pending); --status is comma-separated:
+                              pending, processing, done, retry, failed, skipped
+  stats [--json] [--since <time>] [--until <time>]
+                              state summary: errors and stage durations,
+                              tokens and cost (gemini.price), peak hours, question
+                              kinds and sessions; time is a date, RFC3339 or 7d
+  reset <name>... | --failed  reset records so the files are processed again
+  cleanup [--dry-run]         remove outputs and dumps older than retention in
+                              config.yml; --dry-run only lists them
+  translate [--to en] [--force] <file|pattern>... | --all
+                              translate answers to <name>.<lang>.md, code is kept
+  export [--format anki|gdoc] [--output <file>]
+                              export questions and answers as Anki cards (TSV)
+                              or to a Google Docs document (gdocs in config.yml)
+  archive [--session <id>] [--screenshots] [--anonymize]
+                              pack a session (the latest by default) into
+                              OutputDir/archives/session-<id>.zip with manifest.json;
+                              --anonymize hides emails, phones and keys in text
+  doctor [--offline]          check configuration, directories, state,
+                              external programs and provider keys (one
+                              minimal request each — a little quota)
+  config encrypt-key          encrypt a key for config.yml: the key is entered
+                              without echo or read from stdin, the passphrase comes
+                              from HACK_INTERVIEW_PASSPHRASE or is entered twice;
+                              paste the printed enc:v1:... in place of the key
+  config set-secret <service>/<account>
+                              store a key in the OS credential store (Keychain,
+                              Credential Manager, secret-service) and print a
+                              keychain:... reference for config.yml
+  install-service [--dry-run] run the program as a service: a systemd user unit,
+                              a launchd agent or a Windows service (needs
+                              administrator rights); config is config.yml
+                              or --config, on Windows logs go to logFile only
+  uninstall-service [--dry-run]
+                              stop and remove the service
+  update [--check]            update to the latest GitHub release
+  version                     show the version`,
+	})
+}
@@ -0,0 +1,75 @@
+// Package msg каталог сообщений логов и консольного вывода на нескольких
+// языках. Сообщение ищется по идентификатору на выбранном языке, при его
+// отсутствии — на английском. Каждый язык — отдельный файл пакета, который
+// регистрирует свой каталог в init.
+package msg
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Fallback язык, на котором берутся сообщения без перевода
+const Fallback = "en"
+
+var (
+	catalogs = map[string]map[string]string{}
+	current  atomic.Value // string
+	reported sync.Map     // язык + "/" + id сообщений без перевода
+)
+
+func init() {
+	current.Store("ru")
+}
+
+// register добавляет каталог языка; вызывается из init файла языка
+func register(lang string, messages map[string]string) {
+	catalogs[lang] = messages
+}
+
+// Languages доступные языки по алфавиту
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Supported сообщает, есть ли каталог языка
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// SetLanguage выбирает язык сообщений; неизвестный язык не меняет текущий
+func SetLanguage(lang string) {
+	if Supported(lang) {
+		current.Store(lang)
+	}
+}
+
+// Language текущий язык сообщений
+func Language() string {
+	return current.Load().(string)
+}
+
+// T сообщение id на текущем языке. Сообщение без перевода берётся на
+// английском, а его id один раз пишется в лог на уровне debug; неизвестный
+// id возвращается как есть.
+func T(id string) string {
+	lang := Language()
+	if s, ok := catalogs[lang][id]; ok {
+		return s
+	}
+	if _, seen := reported.LoadOrStore(lang+"/"+id, true); !seen {
+		slog.Debug("message not translated", "id", id, "language", lang)
+	}
+	if s, ok := catalogs[Fallback][id]; ok {
+		return s
+	}
+	return id
+}
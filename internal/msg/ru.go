@@ -0,0 +1,376 @@
+package msg
+
+// Русский каталог, язык по умолчанию
+func init() {
+	register("ru", map[string]string{
+		"archive.done":                     "Архив: %s (файлов %d, не найдено %d)\n",
+		"archive.failed":                   "Ошибка записи архива",
+		"archive.no-sessions":              "Сессий нет: нечего архивировать",
+		"archive.screenshots-raw":          "Снимки добавляются в архив как есть: --anonymize скрывает данные только в тексте",
+		"archive.unknown-session":          "Сессия %q не найдена\n",
+		"breaker.closed":                   "Провайдер восстановился, цепь замкнута",
+		"breaker.half-open":                "Пробный запрос к провайдеру",
+		"breaker.llm-fallback":             "Запрос к запасной модели",
+		"breaker.ocr-fallback":             "Запрос к запасному провайдеру OCR",
+		"breaker.open":                     "Провайдер недоступен, цепь разомкнута",
+		"capture.disabled":                 "Захват экрана отключён",
+		"capture.disabled-no-window":       "Захват экрана отключён: окно не выбрано",
+		"capture.failed":                   "Ошибка захвата экрана",
+		"capture.hotkey":                   "Захват экрана по горячей клавише",
+		"capture.hotkey-unregister-failed": "Ошибка снятия горячей клавиши",
+		"capture.hourly-limit":             "Периодический захват: достигнут лимит кадров в час",
+		"capture.interval":                 "Периодический захват экрана",
+		"capture.interval-disabled":        "Периодический захват экрана отключён",
+		"capture.interval-no-window":       "Периодический захват приостановлен: окно не найдено",
+		"capture.pick-prompt":              "Номер окна для захвата: ",
+		"capture.save-failed":              "Ошибка сохранения снимка",
+		"capture.saved":                    "Снимок экрана сохранён",
+		"capture.skipped-no-window":        "Снимок пропущен: окно для захвата не найдено",
+		"capture.window-found":             "Окно для захвата найдено, захват возобновлён",
+		"cassette.enabled":                 "Ответы API через кассету",
+		"cleanup.disabled":                 "Сроки хранения не заданы: retention.outputs или retention.debug в config.yml",
+		"cleanup.done":                     "Удалено файлов: %d, записей состояния: %d\n",
+		"cleanup.would":                    "Будет удалено файлов: %d, записей состояния: %d\n",
+		"clipboard.disabled":               "Наблюдение за буфером обмена отключено",
+		"clipboard.image":                  "Изображение из буфера обмена",
+		"clipboard.save-failed":            "Ошибка сохранения изображения из буфера обмена",
+		"clipboard.watching":               "Наблюдение за буфером обмена",
+		"code.save-failed":                 "Ошибка сохранения кода",
+		"code.saved":                       "Код сохранён",
+		"command.unknown":                  "Неизвестная команда: %s\n",
+		"compare.disagreements-failed":     "Не удалось сравнить ответы моделей",
+		"compare.mode":                     "Режим сравнения моделей",
+		"compare.model-answered":           "Ответ модели сравнения",
+		"compare.model-failed":             "Модель сравнения не ответила",
+		"complexity.failed":                "Не удалось получить оценку сложности",
+		"config.chdir-failed":              "Ошибка перехода в директорию конфигурации",
+		"config.failed":                    "Ошибка конфигурации",
+		"config.path-failed":               "Ошибка пути конфигурации",
+		"config.reload-failed":             "Изменённый config.yml не применён",
+		"config.usage":                     "Использование: hack_interview config encrypt-key | set-secret <служба>/<запись>",
+		"debughttp.toggled":                "Дампы HTTP переключены",
+		"debughttp.write-failed":           "Ошибка записи дампа HTTP",
+		"dir.read-failed":                  "Ошибка чтения директории",
+		"doctor.failed":                    "\nНе пройдено проверок: %d\n",
+		"doctor.offline":                   "\nПровайдеры не проверялись (--offline)",
+		"doctor.ok":                        "\nВсё готово",
+		"doctor.probes":                    "\nПробные запросы к провайдерам: по одному минимальному запросу, расходуют немного квоты",
+		"envfile.failed":                   "Ошибка файла переменных окружения",
+		"envfile.path-failed":              "Ошибка пути файла переменных окружения",
+		"error.prefix":                     "Ошибка:",
+		"errorsdir.move-failed":            "Ошибка переноса в директорию ошибок",
+		"errorsdir.moved":                  "Файл перенесён в директорию ошибок",
+		"examples.enabled":                 "Включён runExamples: код из ответов модели будет выполняться на этой машине",
+		"examples.failed":                  "Не удалось получить примеры из условия",
+		"examples.no-go":                   "Тулчейн Go не найден, запуск примеров пропущен",
+		"examples.none":                    "В условии нет примеров",
+		"examples.parse-failed":            "Не удалось разобрать примеры",
+		"examples.running":                 "Выполняется код, сгенерированный моделью",
+		"export.anki-done":                 "Карточек: %d, файл: %s\n",
+		"export.failed":                    "Ошибка выгрузки",
+		"export.gdocs-done":                "Ответов: %d, документ: %s\n",
+		"export.gdocs-failed":              "Ошибка выгрузки в Google Docs, повторный запуск продолжит с этого места",
+		"export.gdocs-no-credentials":      "Для выгрузки в Google Docs задайте gdocs.credentials в config.yml",
+		"export.unknown-format":            "Неизвестный формат %q, поддерживаются anki и gdoc\n",
+		"file.attempts-exhausted":          "Файл не обработан, попытки исчерпаны",
+		"file.cancelled":                   "Обработка прервана",
+		"file.done":                        "Обработка завершена",
+		"file.dry-run":                     "Пробный запуск: OCR и запрос к модели пропущены",
+		"file.failed":                      "Ошибка обработки",
+		"file.follow-up":                   "Вопрос-продолжение",
+		"file.kind":                        "Распознан вид вопроса",
+		"file.named-prompt":                "Промпт из имени файла",
+		"file.ocr-save-failed":             "Ошибка сохранения распознанного текста",
+		"file.panic":                       "Паника при обработке файла",
+		"file.processing":                  "Обрабатывается файл",
+		"file.request-save-failed":         "Ошибка сохранения запроса",
+		"file.request-saved":               "Запрос сохранён",
+		"file.retry-scheduled":             "Назначена повторная попытка",
+		"file.reused-ocr":                  "Использован распознанный ранее текст",
+		"file.saved":                       "Файл сохранён",
+		"file.style":                       "Стиль ответа",
+		"filter.accepted":                  "Файл принят фильтром",
+		"filter.looks-like-question":       "Текст похож на вопрос",
+		"filter.not-question-sent":         "Текст не похож на вопрос, но отправлен модели (questionFilter.mode: warn)",
+		"filter.not-question-skipped":      "Текст не похож на вопрос, запрос к модели пропущен",
+		"filter.skipped":                   "Файл пропущен фильтром",
+		"flags.aliases":                    "  сокращения: %s\n",
+		"flags.general":                    "общие",
+		"flags.header":                     "\nПоля config.yml на один запуск (флаг важнее окружения и файла):",
+		"gdocs.changed":                    "Документ изменён после прерванной записи, оформление записи не завершено",
+		"gdocs.created":                    "Создан документ Google Docs",
+		"git.commit-failed":                "Ошибка коммита результатов",
+		"git.committed":                    "Результаты закоммичены",
+		"git.no-author":                    "В git не задан автор коммитов, коммиты результатов выключены",
+		"git.not-repo":                     "OutputDir не в git-репозитории, коммиты результатов выключены",
+		"gocheck.broken":                   "Код не компилируется",
+		"gocheck.failed":                   "Не удалось проверить код",
+		"gocheck.fixed":                    "Код исправлен после ошибки компиляции",
+		"gocheck.no-go":                    "Тулчейн Go не найден, проверка кода пропущена",
+		"gocheck.ok":                       "Код компилируется",
+		"group.incomplete":                 "Группа не сложилась, части обрабатываются отдельно",
+		"group.merged":                     "Части вопроса объединены",
+		"group.waiting":                    "Ожидаются остальные части вопроса",
+		"grpc.accepted":                    "Принят файл по gRPC",
+		"grpc.failed":                      "Ошибка gRPC-сервиса",
+		"grpc.no-tls":                      "gRPC-сервис работает без TLS",
+		"grpc.start-failed":                "Ошибка запуска gRPC-сервиса",
+		"grpc.started":                     "gRPC-сервис запущен",
+		"http.setup-failed":                "Ошибка настройки HTTP-клиентов",
+		"http.trace":                       "Запрос к провайдеру",
+		"image.gif-first-frame":            "Анимированный GIF, распознаётся только первый кадр",
+		"image.invert-failed":              "Не удалось проверить снимок на инверсию, распознаётся исходный",
+		"image.inverted":                   "Снимок инвертирован перед OCR",
+		"image.not-inverted":               "Снимок не инвертирован: недостаточно тёмный",
+		"image.split":                      "Высокий снимок разрезан на полосы для OCR",
+		"image.split-failed":               "Не удалось разрезать высокий снимок, распознаётся целиком",
+		"image.strip-failed":               "Полоса снимка не распознана, текст собран из остальных",
+		"image.trim-failed":                "Не удалось обрезать поля снимка, распознаётся исходный",
+		"imap.attachment-skipped":          "Вложение пропущено",
+		"imap.lost":                        "Соединение IMAP потеряно",
+		"imap.message-skipped":             "Письмо без подходящих вложений пропущено",
+		"imap.parse-failed":                "Ошибка разбора письма",
+		"imap.watching":                    "Наблюдение за почтой",
+		"list.header":                      "ФАЙЛ\tСТАТУС\tПОПЫТОК\tОБНОВЛЁН\tПОВТОР\tРЕЗУЛЬТАТ\tОШИБКА",
+		"llm.file-cleanup-failed":          "Не удалось удалить снимок, загруженный через Files API",
+		"llm.image-unsupported":            "Формат снимка не поддерживается моделью, отправляется только текст",
+		"llm.truncated":                    "Ответ модели обрезан: достигнут maxOutputTokens",
+		"lock.failed":                      "Не удалось создать блокировку экземпляра",
+		"logging.failed":                   "Ошибка настройки логирования",
+		"logging.file-failed":              "Ошибка записи в файл логов, дальше логи только в stderr",
+		"notion.http-failed":               "Ошибка настройки HTTP для Notion, используется клиент по умолчанию",
+		"once.start":                       "Обработка директории",
+		"output.failed":                    "Ошибка вывода",
+		"pause.breaker":                    "Обработка приостановлена: провайдер недоступен",
+		"pause.file":                       "Обработка приостановлена: найден файл паузы (удалите его, чтобы продолжить)",
+		"pause.quota":                      "Обработка приостановлена: исчерпан дневной лимит провайдера",
+		"pause.resumed":                    "Обработка возобновлена",
+		"pause.signal":                     "Обработка приостановлена по сигналу (повторный сигнал возобновит её)",
+		"pidfile.failed":                   "Ошибка PID-файла",
+		"pidfile.stale":                    "Найден устаревший PID-файл, перезаписываем",
+		"problems.load-failed":             "Ошибка загрузки известных задач",
+		"problems.matched":                 "Распознана известная задача",
+		"process.usage":                    "Использование: hack_interview process [--dry-run] [--output <дир>] [--record] <путь|шаблон>...",
+		"publish.discord-disabled":         "Публикация в Discord отключена",
+		"publish.done":                     "Результат опубликован",
+		"publish.email-test-failed":        "Пробное письмо не отправлено",
+		"publish.email-test-sent":          "Пробное письмо отправлено",
+		"publish.failed":                   "Ошибка публикации результата",
+		"publish.gdocs-disabled":           "Публикация в Google Docs отключена",
+		"publish.queue-full":               "Очередь публикации переполнена, результат не опубликован",
+		"publish.queued":                   "Результат принят к публикации",
+		"publish.timeout":                  "Публикация не завершена за отведённое время, остаток очереди пропущен",
+		"queue.drained":                    "Очередь освободилась",
+		"queue.full":                       "Очередь заполнена",
+		"quota.exhausted":                  "Дневной лимит провайдера исчерпан",
+		"quota.exhausted-paused":           "Дневной лимит провайдера исчерпан, обработка приостановлена до сброса",
+		"quota.load-failed":                "Ошибка загрузки счётчиков квот",
+		"quota.near-limit":                 "Квота провайдера почти исчерпана",
+		"quota.remaining":                  "Остаток квоты",
+		"quota.save-failed":                "Ошибка сохранения счётчиков квот",
+		"recover.restart":                  "Прерванная обработка начнётся заново",
+		"recover.resume-llm":               "Прерванная обработка продолжится с запроса к модели: распознанный текст сохранён",
+		"report.write-failed":              "Ошибка записи отчёта об обработке",
+		"reprocess.usage":                  "Использование: hack_interview reprocess [--style <стиль>] [--force] <путь|шаблон>...",
+		"reset.done":                       "Сброшено записей: %d\n",
+		"reset.usage":                      "Использование: hack_interview reset <имя>... | --failed",
+		"retention.done":                   "Удалены устаревшие файлы",
+		"retention.failed":                 "Ошибка очистки устаревших файлов",
+		"retention.remove-failed":          "Не удалось удалить устаревший файл",
+		"s3.download-failed":               "Ошибка загрузки объекта S3",
+		"s3.poll-failed":                   "Ошибка опроса S3",
+		"s3.setup-failed":                  "Ошибка настройки S3",
+		"s3.watching":                      "Наблюдение за S3",
+		"secrets.key-prompt":               "Ключ: ",
+		"secrets.new-passphrase-prompt":    "Парольная фраза: ",
+		"secrets.passphrase-prompt":        "Парольная фраза ключей config.yml: ",
+		"secrets.repeat-prompt":            "Ещё раз: ",
+		"server.accepted":                  "Принят файл по HTTP",
+		"server.save-failed":               "Ошибка сохранения загруженного файла",
+		"server.start-failed":              "Ошибка запуска HTTP-сервера",
+		"server.started":                   "HTTP-сервер запущен",
+		"service.failed":                   "Ошибка службы",
+		"service.linger":                   "Чтобы она работала и без входа в систему: loginctl enable-linger ",
+		"service.recovery-failed":          "Перезапуск службы при сбое не настроен",
+		"service.removed":                  "Удалён",
+		"service.started":                  "Служба запущена; журнал: journalctl --user -u ",
+		"service.step-failed":              "Шаг службы не выполнен",
+		"service.windows-dry-run":          "Служба %s, запуск автоматически, перезапуск при сбое через 5s:\n  %s %s\n",
+		"service.windows-dry-run-remove":   "Остановить и удалить службу %s\n",
+		"service.windows-installed":        "Служба %s установлена и запущена; логи пишутся в logFile из config.yml\n",
+		"service.windows-removed":          "Служба %s удалена\n",
+		"service.written":                  "Записан",
+		"session.started":                  "Начата сессия",
+		"shutdown.forced":                  "Повторный сигнал, немедленный выход",
+		"shutdown.signal":                  "Получен сигнал, завершаем текущую обработку",
+		"shutdown.stages":                  "Время по этапам:",
+		"shutdown.timeout":                 "Время ожидания истекло, обработка отменяется",
+		"shutdown.totals":                  "Итоги: обработано %d, пропущено %d, с ошибкой %d, время работы %v\n",
+		"shutdown.was-paused":              "Обработка была приостановлена, необработанные файлы будут взяты при следующем запуске",
+		"split.bad-response":               "Некорректный ответ о разделении вопросов, текст обрабатывается целиком",
+		"split.done":                       "Текст разделён на отдельные вопросы",
+		"split.failed":                     "Не удалось разделить вопросы, текст обрабатывается целиком",
+		"split.too-many":                   "Слишком много частей, текст обрабатывается целиком",
+		"split.too-short":                  "Части заметно короче текста, он обрабатывается целиком",
+		"split.unsure":                     "Модель не уверена в разделении вопросов, текст обрабатывается целиком",
+		"stages.started":                   "Обработка по этапам: распознавание и ответ модели параллельно",
+		"state.close-failed":               "Ошибка закрытия состояния",
+		"state.imported":                   "Состояние импортировано из JSON",
+		"state.load-failed":                "Ошибка загрузки состояния",
+		"state.read-failed":                "Ошибка чтения состояния",
+		"state.save-failed":                "Ошибка сохранения состояния",
+		"stats.empty":                      "Нет обработанных файлов за период",
+		"stats.files":                      "Файлов: %d (готово %d, с ошибкой %d, ждут повтора %d, пропущено %d)\n",
+		"stats.kinds":                      "Типы вопросов:",
+		"stats.peak-hours":                 "Часы пик:",
+		"stats.sessions-header":            "СЕССИЯ\tНАЧАЛО\tФАЙЛОВ\tГОТОВО\tОШИБОК\tПРОПУЩЕНО\tТОКЕНОВ\tРАСХОДЫ",
+		"stats.stages-header":              "ЭТАП\tФАЙЛОВ\tОШИБОК\tДОЛЯ ОШИБОК\tСРЕДНЕЕ\tP95",
+		"stats.stage-line":                 "%-9s p50 %v, p95 %v (замеров: %d)",
+		"stats.tokens":                     "\nТокены: запрос %d, ответ %d, оценка расходов $%.4f\n",
+		"status.circuit":                   "Цепь %s: %s\n",
+		"status.circuit-retry":             "Цепь %s: %s, пробный запрос в %s\n",
+		"status.last-error":                "Последняя ошибка: %s\n",
+		"status.paused":                    "приостановлен",
+		"status.processed":                 "Обработано:   %d, с ошибкой: %d, пропущено: %d\n",
+		"status.queue":                     "Очередь:      %d/%d\n",
+		"status.queue-deferred":            "Отложено:     %d\n",
+		"status.running":                   "работает",
+		"status.socket-failed":             "Ошибка сокета состояния",
+		"status.unavailable":               "Команда status недоступна",
+		"status.uptime":                    "Время работы: %s\n",
+		"statusline.line":                  "[%s] очередь %d/%d | готово %d, ошибок %d | OCR %d, токенов %d",
+		"statusline.status":                "Состояние",
+		"style.unknown":                    "Неизвестный стиль",
+		"summary.failed":                   "Не удалось получить краткий ответ",
+		"tests.failed":                     "Не удалось получить тесты",
+		"tests.no-block":                   "В ответе модели нет блока с тестами",
+		"tests.no-code":                    "В ответе нет кода для тестов",
+		"tests.save-failed":                "Ошибка сохранения тестов",
+		"tests.saved":                      "Тесты сохранены",
+		"transcript.create-failed":         "Ошибка создания стенограммы",
+		"transcript.write-failed":          "Ошибка записи стенограммы",
+		"translate.failed":                 "Ошибка перевода",
+		"translate.totals":                 "Переведено %d, пропущено %d, с ошибкой %d, токенов %d\n",
+		"translate.usage":                  "Использование: hack_interview translate [--to en] [--force] <файл|шаблон>... | --all",
+		"tui.copied":                       "ответ скопирован",
+		"tui.counters":                     "%s %d/%d   готово %d, ошибок %d   OCR %d, токенов %d",
+		"tui.failed":                       "Ошибка интерфейса",
+		"tui.help":                         "↑/↓ выбор  o открыть  c копировать  r обработать заново  pgup/pgdn листать  q выход",
+		"tui.no-answer":                    "нет ответа",
+		"tui.no-files":                     "файлов пока нет",
+		"tui.no-output":                    "нет файла ответа",
+		"tui.open-failed":                  "не удалось открыть: ",
+		"tui.opened":                       "открыт ",
+		"tui.preview-truncated":            "… ответ обрезан, откройте файл клавишей o",
+		"tui.queue":                        "Очередь:",
+		"tui.queued":                       "поставлен в очередь: ",
+		"tui.reprocess-local-only":         "повторная обработка доступна только для локальных файлов",
+		"tui.reprocess-next-scan":          "файл будет взят при следующем сканировании",
+		"tui.workers":                      "Обработчики: ",
+		"ui.available":                     "Веб-интерфейс доступен",
+		"ui.reprocess":                     "Повторная обработка из веб-интерфейса",
+		"ui.template-failed":               "Ошибка шаблона веб-интерфейса",
+		"update.available":                 "Доступна версия %s (установлена %s)\n",
+		"update.check-failed":              "Ошибка проверки обновлений:",
+		"update.done":                      "Обновлено до %s\n",
+		"update.failed":                    "Обновление не выполнено:",
+		"update.latest":                    "Установлена последняя версия (%s)\n",
+		"verify.failed":                    "Не удалось проверить ответ",
+		"verify.issues":                    "Проверка ответа нашла замечания",
+		"verify.ok":                        "Проверка ответа: замечаний нет",
+		"verify.regenerate-failed":         "Не удалось исправить ответ по замечаниям проверки",
+		"verify.regenerated":               "Ответ исправлен по замечаниям проверки",
+		"watch.input-available":            "Входная директория снова доступна",
+		"watch.input-unavailable":          "Входная директория недоступна дольше допустимого",
+		"watch.old-skipped":                "Пропущены старые файлы",
+		"watch.read-retry":                 "Ошибка чтения директории, повтор",
+		"watch.start":                      "Запуск мониторинга директории",
+
+		"usage": `Использование: hack_interview [флаги] [команда]
+
+Без команды запускается наблюдение за входной директорией.
+
+Флаги:
+  --once                      обработать накопившиеся файлы и выйти (то же, что run)
+  --tui                       полноэкранный интерфейс: список ответов, просмотр,
+                              обработчики и очередь (логи только в logFile)
+  --pidfile <файл>            записать PID на время работы
+  --debug-http                писать запросы и ответы API в OutputDir/.debug
+                              (ключи скрываются; debugHTTP в config.yml)
+  --cassette record|replay    записывать ответы API в cassette.dir или отвечать
+                              из записей без сети (cassette.mode в config.yml)
+  --no-tests                  не генерировать тесты к коду (tests.enabled)
+  --no-verify                 не проверять ответ вторым запросом (verify.enabled)
+  --email-test                отправить пробное письмо при запуске и завершиться
+                              с ошибкой, если не удалось (email в config.yml)
+  --log-level <уровень>       debug, info, warn или error (logLevel в config.yml)
+  --log-format text|json      формат логов в stderr (logFormat в config.yml)
+  --quiet                     только ошибки; строка состояния остаётся, её
+                              выключает --status-line=false
+  -v, -vv                     debug; -vv ещё и сводка каждого запроса к
+                              провайдерам (важнее logLevel, с --log-level нельзя)
+  --no-color                  итоги файлов в терминале значками без цвета
+                              (также NO_COLOR); в трубу логи идут как есть
+  --config <файл>             конфигурация вместо config.yml; программа переходит
+                              в её директорию, и относительные пути в ней, как
+                              и .env, отсчитываются от неё
+  --env-file <файл>           переменные окружения из файла, вдобавок к .env
+                              рядом с config.yml; окружение важнее --env-file,
+                              он — .env, а .env — config.yml (OCR_API_KEY,
+                              GEMINI_API_KEY, DISCORD_WEBHOOK_URL)
+
+Команды:
+  run                         обработать накопившиеся файлы и выйти
+  process [флаги] <путь|шаблон>... | -
+                              обработать указанные файлы без наблюдения;
+                              "-" читает изображение из stdin и печатает ответ
+      --dry-run               только показать, что было бы сделано
+      --output <дир>          директория для результатов
+      --record                записать итог в состояние
+  reprocess [--style <стиль>] [--force] <путь|шаблон>...
+                              обработать файлы заново; --force отправляет
+                              модели и слишком короткий текст (minText)
+  status [--json]             состояние запущенного экземпляра
+  new-session                 закрыть текущую сессию запущенного экземпляра
+                              и начать новую стенограмму
+  list [--status <статусы>] [--today] [--failed] [--json]
+                              показать файлы из состояния и ждущие во входной
+                              директории (pending); --status через запятую:
+                              pending, processing, done, retry, failed, skipped
+  stats [--json] [--since <время>] [--until <время>]
+                              сводка по состоянию: ошибки и длительности этапов,
+                              токены и расходы (gemini.price), часы пик, типы
+                              вопросов и сессии; время — дата, RFC3339 или 7d
+  reset <имя>... | --failed   сбросить записи, чтобы файлы обработались снова
+  cleanup [--dry-run]         удалить результаты и дампы старше сроков retention
+                              в config.yml; --dry-run только перечисляет их
+  translate [--to en] [--force] <файл|шаблон>... | --all
+                              перевести ответы в <имя>.<язык>.md, код не меняется
+  export [--format anki|gdoc] [--output <файл>]
+                              выгрузить вопросы и ответы карточками Anki (TSV)
+                              или в документ Google Docs (gdocs в config.yml)
+  archive [--session <id>] [--screenshots] [--anonymize]
+                              упаковать сессию (по умолчанию последнюю) в
+                              OutputDir/archives/session-<id>.zip с manifest.json;
+                              --anonymize скрывает почту, телефоны и ключи в тексте
+  doctor [--offline]          проверить конфигурацию, директории, состояние,
+                              внешние программы и ключи провайдеров (по одному
+                              минимальному запросу — немного квоты)
+  config encrypt-key          зашифровать ключ для config.yml: ключ вводится без
+                              эха или читается из stdin, парольная фраза — из
+                              HACK_INTERVIEW_PASSPHRASE или вводится дважды;
+                              напечатанное enc:v1:... вставьте вместо ключа
+  config set-secret <служба>/<запись>
+                              сохранить ключ в хранилище ОС (Keychain,
+                              Credential Manager, secret-service) и напечатать
+                              ссылку keychain:... для config.yml
+  install-service [--dry-run] запускать программу службой: пользовательский юнит
+                              systemd, агент launchd или служба Windows (нужны
+                              права администратора); конфигурация — config.yml
+                              или --config, логи в Windows — только logFile
+  uninstall-service [--dry-run]
+                              остановить и удалить службу
+  update [--check]            обновиться до последнего выпуска на GitHub
+  version                     показать версию`,
+	})
+}
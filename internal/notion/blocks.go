@@ -0,0 +1,147 @@
+package notion
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxTextRunes длина одного объекта rich_text
+const maxTextRunes = 2000
+
+// maxTexts объектов rich_text в одном блоке; длинный текст переходит в
+// следующий блок того же типа
+const maxTexts = 100
+
+// Block блок содержимого страницы в формате API
+type Block map[string]any
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletRe   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numberedRe = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	fenceRe    = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+#-]*)")
+)
+
+// Blocks переводит Markdown в блоки Notion: заголовки, абзацы, списки,
+// цитаты и блоки кода с языком. Встроенная разметка (**жирный**, ссылки)
+// остаётся текстом.
+func Blocks(markdown string) []Block {
+	var blocks []Block
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, textBlocks("paragraph", strings.Join(para, " "))...)
+			para = nil
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, codeBlocks(strings.Join(code, "\n"), Language(m[2]))...)
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case headingRe.MatchString(trimmed):
+			flush()
+			m := headingRe.FindStringSubmatch(trimmed)
+			level := min(len(m[1]), 3)
+			blocks = append(blocks, textBlocks("heading_"+string(rune('0'+level)), m[2])...)
+		case bulletRe.MatchString(line):
+			flush()
+			blocks = append(blocks, textBlocks("bulleted_list_item", bulletRe.FindStringSubmatch(line)[1])...)
+		case numberedRe.MatchString(line):
+			flush()
+			blocks = append(blocks, textBlocks("numbered_list_item", numberedRe.FindStringSubmatch(line)[1])...)
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			blocks = append(blocks, textBlocks("quote", strings.TrimSpace(strings.TrimPrefix(trimmed, ">")))...)
+		case trimmed == "---" || trimmed == "***":
+			flush()
+			blocks = append(blocks, Block{"object": "block", "type": "divider", "divider": map[string]any{}})
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// textBlocks блоки типа kind с текстом; текст длиннее maxTexts объектов
+// rich_text занимает несколько блоков
+func textBlocks(kind, text string) []Block {
+	var blocks []Block
+	texts := richText(text)
+	for len(texts) > 0 {
+		n := min(len(texts), maxTexts)
+		blocks = append(blocks, Block{"object": "block", "type": kind, kind: map[string]any{"rich_text": texts[:n]}})
+		texts = texts[n:]
+	}
+	return blocks
+}
+
+func codeBlocks(code, lang string) []Block {
+	var blocks []Block
+	texts := richText(code)
+	if len(texts) == 0 {
+		texts = richText(" ")
+	}
+	for len(texts) > 0 {
+		n := min(len(texts), maxTexts)
+		blocks = append(blocks, Block{"object": "block", "type": "code", "code": map[string]any{"rich_text": texts[:n], "language": lang}})
+		texts = texts[n:]
+	}
+	return blocks
+}
+
+// richText режет текст на объекты rich_text не длиннее maxTextRunes
+func richText(text string) []map[string]any {
+	var out []map[string]any
+	r := []rune(text)
+	for len(r) > 0 {
+		n := min(len(r), maxTextRunes)
+		out = append(out, map[string]any{"type": "text", "text": map[string]string{"content": string(r[:n])}})
+		r = r[n:]
+	}
+	return out
+}
+
+// languages языки блоков кода Notion
+var languages = map[string]bool{
+	"bash": true, "c": true, "c#": true, "c++": true, "css": true, "dart": true, "docker": true,
+	"elixir": true, "go": true, "graphql": true, "haskell": true, "html": true, "java": true,
+	"javascript": true, "json": true, "kotlin": true, "lua": true, "makefile": true,
+	"markdown": true, "mermaid": true, "objective-c": true, "perl": true, "php": true,
+	"plain text": true, "powershell": true, "python": true, "r": true, "ruby": true, "rust": true,
+	"scala": true, "shell": true, "sql": true, "swift": true, "typescript": true, "xml": true,
+	"yaml": true,
+}
+
+// languageAliases метки блоков кода Markdown, которые в Notion называются иначе
+var languageAliases = map[string]string{
+	"golang": "go", "py": "python", "js": "javascript", "ts": "typescript", "cpp": "c++",
+	"cs": "c#", "csharp": "c#", "sh": "shell", "zsh": "shell", "yml": "yaml", "kt": "kotlin",
+	"rs": "rust", "rb": "ruby", "md": "markdown", "dockerfile": "docker", "text": "plain text",
+	"txt": "plain text", "postgresql": "sql", "mysql": "sql",
+}
+
+// Language язык блока кода Notion для метки Markdown; неизвестная —
+// plain text
+func Language(tag string) string {
+	tag = strings.ToLower(tag)
+	if alias, ok := languageAliases[tag]; ok {
+		tag = alias
+	}
+	if languages[tag] {
+		return tag
+	}
+	return "plain text"
+}
@@ -0,0 +1,192 @@
+// Package notion создаёт страницы в базе данных Notion: свойства вопроса и
+// ответ, переведённый из Markdown в блоки Notion.
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// DefaultURL адрес Notion API
+const DefaultURL = "https://api.notion.com"
+
+// apiVersion версия API в заголовке Notion-Version
+const apiVersion = "2022-06-28"
+
+// maxChildren блоков в одном запросе; остальные дописываются отдельными
+// запросами
+const maxChildren = 100
+
+// maxAttempts попыток одного запроса при ответе 429
+const maxAttempts = 4
+
+// Client клиент Notion API. Запросы идут не чаще RequestsPerSecond: Notion
+// ограничивает интеграцию в среднем тремя запросами в секунду.
+type Client struct {
+	Token string
+	// BaseURL адрес API; пустой — DefaultURL
+	BaseURL string
+	// Client общий клиент; nil — новый клиент на каждый запрос
+	Client *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+	// RequestsPerSecond 0 — 3
+	RequestsPerSecond int
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// Page страница базы данных
+type Page struct {
+	Title   string
+	Date    time.Time
+	Session string
+	Kind    string
+	Status  string
+	// Markdown содержимое страницы
+	Markdown string
+}
+
+// Properties имена свойств базы данных. Title — свойство-заголовок,
+// Date — дата, Session, Kind и Status — свойства типа select. Пустое имя —
+// свойство не заполняется.
+type Properties struct {
+	Title, Date, Session, Kind, Status string
+}
+
+// CreatePage создаёт страницу в базе databaseID и возвращает её адрес
+func (c *Client) CreatePage(ctx context.Context, databaseID string, props Properties, p Page) (string, error) {
+	values := map[string]any{}
+	if props.Title != "" {
+		values[props.Title] = map[string]any{"title": richText(p.Title)}
+	}
+	if props.Date != "" && !p.Date.IsZero() {
+		values[props.Date] = map[string]any{"date": map[string]string{"start": p.Date.Format(time.RFC3339)}}
+	}
+	for name, value := range map[string]string{props.Session: p.Session, props.Kind: p.Kind, props.Status: p.Status} {
+		if name != "" && value != "" {
+			values[name] = map[string]any{"select": map[string]string{"name": selectName(value)}}
+		}
+	}
+
+	blocks := Blocks(p.Markdown)
+	first := blocks[:min(len(blocks), maxChildren)]
+	var page struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	err := c.do(ctx, http.MethodPost, "/v1/pages", map[string]any{
+		"parent":     map[string]string{"database_id": databaseID},
+		"properties": values,
+		"children":   first,
+	}, &page)
+	if err != nil {
+		return "", err
+	}
+	for rest := blocks[len(first):]; len(rest) > 0; {
+		n := min(len(rest), maxChildren)
+		if err := c.do(ctx, http.MethodPatch, "/v1/blocks/"+page.ID+"/children", map[string]any{"children": rest[:n]}, nil); err != nil {
+			return page.URL, fmt.Errorf("страница создана не полностью: %w", err)
+		}
+		rest = rest[n:]
+	}
+	return page.URL, nil
+}
+
+// selectName значение select: Notion не допускает в нём запятых
+func selectName(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, ",", " "))
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result any) error {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	client := c.Client
+	if client == nil {
+		client = resty.New()
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
+		reqCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.Timeout > 0 {
+			reqCtx, cancel = context.WithTimeout(ctx, c.Timeout)
+		}
+		start := time.Now()
+		resp, err := client.R().
+			SetContext(reqCtx).
+			SetAuthToken(c.Token).
+			SetHeader("Notion-Version", apiVersion).
+			SetHeader("Content-Type", "application/json").
+			SetBody(data).
+			Execute(method, strings.TrimSuffix(baseURL, "/")+path)
+		cancel()
+		metrics.ObserveRequest(metrics.ProviderNotion, time.Since(start), err)
+		if err != nil {
+			return errs.FromTransport(err)
+		}
+		if resp.StatusCode() == http.StatusTooManyRequests && attempt < maxAttempts {
+			delay := time.Second
+			if s, err := strconv.Atoi(resp.Header().Get("Retry-After")); err == nil && s > 0 {
+				delay = time.Duration(s) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+		if resp.IsError() {
+			return errs.FromStatus(metrics.ProviderNotion, resp.StatusCode(), resp.String())
+		}
+		if result != nil {
+			if err := json.Unmarshal(resp.Body(), result); err != nil {
+				return fmt.Errorf("некорректный ответ Notion API: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// wait выдерживает интервал между запросами
+func (c *Client) wait(ctx context.Context) error {
+	rps := c.RequestsPerSecond
+	if rps <= 0 {
+		rps = 3
+	}
+	interval := time.Second / time.Duration(rps)
+	c.mu.Lock()
+	next := c.last.Add(interval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	c.last = next
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
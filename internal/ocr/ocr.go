@@ -0,0 +1,164 @@
+// Package ocr распознаёт текст на изображениях.
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Provider распознаёт текст изображения по пути к файлу
+type Provider interface {
+	ExtractText(ctx context.Context, imagePath string) (string, error)
+}
+
+// DefaultOCRSpaceURL адрес OCR.space; к нему добавляется /parse/image
+const DefaultOCRSpaceURL = "https://api.ocr.space"
+
+// OCRSpace клиент api.ocr.space
+type OCRSpace struct {
+	APIKey string
+	// BaseURL адрес API, например региональный; пустой — DefaultOCRSpaceURL
+	BaseURL string
+	// Headers дополнительные заголовки каждого запроса
+	Headers map[string]string
+	// Client общий клиент провайдера; nil — новый клиент на каждый запрос
+	Client *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+	// OnRequest вызывается после каждого отправленного запроса
+	OnRequest func()
+	// MaxFileSize предельный размер файла по тарифу; файл больше не
+	// отправляется. 0 — без проверки.
+	MaxFileSize int64
+}
+
+// OCR API Response Structure
+type OCRResponse struct {
+	ParsedResults []struct {
+		ParsedText string `json:"ParsedText"`
+	} `json:"ParsedResults"`
+	IsErroredOnProcessing bool `json:"IsErroredOnProcessing"`
+	// ErrorMessage строка или массив строк, в зависимости от ошибки
+	ErrorMessage json.RawMessage `json:"ErrorMessage"`
+}
+
+// errorMessage текст ошибки из ответа OCR.space
+func (r OCRResponse) errorMessage() string {
+	var list []string
+	if json.Unmarshal(r.ErrorMessage, &list) == nil {
+		return strings.Join(list, "; ")
+	}
+	var s string
+	json.Unmarshal(r.ErrorMessage, &s)
+	return s
+}
+
+// quotaMessage тариф OCR.space сообщает об исчерпанном дневном лимите
+// ответом 403 с таким текстом
+func quotaMessage(body string) bool {
+	return strings.Contains(body, "maximum") && strings.Contains(body, "times within")
+}
+
+func encodeImageToBase64(imagePath string) (string, error) {
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(imageData), nil
+}
+
+// ImageMIMEType тип содержимого для data URI по расширению файла
+func ImageMIMEType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".pdf":
+		return "application/pdf"
+	default:
+		return "image/png"
+	}
+}
+
+func (o *OCRSpace) ExtractText(ctx context.Context, imagePath string) (string, error) {
+	if o.MaxFileSize > 0 {
+		if fi, err := os.Stat(imagePath); err == nil && fi.Size() > o.MaxFileSize {
+			return "", fmt.Errorf("%w: %d КБ при пределе тарифа %d КБ", errs.ErrFileTooLarge, fi.Size()>>10, o.MaxFileSize>>10)
+		}
+	}
+	imageBase64, err := encodeImageToBase64(imagePath)
+	if err != nil {
+		return "", err
+	}
+
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOCRSpaceURL
+	}
+
+	client := o.Client
+	if client == nil {
+		client = resty.New()
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeaders(o.Headers).
+		SetHeader("apikey", o.APIKey).
+		SetFormData(map[string]string{
+			"language":                     "rus",
+			"isOverlayRequired":            "false",
+			"base64Image":                  "data:" + ImageMIMEType(imagePath) + ";base64," + imageBase64,
+			"iscreatesearchablepdf":        "false",
+			"issearchablepdfhidetextlayer": "false",
+		}).
+		Post(strings.TrimSuffix(baseURL, "/") + "/parse/image")
+	metrics.ObserveRequest(metrics.ProviderOCRSpace, time.Since(start), err)
+	if o.OnRequest != nil {
+		o.OnRequest()
+	}
+
+	if err != nil {
+		return "", errs.FromTransport(err)
+	}
+	if resp.IsError() {
+		if quotaMessage(resp.String()) {
+			return "", &errs.QuotaExceededError{Provider: metrics.ProviderOCRSpace}
+		}
+		return "", errs.FromStatus(metrics.ProviderOCRSpace, resp.StatusCode(), resp.String())
+	}
+
+	var ocrResp OCRResponse
+	if err := json.Unmarshal(resp.Body(), &ocrResp); err != nil {
+		return "", fmt.Errorf("некорректный ответ OCR.space: %w", err)
+	}
+	if ocrResp.IsErroredOnProcessing && len(ocrResp.ParsedResults) == 0 {
+		message := ocrResp.errorMessage()
+		if strings.Contains(message, "file size limit") {
+			return "", fmt.Errorf("OCR.space: %w: %s", errs.ErrFileTooLarge, message)
+		}
+		return "", fmt.Errorf("OCR.space: %s", message)
+	}
+
+	if len(ocrResp.ParsedResults) > 0 {
+		return ocrResp.ParsedResults[0].ParsedText, nil
+	}
+
+	return "", errs.ErrNoText
+}
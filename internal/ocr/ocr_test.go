@@ -0,0 +1,182 @@
+package ocr
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/errs"
+)
+
+// received запрос к подставному OCR.space
+type received struct {
+	Method string
+	Path   string
+	Header http.Header
+	Form   url.Values
+}
+
+// fakeOCRSpace отвечает кодом code и содержимым testdata/fixture и
+// записывает полученные запросы
+func fakeOCRSpace(t *testing.T, code int, fixture string) (*httptest.Server, *[]received) {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reqs []received
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		reqs = append(reqs, received{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Form: r.PostForm})
+		w.WriteHeader(code)
+		w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+// writeImage снимок с содержимым data
+func writeImage(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractText(t *testing.T) {
+	srv, reqs := fakeOCRSpace(t, http.StatusOK, "success.json")
+	data := []byte("\x89PNG\r\n\x1a\nscreenshot")
+	path := writeImage(t, "shot.png", data)
+	requests := 0
+	o := &OCRSpace{
+		APIKey:    "ocr-key",
+		BaseURL:   srv.URL + "/",
+		Headers:   map[string]string{"X-Gateway": "gw"},
+		OnRequest: func() { requests++ },
+	}
+
+	got, err := o.ExtractText(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Чем отличается слайс от массива?\r\nПриведите пример.\r\n" {
+		t.Errorf("текст %q", got)
+	}
+	if requests != 1 || len(*reqs) != 1 {
+		t.Fatalf("OnRequest %d, запросов %d", requests, len(*reqs))
+	}
+	r := (*reqs)[0]
+	if r.Method != http.MethodPost || r.Path != "/parse/image" {
+		t.Errorf("%s %s", r.Method, r.Path)
+	}
+	if r.Header.Get("apikey") != "ocr-key" || r.Header.Get("X-Gateway") != "gw" {
+		t.Errorf("заголовки %v", r.Header)
+	}
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/x-www-form-urlencoded") {
+		t.Errorf("Content-Type %q", ct)
+	}
+	want := url.Values{
+		"language":                     {"rus"},
+		"isOverlayRequired":            {"false"},
+		"base64Image":                  {"data:image/png;base64," + base64.StdEncoding.EncodeToString(data)},
+		"iscreatesearchablepdf":        {"false"},
+		"issearchablepdfhidetextlayer": {"false"},
+	}
+	if len(r.Form) != len(want) {
+		t.Errorf("поля формы %v", r.Form)
+	}
+	for k, v := range want {
+		if r.Form.Get(k) != v[0] {
+			t.Errorf("%s = %q, want %q", k, r.Form.Get(k), v[0])
+		}
+	}
+}
+
+func TestExtractTextMIMEType(t *testing.T) {
+	srv, reqs := fakeOCRSpace(t, http.StatusOK, "success.json")
+	o := &OCRSpace{APIKey: "k", BaseURL: srv.URL}
+	for _, name := range []string{"a.JPG", "b.pdf"} {
+		if _, err := o.ExtractText(context.Background(), writeImage(t, name, []byte("x"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, prefix := range []string{"data:image/jpeg;base64,", "data:application/pdf;base64,"} {
+		if got := (*reqs)[i].Form.Get("base64Image"); !strings.HasPrefix(got, prefix) {
+			t.Errorf("base64Image %q, want %s…", got, prefix)
+		}
+	}
+}
+
+func TestExtractTextErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		code    int
+		fixture string
+		check   func(error) bool
+		text    string
+	}{
+		{"пустой результат", http.StatusOK, "empty.json", func(err error) bool { return errors.Is(err, errs.ErrNoText) }, ""},
+		{"ошибка списком", http.StatusOK, "error-list.json", nil, "Unable to recognize the file type; E216:"},
+		{"ошибка строкой", http.StatusOK, "error-string.json", nil, "OCR.space: Timed out waiting for results"},
+		{"предел размера", http.StatusOK, "size-limit.json", func(err error) bool { return errors.Is(err, errs.ErrFileTooLarge) }, "1024 KB"},
+		{"некорректный JSON", http.StatusOK, "malformed.json", nil, "некорректный ответ OCR.space"},
+		{"квота", http.StatusForbidden, "quota.txt", func(err error) bool {
+			var q *errs.QuotaExceededError
+			return errors.As(err, &q) && q.Provider == "ocrspace"
+		}, ""},
+		{"ключ", http.StatusUnauthorized, "unauthorized.json", func(err error) bool { return errors.Is(err, errs.ErrAuth) }, "Invalid API key"},
+		{"502", http.StatusBadGateway, "bad-gateway.html", func(err error) bool {
+			var s *errs.StatusError
+			return errors.As(err, &s) && s.Code == http.StatusBadGateway
+		}, "502 Bad Gateway"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, _ := fakeOCRSpace(t, tc.code, tc.fixture)
+			o := &OCRSpace{APIKey: "k", BaseURL: srv.URL}
+			got, err := o.ExtractText(context.Background(), writeImage(t, "shot.png", []byte("x")))
+			if err == nil {
+				t.Fatalf("нет ошибки, текст %q", got)
+			}
+			if tc.check != nil && !tc.check(err) {
+				t.Errorf("err = %v", err)
+			}
+			if !strings.Contains(err.Error(), tc.text) {
+				t.Errorf("err = %v, want %q", err, tc.text)
+			}
+		})
+	}
+}
+
+// Файл больше предела тарифа не отправляется
+func TestExtractTextMaxFileSize(t *testing.T) {
+	srv, reqs := fakeOCRSpace(t, http.StatusOK, "success.json")
+	o := &OCRSpace{APIKey: "k", BaseURL: srv.URL, MaxFileSize: 4}
+	_, err := o.ExtractText(context.Background(), writeImage(t, "shot.png", []byte("12345")))
+	if !errors.Is(err, errs.ErrFileTooLarge) || len(*reqs) != 0 {
+		t.Errorf("err = %v, запросов %d", err, len(*reqs))
+	}
+}
+
+func TestExtractTextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+	o := &OCRSpace{APIKey: "k", BaseURL: srv.URL, Timeout: 20 * time.Millisecond}
+	if _, err := o.ExtractText(context.Background(), writeImage(t, "shot.png", []byte("x"))); !errors.Is(err, errs.ErrTimeout) {
+		t.Errorf("err = %v", err)
+	}
+}
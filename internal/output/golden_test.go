@@ -0,0 +1,153 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test ./internal/output -update перезаписывает testdata/*.golden
+var update = flag.Bool("update", false, "перезаписать эталоны testdata/*.golden")
+
+// goldenCases ответы модели, на которых ломается вывод: вложенная
+// разметка, не-ASCII текст, огромный блок кода и пустой ответ
+var goldenCases = []struct {
+	name string
+	r    Result
+}{
+	{"markdown", Result{
+		Source:   "in/slice.png",
+		Question: "Чем слайс отличается от массива?",
+		Answer: "## Answer\n\nСлайс — **ссылка** на участок массива: `ptr`, `len`, `cap`.\n\n" +
+			"| | массив | слайс |\n|---|---|---|\n| размер | в типе | динамический |\n\n" +
+			"> Цитата с `кодом` и [ссылкой](https://go.dev/blog/slices-intro).\n\n" +
+			"### Подсказка 1\n\nПосмотрите на `append`.\n\n" +
+			"### Подсказка 2 <cap> & \"len\"\n\n1. список\n   - вложенный\n\n" +
+			"## Solution (Go)\n\n```go\n// ### не раздел внутри кода\ns := make([]int, 0, 4)\ns = append(s, 1)\n```\n\n" +
+			"## Solution (Python)\n\n```python\ns = [1]\n```\n\n" +
+			"## Design\n\n```mermaid\ngraph TD\n  A[Клиент] --> B[Сервис]\n```\n\n" +
+			"```mermaid\ngraph\n  A[Клиент] --> B[Очередь]\n  B --> C[(БД)]\n```\n",
+	}},
+	{"unicode", Result{
+		Source:   "in/юникод.png",
+		Question: "Сколько байт в строке «héllo, 世界 👋»?",
+		Answer: "## Answer\n\n`len(\"héllo, 世界 👋\")` — 18 байт, а рун — 11: é (U+00E9), 世, 界, 👋 и e\u0301 " +
+			"(два кода, одна буква).\n\nRTL: שלום, مرحبا. Нулевая ширина:\u200b|\u200d|.\n\n" +
+			"### Руны ✨\n\n```go\nfor i, r := range \"世界\" {\n\tfmt.Println(i, string(r)) // 0 世, 3 界\n}\n```\n",
+	}},
+	{"huge-code", Result{
+		Source:   "in/huge.png",
+		Question: "Напишите генератор таблицы",
+		Answer:   "## Solution (Go)\n\n```go\n" + hugeCode() + "\n```\n\nГотово.\n",
+	}},
+	{"empty", Result{
+		Source:   "in/empty.png",
+		Question: "Что на снимке?",
+		Answer:   "",
+	}},
+}
+
+// hugeCode тысяча строк кода и одна строка в 8 КБ
+func hugeCode() string {
+	var b strings.Builder
+	b.WriteString("var table = []string{\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&b, "\t\"row-%04d\",\n", i)
+	}
+	b.WriteString("}\n\nconst long = \"" + strings.Repeat("0123456789abcdef", 512) + "\"")
+	return b.String()
+}
+
+// goldenRenderers режимы вывода, которые меняет конфигурация
+var goldenRenderers = []struct {
+	name string
+	set  func(r *Result)
+}{
+	{"markdown", func(*Result) {}},
+	{"code-only", func(r *Result) { r.CodeOnly = true }},
+	{"hints", func(r *Result) { r.Hints = true }},
+	{"design", func(r *Result) { r.Diagrams = true }},
+	{"solutions", func(r *Result) { r.Languages = []string{"Python", "Go", "Rust"} }},
+	{"annotated", func(r *Result) {
+		r.Summary = "Слайс — окно в массив"
+		r.Parent = "out/prev.md"
+		r.Prompt = "teach"
+		r.Sources = []string{r.Source, "in/part-2.png"}
+		r.Known = "Известная задача: Slices"
+		r.Review = "Ответ верен."
+	}},
+}
+
+func TestRenderMarkdownGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		for _, g := range goldenRenderers {
+			t.Run(c.name+"/"+g.name, func(t *testing.T) {
+				r := c.r
+				g.set(&r)
+				checkGolden(t, c.name+"."+g.name, RenderMarkdown(r))
+			})
+		}
+	}
+}
+
+// Save пишет в файл ровно то, что возвращает RenderMarkdown
+func TestSaveMatchesRender(t *testing.T) {
+	dir := t.TempDir()
+	r := goldenCases[0].r
+	path, err := Markdown{}.Save(dir, "slice", r, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, RenderMarkdown(r)) {
+		t.Error("содержимое файла отличается от RenderMarkdown")
+	}
+}
+
+// checkGolden сравнивает got с testdata/<name>.golden или, с -update,
+// перезаписывает эталон
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (go test -update создаст эталон)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s не совпадает с эталоном:\n%s", path, firstDiff(want, got))
+	}
+}
+
+// firstDiff первая различающаяся строка эталона и вывода
+func firstDiff(want, got []byte) string {
+	w, g := strings.Split(string(want), "\n"), strings.Split(string(got), "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl || i >= len(w) || i >= len(g) {
+			return fmt.Sprintf("строка %d:\n  эталон: %.200q\n  вывод:  %.200q", i+1, wl, gl)
+		}
+	}
+	return ""
+}
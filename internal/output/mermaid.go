@@ -0,0 +1,135 @@
+package output
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mermaidHeaderRe = regexp.MustCompile(`^(graph|flowchart)\s+(TD|TB|LR|RL|BT)\s*;?$`)
+	mermaidEdgeRe   = regexp.MustCompile(`--+>|==+>|-\.+->|---+|--\s*[^-].*?\s*-->`)
+	mermaidNodeRe   = regexp.MustCompile(`^[\p{L}\p{N}_]+\s*(\[\[|\[\(|\(\(|\[|\(|\{\{|\{|>)`)
+	mermaidIDRe     = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+	// mermaidLabelRe узел с подписью: id[...], id(...), id((...)), id{...}
+	mermaidLabelRe = regexp.MustCompile(`([\p{L}\p{N}_]+)\s*(?:\[\[|\[\(|\(\(|\[|\(|\{\{|\{|>)"?([^\]\)\}"]+)"?(?:\]\]|\)\]|\)\)|\]|\)|\}\}|\})`)
+	mermaidServRe  = regexp.MustCompile(`^(subgraph\b|end$|classDef\b|class\b|style\b|linkStyle\b|click\b|direction\b|%%)`)
+)
+
+// ValidMermaid проверяет диаграмму на грубые ошибки: заголовок graph или
+// flowchart с направлением, парные скобки и кавычки, а каждая строка —
+// описание узла, связь или служебная команда. Полного разбора синтаксиса
+// Mermaid здесь нет.
+func ValidMermaid(src string) bool {
+	lines := nonEmptyLines(src)
+	if len(lines) < 2 || !mermaidHeaderRe.MatchString(lines[0]) {
+		return false
+	}
+	depth := 0
+	for _, line := range lines[1:] {
+		if !balanced(line) {
+			return false
+		}
+		switch {
+		case mermaidServRe.MatchString(line):
+			if strings.HasPrefix(line, "subgraph") {
+				depth++
+			} else if line == "end" {
+				depth--
+			}
+		case mermaidEdgeRe.MatchString(line), mermaidNodeRe.MatchString(line), mermaidIDRe.MatchString(line):
+		default:
+			return false
+		}
+		if depth < 0 {
+			return false
+		}
+	}
+	return depth == 0
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// balanced проверяет парность скобок вне кавычек и закрытые кавычки
+func balanced(line string) bool {
+	pairs := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var stack []rune
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(' || r == '[' || r == '{':
+			stack = append(stack, r)
+		case r == ')' || r == ']' || r == '}':
+			if len(stack) == 0 || stack[len(stack)-1] != pairs[r] {
+				return false
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return !quoted && len(stack) == 0
+}
+
+// MermaidComponents подписи узлов диаграммы в порядке появления
+func MermaidComponents(src string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, line := range nonEmptyLines(src) {
+		for _, m := range mermaidLabelRe.FindAllStringSubmatch(line, -1) {
+			label := strings.TrimSpace(m[2])
+			if label == "" {
+				label = m[1]
+			}
+			if !seen[label] {
+				seen[label] = true
+				names = append(names, label)
+			}
+		}
+	}
+	return names
+}
+
+// CheckDiagrams оставляет корректные блоки ```mermaid как есть, а
+// некорректные заменяет списком компонентов, чтобы в результате не было
+// сломанной диаграммы
+func CheckDiagrams(md string) string {
+	var out, block []string
+	in := false
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !in && strings.EqualFold(trimmed, "```mermaid"):
+			in = true
+			block = block[:0]
+		case in && trimmed == "```":
+			in = false
+			src := strings.Join(block, "\n")
+			if ValidMermaid(src) {
+				out = append(out, "```mermaid", src, "```")
+				continue
+			}
+			out = append(out, "Компоненты (диаграмма не прошла проверку):", "")
+			for _, c := range MermaidComponents(src) {
+				out = append(out, "- "+c)
+			}
+		case in:
+			block = append(block, line)
+		default:
+			out = append(out, line)
+		}
+	}
+	if in {
+		out = append(out, "```mermaid")
+		out = append(out, block...)
+	}
+	return strings.Join(out, "\n")
+}
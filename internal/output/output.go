@@ -0,0 +1,331 @@
+// Package output сохраняет результаты обработки.
+package output
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Result данные одного обработанного файла для вывода
+type Result struct {
+	// Source путь исходного изображения
+	Source string
+	// Prompt имя промпта из библиотеки, выбранного меткой в имени файла
+	Prompt string
+	// Sources все снимки вопроса, собранного из нескольких частей
+	Sources []string
+	// Question распознанный текст
+	Question string
+	Answer   string
+	// CodeOnly в результат попадают только блоки кода ответа
+	CodeOnly bool
+	// Parent ссылка на ответ, продолжением которого является этот вопрос
+	Parent string
+	// Summary главное из ответа одной фразой, первой строкой результата
+	Summary string
+	// Hints разделы «### …» выводятся раскрывающимися блоками
+	Hints bool
+	// Known заметка о распознанной известной задаче, выводится цитатой в
+	// начале ответа
+	Known string
+	// Diagrams проверять диаграммы Mermaid и заменять некорректные списком
+	// компонентов
+	Diagrams bool
+	// Review итог проверки ответа, выводится разделом «## Review» в конце
+	Review string
+	// Languages названия языков решения; при нескольких разделы
+	// «## Solution (<язык>)» выводятся в этом порядке
+	Languages []string
+}
+
+// Sink сохраняет результат для файла name в директорию dir и возвращает
+// путь результата. При versioned существующий результат не перезаписывается.
+type Sink interface {
+	Save(dir, name string, r Result, versioned bool) (string, error)
+}
+
+// RenderMarkdown содержимое файла <name>.md: ответ модели как есть или,
+// при CodeOnly, только его блоки кода (если они есть). У продолжения
+// вопроса в начале ссылка на исходный ответ, а краткий ответ — первой
+// строкой жирным.
+func RenderMarkdown(r Result) []byte {
+	if r.Summary != "" {
+		line := "**" + r.Summary + "**\n\n"
+		r.Summary = ""
+		return append([]byte(line), RenderMarkdown(r)...)
+	}
+	if r.Parent != "" {
+		link := fmt.Sprintf("> Продолжение: [%s](%s)\n\n", filepath.Base(r.Parent), r.Parent)
+		r.Parent = ""
+		return append([]byte(link), RenderMarkdown(r)...)
+	}
+	if r.Prompt != "" {
+		line := "> Промпт: " + r.Prompt + "\n\n"
+		r.Prompt = ""
+		return append([]byte(line), RenderMarkdown(r)...)
+	}
+	if len(r.Sources) > 1 {
+		names := make([]string, len(r.Sources))
+		for i, s := range r.Sources {
+			names[i] = filepath.Base(s)
+		}
+		line := "> Снимки: " + strings.Join(names, ", ") + "\n\n"
+		r.Sources = nil
+		return append([]byte(line), RenderMarkdown(r)...)
+	}
+	if r.Review != "" {
+		review := "\n\n## Review\n\n" + strings.TrimSpace(r.Review) + "\n"
+		r.Review = ""
+		body := strings.TrimRight(string(RenderMarkdown(r)), "\n")
+		return []byte(body + review)
+	}
+	if r.Known != "" {
+		note := "> " + r.Known + "\n\n"
+		r.Known = ""
+		return append([]byte(note), RenderMarkdown(r)...)
+	}
+	if len(r.Languages) > 1 {
+		r.Answer = OrderSolutions(r.Answer, r.Languages)
+	}
+	if r.Hints {
+		r.Answer = CollapseSections(r.Answer)
+	}
+	if r.Diagrams {
+		r.Answer = CheckDiagrams(r.Answer)
+	}
+	if r.CodeOnly {
+		var parts []string
+		for _, b := range CodeBlocks(r.Answer) {
+			parts = append(parts, "```"+b.Lang+"\n"+b.Code+"\n```")
+		}
+		if len(parts) > 0 {
+			return []byte(strings.Join(parts, "\n\n") + "\n")
+		}
+	}
+	return []byte(r.Answer)
+}
+
+// Markdown сохраняет ответы в <name>.md
+type Markdown struct{}
+
+// Name имя результата для изображения: имя файла без расширения
+func Name(imagePath string) string {
+	base := filepath.Base(imagePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// VersionedPath подбирает первое свободное имя вида name-v2.md, name-v3.md...
+func VersionedPath(dir, name string) string {
+	p := filepath.Join(dir, name+".md")
+	for v := 2; ; v++ {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			return p
+		}
+		p = filepath.Join(dir, fmt.Sprintf("%s-v%d.md", name, v))
+	}
+}
+
+// Path путь, по которому Save запишет результат
+func (Markdown) Path(dir, name string, versioned bool) string {
+	if versioned {
+		return VersionedPath(dir, name)
+	}
+	return filepath.Join(dir, name+".md")
+}
+
+func (m Markdown) Save(dir, name string, r Result, versioned bool) (string, error) {
+	outputFilename := m.Path(dir, name, versioned)
+	err := os.WriteFile(outputFilename, RenderMarkdown(r), 0644)
+	if err != nil {
+		return "", err
+	}
+	return outputFilename, nil
+}
+
+// OCRTextPath файл распознанного текста результата name
+func OCRTextPath(dir, name string) string {
+	return filepath.Join(dir, name+".ocr.txt")
+}
+
+// LoadOCRText читает текст, сохранённый SaveOCRText; false, если его нет
+func LoadOCRText(dir, name string) (string, bool) {
+	data, err := os.ReadFile(OCRTextPath(dir, name))
+	if err != nil || len(data) == 0 {
+		return "", false
+	}
+	return string(data), true
+}
+
+// SaveOCRText сохраняет распознанный текст рядом с результатом
+func SaveOCRText(dir, name, text string) error {
+	return os.WriteFile(OCRTextPath(dir, name), []byte(text), 0644)
+}
+
+// CodeBlock блок кода из ответа
+type CodeBlock struct {
+	Lang string
+	Code string
+}
+
+// CodeBlocks блоки кода ответа в порядке появления; незакрытый блок не
+// учитывается
+func CodeBlocks(answer string) []CodeBlock {
+	var blocks []CodeBlock
+	var cur []string
+	lang, in := "", false
+	for _, line := range strings.Split(answer, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !in && strings.HasPrefix(trimmed, "```"):
+			in = true
+			lang = strings.TrimSpace(trimmed[3:])
+			cur = cur[:0]
+		case in && trimmed == "```":
+			in = false
+			blocks = append(blocks, CodeBlock{Lang: lang, Code: strings.Join(cur, "\n")})
+		case in:
+			cur = append(cur, line)
+		}
+	}
+	return blocks
+}
+
+// ExtractCode собирает содержимое всех блоков кода ```lang из ответа
+func ExtractCode(answer, lang string) string {
+	var code []string
+	for _, b := range CodeBlocks(answer) {
+		if strings.EqualFold(b.Lang, lang) {
+			code = append(code, b.Code)
+		}
+	}
+	return strings.Join(code, "\n\n")
+}
+
+// SaveCode записывает код рядом с результатом, заменяя расширение на
+// suffix: name.md → name.sql, name_test.go
+func SaveCode(resultPath, suffix, code string) (string, error) {
+	p := strings.TrimSuffix(resultPath, filepath.Ext(resultPath)) + suffix
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	return p, os.WriteFile(p, []byte(code), 0644)
+}
+
+var solutionHeadingRe = regexp.MustCompile(`(?i)^##\s+solution\s*\((.+?)\)\s*$`)
+
+// OrderSolutions выстраивает разделы «## Solution (<язык>)» в порядке
+// languages на месте первого из них; текст до и после остаётся на своих
+// местах. Для языка без раздела добавляется заглушка, разделы других
+// языков идут сразу за разделами из languages.
+func OrderSolutions(answer string, languages []string) string {
+	type section struct {
+		lang  string
+		lines []string
+	}
+	var sections []section
+	cur := &section{}
+	in := false
+	for _, line := range strings.Split(answer, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			in = !in
+		}
+		if !in && strings.HasPrefix(line, "## ") {
+			sections = append(sections, *cur)
+			cur = &section{}
+			if m := solutionHeadingRe.FindStringSubmatch(trimmed); m != nil {
+				cur.lang = strings.TrimSpace(m[1])
+			}
+		}
+		cur.lines = append(cur.lines, line)
+	}
+	sections = append(sections, *cur)
+
+	var before, after, extra []string
+	byLang := make(map[string]string)
+	seen := false
+	for _, s := range sections {
+		text := strings.TrimRight(strings.Join(s.lines, "\n"), "\n")
+		switch {
+		case text == "":
+		case s.lang == "" && !seen:
+			before = append(before, text)
+		case s.lang == "":
+			after = append(after, text)
+		default:
+			seen = true
+			key := strings.ToLower(s.lang)
+			if _, dup := byLang[key]; dup || !containsFold(languages, s.lang) {
+				extra = append(extra, text)
+			} else {
+				byLang[key] = text
+			}
+		}
+	}
+	parts := before
+	for _, lang := range languages {
+		if text, ok := byLang[strings.ToLower(lang)]; ok {
+			parts = append(parts, text)
+		} else {
+			parts = append(parts, "## Solution ("+lang+")\n\n_Решение не получено._")
+		}
+	}
+	parts = append(append(parts, extra...), after...)
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CollapseSections оборачивает каждый раздел «### заголовок» в
+// <details><summary>заголовок</summary>…</details>; текст до первого
+// раздела остаётся открытым, а заголовок «#» или «##» закрывает блок
+func CollapseSections(md string) string {
+	var out []string
+	open, in, lead := false, false, false
+	closeBlock := func() {
+		for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+			out = out[:len(out)-1]
+		}
+		out = append(out, "", "</details>", "")
+		open = false
+	}
+	for _, line := range strings.Split(strings.TrimRight(md, "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			in = !in
+		}
+		if !in && open && (strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ")) {
+			closeBlock()
+		}
+		if !in && strings.HasPrefix(line, "### ") {
+			if open {
+				closeBlock()
+			}
+			title := strings.TrimSpace(strings.TrimPrefix(line, "### "))
+			out = append(out, "<details><summary>"+html.EscapeString(title)+"</summary>", "")
+			open, lead = true, true
+			continue
+		}
+		// Пустые строки сразу после summary уже есть
+		if lead && strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead = false
+		out = append(out, line)
+	}
+	if open {
+		closeBlock()
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Из ответа SQL-задачи в <имя>.sql попадают только блоки ```sql, без
+// учёта регистра метки
+func TestExtractCodeSQL(t *testing.T) {
+	answer := "Запрос:\n\n```sql\nSELECT d.name, AVG(e.salary)\nFROM emp e JOIN dept d ON d.id = e.dept_id\nGROUP BY d.name;\n```\n\n" +
+		"Ожидаемый результат:\n\n```\n| name | avg |\n```\n\nС окном:\n\n```SQL\nSELECT name, RANK() OVER (ORDER BY salary) FROM emp;\n```\n\n```go\ndb.Query(q)\n```\n"
+	want := "SELECT d.name, AVG(e.salary)\nFROM emp e JOIN dept d ON d.id = e.dept_id\nGROUP BY d.name;\n\nSELECT name, RANK() OVER (ORDER BY salary) FROM emp;"
+	if got := ExtractCode(answer, "sql"); got != want {
+		t.Errorf("ExtractCode:\n%s", got)
+	}
+	if got := ExtractCode("```sql\nSELECT 1\n", "sql"); got != "" {
+		t.Errorf("незакрытый блок: %q", got)
+	}
+
+	dir := t.TempDir()
+	path, err := SaveCode(filepath.Join(dir, "task-v2.md"), ".sql", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if filepath.Base(path) != "task-v2.sql" || string(data) != want+"\n" {
+		t.Errorf("%s: %q", path, data)
+	}
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SplitMessage режет Markdown на сообщения для мессенджеров с лимитом
+// длины: первое не длиннее first символов, остальные — rest. Границы
+// выбираются между абзацами и блоками кода; блок кода, не помещающийся
+// целиком, режется по строкам, и каждая часть снова открывается и
+// закрывается ``` с тем же языком, чтобы подсветка не ломалась.
+func SplitMessage(md string, first, rest int) []string {
+	var chunks []string
+	var cur strings.Builder
+	limit := first
+	emit := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			chunks = append(chunks, s)
+			limit = rest
+		}
+		cur.Reset()
+	}
+	add := func(seg string) {
+		sep := ""
+		if cur.Len() > 0 {
+			sep = "\n\n"
+		}
+		if utf8.RuneCountInString(cur.String()+sep+seg) <= limit {
+			cur.WriteString(sep + seg)
+			return
+		}
+		emit()
+		cur.WriteString(seg)
+	}
+	for _, seg := range messageSegments(md) {
+		for _, part := range splitSegment(seg, rest) {
+			add(part)
+		}
+	}
+	emit()
+	return chunks
+}
+
+// messageSegments абзацы и блоки кода целиком
+func messageSegments(md string) []string {
+	var segs []string
+	var cur []string
+	inCode := false
+	flush := func() {
+		if len(cur) > 0 {
+			segs = append(segs, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		switch {
+		case fence && !inCode:
+			flush()
+			inCode = true
+			cur = append(cur, line)
+		case fence && inCode:
+			cur = append(cur, line)
+			flush()
+			inCode = false
+		case !inCode && strings.TrimSpace(line) == "":
+			flush()
+		default:
+			cur = append(cur, line)
+		}
+	}
+	flush()
+	return segs
+}
+
+// splitSegment режет слишком длинный абзац по строкам и словам, а блок
+// кода — по строкам с повтором ограды
+func splitSegment(seg string, limit int) []string {
+	if utf8.RuneCountInString(seg) <= limit {
+		return []string{seg}
+	}
+	lines := strings.Split(seg, "\n")
+	opening, closing := "", ""
+	if strings.HasPrefix(strings.TrimSpace(lines[0]), "```") {
+		opening, closing = strings.TrimSpace(lines[0])+"\n", "\n```"
+		lines = lines[1:]
+		if n := len(lines); n > 0 && strings.HasPrefix(strings.TrimSpace(lines[n-1]), "```") {
+			lines = lines[:n-1]
+		}
+	}
+	room := limit - utf8.RuneCountInString(opening+closing)
+	var parts []string
+	var cur []string
+	size := 0
+	flush := func() {
+		if len(cur) > 0 {
+			parts = append(parts, opening+strings.Join(cur, "\n")+closing)
+			cur, size = nil, 0
+		}
+	}
+	for _, line := range lines {
+		for _, piece := range hardSplit(line, room) {
+			n := utf8.RuneCountInString(piece) + 1
+			if size+n > room {
+				flush()
+			}
+			cur = append(cur, piece)
+			size += n
+		}
+	}
+	flush()
+	return parts
+}
+
+// hardSplit режет строку длиннее limit по пробелам, а слово длиннее
+// limit — по символам
+func hardSplit(line string, limit int) []string {
+	if limit <= 0 || utf8.RuneCountInString(line) <= limit {
+		return []string{line}
+	}
+	var parts []string
+	r := []rune(line)
+	for len(r) > limit {
+		cut := limit
+		for i := limit; i > limit/2; i-- {
+			if r[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, string(r[:cut]))
+		r = []rune(strings.TrimLeft(string(r[cut:]), " "))
+	}
+	return append(parts, string(r))
+}
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// stripped текст без оград блоков кода и пробельных символов: после
+// разбиения на сообщения он не должен меняться
+func stripped(md string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		b.WriteString(strings.Join(strings.Fields(line), ""))
+	}
+	return b.String()
+}
+
+// Сообщения не длиннее лимитов Discord в символах, а не байтах, и вместе
+// содержат весь текст
+func TestSplitMessageLimits(t *testing.T) {
+	var code strings.Builder
+	code.WriteString("Решение:\n\n```go\n")
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&code, "\tsum += nums[%d] // шаг %d\n", i, i)
+	}
+	code.WriteString("```\n\nСложность O(n).")
+
+	for _, tc := range []struct {
+		name string
+		md   string
+	}{
+		{"абзацы", strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n\n", 200)},
+		{"кириллица", strings.Repeat("Горутина — лёгкий поток, которым управляет среда выполнения Go. ", 150)},
+		{"длинное слово", strings.Repeat("ж", 9000)},
+		{"блок кода", code.String()},
+	} {
+		chunks := SplitMessage(tc.md, 4096, 2000)
+		if len(chunks) < 2 {
+			t.Errorf("%s: %d сообщений", tc.name, len(chunks))
+			continue
+		}
+		for i, c := range chunks {
+			limit := 2000
+			if i == 0 {
+				limit = 4096
+			}
+			if n := utf8.RuneCountInString(c); n > limit {
+				t.Errorf("%s: сообщение %d длиной %d > %d", tc.name, i, n, limit)
+			}
+		}
+		if got := stripped(strings.Join(chunks, "\n")); got != stripped(tc.md) {
+			t.Errorf("%s: текст изменился при разбиении", tc.name)
+		}
+	}
+}
+
+// Кириллица считается по символам: первое сообщение вмещает 3000
+// символов, хотя в байтах оно длиннее 4096
+func TestSplitMessageCountsRunes(t *testing.T) {
+	chunks := SplitMessage(strings.Repeat("слово ", 500), 4096, 2000)
+	if len(chunks) != 1 || len(chunks[0]) <= 4096 {
+		t.Errorf("%d сообщений, первое %d байт", len(chunks), len(chunks[0]))
+	}
+}
+
+// Блок кода, не помещающийся в сообщение, режется по строкам, и каждая
+// часть открывается ```go и закрывается ```
+func TestSplitMessageRefencesCode(t *testing.T) {
+	var code strings.Builder
+	code.WriteString("```go\n")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&code, "fmt.Println(%d) // строка\n", i)
+	}
+	code.WriteString("```")
+
+	chunks := SplitMessage("Код:\n\n"+code.String(), 4096, 2000)
+	if len(chunks) < 3 || !strings.HasPrefix(chunks[0], "Код:\n\n```go\n") {
+		t.Fatalf("%d сообщений", len(chunks))
+	}
+	next, parts := 0, 0
+	for i, c := range chunks {
+		open := false
+		for _, line := range strings.Split(c, "\n") {
+			switch {
+			case line == "```go" && !open:
+				open = true
+				parts++
+			case line == "```" && open:
+				open = false
+			case open:
+				// Строки кода не разрезаны и идут по порядку
+				if want := fmt.Sprintf("fmt.Println(%d) // строка", next); line != want {
+					t.Fatalf("сообщение %d: строка %q, want %q", i, line, want)
+				}
+				next++
+			case line != "" && line != "Код:":
+				t.Errorf("сообщение %d: строка %q вне блока кода", i, line)
+			}
+		}
+		if open {
+			t.Errorf("сообщение %d: блок кода не закрыт", i)
+		}
+	}
+	if next != 300 || parts < len(chunks) {
+		t.Errorf("строк кода %d, частей %d на %d сообщений", next, parts, len(chunks))
+	}
+}
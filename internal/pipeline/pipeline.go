@@ -0,0 +1,303 @@
+// Package pipeline обрабатывает файл по этапам: распознавание текста
+// снимка или речи, проверка, похож ли текст на вопрос, и ответ на каждый
+// вопрос из текста. Распознавание выполняет OCR, а ответ и его сохранение —
+// Answer, поэтому этапы подменяются по отдельности.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/ocr"
+	"hack_interview/internal/output"
+)
+
+// Этапы, длительности которых записываются в Timings
+const (
+	StageWait = "wait" // от конца распознавания до обработчика модели
+	StageOCR  = "ocr"
+)
+
+// Занятия обработчика, о которых сообщает Activity
+const (
+	ActivityIdle = "idle"
+	ActivityOCR  = "OCR"
+	ActivityLLM  = "LLM"
+)
+
+// Request параметры обработки одного файла
+type Request struct {
+	Path   string
+	Prompt string
+	// Versioned сохраняет результат под новым именем вместо перезаписи
+	Versioned bool
+	// OutputDir переопределяет директорию результатов Pipeline.OutputDir
+	OutputDir string
+	// DryRun только сообщает, что было бы сделано, без запросов к API
+	DryRun bool
+	// Timings куда записываются длительности этапов; может быть nil
+	Timings Timings
+	// Style стиль ответа вместо выбранного по конфигурации
+	Style string
+	// ReuseOCR берёт распознанный текст из <имя>.ocr.txt прошлой обработки,
+	// если он есть, вместо нового запроса OCR
+	ReuseOCR bool
+	// Force отправляет модели текст, который Filter отклонил бы
+	Force bool
+	// Parts пути остальных снимков того же вопроса; их текст добавляется
+	// после текста Path
+	Parts []string
+	// Name имя результата вместо имени файла Path, например у группы
+	Name string
+}
+
+// Sources пути всех снимков группы или nil для одного снимка
+func (r Request) Sources() []string {
+	if len(r.Parts) == 0 {
+		return nil
+	}
+	return append([]string{r.Path}, r.Parts...)
+}
+
+// Timings замеры этапов обработки файла
+type Timings interface {
+	// Track выполняет этап и записывает его длительность
+	Track(stage string, fn func() error) error
+	// Span записывает уже прошедший интервал этапа
+	Span(stage string, start, end time.Time, err error)
+	// AddOutput запоминает сохранённый файл результата
+	AddOutput(path string)
+}
+
+// noTimings замеры запроса без Timings
+type noTimings struct{}
+
+func (noTimings) Track(_ string, fn func() error) error    { return fn() }
+func (noTimings) Span(string, time.Time, time.Time, error) {}
+func (noTimings) AddOutput(string)                         {}
+
+// SkippedError файл пропущен после распознавания без запроса к модели:
+// текст не похож на вопрос. Это не ошибка обработки: файл не повторяется
+// и не считается неудачным.
+type SkippedError struct {
+	Reason string
+	Text   string
+}
+
+func (e *SkippedError) Error() string {
+	return "файл пропущен: " + e.Reason
+}
+
+// Pipeline связывает этапы обработки файла. OCR распознаёт текст, LLM и
+// Output — модель и сток результатов, которыми пользуется Answer.
+type Pipeline struct {
+	OCR    ocr.Provider
+	LLM    llm.Answerer
+	Output output.Sink
+	// FileTimeout срок обработки одного файла целиком
+	FileTimeout time.Duration
+	// OutputDir директория результатов, если её нет в запросе
+	OutputDir string
+
+	// Name имя результата запроса; nil — Request.Name или имя файла
+	Name func(r Request) string
+	// Begin дополняет контекст этапа (замеры, сведения для дампов
+	// запросов) и может задать Timings запроса
+	Begin func(ctx context.Context, r Request) (context.Context, Request)
+	// Activity отмечает занятие обработчика для строки состояния
+	Activity func(ctx context.Context, activity, path string)
+	// OnOCR получает итог распознавания: nil при успехе
+	OnOCR func(err error)
+	// Filter причина не отвечать на текст или ""; nil — отвечать всегда
+	Filter func(logger *slog.Logger, text string) string
+	// Split делит текст на вопросы; nil — весь текст один вопрос
+	Split func(ctx context.Context, logger *slog.Logger, text string) []string
+	// Answer получает и сохраняет ответ на вопрос text в результат name.
+	// fileCtx ограничен FileTimeout, ctx — нет.
+	Answer func(ctx, fileCtx context.Context, logger *slog.Logger, req Request, text, name string) (string, error)
+}
+
+// ResultName имя результата запроса r
+func (p *Pipeline) ResultName(r Request) string {
+	if p.Name != nil {
+		return p.Name(r)
+	}
+	if r.Name != "" {
+		return r.Name
+	}
+	return output.Name(r.Path)
+}
+
+// ResultDir директория результатов запроса r
+func (p *Pipeline) ResultDir(r Request) string {
+	if r.OutputDir != "" {
+		return r.OutputDir
+	}
+	return p.OutputDir
+}
+
+func (p *Pipeline) begin(ctx context.Context, r Request) (context.Context, Request) {
+	if p.Begin != nil {
+		ctx, r = p.Begin(ctx, r)
+	}
+	if r.Timings == nil {
+		r.Timings = noTimings{}
+	}
+	return ctx, r
+}
+
+func (p *Pipeline) activity(ctx context.Context, activity, path string) {
+	if p.Activity != nil {
+		p.Activity(ctx, activity, path)
+	}
+}
+
+// Process распознаёт текст изображения или речь аудиозаписи, получает
+// ответ модели и сохраняет его. Возвращает путь к сохранённому результату.
+//
+// Вся обработка ограничена FileTimeout; таймауты отдельных запросов
+// выводятся из того же контекста, поэтому срабатывает более ранний срок.
+// Распознанный текст сохраняется рядом с результатом (<имя>.ocr.txt) и
+// остаётся, даже если ответ получить не удалось.
+func (p *Pipeline) Process(ctx context.Context, req Request) (string, error) {
+	rec, out, err := p.Recognize(ctx, req)
+	if rec == nil {
+		return out, err
+	}
+	return p.Respond(ctx, rec)
+}
+
+// Recognized распознанный файл, ждущий ответа модели
+type Recognized struct {
+	Req    Request
+	Logger *slog.Logger
+	Text   string
+	// Spent сколько из FileTimeout ушло на распознавание
+	Spent time.Duration
+	// Done когда закончилось распознавание: ожидание этапа модели после
+	// него в FileTimeout не входит
+	Done time.Time
+}
+
+// Recognize первая половина Process: распознавание и проверка, похож ли
+// текст на вопрос. Возвращает nil, если файл обработан уже на этом этапе:
+// ошибкой, пропуском (*SkippedError) или в режиме DryRun, — тогда итог в
+// out и err.
+func (p *Pipeline) Recognize(ctx context.Context, req Request) (*Recognized, string, error) {
+	logger := slog.With("file", req.Path)
+	logger.Info(msg.T("file.processing"))
+	ctx, req = p.begin(ctx, req)
+	tm := req.Timings
+	dir, name := p.ResultDir(req), p.ResultName(req)
+
+	if req.DryRun {
+		out := output.Markdown{}.Path(dir, name, req.Versioned)
+		logger.Info(msg.T("file.dry-run"), "output", out)
+		return nil, out, nil
+	}
+
+	started := time.Now()
+	fileCtx, cancel := context.WithTimeout(ctx, p.FileTimeout)
+	defer cancel()
+
+	p.activity(ctx, ActivityOCR, req.Path)
+	defer p.activity(ctx, ActivityIdle, "")
+	text, reused := "", false
+	if req.ReuseOCR {
+		text, reused = output.LoadOCRText(dir, name)
+		if reused {
+			logger.Info(msg.T("file.reused-ocr"))
+		}
+	}
+	if !reused {
+		failed := req.Path
+		err := tm.Track(StageOCR, func() error {
+			var texts []string
+			for _, path := range append([]string{req.Path}, req.Parts...) {
+				t, err := p.OCR.ExtractText(fileCtx, path)
+				if err != nil {
+					failed = path
+					return err
+				}
+				texts = append(texts, strings.TrimSpace(t))
+			}
+			text = strings.Join(texts, "\n\n")
+			return nil
+		})
+		if p.OnOCR != nil {
+			p.OnOCR(err)
+		}
+		if err != nil {
+			metrics.StageFailed(metrics.StageOCR)
+			return nil, "", p.TimeoutError(ctx, fileCtx, fmt.Errorf("ошибка OCR (%s): %w", failed, err))
+		}
+		if err := output.SaveOCRText(dir, name, text); err != nil {
+			logger.Warn(msg.T("file.ocr-save-failed"), "error", err)
+		} else {
+			tm.AddOutput(output.OCRTextPath(dir, name))
+		}
+	}
+
+	if !req.Force && p.Filter != nil {
+		if reason := p.Filter(logger, text); reason != "" {
+			logger.Info(msg.T("filter.not-question-skipped"), "reason", reason)
+			return nil, "", &SkippedError{Reason: reason, Text: text}
+		}
+	}
+	now := time.Now()
+	return &Recognized{Req: req, Logger: logger, Text: text, Spent: now.Sub(started), Done: now}, "", nil
+}
+
+// Respond вторая половина Process: ответ модели на распознанный текст,
+// по вопросу за раз, если Split разбил его на несколько
+func (p *Pipeline) Respond(ctx context.Context, rec *Recognized) (string, error) {
+	ctx, req := p.begin(ctx, rec.Req)
+	logger, text := rec.Logger, rec.Text
+	if now := time.Now(); now.Sub(rec.Done) >= time.Millisecond {
+		req.Timings.Span(StageWait, rec.Done, now, nil)
+	}
+	fileCtx, cancel := context.WithTimeout(ctx, p.FileTimeout-rec.Spent)
+	defer cancel()
+	defer p.activity(ctx, ActivityIdle, "")
+
+	segments := []string{text}
+	if p.Split != nil {
+		p.activity(ctx, ActivityLLM, req.Path)
+		segments = p.Split(fileCtx, logger, text)
+	}
+	name := p.ResultName(req)
+	if len(segments) <= 1 {
+		return p.Answer(ctx, fileCtx, logger, req, text, name)
+	}
+	var first string
+	for i, segment := range segments {
+		out, err := p.Answer(ctx, fileCtx, logger.With("question", i+1), req, segment, fmt.Sprintf("%s-q%d", name, i+1))
+		if err != nil {
+			return first, err
+		}
+		if first == "" {
+			first = out
+		}
+	}
+	return first, nil
+}
+
+// TimeoutError помечает ошибку как превышение FileTimeout, если истёк
+// срок файла fileCtx, а не отменён родительский контекст
+func (p *Pipeline) TimeoutError(parent, fileCtx context.Context, err error) error {
+	if parent.Err() == nil && errors.Is(fileCtx.Err(), context.DeadlineExceeded) {
+		if errors.Is(err, errs.ErrTimeout) {
+			return fmt.Errorf("превышено время обработки файла (%v): %w", p.FileTimeout, err)
+		}
+		return fmt.Errorf("превышено время обработки файла (%v): %w: %w", p.FileTimeout, errs.ErrTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,231 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/output"
+)
+
+// fakeOCR текст по имени файла и число запросов
+type fakeOCR struct {
+	mu    sync.Mutex
+	texts map[string]string
+	calls int
+	delay time.Duration
+}
+
+func (o *fakeOCR) ExtractText(ctx context.Context, path string) (string, error) {
+	o.mu.Lock()
+	o.calls++
+	o.mu.Unlock()
+	if o.delay > 0 {
+		select {
+		case <-time.After(o.delay):
+		case <-ctx.Done():
+			return "", errs.FromTransport(ctx.Err())
+		}
+	}
+	text, ok := o.texts[filepath.Base(path)]
+	if !ok {
+		return "", errs.ErrNoText
+	}
+	return text + "\n", nil
+}
+
+// answered вызов Answer
+type answered struct {
+	text, name string
+}
+
+// testPipeline конвейер с fakeOCR и Answer, который записывает вызовы
+func testPipeline(t *testing.T, ocr *fakeOCR) (*Pipeline, *[]answered) {
+	t.Helper()
+	var calls []answered
+	var p *Pipeline
+	p = &Pipeline{
+		OCR:         ocr,
+		FileTimeout: time.Second,
+		OutputDir:   t.TempDir(),
+		Answer: func(_, _ context.Context, _ *slog.Logger, req Request, text, name string) (string, error) {
+			calls = append(calls, answered{text, name})
+			return filepath.Join(p.ResultDir(req), name+".md"), nil
+		},
+	}
+	return p, &calls
+}
+
+func TestProcessJoinsPartsAndSavesText(t *testing.T) {
+	p, calls := testPipeline(t, &fakeOCR{texts: map[string]string{"q.png": "Условие", "q-2.png": "Примеры"}})
+	out, err := p.Process(context.Background(), Request{Path: "in/q.png", Parts: []string{"in/q-2.png"}, Name: "q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != filepath.Join(p.OutputDir, "q.md") {
+		t.Errorf("out = %q", out)
+	}
+	if len(*calls) != 1 || (*calls)[0] != (answered{"Условие\n\nПримеры", "q"}) {
+		t.Errorf("Answer: %+v", *calls)
+	}
+	if text, ok := output.LoadOCRText(p.OutputDir, "q"); !ok || text != "Условие\n\nПримеры" {
+		t.Errorf("сохранённый текст %q", text)
+	}
+}
+
+func TestProcessReuseOCR(t *testing.T) {
+	ocr := &fakeOCR{texts: map[string]string{"q.png": "новый"}}
+	p, calls := testPipeline(t, ocr)
+	if err := output.SaveOCRText(p.OutputDir, "q", "прежний"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Process(context.Background(), Request{Path: "in/q.png", ReuseOCR: true}); err != nil {
+		t.Fatal(err)
+	}
+	if ocr.calls != 0 || (*calls)[0].text != "прежний" {
+		t.Errorf("OCR %d раз, текст %q", ocr.calls, (*calls)[0].text)
+	}
+}
+
+func TestProcessFilter(t *testing.T) {
+	p, calls := testPipeline(t, &fakeOCR{texts: map[string]string{"q.png": "ок"}})
+	p.Filter = func(_ *slog.Logger, text string) string {
+		if len([]rune(text)) < 5 {
+			return "слишком короткий"
+		}
+		return ""
+	}
+	_, err := p.Process(context.Background(), Request{Path: "in/q.png"})
+	var skipped *SkippedError
+	if !errors.As(err, &skipped) || skipped.Reason != "слишком короткий" || skipped.Text != "ок" {
+		t.Fatalf("err = %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("Answer вызван для пропущенного текста")
+	}
+	if _, err := p.Process(context.Background(), Request{Path: "in/q.png", Force: true}); err != nil || len(*calls) != 1 {
+		t.Errorf("Force: %v, %+v", err, *calls)
+	}
+}
+
+func TestProcessSplit(t *testing.T) {
+	p, calls := testPipeline(t, &fakeOCR{texts: map[string]string{"q.png": "1) A 2) B"}})
+	var activities []string
+	p.Activity = func(_ context.Context, activity, _ string) { activities = append(activities, activity) }
+	p.Split = func(context.Context, *slog.Logger, string) []string { return []string{"A", "B"} }
+
+	out, err := p.Process(context.Background(), Request{Path: "in/q.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []answered{{"A", "q-q1"}, {"B", "q-q2"}}
+	if len(*calls) != 2 || (*calls)[0] != want[0] || (*calls)[1] != want[1] {
+		t.Errorf("Answer: %+v", *calls)
+	}
+	if filepath.Base(out) != "q-q1.md" {
+		t.Errorf("out = %q, want результат первого вопроса", out)
+	}
+	if got := strings.Join(activities, ","); got != "OCR,idle,LLM,idle" {
+		t.Errorf("занятия %s", got)
+	}
+}
+
+func TestProcessDryRun(t *testing.T) {
+	ocr := &fakeOCR{}
+	p, calls := testPipeline(t, ocr)
+	out, err := p.Process(context.Background(), Request{Path: "in/q#teach.png", DryRun: true, Name: "q"})
+	if err != nil || out != filepath.Join(p.OutputDir, "q.md") {
+		t.Errorf("out = %q, %v", out, err)
+	}
+	if ocr.calls != 0 || len(*calls) != 0 {
+		t.Error("в режиме DryRun были запросы")
+	}
+}
+
+// Ошибка OCR по истечении FileTimeout помечается как таймаут файла, а
+// отмена родительского контекста — нет
+func TestProcessFileTimeout(t *testing.T) {
+	p, _ := testPipeline(t, &fakeOCR{delay: time.Second, texts: map[string]string{"q.png": "x"}})
+	p.FileTimeout = 20 * time.Millisecond
+	_, err := p.Process(context.Background(), Request{Path: "in/q.png"})
+	if !errors.Is(err, errs.ErrTimeout) || !strings.Contains(err.Error(), "превышено время обработки файла") {
+		t.Errorf("err = %v", err)
+	}
+	if _, statErr := os.Stat(output.OCRTextPath(p.OutputDir, "q")); statErr == nil {
+		t.Error("текст сохранён после ошибки OCR")
+	}
+
+	p.FileTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.Process(ctx, Request{Path: "in/q.png"})
+	if err == nil || strings.Contains(err.Error(), "превышено время обработки файла") {
+		t.Errorf("отмена родителя: %v", err)
+	}
+}
+
+// recordingTimings записывает этапы
+type recordingTimings struct {
+	stages  []string
+	outputs []string
+}
+
+func (r *recordingTimings) Track(stage string, fn func() error) error {
+	r.stages = append(r.stages, stage)
+	return fn()
+}
+func (r *recordingTimings) Span(stage string, _, _ time.Time, _ error) {
+	r.stages = append(r.stages, stage)
+}
+func (r *recordingTimings) AddOutput(path string) { r.outputs = append(r.outputs, path) }
+
+// Ожидание между распознаванием и ответом учитывается как этап wait, а
+// Respond получает остаток FileTimeout
+func TestRecognizeRespond(t *testing.T) {
+	p, calls := testPipeline(t, &fakeOCR{texts: map[string]string{"q.png": "вопрос"}})
+	tm := &recordingTimings{}
+	var begun int
+	p.Begin = func(ctx context.Context, r Request) (context.Context, Request) {
+		begun++
+		if r.Timings == nil {
+			r.Timings = tm
+		}
+		return ctx, r
+	}
+	var deadline time.Duration
+	p.Answer = func(_, fileCtx context.Context, _ *slog.Logger, _ Request, text, name string) (string, error) {
+		d, _ := fileCtx.Deadline()
+		deadline = time.Until(d)
+		*calls = append(*calls, answered{text, name})
+		return "", nil
+	}
+
+	rec, _, err := p.Recognize(context.Background(), Request{Path: "in/q.png"})
+	if err != nil || rec == nil {
+		t.Fatalf("rec = %v, err = %v", rec, err)
+	}
+	rec.Spent = 900 * time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	if _, err := p.Respond(context.Background(), rec); err != nil {
+		t.Fatal(err)
+	}
+	if begun != 2 || len(*calls) != 1 {
+		t.Errorf("Begin %d раз, Answer %+v", begun, *calls)
+	}
+	if got := strings.Join(tm.stages, ","); got != "ocr,wait" {
+		t.Errorf("этапы %s", got)
+	}
+	if len(tm.outputs) != 1 || !strings.HasSuffix(tm.outputs[0], "q.ocr.txt") {
+		t.Errorf("outputs %v", tm.outputs)
+	}
+	if deadline > 100*time.Millisecond {
+		t.Errorf("срок ответа %v, want остаток FileTimeout", deadline)
+	}
+}
@@ -0,0 +1,135 @@
+// Package problems узнаёт в тексте вопроса известные задачи с LeetCode,
+// чтобы подсказать канонический подход и направить на него модель.
+package problems
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Problem известная задача и её признаки
+type Problem struct {
+	Name     string   `yaml:"name"`
+	Number   int      `yaml:"number"`
+	Approach string   `yaml:"approach"`
+	Phrases  []string `yaml:"phrases"`
+	Keywords []string `yaml:"keywords"`
+}
+
+// Title название с номером: «LC 146 LRU Cache»
+func (p Problem) Title() string {
+	if p.Number > 0 {
+		return fmt.Sprintf("LC %d %s", p.Number, p.Name)
+	}
+	return p.Name
+}
+
+// fullScore очки полной уверенности: характерная фраза и несколько слов.
+// Фразы и слова обычно даны на двух языках, и текст на одном из них не
+// набирает всех очков задачи, поэтому доля от максимума не подходит.
+const fullScore = 6
+
+// Match найденная задача и уверенность от 0 до 1
+type Match struct {
+	Problem    Problem
+	Confidence float64
+}
+
+//go:embed problems.yml
+var builtin []byte
+
+// Builtin встроенный набор задач
+func Builtin() []Problem {
+	var list []Problem
+	if err := yaml.Unmarshal(builtin, &list); err != nil {
+		panic(fmt.Sprintf("problems.yml: %v", err))
+	}
+	return list
+}
+
+// Load встроенный набор, дополненный задачами из YAML-файла. Задача из
+// файла с тем же номером или названием заменяет встроенную.
+func Load(path string) ([]Problem, error) {
+	list := Builtin()
+	if path == "" {
+		return list, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var extra []Problem
+	if err := yaml.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, e := range extra {
+		replaced := false
+		for i, p := range list {
+			if (e.Number > 0 && p.Number == e.Number) || strings.EqualFold(p.Name, e.Name) {
+				list[i], replaced = e, true
+				break
+			}
+		}
+		if !replaced {
+			list = append(list, e)
+		}
+	}
+	return list, nil
+}
+
+var spaceRe = regexp.MustCompile(`\s+`)
+
+// Find лучшая задача с уверенностью не ниже threshold
+func Find(text string, list []Problem, threshold float64) (Match, bool) {
+	lower := spaceRe.ReplaceAllString(strings.ToLower(text), " ")
+	var best Match
+	for _, p := range list {
+		score := 0
+		for _, ph := range p.Phrases {
+			if containsPrefix(lower, strings.ToLower(ph)) {
+				score += 2
+			}
+		}
+		for _, kw := range p.Keywords {
+			if containsPrefix(lower, strings.ToLower(kw)) {
+				score++
+			}
+		}
+		if c := min(float64(score)/fullScore, 1); c > best.Confidence {
+			best = Match{Problem: p, Confidence: c}
+		}
+	}
+	return best, best.Confidence > 0 && best.Confidence >= threshold
+}
+
+// containsPrefix ищет kw с начала слова, чтобы «put» не находилось в
+// «input»
+func containsPrefix(text, kw string) bool {
+	if kw == "" {
+		return false
+	}
+	first, _ := utf8.DecodeRuneInString(kw)
+	for i := 0; ; {
+		j := strings.Index(text[i:], kw)
+		if j < 0 {
+			return false
+		}
+		j += i
+		prev, _ := utf8.DecodeLastRuneInString(text[:j])
+		if j == 0 || !isWord(first) || !isWord(prev) {
+			return true
+		}
+		i = j + 1
+	}
+}
+
+func isWord(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,84 @@
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeychainPrefix начало ссылки на запись хранилища ОС:
+// keychain:<служба>/<учётная запись>
+const KeychainPrefix = "keychain:"
+
+// ErrNotFound в хранилище нет записи
+var ErrNotFound = errors.New("запись не найдена")
+
+// Store хранилище учётных данных: Keychain в macOS, Credential Manager в
+// Windows, secret-service в Linux
+type Store interface {
+	Get(service, account string) (string, error)
+	Set(service, account, value string) error
+}
+
+// Keyring хранилище ОС
+type Keyring struct{}
+
+func (Keyring) Get(service, account string) (string, error) {
+	v, err := keyring.Get(service, account)
+	return v, keyringError(err)
+}
+
+func (Keyring) Set(service, account, value string) error {
+	return keyringError(keyring.Set(service, account, value))
+}
+
+func keyringError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, keyring.ErrNotFound):
+		return ErrNotFound
+	case runtime.GOOS == "linux":
+		// Без сеанса D-Bus или службы secret-service (сервер без рабочего
+		// стола) go-keyring возвращает ошибку D-Bus
+		return fmt.Errorf("служба secret-service недоступна (%v): на машине без рабочего стола "+
+			"храните ключ в config.yml открытым текстом или зашифрованным (config encrypt-key)", err)
+	}
+	return err
+}
+
+// IsKeychainRef сообщает, что value — ссылка keychain:
+func IsKeychainRef(value string) bool {
+	return strings.HasPrefix(value, KeychainPrefix)
+}
+
+// ParseKeychainRef служба и учётная запись ссылки keychain:<служба>/<запись>;
+// служба может содержать /, учётная запись — нет
+func ParseKeychainRef(ref string) (service, account string, err error) {
+	s := strings.TrimPrefix(ref, KeychainPrefix)
+	i := strings.LastIndex(s, "/")
+	if !IsKeychainRef(ref) || i <= 0 || i == len(s)-1 {
+		return "", "", fmt.Errorf("ссылка на хранилище должна иметь вид %s<служба>/<запись>, а не %q", KeychainPrefix, ref)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+// Lookup значение по ссылке keychain:; ошибка называет службу и запись
+func Lookup(store Store, ref string) (string, error) {
+	service, account, err := ParseKeychainRef(ref)
+	if err != nil {
+		return "", err
+	}
+	v, err := store.Get(service, account)
+	if errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("в хранилище ключей нет записи: служба %q, учётная запись %q (добавьте её командой config set-secret %s/%s): %w",
+			service, account, service, account, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("хранилище ключей, служба %q, учётная запись %q: %w", service, account, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mapStore хранилище в памяти по паре служба и учётная запись
+type mapStore map[[2]string]string
+
+func (m mapStore) Get(service, account string) (string, error) {
+	v, ok := m[[2]string{service, account}]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (m mapStore) Set(service, account, value string) error {
+	m[[2]string{service, account}] = value
+	return nil
+}
+
+// Служба может содержать /, учётная запись отделяется последним /
+func TestParseKeychainRef(t *testing.T) {
+	for _, tc := range []struct {
+		ref, service, account string
+		ok                    bool
+	}{
+		{"keychain:hack_interview/gemini", "hack_interview", "gemini", true},
+		{"keychain:hack_interview/prod/gemini", "hack_interview/prod", "gemini", true},
+		{"keychain:a/b", "a", "b", true},
+		{"keychain:hack_interview", "", "", false},
+		{"keychain:/gemini", "", "", false},
+		{"keychain:hack_interview/", "", "", false},
+		{"keychain:", "", "", false},
+		{"hack_interview/gemini", "", "", false},
+		{"Keychain:hack_interview/gemini", "", "", false},
+	} {
+		service, account, err := ParseKeychainRef(tc.ref)
+		if tc.ok != (err == nil) || service != tc.service || account != tc.account {
+			t.Errorf("%q: %q, %q, %v", tc.ref, service, account, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tc.ref) {
+			t.Errorf("%q: ошибка не называет ссылку: %v", tc.ref, err)
+		}
+	}
+}
+
+// Ошибка Lookup называет службу и запись, отсутствие записи отличимо от
+// сбоя хранилища
+func TestLookup(t *testing.T) {
+	store := mapStore{{"hack_interview/prod", "gemini"}: "gemini-key"}
+	if v, err := Lookup(store, "keychain:hack_interview/prod/gemini"); err != nil || v != "gemini-key" {
+		t.Errorf("Lookup = %q, %v", v, err)
+	}
+
+	_, err := Lookup(store, "keychain:hack_interview/ocr")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("нет записи: %v", err)
+	}
+	for _, want := range []string{`служба "hack_interview"`, `учётная запись "ocr"`, "config set-secret hack_interview/ocr"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("в ошибке нет %s: %v", want, err)
+		}
+	}
+
+	if _, err := Lookup(store, "keychain:gemini"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("неверная ссылка: %v", err)
+	}
+	broken := errors.New("хранилище заблокировано")
+	if _, err := Lookup(brokenStore{broken}, "keychain:hack_interview/gemini"); !errors.Is(err, broken) || errors.Is(err, ErrNotFound) ||
+		!strings.Contains(err.Error(), `"hack_interview"`) || !strings.Contains(err.Error(), `"gemini"`) {
+		t.Errorf("сбой хранилища: %v", err)
+	}
+}
+
+// brokenStore хранилище, которое всегда отвечает ошибкой
+type brokenStore struct{ err error }
+
+func (s brokenStore) Get(string, string) (string, error) { return "", s.err }
+func (s brokenStore) Set(string, string, string) error   { return s.err }
@@ -0,0 +1,117 @@
+// Package secret шифрует ключи API для хранения в config.yml: парольная
+// фраза через scrypt даёт ключ NaCl secretbox. Зашифрованное значение —
+// строка enc:v1:<base64>, которую можно вставить в YAML вместо ключа.
+package secret
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Prefix начало зашифрованного значения
+const Prefix = "enc:v1:"
+
+var (
+	// ErrWrongPassphrase значение цело, но зашифровано другой парольной
+	// фразой
+	ErrWrongPassphrase = errors.New("неверная парольная фраза")
+	// ErrCorrupted значение обрезано или изменено
+	ErrCorrupted = errors.New("зашифрованное значение повреждено")
+)
+
+const (
+	saltSize  = 16
+	checkSize = 8
+	nonceSize = 24
+	sumSize   = 4
+	// Параметры scrypt: около 50 мс и 32 МБ на ключ
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// IsEncrypted сообщает, что value зашифровано Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// Encrypt шифрует plaintext парольной фразой
+func Encrypt(plaintext, passphrase string) (string, error) {
+	salt := make([]byte, saltSize)
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return "", err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	// salt | проверка ключа | nonce | шифротекст | crc32 всего предыдущего
+	blob := append(salt, keyCheck(key)...)
+	blob = append(blob, nonce[:]...)
+	blob = secretbox.Seal(blob, []byte(plaintext), &nonce, key)
+	blob = append(blob, checksum(blob)...)
+	return Prefix + base64.RawStdEncoding.EncodeToString(blob), nil
+}
+
+// Decrypt расшифровывает значение Encrypt. Ошибка — ErrWrongPassphrase,
+// если значение цело, но фраза другая, иначе ErrCorrupted.
+func Decrypt(value, passphrase string) (string, error) {
+	blob, err := base64.RawStdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(value, Prefix)))
+	if err != nil || !IsEncrypted(value) || len(blob) < saltSize+checkSize+nonceSize+secretbox.Overhead+sumSize {
+		return "", ErrCorrupted
+	}
+	body, sum := blob[:len(blob)-sumSize], blob[len(blob)-sumSize:]
+	if !hmac.Equal(sum, checksum(body)) {
+		return "", ErrCorrupted
+	}
+	salt, check := body[:saltSize], body[saltSize:saltSize+checkSize]
+	var nonce [nonceSize]byte
+	copy(nonce[:], body[saltSize+checkSize:])
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(check, keyCheck(key)) {
+		return "", ErrWrongPassphrase
+	}
+	plaintext, ok := secretbox.Open(nil, body[saltSize+checkSize+nonceSize:], &nonce, key)
+	if !ok {
+		return "", ErrCorrupted
+	}
+	return string(plaintext), nil
+}
+
+func deriveKey(passphrase string, salt []byte) (*[32]byte, error) {
+	k, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
+
+// keyCheck короткая метка ключа: по ней неверная фраза отличается от
+// повреждённого шифротекста
+func keyCheck(key *[32]byte) []byte {
+	m := hmac.New(sha256.New, key[:])
+	m.Write([]byte("hack_interview key check"))
+	return m.Sum(nil)[:checkSize]
+}
+
+func checksum(b []byte) []byte {
+	return binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(b))
+}
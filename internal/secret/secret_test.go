@@ -0,0 +1,66 @@
+package secret
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	value, err := Encrypt("AIza-test-key", "фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(value) || strings.Contains(value, "AIza-test-key") {
+		t.Fatalf("значение %q", value)
+	}
+	if got, err := Decrypt(value, "фраза"); err != nil || got != "AIza-test-key" {
+		t.Errorf("Decrypt = %q, %v", got, err)
+	}
+	// Значение из YAML может прийти с пробелами и переводом строки
+	if got, err := Decrypt(value+"\n", "фраза"); err != nil || got != "AIza-test-key" {
+		t.Errorf("с переводом строки: %q, %v", got, err)
+	}
+	// Соль и nonce случайны: одинаковые ключи шифруются по-разному
+	if again, _ := Encrypt("AIza-test-key", "фраза"); again == value {
+		t.Error("повторное шифрование дало то же значение")
+	}
+}
+
+// Неверная фраза отличается от повреждённого значения
+func TestDecryptErrors(t *testing.T) {
+	value, err := Encrypt("AIza-test-key", "фраза")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decrypt(value, "другая"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("неверная фраза: %v", err)
+	}
+
+	blob, _ := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	flipped := append([]byte(nil), blob...)
+	flipped[len(flipped)-sumSize-1] ^= 1
+	for name, v := range map[string]string{
+		"изменён байт":    Prefix + base64.RawStdEncoding.EncodeToString(flipped),
+		"обрезано":        value[:len(value)-10],
+		"пусто":           Prefix,
+		"не base64":       Prefix + "!!!",
+		"без префикса":    strings.TrimPrefix(value, Prefix),
+		"другая версия":   "enc:v2:" + strings.TrimPrefix(value, Prefix),
+		"слишком коротко": Prefix + base64.RawStdEncoding.EncodeToString(blob[:20]),
+	} {
+		for _, pass := range []string{"фраза", "другая"} {
+			if _, err := Decrypt(v, pass); !errors.Is(err, ErrCorrupted) {
+				t.Errorf("%s, фраза %q: %v", name, pass, err)
+			}
+		}
+	}
+
+	// Изменённый шифротекст с верной контрольной суммой ловит secretbox
+	forged := append([]byte(nil), flipped[:len(flipped)-sumSize]...)
+	forged = append(forged, checksum(forged)...)
+	if _, err := Decrypt(Prefix+base64.RawStdEncoding.EncodeToString(forged), "фраза"); !errors.Is(err, ErrCorrupted) {
+		t.Errorf("подделано: %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package speech распознаёт речь в аудиофайлах: через OpenAI Whisper API
+// или локальный whisper.cpp. Длинные записи режутся ffmpeg на части.
+package speech
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/metrics"
+)
+
+// Extensions расширения аудиофайлов
+var Extensions = []string{".wav", ".mp3", ".m4a"}
+
+// IsAudio сообщает, аудиофайл ли это, по расширению
+func IsAudio(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Transcriber распознаёт речь в аудиофайле
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioPath string) (string, error)
+}
+
+// DefaultOpenAIURL адрес OpenAI API; к нему добавляется
+// /v1/audio/transcriptions
+const DefaultOpenAIURL = "https://api.openai.com"
+
+// maxUploadSize предел файла в Whisper API
+const maxUploadSize = 25 << 20
+
+// Splitter режет запись на части по Seconds секунд в формате 16 кГц моно
+// WAV, который понимают оба бэкенда
+type Splitter struct {
+	// FFmpeg путь к ffmpeg; пустой — ffmpeg из PATH
+	FFmpeg string
+	// Seconds длина части
+	Seconds int
+}
+
+// Split режет запись в dir и возвращает части по порядку
+func (s Splitter) Split(ctx context.Context, audioPath, dir string) ([]string, error) {
+	bin := s.FFmpeg
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	seconds := s.Seconds
+	if seconds <= 0 {
+		seconds = 600
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "-hide_banner", "-loglevel", "error", "-i", audioPath,
+		"-ac", "1", "-ar", "16000", "-f", "segment", "-segment_time", strconv.Itoa(seconds),
+		filepath.Join(dir, "part%03d.wav"))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	parts, err := filepath.Glob(filepath.Join(dir, "part*.wav"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(parts)
+	return parts, nil
+}
+
+// Available сообщает, найден ли ffmpeg
+func (s Splitter) Available() bool {
+	bin := s.FFmpeg
+	if bin == "" {
+		bin = "ffmpeg"
+	}
+	_, err := exec.LookPath(bin)
+	return err == nil
+}
+
+// OpenAI клиент Whisper API
+type OpenAI struct {
+	APIKey string
+	// BaseURL адрес API; пустой — DefaultOpenAIURL
+	BaseURL string
+	Headers map[string]string
+	// Model по умолчанию whisper-1
+	Model    string
+	Language string
+	Client   *resty.Client
+	// Timeout срок одного запроса; 0 — только срок ctx
+	Timeout time.Duration
+	// Splitter режет записи больше предела API; без ffmpeg такие записи
+	// не обрабатываются
+	Splitter Splitter
+}
+
+// Transcribe отправляет запись целиком, а больше 25 МБ — частями
+func (o *OpenAI) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	fi, err := os.Stat(audioPath)
+	if err != nil {
+		return "", err
+	}
+	if fi.Size() <= maxUploadSize {
+		text, err := o.transcribeFile(ctx, audioPath)
+		if err != nil {
+			return "", err
+		}
+		return cleanTranscript(text)
+	}
+	if !o.Splitter.Available() {
+		return "", fmt.Errorf("запись больше %d МБ, а ffmpeg для нарезки не найден", maxUploadSize>>20)
+	}
+	return transcribeParts(ctx, o.Splitter, audioPath, o.transcribeFile)
+}
+
+func (o *OpenAI) transcribeFile(ctx context.Context, path string) (string, error) {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOpenAIURL
+	}
+	model := o.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+	client := o.Client
+	if client == nil {
+		client = resty.New()
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	form := map[string]string{"model": model, "response_format": "text"}
+	if o.Language != "" {
+		form["language"] = o.Language
+	}
+	start := time.Now()
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeaders(o.Headers).
+		SetAuthToken(o.APIKey).
+		SetFile("file", path).
+		SetFormData(form).
+		Post(strings.TrimSuffix(baseURL, "/") + "/v1/audio/transcriptions")
+	metrics.ObserveRequest(metrics.ProviderWhisper, time.Since(start), err)
+	if err != nil {
+		return "", errs.FromTransport(err)
+	}
+	if resp.IsError() {
+		return "", errs.FromStatus(metrics.ProviderWhisper, resp.StatusCode(), resp.String())
+	}
+	return resp.String(), nil
+}
+
+// WhisperCPP локальный whisper.cpp
+type WhisperCPP struct {
+	// Binary путь к whisper-cli; пустой — whisper-cli из PATH
+	Binary string
+	// Model путь к модели ggml
+	Model    string
+	Language string
+	// Splitter переводит запись в WAV 16 кГц и режет на части
+	Splitter Splitter
+}
+
+// Transcribe распознаёт запись по частям
+func (w *WhisperCPP) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	return transcribeParts(ctx, w.Splitter, audioPath, w.transcribeFile)
+}
+
+func (w *WhisperCPP) transcribeFile(ctx context.Context, path string) (string, error) {
+	bin := w.Binary
+	if bin == "" {
+		bin = "whisper-cli"
+	}
+	args := []string{"-m", w.Model, "-f", path, "-nt", "-np"}
+	if w.Language != "" {
+		args = append(args, "-l", w.Language)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", errs.FromTransport(ctx.Err())
+		}
+		return "", fmt.Errorf("whisper.cpp: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// transcribeParts режет запись и распознаёт части по очереди, склеивая
+// текст. Тишина в отдельной части не ошибка, пустая запись целиком —
+// ErrNoSpeech.
+func transcribeParts(ctx context.Context, s Splitter, audioPath string, transcribe func(context.Context, string) (string, error)) (string, error) {
+	dir, err := os.MkdirTemp("", "hack_interview-audio-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	parts, err := s.Split(ctx, audioPath, dir)
+	if err != nil {
+		return "", err
+	}
+	var texts []string
+	for i, part := range parts {
+		text, err := transcribe(ctx, part)
+		if err != nil {
+			return "", fmt.Errorf("часть %d из %d: %w", i+1, len(parts), err)
+		}
+		if text, err := cleanTranscript(text); err == nil {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return "", errs.ErrNoSpeech
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// noiseRe пометки, которые Whisper пишет вместо речи: [BLANK_AUDIO],
+// [музыка], (silence) и т. п.
+var noiseRe = regexp.MustCompile(`\[[^\]]*\]|\((?i:silence|music|тишина|музыка)[^)]*\)`)
+
+// cleanTranscript убирает пометки шума; пустой результат — ErrNoSpeech
+func cleanTranscript(text string) (string, error) {
+	text = strings.TrimSpace(noiseRe.ReplaceAllString(text, ""))
+	if text == "" {
+		return "", errs.ErrNoSpeech
+	}
+	return text, nil
+}
@@ -0,0 +1,74 @@
+// Package transport создаёт HTTP-клиенты провайдеров с общей политикой
+// соединений и повторов.
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Options настройки транспорта, общие для всех провайдеров
+type Options struct {
+	// Proxy адрес HTTP(S)-прокси; пустой — из переменных окружения
+	// HTTPS_PROXY/HTTP_PROXY
+	Proxy string `yaml:"proxy"`
+	// MaxIdleConns предел простаивающих соединений на хост
+	MaxIdleConns int `yaml:"maxIdleConns"`
+	// IdleConnTimeout сколько держать простаивающее соединение
+	IdleConnTimeout time.Duration `yaml:"idleConnTimeout"`
+	// Retries число повторов запроса при сетевой ошибке или ответе 5xx,
+	// по умолчанию 0
+	Retries int `yaml:"retries"`
+	// RetryWait начальная пауза между повторами, далее растёт
+	RetryWait time.Duration `yaml:"retryWait"`
+}
+
+// New создаёт клиент. Клиент создаётся один раз на провайдера и
+// переиспользует соединения между запросами.
+func New(opts Options) (*resty.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	tr := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   opts.MaxIdleConns,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	c := resty.New().
+		SetTransport(tr).
+		SetRetryCount(opts.Retries).
+		SetRetryWaitTime(opts.RetryWait).
+		SetRetryMaxWaitTime(10 * opts.RetryWait).
+		AddRetryCondition(retryable)
+	return c, nil
+}
+
+// retryable повторяет сетевые ошибки и 5xx. Истёкший срок и 429 не
+// повторяются: первый решает вызывающий, второй означает квоту.
+func retryable(resp *resty.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp != nil && resp.StatusCode() >= http.StatusInternalServerError
+}
@@ -0,0 +1,150 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer считает новые соединения и запросы
+func countingServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var conns, reqs atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs.Add(1)
+		handler(w, r)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &conns, &reqs
+}
+
+// Параллельные запросы через общий клиент держат не больше соединений,
+// чем обработчиков, и переиспользуют их между запросами
+func TestClientReusesConnections(t *testing.T) {
+	srv, conns, reqs := countingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+	c, err := New(Options{MaxIdleConns: 4, IdleConnTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 4, 10
+	var wg sync.WaitGroup
+	errc := make(chan error, workers*perWorker)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				resp, err := c.R().Get(srv.URL)
+				if err == nil && resp.String() != "ok" {
+					err = fmt.Errorf("ответ %q", resp.String())
+				}
+				if err != nil {
+					errc <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		t.Fatal(err)
+	}
+	if reqs.Load() != workers*perWorker {
+		t.Errorf("запросов %d", reqs.Load())
+	}
+	if n := conns.Load(); n > workers {
+		t.Errorf("соединений %d на %d запросов, want не больше %d", n, workers*perWorker, workers)
+	}
+}
+
+// Новый клиент на каждый запрос — по соединению на запрос: то, от чего
+// избавляет общий клиент
+func TestSeparateClientsDoNotShare(t *testing.T) {
+	srv, conns, _ := countingServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	for i := 0; i < 3; i++ {
+		c, _ := New(Options{MaxIdleConns: 4})
+		if _, err := c.R().Get(srv.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := conns.Load(); n != 3 {
+		t.Errorf("соединений %d, want 3", n)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		code int
+		want int32
+	}{
+		{"5xx повторяется", http.StatusServiceUnavailable, 3},
+		{"429 не повторяется", http.StatusTooManyRequests, 1},
+		{"4xx не повторяется", http.StatusBadRequest, 1},
+		{"успех", http.StatusOK, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, _, reqs := countingServer(t, func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(tc.code) })
+			c, _ := New(Options{Retries: 2, RetryWait: time.Millisecond})
+			resp, err := c.R().Get(srv.URL)
+			if err != nil || resp.StatusCode() != tc.code {
+				t.Fatalf("%v, %v", resp, err)
+			}
+			if reqs.Load() != tc.want {
+				t.Errorf("запросов %d, want %d", reqs.Load(), tc.want)
+			}
+		})
+	}
+}
+
+// Истёкший срок запроса не повторяется: повтор решает вызывающий
+func TestNoRetryAfterDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv, _, reqs := countingServer(t, func(w http.ResponseWriter, r *http.Request) { <-release })
+	defer close(release)
+	c, _ := New(Options{Retries: 5, RetryWait: time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.R().SetContext(ctx).Get(srv.URL); err == nil {
+		t.Fatal("нет ошибки")
+	}
+	if reqs.Load() != 1 {
+		t.Errorf("запросов %d, want 1", reqs.Load())
+	}
+}
+
+func TestProxy(t *testing.T) {
+	if _, err := New(Options{Proxy: "http://[::1"}); err == nil {
+		t.Error("некорректный адрес прокси принят")
+	}
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "api.example.test" {
+			proxied.Add(1)
+		}
+	}))
+	defer proxy.Close()
+	c, err := New(Options{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.R().Get("http://api.example.test/v1"); err != nil || proxied.Load() != 1 {
+		t.Errorf("через прокси %d запросов, %v", proxied.Load(), err)
+	}
+}
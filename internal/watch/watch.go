@@ -0,0 +1,199 @@
+// Package watch опрашивает входную директорию и ставит новые файлы в
+// очередь. Состояние файлов, очередь и группы снимков передаются через
+// интерфейсы, поэтому проход по директории не зависит от их хранения.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// Store состояние обработанных файлов
+type Store interface {
+	// Refresh перечитывает состояние, изменённое другим экземпляром
+	Refresh() error
+	// Eligible сообщает, нужно ли брать файл в обработку
+	Eligible(name string, now time.Time) bool
+	// Seen сообщает, есть ли у файла запись в состоянии
+	Seen(name string) bool
+	// MarkSkipped записывает пропуск файлов с причиной
+	MarkSkipped(names []string, reason string) error
+}
+
+// Queue очередь заданий
+type Queue interface {
+	Has(name string) bool
+	// Push ставит файл в очередь; false — очередь заполнена
+	Push(name, path string) bool
+	// Overflow сообщает, сколько файлов не поместилось за проход
+	Overflow(deferred int)
+}
+
+// Groups придерживает снимки одного вопроса и ставит их в очередь вместе.
+// toggle — на время прохода группировка включена файлом-переключателем.
+type Groups interface {
+	// Hold придерживает файл, если он часть группы
+	Hold(name string, toggle bool, now time.Time) bool
+	// Ready ставит в очередь собранные группы и возвращает число
+	// не поместившихся файлов
+	Ready(toggle bool, now time.Time) int
+}
+
+// Задержки повторного чтения недоступной директории по умолчанию
+const (
+	DefaultRetryMin = time.Second
+	DefaultRetryMax = 30 * time.Second
+)
+
+// defaultInterval пауза между проходами по доступной директории
+const defaultInterval = 100 * time.Millisecond
+
+// Watcher наблюдает за директорией Dir
+type Watcher struct {
+	Dir   string
+	Store Store
+	Queue Queue
+	// Groups nil — каждый файл обрабатывается отдельно
+	Groups Groups
+	// Toggle включена ли группировка переключателем; проверяется раз за
+	// проход, nil — выключена
+	Toggle func() bool
+	// ReadDir читает директорию; nil — ioutil.ReadDir
+	ReadDir func(dir string) ([]os.FileInfo, error)
+	// Accept отбирает файлы по имени; nil — все
+	Accept func(name string) bool
+	// Sort задаёт порядок постановки файлов в очередь
+	Sort func(files []os.FileInfo, now time.Time)
+	// MaxFileAge файлы старше, ещё не попавшие в состояние, пропускаются;
+	// 0 — без ограничения
+	MaxFileAge time.Duration
+	// GracePeriod сколько директория может быть недоступна подряд, прежде
+	// чем Run вернёт ошибку
+	GracePeriod time.Duration
+	// Interval пауза между проходами; 0 — 100ms
+	Interval time.Duration
+	// RetryMin и RetryMax пределы задержки повторного чтения недоступной
+	// директории; 0 — DefaultRetryMin и DefaultRetryMax
+	RetryMin, RetryMax time.Duration
+	// OnScan вызывается после каждого прочтения директории
+	OnScan func()
+	// OnSkipped получает файлы, пропущенные по MaxFileAge
+	OnSkipped func(names []string, reason string)
+}
+
+// Run опрашивает директорию и ставит новые файлы в очередь, пока не
+// отменён ctx. Файлы, не поместившиеся в очередь, предлагаются снова при
+// следующем проходе.
+//
+// Ошибка чтения директории (сбой сетевого диска, временное переименование)
+// не останавливает наблюдение: чтение повторяется с растущей задержкой, и
+// только если директория недоступна дольше GracePeriod, Run возвращает
+// последнюю ошибку чтения.
+func (w *Watcher) Run(ctx context.Context) error {
+	minDelay, maxDelay := w.RetryMin, w.RetryMax
+	if minDelay <= 0 {
+		minDelay = DefaultRetryMin
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMax
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	var failingSince time.Time
+	delay := minDelay
+	for {
+		wait := interval
+		if _, err := w.Scan(ctx); err != nil {
+			if failingSince.IsZero() {
+				failingSince = time.Now()
+			}
+			if time.Since(failingSince) > w.GracePeriod {
+				return err
+			}
+			slog.Warn(msg.T("watch.read-retry"), "dir", w.Dir, "error", err, "retryIn", delay)
+			wait = delay
+			delay = min(delay*2, maxDelay)
+		} else if !failingSince.IsZero() {
+			slog.Info(msg.T("watch.input-available"), "dir", w.Dir, "downtime", time.Since(failingSince).Round(time.Second))
+			failingSince = time.Time{}
+			delay = minDelay
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Scan один проход по директории. Возвращает число файлов, отложенных
+// из-за заполненной очереди, или ошибку чтения директории.
+func (w *Watcher) Scan(ctx context.Context) (int, error) {
+	readDir := w.ReadDir
+	if readDir == nil {
+		readDir = ioutil.ReadDir
+	}
+	files, err := readDir(w.Dir)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Store.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+	}
+	if w.OnScan != nil {
+		w.OnScan()
+	}
+
+	deferred := 0
+	var tooOld []string
+	now := time.Now()
+	toggle := w.Toggle != nil && w.Toggle()
+	if w.Sort != nil {
+		w.Sort(files, now)
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return deferred, nil
+		}
+		name := file.Name()
+		if file.IsDir() || !w.Store.Eligible(name, now) || w.Queue.Has(name) || (w.Accept != nil && !w.Accept(name)) {
+			continue
+		}
+		if w.MaxFileAge > 0 && !w.Store.Seen(name) && file.ModTime().Before(now.Add(-w.MaxFileAge)) {
+			tooOld = append(tooOld, name)
+			continue
+		}
+		if w.Groups != nil && w.Groups.Hold(name, toggle, now) {
+			continue
+		}
+		if !w.Queue.Push(name, filepath.Join(w.Dir, name)) {
+			deferred++
+		}
+	}
+	if w.Groups != nil {
+		deferred += w.Groups.Ready(toggle, now)
+	}
+	w.Queue.Overflow(deferred)
+	if len(tooOld) > 0 {
+		reason := fmt.Sprintf("старше %v", w.MaxFileAge)
+		if err := w.Store.MarkSkipped(tooOld, reason); err != nil {
+			slog.Error(msg.T("state.save-failed"), "error", err)
+		}
+		if w.OnSkipped != nil {
+			w.OnSkipped(tooOld, reason)
+		}
+		slog.Info(msg.T("watch.old-skipped"), "reason", reason, "count", len(tooOld))
+	}
+	return deferred, nil
+}
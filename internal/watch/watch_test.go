@@ -0,0 +1,318 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFile запись директории
+type fakeFile struct {
+	name  string
+	dir   bool
+	mtime time.Time
+}
+
+func (f fakeFile) Name() string       { return f.name }
+func (f fakeFile) Size() int64        { return 0 }
+func (f fakeFile) Mode() fs.FileMode  { return 0644 }
+func (f fakeFile) ModTime() time.Time { return f.mtime }
+func (f fakeFile) IsDir() bool        { return f.dir }
+func (f fakeFile) Sys() any           { return nil }
+
+// fakeStore состояние в памяти: done обработаны, seen встречались
+type fakeStore struct {
+	mu      sync.Mutex
+	done    map[string]bool
+	seen    map[string]bool
+	skipped map[string]string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{done: map[string]bool{}, seen: map[string]bool{}, skipped: map[string]string{}}
+}
+
+func (s *fakeStore) Refresh() error { return nil }
+func (s *fakeStore) Eligible(name string, _ time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.done[name] && s.skipped[name] == ""
+}
+func (s *fakeStore) Seen(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen[name] || s.done[name]
+}
+func (s *fakeStore) MarkSkipped(names []string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, n := range names {
+		s.skipped[n] = reason
+	}
+	return nil
+}
+
+// fakeQueue очередь ёмкостью limit
+type fakeQueue struct {
+	mu       sync.Mutex
+	limit    int
+	paths    []string
+	overflow []int
+}
+
+func (q *fakeQueue) Has(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, p := range q.paths {
+		if filepath.Base(p) == name {
+			return true
+		}
+	}
+	return false
+}
+func (q *fakeQueue) Push(_, path string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.paths) >= q.limit {
+		return false
+	}
+	q.paths = append(q.paths, path)
+	return true
+}
+func (q *fakeQueue) Overflow(deferred int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.overflow = append(q.overflow, deferred)
+}
+
+func (q *fakeQueue) names() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var names []string
+	for _, p := range q.paths {
+		names = append(names, filepath.Base(p))
+	}
+	return strings.Join(names, ",")
+}
+
+func listing(files ...fakeFile) func(string) ([]os.FileInfo, error) {
+	return func(string) ([]os.FileInfo, error) {
+		out := make([]os.FileInfo, len(files))
+		for i, f := range files {
+			out[i] = f
+		}
+		return out, nil
+	}
+}
+
+func TestScan(t *testing.T) {
+	now := time.Now()
+	store := newFakeStore()
+	store.done["done.png"] = true
+	q := &fakeQueue{limit: 2}
+	var skipped []string
+	w := &Watcher{
+		Dir:   "in",
+		Store: store,
+		Queue: q,
+		ReadDir: listing(
+			fakeFile{name: "sub", dir: true, mtime: now},
+			fakeFile{name: "b.png", mtime: now},
+			fakeFile{name: "done.png", mtime: now},
+			fakeFile{name: "notes.txt", mtime: now},
+			fakeFile{name: "old.png", mtime: now.Add(-2 * time.Hour)},
+			fakeFile{name: "a.png", mtime: now},
+			fakeFile{name: "c.png", mtime: now},
+		),
+		Accept:     func(name string) bool { return strings.HasSuffix(name, ".png") },
+		Sort:       func(files []os.FileInfo, _ time.Time) { sortByName(files) },
+		MaxFileAge: time.Hour,
+		OnSkipped:  func(names []string, _ string) { skipped = append(skipped, names...) },
+	}
+
+	deferred, err := w.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.names(); got != "a.png,b.png" {
+		t.Errorf("в очереди %s", got)
+	}
+	if q.paths[0] != filepath.Join("in", "a.png") {
+		t.Errorf("путь %s", q.paths[0])
+	}
+	if deferred != 1 || len(q.overflow) != 1 || q.overflow[0] != 1 {
+		t.Errorf("deferred = %d, Overflow %v", deferred, q.overflow)
+	}
+	if store.skipped["old.png"] != "старше 1h0m0s" || len(skipped) != 1 {
+		t.Errorf("пропуск по возрасту: %v, %v", store.skipped, skipped)
+	}
+
+	// Файлы уже в очереди не ставятся повторно, отложенный берётся, когда
+	// освободится место
+	q.limit = 3
+	if deferred, _ := w.Scan(context.Background()); deferred != 0 || q.names() != "a.png,b.png,c.png" {
+		t.Errorf("второй проход: deferred = %d, очередь %s", deferred, q.names())
+	}
+}
+
+func sortByName(files []os.FileInfo) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+}
+
+// fakeGroups придерживает файлы с «-» в имени и выпускает их вместе
+type fakeGroups struct {
+	q       *fakeQueue
+	held    []string
+	toggles []bool
+}
+
+func (g *fakeGroups) Hold(name string, toggle bool, _ time.Time) bool {
+	g.toggles = append(g.toggles, toggle)
+	if !strings.Contains(name, "-") {
+		return false
+	}
+	g.held = append(g.held, name)
+	return true
+}
+
+func (g *fakeGroups) Ready(bool, time.Time) int {
+	deferred := 0
+	for _, name := range g.held {
+		if !g.q.Push(name, name) {
+			deferred++
+		}
+	}
+	g.held = nil
+	return deferred
+}
+
+func TestScanGroups(t *testing.T) {
+	q := &fakeQueue{limit: 2}
+	g := &fakeGroups{q: q}
+	toggleCalls := 0
+	w := &Watcher{
+		Store:   newFakeStore(),
+		Queue:   q,
+		Groups:  g,
+		Toggle:  func() bool { toggleCalls++; return true },
+		ReadDir: listing(fakeFile{name: "q-1.png"}, fakeFile{name: "solo.png"}, fakeFile{name: "q-2.png"}),
+	}
+	deferred, err := w.Scan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.names(); got != "solo.png,q-1.png" || deferred != 1 {
+		t.Errorf("очередь %s, deferred = %d", got, deferred)
+	}
+	if toggleCalls != 1 || len(g.toggles) != 3 || !g.toggles[2] {
+		t.Errorf("Toggle %d раз, Hold получил %v", toggleCalls, g.toggles)
+	}
+}
+
+// flakyDir ReadDir, который падает первые fail раз, а потом отдаёт files;
+// calls — время каждого вызова
+type flakyDir struct {
+	mu    sync.Mutex
+	fail  int
+	files []fakeFile
+	calls []time.Time
+}
+
+func (d *flakyDir) ReadDir(dir string) ([]os.FileInfo, error) {
+	d.mu.Lock()
+	n := len(d.calls)
+	d.calls = append(d.calls, time.Now())
+	d.mu.Unlock()
+	if d.fail < 0 || n < d.fail {
+		return nil, &fs.PathError{Op: "open", Path: dir, Err: errors.New("transport endpoint is not connected")}
+	}
+	return listing(d.files...)(dir)
+}
+
+func (d *flakyDir) gaps() []time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []time.Duration
+	for i := 1; i < len(d.calls); i++ {
+		out = append(out, d.calls[i].Sub(d.calls[i-1]))
+	}
+	return out
+}
+
+// Директория недоступна пять проходов подряд (сетевой диск отвалился):
+// Run не выходит, повторяет чтение с растущей до RetryMax паузой и после
+// восстановления ставит файлы в очередь
+func TestRunSurvivesReadDirFailures(t *testing.T) {
+	dir := &flakyDir{fail: 5, files: []fakeFile{{name: "a.png", mtime: time.Now()}}}
+	q := &fakeQueue{limit: 10}
+	w := &Watcher{
+		Dir:         "in",
+		Store:       newFakeStore(),
+		Queue:       q,
+		ReadDir:     dir.ReadDir,
+		GracePeriod: 5 * time.Second,
+		Interval:    5 * time.Millisecond,
+		RetryMin:    10 * time.Millisecond,
+		RetryMax:    40 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for q.names() != "a.png" {
+		if time.Now().After(deadline) {
+			t.Fatalf("файл не поставлен в очередь; вызовов ReadDir %d", len(dir.gaps())+1)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run после отмены: %v", err)
+	}
+
+	// Паузы после ошибок: 10, 20, 40, 40, 40 мс
+	gaps := dir.gaps()
+	for i, want := range []time.Duration{10, 20, 40, 40, 40} {
+		if gaps[i] < want*time.Millisecond {
+			t.Errorf("пауза %d = %v, want не меньше %vms", i+1, gaps[i], want)
+		}
+	}
+	// После восстановления проходы снова с интервалом Interval
+	if len(gaps) > 6 && gaps[6] >= 40*time.Millisecond {
+		t.Errorf("пауза после восстановления %v", gaps[6])
+	}
+}
+
+// Недоступная дольше GracePeriod директория — ошибка Run
+func TestRunGivesUpAfterGracePeriod(t *testing.T) {
+	dir := &flakyDir{fail: -1}
+	w := &Watcher{
+		Dir:         "in",
+		Store:       newFakeStore(),
+		Queue:       &fakeQueue{},
+		ReadDir:     dir.ReadDir,
+		GracePeriod: 50 * time.Millisecond,
+		RetryMin:    10 * time.Millisecond,
+		RetryMax:    20 * time.Millisecond,
+	}
+	start := time.Now()
+	err := w.Run(context.Background())
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != "in" {
+		t.Fatalf("err = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Run вышел через %v при GracePeriod 50ms", elapsed)
+	}
+	if n := len(dir.gaps()) + 1; n < 3 {
+		t.Errorf("вызовов ReadDir %d, want повторы до выхода", n)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"hack_interview/internal/classify"
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/problems"
+)
+
+// classifyText вид вопроса по настройкам из конфигурации
+func classifyText(text string) classify.Kind {
+	return classify.Classify(text, classify.Options{
+		SQL:        kindRules(config.SQL),
+		Design:     kindRules(config.Design),
+		Behavioral: kindRules(config.Behavioral),
+	})
+}
+
+func kindRules(c appconfig.KindConfig) classify.Rules {
+	return classify.Rules{Threshold: *c.Threshold, Keywords: c.Keywords}
+}
+
+// kindPrompt промпт для вида вопроса; пустая строка — общий промпт
+func kindPrompt(kind classify.Kind) string {
+	switch kind {
+	case classify.KindSQL:
+		return config.SQL.Prompt
+	case classify.KindDesign:
+		return config.Design.Prompt
+	case classify.KindBehavioral:
+		if config.PersonalContext != "" {
+			return "Опыт кандидата, на котором строится ответ:\n" +
+				strings.TrimSpace(config.PersonalContext) + "\n\n" + config.Behavioral.Prompt
+		}
+		return config.Behavioral.Prompt
+	}
+	return ""
+}
+
+// codeOnlyKind сообщает, есть ли в ответе код, ради которого стиль
+// code-only может выбросить остальное: в поведенческом ответе его нет
+func codeOnlyKind(kind classify.Kind) bool {
+	return kind != classify.KindBehavioral
+}
+
+// codeKind сообщает, ждать ли в ответе кода решения: к нему применяются
+// оценка сложности, проверка компиляции, тесты и языки решения
+func codeKind(kind classify.Kind) bool {
+	return kind == classify.KindGeneric
+}
+
+// knownProblems набор известных задач; nil — распознавание выключено
+var knownProblems []problems.Problem
+
+func loadKnownProblems(c appconfig.KnownProblemsConfig) error {
+	if c.Enabled != nil && !*c.Enabled {
+		knownProblems = nil
+		return nil
+	}
+	list, err := problems.Load(c.File)
+	if err != nil {
+		return err
+	}
+	knownProblems = list
+	return nil
+}
+
+// knownProblem ищет в тексте известную задачу
+func knownProblem(text string) (problems.Match, bool) {
+	if knownProblems == nil {
+		return problems.Match{}, false
+	}
+	return problems.Find(text, knownProblems, config.KnownProblems.Threshold)
+}
+
+// knownProblemPrompt подсказка модели о распознанной задаче: модель
+// опирается на канонический подход, но проверяет, что условие совпадает
+func knownProblemPrompt(m problems.Match) string {
+	return fmt.Sprintf("Похоже, это известная задача «%s», канонический подход: %s. "+
+		"Если условие отличается, решай по условию, а не по известной задаче.",
+		m.Problem.Title(), m.Problem.Approach)
+}
+
+// knownProblemNote заметка в начале ответа
+func knownProblemNote(m problems.Match) string {
+	return fmt.Sprintf("Похоже на: %s — канонический подход: %s (уверенность %.0f%%)",
+		m.Problem.Title(), m.Problem.Approach, m.Confidence*100)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// codeExtensions расширения файлов кода по языку блока
+var codeExtensions = map[string]string{
+	"go":         "go",
+	"python":     "py",
+	"java":       "java",
+	"javascript": "js",
+	"typescript": "ts",
+	"rust":       "rs",
+	"cpp":        "cpp",
+	"c++":        "cpp",
+	"kotlin":     "kt",
+	"csharp":     "cs",
+}
+
+// languageTitles названия языков в заголовках разделов
+var languageTitles = map[string]string{
+	"go":         "Go",
+	"python":     "Python",
+	"java":       "Java",
+	"javascript": "JavaScript",
+	"typescript": "TypeScript",
+	"rust":       "Rust",
+	"cpp":        "C++",
+	"c++":        "C++",
+	"kotlin":     "Kotlin",
+	"csharp":     "C#",
+}
+
+// fenceAliases другие обозначения языка в блоках кода
+var fenceAliases = map[string][]string{
+	"go":         {"golang"},
+	"python":     {"py", "python3"},
+	"javascript": {"js"},
+	"typescript": {"ts"},
+	"cpp":        {"c++"},
+	"csharp":     {"cs", "c#"},
+}
+
+func languageTitle(lang string) string {
+	if t, ok := languageTitles[lang]; ok {
+		return t
+	}
+	return lang
+}
+
+func languageTitlesOf(langs []string) []string {
+	titles := make([]string, len(langs))
+	for i, l := range langs {
+		titles[i] = languageTitle(l)
+	}
+	return titles
+}
+
+// codeLanguages языки решения из конфигурации в нижнем регистре
+func codeLanguages() []string {
+	langs := make([]string, 0, len(config.CodeLanguage))
+	for _, l := range config.CodeLanguage {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			langs = append(langs, l)
+		}
+	}
+	return langs
+}
+
+// languagesPrompt указание модели о языках решения
+func languagesPrompt(langs []string) string {
+	switch len(langs) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("Код пиши на %s в блоке ```%s.", languageTitle(langs[0]), langs[0])
+	}
+	var b strings.Builder
+	b.WriteString("Дай решение на каждом из языков отдельным разделом с заголовком и блоком кода:")
+	for _, l := range langs {
+		fmt.Fprintf(&b, " «## Solution (%s)» с ```%s;", languageTitle(l), l)
+	}
+	return strings.TrimSuffix(b.String(), ";") + "."
+}
+
+// extractLanguageCode код решения на языке: блоки ```lang и его
+// синонимов
+func extractLanguageCode(answer, lang string) string {
+	if code := output.ExtractCode(answer, lang); code != "" {
+		return code
+	}
+	for _, alias := range fenceAliases[lang] {
+		if code := output.ExtractCode(answer, alias); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// saveLanguageCode сохраняет код каждого языка в <имя>.<ext>
+func saveLanguageCode(logger *slog.Logger, tm *stageTimings, resultPath, answer string, langs []string) {
+	for _, lang := range langs {
+		code := extractLanguageCode(answer, lang)
+		if code == "" {
+			continue
+		}
+		ext := codeExtensions[lang]
+		if ext == "" {
+			ext = lang
+		}
+		if path, err := output.SaveCode(resultPath, "."+ext, code); err != nil {
+			logger.Warn(msg.T("code.save-failed"), "language", lang, "error", err)
+		} else {
+			tm.AddOutput(path)
+			logger.Debug(msg.T("code.saved"), "language", lang, "output", path)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"hack_interview/internal/msg"
+)
+
+// Флаги командной строки, переопределяющие logLevel и logFormat
+var (
+	flagLogLevel  string
+	flagLogFormat string
+	// flagQuiet (--quiet) только ошибки, flagVerbose (-v, -vv) подробнее
+	flagQuiet   bool
+	flagVerbose int
+)
+
+// traceHTTP пишет в лог краткую сводку каждого запроса к провайдерам (-vv)
+var traceHTTP bool
+
+// verbosityFlag bool-флаг, который прибавляет step к счётчику подробности:
+// -v на единицу, -vv на две, так что -v -v и -vv равнозначны
+type verbosityFlag struct {
+	n    *int
+	step int
+}
+
+func (f verbosityFlag) String() string { return "" }
+
+func (f verbosityFlag) IsBoolFlag() bool { return true }
+
+func (f verbosityFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*f.n += f.step
+	}
+	return nil
+}
+
+// registerVerbosityFlags добавляет --quiet, -v и -vv
+func registerVerbosityFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&flagQuiet, "quiet", false, "только ошибки в логах; строка состояния остаётся")
+	fs.Var(verbosityFlag{&flagVerbose, 1}, "v", "подробные логи (debug); -vv — ещё и сводки HTTP-запросов")
+	fs.Var(verbosityFlag{&flagVerbose, 2}, "vv", "то же, что -v -v")
+}
+
+// logLevelChoice источники уровня логирования по убыванию приоритета:
+// --quiet и -v, затем --log-level, затем logLevel и debug из config.yml
+type logLevelChoice struct {
+	Quiet       bool
+	Verbose     int
+	FlagLevel   string
+	ConfigLevel string
+	Debug       bool
+}
+
+// resolve возвращает уровень и нужны ли сводки HTTP-запросов. --quiet, -v и
+// --log-level задают одно и то же, поэтому вместе это ошибка, а не молчаливый
+// выбор одного из них.
+func (c logLevelChoice) resolve() (level slog.Level, trace bool, err error) {
+	switch {
+	case c.Quiet && c.Verbose > 0:
+		return 0, false, fmt.Errorf("--quiet и -v несовместимы")
+	case (c.Quiet || c.Verbose > 0) && c.FlagLevel != "":
+		return 0, false, fmt.Errorf("--log-level несовместим с --quiet и -v")
+	case c.Quiet:
+		return slog.LevelError, false, nil
+	case c.Verbose > 0:
+		return slog.LevelDebug, c.Verbose > 1, nil
+	}
+	name := c.FlagLevel
+	if name == "" {
+		name = c.ConfigLevel
+	}
+	if name == "" {
+		name = "info"
+		if c.Debug {
+			name = "debug"
+		}
+	}
+	level, err = parseLogLevel(name)
+	return level, false, err
+}
+
+// parseLogLevel разбирает уровень debug/info/warn/error
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return level, fmt.Errorf("неизвестный уровень логирования %q (debug, info, warn, error)", s)
+	}
+	return level, nil
+}
+
+// setupLogging настраивает slog по конфигурации и флагам: text для людей,
+// json для сборщиков логов. Все записи идут в stderr
+// через console, чтобы не ломать строку состояния; в терминале итоги
+// файлов рисует prettyHandler.
+func setupLogging() error {
+	level, trace, err := logLevelChoice{
+		Quiet:       flagQuiet,
+		Verbose:     flagVerbose,
+		FlagLevel:   flagLogLevel,
+		ConfigLevel: config.LogLevel,
+		Debug:       config.Debug,
+	}.resolve()
+	if err != nil {
+		return err
+	}
+	traceHTTP = trace
+
+	format := config.LogFormat
+	if flagLogFormat != "" {
+		format = flagLogFormat
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	newHandler := func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, opts) }
+	switch strings.ToLower(format) {
+	case "", "text":
+	case "json":
+		newHandler = func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, opts) }
+	default:
+		return fmt.Errorf("неизвестный формат логов %q (text, json)", format)
+	}
+
+	handler := newHandler(console)
+	if pretty, color := prettyOutput(); pretty && strings.ToLower(format) != "json" {
+		handler = newPrettyHandler(handler, console, color)
+	}
+	if config.LogFile != "" {
+		sink, err := openLogFile(config.LogFile, slog.New(newHandler(console)))
+		if err != nil {
+			return err
+		}
+		handler = teeHandler{handler, newHandler(sink)}
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// logFileSink пишет логи в файл с ротацией по размеру. После ошибки записи
+// файл отключается до конца работы, логи продолжают идти в stderr.
+type logFileSink struct {
+	mu     sync.Mutex
+	w      *lumberjack.Logger
+	warn   *slog.Logger
+	failed bool
+}
+
+// openLogFile открывает файл логов заранее, чтобы ошибка доступа
+// обнаружилась при запуске, а не при первой записи
+func openLogFile(path string, warn *slog.Logger) (*logFileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию для файла логов %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл логов: %w", err)
+	}
+	f.Close()
+
+	maxAge := 0
+	if config.LogMaxAge > 0 {
+		// lumberjack считает возраст в днях; неполный день округляется вверх
+		maxAge = int((config.LogMaxAge + 24*time.Hour - 1) / (24 * time.Hour))
+	}
+	return &logFileSink{
+		w: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    config.LogMaxSize,
+			MaxBackups: config.LogMaxBackups,
+			MaxAge:     maxAge,
+		},
+		warn: warn,
+	}, nil
+}
+
+// Write никогда не возвращает ошибку, чтобы io.MultiWriter не прекращал
+// запись в stderr
+func (s *logFileSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed {
+		return len(p), nil
+	}
+	if _, err := s.w.Write(p); err != nil {
+		s.failed = true
+		s.w.Close()
+		s.warn.Warn(msg.T("logging.file-failed"), "path", s.w.Filename, "error", err)
+	}
+	return len(p), nil
+}
+
+// fatal записывает ошибку и завершает процесс
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
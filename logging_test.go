@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// parseVerbosity разбирает args флагами --quiet, -v и -vv
+func parseVerbosity(t *testing.T, args ...string) (bool, int, error) {
+	t.Helper()
+	oldQuiet, oldVerbose := flagQuiet, flagVerbose
+	t.Cleanup(func() { flagQuiet, flagVerbose = oldQuiet, oldVerbose })
+	flagQuiet, flagVerbose = false, 0
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	registerVerbosityFlags(fs)
+	err := fs.Parse(args)
+	return flagQuiet, flagVerbose, err
+}
+
+func TestVerbosityFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		quiet   bool
+		verbose int
+	}{
+		{nil, false, 0},
+		{[]string{"--quiet"}, true, 0},
+		{[]string{"-v"}, false, 1},
+		{[]string{"-vv"}, false, 2},
+		{[]string{"-v", "-v"}, false, 2},
+		{[]string{"-v=false"}, false, 0},
+		{[]string{"-v", "-vv"}, false, 3},
+	} {
+		quiet, verbose, err := parseVerbosity(t, tc.args...)
+		if err != nil || quiet != tc.quiet || verbose != tc.verbose {
+			t.Errorf("%v: quiet %v, verbose %d, %v", tc.args, quiet, verbose, err)
+		}
+	}
+	if _, _, err := parseVerbosity(t, "-v=очень"); err == nil {
+		t.Error("-v=очень принят")
+	}
+}
+
+func TestLogLevelResolve(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		choice logLevelChoice
+		level  slog.Level
+		trace  bool
+	}{
+		{"по умолчанию", logLevelChoice{}, slog.LevelInfo, false},
+		{"debug из config.yml", logLevelChoice{Debug: true}, slog.LevelDebug, false},
+		{"logLevel из config.yml", logLevelChoice{ConfigLevel: "warn"}, slog.LevelWarn, false},
+		{"logLevel важнее debug", logLevelChoice{ConfigLevel: "error", Debug: true}, slog.LevelError, false},
+		{"--log-level важнее файла", logLevelChoice{FlagLevel: "debug", ConfigLevel: "error"}, slog.LevelDebug, false},
+		{"--quiet важнее файла", logLevelChoice{Quiet: true, ConfigLevel: "debug", Debug: true}, slog.LevelError, false},
+		{"-v важнее файла", logLevelChoice{Verbose: 1, ConfigLevel: "error"}, slog.LevelDebug, false},
+		{"-vv со сводками HTTP", logLevelChoice{Verbose: 2}, slog.LevelDebug, true},
+		{"регистр не важен", logLevelChoice{ConfigLevel: "WARN"}, slog.LevelWarn, false},
+	} {
+		level, trace, err := tc.choice.resolve()
+		if err != nil || level != tc.level || trace != tc.trace {
+			t.Errorf("%s: %v, trace %v, %v; want %v, trace %v", tc.name, level, trace, err, tc.level, tc.trace)
+		}
+	}
+
+	for _, choice := range []logLevelChoice{
+		{Quiet: true, Verbose: 1},
+		{Quiet: true, FlagLevel: "info"},
+		{Verbose: 2, FlagLevel: "debug"},
+		{FlagLevel: "громко"},
+		{ConfigLevel: "trace"},
+	} {
+		if _, _, err := choice.resolve(); err == nil {
+			t.Errorf("%+v: нет ошибки", choice)
+		}
+	}
+}
+
+// withConsole направляет вывод консоли в буфер и восстанавливает флаги,
+// логгер и конфигурацию после теста
+func withConsole(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	oldConsole, oldConfig, oldLogger, oldTrace := console, config, slog.Default(), traceHTTP
+	oldQuiet, oldVerbose, oldLevel := flagQuiet, flagVerbose, flagLogLevel
+	t.Cleanup(func() {
+		console, config, traceHTTP = oldConsole, oldConfig, oldTrace
+		flagQuiet, flagVerbose, flagLogLevel = oldQuiet, oldVerbose, oldLevel
+		slog.SetDefault(oldLogger)
+	})
+	console = &consoleWriter{w: &buf}
+	config.LogFile, config.LogFormat = "", ""
+	return &buf
+}
+
+// В --quiet видны только ошибки, а строка состояния остаётся и
+// перерисовывается под каждой записью
+func TestQuietKeepsStatusLine(t *testing.T) {
+	buf := withConsole(t)
+	config.LogLevel = "debug"
+	flagQuiet = true
+	if err := setupLogging(); err != nil {
+		t.Fatal(err)
+	}
+	console.SetStatus("обработано 3")
+	slog.Info("обычная запись")
+	slog.Warn("предупреждение")
+	slog.Error("сбой OCR")
+
+	out := buf.String()
+	if strings.Contains(out, "обычная запись") || strings.Contains(out, "предупреждение") {
+		t.Errorf("--quiet пропустил запись ниже ERROR:\n%q", out)
+	}
+	i := strings.Index(out, "сбой OCR")
+	if i < 0 || !strings.HasSuffix(out, "\nобработано 3") || !strings.Contains(out[:i], "\r\033[K") {
+		t.Errorf("строка состояния не перерисована вокруг ошибки:\n%q", out)
+	}
+}
+
+func TestVerboseEnablesTrace(t *testing.T) {
+	buf := withConsole(t)
+	config.LogLevel = "error"
+	flagVerbose = 2
+	if err := setupLogging(); err != nil {
+		t.Fatal(err)
+	}
+	slog.Debug("подробности")
+	if !traceHTTP || !strings.Contains(buf.String(), "подробности") {
+		t.Errorf("-vv: trace %v, лог %q", traceHTTP, buf)
+	}
+
+	flagVerbose, flagLogLevel = 1, "warn"
+	if err := setupLogging(); err == nil {
+		t.Error("-v вместе с --log-level принят")
+	}
+}
+
+// Отключённая в конфигурации строка состояния не рисуется даже в терминале
+func TestStatusLineDisabled(t *testing.T) {
+	withConsole(t)
+	off := false
+	config.StatusLine = &off
+	if statusLineEnabled() {
+		t.Error("statusLine: false не выключил строку состояния")
+	}
+}
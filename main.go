@@ -1,203 +1,215 @@
 package main
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"log/slog"
 	"os"
-	"path/filepath"
-	"strings"
-	"time"
 
-	"github.com/go-resty/resty/v2"
-	"gopkg.in/yaml.v2"
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
 )
 
-// Config структура для загрузки конфигурации из YAML
-type Config struct {
-	InputDir     string `yaml:"inputDir"`
-	OutputDir    string `yaml:"outputDir"`
-	OCRAPIKey    string `yaml:"OCR_API_KEY"`
-	GeminiAPIKey string `yaml:"GEMINI_API_KEY"`
-	PROMPT       string `yaml:"PROMPT"`
-}
-
-var config Config
-
-// OCR API Response Structure
-type OCRResponse struct {
-	ParsedResults []struct {
-		ParsedText string `json:"ParsedText"`
-	} `json:"ParsedResults"`
-}
-
-type GeminiRequest struct {
-	Contents []Content `json:"contents"`
-}
-
-type Content struct {
-	Parts []Part `json:"parts"`
-}
-
-type Part struct {
-	Text string `json:"text"`
-}
+// runMain запускает программу; сборки с захватом экрана подменяют его, чтобы
+// отдать главный поток обработке горячих клавиш.
+var runMain = func(f func()) { f() }
 
-type GeminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
-}
-
-var processedFiles = make(map[string]bool)
-
-// Функция загрузки конфигурации
-func loadConfig() {
-	data, err := ioutil.ReadFile("config.yml")
-	if err != nil {
-		log.Fatalf("Ошибка загрузки config.yml: %v", err)
-	}
-
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		log.Fatalf("Ошибка разбора YAML: %v", err)
+func main() {
+	if runAsService(run) {
+		return
 	}
+	runMain(run)
 }
 
-func encodeImageToBase64(imagePath string) (string, error) {
-	imageData, err := ioutil.ReadFile(imagePath)
-	if err != nil {
-		return "", err
+func run() {
+	appconfig.Passphrase = passphrase
+	once := flag.Bool("once", false, "обработать накопившиеся файлы и выйти")
+	pidFile := flag.String("pidfile", "", "записать PID в файл на время работы")
+	tui := flag.Bool("tui", false, "полноэкранный интерфейс вместо логов")
+	flag.BoolVar(&flagDebugHTTP, "debug-http", false, "писать дампы запросов к API в OutputDir/.debug")
+	flag.StringVar(&flagCassette, "cassette", "", "record или replay: записывать ответы API или воспроизводить их")
+	flag.BoolVar(&flagNoTests, "no-tests", false, "не генерировать тесты к коду ответа в этот запуск")
+	flag.BoolVar(&flagNoVerify, "no-verify", false, "не проверять ответ вторым запросом в этот запуск")
+	flag.BoolVar(&flagEmailTest, "email-test", false, "отправить пробное письмо при запуске (email в config.yml)")
+	flag.StringVar(&flagLogLevel, "log-level", "", "уровень логирования: debug, info, warn, error")
+	flag.StringVar(&flagLogFormat, "log-format", "", "формат логов: text или json")
+	registerVerbosityFlags(flag.CommandLine)
+	flag.BoolVar(&flagNoColor, "no-color", false, "без цвета в терминале, как при NO_COLOR")
+	envFile := flag.String("env-file", "", "дополнительный файл переменных окружения, важнее .env")
+	configFile := flag.String("config", "", "файл конфигурации вместо config.yml в текущей директории")
+	appconfig.RegisterFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, msg.T("usage"))
+		appconfig.FlagUsage(os.Stderr)
+	}
+	flag.Parse()
+	loadEnvFiles(useConfigFile(*configFile, *envFile))
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Arg(0), flag.Args()[1:]))
+	}
+	if *once {
+		os.Exit(runOnce())
 	}
-	return base64.StdEncoding.EncodeToString(imageData), nil
-}
 
-func extractTextFromImage(imagePath string) (string, error) {
-	imageBase64, err := encodeImageToBase64(imagePath)
-	if err != nil {
-		return "", err
+	loadConfig()
+	prepareDirs()
+	if *pidFile != "" {
+		if err := writePIDFile(*pidFile); err != nil {
+			fatal(msg.T("pidfile.failed"), "error", err)
+		}
 	}
 
-	client := resty.New()
-	resp, err := client.R().
-		SetHeader("apikey", config.OCRAPIKey).
-		SetFormData(map[string]string{
-			"language":                     "rus",
-			"isOverlayRequired":            "false",
-			"base64Image":                  "data:image/png;base64," + imageBase64,
-			"iscreatesearchablepdf":        "false",
-			"issearchablepdfhidetextlayer": "false",
-		}).
-		Post("https://api.ocr.space/parse/image")
+	startSession()
+	startPublishing()
+	ctx, stop := context.WithCancel(context.Background())
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	go handleSignals(stop, cancelWork)
+	go handlePauseSignal()
+	go watchDebugHTTP(ctx, configPath)
+	startRetention(ctx)
 
-	if err != nil {
-		return "", err
+	q := newQueue(config.QueueSize)
+	recoverJobs(q)
+	workers := startWorkers(ctx, workCtx, q, config.Workers)
+	if !*tui {
+		go runStatusLine(ctx, q)
 	}
-
-	var ocrResp OCRResponse
-	if err := json.Unmarshal(resp.Body(), &ocrResp); err != nil {
-		return "", err
+	if err := startStatusServer(ctx, q); err != nil {
+		slog.Warn(msg.T("status.unavailable"), "error", err)
 	}
-
-	if len(ocrResp.ParsedResults) > 0 {
-		return ocrResp.ParsedResults[0].ParsedText, nil
+	if config.ClipboardWatch {
+		go watchClipboard(ctx)
 	}
-
-	return "", fmt.Errorf("no text found in image")
-}
-
-func getGeminiResponse(prompt string) (string, error) {
-	client := resty.New()
-	requestBody := GeminiRequest{
-		Contents: []Content{{Parts: []Part{{Text: prompt}}}},
+	for _, src := range configuredSources(ctx) {
+		go src.Run(ctx, q)
 	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
+	if config.Server.Listen != "" {
+		if err := startServer(ctx); err != nil {
+			fatal(msg.T("server.start-failed"), "error", err)
+		}
 	}
-
-	resp, err := client.R().
-		SetHeader("Content-Type", "application/json").
-		SetBody(bytes.NewBuffer(jsonData)).
-		Post("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent?key=" + config.GeminiAPIKey)
-
-	if err != nil {
-		return "", err
+	if config.GRPC.Listen != "" {
+		if err := startGRPCServer(ctx); err != nil {
+			fatal(msg.T("grpc.start-failed"), "error", err)
+		}
 	}
-
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(resp.Body(), &geminiResp); err != nil {
-		return "", err
+	captureOK := true
+	if config.Capture.PickWindow && (config.Capture.Hotkey != "" || config.Capture.Interval > 0) {
+		if err := pickWindow(); err != nil {
+			slog.Warn(msg.T("capture.disabled-no-window"), "error", err)
+			captureOK = false
+		}
 	}
-
-	if len(geminiResp.Candidates) > 0 && len(geminiResp.Candidates[0].Content.Parts) > 0 {
-		return geminiResp.Candidates[0].Content.Parts[0].Text, nil
+	if config.Capture.Hotkey != "" && captureOK {
+		if err := startHotkeyCapture(ctx); err != nil {
+			slog.Warn(msg.T("capture.disabled"), "error", err)
+		}
+	}
+	if config.Capture.Interval > 0 && captureOK {
+		if err := startIntervalCapture(ctx); err != nil {
+			slog.Warn(msg.T("capture.interval-disabled"), "error", err)
+		}
 	}
 
-	return "", fmt.Errorf("no response from Gemini API")
-}
-
-func saveToMarkdown(filename, content string) error {
-	outputFilename := filepath.Join(config.OutputDir, filename+".md")
-	err := ioutil.WriteFile(outputFilename, []byte(content), 0644)
-	if err != nil {
-		return err
+	slog.Info(msg.T("watch.start"), "dir", config.InputDir, "workers", config.Workers, "queue", config.QueueSize)
+	dir := dirSource{}
+	if *tui {
+		go dir.Run(ctx, q)
+		if err := runTUI(ctx, q, stop); err != nil {
+			slog.Error(msg.T("tui.failed"), "error", err)
+		}
+	} else {
+		dir.Run(ctx, q)
 	}
+	workers.Wait()
 
-	fmt.Println("Файл сохранён:", outputFilename)
-	return nil
+	code := shutdown(workCtx)
+	if *pidFile != "" {
+		removePIDFile(*pidFile)
+	}
+	exit(code)
 }
 
-func processFile(imagePath, prompt string) error {
-	fmt.Println("Обрабатывается файл:", imagePath)
+// exit завершает процесс с кодом; служба Windows подменяет его, чтобы
+// сообщить диспетчеру служб об остановке до выхода
+var exit = os.Exit
 
-	text, err := extractTextFromImage(imagePath)
-	if err != nil {
-		log.Printf("Ошибка OCR (%s): %v\n", imagePath, err)
-		return nil
+// runOnce обрабатывает всё, что накопилось во входной директории, теми же
+// обработчиками, что и режим наблюдения, печатает итоги и завершается.
+// Код выхода ненулевой, если хотя бы один файл не обработан.
+func runOnce() int {
+	loadConfig()
+	prepareDirs()
+	startSession()
+	startPublishing()
+
+	ctx, stop := context.WithCancel(context.Background())
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+	go handleSignals(stop, cancelWork)
+
+	q := newQueue(config.QueueSize)
+	recoverJobs(q)
+	workers := startWorkers(ctx, workCtx, q, config.Workers)
+	go runStatusLine(ctx, q)
+
+	groups.flush = true
+	slog.Info(msg.T("once.start"), "dir", config.InputDir, "workers", config.Workers)
+	readFailed := false
+	for ctx.Err() == nil {
+		deferred, err := scanDirectory(ctx, q)
+		if err != nil {
+			slog.Error(msg.T("dir.read-failed"), "dir", config.InputDir, "error", err)
+			readFailed = true
+			break
+		}
+		q.WaitIdle(ctx)
+		if deferred == 0 {
+			break
+		}
 	}
+	stop()
+	workers.Wait()
 
-	p := prompt + ":\n" + text
-	response, err := getGeminiResponse(p)
-	if err != nil {
-		log.Printf("Ошибка Gemini API (%s): %v\n", imagePath, err)
-		return nil
+	code := shutdown(workCtx)
+	if code == exitOK && (readFailed || totals.FailedCount() > 0) {
+		code = exitCancelled
 	}
-
-	return saveToMarkdown("result", response)
+	return code
 }
 
-func watchDirectory() {
-	for {
-		files, err := ioutil.ReadDir(config.InputDir)
+// configuredSources внешние источники файлов, включённые в конфигурации
+func configuredSources(ctx context.Context) []Source {
+	var sources []Source
+	if config.S3.Bucket != "" {
+		src, err := newS3Source(ctx)
 		if err != nil {
-			log.Fatalf("Ошибка чтения директории %s: %v", config.InputDir, err)
+			fatal(msg.T("s3.setup-failed"), "error", err)
 		}
-
-		for _, file := range files {
-			if !file.IsDir() && !processedFiles[file.Name()] && (strings.HasSuffix(file.Name(), ".png") || strings.HasSuffix(file.Name(), ".jpg") || strings.HasSuffix(file.Name(), ".jpeg")) {
-				processedFiles[file.Name()] = true
-				processFile(filepath.Join(config.InputDir, file.Name()), config.PROMPT)
-			}
-		}
-		time.Sleep(100 * time.Millisecond)
+		sources = append(sources, src)
+	}
+	if config.IMAP.Addr != "" {
+		sources = append(sources, newIMAPSource())
 	}
+	return sources
 }
 
-func main() {
-	loadConfig()
-
+// prepareDirs создаёт директорию результатов и загружает состояние
+func prepareDirs() {
 	if _, err := os.Stat(config.OutputDir); os.IsNotExist(err) {
 		os.Mkdir(config.OutputDir, os.ModePerm)
 	}
 
-	fmt.Println("Запуск мониторинга директории:", config.InputDir)
-	watchDirectory()
+	if err := loadState(); err != nil {
+		fatal(msg.T("state.load-failed"), "backend", config.StateBackend, "error", err)
+	}
+	if err := loadQuota(config.Quota); err != nil {
+		fatal(msg.T("quota.load-failed"), "path", config.Quota.File, "error", err)
+	}
+	if err := loadKnownProblems(config.KnownProblems); err != nil {
+		fatal(msg.T("problems.load-failed"), "path", config.KnownProblems.File, "error", err)
+	}
 }
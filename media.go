@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/imageprep"
+	"hack_interview/internal/ocr"
+	"hack_interview/internal/speech"
+)
+
+// mediaProvider распознаёт текст изображений через OCR, а аудиофайлов —
+// через распознавание речи, чтобы дальше конвейер не различал источники.
+// Подготовка снимков перед OCR — в image.go. Имена провайдеров попадают в
+// отчёт об обработке.
+type mediaProvider struct {
+	image     ocr.Provider
+	imageName string
+	audio     speech.Transcriber
+	// audioName и audioModel бэкенд и модель распознавания речи
+	audioName, audioModel string
+	// trim обрезка полей снимка; nil — выключена
+	trim *imageprep.TrimOptions
+	// split нарезка высоких снимков; nil — выключена
+	split *imageprep.SplitOptions
+	// invert инверсия тёмных снимков
+	invert appconfig.InvertConfig
+}
+
+func (m mediaProvider) ExtractText(ctx context.Context, path string) (string, error) {
+	if m.audio != nil && speech.IsAudio(path) {
+		text, err := m.audio.Transcribe(ctx, path)
+		if err == nil {
+			timingsFrom(ctx).UseProvider(stageOCR, m.audioName, m.audioModel)
+		}
+		return text, err
+	}
+	return m.extractImage(ctx, path)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/metrics"
+)
+
+// scrapeMetrics значения метрик /metrics по строке «имя{метки}»
+func scrapeMetrics(t *testing.T, srv *httptest.Server) (map[string]float64, string) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/metrics: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	sc := bufio.NewScanner(strings.NewReader(string(body)))
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("строка %q: %v", line, err)
+		}
+		values[line[:i]] = v
+	}
+	return values, string(body)
+}
+
+// Файл проходит через OCR.space и Gemini по HTTP, после чего в /metrics
+// растут счётчики итога, запросов, длительностей и токенов, а имя файла
+// не появляется ни в одной метке
+func TestMetricsScrape(t *testing.T) {
+	t.Setenv("OCR_API_KEY", "ocr-test-key")
+	t.Setenv("GEMINI_API_KEY", "gemini-test-key")
+	_, ocrURL, geminiURL := newE2EServers(t)
+	loadTestEnv(t, "ocr:\n  baseURL: "+ocrURL+"\n  allowInsecure: true\n"+
+		"gemini:\n  baseURL: "+geminiURL+"\n  allowInsecure: true\n")
+	writePNGInput(t, "metrics-secret-name.png")
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	before, _ := scrapeMetrics(t, srv)
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	after, body := scrapeMetrics(t, srv)
+	for series, delta := range map[string]float64{
+		`hack_interview_files_processed_total{outcome="done"}`:                                 1,
+		`hack_interview_provider_requests_total{outcome="ok",provider="ocrspace"}`:             1,
+		`hack_interview_provider_requests_total{outcome="ok",provider="gemini"}`:               1,
+		`hack_interview_provider_request_duration_seconds_count{provider="ocrspace"}`:          1,
+		`hack_interview_provider_request_duration_seconds_count{provider="gemini"}`:            1,
+		`hack_interview_tokens_total{kind="prompt",provider="gemini"}`:                         21,
+		`hack_interview_tokens_total{kind="candidates",provider="gemini"}`:                     12,
+		`hack_interview_provider_request_duration_seconds_bucket{provider="gemini",le="+Inf"}`: 1,
+	} {
+		if got := after[series] - before[series]; got != delta {
+			t.Errorf("%s вырос на %v, want %v", series, got, delta)
+		}
+	}
+	if after["hack_interview_queue_depth"] != 0 {
+		t.Errorf("глубина очереди %v после обработки", after["hack_interview_queue_depth"])
+	}
+	if strings.Contains(body, "metrics-secret-name") {
+		t.Error("имя файла попало в метрики")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+func TestRequestImages(t *testing.T) {
+	testEnv(t, "")
+	req := apppipeline.Request{Path: "in/q.png", Parts: []string{"in/q-2.jpg", "in/q.mp3"}}
+	if got := requestImages(slog.Default(), req); got != nil {
+		t.Fatalf("без multimodal: %+v", got)
+	}
+
+	config.Gemini.Multimodal = true
+	got := requestImages(slog.Default(), req)
+	if len(got) != 2 || got[0].Path != "in/q.png" || got[0].MIMEType != "image/png" ||
+		got[1].Path != "in/q-2.jpg" || got[1].MIMEType != "image/jpeg" {
+		t.Errorf("images = %+v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/notion"
+	"hack_interview/internal/transport"
+)
+
+// notionPublisher страница в базе данных Notion на каждый вопрос
+type notionPublisher struct {
+	client *notion.Client
+	cfg    appconfig.NotionConfig
+}
+
+func newNotionPublisher(cfg appconfig.NotionConfig) *notionPublisher {
+	client, err := transport.New(config.HTTP)
+	if err != nil {
+		slog.Warn(msg.T("notion.http-failed"), "error", err)
+		client = nil
+	} else {
+		attachHTTPDump(client, metrics.ProviderNotion)
+	}
+	return &notionPublisher{
+		client: &notion.Client{Token: cfg.Token, BaseURL: cfg.BaseURL, Client: client, Timeout: config.RequestTimeout},
+		cfg:    cfg,
+	}
+}
+
+func (n *notionPublisher) Name() string { return "notion" }
+
+func (n *notionPublisher) Publish(ctx context.Context, r publishedResult) (string, error) {
+	session := n.cfg.Session
+	if session == "" {
+		session = r.Session
+	}
+	props := n.cfg.Properties
+	return n.client.CreatePage(ctx, n.cfg.DatabaseID, notion.Properties{
+		Title:   props.Title,
+		Date:    props.Date,
+		Session: props.Session,
+		Kind:    props.Kind,
+		Status:  props.Status,
+	}, notion.Page{
+		Title:    questionTitle(r.Question, r.Name),
+		Date:     r.Time,
+		Session:  session,
+		Kind:     r.Kind,
+		Status:   n.cfg.Status,
+		Markdown: r.Markdown,
+	})
+}
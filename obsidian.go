@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	appconfig "hack_interview/internal/config"
+)
+
+// Ответы в хранилище Obsidian: <vault>/<folder>/<slug>.md с тегами во
+// front matter и ссылкой на заметку сессии, которая перечисляет все
+// вопросы сессии. Запись идёт из горутины публикации, поэтому заметка
+// сессии не обновляется параллельно; файлы заменяются через временный
+// файл, и Obsidian не видит их наполовину записанными.
+
+// maxSlugRunes длина имени заметки без расширения
+const maxSlugRunes = 60
+
+// obsidianForbidden символы, которые Obsidian не допускает в именах или
+// ссылках, плюс запрещённые в Windows
+var obsidianForbidden = regexp.MustCompile(`[\\/:*?"<>|#^\[\]%{}]+`)
+
+type obsidianPublisher struct {
+	cfg appconfig.ObsidianConfig
+}
+
+func (o obsidianPublisher) Name() string { return "obsidian" }
+
+func (o obsidianPublisher) Publish(_ context.Context, r publishedResult) (string, error) {
+	dir := filepath.Join(o.cfg.Vault, o.cfg.Folder)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	title := questionTitle(r.Question, r.Name)
+	note := obsidianNoteName(dir, slugify(title, r.Name), r.Output)
+	session := obsidianSession(r)
+	path := filepath.Join(dir, note+".md")
+	if err := writeFileAtomic(path, renderObsidianNote(r)); err != nil {
+		return "", err
+	}
+	if session == "" {
+		return path, nil
+	}
+	if err := updateSessionNote(filepath.Join(dir, session+".md"), r.Session, note, title); err != nil {
+		return path, fmt.Errorf("ответ записан, но заметка сессии не обновлена: %w", err)
+	}
+	return path, nil
+}
+
+// obsidianSession имя заметки сессии результата или "" без сессии
+func obsidianSession(r publishedResult) string {
+	if r.Session == "" {
+		return ""
+	}
+	return "session-" + slugify(r.Session, "")
+}
+
+// renderObsidianNote содержимое заметки ответа: front matter с тегами,
+// ссылка на заметку сессии и markdown результата
+func renderObsidianNote(r publishedResult) []byte {
+	tags := []string{"interview", slugify(r.Kind, "general")}
+	session := obsidianSession(r)
+	if session != "" {
+		tags = append(tags, "session/"+slugify(r.Session, ""))
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(tags, ", "))
+	if session != "" {
+		fmt.Fprintf(&b, "session: \"[[%s]]\"\n", session)
+	}
+	fmt.Fprintf(&b, "date: %s\n", r.Time.Format("2006-01-02T15:04"))
+	fmt.Fprintf(&b, "source: %q\n", filepath.Base(r.Source))
+	fmt.Fprintf(&b, "output: %q\n", r.Output)
+	b.WriteString("---\n\n")
+	if session != "" {
+		fmt.Fprintf(&b, "Сессия: [[%s]]\n\n", session)
+	}
+	b.WriteString(strings.TrimRight(r.Markdown, "\n") + "\n")
+	return []byte(b.String())
+}
+
+// slugify короткое имя из заголовка: строчные буквы и цифры любого
+// алфавита через дефис, без символов, которые не любит Obsidian. Пустой
+// результат заменяется fallback.
+func slugify(s, fallback string) string {
+	s = obsidianForbidden.ReplaceAllString(strings.ToLower(s), " ")
+	var b strings.Builder
+	dash := false
+	n := 0
+	for _, r := range s {
+		if n >= maxSlugRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			n++
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+			n++
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		if fallback == "" {
+			return "note"
+		}
+		return slugify(fallback, "note")
+	}
+	return slug
+}
+
+// obsidianNoteName свободное имя заметки: slug, slug-2, … Заметка того же
+// результата (повторная обработка) перезаписывается под прежним именем.
+func obsidianNoteName(dir, slug, output string) string {
+	for n := 1; ; n++ {
+		name := slug
+		if n > 1 {
+			name = fmt.Sprintf("%s-%d", slug, n)
+		}
+		owner, err := frontMatterValue(filepath.Join(dir, name+".md"), "output")
+		if os.IsNotExist(err) || (err == nil && owner == output) {
+			return name
+		}
+	}
+}
+
+// frontMatterValue значение поля key front matter заметки
+func frontMatterValue(path, key string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() || sc.Text() != "---" {
+		return "", nil
+	}
+	for sc.Scan() && sc.Text() != "---" {
+		if k, v, ok := strings.Cut(sc.Text(), ":"); ok && strings.TrimSpace(k) == key {
+			v = strings.TrimSpace(v)
+			if strings.HasPrefix(v, `"`) {
+				fmt.Sscanf(v, "%q", &v)
+			}
+			return v, nil
+		}
+	}
+	return "", sc.Err()
+}
+
+// updateSessionNote добавляет ссылку на заметку в заметку сессии, если её
+// там ещё нет
+func updateSessionNote(path, session, note, title string) error {
+	link := "[[" + note + "]]"
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		data = []byte(fmt.Sprintf("---\ntags: [interview, session]\n---\n\n# Сессия %s\n\n", session))
+	case err != nil:
+		return err
+	}
+	text := string(data)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "- "+link) {
+			return nil
+		}
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	text += "- " + link + " — " + strings.TrimSpace(obsidianForbidden.ReplaceAllString(title, " ")) + "\n"
+	return writeFileAtomic(path, []byte(text))
+}
+
+// writeFileAtomic записывает файл через временный в той же директории
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
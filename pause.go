@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// pauseFileName файл во входной директории, наличие которого
+// приостанавливает обработку
+const pauseFileName = "PAUSE"
+
+// pauseControl приостанавливает выдачу заданий обработчикам. Наблюдатель
+// при этом продолжает находить файлы и ставить их в очередь, а начатая
+// обработка завершается как обычно. Пауза включается также при исчерпании
+// дневного лимита провайдера (quota.stop) и снимается после его сброса, а
+// при breaker.mode wait — на время паузы разомкнутой цепи провайдера.
+type pauseControl struct {
+	mu      sync.Mutex
+	toggled bool
+	paused  bool
+}
+
+var pause = &pauseControl{}
+
+// Toggle переключает паузу по сигналу
+func (p *pauseControl) Toggle() {
+	p.mu.Lock()
+	p.toggled = !p.toggled
+	p.mu.Unlock()
+	p.update()
+}
+
+// Paused сообщает, приостановлена ли обработка
+func (p *pauseControl) Paused() bool {
+	p.update()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+func (p *pauseControl) update() {
+	_, err := os.Stat(filepath.Join(config.InputDir, pauseFileName))
+	byFile := err == nil
+	byQuota := quota.Exhausted()
+	byBreaker := blockedProvider()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	paused := p.toggled || byFile || byQuota != "" || byBreaker != ""
+	if paused == p.paused {
+		return
+	}
+	p.paused = paused
+	switch {
+	case !paused:
+		slog.Info(msg.T("pause.resumed"))
+	case byQuota != "":
+		slog.Info(msg.T("pause.quota"), "provider", byQuota)
+	case byBreaker != "":
+		slog.Info(msg.T("pause.breaker"), "provider", byBreaker)
+	case byFile:
+		slog.Info(msg.T("pause.file"), "file", pauseFileName)
+	default:
+		slog.Info(msg.T("pause.signal"))
+	}
+}
+
+// waitWhilePaused блокирует обработчик, пока действует пауза.
+// Возвращает false, если за это время отменён ctx.
+func (p *pauseControl) waitWhilePaused(ctx context.Context) bool {
+	for p.Paused() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	return true
+}
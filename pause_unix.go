@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// handlePauseSignal переключает паузу по SIGUSR1
+func handlePauseSignal() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1)
+	for range sigs {
+		pause.Toggle()
+	}
+}
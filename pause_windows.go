@@ -0,0 +1,7 @@
+//go:build windows
+
+package main
+
+// handlePauseSignal на Windows нет SIGUSR1, пауза доступна только через файл
+// PAUSE во входной директории
+func handlePauseSignal() {}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"image"
+	"log/slog"
+	"math/bits"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// imageHash разностный перцептивный хеш (dHash): изображение сжимается до
+// 9×8 оттенков серого, и каждый бит сообщает, светлее ли пиксель соседа
+// справа. Мелкие изменения — курсор, часы, сглаживание — меняют лишь
+// несколько бит, поэтому похожесть снимков оценивается расстоянием
+// Хэмминга между хешами.
+func imageHash(img image.Image) uint64 {
+	const w, h = 9, 8
+	b := img.Bounds()
+	var gray [h][w]uint32
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			gray[y][x] = cellLuma(img, image.Rect(
+				b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h,
+				b.Min.X+(x+1)*b.Dx()/w, b.Min.Y+(y+1)*b.Dy()/h,
+			))
+		}
+	}
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// cellLuma средняя яркость клетки; для скорости берётся не больше 16×16
+// точек клетки
+func cellLuma(img image.Image, r image.Rectangle) uint32 {
+	if r.Empty() {
+		return 0
+	}
+	stepX, stepY := max(r.Dx()/16, 1), max(r.Dy()/16, 1)
+	var sum, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y += stepY {
+		for x := r.Min.X; x < r.Max.X; x += stepX {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			sum += uint64(299*cr+587*cg+114*cb) / 1000
+			n++
+		}
+	}
+	return uint32(sum / n)
+}
+
+// hashDistance число различающихся бит двух хешей
+func hashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// frameFilter решает, какие кадры периодического захвата отправлять в
+// конвейер. Кадр отправляется, когда экран успокоился (совпадает с
+// предыдущим кадром) и заметно отличается от последнего отправленного, но
+// не чаще minGap и не больше maxPerHour кадров за час: прокрутка или видео
+// на экране не должны расходовать квоту OCR.
+type frameFilter struct {
+	similarity int
+	minGap     time.Duration
+	maxPerHour int
+
+	prev, last  uint64
+	havePrev    bool
+	haveLast    bool
+	sent        []time.Time
+	limitLogged bool
+}
+
+func newFrameFilter(similarity int, minGap time.Duration, maxPerHour int) *frameFilter {
+	return &frameFilter{similarity: similarity, minGap: minGap, maxPerHour: maxPerHour}
+}
+
+// Accept сообщает, отправлять ли кадр с хешем hash, снятый в now
+func (f *frameFilter) Accept(hash uint64, now time.Time) bool {
+	settled := f.havePrev && hashDistance(hash, f.prev) <= f.similarity
+	f.prev, f.havePrev = hash, true
+	if !settled {
+		return false
+	}
+	if f.haveLast && hashDistance(hash, f.last) <= f.similarity {
+		return false
+	}
+	if n := len(f.sent); n > 0 && now.Sub(f.sent[n-1]) < f.minGap {
+		return false
+	}
+	for len(f.sent) > 0 && now.Sub(f.sent[0]) >= time.Hour {
+		f.sent = f.sent[1:]
+	}
+	if f.maxPerHour > 0 && len(f.sent) >= f.maxPerHour {
+		if !f.limitLogged {
+			slog.Warn(msg.T("capture.hourly-limit"), "maxPerHour", f.maxPerHour)
+			f.limitLogged = true
+		}
+		return false
+	}
+	f.limitLogged = false
+	f.last, f.haveLast = hash, true
+	f.sent = append(f.sent, now)
+	return true
+}
@@ -0,0 +1,588 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"hack_interview/internal/breaker"
+	"hack_interview/internal/cassette"
+	"hack_interview/internal/classify"
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/errs"
+	"hack_interview/internal/llm"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/mock"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/ocr"
+	"hack_interview/internal/output"
+	apppipeline "hack_interview/internal/pipeline"
+	"hack_interview/internal/transport"
+)
+
+// requestName имя результата запроса: имя группы или имя файла без метки
+// промпта
+func requestName(r apppipeline.Request) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	name, _ := splitPromptMarker(output.Name(r.Path))
+	return name
+}
+
+// Pipeline конвейер apppipeline.Pipeline с этапом ответа модели: промпт
+// по виду вопроса, проверка, сравнение и оценка ответа, сохранение
+// результата и действия после него
+type Pipeline struct {
+	*apppipeline.Pipeline
+	// Verifier модель проверки ответа; nil — LLM
+	Verifier llm.Answerer
+	// Compare модели режима сравнения
+	Compare []compareModel
+}
+
+// pipeline конвейер, собранный по конфигурации в loadConfig
+var pipeline *Pipeline
+
+func newPipeline(cfg Config) (*Pipeline, error) {
+	ocrClient, err := transport.New(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	llmClient, err := transport.New(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	audioClient, err := transport.New(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	attachHTTPDump(ocrClient, metrics.ProviderOCRSpace)
+	attachHTTPDump(llmClient, metrics.ProviderGemini)
+	attachHTTPDump(audioClient, metrics.ProviderWhisper)
+	tape := &cassette.Cassette{Dir: cfg.Cassette.Dir, Mode: cfg.Cassette.Mode}
+	gemini := &llm.Gemini{
+		APIKey:      cfg.GeminiAPIKey,
+		Model:       cfg.Gemini.Model,
+		BaseURL:     cfg.Gemini.BaseURL,
+		Headers:     cfg.Gemini.Headers,
+		KeyInHeader: cfg.Gemini.KeyInHeader,
+		Timeout:     cfg.RequestTimeout,
+		Client:      llmClient,
+		OnRequest:   func() { quota.AddRequest(metrics.ProviderGemini) },
+		OnTruncated: func(ctx context.Context) {
+			info, _ := dumpInfoFrom(ctx)
+			slog.Warn(msg.T("llm.truncated"), "file", info.Name)
+		},
+		InlineLimit: cfg.Gemini.InlineLimit,
+		KeepFiles:   cfg.Gemini.KeepFiles,
+		OnFileCleanup: func(ctx context.Context, err error) {
+			info, _ := dumpInfoFrom(ctx)
+			slog.Warn(msg.T("llm.file-cleanup-failed"), "file", info.Name, "error", err)
+		},
+	}
+	// usageOf учёт токенов ответа модели model; модель попадает в отчёт
+	usageOf := func(model string) func(ctx context.Context, prompt, candidates int) {
+		if model == "" {
+			model = llm.DefaultGeminiModel
+		}
+		if cfg.LLMProvider == appconfig.ProviderMock {
+			model = ""
+		}
+		return func(ctx context.Context, prompt, candidates int) {
+			if tm := timingsFrom(ctx); tm != nil {
+				tm.AddTokens(prompt, candidates)
+				tm.UseProvider(stageLLM, cfg.LLMProvider, model)
+			}
+			totals.AddTokens(prompt + candidates)
+			quota.AddTokens(metrics.ProviderGemini, prompt+candidates)
+		}
+	}
+	gemini.OnUsage = usageOf(gemini.Model)
+	// answerer клиент g или, при llmProvider mock, заготовки с тем же
+	// учётом токенов
+	answerer := func(g *llm.Gemini) llm.Answerer {
+		if cfg.LLMProvider != appconfig.ProviderMock {
+			return g
+		}
+		return newMockLLM(cfg, g.OnUsage)
+	}
+	var geminiBreaker *breaker.Breaker
+	if cfg.LLMProvider != appconfig.ProviderMock {
+		geminiBreaker = newBreaker(cfg, metrics.ProviderGemini)
+	}
+	var geminiFallback llm.Answerer
+	if cfg.Breaker.GeminiFallback.Model != "" {
+		f := geminiVariant(gemini, cfg.Breaker.GeminiFallback)
+		f.OnUsage = usageOf(f.Model)
+		geminiFallback = answerer(f)
+	}
+	var verifier llm.Answerer
+	if cfg.Verify.Model != "" {
+		v := *gemini
+		v.Model = cfg.Verify.Model
+		v.OnUsage = usageOf(v.Model)
+		verifier = tape.LLM(guardLLM(geminiBreaker, answerer(&v), nil))
+	}
+	var compare []compareModel
+	for _, m := range cfg.Compare.Models {
+		c := geminiVariant(gemini, m)
+		onUsage := usageOf(c.Model)
+		c.OnUsage = func(ctx context.Context, prompt, candidates int) {
+			if u := compareUsageFrom(ctx); u != nil {
+				u.in += prompt
+				u.out += candidates
+			}
+			onUsage(ctx, prompt, candidates)
+		}
+		compare = append(compare, compareModel{Name: m.Name, LLM: tape.ModelLLM(m.Name, answerer(c))})
+	}
+	ocrSpace := &ocr.OCRSpace{
+		APIKey:  cfg.OCRAPIKey,
+		BaseURL: cfg.OCRBaseURL(),
+		Headers: cfg.OCR.Headers,
+		Timeout: cfg.RequestTimeout,
+		Client:  ocrClient,
+		OnRequest: func() {
+			totals.AddOCRRequest()
+			quota.AddRequest(metrics.ProviderOCRSpace)
+		},
+		MaxFileSize: cfg.OCRMaxFileSize(),
+	}
+	var image ocr.Provider = &mock.OCR{
+		Fixtures: mock.Fixtures{Dir: cfg.Mock.Fixtures},
+		Text:     cfg.Mock.Text,
+		Latency:  cfg.Mock.OCRLatency,
+	}
+	if cfg.OCRProvider != appconfig.ProviderMock {
+		var ocrFallback ocr.Provider
+		if cfg.Breaker.OCRFallback.BaseURL != "" {
+			f := *ocrSpace
+			f.BaseURL, f.Headers = cfg.Breaker.OCRFallback.BaseURL, cfg.Breaker.OCRFallback.Headers
+			ocrFallback = &f
+		}
+		image = guardOCR(newBreaker(cfg, metrics.ProviderOCRSpace), ocrSpace, ocrFallback)
+	}
+	p := &Pipeline{Verifier: verifier, Compare: compare}
+	p.Pipeline = &apppipeline.Pipeline{
+		OCR: tape.OCR(mediaProvider{
+			image:      image,
+			imageName:  cfg.OCRProvider,
+			audio:      newTranscriber(cfg, audioClient),
+			audioName:  cfg.Audio.Backend,
+			audioModel: cfg.Audio.Model,
+			trim:       trimOptions(cfg.Image.Trim),
+			split:      splitOptions(cfg.Image.Split),
+			invert:     cfg.Image.Invert,
+		}),
+		LLM:         tape.LLM(guardLLM(geminiBreaker, answerer(gemini), geminiFallback)),
+		Output:      output.Markdown{},
+		FileTimeout: cfg.FileTimeout,
+		OutputDir:   cfg.OutputDir,
+		Name:        requestName,
+		Begin:       beginStage,
+		Activity:    activity.Set,
+		OnOCR:       providers.Record,
+		Filter:      filterReason,
+		Answer:      p.answer,
+	}
+	if cfg.SplitQuestions.Enabled {
+		p.Split = p.splitQuestions
+	}
+	return p, nil
+}
+
+// newMockLLM модель с заготовленными ответами: заготовка выбирается по
+// имени обрабатываемого файла без метки промпта
+func newMockLLM(cfg Config, onUsage func(ctx context.Context, prompt, candidates int)) *mock.LLM {
+	return &mock.LLM{
+		Fixtures: mock.Fixtures{Dir: cfg.Mock.Fixtures},
+		Text:     cfg.Mock.Answer,
+		Latency:  cfg.Mock.LLMLatency,
+		Name: func(ctx context.Context) string {
+			info, _ := dumpInfoFrom(ctx)
+			name, _ := splitPromptMarker(info.Name)
+			return name
+		},
+		OnUsage: onUsage,
+	}
+}
+
+// geminiVariant копия клиента gemini с моделью, ключом и адресом m;
+// пустые поля m берутся из gemini
+func geminiVariant(gemini *llm.Gemini, m appconfig.CompareModel) *llm.Gemini {
+	c := *gemini
+	c.Model = m.Model
+	if m.APIKey != "" {
+		c.APIKey = m.APIKey
+	}
+	if m.BaseURL != "" {
+		c.BaseURL, c.Headers, c.KeyInHeader = m.BaseURL, m.Headers, m.KeyInHeader
+	}
+	return &c
+}
+
+// processFile обрабатывает файл конвейером из конфигурации
+func processFile(ctx context.Context, req apppipeline.Request) (string, error) {
+	return pipeline.Process(ctx, req)
+}
+
+// beginStage замеры файла и сведения для дампов запросов в контексте
+// этапа; замеры создаются, если их нет в запросе
+func beginStage(ctx context.Context, req apppipeline.Request) (context.Context, apppipeline.Request) {
+	tm, _ := req.Timings.(*stageTimings)
+	if tm == nil {
+		tm = newStageTimings()
+		req.Timings = tm
+	}
+	if _, ok := dumpInfoFrom(ctx); !ok {
+		ctx = withDumpInfo(ctx, requestName(req), 1)
+	}
+	return withTimings(ctx, tm), req
+}
+
+// answer получает и сохраняет ответ на один вопрос в результат name
+func (p *Pipeline) answer(ctx, fileCtx context.Context, logger *slog.Logger, req apppipeline.Request, text, name string) (string, error) {
+	tm := timingsFrom(ctx)
+	kind := classifyText(text)
+	basePrompt := req.Prompt
+	if kp := kindPrompt(kind); kp != "" {
+		logger.Info(msg.T("file.kind"), "kind", kind)
+		basePrompt = kp
+	}
+	promptName, named := namedPrompt(req.Path)
+	if named != "" {
+		logger.Info(msg.T("file.named-prompt"), "prompt", promptName)
+		basePrompt = named
+	}
+	prompt := basePrompt + ":\n" + text
+	styleName, style := styleFor(req.Path, req.Style)
+	if style.Prompt != "" {
+		prompt += "\n\n" + style.Prompt
+	}
+	if styleName != "" {
+		logger.Debug(msg.T("file.style"), "style", styleName)
+	}
+	var langs []string
+	var known string
+	maxTokens := style.MaxOutputTokens
+	if codeKind(kind) {
+		if m, ok := knownProblem(text); ok {
+			logger.Info(msg.T("problems.matched"), "problem", m.Problem.Title(), "confidence", fmt.Sprintf("%.2f", m.Confidence))
+			prompt += "\n\n" + knownProblemPrompt(m)
+			known = knownProblemNote(m)
+		}
+		langs = codeLanguages()
+		if lp := languagesPrompt(langs); lp != "" {
+			prompt += "\n\n" + lp
+		}
+		if len(langs) > 1 {
+			maxTokens *= len(langs)
+		}
+	}
+	var history []llm.Turn
+	parent := dialog.FollowUp(req.Path)
+	if parent != nil {
+		history = parent.History
+		logger.Info(msg.T("file.follow-up"), "parent", parent.Output)
+	}
+	activity.Set(ctx, activityLLM, req.Path)
+	compare := compareEnabled(req.Path)
+	if compare {
+		logger.Info(msg.T("compare.mode"), "models", len(p.Compare))
+	}
+	var response, summary, review string
+	err := tm.Track(stageLLM, func() (err error) {
+		llmReq := llm.Request{
+			Prompt:          prompt,
+			MaxOutputTokens: maxTokens,
+			Temperature:     style.Temperature,
+			History:         history,
+			Images:          requestImages(logger, req),
+		}
+		if compare {
+			// Проверка и оценка сложности переписывают один ответ, а не
+			// сводку нескольких
+			response, err = p.compareAnswers(fileCtx, logger, llmReq, text)
+			if err == nil && config.Summary && !style.Hints {
+				summary = p.cheatSummary(fileCtx, logger, text, response)
+			}
+			return err
+		}
+		response, err = p.LLM.Answer(fileCtx, llmReq)
+		if err == nil && config.Verify.Enabled && !flagNoVerify && !(style.CodeOnly && codeOnlyKind(kind)) {
+			response, review = p.verifyAnswer(fileCtx, logger, llmReq, text, response)
+		}
+		if err == nil && codeKind(kind) && !style.CodeOnly {
+			response = p.withComplexity(fileCtx, logger, response)
+		}
+		if err == nil && config.Summary && !style.Hints {
+			summary = p.cheatSummary(fileCtx, logger, text, response)
+		}
+		return err
+	})
+	providers.Record(err)
+	if err != nil {
+		metrics.StageFailed(metrics.StageLLM)
+		return "", p.TimeoutError(ctx, fileCtx, fmt.Errorf("ошибка Gemini API (%s): %w", req.Path, err))
+	}
+
+	if (config.GoCheck || config.RunExamples) && codeKind(kind) {
+		tm.Track(stageCheck, func() error {
+			var code string
+			response, code = p.checkGoCode(fileCtx, logger, response)
+			response = p.checkExamples(fileCtx, logger, text, response, code)
+			return nil
+		})
+	}
+
+	result := output.Result{
+		Source:    req.Path,
+		Sources:   req.Sources(),
+		Question:  text,
+		Answer:    response,
+		CodeOnly:  style.CodeOnly && codeOnlyKind(kind),
+		Hints:     style.Hints,
+		Diagrams:  kind == classify.KindDesign,
+		Parent:    parentLink(p.ResultDir(req), parent),
+		Summary:   summary,
+		Prompt:    promptName,
+		Review:    review,
+		Known:     known,
+		Languages: languageTitlesOf(langs),
+	}
+	var out string
+	err = tm.Track(stageOutput, func() (err error) {
+		out, err = p.Output.Save(p.ResultDir(req), name, result, req.Versioned)
+		return err
+	})
+	if err != nil {
+		metrics.StageFailed(metrics.StageOutput)
+		return out, err
+	}
+	tm.AddOutput(out)
+	if len(langs) > 0 {
+		saveLanguageCode(logger, tm, out, response, langs)
+	}
+	if kind == classify.KindSQL {
+		if code := output.ExtractCode(response, "sql"); code != "" {
+			if path, err := output.SaveCode(out, ".sql", code); err != nil {
+				logger.Warn(msg.T("file.request-save-failed"), "error", err)
+			} else {
+				tm.AddOutput(path)
+				logger.Info(msg.T("file.request-saved"), "output", path)
+			}
+		}
+	}
+	logger.Info(msg.T("file.saved"), "output", out)
+	dialog.Record(req.Path, out, history, llm.Turn{Prompt: prompt, Answer: response})
+	afterCommit(ctx, func() {
+		transcripts.Append(transcriptEntry{Time: time.Now(), Source: req.Path, Output: out, Question: text, Answer: response})
+		publishing.Publish(publishedResult{
+			Name:     name,
+			Output:   out,
+			Source:   req.Path,
+			Markdown: string(output.RenderMarkdown(result)),
+			Question: text,
+			Kind:     kindLabel(kind),
+			Session:  transcripts.Session(),
+			Time:     time.Now(),
+			Report:   reportPath(filepath.Dir(out), requestName(req)),
+		})
+	})
+	if config.Tests.Enabled && !flagNoTests && codeKind(kind) {
+		activity.Set(ctx, activityLLM, req.Path)
+		p.generateTests(fileCtx, logger, out, response)
+	}
+	return out, nil
+}
+
+// requestImages снимки файла для мультимодального режима: сам файл и
+// остальные части группы в формате, который принимает модель
+func requestImages(logger *slog.Logger, req apppipeline.Request) []llm.Image {
+	if !config.Gemini.Multimodal {
+		return nil
+	}
+	var images []llm.Image
+	for _, path := range append([]string{req.Path}, req.Parts...) {
+		mime := llm.ImageMIMEType(path)
+		if mime == "" {
+			logger.Debug(msg.T("llm.image-unsupported"), "image", path)
+			continue
+		}
+		images = append(images, llm.Image{Path: path, MIMEType: mime})
+	}
+	return images
+}
+
+// parentLink путь к ответу на исходный вопрос относительно директории
+// результата
+func parentLink(dir string, parent *conversationTurn) string {
+	if parent == nil {
+		return ""
+	}
+	if rel, err := filepath.Rel(dir, parent.Output); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return parent.Output
+}
+
+// processFileRecover обрабатывает файл, превращая панику в ошибку, чтобы
+// один неудачный файл не останавливал обработчик. Стек пишется в лог на
+// уровне error: паника — это ошибка в программе, а не в файле.
+func processFileRecover(ctx context.Context, req apppipeline.Request) (out string, err error) {
+	defer recoverFile(req.Path, &err)
+	return processFile(ctx, req)
+}
+
+// recognizeRecover и respondRecover этапы processFileRecover по отдельности
+func recognizeRecover(ctx context.Context, req apppipeline.Request) (rec *apppipeline.Recognized, out string, err error) {
+	defer recoverFile(req.Path, &err)
+	return pipeline.Recognize(ctx, req)
+}
+
+func respondRecover(ctx context.Context, rec *apppipeline.Recognized) (out string, err error) {
+	defer recoverFile(rec.Req.Path, &err)
+	return pipeline.Respond(ctx, rec)
+}
+
+// recoverFile вызывается через defer: паника обработки файла path
+// становится ошибкой *err
+func recoverFile(path string, err *error) {
+	if r := recover(); r != nil {
+		slog.Error(msg.T("file.panic"), "file", path, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+		*err = fmt.Errorf("%w (%s): %v", errs.ErrPanic, path, r)
+	}
+}
+
+// handleFile обрабатывает один файл и фиксирует результат в состоянии.
+// Прерванная отменой обработка не записывается, чтобы файл был взят
+// повторно при следующем запуске.
+func handleFile(ctx context.Context, job Job) {
+	ctx, job, req := beginJob(ctx, job)
+	out, err := processFileRecover(ctx, req)
+	finishJob(ctx, job, out, err)
+}
+
+// beginJob отмечает начало обработки задания: время в очереди, статус
+// processing и номер попытки для дампов запросов
+func beginJob(ctx context.Context, job Job) (context.Context, Job, apppipeline.Request) {
+	job.Timings = newStageTimings()
+	if !job.Enqueued.IsZero() {
+		job.Timings.Span(stageWait, job.Enqueued, time.Now(), nil)
+	}
+	prev, _ := state.Get(job.Name)
+	markProcessing(job)
+	ctx = withDumpInfo(ctx, output.Name(job.Name), prev.Attempts+1)
+	req := apppipeline.Request{Path: job.Path, Parts: job.Parts, Name: job.Group, Prompt: config.PROMPT, Timings: job.Timings, ReuseOCR: job.ResumeOCR}
+	return ctx, job, req
+}
+
+// finishJob фиксирует итог обработки задания, начатой beginJob
+func finishJob(ctx context.Context, job Job, out string, err error) {
+	if err != nil && ctx.Err() != nil {
+		slog.Warn(msg.T("file.cancelled"), "file", job.Name, "error", err)
+		return
+	}
+	fs := recordResult(job, out, err)
+	if job.OnDone != nil {
+		job.Timings.Track(stageDelivery, func() error {
+			job.OnDone(fs)
+			return nil
+		})
+	}
+	slog.Info(msg.T("file.done"), append([]any{"file", job.Name, "status", fs.Status}, job.Timings.LogAttrs()...)...)
+	if job.Temp {
+		// Неудачный файл к этому моменту уже перенесён в errorsDir либо
+		// будет скачан заново при повторной попытке
+		os.RemoveAll(filepath.Dir(job.Path))
+	}
+}
+
+// recordSkipped записывает пропуск файла с коротким текстом: повторов
+// нет, файл не считается неудачным и не переносится в ErrorsDir
+func recordSkipped(job Job, skipped *apppipeline.SkippedError) FileState {
+	fs := FileState{Status: statusSkipped, LastError: skipped.Reason, Text: skipped.Text, ETag: job.ETag, Group: job.Group, Session: transcripts.Session()}
+	job.Timings.Record(&fs)
+	for _, name := range append([]string{job.Name}, job.Members...) {
+		if err := state.Set(name, fs); err != nil {
+			slog.Error(msg.T("state.save-failed"), "file", name, "error", err)
+		}
+	}
+	totals.AddSkipped(1)
+	metrics.FileOutcome(statusSkipped)
+	writeJobReport(job, fs, nil)
+	bus.Publish(Event{Kind: EventDone, Name: job.Name, Path: job.Path, State: fs})
+	return fs
+}
+
+// recordResult записывает итог обработки файла в состояние и счётчики.
+// Файл с исчерпанными попытками переносится в ErrorsDir.
+func recordResult(job Job, out string, err error) FileState {
+	name, path := job.Name, job.Path
+	logger := slog.With("file", name)
+	var skipped *apppipeline.SkippedError
+	if errors.As(err, &skipped) {
+		return recordSkipped(job, skipped)
+	}
+	if err != nil {
+		logger.Error(msg.T("file.failed"), "error", err)
+		totals.AddFailed(name, err)
+	} else {
+		totals.AddProcessed()
+	}
+
+	res := FileState{Output: out, ETag: job.ETag, Group: job.Group, Session: transcripts.Session()}
+	job.Timings.Record(&res)
+	maxAttempts := config.MaxAttempts
+	if err != nil && !errs.Retryable(err) {
+		// Повтор не поможет: файл сразу считается неудачным
+		maxAttempts = 1
+	}
+	fs, serr := state.RecordAttempt(name, res, err, maxAttempts, config.RetryDelay)
+	if serr != nil {
+		logger.Error(msg.T("state.save-failed"), "error", serr)
+		return fs
+	}
+	// Остальные части группы получают тот же итог
+	for i, m := range job.Members {
+		mfs, serr := state.RecordAttempt(m, res, err, maxAttempts, config.RetryDelay)
+		if serr != nil {
+			logger.Error(msg.T("state.save-failed"), "part", m, "error", serr)
+			continue
+		}
+		if mfs.Status == statusFailed {
+			if dest, merr := moveToErrors(job.Parts[i], mfs, err); merr == nil && dest != "" && dest != job.Parts[i] {
+				mfs.MovedTo = dest
+				state.Set(m, mfs)
+			}
+		}
+	}
+	metrics.FileOutcome(fs.Status)
+	switch fs.Status {
+	case statusRetry:
+		metrics.Retry()
+		logger.Info(msg.T("file.retry-scheduled"), "attempt", fs.Attempts+1, "maxAttempts", config.MaxAttempts, "at", fs.NextRetry.Format("15:04:05"))
+	case statusFailed:
+		logger.Error(msg.T("file.attempts-exhausted"), "attempt", fs.Attempts)
+		dest, merr := moveToErrors(path, fs, err)
+		if merr != nil {
+			logger.Error(msg.T("errorsdir.move-failed"), "errorsDir", config.ErrorsDir, "error", merr)
+		}
+		if dest != "" && dest != path {
+			fs.MovedTo = dest
+			if serr := state.Set(name, fs); serr != nil {
+				logger.Error(msg.T("state.save-failed"), "error", serr)
+			}
+			logger.Info(msg.T("errorsdir.moved"), "dest", dest)
+		}
+	}
+	writeJobReport(job, fs, err)
+	bus.Publish(Event{Kind: EventDone, Name: name, Path: path, State: fs, Err: err})
+	return fs
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/errs"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/ocr"
+)
+
+// panicOCR паникует на файле bad, как декодер на испорченном снимке
+type panicOCR struct {
+	next ocr.Provider
+	bad  string
+}
+
+func (p panicOCR) ExtractText(ctx context.Context, path string) (string, error) {
+	if filepath.Base(path) == p.bad {
+		var m map[string]int
+		m["boom"]++ // запись в nil map
+	}
+	return p.next.ExtractText(ctx, path)
+}
+
+// Паника провайдера на одном файле не останавливает обработчик: файл
+// сразу неудачный, паника со стеком в логе на уровне ERROR, а следующий
+// файл обрабатывается
+func TestPanickingProviderDoesNotStopWorker(t *testing.T) {
+	testEnv(t, "maxAttempts: 3\n")
+	buf := captureLog(t)
+	pipeline.OCR = panicOCR{next: pipeline.OCR, bad: "bad.png"}
+	writeInput(t, "bad.png", "good.png")
+	failedBefore := totals.FailedCount()
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	bad, _ := state.Get("bad.png")
+	if bad.Status != statusFailed || bad.Attempts != 1 || !strings.Contains(bad.LastError, errs.ErrPanic.Error()) {
+		t.Errorf("bad.png: %s после %d попыток: %s", bad.Status, bad.Attempts, bad.LastError)
+	}
+	if good, _ := state.Get("good.png"); good.Status != statusDone {
+		t.Errorf("good.png: %s %s", good.Status, good.LastError)
+	}
+	if got := totals.FailedCount() - failedBefore; got != 1 {
+		t.Errorf("неудачных файлов %d, want 1", got)
+	}
+	log := buf.String()
+	var panicLine string
+	for _, line := range strings.Split(log, "\n") {
+		if strings.Contains(line, msg.T("file.panic")) {
+			panicLine = line
+		}
+	}
+	if !strings.Contains(panicLine, "level=ERROR") || !strings.Contains(panicLine, "assignment to entry in nil map") ||
+		!strings.Contains(panicLine, "panicOCR.ExtractText") {
+		t.Errorf("в логе нет паники со стеком:\n%s", log)
+	}
+}
+
+func TestRecoverFile(t *testing.T) {
+	captureLog(t)
+	run := func() (err error) {
+		defer recoverFile("in/a.png", &err)
+		panic("сломано")
+	}
+	err := run()
+	if !errors.Is(err, errs.ErrPanic) || errs.Retryable(err) || !strings.Contains(err.Error(), "in/a.png") || !strings.Contains(err.Error(), "сломано") {
+		t.Errorf("err = %v", err)
+	}
+	// Без паники ошибка функции не меняется
+	want := errors.New("обычная ошибка")
+	calm := func() (err error) {
+		defer recoverFile("in/a.png", &err)
+		return want
+	}
+	if err := calm(); err != want {
+		t.Errorf("err = %v", err)
+	}
+}
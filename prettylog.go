@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Итоги обработки файлов в терминале. Запись об окончании обработки
+// (атрибуты file и status) печатается значком статуса с выровненными
+// колонками имени и длительности, остальные записи — обычным текстовым
+// обработчиком. Используется только для text-логов в терминале: JSON,
+// вывод в трубу и файл логов не меняются.
+
+// flagNoColor --no-color: значки и колонки без цвета, как при NO_COLOR
+var flagNoColor bool
+
+// prettyNameWidth ширина колонки имени файла
+const prettyNameWidth = 32
+
+const (
+	ansiReset  = "\033[0m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+)
+
+// prettyMarks значок и цвет итогового статуса файла
+var prettyMarks = map[string]struct{ symbol, color string }{
+	statusDone:    {"✓", ansiGreen},
+	statusFailed:  {"✗", ansiRed},
+	statusRetry:   {"↻", ansiYellow},
+	statusSkipped: {"⏭", ansiDim},
+}
+
+// prettyOutput сообщает, рисовать ли итоги значками, и можно ли цвет:
+// только в терминале, цвет выключают --no-color и NO_COLOR
+func prettyOutput() (pretty, color bool) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return false, false
+	}
+	return true, !flagNoColor && os.Getenv("NO_COLOR") == ""
+}
+
+// prettyHandler рисует итоги обработки файлов в w, остальное отдаёт plain
+type prettyHandler struct {
+	plain slog.Handler
+	w     io.Writer
+	color bool
+	attrs []slog.Attr
+}
+
+func newPrettyHandler(plain slog.Handler, w io.Writer, color bool) *prettyHandler {
+	return &prettyHandler{plain: plain, w: w, color: color}
+}
+
+func (h *prettyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.plain.Enabled(ctx, level)
+}
+
+func (h *prettyHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, ok := h.render(r)
+	if !ok {
+		return h.plain.Handle(ctx, r)
+	}
+	_, err := io.WriteString(h.w, line)
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.plain = h.plain.WithAttrs(attrs)
+	c.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup уводит записи в plain: атрибуты в группе не итоги файла
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	return h.plain.WithGroup(name)
+}
+
+// render строка итога или false, если запись не об окончании обработки.
+// Длительность — сумма этапов без ожидания в очереди, этапы — справа.
+func (h *prettyHandler) render(r slog.Record) (string, bool) {
+	var file, status string
+	var total time.Duration
+	var stages []string
+	visit := func(a slog.Attr) {
+		v := a.Value.Resolve()
+		switch {
+		case a.Key == "file" && v.Kind() == slog.KindString:
+			file = v.String()
+		case a.Key == "status" && v.Kind() == slog.KindString:
+			status = v.String()
+		case v.Kind() == slog.KindDuration:
+			if a.Key != stageWait {
+				total += v.Duration()
+			}
+			stages = append(stages, a.Key+"="+v.Duration().String())
+		}
+	}
+	for _, a := range h.attrs {
+		visit(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		visit(a)
+		return true
+	})
+	mark, ok := prettyMarks[status]
+	if file == "" || !ok {
+		return "", false
+	}
+
+	name := truncateRunes(file, prettyNameWidth)
+	name += strings.Repeat(" ", prettyNameWidth-utf8.RuneCountInString(name))
+	symbol, details := mark.symbol, strings.Join(stages, " ")
+	if h.color {
+		symbol = mark.color + symbol + ansiReset
+		if details != "" {
+			details = ansiDim + details + ansiReset
+		}
+	}
+	line := fmt.Sprintf("%s %s %s %8s", r.Time.Format("15:04:05"), symbol, name, total.Round(time.Millisecond))
+	if details != "" {
+		line += "  " + details
+	}
+	return line + "\n", true
+}
+
+// teeHandler пишет запись в оба обработчика: итоги значками в терминал и
+// обычный текст в файл логов
+type teeHandler struct {
+	a, b slog.Handler
+}
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t.a.Enabled(ctx, level) || t.b.Enabled(ctx, level)
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errA, errB error
+	if t.a.Enabled(ctx, r.Level) {
+		errA = t.a.Handle(ctx, r.Clone())
+	}
+	if t.b.Enabled(ctx, r.Level) {
+		errB = t.b.Handle(ctx, r)
+	}
+	if errA != nil {
+		return errA
+	}
+	return errB
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return teeHandler{t.a.WithAttrs(attrs), t.b.WithAttrs(attrs)}
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	return teeHandler{t.a.WithGroup(name), t.b.WithGroup(name)}
+}
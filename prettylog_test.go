@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// prettyEvent запись лога канонической последовательности
+type prettyEvent struct {
+	level slog.Level
+	msg   string
+	attrs []any
+}
+
+// prettySequences последовательности событий: вся обработка в итогах
+// значками, чужие записи между ними и записи с атрибутами из With и в
+// группе, которые остаются обычным текстом
+var prettySequences = []struct {
+	name   string
+	with   []any
+	group  string
+	events []prettyEvent
+}{
+	{name: "statuses", events: []prettyEvent{
+		{slog.LevelInfo, "Наблюдение запущено", []any{"dir", "in"}},
+		{slog.LevelInfo, "Файл обработан", []any{"file", "task.png", "status", statusDone,
+			stageWait, 3 * time.Second, stageOCR, 812 * time.Millisecond, stageLLM, 2400 * time.Millisecond}},
+		{slog.LevelWarn, "Повтор запроса", []any{"file", "slow.png", "attempt", 2}},
+		{slog.LevelInfo, "Файл обработан", []any{"file", "slow.png", "status", statusRetry, stageOCR, 30 * time.Second}},
+		{slog.LevelError, "Файл обработан", []any{"file", "broken.jpg", "status", statusFailed, stageOCR, 120 * time.Millisecond}},
+		{slog.LevelInfo, "Файл обработан", []any{"file", "desktop.png", "status", statusSkipped}},
+		{slog.LevelInfo, "Файл обработан", []any{"file", "очень-длинное-имя-снимка-экрана-собеседования-2026.png", "status", statusDone,
+			stageLLM, 1500 * time.Microsecond}},
+		// Неизвестный статус и запись без файла — обычным текстом
+		{slog.LevelInfo, "Файл обработан", []any{"file", "odd.png", "status", "processing"}},
+		{slog.LevelInfo, "Итоги", []any{"status", statusDone, "processed", 4}},
+	}},
+	{name: "with", with: []any{"file", "part-1.png"}, events: []prettyEvent{
+		{slog.LevelInfo, "Распознавание", []any{"bytes", 2048}},
+		{slog.LevelInfo, "Файл обработан", []any{"status", statusDone, stageOCR, time.Second}},
+	}},
+	{name: "group", group: "http", events: []prettyEvent{
+		{slog.LevelInfo, "Файл обработан", []any{"file", "task.png", "status", statusDone, stageOCR, time.Second}},
+	}},
+}
+
+// renderPretty выводит последовательность через prettyHandler
+func renderPretty(t *testing.T, with []any, group string, events []prettyEvent, color bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	plain := slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}})
+	logger := slog.New(newPrettyHandler(plain, &buf, color)).With(with...)
+	if group != "" {
+		logger = logger.WithGroup(group)
+	}
+	at := time.Date(2026, 3, 14, 9, 26, 53, 0, time.Local)
+	for i, e := range events {
+		r := slog.NewRecord(at.Add(time.Duration(i)*time.Second), e.level, e.msg, 0)
+		r.Add(e.attrs...)
+		if err := logger.Handler().Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestPrettyLogGolden(t *testing.T) {
+	for _, s := range prettySequences {
+		t.Run(s.name, func(t *testing.T) {
+			checkGolden(t, "prettylog-"+s.name, renderPretty(t, s.with, s.group, s.events, false))
+			checkGolden(t, "prettylog-"+s.name+"-color", renderPretty(t, s.with, s.group, s.events, true))
+		})
+	}
+}
+
+// Файл логов через teeHandler получает обычный текст той же записи
+func TestTeeHandlerPlainCopy(t *testing.T) {
+	var term, file bytes.Buffer
+	pretty := newPrettyHandler(slog.NewTextHandler(&term, nil), &term, false)
+	logger := slog.New(teeHandler{pretty, slog.NewTextHandler(&file, nil)})
+	logger.Info("Файл обработан", "file", "task.png", "status", statusDone)
+	if !bytes.Contains(term.Bytes(), []byte("✓ task.png")) {
+		t.Errorf("терминал: %q", term.String())
+	}
+	if !bytes.Contains(file.Bytes(), []byte("file=task.png status=done")) || bytes.Contains(file.Bytes(), []byte("✓")) {
+		t.Errorf("файл: %q", file.String())
+	}
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// processAlive проверяет, что процесс существует и ещё не завершился
+func processAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	const stillActive = 259
+	return code == stillActive
+}
+
+// isolateCommand на Windows не меняет запуск: по отмене процесс
+// завершается штатно
+func isolateCommand(cmd *exec.Cmd) {}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"regexp"
+
+	"hack_interview/internal/output"
+)
+
+// promptMarkerRe метка промпта в конце имени файла: q17+teach, q17#teach
+var promptMarkerRe = regexp.MustCompile(`^(.+)[+#]([\p{L}\p{N}_-]+)$`)
+
+// splitPromptMarker отделяет метку промпта от имени результата:
+// «q17+teach» → «q17», «teach». Метка отделяется, только если это промпт
+// из библиотеки или compare; иначе имя остаётся целым, чтобы task#1 и
+// task#2 не записались в один task.md.
+func splitPromptMarker(name string) (string, string) {
+	if m := promptMarkerRe.FindStringSubmatch(name); m != nil {
+		if _, ok := config.Prompts[m[2]]; ok || m[2] == compareMarker {
+			return m[1], m[2]
+		}
+	}
+	return name, ""
+}
+
+// namedPrompt промпт из библиотеки prompts по метке в имени файла. Метка
+// compare включает сравнение моделей и промпт не выбирает.
+func namedPrompt(path string) (string, string) {
+	_, name := splitPromptMarker(output.Name(path))
+	if name == "" || name == compareMarker {
+		return "", ""
+	}
+	return name, config.Prompts[name]
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+func TestSplitPromptMarker(t *testing.T) {
+	old := config
+	t.Cleanup(func() { config = old })
+	config.Prompts = map[string]string{"teach": "Объясни подробно"}
+
+	for _, tc := range []struct {
+		in, name, marker string
+	}{
+		{"q17+teach", "q17", "teach"},
+		{"q17#teach", "q17", "teach"},
+		{"q17+compare", "q17", compareMarker},
+		{"task#1", "task#1", ""},
+		{"task#2", "task#2", ""},
+		{"a+b+teach", "a+b", "teach"},
+		{"c++", "c++", ""},
+		{"plain", "plain", ""},
+	} {
+		name, marker := splitPromptMarker(tc.in)
+		if name != tc.name || marker != tc.marker {
+			t.Errorf("splitPromptMarker(%q) = %q, %q; want %q, %q", tc.in, name, marker, tc.name, tc.marker)
+		}
+	}
+}
+
+func TestNamedPrompt(t *testing.T) {
+	old := config
+	t.Cleanup(func() { config = old })
+	config.Prompts = map[string]string{"teach": "Объясни подробно"}
+
+	if name, prompt := namedPrompt("in/q17+teach.png"); name != "teach" || prompt != "Объясни подробно" {
+		t.Errorf("namedPrompt = %q, %q", name, prompt)
+	}
+	for _, path := range []string{"in/q17+compare.png", "in/task#1.png", "in/q17.png"} {
+		if name, prompt := namedPrompt(path); name != "" || prompt != "" {
+			t.Errorf("namedPrompt(%q) = %q, %q", path, name, prompt)
+		}
+	}
+	if a, b := requestName(apppipeline.Request{Path: "in/task#1.png"}), requestName(apppipeline.Request{Path: "in/task#2.png"}); a == b {
+		t.Errorf("одно имя результата %q у task#1 и task#2", a)
+	}
+}
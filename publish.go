@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"hack_interview/internal/classify"
+	"hack_interview/internal/msg"
+)
+
+// Публикация результатов во внешние сервисы. Локальный <имя>.md уже
+// сохранён к моменту публикации, поэтому ошибки сервисов только пишутся в
+// лог. Отправкой владеет одна горутина: медленный сервис не задерживает
+// обработчики, а при переполнении очереди результат не публикуется.
+
+// publishDrainTimeout сколько при завершении ждать отправки очереди
+const publishDrainTimeout = 30 * time.Second
+
+// publishTimeout срок публикации одного результата в одном сервисе
+const publishTimeout = 2 * time.Minute
+
+// publishedResult сохранённый ответ для внешних сервисов
+type publishedResult struct {
+	Name   string
+	Output string
+	Source string
+	// Markdown содержимое <имя>.md
+	Markdown string
+	Question string
+	Kind     string
+	Session  string
+	Time     time.Time
+	// Report отчёт об обработке, куда записываются итоги публикации
+	Report string
+}
+
+// publisher внешний сервис для результатов
+type publisher interface {
+	Name() string
+	// Publish возвращает адрес опубликованного результата, если он есть
+	Publish(ctx context.Context, r publishedResult) (string, error)
+}
+
+// flusher получатель, который копит результаты и отправляет их при
+// завершении
+type flusher interface {
+	Flush()
+}
+
+type publishQueue struct {
+	items   chan publishedResult
+	done    chan struct{}
+	cancel  context.CancelFunc
+	targets []publisher
+}
+
+// publishing nil, пока ни один сервис не настроен
+var publishing *publishQueue
+
+// startPublishing запускает отправку в сервисы из конфигурации
+func startPublishing() {
+	var targets []publisher
+	if config.Notion.Token != "" {
+		targets = append(targets, newNotionPublisher(config.Notion))
+	}
+	if config.Obsidian.Vault != "" {
+		targets = append(targets, obsidianPublisher{config.Obsidian})
+	}
+	if config.Discord.WebhookURL != "" {
+		d, err := newDiscordPublisher(config.Discord.WebhookURL)
+		if err != nil {
+			slog.Warn(msg.T("publish.discord-disabled"), "error", err)
+		} else {
+			targets = append(targets, d)
+		}
+	}
+	if config.Email.Addr != "" {
+		e := emailPublisher{config.Email}
+		if flagEmailTest {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			err := e.Preflight(ctx)
+			cancel()
+			if err != nil {
+				fatal(msg.T("publish.email-test-failed"), "addr", config.Email.Addr, "error", err)
+			}
+			slog.Info(msg.T("publish.email-test-sent"), "to", strings.Join(config.Email.To, ", "))
+		}
+		targets = append(targets, e)
+	}
+	if config.Git.Enabled {
+		if g := newGitCommitter(config.Git); g != nil {
+			targets = append(targets, g)
+		}
+	}
+	if config.GDocs.Credentials != "" && config.GDocs.Publish {
+		w, err := newGDocsWriter(config.GDocs)
+		if err != nil {
+			slog.Warn(msg.T("publish.gdocs-disabled"), "error", err)
+		} else {
+			targets = append(targets, gdocsPublisher{w})
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &publishQueue{
+		items:   make(chan publishedResult, 64),
+		done:    make(chan struct{}),
+		cancel:  cancel,
+		targets: targets,
+	}
+	go q.run(ctx)
+	publishing = q
+}
+
+func (q *publishQueue) run(ctx context.Context) {
+	defer close(q.done)
+	for r := range q.items {
+		for _, t := range q.targets {
+			pubCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+			url, err := t.Publish(pubCtx, r)
+			cancel()
+			d := ReportDelivery{Target: t.Name(), Output: r.Output, URL: url, Time: time.Now()}
+			switch {
+			case err != nil:
+				d.Status, d.Error = deliveryFailed, redactSecrets(err.Error())
+				slog.Warn(msg.T("publish.failed"), "target", t.Name(), "file", r.Source, "error", err)
+			case url == "":
+				d.Status = deliveryQueued
+				slog.Debug(msg.T("publish.queued"), "target", t.Name(), "file", r.Source)
+			default:
+				d.Status = deliveryPublished
+				slog.Info(msg.T("publish.done"), "target", t.Name(), "file", r.Source, "url", url)
+			}
+			reports.Deliver(r.Report, d)
+		}
+	}
+}
+
+// Publish ставит результат в очередь отправки
+func (q *publishQueue) Publish(r publishedResult) {
+	if q == nil {
+		return
+	}
+	select {
+	case q.items <- r:
+	default:
+		slog.Warn(msg.T("publish.queue-full"), "file", r.Source)
+		for _, t := range q.targets {
+			reports.Deliver(r.Report, ReportDelivery{Target: t.Name(), Output: r.Output, Status: deliveryDropped, Time: time.Now()})
+		}
+	}
+}
+
+// Close дожидается отправки очереди, но не дольше publishDrainTimeout
+func (q *publishQueue) Close() {
+	if q == nil {
+		return
+	}
+	close(q.items)
+	select {
+	case <-q.done:
+	case <-time.After(publishDrainTimeout):
+		slog.Warn(msg.T("publish.timeout"))
+		q.cancel()
+		<-q.done
+	}
+	q.cancel()
+	for _, t := range q.targets {
+		if f, ok := t.(flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// kindLabel вид вопроса для внешних сервисов
+func kindLabel(kind classify.Kind) string {
+	if kind == classify.KindGeneric {
+		return "general"
+	}
+	return string(kind)
+}
+
+// questionTitle заголовок результата: первая строка вопроса или имя
+func questionTitle(question, name string) string {
+	for _, line := range strings.Split(question, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return truncateRunes(line, 100)
+		}
+	}
+	return name
+}
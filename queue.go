@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+)
+
+// Job файл, ожидающий обработки
+type Job struct {
+	// Name ключ файла в состоянии
+	Name string
+	// Path путь к файлу на диске
+	Path string
+	// ETag версия объекта во внешнем источнике, если есть
+	ETag string
+	// Temp файл скачан во временную директорию и удаляется после обработки
+	Temp bool
+	// ResumeOCR продолжает прерванную обработку с распознанного ранее текста
+	ResumeOCR bool
+	// OnDone вызывается после записи итога обработки в состояние
+	OnDone   func(FileState)
+	Enqueued time.Time
+	// Seq порядковый номер постановки в очередь
+	Seq uint64
+	// Timings длительности этапов, заполняются при обработке
+	Timings *stageTimings
+	// Group имя результата группы снимков одного вопроса; Members и Parts
+	// имена и пути остальных частей по порядку. Name и Path — первая часть.
+	Group   string
+	Members []string
+	Parts   []string
+}
+
+// Source поставляет файлы в очередь, пока не отменён ctx
+type Source interface {
+	Run(ctx context.Context, q *Queue)
+}
+
+// Queue ограниченная очередь между наблюдателем и обработчиками.
+// Файл считается занятым с момента постановки в очередь и до конца
+// обработки, чтобы повторное сканирование не добавило его второй раз.
+type Queue struct {
+	jobs chan Job
+
+	mu      sync.Mutex
+	pending map[string]bool
+	// deferred сколько файлов не поместилось при последнем сканировании
+	deferred int
+	seq      uint64
+}
+
+func newQueue(size int) *Queue {
+	return &Queue{
+		jobs:    make(chan Job, size),
+		pending: make(map[string]bool),
+	}
+}
+
+// TryPush ставит файл в очередь без блокировки. Возвращает false, если
+// очередь заполнена; такой файл следует предложить снова при следующем
+// сканировании.
+func (q *Queue) TryPush(job Job) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.Enqueued = time.Now()
+	job.Seq = q.seq + 1
+	select {
+	case q.jobs <- job:
+		q.seq = job.Seq
+		q.pending[job.Name] = true
+		for _, m := range job.Members {
+			q.pending[m] = true
+		}
+		metrics.SetQueueDepth(len(q.jobs))
+		bus.Publish(Event{Kind: EventQueued, Name: job.Name, Path: job.Path})
+		return true
+	default:
+		return false
+	}
+}
+
+// Has сообщает, находится ли файл в очереди или в обработке
+func (q *Queue) Has(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending[name]
+}
+
+// Done снимает отметку о занятости файлов задания
+func (q *Queue) Done(job Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, job.Name)
+	for _, m := range job.Members {
+		delete(q.pending, m)
+	}
+}
+
+// WaitIdle ждёт, пока очередь опустеет и все начатые задания завершатся
+func (q *Queue) WaitIdle(ctx context.Context) {
+	for {
+		q.mu.Lock()
+		idle := len(q.pending) == 0
+		q.mu.Unlock()
+		if idle {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// Len текущая глубина очереди
+func (q *Queue) Len() int {
+	return len(q.jobs)
+}
+
+func (q *Queue) Cap() int {
+	return cap(q.jobs)
+}
+
+// Full сообщает, что в очереди нет места: источник, которому файл нужно
+// сначала скачать, проверяет это до скачивания
+func (q *Queue) Full() bool {
+	return len(q.jobs) >= cap(q.jobs)
+}
+
+// startWorkers запускает n обработчиков очереди или, если задан stages,
+// обработчики по этапам. После отмены ctx обработчики доделывают текущий
+// файл и выходят; ещё не начатые задания остаются неотмеченными в
+// состоянии и будут взяты при следующем запуске. Во время паузы новые
+// задания из очереди не берутся.
+func startWorkers(ctx, workCtx context.Context, q *Queue, n int) *sync.WaitGroup {
+	if config.Stages.Enabled() {
+		return startStages(ctx, workCtx, q)
+	}
+	var wg sync.WaitGroup
+	activity.init(n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for {
+				job, ok := nextJob(ctx, q)
+				if !ok {
+					return
+				}
+				handleFile(withWorker(workCtx, id), job)
+				q.Done(job)
+			}
+		}(i)
+	}
+	return &wg
+}
+
+// nextJob ждёт задание из очереди; false, если отменён ctx
+func nextJob(ctx context.Context, q *Queue) (Job, bool) {
+	if !pause.waitWhilePaused(ctx) {
+		return Job{}, false
+	}
+	select {
+	case <-ctx.Done():
+		return Job{}, false
+	case job := <-q.jobs:
+		metrics.SetQueueDepth(q.Len())
+		// Пауза могла начаться, пока обработчик ждал задание
+		if !pause.waitWhilePaused(ctx) || ctx.Err() != nil {
+			q.Done(job)
+			return Job{}, false
+		}
+		return job, true
+	}
+}
+
+// Overflow сообщает итог сканирования: сколько файлов не поместилось в
+// очередь. Предупреждение пишется только при переполнении, а не на каждом
+// сканировании, пока очередь заполнена; освобождение отмечается в логе.
+func (q *Queue) Overflow(deferred int) {
+	q.mu.Lock()
+	was := q.deferred
+	q.deferred = deferred
+	q.mu.Unlock()
+
+	metrics.SetQueueDeferred(deferred)
+	switch {
+	case deferred > 0 && was == 0:
+		slog.Warn(msg.T("queue.full"), "depth", q.Len(), "capacity", q.Cap(), "deferred", deferred)
+	case deferred == 0 && was > 0:
+		slog.Info(msg.T("queue.drained"), "depth", q.Len(), "capacity", q.Cap())
+	}
+}
+
+// Deferred сколько файлов не поместилось в очередь при последнем
+// сканировании
+func (q *Queue) Deferred() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.deferred
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// captureLog перенаправляет slog в буфер до конца теста
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+func TestQueueTryPushFull(t *testing.T) {
+	q := newQueue(2)
+	for _, name := range []string{"a.png", "b.png"} {
+		if !q.TryPush(Job{Name: name}) {
+			t.Fatalf("%s не поставлен в очередь", name)
+		}
+	}
+	if q.TryPush(Job{Name: "c.png"}) {
+		t.Fatal("очередь приняла файл сверх ёмкости")
+	}
+	if !q.Has("a.png") || q.Has("c.png") {
+		t.Error("неверная отметка занятости")
+	}
+	if q.Len() != 2 || q.Cap() != 2 {
+		t.Errorf("Len/Cap = %d/%d", q.Len(), q.Cap())
+	}
+	q.Done(Job{Name: "a.png"})
+	if q.Has("a.png") {
+		t.Error("Done не снял отметку")
+	}
+}
+
+func TestQueueOverflowLogsTransition(t *testing.T) {
+	buf := captureLog(t)
+	q := newQueue(1)
+	for _, n := range []int{3, 3, 2, 0, 0, 1} {
+		q.Overflow(n)
+	}
+	out := buf.String()
+	if got := strings.Count(out, msg.T("queue.full")); got != 2 {
+		t.Errorf("предупреждений о заполнении %d, want 2:\n%s", got, out)
+	}
+	if got := strings.Count(out, msg.T("queue.drained")); got != 1 {
+		t.Errorf("сообщений об освобождении %d, want 1:\n%s", got, out)
+	}
+	if q.Deferred() != 1 {
+		t.Errorf("Deferred = %d", q.Deferred())
+	}
+}
+
+func TestScanDirectoryDefersWhenFull(t *testing.T) {
+	testEnv(t, "")
+	writeInput(t, "a.png", "b.png", "c.png")
+	q := newQueue(1)
+
+	deferred, err := scanDirectory(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deferred != 2 || q.Len() != 1 || q.Deferred() != 2 {
+		t.Errorf("deferred = %d, Len = %d, Deferred = %d", deferred, q.Len(), q.Deferred())
+	}
+	// Отложенные файлы предлагаются снова, как только место освободилось
+	job := <-q.jobs
+	if err := state.Set(job.Name, FileState{Status: statusDone}); err != nil {
+		t.Fatal(err)
+	}
+	q.Done(job)
+	if deferred, _ := scanDirectory(context.Background(), q); deferred != 1 {
+		t.Errorf("повторное сканирование: deferred = %d", deferred)
+	}
+}
+
+// После отмены ctx обработчики доделывают взятые файлы, а оставшиеся в
+// очереди не отмечаются в состоянии и будут взяты при следующем запуске
+func TestWorkersDrainOnShutdown(t *testing.T) {
+	testEnv(t, "  llmLatency: 300ms\n")
+	names := []string{"a.png", "b.png", "c.png", "d.png"}
+	writeInput(t, names...)
+	q := newQueue(len(names))
+	for _, name := range names {
+		q.TryPush(Job{Name: name, Path: filepath.Join(config.InputDir, name)})
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() > 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("обработчики не взяли задания")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("обработчики не завершились после отмены")
+	}
+
+	finished := 0
+	for _, name := range names {
+		fs, ok := state.Get(name)
+		switch {
+		case ok && fs.Status == statusDone:
+			finished++
+		case ok:
+			t.Errorf("%s: статус %q вместо отсутствия записи", name, fs.Status)
+		}
+	}
+	if finished != 2 {
+		t.Errorf("обработано %d, want 2", finished)
+	}
+	if totals.ProcessedCount() != 2 {
+		t.Errorf("ProcessedCount = %d", totals.ProcessedCount())
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+)
+
+// quotaUsage расход одного провайдера за день Day (в его часовом поясе
+// сброса)
+type quotaUsage struct {
+	Day      string `json:"day"`
+	Requests int    `json:"requests"`
+	Tokens   int    `json:"tokens"`
+}
+
+// quotaTracker считает запросы и токены по провайдерам и сохраняет
+// счётчики в файл после каждого изменения. Файл может быть общим у
+// нескольких экземпляров, поэтому каждое изменение, как в jsonStore,
+// выполняется под блокировкой <file>.lock: счётчики перечитываются с
+// диска, изменяются и записываются обратно.
+type quotaTracker struct {
+	mu     sync.Mutex
+	path   string
+	warnAt float64
+	stop   bool
+	limits map[string]appconfig.QuotaLimit
+	zones  map[string]*time.Location
+	Usage  map[string]*quotaUsage `json:"usage"`
+	// warned уровень предупреждения, уже выданный за текущий день
+	warned map[string]string
+}
+
+var quota = &quotaTracker{}
+
+// loadQuota читает сохранённые счётчики; отсутствующий файл — нулевой
+// расход
+func loadQuota(cfg appconfig.QuotaConfig) error {
+	q := &quotaTracker{
+		path:   cfg.File,
+		warnAt: cfg.WarnAt,
+		stop:   cfg.Stop,
+		limits: map[string]appconfig.QuotaLimit{
+			metrics.ProviderOCRSpace: cfg.OCRSpace,
+			metrics.ProviderGemini:   cfg.Gemini,
+		},
+		zones:  make(map[string]*time.Location),
+		Usage:  make(map[string]*quotaUsage),
+		warned: make(map[string]string),
+	}
+	for name, l := range q.limits {
+		loc, err := time.LoadLocation(l.ResetZone)
+		if err != nil {
+			return err
+		}
+		q.zones[name] = loc
+	}
+
+	if err := q.read(); err != nil {
+		return err
+	}
+	quota = q
+	return nil
+}
+
+// read перечитывает счётчики из файла; отсутствующий файл — нулевой
+// расход
+func (q *quotaTracker) read() error {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		q.Usage = make(map[string]*quotaUsage)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var saved struct {
+		Usage map[string]*quotaUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("%s: %w", q.path, err)
+	}
+	if saved.Usage == nil {
+		saved.Usage = make(map[string]*quotaUsage)
+	}
+	q.Usage = saved.Usage
+	return nil
+}
+
+// usage счётчики провайдера за текущий день; с наступлением нового дня в
+// поясе сброса они обнуляются
+func (q *quotaTracker) usage(provider string, now time.Time) *quotaUsage {
+	loc := q.zones[provider]
+	if loc == nil {
+		loc = time.UTC
+	}
+	day := now.In(loc).Format("2006-01-02")
+	u := q.Usage[provider]
+	if u == nil || u.Day != day {
+		u = &quotaUsage{Day: day}
+		q.Usage[provider] = u
+		delete(q.warned, provider)
+	}
+	return u
+}
+
+// AddRequest учитывает отправленный запрос
+func (q *quotaTracker) AddRequest(provider string) {
+	q.add(provider, 1, 0)
+}
+
+// AddTokens учитывает токены ответа
+func (q *quotaTracker) AddTokens(provider string, n int) {
+	q.add(provider, 0, n)
+}
+
+func (q *quotaTracker) add(provider string, requests, tokens int) {
+	if q.Usage == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.update(func() {
+		u := q.usage(provider, time.Now())
+		u.Requests += requests
+		u.Tokens += tokens
+	}); err != nil {
+		slog.Warn(msg.T("quota.save-failed"), "path", q.path, "error", err)
+	}
+	u := q.usage(provider, time.Now())
+
+	l := q.limits[provider]
+	attrs := []any{"provider", provider}
+	if l.DailyRequests > 0 {
+		attrs = append(attrs, "requestsLeft", l.DailyRequests-u.Requests)
+	}
+	if l.DailyTokens > 0 {
+		attrs = append(attrs, "tokensLeft", l.DailyTokens-u.Tokens)
+	}
+	if len(attrs) == 2 {
+		return
+	}
+	slog.Debug(msg.T("quota.remaining"), attrs...)
+
+	level := ""
+	switch {
+	case reached(u.Requests, l.DailyRequests, 1) || reached(u.Tokens, l.DailyTokens, 1):
+		level = "exhausted"
+	case reached(u.Requests, l.DailyRequests, q.warnAt) || reached(u.Tokens, l.DailyTokens, q.warnAt):
+		level = "warn"
+	}
+	if level == "" || q.warned[provider] == level {
+		return
+	}
+	q.warned[provider] = level
+	if level == "warn" {
+		slog.Warn(msg.T("quota.near-limit"), attrs...)
+		return
+	}
+	if q.stop {
+		slog.Error(msg.T("quota.exhausted-paused"), attrs...)
+	} else {
+		slog.Warn(msg.T("quota.exhausted"), attrs...)
+	}
+}
+
+func reached(used, limit int, share float64) bool {
+	return limit > 0 && float64(used) >= float64(limit)*share
+}
+
+// Exhausted возвращает провайдера, чей дневной лимит исчерпан, если
+// включена остановка по квоте
+func (q *quotaTracker) Exhausted() string {
+	if !q.stop {
+		return ""
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	for provider, l := range q.limits {
+		u := q.usage(provider, now)
+		if reached(u.Requests, l.DailyRequests, 1) || reached(u.Tokens, l.DailyTokens, 1) {
+			return provider
+		}
+	}
+	return ""
+}
+
+// update выполняет fn над счётчиками, перечитанными под блокировкой файла,
+// и сохраняет результат. Вызывается под q.mu. Если файл не удалось
+// заблокировать или прочитать, fn всё равно применяется к счётчикам в
+// памяти, чтобы расход не потерялся.
+func (q *quotaTracker) update(fn func()) error {
+	unlock, err := lockFile(q.path + ".lock")
+	if err != nil {
+		fn()
+		return err
+	}
+	defer unlock()
+	if err := q.read(); err != nil {
+		fn()
+		return err
+	}
+	fn()
+	return q.write()
+}
+
+// write сохраняет счётчики через временный файл
+func (q *quotaTracker) write() error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".quota-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
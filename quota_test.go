@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/metrics"
+)
+
+func newTestQuota(t *testing.T, path string) *quotaTracker {
+	t.Helper()
+	old := quota
+	t.Cleanup(func() { quota = old })
+	if err := loadQuota(appconfig.QuotaConfig{File: path, WarnAt: 0.8}); err != nil {
+		t.Fatal(err)
+	}
+	return quota
+}
+
+// Два экземпляра с общим файлом не теряют расход друг друга
+func TestQuotaSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	a := newTestQuota(t, path)
+	b := newTestQuota(t, path)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for _, q := range []*quotaTracker{a, b} {
+		wg.Add(1)
+		go func(q *quotaTracker) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				q.AddRequest(metrics.ProviderGemini)
+				q.AddTokens(metrics.ProviderGemini, 10)
+			}
+		}(q)
+	}
+	wg.Wait()
+
+	c := newTestQuota(t, path)
+	u := c.Usage[metrics.ProviderGemini]
+	if u == nil || u.Requests != 2*n || u.Tokens != 2*n*10 {
+		t.Fatalf("usage = %+v, want %d запросов и %d токенов", u, 2*n, 2*n*10)
+	}
+}
+
+func TestQuotaCorruptFileKeepsCounting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	q := newTestQuota(t, path)
+	q.AddRequest(metrics.ProviderOCRSpace)
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q.AddRequest(metrics.ProviderOCRSpace)
+	if u := q.Usage[metrics.ProviderOCRSpace]; u == nil || u.Requests != 2 {
+		t.Errorf("usage = %+v", u)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Незавершённая обработка отмечается в состоянии статусом processing с
+// владельцем — идентификатором экземпляра. Экземпляр держит блокировку
+// <OutputDir>/.instances/<id>.lock до выхода, поэтому после падения
+// блокировку можно взять: так записи упавшего экземпляра отличаются от
+// записей другого, ещё работающего.
+
+var (
+	instanceOnce sync.Once
+	instanceID   string
+	// instanceLock держит файл блокировки открытым до выхода
+	instanceLock func()
+)
+
+func instancesDir() string {
+	return filepath.Join(config.OutputDir, ".instances")
+}
+
+// ownInstance идентификатор этого экземпляра; при первом вызове берётся
+// блокировка экземпляра, которую освобождает только выход из процесса
+func ownInstance() string {
+	instanceOnce.Do(func() {
+		instanceID = fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+		if err := os.MkdirAll(instancesDir(), os.ModePerm); err != nil {
+			slog.Warn(msg.T("lock.failed"), "error", err)
+			return
+		}
+		unlock, ok, err := tryLockFile(filepath.Join(instancesDir(), instanceID+".lock"))
+		if !ok {
+			slog.Warn(msg.T("lock.failed"), "error", err)
+			return
+		}
+		instanceLock = unlock
+	})
+	return instanceID
+}
+
+// instanceAlive сообщает, работает ли экземпляр id. Блокировка упавшего
+// экземпляра при проверке удаляется.
+func instanceAlive(id string) bool {
+	path := filepath.Join(instancesDir(), id+".lock")
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	unlock, ok, err := tryLockFile(path)
+	if err != nil || !ok {
+		// При ошибке экземпляр считается живым: лучше оставить запись,
+		// чем обработать файл дважды
+		return true
+	}
+	unlock()
+	os.Remove(path)
+	return false
+}
+
+// markProcessing отмечает начало обработки задания, сохраняя счётчик
+// попыток
+func markProcessing(job Job) {
+	owner := ownInstance()
+	for _, name := range append([]string{job.Name}, job.Members...) {
+		fs, _ := state.Get(name)
+		fs.Status, fs.Owner = statusProcessing, owner
+		fs.ETag, fs.Group = job.ETag, job.Group
+		if err := state.Set(name, fs); err != nil {
+			slog.Error(msg.T("state.save-failed"), "file", name, "error", err)
+		}
+	}
+}
+
+// recoverJobs возвращает в работу файлы, обработка которых прервалась при
+// падении или аварийном выходе. Если распознанный текст уже сохранён, файл
+// ставится в очередь с продолжением от запроса к модели; иначе запись
+// сбрасывается, и файл обработается заново при сканировании или
+// повторной доставке источником.
+func recoverJobs(q *Queue) {
+	if err := state.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+		return
+	}
+	var reset []string
+	for name, fs := range state.Snapshot() {
+		if fs.Status != statusProcessing || fs.Owner != "" && instanceAlive(fs.Owner) {
+			continue
+		}
+		logger := slog.With("file", name, "since", fs.UpdatedAt.Local().Format(time.DateTime))
+		path := filepath.Join(config.InputDir, name)
+		if fs.Group == "" && ocrSaved(path, fs.UpdatedAt) && q.TryPush(Job{Name: name, Path: path, ETag: fs.ETag, ResumeOCR: true}) {
+			logger.Warn(msg.T("recover.resume-llm"))
+			continue
+		}
+		logger.Warn(msg.T("recover.restart"))
+		reset = append(reset, name)
+	}
+	if len(reset) > 0 {
+		if err := state.Reset(reset); err != nil {
+			slog.Error(msg.T("state.save-failed"), "error", err)
+		}
+	}
+	cleanInstances()
+}
+
+// ocrSaved сообщает, что файл path на месте, а его распознанный текст
+// сохранён после начала прерванной обработки since, а не остался от
+// прежнего файла с тем же именем
+func ocrSaved(path string, since time.Time) bool {
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(config.OutputDir, requestName(apppipeline.Request{Path: path})+".ocr.txt"))
+	return err == nil && fi.Size() > 0 && !fi.ModTime().Before(since.Truncate(time.Second))
+}
+
+// cleanInstances удаляет блокировки завершившихся экземпляров
+func cleanInstances() {
+	entries, err := os.ReadDir(instancesDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".lock"); ok && id != instanceID {
+			instanceAlive(id)
+		}
+	}
+}
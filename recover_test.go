@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// failOCR проваливает тест при запросе распознавания
+type failOCR struct{ t *testing.T }
+
+func (f failOCR) ExtractText(_ context.Context, path string) (string, error) {
+	f.t.Errorf("повторное распознавание %s", path)
+	return "", nil
+}
+
+// seedProcessing записывает файл name как прерванный упавшим экземпляром
+// owner и сохраняет распознанный текст text, если он не пустой
+func seedProcessing(t *testing.T, name, owner, text string) string {
+	t.Helper()
+	writeInput(t, name)
+	if err := state.Set(name, FileState{Status: statusProcessing, Owner: owner}); err != nil {
+		t.Fatal(err)
+	}
+	sidecar := filepath.Join(config.OutputDir, requestName(apppipeline.Request{Path: filepath.Join(config.InputDir, name)})+".ocr.txt")
+	if text != "" {
+		if err := os.WriteFile(sidecar, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sidecar
+}
+
+// Упавшая между OCR и ответом обработка продолжается с запроса к модели
+// по сохранённому тексту, без повторного распознавания
+func TestRecoverResumesFromOCR(t *testing.T) {
+	testEnv(t, "")
+	buf := captureLog(t)
+	seedProcessing(t, "a.png", "1-1", "Сохранённый вопрос про каналы")
+	pipeline.OCR = failOCR{t}
+	counter := &countingLLM{next: pipeline.LLM}
+	pipeline.LLM = counter
+
+	q := newQueue(config.QueueSize)
+	recoverJobs(q)
+	if q.Len() != 1 {
+		t.Fatalf("в очереди %d заданий, want 1", q.Len())
+	}
+	if !strings.Contains(buf.String(), msg.T("recover.resume-llm")) || !strings.Contains(buf.String(), "file=a.png") {
+		t.Errorf("в логе нет восстановления:\n%s", buf)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	fs, _ := state.Get("a.png")
+	if fs.Status != statusDone || fs.Owner != "" {
+		t.Errorf("a.png: %s %s, владелец %q", fs.Status, fs.LastError, fs.Owner)
+	}
+	if len(counter.prompts) != 1 || !strings.Contains(counter.prompts[0], "Сохранённый вопрос про каналы") {
+		t.Errorf("промпты модели %q", counter.prompts)
+	}
+}
+
+// Без распознанного текста или с текстом от прежнего файла с тем же
+// именем запись сбрасывается, и файл обрабатывается заново
+func TestRecoverRestarts(t *testing.T) {
+	testEnv(t, "")
+	buf := captureLog(t)
+	seedProcessing(t, "fresh.png", "1-1", "")
+	stale := seedProcessing(t, "stale.png", "1-1", "Старый текст")
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	q := newQueue(config.QueueSize)
+	recoverJobs(q)
+	if q.Len() != 0 {
+		t.Errorf("в очереди %+v", popJobs(q))
+	}
+	for _, name := range []string{"fresh.png", "stale.png"} {
+		if _, ok := state.Get(name); ok {
+			t.Errorf("запись %s не сброшена", name)
+		}
+	}
+	if strings.Count(buf.String(), msg.T("recover.restart")) != 2 {
+		t.Errorf("лог:\n%s", buf)
+	}
+	if _, err := scanDirectory(context.Background(), q); err != nil || q.Len() != 2 {
+		t.Errorf("сканирование: в очереди %d, %v", q.Len(), err)
+	}
+}
+
+// Записи работающего экземпляра, держащего блокировку, не трогаются, а
+// блокировка упавшего удаляется
+func TestRecoverSkipsLiveInstance(t *testing.T) {
+	testEnv(t, "")
+	captureLog(t)
+	seedProcessing(t, "live.png", "2-2", "Текст")
+	seedProcessing(t, "dead.png", "3-3", "")
+	if err := os.MkdirAll(instancesDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	unlock, ok, err := tryLockFile(filepath.Join(instancesDir(), "2-2.lock"))
+	if !ok {
+		t.Fatal(err)
+	}
+	defer unlock()
+	deadLock := filepath.Join(instancesDir(), "3-3.lock")
+	os.WriteFile(deadLock, nil, 0644)
+
+	q := newQueue(config.QueueSize)
+	recoverJobs(q)
+	if fs, _ := state.Get("live.png"); fs.Status != statusProcessing || fs.Owner != "2-2" || q.Has("live.png") {
+		t.Errorf("запись работающего экземпляра изменена: %+v", fs)
+	}
+	if _, ok := state.Get("dead.png"); ok {
+		t.Error("запись упавшего экземпляра не сброшена")
+	}
+	if _, err := os.Stat(deadLock); !os.IsNotExist(err) {
+		t.Errorf("блокировка упавшего экземпляра осталась: %v", err)
+	}
+}
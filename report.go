@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Отчёт об обработке файла <имя>.report.json рядом с ответом: что
+// происходило на каждом этапе, какие провайдеры ответили, попытки с
+// ошибками, токены, файлы результатов и публикации. Пишется после каждой
+// попытки, в том числе неудачной, и дополняется итогами публикации;
+// REST API отдаёт ту же структуру в поле report.
+
+// reportVersion версия схемы Report; увеличивается при несовместимом
+// изменении полей
+const reportVersion = 1
+
+const reportSuffix = ".report.json"
+
+// Итоги этапа
+const (
+	outcomeOK    = "ok"
+	outcomeError = "error"
+)
+
+// Итоги публикации
+const (
+	deliveryPublished = "published" // сервис вернул адрес результата
+	deliveryQueued    = "queued"    // принят, адреса нет или отправка позже
+	deliveryFailed    = "failed"
+	deliveryDropped   = "dropped" // очередь публикации переполнена
+)
+
+// Report отчёт об обработке одного файла
+type Report struct {
+	Version int         `json:"version"`
+	Name    string      `json:"name"`
+	Input   ReportInput `json:"input"`
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Session string      `json:"session,omitempty"`
+	// Stages этапы последней попытки в порядке выполнения
+	Stages    []ReportStage    `json:"stages"`
+	Providers []ReportProvider `json:"providers,omitempty"`
+	// Attempts все попытки, включая прошлые запуски
+	Attempts []ReportAttempt `json:"attempts"`
+	Tokens   ReportTokens    `json:"tokens"`
+	// Inverts решения об инверсии тёмных снимков (image.invert)
+	Inverts []ReportInvert `json:"inverts,omitempty"`
+	Outputs []string       `json:"outputs,omitempty"`
+	// Deliveries публикации во внешние сервисы по всем попыткам
+	Deliveries []ReportDelivery `json:"deliveries,omitempty"`
+	UpdatedAt  time.Time        `json:"updatedAt"`
+}
+
+// ReportInput входной файл; SHA256 пустой, если файл уже недоступен
+type ReportInput struct {
+	Path    string   `json:"path"`
+	Parts   []string `json:"parts,omitempty"`
+	SHA256  string   `json:"sha256,omitempty"`
+	Size    int64    `json:"size,omitempty"`
+	MovedTo string   `json:"movedTo,omitempty"`
+}
+
+type ReportStage struct {
+	Name       string    `json:"name"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	DurationMs int64     `json:"durationMs"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type ReportProvider struct {
+	Stage    string `json:"stage"`
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+type ReportAttempt struct {
+	Attempt int       `json:"attempt"`
+	Time    time.Time `json:"time"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ReportInvert решение об инверсии снимка File; Luminance -1, если яркость
+// не измерялась
+type ReportInvert struct {
+	File      string `json:"file"`
+	Mode      string `json:"mode"`
+	Threshold int    `json:"threshold"`
+	Luminance int    `json:"luminance"`
+	Inverted  bool   `json:"inverted"`
+}
+
+type ReportTokens struct {
+	Prompt   int `json:"prompt"`
+	Response int `json:"response"`
+}
+
+type ReportDelivery struct {
+	Target string    `json:"target"`
+	Output string    `json:"output"`
+	Status string    `json:"status"`
+	URL    string    `json:"url,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// reportPath путь отчёта о результате name в dir
+func reportPath(dir, name string) string {
+	return filepath.Join(dir, name+reportSuffix)
+}
+
+// jobReportPath путь отчёта задания: рядом с ответом out или, если ответа
+// нет, в OutputDir под тем же именем
+func jobReportPath(job Job, out string) string {
+	dir := config.OutputDir
+	if out != "" {
+		dir = filepath.Dir(out)
+	}
+	return reportPath(dir, requestName(apppipeline.Request{Path: job.Path, Name: job.Group}))
+}
+
+// reportWriter сериализует запись отчётов: публикация идёт в своей
+// горутине и может закончиться раньше, чем записан отчёт попытки, — такие
+// итоги ждут в pending
+type reportWriter struct {
+	mu      sync.Mutex
+	pending map[string][]ReportDelivery
+}
+
+var reports = &reportWriter{pending: make(map[string][]ReportDelivery)}
+
+// Write записывает отчёт попытки, дополняя его попытками и публикациями из
+// прежнего отчёта
+func (w *reportWriter) Write(path string, r Report) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if prev, err := readReport(path); err == nil {
+		r.Attempts = append(prev.Attempts, r.Attempts...)
+		r.Deliveries = append(prev.Deliveries, r.Deliveries...)
+	}
+	for i := range r.Attempts {
+		r.Attempts[i].Attempt = i + 1
+	}
+	r.Deliveries = append(r.Deliveries, w.pending[path]...)
+	delete(w.pending, path)
+	return writeReport(path, r)
+}
+
+// Deliver добавляет итог публикации к отчёту path
+func (w *reportWriter) Deliver(path string, d ReportDelivery) {
+	if path == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r, err := readReport(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		w.pending[path] = append(w.pending[path], d)
+		return
+	}
+	if err != nil {
+		slog.Warn(msg.T("report.write-failed"), "path", path, "error", err)
+		return
+	}
+	r.Deliveries = append(r.Deliveries, d)
+	r.UpdatedAt = time.Now()
+	if err := writeReport(path, r); err != nil {
+		slog.Warn(msg.T("report.write-failed"), "path", path, "error", err)
+	}
+}
+
+func readReport(path string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(data, &r)
+	return r, err
+}
+
+func writeReport(path string, r Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeJobReport записывает отчёт о попытке обработки задания. Ошибка
+// только пишется в лог: итог уже записан в состояние.
+func writeJobReport(job Job, fs FileState, procErr error) {
+	r := Report{
+		Version: reportVersion,
+		Name:    job.Name,
+		Input:   ReportInput{Path: job.Path, Parts: job.Parts, MovedTo: fs.MovedTo},
+		Status:  fs.Status,
+		Session: fs.Session,
+		Stages:  []ReportStage{},
+		Tokens:  ReportTokens{Prompt: fs.TokensIn, Response: fs.TokensOut},
+	}
+	if procErr != nil {
+		r.Error = redactSecrets(procErr.Error())
+	} else if fs.Status == statusSkipped {
+		r.Error = fs.LastError
+	}
+	for _, path := range []string{job.Path, fs.MovedTo} {
+		if sum, size, err := hashFile(path); err == nil {
+			r.Input.SHA256, r.Input.Size = sum, size
+			break
+		}
+	}
+	if t := job.Timings; t != nil {
+		t.mu.Lock()
+		r.Stages = append(r.Stages, t.spans...)
+		r.Providers = append(r.Providers, t.providers...)
+		r.Outputs = append(r.Outputs, t.outputs...)
+		r.Inverts = append(r.Inverts, t.inverts...)
+		t.mu.Unlock()
+	}
+	if fs.Output != "" && !slices.Contains(r.Outputs, fs.Output) {
+		r.Outputs = append(r.Outputs, fs.Output)
+	}
+	now := time.Now()
+	r.Attempts = []ReportAttempt{{Time: now, Status: fs.Status, Error: r.Error}}
+	r.UpdatedAt = now
+
+	path := jobReportPath(job, fs.Output)
+	if err := reports.Write(path, r); err != nil {
+		slog.Warn(msg.T("report.write-failed"), "path", path, "error", err)
+	}
+}
+
+func hashFile(path string) (string, int64, error) {
+	if path == "" {
+		return "", 0, fs.ErrNotExist
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
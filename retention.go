@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// Сроки хранения (retention в config.yml). Проход очистки сначала
+// составляет план — файлы и записи состояния к удалению, — затем выполняет
+// его; cleanup --dry-run только печатает план. Возраст сравнивается как
+// момент времени: mtime файла или updatedAt записи против now минус срок,
+// без перевода в календарные даты, поэтому часовой пояс и переход на
+// летнее время на решение не влияют, а файл с mtime в будущем не стареет.
+//
+// В OutputDir и SessionsDir не трогаются файлы и директории с точкой в
+// начале имени (состояние, квота, .debug, .trash, .git), входная
+// директория, errorsDir, capture.saveDir, записи cassette и файл логов, если они
+// лежат внутри.
+
+const (
+	// pinnedMarker файл в SessionsDir/<id>, закрепляющий сессию
+	pinnedMarker = "pinned"
+	// trashDir корзина в OutputDir: в ней по директории на проход очистки
+	trashDir = ".trash"
+	// retentionInterval как часто очистка повторяется в режиме наблюдения
+	retentionInterval = 24 * time.Hour
+)
+
+// Виды удаляемого в плане очистки
+const (
+	cleanupOutput = "output"
+	cleanupDebug  = "debug"
+	cleanupTrash  = "trash"
+)
+
+type cleanupFile struct {
+	Path    string
+	Kind    string
+	ModTime time.Time
+}
+
+// cleanupPlan что удалит проход очистки
+type cleanupPlan struct {
+	Files []cleanupFile
+	// Rows записи состояния о файлах, которых больше нет ни во входной
+	// директории, ни среди результатов
+	Rows []string
+}
+
+// startRetention очищает просроченное сразу и далее раз в сутки, пока не
+// отменён ctx; без сроков хранения ничего не делает
+func startRetention(ctx context.Context) {
+	if !config.Retention.Enabled() {
+		return
+	}
+	go func() {
+		t := time.NewTicker(retentionInterval)
+		defer t.Stop()
+		for {
+			runRetention(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// runCleanup один проход очистки по retention в config.yml; --dry-run
+// только печатает, что было бы удалено
+func runCleanup(args []string) int {
+	fset := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	dryRun := fset.Bool("dry-run", false, "только показать, что было бы удалено")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+	if !config.Retention.Enabled() {
+		fmt.Fprintln(os.Stderr, msg.T("cleanup.disabled"))
+		return 2
+	}
+
+	now := time.Now()
+	plan := planCleanup(now, state.Snapshot())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range plan.Files {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", f.Kind, f.ModTime.Local().Format("2006-01-02 15:04"), f.Path)
+	}
+	for _, name := range plan.Rows {
+		fs, _ := state.Get(name)
+		fmt.Fprintf(w, "state\t%s\t%s\n", fs.UpdatedAt.Local().Format("2006-01-02 15:04"), name)
+	}
+	w.Flush()
+	if *dryRun {
+		fmt.Printf(msg.T("cleanup.would"), len(plan.Files), len(plan.Rows))
+		return 0
+	}
+	removed, err := plan.apply(now)
+	if err != nil {
+		slog.Error(msg.T("state.save-failed"), "error", err)
+		return 1
+	}
+	fmt.Printf(msg.T("cleanup.done"), removed, len(plan.Rows))
+	if removed < len(plan.Files) {
+		return 1
+	}
+	return 0
+}
+
+// runRetention один проход очистки; ошибки только пишутся в лог
+func runRetention(now time.Time) {
+	if err := state.Refresh(); err != nil {
+		slog.Warn(msg.T("retention.failed"), "error", err)
+		return
+	}
+	plan := planCleanup(now, state.Snapshot())
+	removed, err := plan.apply(now)
+	if err != nil {
+		slog.Warn(msg.T("retention.failed"), "error", err)
+		return
+	}
+	if removed > 0 || len(plan.Rows) > 0 {
+		slog.Info(msg.T("retention.done"), "files", removed, "rows", len(plan.Rows), "trash", config.Retention.Trash)
+	}
+}
+
+// planCleanup план очистки на момент now по записям состояния files
+func planCleanup(now time.Time, files map[string]FileState) cleanupPlan {
+	cfg := config.Retention
+	g := newRetentionGuard(files)
+	var plan cleanupPlan
+
+	if cfg.Outputs > 0 {
+		cutoff := now.Add(-cfg.Outputs)
+		roots := []string{config.OutputDir}
+		if !within(absPath(config.SessionsDir), absPath(config.OutputDir)) {
+			roots = append(roots, config.SessionsDir)
+		}
+		for _, root := range roots {
+			plan.Files = append(plan.Files, expiredFiles(root, cutoff, cleanupOutput, g.skip)...)
+		}
+		plan.Rows = g.expiredRows(files, cutoff, plan.Files)
+	}
+	if cfg.Debug > 0 {
+		dir := filepath.Join(config.OutputDir, ".debug")
+		plan.Files = append(plan.Files, expiredFiles(dir, now.Add(-cfg.Debug), cleanupDebug, nil)...)
+	}
+	if entries, err := os.ReadDir(filepath.Join(config.OutputDir, trashDir)); err == nil {
+		cutoff := now.Add(-cfg.TrashKeep)
+		for _, e := range entries {
+			info, err := e.Info()
+			if err == nil && info.ModTime().Before(cutoff) {
+				path := filepath.Join(config.OutputDir, trashDir, e.Name())
+				plan.Files = append(plan.Files, cleanupFile{Path: path, Kind: cleanupTrash, ModTime: info.ModTime()})
+			}
+		}
+	}
+	return plan
+}
+
+// expiredFiles файлы в root с mtime раньше cutoff; skip исключает файлы и
+// целые директории
+func expiredFiles(root string, cutoff time.Time, kind string, skip func(path string, d os.DirEntry) bool) []cleanupFile {
+	var out []cleanupFile
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// root нет или директория не читается: в ней ничего не удаляется
+			return nil
+		}
+		if path != root && skip != nil && skip(path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		if info.ModTime().Before(cutoff) {
+			out = append(out, cleanupFile{Path: path, Kind: kind, ModTime: info.ModTime()})
+		}
+		return nil
+	})
+	return out
+}
+
+// retentionGuard что очистка результатов не трогает
+type retentionGuard struct {
+	pinned map[string]bool
+	// stems пути результатов вопросов закреплённых сессий без расширения:
+	// ответ, распознанный текст и отчёт отличаются только расширениями
+	stems map[string]bool
+	// keepDirs и keepFiles абсолютные пути
+	keepDirs  map[string]bool
+	keepFiles map[string]bool
+	sessions  string
+	logStem   string
+}
+
+func newRetentionGuard(files map[string]FileState) *retentionGuard {
+	g := &retentionGuard{
+		pinned:    pinnedSessions(),
+		stems:     make(map[string]bool),
+		keepDirs:  make(map[string]bool),
+		keepFiles: make(map[string]bool),
+		sessions:  absPath(config.SessionsDir),
+	}
+	for _, dir := range []string{config.InputDir, config.ErrorsDir, config.Capture.SaveDir, config.Cassette.Dir} {
+		if dir != "" {
+			g.keepDirs[absPath(dir)] = true
+		}
+	}
+	for _, file := range []string{config.StateFile, config.StateDB, config.LogFile, configPath} {
+		if file != "" {
+			g.keepFiles[absPath(file)] = true
+		}
+	}
+	if config.LogFile != "" {
+		// ротированные копии: <имя>-<время><расширение>
+		log := absPath(config.LogFile)
+		g.logStem = strings.TrimSuffix(log, filepath.Ext(log)) + "-"
+	}
+	for name, fs := range files {
+		if !g.pinned[fs.Session] {
+			continue
+		}
+		job := Job{Name: name, Path: filepath.Join(config.InputDir, name), Group: fs.Group}
+		g.stems[stem(absPath(strings.TrimSuffix(jobReportPath(job, fs.Output), reportSuffix)))] = true
+		if fs.Output != "" {
+			g.stems[stem(absPath(fs.Output))] = true
+		}
+	}
+	return g
+}
+
+// pinnedSessions идентификаторы сессий с файлом pinned
+func pinnedSessions() map[string]bool {
+	pinned := make(map[string]bool)
+	entries, err := os.ReadDir(config.SessionsDir)
+	if err != nil {
+		return pinned
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(config.SessionsDir, e.Name(), pinnedMarker)); err == nil {
+			pinned[e.Name()] = true
+		}
+	}
+	return pinned
+}
+
+func (g *retentionGuard) skip(path string, d os.DirEntry) bool {
+	if strings.HasPrefix(d.Name(), ".") {
+		return true
+	}
+	abs := absPath(path)
+	if d.IsDir() {
+		return g.keepDirs[abs] || filepath.Dir(abs) == g.sessions && g.pinned[d.Name()]
+	}
+	if g.keepFiles[abs] || g.logStem != "" && strings.HasPrefix(abs, g.logStem) {
+		return true
+	}
+	for p := abs; ; p = stem(p) {
+		if g.stems[p] {
+			return true
+		}
+		if stem(p) == p {
+			return false
+		}
+	}
+}
+
+// expiredRows записи, обновлённые раньше cutoff, о файлах, которых нет во
+// входной директории и в errorsDir и чей результат удаляется или уже
+// удалён. Запись о файле, который ещё лежит во входной директории, нужна,
+// чтобы он не был обработан заново, поэтому остаётся. Не удаляются записи
+// о файлах в обработке или ждущих повтора, о закреплённых сессиях и о
+// внешних источниках (s3://, imap://): по ним источник узнаёт, что объект
+// уже скачан.
+func (g *retentionGuard) expiredRows(files map[string]FileState, cutoff time.Time, removed []cleanupFile) []string {
+	gone := make(map[string]bool, len(removed))
+	for _, f := range removed {
+		gone[absPath(f.Path)] = true
+	}
+	var rows []string
+	for name, fs := range files {
+		switch {
+		case fs.Status == statusProcessing || fs.Status == statusRetry,
+			g.pinned[fs.Session],
+			strings.Contains(name, "://"),
+			fs.UpdatedAt.IsZero() || !fs.UpdatedAt.Before(cutoff),
+			exists(filepath.Join(config.InputDir, name)),
+			fs.MovedTo != "" && exists(fs.MovedTo),
+			fs.Output != "" && !gone[absPath(fs.Output)] && exists(fs.Output):
+			continue
+		}
+		rows = append(rows, name)
+	}
+	sort.Strings(rows)
+	return rows
+}
+
+// apply выполняет план: файлы удаляются или при retention.trash
+// переносятся в корзину, опустевшие директории удаляются, затем
+// удаляются записи состояния. Файл, который не удалось удалить или
+// перенести, пропускается с предупреждением. Возвращает число удалённых
+// файлов.
+func (p cleanupPlan) apply(now time.Time) (int, error) {
+	trash := filepath.Join(config.OutputDir, trashDir, now.Format("20060102-150405"))
+	removed := 0
+	for _, f := range p.Files {
+		var err error
+		switch {
+		case f.Kind == cleanupTrash:
+			err = os.RemoveAll(f.Path)
+		case config.Retention.Trash:
+			err = moveToTrash(f.Path, trash)
+		default:
+			err = os.Remove(f.Path)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn(msg.T("retention.remove-failed"), "path", f.Path, "error", err)
+			continue
+		}
+		removed++
+		if f.Kind == cleanupOutput {
+			removeEmptyDirs(filepath.Dir(f.Path))
+		}
+	}
+	if len(p.Rows) > 0 {
+		if err := state.Reset(p.Rows); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// moveToTrash переносит файл в trash с тем же путём относительно OutputDir
+// или, для SessionsDir вне OutputDir, относительно родителя SessionsDir
+func moveToTrash(path, trash string) error {
+	rel, err := filepath.Rel(absPath(config.OutputDir), absPath(path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		if rel, err = filepath.Rel(filepath.Dir(absPath(config.SessionsDir)), absPath(path)); err != nil {
+			return err
+		}
+	}
+	dst := filepath.Join(trash, rel)
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(path, dst)
+}
+
+// removeEmptyDirs удаляет опустевшую директорию результатов и её
+// опустевших родителей до OutputDir и SessionsDir, не включая их
+func removeEmptyDirs(dir string) {
+	out, sessions := absPath(config.OutputDir), absPath(config.SessionsDir)
+	for d := absPath(dir); d != out && d != sessions && (within(d, out) || within(d, sessions)); d = filepath.Dir(d) {
+		if os.Remove(d) != nil {
+			return
+		}
+	}
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// within сообщает, лежит ли path внутри dir или совпадает с ней
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// stem путь без расширения
+func stem(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
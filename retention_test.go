@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// writeAged создаёт файл path с временем изменения mtime
+func writeAged(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("ответ"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// planned пути файлов и записи состояния плана
+func planned(plan cleanupPlan) ([]string, []string) {
+	var files []string
+	for _, f := range plan.Files {
+		files = append(files, filepath.ToSlash(f.Path))
+	}
+	sort.Strings(files)
+	return files, plan.Rows
+}
+
+// Срок отсчитывается от момента, а не от календарной даты: в любом
+// часовом поясе, в том числе в день перехода на летнее время, когда
+// вчерашний полдень был меньше суток назад, удаляется только то, что
+// старше срока
+func TestPlanCleanupTimezones(t *testing.T) {
+	zones := []*time.Location{time.UTC, time.FixedZone("UTC+14", 14*3600), time.FixedZone("UTC-12", -12*3600)}
+	for _, name := range []string{"America/New_York", "Europe/Moscow", "Australia/Lord_Howe"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zones = append(zones, loc)
+	}
+	oldLocal := time.Local
+	t.Cleanup(func() { time.Local = oldLocal })
+
+	for _, loc := range zones {
+		t.Run(loc.String(), func(t *testing.T) {
+			testEnv(t, "")
+			time.Local = loc
+			config.Retention.Outputs = 24 * time.Hour
+			// 8 марта 2026 в Нью-Йорке переводят часы на летнее время
+			now := time.Date(2026, 3, 8, 12, 0, 0, 0, loc)
+			y, m, d := now.Date()
+			for name, mtime := range map[string]time.Time{
+				"today.md":     now.Add(-time.Hour),
+				"midnight.md":  time.Date(y, m, d, 0, 0, 0, 0, loc),
+				"yesterday.md": time.Date(y, m, d-1, 12, 30, 0, 0, loc),
+				"future.md":    now.Add(2 * time.Hour),
+				"utc.md":       now.UTC().Add(-23 * time.Hour),
+				"old.md":       now.Add(-24*time.Hour - time.Minute),
+			} {
+				writeAged(t, filepath.Join(config.OutputDir, name), mtime)
+			}
+			if dst := now.Sub(time.Date(y, m, d-1, 12, 30, 0, 0, loc)); dst >= 24*time.Hour {
+				t.Fatalf("вчерашний файл старше суток: %v", dst)
+			}
+
+			files := map[string]FileState{
+				// Свежие записи в UTC и UTC+14 и запись старше срока
+				"fresh.png": {Status: statusDone, UpdatedAt: now.UTC().Add(-time.Hour)},
+				"stale.png": {Status: statusDone, UpdatedAt: now.Add(-25 * time.Hour)},
+				"ahead.png": {Status: statusDone, UpdatedAt: now.In(time.FixedZone("UTC+14", 14*3600)).Add(-23 * time.Hour)},
+			}
+			got, rows := planned(planCleanup(now, files))
+			if len(got) != 1 || got[0] != "out/old.md" {
+				t.Errorf("удаляются %q, want только out/old.md", got)
+			}
+			if len(rows) != 1 || rows[0] != "stale.png" {
+				t.Errorf("записи %q, want только stale.png", rows)
+			}
+		})
+	}
+}
+
+// Проход очистки удаляет только просроченное, переносит его в корзину при
+// retention.trash и не трогает результаты закреплённой сессии
+func TestRunRetention(t *testing.T) {
+	testEnv(t, "")
+	config.Retention.Outputs = 90 * 24 * time.Hour
+	config.Retention.Trash = true
+	now := time.Now()
+	old := now.Add(-91 * 24 * time.Hour)
+
+	writeAged(t, filepath.Join(config.OutputDir, "fresh.md"), now.Add(-time.Minute))
+	writeAged(t, filepath.Join(config.OutputDir, "expired.md"), old)
+	writeAged(t, filepath.Join(config.OutputDir, "pinned.md"), old)
+	writeAged(t, filepath.Join(config.OutputDir, "pinned.ocr.txt"), old)
+	writeAged(t, filepath.Join(config.SessionsDir, "s1", pinnedMarker), old)
+	writeAged(t, filepath.Join(config.SessionsDir, "s1", "transcript.md"), old)
+	writeAged(t, filepath.Join(config.SessionsDir, "s2", "transcript.md"), old)
+	err := state.(*jsonStore).Update(func(files map[string]*FileState) {
+		files["pinned.png"] = &FileState{Status: statusDone, Session: "s1", Output: filepath.Join(config.OutputDir, "pinned.md"), UpdatedAt: old}
+		files["expired.png"] = &FileState{Status: statusDone, Session: "s2", Output: filepath.Join(config.OutputDir, "expired.md"), UpdatedAt: old}
+		files["today.png"] = &FileState{Status: statusDone, Session: "s2", UpdatedAt: now}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runRetention(now)
+
+	for path, want := range map[string]bool{
+		"fresh.md":                  true,
+		"expired.md":                false,
+		"pinned.md":                 true,
+		"pinned.ocr.txt":            true,
+		"sessions/s1/transcript.md": true,
+		"sessions/s2/transcript.md": false,
+	} {
+		if got := exists(filepath.Join(config.OutputDir, path)); got != want {
+			t.Errorf("%s: есть %v, want %v", path, got, want)
+		}
+	}
+	trash := filepath.Join(config.OutputDir, trashDir, now.Format("20060102-150405"))
+	if !exists(filepath.Join(trash, "expired.md")) || !exists(filepath.Join(trash, "sessions", "s2", "transcript.md")) {
+		t.Errorf("просроченное не в корзине %s", trash)
+	}
+	if _, ok := state.Get("expired.png"); ok {
+		t.Error("запись expired.png не удалена")
+	}
+	for _, name := range []string{"pinned.png", "today.png"} {
+		if _, ok := state.Get(name); !ok {
+			t.Errorf("запись %s удалена", name)
+		}
+	}
+}
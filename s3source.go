@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"hack_interview/internal/msg"
+)
+
+// s3Source опрашивает бакет и скачивает новые объекты во временную
+// директорию. Обработанные ключи и их ETag хранятся в состоянии под именами
+// s3://bucket/key, поэтому после перезапуска объекты не скачиваются заново.
+type s3Source struct {
+	client *s3.Client
+	cfg    S3Config
+}
+
+func newS3Source(ctx context.Context) (*s3Source, error) {
+	cfg := config.S3
+	opts := []func(*awsconfig.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+	return &s3Source{client: client, cfg: cfg}, nil
+}
+
+func (s *s3Source) Run(ctx context.Context, q *Queue) {
+	slog.Info(msg.T("s3.watching"), "bucket", s.cfg.Bucket, "prefix", s.cfg.Prefix)
+	for {
+		if err := s.poll(ctx, q); err != nil && ctx.Err() == nil {
+			slog.Error(msg.T("s3.poll-failed"), "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.Interval):
+		}
+	}
+}
+
+func (s *s3Source) stateName(key string) string {
+	return "s3://" + s.cfg.Bucket + "/" + key
+}
+
+// poll проходит все страницы листинга. Один и тот же ключ может встретиться
+// повторно (в том числе из-за согласованности листинга), поэтому решение
+// принимается по ETag и по занятости файла в очереди. Пока очередь
+// заполнена, объекты не скачиваются: они будут предложены при следующем
+// опросе.
+func (s *s3Source) poll(ctx context.Context, q *Queue) error {
+	if err := state.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+	}
+
+	p := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(s.cfg.Prefix),
+	})
+	now := time.Now()
+	deferred := 0
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			etag := strings.Trim(aws.ToString(obj.ETag), `"`)
+			if strings.HasSuffix(key, "/") || !acceptFile(path.Base(key)) {
+				continue
+			}
+
+			name := s.stateName(key)
+			if q.Has(name) {
+				continue
+			}
+			if fs, ok := state.Get(name); ok {
+				if fs.ETag == etag && !state.Eligible(name, now) {
+					continue
+				}
+				if fs.ETag != etag {
+					// Объект перезаписан: новая версия обрабатывается с нуля
+					if err := state.Reset([]string{name}); err != nil {
+						slog.Error(msg.T("state.save-failed"), "error", err)
+					}
+				}
+			}
+
+			if q.Full() {
+				deferred++
+				continue
+			}
+			local, err := s.download(ctx, key)
+			if err != nil {
+				slog.Error(msg.T("s3.download-failed"), "bucket", s.cfg.Bucket, "key", key, "error", err)
+				continue
+			}
+			if !q.TryPush(Job{Name: name, Path: local, ETag: etag, Temp: true}) {
+				// Очередь заполнил другой источник, пока объект скачивался
+				os.RemoveAll(filepath.Dir(local))
+				deferred++
+			}
+		}
+	}
+	q.Overflow(deferred)
+	return nil
+}
+
+// download скачивает объект в отдельную временную директорию, сохраняя
+// исходное имя файла для именования результата
+func (s *s3Source) download(ctx context.Context, key string) (string, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer out.Body.Close()
+
+	dir, err := os.MkdirTemp("", "hack_interview-s3-*")
+	if err != nil {
+		return "", err
+	}
+	local := filepath.Join(dir, path.Base(key))
+	f, err := os.Create(local)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	if _, err := io.Copy(f, out.Body); err != nil {
+		f.Close()
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("чтение объекта: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return local, nil
+}
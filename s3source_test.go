@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 бакет с объектами keys: листинг одной страницей и скачивание
+func fakeS3(t *testing.T, keys ...string) (*s3.Client, *atomic.Int32) {
+	t.Helper()
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("list-type") == "2" {
+			var b strings.Builder
+			b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>shots</Name><IsTruncated>false</IsTruncated>`)
+			for i, k := range keys {
+				fmt.Fprintf(&b, `<Contents><Key>%s</Key><ETag>"etag-%d"</ETag><Size>3</Size></Contents>`, k, i)
+			}
+			b.WriteString(`</ListBucketResult>`)
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(b.String()))
+			return
+		}
+		downloads.Add(1)
+		w.Write([]byte("png"))
+	}))
+	t.Cleanup(srv.Close)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("id", "secret", ""),
+	})
+	return client, &downloads
+}
+
+// Пока очередь заполнена, объекты не скачиваются
+func TestS3PollSkipsDownloadWhenQueueFull(t *testing.T) {
+	testEnv(t, "")
+	client, downloads := fakeS3(t, "a.png", "b.png", "c.png")
+	s := &s3Source{client: client, cfg: S3Config{Bucket: "shots"}}
+	q := newQueue(1)
+
+	if err := s.poll(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("скачано %d объектов, want 1", n)
+	}
+	if q.Len() != 1 || q.Deferred() != 2 {
+		t.Errorf("Len = %d, Deferred = %d", q.Len(), q.Deferred())
+	}
+	job := <-q.jobs
+	defer os.RemoveAll(filepath.Dir(job.Path))
+	if job.Name != "s3://shots/a.png" || job.ETag != "etag-0" || !job.Temp {
+		t.Errorf("job = %+v", job)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/secret"
+)
+
+// passphraseEnv переменная окружения с парольной фразой зашифрованных
+// ключей config.yml
+const passphraseEnv = "HACK_INTERVIEW_PASSPHRASE"
+
+var passphraseCache struct {
+	sync.Mutex
+	value string
+	ok    bool
+}
+
+// passphrase парольная фраза из HACK_INTERVIEW_PASSPHRASE или, если stdin
+// терминал, введённая при запуске. Введённая фраза запоминается, чтобы
+// перечитывание config.yml не спрашивало её снова.
+func passphrase() (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	passphraseCache.Lock()
+	defer passphraseCache.Unlock()
+	if passphraseCache.ok {
+		return passphraseCache.value, nil
+	}
+	p, err := promptSecret(msg.T("secrets.passphrase-prompt"))
+	if err != nil {
+		return "", err
+	}
+	passphraseCache.value, passphraseCache.ok = p, true
+	return p, nil
+}
+
+// promptSecret читает строку с терминала без эха
+func promptSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("stdin не терминал: задайте %s", passphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// runConfigCommand подкоманды config
+func runConfigCommand(args []string) int {
+	var err error
+	switch {
+	case len(args) == 1 && args[0] == "encrypt-key":
+		err = encryptKey()
+	case len(args) == 2 && args[0] == "set-secret":
+		err = setSecret(args[1])
+	default:
+		fmt.Fprintln(os.Stderr, msg.T("config.usage"))
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msg.T("error.prefix"), err)
+		return 1
+	}
+	return 0
+}
+
+// readKey ключ, введённый без эха или прочитанный из stdin
+func readKey() (string, error) {
+	var key string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		k, err := promptSecret(msg.T("secrets.key-prompt"))
+		if err != nil {
+			return "", err
+		}
+		key = k
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("ключ не прочитан из stdin: %w", err)
+		}
+		key = line
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("пустой ключ")
+	}
+	return key, nil
+}
+
+// setSecret записывает ключ в хранилище ОС и печатает ссылку для
+// config.yml
+func setSecret(name string) error {
+	ref := secret.KeychainPrefix + name
+	service, account, err := secret.ParseKeychainRef(ref)
+	if err != nil {
+		return err
+	}
+	key, err := readKey()
+	if err != nil {
+		return err
+	}
+	if err := appconfig.Keychain.Set(service, account, key); err != nil {
+		return err
+	}
+	fmt.Println(ref)
+	return nil
+}
+
+// encryptKey шифрует ключ и печатает значение для config.yml. Ключ не
+// передаётся аргументом, чтобы не остаться в истории оболочки.
+func encryptKey() error {
+	key, err := readKey()
+	if err != nil {
+		return err
+	}
+
+	pass := os.Getenv(passphraseEnv)
+	if pass == "" {
+		p, err := promptSecret(msg.T("secrets.new-passphrase-prompt"))
+		if err != nil {
+			return err
+		}
+		again, err := promptSecret(msg.T("secrets.repeat-prompt"))
+		if err != nil {
+			return err
+		}
+		if p != again {
+			return errors.New("парольные фразы не совпадают")
+		}
+		pass = p
+	}
+	if pass == "" {
+		return errors.New("пустая парольная фраза")
+	}
+	value, err := secret.Encrypt(key, pass)
+	if err != nil {
+		return err
+	}
+	fmt.Println(value)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/secret"
+)
+
+// Расшифрованные ключи уходят провайдерам, но не попадают ни в лог на
+// уровне debug со сводками запросов, ни в дампы --debug-http, ни в
+// результаты и состояние
+func TestDecryptedKeyNeverLogged(t *testing.T) {
+	const ocrKey, geminiKey, pass = "ocr-plain-5f2a91", "gemini-plain-9c1b77", "фраза ключей"
+	encrypt := func(key string) string {
+		v, err := secret.Encrypt(key, pass)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	t.Setenv(passphraseEnv, pass)
+	t.Setenv("OCR_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+	oldPassphrase, oldTrace := appconfig.Passphrase, traceHTTP
+	appconfig.Passphrase, traceHTTP = passphrase, true
+	debugHTTP.Store(true)
+	t.Cleanup(func() {
+		appconfig.Passphrase, traceHTTP = oldPassphrase, oldTrace
+		debugHTTP.Store(false)
+	})
+
+	buf := captureLog(t)
+	srv, ocrURL, geminiURL := newE2EServers(t)
+	dir := loadTestEnv(t, "logLevel: debug\n"+
+		"OCR_API_KEY: "+encrypt(ocrKey)+"\nGEMINI_API_KEY: "+encrypt(geminiKey)+"\n"+
+		"ocr:\n  baseURL: "+ocrURL+"\n  allowInsecure: true\n"+
+		"gemini:\n  baseURL: "+geminiURL+"\n  allowInsecure: true\n")
+	writePNGInput(t, "task.png")
+
+	q := newQueue(config.QueueSize)
+	if _, err := scanDirectory(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, 1)
+	q.WaitIdle(context.Background())
+	stop()
+	wg.Wait()
+
+	if fs, _ := state.Get("task.png"); fs.Status != statusDone {
+		t.Fatalf("task.png: %s %s", fs.Status, fs.LastError)
+	}
+	// Ключи расшифрованы и отправлены
+	if len(srv.ocr) != 1 || srv.ocr[0].Header.Get("apikey") != ocrKey ||
+		len(srv.gemini) != 1 || srv.gemini[0].URL.Query().Get("key") != geminiKey {
+		t.Fatal("провайдеры не получили расшифрованные ключи")
+	}
+
+	dumps, _ := filepath.Glob(filepath.Join(config.OutputDir, ".debug", "*"))
+	if len(dumps) == 0 || buf.Len() == 0 {
+		t.Fatalf("нечего проверять: дампов %d, лог %d байт", len(dumps), buf.Len())
+	}
+	outputs := map[string]string{"лог": buf.String()}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			data, _ := os.ReadFile(path)
+			outputs[path] = string(data)
+		}
+		return nil
+	})
+	for where, text := range outputs {
+		for _, key := range []string{ocrKey, geminiKey} {
+			if strings.Contains(text, key) {
+				t.Errorf("ключ %q в %s", key, where)
+			}
+		}
+	}
+}
+
+// keychainMap хранилище ОС в памяти по ссылке <служба>/<запись>
+type keychainMap map[string]string
+
+func (m keychainMap) Get(service, account string) (string, error) {
+	v, ok := m[service+"/"+account]
+	if !ok {
+		return "", secret.ErrNotFound
+	}
+	return v, nil
+}
+
+func (m keychainMap) Set(service, account, value string) error {
+	m[service+"/"+account] = value
+	return nil
+}
+
+// runConfigStdio запускает подкоманду config с stdin и возвращает код
+// выхода и вывод в stdout; stderr отбрасывается
+func runConfigStdio(t *testing.T, stdin string, args ...string) (int, string) {
+	t.Helper()
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	oldStdin, oldStdout, oldStderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = inR, outW, devNull
+	defer func() { os.Stdin, os.Stdout, os.Stderr = oldStdin, oldStdout, oldStderr }()
+	inW.WriteString(stdin)
+	inW.Close()
+
+	code := runConfigCommand(args)
+	outW.Close()
+	out, _ := io.ReadAll(outR)
+	return code, string(out)
+}
+
+// config set-secret пишет ключ из stdin в хранилище и печатает ссылку для
+// config.yml; неверное имя и пустой ключ в хранилище не попадают
+func TestSetSecret(t *testing.T) {
+	store := keychainMap{}
+	old := appconfig.Keychain
+	appconfig.Keychain = store
+	t.Cleanup(func() { appconfig.Keychain = old })
+
+	code, out := runConfigStdio(t, "  gemini-key \n", "set-secret", "hack_interview/prod/gemini")
+	if code != 0 || out != "keychain:hack_interview/prod/gemini\n" {
+		t.Errorf("код %d, вывод %q", code, out)
+	}
+	if v, err := store.Get("hack_interview/prod", "gemini"); err != nil || v != "gemini-key" {
+		t.Errorf("в хранилище %q, %v", v, err)
+	}
+
+	for _, tc := range []struct {
+		stdin string
+		args  []string
+		code  int
+	}{
+		{"key\n", []string{"set-secret", "gemini"}, 1},
+		{"key\n", []string{"set-secret", "hack_interview/"}, 1},
+		{"\n", []string{"set-secret", "hack_interview/ocr"}, 1},
+		{"", []string{"set-secret", "hack_interview/ocr"}, 1},
+		{"key\n", []string{"set-secret"}, 2},
+	} {
+		if code, out := runConfigStdio(t, tc.stdin, tc.args...); code != tc.code || out != "" {
+			t.Errorf("%q: код %d, вывод %q, want %d", tc.args, code, out, tc.code)
+		}
+	}
+	if len(store) != 1 {
+		t.Errorf("в хранилище %v", store)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+)
+
+// startServer запускает HTTP-сервер и останавливает его при отмене ctx
+func startServer(ctx context.Context) error {
+	mux := http.NewServeMux()
+	if config.Server.Upload {
+		mux.Handle("POST /submit", requireToken(http.HandlerFunc(handleSubmit)))
+		mux.Handle("GET /result/{id}", requireToken(http.HandlerFunc(handleResult)))
+	}
+	if config.Server.Health {
+		mux.HandleFunc("GET /healthz", handleHealthz)
+		mux.HandleFunc("GET /readyz", handleReadyz)
+	}
+	if config.Server.Metrics {
+		mux.Handle("GET /metrics", metrics.Handler())
+	}
+	if config.Server.UI {
+		registerUI(ctx, mux)
+	}
+	if config.Server.API {
+		registerAPI(mux)
+	}
+
+	srv := &http.Server{
+		Addr:              config.Server.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+
+	// Ошибка привязки к адресу проявляется сразу
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(100 * time.Millisecond):
+	}
+	slog.Info(msg.T("server.started"), "addr", config.Server.Listen)
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	return nil
+}
+
+// requireToken сверяет токен за постоянное время
+func requireToken(next http.Handler) http.Handler {
+	want := []byte("Bearer " + config.Server.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// uploadExtensions допустимые типы загружаемых изображений
+var uploadExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// handleSubmit принимает multipart-поле image и кладёт файл во входную
+// директорию; в ответ возвращается идентификатор задания.
+func handleSubmit(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, config.Server.MaxUploadSize+1<<20)
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": errUploadTooLarge.Error()})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "ожидается multipart-поле image"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, config.Server.MaxUploadSize+1))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	id, err := saveUpload(data, config.Server.MaxUploadSize)
+	switch {
+	case errors.Is(err, errUploadTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, errUploadType):
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	slog.Info(msg.T("server.accepted"), "job", id)
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+}
+
+// handleResult отдаёт ответ по заданию: markdown по умолчанию, JSON при
+// ?format=json или Accept: application/json.
+func handleResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	name, fs, ok := findUpload(id)
+	if !ok {
+		if uploadPending(id) {
+			writeJSON(w, http.StatusAccepted, map[string]string{"id": id, "status": "pending"})
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "задание не найдено"})
+		return
+	}
+
+	wantJSON := r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
+	if fs.Status != statusDone {
+		writeJSON(w, http.StatusAccepted, map[string]string{"id": id, "file": name, "status": fs.Status, "error": fs.LastError})
+		return
+	}
+
+	answer, err := os.ReadFile(fs.Output)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "результат недоступен"})
+		return
+	}
+	if wantJSON {
+		writeJSON(w, http.StatusOK, map[string]string{"id": id, "file": name, "status": fs.Status, "answer": string(answer)})
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write(answer)
+}
+
+// Ошибки проверки загружаемого изображения
+var (
+	errUploadTooLarge = errors.New("файл слишком большой")
+	errUploadType     = errors.New("поддерживаются только PNG, JPEG и GIF")
+)
+
+// saveUpload проверяет изображение и кладёт его во входную директорию под
+// новым идентификатором задания
+func saveUpload(data []byte, maxSize int64) (string, error) {
+	if int64(len(data)) > maxSize {
+		return "", errUploadTooLarge
+	}
+	// Тип определяется по содержимому, заголовку клиента не доверяем
+	ext, ok := uploadExtensions[http.DetectContentType(data)]
+	if !ok {
+		return "", errUploadType
+	}
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	if _, err := writeInputFile(uploadName(id)+ext, data); err != nil {
+		slog.Error(msg.T("server.save-failed"), "error", err)
+		return "", errors.New("не удалось сохранить файл")
+	}
+	return id, nil
+}
+
+func uploadName(id string) string {
+	return "upload-" + id
+}
+
+func findUpload(id string) (string, FileState, bool) {
+	if err := state.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+	}
+	for _, ext := range uploadExtensions {
+		name := uploadName(id) + ext
+		if fs, ok := state.Get(name); ok {
+			return name, fs, true
+		}
+	}
+	return "", FileState{}, false
+}
+
+// uploadPending сообщает, что файл задания ещё лежит во входной директории
+// и ждёт обработки
+func uploadPending(id string) bool {
+	for _, ext := range uploadExtensions {
+		if _, err := os.Stat(filepath.Join(config.InputDir, uploadName(id)+ext)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("генерация идентификатора: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"hack_interview/internal/msg"
+)
+
+// serviceName имя службы systemd, launchd и Windows
+const serviceName = "hack_interview"
+
+// serviceSpec что запускает служба: программа с --config из рабочей
+// директории конфигурации
+type serviceSpec struct {
+	Exe    string
+	Config string
+	Dir    string
+}
+
+func (s serviceSpec) Args() []string {
+	return []string{"--config", s.Config}
+}
+
+// runInstallService регистрирует программу службой текущей платформы
+func runInstallService(args []string) int {
+	return serviceCommand("install-service", args, true)
+}
+
+// runUninstallService удаляет службу, созданную install-service
+func runUninstallService(args []string) int {
+	return serviceCommand("uninstall-service", args, false)
+}
+
+func serviceCommand(name string, args []string, install bool) int {
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	dryRun := fset.Bool("dry-run", false, "показать, что будет записано и выполнено, ничего не меняя")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	spec, err := newServiceSpec()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msg.T("error.prefix"), err)
+		return 1
+	}
+	switch runtime.GOOS {
+	case "linux":
+		err = systemdService(spec, install, *dryRun)
+	case "darwin":
+		err = launchdService(spec, install, *dryRun)
+	case "windows":
+		err = windowsService(spec, install, *dryRun)
+	default:
+		err = fmt.Errorf("службы на %s не поддерживаются", runtime.GOOS)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msg.T("error.prefix"), err)
+		return 1
+	}
+	return 0
+}
+
+func newServiceSpec() (serviceSpec, error) {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("путь к программе: %w", err)
+	}
+	cfg, err := filepath.Abs(configPath)
+	if err != nil {
+		return serviceSpec{}, err
+	}
+	if _, err := os.Stat(cfg); err != nil {
+		return serviceSpec{}, fmt.Errorf("служба запускается с --config %s: %w", cfg, err)
+	}
+	return serviceSpec{Exe: exe, Config: cfg, Dir: filepath.Dir(cfg)}, nil
+}
+
+// systemdUnit пользовательский юнит: SIGTERM от systemctl stop идёт в
+// штатное завершение, TimeoutStopSec с запасом больше shutdownTimeout по
+// умолчанию
+var systemdUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=hack_interview: ответы на вопросы со скриншотов
+After=network-online.target
+
+[Service]
+Type=simple
+WorkingDirectory={{.Dir}}
+ExecStart={{.Exec}}
+Restart=on-failure
+RestartSec=5
+TimeoutStopSec=60
+
+[Install]
+WantedBy=default.target
+`))
+
+func systemdService(spec serviceSpec, install, dryRun bool) error {
+	home, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(home, "systemd", "user", serviceName+".service")
+	unit := serviceName + ".service"
+	if !install {
+		return serviceSteps(dryRun, path, nil,
+			optionalStep("systemctl", "--user", "disable", "--now", unit),
+			step("rm", path),
+			step("systemctl", "--user", "daemon-reload"))
+	}
+	var buf bytes.Buffer
+	argv := append([]string{spec.Exe}, spec.Args()...)
+	for i, a := range argv {
+		argv[i] = systemdQuote(a)
+	}
+	systemdUnit.Execute(&buf, map[string]string{"Dir": strings.ReplaceAll(spec.Dir, "%", "%%"), "Exec": strings.Join(argv, " ")})
+	if err := serviceSteps(dryRun, path, buf.Bytes(),
+		step("systemctl", "--user", "daemon-reload"),
+		step("systemctl", "--user", "enable", unit),
+		// restart запускает службу или перезапускает её с новым юнитом
+		step("systemctl", "--user", "restart", unit)); err != nil {
+		return err
+	}
+	if !dryRun {
+		fmt.Println(msg.T("service.started") + unit)
+		fmt.Println(msg.T("service.linger") + os.Getenv("USER"))
+	}
+	return nil
+}
+
+// systemdQuote заключает аргумент в кавычки, если в нём есть пробелы или
+// спецсимволы
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$%;") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// launchdPlist агент launchd: запуск при входе, перезапуск после аварийного
+// завершения, вывод в hack_interview.log рядом с конфигурацией
+var launchdPlist = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": html.EscapeString}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{xml .Dir}}</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ExitTimeOut</key>
+	<integer>60</integer>
+	<key>StandardOutPath</key>
+	<string>{{xml .Log}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .Log}}</string>
+</dict>
+</plist>
+`))
+
+func launchdService(spec serviceSpec, install, dryRun bool) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	label := "com." + serviceName
+	path := filepath.Join(home, "Library", "LaunchAgents", label+".plist")
+	domain := fmt.Sprintf("gui/%d", os.Getuid())
+	if !install {
+		return serviceSteps(dryRun, path, nil,
+			optionalStep("launchctl", "bootout", domain+"/"+label),
+			step("rm", path))
+	}
+	var buf bytes.Buffer
+	launchdPlist.Execute(&buf, map[string]any{
+		"Label": label,
+		"Args":  append([]string{spec.Exe}, spec.Args()...),
+		"Dir":   spec.Dir,
+		"Log":   filepath.Join(spec.Dir, serviceName+".log"),
+	})
+	return serviceSteps(dryRun, path, buf.Bytes(),
+		// Уже загруженный агент выгружается, иначе bootstrap откажет
+		optionalStep("launchctl", "bootout", domain+"/"+label),
+		step("launchctl", "bootstrap", domain, path))
+}
+
+// serviceStep команда установки; ошибка optional шага (служба уже
+// остановлена или не загружена) только печатается
+type serviceStep struct {
+	argv     []string
+	optional bool
+}
+
+func step(argv ...string) serviceStep         { return serviceStep{argv: argv} }
+func optionalStep(argv ...string) serviceStep { return serviceStep{argv: argv, optional: true} }
+
+// serviceSteps записывает файл службы, если content не nil, и выполняет
+// шаги; rm удаляет файл. При dryRun только печатает, что было бы сделано.
+func serviceSteps(dryRun bool, path string, content []byte, steps ...serviceStep) error {
+	if dryRun {
+		if content != nil {
+			fmt.Printf("# %s\n%s\n", path, content)
+		}
+		for _, st := range steps {
+			fmt.Println(strings.Join(st.argv, " "))
+		}
+		return nil
+	}
+	if content != nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+		fmt.Println(msg.T("service.written"), path)
+	}
+	for _, st := range steps {
+		var err error
+		var out []byte
+		if st.argv[0] == "rm" {
+			if err = os.Remove(st.argv[1]); err == nil {
+				fmt.Println(msg.T("service.removed"), st.argv[1])
+			} else if errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			out, err = exec.Command(st.argv[0], st.argv[1:]...).CombinedOutput()
+		}
+		switch {
+		case err == nil:
+		case st.optional:
+			slog.Debug(msg.T("service.step-failed"), "command", strings.Join(st.argv, " "), "error", err, "output", string(bytes.TrimSpace(out)))
+		default:
+			return fmt.Errorf("%s: %w: %s", strings.Join(st.argv, " "), err, bytes.TrimSpace(out))
+		}
+	}
+	return nil
+}
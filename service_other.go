@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// runAsService вне Windows не нужен: systemd и launchd останавливают
+// программу сигналом
+func runAsService(func()) bool { return false }
+
+func windowsService(serviceSpec, bool, bool) error {
+	return errors.New("службы Windows доступны только в Windows")
+}
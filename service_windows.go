@@ -0,0 +1,147 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"hack_interview/internal/msg"
+)
+
+// runAsService выполняет run под диспетчером служб, если процесс запущен
+// им; иначе сразу возвращает false
+func runAsService(run func()) bool {
+	ok, err := svc.IsWindowsService()
+	if err != nil || !ok {
+		return false
+	}
+	if err := svc.Run(serviceName, serviceHandler{run: run}); err != nil {
+		slog.Error(msg.T("service.failed"), "error", err)
+	}
+	return true
+}
+
+// serviceHandler запускает программу и передаёт остановку службы и
+// выключение компьютера в штатное завершение, как SIGTERM
+type serviceHandler struct {
+	run func()
+}
+
+func (h serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	codes := make(chan int, 1)
+	exit = func(code int) {
+		codes <- code
+		select {}
+	}
+	go h.run()
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+	for {
+		select {
+		case code := <-codes:
+			status <- svc.Status{State: svc.Stopped}
+			return false, uint32(code)
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				wait := uint32((config.ShutdownTimeout + 10*time.Second) / time.Millisecond)
+				status <- svc.Status{State: svc.StopPending, WaitHint: wait}
+				select {
+				case shutdownRequests <- "service " + cmdName(r.Cmd):
+				default:
+				}
+			}
+		}
+	}
+}
+
+func cmdName(c svc.Cmd) string {
+	if c == svc.Shutdown {
+		return "shutdown"
+	}
+	return "stop"
+}
+
+func windowsService(spec serviceSpec, install, dryRun bool) error {
+	if dryRun {
+		if install {
+			fmt.Printf(msg.T("service.windows-dry-run"),
+				serviceName, spec.Exe, strings.Join(spec.Args(), " "))
+		} else {
+			fmt.Printf(msg.T("service.windows-dry-run-remove"), serviceName)
+		}
+		return nil
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return serviceAccessError(err)
+	}
+	defer m.Disconnect()
+	if !install {
+		return removeWindowsService(m)
+	}
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("служба %s уже установлена; удалите её командой uninstall-service", serviceName)
+	}
+	s, err := m.CreateService(serviceName, spec.Exe, mgr.Config{
+		DisplayName: "hack_interview",
+		Description: "Ответы на вопросы со скриншотов (" + spec.Config + ")",
+		StartType:   mgr.StartAutomatic,
+	}, spec.Args()...)
+	if err != nil {
+		return serviceAccessError(err)
+	}
+	defer s.Close()
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+	}, uint32((24 * time.Hour).Seconds())); err != nil {
+		slog.Warn(msg.T("service.recovery-failed"), "error", err)
+	}
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("служба установлена, но не запустилась: %w", err)
+	}
+	fmt.Printf(msg.T("service.windows-installed"), serviceName)
+	return nil
+}
+
+func removeWindowsService(m *mgr.Mgr) error {
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("служба %s не установлена", serviceName)
+	}
+	defer s.Close()
+	if st, err := s.Control(svc.Stop); err == nil {
+		deadline := time.Now().Add(config.ShutdownTimeout + 30*time.Second)
+		for st.State != svc.Stopped && time.Now().Before(deadline) {
+			time.Sleep(300 * time.Millisecond)
+			if st, err = s.Query(); err != nil {
+				break
+			}
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return serviceAccessError(err)
+	}
+	fmt.Printf(msg.T("service.windows-removed"), serviceName)
+	return nil
+}
+
+func serviceAccessError(err error) error {
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return fmt.Errorf("%w: запустите команду от имени администратора", err)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// Сессия собеседования: с запуска или команды new-session до завершения
+// или следующей new-session. Каждый обработанный вопрос дописывается в
+// SessionsDir/<id>/transcript.md в порядке обработки, при закрытии сессии
+// добавляется итог. Файлом владеет одна горутина, остальные передают ей
+// записи через канал, поэтому параллельные обработчики не перемешивают
+// текст.
+
+// transcriptEntry один вопрос и ответ в стенограмме
+type transcriptEntry struct {
+	Time     time.Time
+	Source   string
+	Output   string
+	Question string
+	Answer   string
+}
+
+type sessionOp struct {
+	entry *transcriptEntry
+	// rotate закрывает текущую сессию и начинает новую; в ответ приходит
+	// её идентификатор
+	rotate chan string
+	// closed закрывает сессию без новой
+	closed chan struct{}
+}
+
+type transcriptWriter struct {
+	ops chan sessionOp
+	// id идентификатор текущей сессии
+	id atomic.Value
+}
+
+// transcripts nil, пока сессия не начата или стенограмма выключена
+var transcripts *transcriptWriter
+
+// sessionState счётчики текущей сессии; принадлежит горутине run
+type sessionState struct {
+	id      string
+	started time.Time
+	path    string
+	seq     int
+	tokens0 int
+	failed0 int
+}
+
+// startSession начинает сессию и запускает владельца стенограммы
+func startSession() {
+	if config.Transcript != nil && !*config.Transcript {
+		return
+	}
+	w := &transcriptWriter{ops: make(chan sessionOp, 64)}
+	s := w.open()
+	go w.run(s)
+	transcripts = w
+}
+
+func (w *transcriptWriter) open() *sessionState {
+	now := time.Now()
+	s := &sessionState{
+		id:      now.Format("20060102-150405"),
+		started: now,
+		tokens0: totals.TokenCount(),
+		failed0: totals.FailedCount(),
+	}
+	dir := filepath.Join(config.SessionsDir, s.id)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		s.id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), n)
+		dir = filepath.Join(config.SessionsDir, s.id)
+	}
+	s.path = filepath.Join(dir, "transcript.md")
+	header := fmt.Sprintf("# Сессия %s\n\nНачало: %s\n", s.id, now.Format("2006-01-02 15:04:05"))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		slog.Warn(msg.T("transcript.create-failed"), "error", err)
+	} else if err := os.WriteFile(s.path, []byte(header), 0644); err != nil {
+		slog.Warn(msg.T("transcript.create-failed"), "error", err)
+	}
+	w.id.Store(s.id)
+	slog.Info(msg.T("session.started"), "session", s.id, "transcript", s.path)
+	return s
+}
+
+func (w *transcriptWriter) run(s *sessionState) {
+	for op := range w.ops {
+		switch {
+		case op.entry != nil:
+			s.seq++
+			s.write(formatEntry(s.seq, *op.entry))
+		case op.rotate != nil:
+			s.write(s.footer())
+			s = w.open()
+			op.rotate <- s.id
+		case op.closed != nil:
+			s.write(s.footer())
+			close(op.closed)
+			return
+		}
+	}
+}
+
+func (s *sessionState) write(text string) {
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err == nil {
+		_, err = f.WriteString(text)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		slog.Warn(msg.T("transcript.write-failed"), "path", s.path, "error", err)
+	}
+}
+
+func formatEntry(seq int, e transcriptEntry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %d. %s — %s\n\n", seq, e.Time.Format("15:04:05"), filepath.Base(e.Source))
+	if e.Output != "" {
+		fmt.Fprintf(&b, "Результат: %s\n\n", e.Output)
+	}
+	b.WriteString("### Вопрос\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(e.Question), "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+	b.WriteString("\n### Ответ\n\n")
+	b.WriteString(strings.TrimSpace(e.Answer) + "\n")
+	return b.String()
+}
+
+func (s *sessionState) footer() string {
+	now := time.Now()
+	return fmt.Sprintf("\n---\n\nИтог сессии %s: вопросов %d, с ошибкой %d, токенов %d, длительность %v (до %s)\n",
+		s.id, s.seq, totals.FailedCount()-s.failed0, totals.TokenCount()-s.tokens0,
+		now.Sub(s.started).Round(time.Second), now.Format("15:04:05"))
+}
+
+// Append дописывает вопрос и ответ в стенограмму текущей сессии
+func (w *transcriptWriter) Append(e transcriptEntry) {
+	if w == nil {
+		return
+	}
+	w.ops <- sessionOp{entry: &e}
+}
+
+// Session идентификатор текущей сессии
+func (w *transcriptWriter) Session() string {
+	if w == nil {
+		return ""
+	}
+	id, _ := w.id.Load().(string)
+	return id
+}
+
+// NewSession закрывает текущую сессию итогом и начинает новую
+func (w *transcriptWriter) NewSession() string {
+	if w == nil {
+		return ""
+	}
+	ch := make(chan string)
+	w.ops <- sessionOp{rotate: ch}
+	return <-ch
+}
+
+// Close дописывает итог и останавливает владельца стенограммы
+func (w *transcriptWriter) Close() {
+	if w == nil {
+		return
+	}
+	done := make(chan struct{})
+	w.ops <- sessionOp{closed: done}
+	<-done
+}
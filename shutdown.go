@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// Коды завершения процесса
+const (
+	exitOK        = 0
+	exitCancelled = 1   // обработка не успела завершиться до дедлайна
+	exitForced    = 130 // повторный сигнал, немедленный выход
+)
+
+// Totals счётчики файлов за текущий запуск
+type Totals struct {
+	mu        sync.Mutex
+	Processed int
+	Failed    int
+	Skipped   int
+	// OCRRequests и Tokens расход квоты API за запуск
+	OCRRequests int
+	Tokens      int
+	// Failures причины ошибок по файлам
+	Failures  map[string]string
+	LastError string
+	Started   time.Time
+}
+
+var totals = &Totals{Started: time.Now(), Failures: make(map[string]string)}
+
+func (t *Totals) AddProcessed() {
+	t.mu.Lock()
+	t.Processed++
+	t.mu.Unlock()
+}
+
+func (t *Totals) AddFailed(name string, err error) {
+	t.mu.Lock()
+	t.Failed++
+	t.Failures[name] = err.Error()
+	t.LastError = err.Error()
+	t.mu.Unlock()
+}
+
+func (t *Totals) AddSkipped(n int) {
+	t.mu.Lock()
+	t.Skipped += n
+	t.mu.Unlock()
+}
+
+func (t *Totals) AddOCRRequest() {
+	t.mu.Lock()
+	t.OCRRequests++
+	t.mu.Unlock()
+}
+
+func (t *Totals) AddTokens(n int) {
+	t.mu.Lock()
+	t.Tokens += n
+	t.mu.Unlock()
+}
+
+func (t *Totals) FailedCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Failed
+}
+
+func (t *Totals) ProcessedCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Processed
+}
+
+func (t *Totals) OCRRequestCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.OCRRequests
+}
+
+func (t *Totals) TokenCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Tokens
+}
+
+// handleSignals по первому SIGINT/SIGTERM прекращает приём новых файлов и
+// даёт текущей обработке ShutdownTimeout на завершение, после чего отменяет
+// её контекст. Повторный сигнал завершает процесс сразу.
+func handleSignals(stop, cancelWork context.CancelFunc) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	var reason string
+	select {
+	case sig := <-sigs:
+		reason = sig.String()
+	case reason = <-shutdownRequests:
+	}
+	slog.Info(msg.T("shutdown.signal"), "signal", reason, "timeout", config.ShutdownTimeout)
+	stop()
+	time.AfterFunc(config.ShutdownTimeout, func() {
+		slog.Warn(msg.T("shutdown.timeout"))
+		cancelWork()
+	})
+
+	select {
+	case <-sigs:
+	case <-shutdownRequests:
+	}
+	slog.Warn(msg.T("shutdown.forced"))
+	os.Exit(exitForced)
+}
+
+// shutdownRequests запросы завершения помимо сигналов, например остановка
+// службы Windows; действуют как SIGTERM
+var shutdownRequests = make(chan string, 2)
+
+// shutdown сохраняет состояние, печатает итоги сессии и возвращает код выхода.
+func shutdown(workCtx context.Context) int {
+	if err := state.Save(); err != nil {
+		slog.Error(msg.T("state.save-failed"), "error", err)
+	}
+	if err := state.Close(); err != nil {
+		slog.Error(msg.T("state.close-failed"), "error", err)
+	}
+	transcripts.Close()
+	publishing.Close()
+
+	if pause.Paused() {
+		fmt.Println(msg.T("shutdown.was-paused"))
+	}
+	totals.mu.Lock()
+	fmt.Printf(msg.T("shutdown.totals"),
+		totals.Processed, totals.Skipped, totals.Failed, time.Since(totals.Started).Round(time.Second))
+	names := make([]string, 0, len(totals.Failures))
+	for name := range totals.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s: %s\n", name, totals.Failures[name])
+	}
+	totals.mu.Unlock()
+	if lines := sessionStages.Summary(); len(lines) > 0 {
+		fmt.Println(msg.T("shutdown.stages"))
+		for _, line := range lines {
+			fmt.Println("  " + line)
+		}
+	}
+
+	if workCtx.Err() != nil {
+		return exitCancelled
+	}
+	return exitOK
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+)
+
+// splitResult ответ модели о составе текста
+type splitResult struct {
+	Multiple  bool     `json:"multiple"`
+	Confident bool     `json:"confident"`
+	Questions []string `json:"questions"`
+}
+
+// splitQuestions спрашивает модель, нет ли в тексте нескольких
+// независимых вопросов, и возвращает их по отдельности. При ошибке,
+// сомнении модели, больше splitQuestions.max частей или частях, которые
+// заметно короче исходного текста (модель пересказала, а не разделила),
+// возвращается текст целиком.
+func (p *Pipeline) splitQuestions(ctx context.Context, logger *slog.Logger, text string) []string {
+	whole := []string{text}
+	prompt := "Определи, сколько независимых вопросов или задач в тексте ниже. Ответь только JSON " +
+		`{"multiple": true|false, "confident": true|false, "questions": ["..."]}: multiple — вопросов ` +
+		"несколько и их можно решать по отдельности; части одной задачи (условие, примеры, " +
+		"ограничения) — это один вопрос. confident — ты уверен в разделении. В questions перепиши " +
+		"каждый вопрос дословно, без сокращений и пересказа.\n\nТекст:\n" + text
+	resp, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("split.failed"), "error", err)
+		return whole
+	}
+
+	raw := strings.TrimSpace(resp)
+	if m := fencedJSONRe.FindStringSubmatch(resp); m != nil {
+		raw = m[1]
+	}
+	var res splitResult
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		logger.Warn(msg.T("split.bad-response"), "error", err)
+		return whole
+	}
+	var questions []string
+	total := 0
+	for _, q := range res.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			questions = append(questions, q)
+			total += utf8.RuneCountInString(q)
+		}
+	}
+	switch {
+	case !res.Multiple || len(questions) < 2:
+		return whole
+	case !res.Confident:
+		logger.Info(msg.T("split.unsure"))
+		return whole
+	case len(questions) > config.SplitQuestions.Max:
+		logger.Warn(msg.T("split.too-many"), "parts", len(questions), "max", config.SplitQuestions.Max)
+		return whole
+	case total*2 < utf8.RuneCountInString(text):
+		logger.Warn(msg.T("split.too-short"), "parts", len(questions))
+		return whole
+	}
+	logger.Info(msg.T("split.done"), "questions", len(questions))
+	return questions
+}
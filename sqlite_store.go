@@ -0,0 +1,391 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations схема базы состояния; номер применённой миграции
+// хранится в PRAGMA user_version
+var sqliteMigrations = []string{
+	`CREATE TABLE jobs (
+		name         TEXT PRIMARY KEY,
+		status       TEXT NOT NULL DEFAULT '',
+		output       TEXT NOT NULL DEFAULT '',
+		attempts     INTEGER NOT NULL DEFAULT 0,
+		last_error   TEXT NOT NULL DEFAULT '',
+		text         TEXT NOT NULL DEFAULT '',
+		next_retry   TEXT NOT NULL DEFAULT '',
+		grp          TEXT NOT NULL DEFAULT '',
+		moved_to     TEXT NOT NULL DEFAULT '',
+		etag         TEXT NOT NULL DEFAULT '',
+		timings_ms   TEXT NOT NULL DEFAULT '',
+		failed_stage TEXT NOT NULL DEFAULT '',
+		tokens_in    INTEGER NOT NULL DEFAULT 0,
+		tokens_out   INTEGER NOT NULL DEFAULT 0,
+		session      TEXT NOT NULL DEFAULT '',
+		updated_at   TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX jobs_status ON jobs(status);
+	CREATE TABLE attempts (
+		id           INTEGER PRIMARY KEY,
+		name         TEXT NOT NULL,
+		attempt      INTEGER NOT NULL,
+		status       TEXT NOT NULL,
+		error        TEXT NOT NULL DEFAULT '',
+		failed_stage TEXT NOT NULL DEFAULT '',
+		timings_ms   TEXT NOT NULL DEFAULT '',
+		tokens_in    INTEGER NOT NULL DEFAULT 0,
+		tokens_out   INTEGER NOT NULL DEFAULT 0,
+		session      TEXT NOT NULL DEFAULT '',
+		at           TEXT NOT NULL
+	);
+	CREATE INDEX attempts_name ON attempts(name);
+	CREATE TABLE sessions (
+		id         TEXT PRIMARY KEY,
+		started_at TEXT NOT NULL,
+		last_at    TEXT NOT NULL
+	);`,
+	`ALTER TABLE jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+}
+
+const jobColumns = `name, status, output, attempts, last_error, text, next_retry, grp, moved_to,
+	etag, timings_ms, failed_stage, tokens_in, tokens_out, session, owner, updated_at`
+
+// sqliteStore состояние в базе SQLite: jobs хранит последнюю запись о
+// файле, attempts — историю попыток, sessions — сессии обработки.
+//
+// Изменения выполняются в транзакциях BEGIN IMMEDIATE и сразу видны другим
+// процессам; режим WAL не блокирует читателей. Чтения идут из копии в
+// памяти, которую Refresh обновляет, если базу изменил другой процесс.
+type sqliteStore struct {
+	mu      sync.Mutex
+	db      *sql.DB
+	version int64
+	files   map[string]*FileState
+}
+
+// openSQLiteStore открывает базу, применяет миграции и при создании
+// импортирует записи из JSON-файла состояния jsonPath
+func openSQLiteStore(path, jsonPath string) (*sqliteStore, error) {
+	dsn := "file:" + filepath.ToSlash(path) +
+		"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Одно соединение: SQLite всё равно сериализует запись, а
+	// PRAGMA data_version сравнивается в пределах соединения
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxIdleTime(0)
+
+	s := &sqliteStore{db: db}
+	if err := s.migrate(jsonPath); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.Refresh(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *sqliteStore) migrate(jsonPath string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version >= len(sqliteMigrations) {
+		return nil
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			return fmt.Errorf("миграция %d: %w", i+1, err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations))); err != nil {
+		return err
+	}
+
+	imported := 0
+	if version == 0 {
+		if imported, err = importJSONState(tx, jsonPath); err != nil {
+			return fmt.Errorf("импорт %s: %w", jsonPath, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if imported > 0 {
+		slog.Info(msg.T("state.imported"), "from", jsonPath, "files", imported)
+	}
+	return nil
+}
+
+// importJSONState переносит записи существующего файла состояния; файла
+// может не быть
+func importJSONState(tx *sql.Tx, path string) (int, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+	js := &jsonStore{path: path, Files: make(map[string]*FileState)}
+	if err := js.Refresh(); err != nil {
+		return 0, err
+	}
+	for name, fs := range js.Files {
+		if err := upsertJob(tx, name, *fs); err != nil {
+			return 0, err
+		}
+	}
+	return len(js.Files), nil
+}
+
+func (s *sqliteStore) Refresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var version int64
+	if err := s.db.QueryRow("PRAGMA data_version").Scan(&version); err != nil {
+		return err
+	}
+	if s.files != nil && version == s.version {
+		return nil
+	}
+	rows, err := s.db.Query("SELECT " + jobColumns + " FROM jobs")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	files := make(map[string]*FileState)
+	for rows.Next() {
+		name, fs, err := scanJob(rows)
+		if err != nil {
+			return err
+		}
+		files[name] = &fs
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	s.files, s.version = files, version
+	return nil
+}
+
+func (s *sqliteStore) Eligible(name string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return eligible(s.files[name], now)
+}
+
+func (s *sqliteStore) Get(name string) (FileState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fs, ok := s.files[name]
+	if !ok {
+		return FileState{}, false
+	}
+	return *fs, true
+}
+
+func (s *sqliteStore) Set(name string, fs FileState) error {
+	fs.UpdatedAt = time.Now()
+	return s.update(func(tx *sql.Tx) error {
+		if err := upsertJob(tx, name, fs); err != nil {
+			return err
+		}
+		s.files[name] = &fs
+		return nil
+	})
+}
+
+// Save ничего не делает: изменения записываются сразу
+func (s *sqliteStore) Save() error { return nil }
+
+func (s *sqliteStore) RecordAttempt(name string, res FileState, procErr error, maxAttempts int, base time.Duration) (FileState, error) {
+	var result FileState
+	err := s.update(func(tx *sql.Tx) error {
+		var prev *FileState
+		_, fs, err := scanJob(tx.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE name = ?", name))
+		switch {
+		case err == nil:
+			prev = &fs
+		case err != sql.ErrNoRows:
+			return err
+		}
+		result = nextAttempt(prev, res, procErr, maxAttempts, base)
+		if err := upsertJob(tx, name, result); err != nil {
+			return err
+		}
+		timings, err := encodeTimings(result.TimingsMs)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO attempts (name, attempt, status, error, failed_stage, timings_ms, tokens_in, tokens_out, session, at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			name, result.Attempts, result.Status, result.LastError, result.FailedStage, timings,
+			result.TokensIn, result.TokensOut, result.Session, formatTime(result.UpdatedAt)); err != nil {
+			return err
+		}
+		s.files[name] = &result
+		return nil
+	})
+	return result, err
+}
+
+func (s *sqliteStore) MarkSkipped(names []string, reason string) error {
+	now := time.Now()
+	return s.update(func(tx *sql.Tx) error {
+		for _, name := range names {
+			fs := FileState{Status: statusSkipped, LastError: reason, UpdatedAt: now}
+			if err := upsertJob(tx, name, fs); err != nil {
+				return err
+			}
+			s.files[name] = &fs
+		}
+		return nil
+	})
+}
+
+// Reset удаляет записи о файлах; история попыток сохраняется
+func (s *sqliteStore) Reset(names []string) error {
+	return s.update(func(tx *sql.Tx) error {
+		for _, name := range names {
+			if _, err := tx.Exec("DELETE FROM jobs WHERE name = ?", name); err != nil {
+				return err
+			}
+			delete(s.files, name)
+		}
+		return nil
+	})
+}
+
+func (s *sqliteStore) Snapshot() map[string]FileState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]FileState, len(s.files))
+	for name, fs := range s.files {
+		out[name] = *fs
+	}
+	return out
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// update выполняет fn в транзакции. fn меняет и копию в памяти, поэтому
+// при ошибке следующий Refresh перечитает копию из базы.
+func (s *sqliteStore) update(fn func(tx *sql.Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		s.version = -1
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.version = -1
+		return err
+	}
+	return nil
+}
+
+// upsertJob записывает запись о файле; сессия из записи отмечается в
+// таблице sessions
+func upsertJob(tx *sql.Tx, name string, fs FileState) error {
+	timings, err := encodeTimings(fs.TimingsMs)
+	if err != nil {
+		return err
+	}
+	updated := formatTime(fs.UpdatedAt)
+	if _, err := tx.Exec("INSERT OR REPLACE INTO jobs ("+jobColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		name, fs.Status, fs.Output, fs.Attempts, fs.LastError, fs.Text, formatTime(fs.NextRetry), fs.Group, fs.MovedTo,
+		fs.ETag, timings, fs.FailedStage, fs.TokensIn, fs.TokensOut, fs.Session, fs.Owner, updated); err != nil {
+		return err
+	}
+	if fs.Session == "" {
+		return nil
+	}
+	_, err = tx.Exec(`INSERT INTO sessions (id, started_at, last_at) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET last_at = max(last_at, excluded.last_at)`, fs.Session, updated, updated)
+	return err
+}
+
+// rowScanner общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (string, FileState, error) {
+	var (
+		name                       string
+		fs                         FileState
+		nextRetry, timings, update string
+	)
+	err := row.Scan(&name, &fs.Status, &fs.Output, &fs.Attempts, &fs.LastError, &fs.Text, &nextRetry, &fs.Group,
+		&fs.MovedTo, &fs.ETag, &timings, &fs.FailedStage, &fs.TokensIn, &fs.TokensOut, &fs.Session, &fs.Owner, &update)
+	if err != nil {
+		return "", fs, err
+	}
+	if timings != "" {
+		if err := json.Unmarshal([]byte(timings), &fs.TimingsMs); err != nil {
+			return "", fs, fmt.Errorf("%s: timings_ms: %w", name, err)
+		}
+	}
+	fs.NextRetry = parseTime(nextRetry)
+	fs.UpdatedAt = parseTime(update)
+	return name, fs, nil
+}
+
+func encodeTimings(t map[string]int64) (string, error) {
+	if len(t) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+// sqliteTime формат времени в базе: UTC и всегда девять знаков долей
+// секунды, чтобы строки сравнивались в SQL в порядке времени. В
+// time.RFC3339Nano нули в конце отбрасываются ("…:00.5Z" меньше "…:00Z"),
+// а местное смещение меняется при переходе на летнее время.
+const sqliteTime = "2006-01-02T15:04:05.000000000Z"
+
+// formatTime время для базы; нулевое — пустая строка
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(sqliteTime)
+}
+
+// parseTime время из базы в местном поясе, как у записей JSON-файла;
+// RFC3339Nano читает и прежний формат со смещением
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// openTestStore открывает базу path и закрывает её после теста
+func openTestStore(t *testing.T, path, jsonPath string) *sqliteStore {
+	t.Helper()
+	s, err := openSQLiteStore(path, jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func userVersion(t *testing.T, s *sqliteStore) int {
+	t.Helper()
+	var v int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// Новая база получает все миграции, повторное открытие ничего не меняет,
+// а JSON-файл состояния импортируется только при создании базы
+func TestSQLiteMigrateAndImport(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath, dbPath := filepath.Join(dir, ".state.json"), filepath.Join(dir, ".state.db")
+	js := &jsonStore{path: jsonPath, Files: make(map[string]*FileState)}
+	err := js.Update(func(files map[string]*FileState) {
+		files["a.png"] = &FileState{Status: statusDone, Output: "out/a.md", Attempts: 1, Session: "s1", UpdatedAt: time.Now()}
+		files["b.png"] = &FileState{Status: statusRetry, LastError: "E500", Attempts: 2, NextRetry: time.Now().Add(time.Minute)}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := captureLog(t)
+	s := openTestStore(t, dbPath, jsonPath)
+	if v := userVersion(t, s); v != len(sqliteMigrations) {
+		t.Errorf("user_version %d, want %d", v, len(sqliteMigrations))
+	}
+	// Столбец из последней миграции
+	if _, err := s.db.Exec("UPDATE jobs SET owner = '' WHERE 0"); err != nil {
+		t.Errorf("нет столбца owner: %v", err)
+	}
+	if fs, ok := s.Get("a.png"); !ok || fs.Status != statusDone || fs.Output != "out/a.md" || fs.Session != "s1" {
+		t.Errorf("a.png не импортирован: %+v", fs)
+	}
+	if fs, _ := s.Get("b.png"); fs.Status != statusRetry || fs.Attempts != 2 || fs.NextRetry.IsZero() {
+		t.Errorf("b.png: %+v", fs)
+	}
+	if strings.Count(log.String(), msg.T("state.imported")) != 1 {
+		t.Errorf("лог импорта:\n%s", log)
+	}
+	if err := s.Reset([]string{"a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Файл состояния изменился, но база уже создана: повторного импорта нет
+	js.Update(func(files map[string]*FileState) {
+		files["c.png"] = &FileState{Status: statusDone}
+	})
+	log.Reset()
+	s = openTestStore(t, dbPath, jsonPath)
+	if v := userVersion(t, s); v != len(sqliteMigrations) {
+		t.Errorf("после повторного открытия user_version %d", v)
+	}
+	snap := s.Snapshot()
+	if _, ok := snap["a.png"]; ok || len(snap) != 1 {
+		t.Errorf("после повторного открытия %v", snap)
+	}
+	if strings.Contains(log.String(), msg.T("state.imported")) {
+		t.Errorf("повторный импорт:\n%s", log)
+	}
+}
+
+func TestSQLiteRecordAttempt(t *testing.T) {
+	s := openTestStore(t, filepath.Join(t.TempDir(), ".state.db"), "")
+	res := FileState{Session: "s1", TimingsMs: map[string]int64{"ocr": 120}}
+	if fs, err := s.RecordAttempt("a.png", res, errors.New("E500"), 3, time.Second); err != nil || fs.Status != statusRetry || fs.Attempts != 1 {
+		t.Fatalf("первая попытка: %+v, %v", fs, err)
+	}
+	if fs, err := s.RecordAttempt("a.png", res, nil, 3, time.Second); err != nil || fs.Status != statusDone || fs.Attempts != 2 {
+		t.Fatalf("вторая попытка: %+v, %v", fs, err)
+	}
+
+	var status string
+	var attempts int
+	if err := s.db.QueryRow("SELECT status, attempts FROM jobs WHERE name = 'a.png'").Scan(&status, &attempts); err != nil || status != statusDone || attempts != 2 {
+		t.Errorf("jobs: %s %d, %v", status, attempts, err)
+	}
+	rows, err := s.db.Query("SELECT attempt, status, error, timings_ms, session FROM attempts WHERE name = 'a.png' ORDER BY attempt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var n int
+		var status, errText, timings, session string
+		if err := rows.Scan(&n, &status, &errText, &timings, &session); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, strings.Join([]string{status, errText, timings, session}, "|"))
+	}
+	want := []string{`retry|E500|{"ocr":120}|s1`, `done||{"ocr":120}|s1`}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("attempts:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// Второе соединение видит запись первого после Refresh по PRAGMA
+// data_version, а без изменений копия в памяти не перечитывается
+func TestSQLiteRefreshSeesOtherHandle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".state.db")
+	a, b := openTestStore(t, path, ""), openTestStore(t, path, "")
+	if err := a.Set("a.png", FileState{Status: statusDone}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Get("a.png"); ok {
+		t.Fatal("запись видна до Refresh")
+	}
+	if err := b.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if fs, ok := b.Get("a.png"); !ok || fs.Status != statusDone {
+		t.Errorf("после Refresh: %+v, %v", fs, ok)
+	}
+
+	// Без изменений в базе копия в памяти не перечитывается: метка,
+	// добавленная только в копию, остаётся до следующей записи
+	b.files["marker.png"] = &FileState{}
+	if err := b.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Get("marker.png"); !ok {
+		t.Error("копия перечитана без изменений в базе")
+	}
+	if err := a.Set("b.png", FileState{Status: statusDone}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := b.Get("marker.png"); ok {
+		t.Error("копия не перечитана после записи другого соединения")
+	}
+}
+
+// Время в базе сравнивается строками в порядке времени: доли секунды
+// всегда девять знаков, пояс всегда UTC
+func TestSQLiteTimeOrder(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	base := time.Date(2026, 3, 29, 0, 59, 59, 0, time.UTC)
+	times := []time.Time{
+		base,
+		base.Add(500 * time.Millisecond),
+		base.Add(time.Second).In(moscow),
+		base.Add(time.Hour),
+	}
+	for i := 1; i < len(times); i++ {
+		if prev, cur := formatTime(times[i-1]), formatTime(times[i]); prev >= cur || len(prev) != len(cur) {
+			t.Errorf("%q не раньше %q", prev, cur)
+		}
+	}
+	for _, tm := range times {
+		if got := parseTime(formatTime(tm)); !got.Equal(tm) {
+			t.Errorf("%v прочитано как %v", tm, got)
+		}
+	}
+	if formatTime(time.Time{}) != "" || !parseTime("").IsZero() {
+		t.Error("нулевое время")
+	}
+
+	// last_at сессии — самое позднее время её записей, даже если позднее
+	// записано с долями секунды или раньше пришло со смещением пояса
+	s := openTestStore(t, filepath.Join(t.TempDir(), ".state.db"), "")
+	noon := time.Date(2026, 3, 29, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		session     string
+		first, last time.Time
+	}{
+		{"fraction", noon, noon.Add(500 * time.Millisecond)},
+		{"offset", noon.In(moscow), noon.Add(time.Second)},
+	} {
+		err := s.update(func(tx *sql.Tx) error {
+			if err := upsertJob(tx, tc.session+"-1.png", FileState{Session: tc.session, UpdatedAt: tc.first}); err != nil {
+				return err
+			}
+			return upsertJob(tx, tc.session+"-2.png", FileState{Session: tc.session, UpdatedAt: tc.last})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var started, last string
+		if err := s.db.QueryRow("SELECT started_at, last_at FROM sessions WHERE id = ?", tc.session).Scan(&started, &last); err != nil {
+			t.Fatal(err)
+		}
+		if !parseTime(started).Equal(tc.first) || !parseTime(last).Equal(tc.last) {
+			t.Errorf("%s: %s — %s, want %v — %v", tc.session, started, last, tc.first, tc.last)
+		}
+	}
+}
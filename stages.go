@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// stagedJob распознанное задание, ждущее обработчика модели
+type stagedJob struct {
+	ctx  context.Context
+	job  Job
+	rec  *apppipeline.Recognized
+	held *heldEffects
+}
+
+// startStages запускает config.Stages.OCR обработчиков распознавания и
+// config.Stages.LLM обработчиков модели. Распознанные задания ждут
+// обработчика модели в канале ёмкостью config.Stages.LLM; когда он полон,
+// распознавание ждёт. После отмены ctx новые задания не распознаются, а
+// уже распознанные доделываются: канал закрывается, когда выйдут все
+// обработчики распознавания, и обработчики модели выходят, разобрав его.
+// Итоги записываются в порядке постановки в очередь, а не получения
+// ответов: стенограмма и номера вопросов в ней идут в том же порядке, что
+// и при одном обработчике.
+func startStages(ctx, workCtx context.Context, q *Queue) *sync.WaitGroup {
+	nOCR, nLLM := config.Stages.OCR, config.Stages.LLM
+	activity.init(nOCR + nLLM)
+	slog.Info(msg.T("stages.started"), "ocr", nOCR, "llm", nLLM)
+	handoff := make(chan stagedJob, nLLM)
+	order := newCommitOrder()
+
+	var wg, recognizing sync.WaitGroup
+	for i := 0; i < nOCR; i++ {
+		wg.Add(1)
+		recognizing.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			defer recognizing.Done()
+			for {
+				job, ok := order.Take(ctx, q)
+				if !ok {
+					return
+				}
+				jobCtx, job, req := beginJob(withWorker(workCtx, id), job)
+				jobCtx, held := withHeldEffects(jobCtx)
+				rec, out, err := recognizeRecover(jobCtx, req)
+				if rec == nil {
+					order.Commit(job.Seq, func() {
+						held.run()
+						finishJob(jobCtx, job, out, err)
+						q.Done(job)
+					})
+					continue
+				}
+				handoff <- stagedJob{ctx: jobCtx, job: job, rec: rec, held: held}
+			}
+		}(i)
+	}
+	go func() {
+		recognizing.Wait()
+		close(handoff)
+	}()
+	for i := 0; i < nLLM; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for s := range handoff {
+				out, err := respondRecover(withWorker(s.ctx, id), s.rec)
+				order.Commit(s.job.Seq, func() {
+					s.held.run()
+					finishJob(s.ctx, s.job, out, err)
+					q.Done(s.job)
+				})
+			}
+		}(nOCR + i)
+	}
+	return &wg
+}
+
+// commitOrder выпускает итоги заданий в порядке, в котором они взяты из
+// очереди, то есть в порядке постановки. Take берёт задания по одному,
+// поэтому порядок взятия совпадает с Seq; Commit откладывает итог, пока не
+// записаны итоги всех взятых раньше.
+type commitOrder struct {
+	take sync.Mutex
+
+	mu      sync.Mutex
+	taken   []uint64
+	waiting map[uint64]func()
+}
+
+func newCommitOrder() *commitOrder {
+	return &commitOrder{waiting: make(map[uint64]func())}
+}
+
+// Take берёт следующее задание из очереди и запоминает его место
+func (o *commitOrder) Take(ctx context.Context, q *Queue) (Job, bool) {
+	o.take.Lock()
+	defer o.take.Unlock()
+	job, ok := nextJob(ctx, q)
+	if ok {
+		o.mu.Lock()
+		o.taken = append(o.taken, job.Seq)
+		o.mu.Unlock()
+	}
+	return job, ok
+}
+
+// Commit выполняет commit задания seq, как только выполнены итоги всех
+// заданий, взятых раньше, и вместе с ним — уже готовые итоги следующих
+func (o *commitOrder) Commit(seq uint64, commit func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.waiting[seq] = commit
+	for len(o.taken) > 0 {
+		next, ok := o.waiting[o.taken[0]]
+		if !ok {
+			return
+		}
+		delete(o.waiting, o.taken[0])
+		o.taken = o.taken[1:]
+		next()
+	}
+}
+
+type heldKey struct{}
+
+// heldEffects действия после сохранения ответа (запись в стенограмму,
+// публикация), которые в режиме stages выполняются вместе с записью итога
+// задания, по порядку очереди
+type heldEffects struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+func withHeldEffects(ctx context.Context) (context.Context, *heldEffects) {
+	h := &heldEffects{}
+	return context.WithValue(ctx, heldKey{}, h), h
+}
+
+// afterCommit выполняет fn сразу или, если задание обрабатывается по
+// этапам, откладывает до записи его итога
+func afterCommit(ctx context.Context, fn func()) {
+	h, ok := ctx.Value(heldKey{}).(*heldEffects)
+	if !ok {
+		fn()
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fns = append(h.fns, fn)
+}
+
+func (h *heldEffects) run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.fns = nil
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
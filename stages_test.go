@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hack_interview/internal/llm"
+)
+
+func TestCommitOrder(t *testing.T) {
+	q := newQueue(3)
+	for _, name := range []string{"a", "b", "c"} {
+		q.TryPush(Job{Name: name})
+	}
+	o := newCommitOrder()
+	var jobs []Job
+	for range 3 {
+		job, ok := o.Take(context.Background(), q)
+		if !ok {
+			t.Fatal("задание не взято")
+		}
+		jobs = append(jobs, job)
+	}
+
+	var got []string
+	for _, i := range []int{2, 0, 1} {
+		job := jobs[i]
+		o.Commit(job.Seq, func() { got = append(got, job.Name) })
+		if i == 2 && len(got) != 0 {
+			t.Fatalf("итог c записан раньше a и b: %v", got)
+		}
+	}
+	if strings.Join(got, ",") != "a,b,c" {
+		t.Errorf("порядок итогов %v", got)
+	}
+}
+
+func TestAfterCommit(t *testing.T) {
+	ran := 0
+	afterCommit(context.Background(), func() { ran++ })
+	if ran != 1 {
+		t.Fatal("без этапов действие не выполнено сразу")
+	}
+	ctx, held := withHeldEffects(context.Background())
+	afterCommit(ctx, func() { ran++ })
+	if ran != 1 {
+		t.Fatal("действие выполнено до записи итога")
+	}
+	held.run()
+	if ran != 2 {
+		t.Fatal("отложенное действие не выполнено")
+	}
+}
+
+// namedLatencyLLM отвечает тем дольше, чем раньше файл в списке slow
+type namedLatencyLLM struct {
+	delay map[string]time.Duration
+}
+
+func (m namedLatencyLLM) Answer(ctx context.Context, _ llm.Request) (string, error) {
+	info, _ := dumpInfoFrom(ctx)
+	select {
+	case <-time.After(m.delay[info.Name]):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	return "ответ " + info.Name, nil
+}
+
+// runStaged обрабатывает names до конца и возвращает порядок записи
+// итогов и общее время
+func runStaged(t *testing.T, names []string) ([]string, time.Duration) {
+	t.Helper()
+	writeInput(t, names...)
+	q := newQueue(len(names))
+	var mu sync.Mutex
+	var order []string
+	for _, name := range names {
+		q.TryPush(Job{Name: name, Path: filepath.Join(config.InputDir, name), OnDone: func(FileState) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}})
+	}
+	start := time.Now()
+	ctx, stop := context.WithCancel(context.Background())
+	wg := startWorkers(ctx, context.Background(), q, config.Workers)
+	q.WaitIdle(context.Background())
+	took := time.Since(start)
+	stop()
+	wg.Wait()
+	return order, took
+}
+
+// Итоги записываются в порядке очереди, даже если первый файл отвечает
+// дольше остальных
+func TestStagesCommitInQueueOrder(t *testing.T) {
+	testEnv(t, "stages:\n  ocr: 1\n  llm: 3\n")
+	names := []string{"a.png", "b.png", "c.png", "d.png"}
+	pipeline.LLM = namedLatencyLLM{delay: map[string]time.Duration{"a": 400 * time.Millisecond, "b": 200 * time.Millisecond}}
+
+	order, _ := runStaged(t, names)
+	if got := strings.Join(order, ","); got != strings.Join(names, ",") {
+		t.Errorf("порядок итогов %s", got)
+	}
+	for _, name := range names {
+		if fs, _ := state.Get(name); fs.Status != statusDone {
+			t.Errorf("%s: статус %q", name, fs.Status)
+		}
+	}
+}
+
+// С медленными провайдерами этапы, работающие одновременно, обрабатывают
+// пачку файлов быстрее, чем один обработчик
+func TestStagesThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("замер пропускается в short-режиме")
+	}
+	const files = 6
+	var names []string
+	for i := range files {
+		names = append(names, fmt.Sprintf("q%d.png", i+1))
+	}
+	latency := "  ocrLatency: 200ms\n  llmLatency: 300ms\n"
+
+	testEnv(t, latency+"workers: 1\n")
+	_, serial := runStaged(t, names)
+
+	testEnv(t, latency+"stages:\n  ocr: 1\n  llm: 2\n")
+	order, staged := runStaged(t, names)
+
+	t.Logf("workers 1: %v, stages 1/2: %v", serial, staged)
+	if staged > serial*7/10 {
+		t.Errorf("stages 1/2 заняли %v при %v у одного обработчика", staged, serial)
+	}
+	if got := strings.Join(order, ","); got != strings.Join(names, ",") {
+		t.Errorf("порядок итогов %s", got)
+	}
+}
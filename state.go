@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	appconfig "hack_interview/internal/config"
+)
+
+// Статусы файла в состоянии
+const (
+	statusProcessing = "processing"
+	statusDone       = "done"
+	statusRetry      = "retry"  // ошибка, файл будет обработан повторно
+	statusFailed     = "failed" // попытки исчерпаны
+	statusSkipped    = "skipped"
+)
+
+// FileState запись о файле в сохраняемом состоянии
+type FileState struct {
+	Status    string `json:"status,omitempty"`
+	Output    string `json:"output,omitempty"`
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+	// Text распознанный текст пропущенного файла, чтобы было видно, почему
+	// он не похож на вопрос
+	Text      string    `json:"text,omitempty"`
+	NextRetry time.Time `json:"nextRetry,omitempty"`
+	// Group имя результата группы снимков, в которую вошёл файл
+	Group string `json:"group,omitempty"`
+	// MovedTo новый путь файла, перенесённого в errorsDir
+	MovedTo string `json:"movedTo,omitempty"`
+	// ETag версия объекта во внешнем источнике (S3)
+	ETag string `json:"etag,omitempty"`
+	// TimingsMs длительности этапов последней попытки в миллисекундах;
+	// подтверждение источнику (delivery) идёт после записи и сюда не входит
+	TimingsMs map[string]int64 `json:"timingsMs,omitempty"`
+	// FailedStage этап, на котором завершилась ошибкой последняя попытка
+	FailedStage string `json:"failedStage,omitempty"`
+	// TokensIn и TokensOut токены запроса и ответа модели за последнюю
+	// попытку, включая дополнительные запросы (проверка, тесты и т. п.)
+	TokensIn  int `json:"tokensIn,omitempty"`
+	TokensOut int `json:"tokensOut,omitempty"`
+	// Session сессия, в которой файл обработан
+	Session string `json:"session,omitempty"`
+	// Owner экземпляр, который обрабатывает файл (статус processing)
+	Owner     string    `json:"owner,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Store сохраняемое между запусками состояние обработки. Его могут
+// одновременно менять наблюдатель и команды reprocess, reset и т. п.,
+// поэтому реализации перечитывают актуальные данные перед изменением.
+type Store interface {
+	// Refresh перечитывает состояние, если его изменил другой процесс
+	Refresh() error
+	// Eligible сообщает, нужно ли ставить файл в очередь: он ещё не
+	// встречался либо ждёт повторной попытки и её время наступило.
+	Eligible(name string, now time.Time) bool
+	Get(name string) (FileState, bool)
+	// Set заменяет запись о файле
+	Set(name string, fs FileState) error
+	// Save сбрасывает состояние на диск
+	Save() error
+	// RecordAttempt фиксирует итог очередной попытки обработки файла
+	RecordAttempt(name string, res FileState, procErr error, maxAttempts int, base time.Duration) (FileState, error)
+	// MarkSkipped отмечает файлы пропущенными без обращения к API
+	MarkSkipped(names []string, reason string) error
+	// Reset удаляет записи о файлах, чтобы наблюдатель обработал их заново
+	Reset(names []string) error
+	// Snapshot возвращает копию всех записей
+	Snapshot() map[string]FileState
+	Close() error
+}
+
+var state Store
+
+// loadState открывает хранилище из конфигурации
+func loadState() error {
+	if config.StateBackend == appconfig.StateSQLite {
+		s, err := openSQLiteStore(config.StateDB, config.StateFile)
+		if err != nil {
+			return err
+		}
+		state = s
+		return nil
+	}
+	s := &jsonStore{path: config.StateFile, Files: make(map[string]*FileState)}
+	state = s
+	return s.Refresh()
+}
+
+// jsonStore состояние в JSON-файле.
+//
+// Каждое изменение выполняется под advisory-блокировкой <stateFile>.lock:
+// состояние перечитывается с диска, изменяется и записывается обратно.
+type jsonStore struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	Files   map[string]*FileState `json:"files"`
+}
+
+func (s *jsonStore) Refresh() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fi, err := os.Stat(s.path); err == nil && fi.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.read()
+}
+
+func (s *jsonStore) read() error {
+	fi, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	s.modTime = fi.ModTime()
+
+	var disk jsonStore
+	if err := json.Unmarshal(data, &disk); err != nil {
+		return err
+	}
+	s.Files = disk.Files
+	if s.Files == nil {
+		s.Files = make(map[string]*FileState)
+	}
+	return nil
+}
+
+// Update применяет fn к актуальному состоянию и сохраняет результат
+func (s *jsonStore) Update(fn func(files map[string]*FileState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.read(); err != nil {
+		return err
+	}
+	fn(s.Files)
+	return s.write()
+}
+
+func (s *jsonStore) Eligible(name string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return eligible(s.Files[name], now)
+}
+
+// eligible решение Eligible по записи о файле; nil — файл не встречался
+func eligible(fs *FileState, now time.Time) bool {
+	if fs == nil {
+		return true
+	}
+	return fs.Status == statusRetry && !now.Before(fs.NextRetry)
+}
+
+func (s *jsonStore) Get(name string) (FileState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fs, ok := s.Files[name]
+	if !ok {
+		return FileState{}, false
+	}
+	return *fs, true
+}
+
+func (s *jsonStore) Set(name string, fs FileState) error {
+	fs.UpdatedAt = time.Now()
+	return s.Update(func(files map[string]*FileState) {
+		files[name] = &fs
+	})
+}
+
+func (s *jsonStore) Save() error {
+	return s.Update(func(map[string]*FileState) {})
+}
+
+// write записывает состояние через временный файл, чтобы прерванная запись
+// не испортила предыдущую версию.
+func (s *jsonStore) write() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(s.path); err == nil {
+		s.modTime = fi.ModTime()
+	}
+	return nil
+}
+
+// retryDelay задержка перед попыткой номер attempts+1: base, 2*base, 4*base...
+// не больше maxRetryDelay
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return d
+}
+
+const maxRetryDelay = time.Hour
+
+func (s *jsonStore) RecordAttempt(name string, res FileState, procErr error, maxAttempts int, base time.Duration) (FileState, error) {
+	var result FileState
+	err := s.Update(func(files map[string]*FileState) {
+		fs := nextAttempt(files[name], res, procErr, maxAttempts, base)
+		files[name] = &fs
+		result = fs
+	})
+	return result, err
+}
+
+// nextAttempt запись после очередной попытки. В res передаются сведения о
+// попытке (путь результата, версия источника); статус и счётчик попыток
+// заполняются здесь по предыдущей записи prev. При ошибке назначается
+// следующая попытка, пока не исчерпан maxAttempts.
+func nextAttempt(prev *FileState, res FileState, procErr error, maxAttempts int, base time.Duration) FileState {
+	fs := res
+	fs.Attempts = 0
+	if prev != nil {
+		fs.Attempts = prev.Attempts
+	}
+	fs.Attempts++
+	fs.UpdatedAt = time.Now()
+
+	switch {
+	case procErr == nil:
+		fs.Status = statusDone
+	case fs.Attempts >= maxAttempts:
+		fs.Status = statusFailed
+		fs.LastError = procErr.Error()
+	default:
+		fs.Status = statusRetry
+		fs.LastError = procErr.Error()
+		fs.NextRetry = fs.UpdatedAt.Add(retryDelay(base, fs.Attempts))
+	}
+	return fs
+}
+
+func (s *jsonStore) MarkSkipped(names []string, reason string) error {
+	now := time.Now()
+	return s.Update(func(files map[string]*FileState) {
+		for _, name := range names {
+			files[name] = &FileState{Status: statusSkipped, LastError: reason, UpdatedAt: now}
+		}
+	})
+}
+
+func (s *jsonStore) Reset(names []string) error {
+	return s.Update(func(files map[string]*FileState) {
+		for _, name := range names {
+			delete(files, name)
+		}
+	})
+}
+
+func (s *jsonStore) Snapshot() map[string]FileState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]FileState, len(s.Files))
+	for name, fs := range s.Files {
+		out[name] = *fs
+	}
+	return out
+}
+
+func (s *jsonStore) Close() error { return nil }
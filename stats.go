@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// statsReport сводка по записям состояния для команды stats
+type statsReport struct {
+	Since    *time.Time     `json:"since,omitempty"`
+	Until    *time.Time     `json:"until,omitempty"`
+	Files    int            `json:"files"`
+	ByStatus map[string]int `json:"byStatus"`
+	Stages   []statsStage   `json:"stages"`
+	Tokens   statsTokens    `json:"tokens"`
+	// Hours число файлов по часам местного времени
+	Hours    [24]int        `json:"hours"`
+	Kinds    map[string]int `json:"kinds"`
+	Sessions []statsSession `json:"sessions"`
+}
+
+// statsStage файлы, дошедшие до этапа, ошибки на нём и длительности
+type statsStage struct {
+	Stage       string  `json:"stage"`
+	Files       int     `json:"files"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failureRate"`
+	AvgMs       int64   `json:"avgMs"`
+	P95Ms       int64   `json:"p95Ms"`
+}
+
+type statsTokens struct {
+	Input  int `json:"input"`
+	Output int `json:"output"`
+	// CostUSD оценка по ценам gemini.price
+	CostUSD float64 `json:"costUsd"`
+}
+
+type statsSession struct {
+	Session string      `json:"session"`
+	Files   int         `json:"files"`
+	Done    int         `json:"done"`
+	Failed  int         `json:"failed"`
+	Skipped int         `json:"skipped"`
+	Tokens  statsTokens `json:"tokens"`
+	First   time.Time   `json:"first"`
+	Last    time.Time   `json:"last"`
+}
+
+// runStats печатает сводку обработки по записям состояния
+func runStats(args []string) int {
+	fset := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := fset.Bool("json", false, "вывести сводку в JSON")
+	sinceFlag := fset.String("since", "", "начало периода: дата, RFC3339 или давность (72h, 7d)")
+	untilFlag := fset.String("until", "", "конец периода: дата, RFC3339 или давность (72h, 7d)")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	since, until, err := statsPeriod(*sinceFlag, *untilFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	loadConfig()
+	prepareDirs()
+
+	report := buildStats(state.Snapshot(), since, until)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			slog.Error(msg.T("output.failed"), "error", err)
+			return 1
+		}
+		return 0
+	}
+	printStats(report)
+	return 0
+}
+
+// statsPeriod разбирает границы периода --since и --until; пустое значение
+// означает отсутствие границы
+func statsPeriod(sinceValue, untilValue string) (since, until *time.Time, err error) {
+	now := time.Now()
+	if sinceValue != "" {
+		t, err := parseStatsTime(sinceValue, now, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("since: %w", err)
+		}
+		since = &t
+	}
+	if untilValue != "" {
+		t, err := parseStatsTime(untilValue, now, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("until: %w", err)
+		}
+		until = &t
+	}
+	return since, until, nil
+}
+
+// parseStatsTime разбирает границу периода: дату (для конца периода —
+// включительно), RFC3339 или давность от now вида 72h и 7d
+func parseStatsTime(s string, now time.Time, end bool) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("ожидается дата 2006-01-02, RFC3339 или давность вида 72h, 7d: %q", s)
+}
+
+// buildStats собирает сводку по записям, обновлённым в [since, until).
+// Части группы снимков делят один итог и считаются одним файлом.
+func buildStats(files map[string]FileState, since, until *time.Time) statsReport {
+	report := statsReport{Since: since, Until: until, ByStatus: make(map[string]int), Kinds: make(map[string]int)}
+	stageFiles := make(map[string]int)
+	stageFailed := make(map[string]int)
+	samples := make(map[string][]time.Duration)
+	sessions := make(map[string]*statsSession)
+	groups := make(map[string]bool)
+
+	// Порядок имён делает выбор представителя группы детерминированным
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fs := files[name]
+		if since != nil && fs.UpdatedAt.Before(*since) || until != nil && !fs.UpdatedAt.Before(*until) {
+			continue
+		}
+		if fs.Group != "" {
+			if groups[fs.Group] {
+				continue
+			}
+			groups[fs.Group] = true
+		}
+
+		report.Files++
+		report.ByStatus[fs.Status]++
+		report.Hours[fs.UpdatedAt.Local().Hour()]++
+		for stage, ms := range fs.TimingsMs {
+			stageFiles[stage]++
+			samples[stage] = append(samples[stage], time.Duration(ms)*time.Millisecond)
+		}
+		if fs.FailedStage != "" && fs.Status != statusDone {
+			stageFailed[fs.FailedStage]++
+		}
+		tokens := statsTokens{Input: fs.TokensIn, Output: fs.TokensOut}
+		report.Tokens.Input += tokens.Input
+		report.Tokens.Output += tokens.Output
+
+		if fs.Status == statusDone && fs.Output != "" {
+			if text, err := os.ReadFile(filepath.Join(filepath.Dir(fs.Output), ocrTextName(fs.Output))); err == nil {
+				report.Kinds[kindLabel(classifyText(string(text)))]++
+			}
+		}
+
+		id := fs.Session
+		if id == "" {
+			id = "-"
+		}
+		ss, ok := sessions[id]
+		if !ok {
+			ss = &statsSession{Session: id, First: fs.UpdatedAt, Last: fs.UpdatedAt}
+			sessions[id] = ss
+		}
+		ss.Files++
+		switch fs.Status {
+		case statusDone:
+			ss.Done++
+		case statusFailed, statusRetry:
+			ss.Failed++
+		case statusSkipped:
+			ss.Skipped++
+		}
+		ss.Tokens.Input += tokens.Input
+		ss.Tokens.Output += tokens.Output
+		if fs.UpdatedAt.Before(ss.First) {
+			ss.First = fs.UpdatedAt
+		}
+		if fs.UpdatedAt.After(ss.Last) {
+			ss.Last = fs.UpdatedAt
+		}
+	}
+
+	report.Tokens.CostUSD = tokenCost(report.Tokens)
+	for _, stage := range stageOrder {
+		s := samples[stage]
+		if len(s) == 0 {
+			continue
+		}
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+		var sum time.Duration
+		for _, d := range s {
+			sum += d
+		}
+		st := statsStage{
+			Stage:  stage,
+			Files:  stageFiles[stage],
+			Failed: stageFailed[stage],
+			AvgMs:  (sum / time.Duration(len(s))).Milliseconds(),
+			P95Ms:  percentile(s, 95).Milliseconds(),
+		}
+		st.FailureRate = float64(st.Failed) / float64(st.Files)
+		report.Stages = append(report.Stages, st)
+	}
+	for _, ss := range sessions {
+		ss.Tokens.CostUSD = tokenCost(ss.Tokens)
+		report.Sessions = append(report.Sessions, *ss)
+	}
+	sort.Slice(report.Sessions, func(i, j int) bool { return report.Sessions[i].First.Before(report.Sessions[j].First) })
+	return report
+}
+
+// tokenCost оценка расходов в долларах по ценам за миллион токенов
+func tokenCost(t statsTokens) float64 {
+	price := config.Gemini.Price
+	return (float64(t.Input)*price.Input + float64(t.Output)*price.Output) / 1e6
+}
+
+func printStats(r statsReport) {
+	if r.Files == 0 {
+		fmt.Println(msg.T("stats.empty"))
+		return
+	}
+	fmt.Printf(msg.T("stats.files"), r.Files,
+		r.ByStatus[statusDone], r.ByStatus[statusFailed], r.ByStatus[statusRetry], r.ByStatus[statusSkipped])
+
+	if len(r.Stages) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, msg.T("stats.stages-header"))
+		for _, s := range r.Stages {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\t%v\t%v\n", s.Stage, s.Files, s.Failed, s.FailureRate*100,
+				time.Duration(s.AvgMs)*time.Millisecond, time.Duration(s.P95Ms)*time.Millisecond)
+		}
+		w.Flush()
+	}
+
+	fmt.Printf(msg.T("stats.tokens"), r.Tokens.Input, r.Tokens.Output, r.Tokens.CostUSD)
+
+	hours := make([]int, 0, 24)
+	for h, n := range r.Hours {
+		if n > 0 {
+			hours = append(hours, h)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return r.Hours[hours[i]] > r.Hours[hours[j]] })
+	if len(hours) > 5 {
+		hours = hours[:5]
+	}
+	busiest := make([]string, 0, len(hours))
+	for _, h := range hours {
+		busiest = append(busiest, fmt.Sprintf("%02d:00 (%d)", h, r.Hours[h]))
+	}
+	fmt.Println(msg.T("stats.peak-hours"), strings.Join(busiest, ", "))
+
+	if len(r.Kinds) > 0 {
+		kinds := make([]string, 0, len(r.Kinds))
+		for k := range r.Kinds {
+			kinds = append(kinds, k)
+		}
+		sort.Slice(kinds, func(i, j int) bool {
+			if r.Kinds[kinds[i]] != r.Kinds[kinds[j]] {
+				return r.Kinds[kinds[i]] > r.Kinds[kinds[j]]
+			}
+			return kinds[i] < kinds[j]
+		})
+		for i, k := range kinds {
+			kinds[i] = fmt.Sprintf("%s %d", k, r.Kinds[k])
+		}
+		fmt.Println(msg.T("stats.kinds"), strings.Join(kinds, ", "))
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, msg.T("stats.sessions-header"))
+	for _, s := range r.Sessions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t$%.4f\n", s.Session, s.First.Local().Format("2006-01-02 15:04"),
+			s.Files, s.Done, s.Failed, s.Skipped, s.Tokens.Input+s.Tokens.Output, s.Tokens.CostUSD)
+	}
+	w.Flush()
+}
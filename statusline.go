@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Этапы обработки файла для строки состояния
+const (
+	activityIdle = apppipeline.ActivityIdle
+	activityOCR  = apppipeline.ActivityOCR
+	activityLLM  = apppipeline.ActivityLLM
+)
+
+type workerActivity struct {
+	Stage string
+	File  string
+	Since time.Time
+}
+
+// activityTracker текущее занятие каждого обработчика
+type activityTracker struct {
+	mu      sync.Mutex
+	workers []workerActivity
+}
+
+var activity = &activityTracker{}
+
+type workerKey struct{}
+
+func (a *activityTracker) init(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.workers = make([]workerActivity, n)
+	for i := range a.workers {
+		a.workers[i] = workerActivity{Stage: activityIdle}
+	}
+}
+
+// withWorker привязывает к контексту номер обработчика, чтобы processFile
+// мог отмечать этапы
+func withWorker(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, workerKey{}, id)
+}
+
+// Set отмечает этап обработчика из контекста; вне обработчиков (команды
+// process, reprocess) ничего не делает
+func (a *activityTracker) Set(ctx context.Context, stage, file string) {
+	id, ok := ctx.Value(workerKey{}).(int)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	if id < len(a.workers) {
+		a.workers[id] = workerActivity{Stage: stage, File: file, Since: time.Now()}
+	}
+	a.mu.Unlock()
+	bus.Publish(Event{Kind: EventStage, Worker: id, Stage: stage, Path: file})
+}
+
+func (a *activityTracker) Snapshot() []workerActivity {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]workerActivity(nil), a.workers...)
+}
+
+// consoleWriter stderr с необязательной строкой состояния внизу: перед
+// записью лога строка стирается и затем рисуется заново, поэтому логи
+// всегда оказываются над ней
+type consoleWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	status string
+}
+
+var console = &consoleWriter{w: os.Stderr}
+
+func (c *consoleWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status == "" {
+		return c.w.Write(p)
+	}
+	fmt.Fprint(c.w, "\r\033[K")
+	n, err := c.w.Write(p)
+	fmt.Fprint(c.w, c.status)
+	return n, err
+}
+
+// SetStatus перерисовывает строку состояния; пустая строка убирает её
+func (c *consoleWriter) SetStatus(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprint(c.w, "\r\033[K", line)
+	c.status = line
+}
+
+// statusLineEnabled строка состояния рисуется, только если stderr —
+// терминал и она не отключена в конфигурации
+func statusLineEnabled() bool {
+	if config.StatusLine != nil && !*config.StatusLine {
+		return false
+	}
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// runStatusLine обновляет строку состояния раз в секунду, а без терминала
+// пишет то же самое в лог раз в StatusInterval
+func runStatusLine(ctx context.Context, q *Queue) {
+	if !statusLineEnabled() {
+		ticker := time.NewTicker(config.StatusInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				slog.Info(msg.T("statusline.status"), "workers", strings.Join(workerSummaries(time.Now()), ", "),
+					"queue", q.Len(), "processed", totals.ProcessedCount(), "failed", totals.FailedCount(),
+					"ocrRequests", totals.OCRRequestCount(), "tokens", totals.TokenCount())
+			}
+		}
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		console.SetStatus(statusLine(q, time.Now()))
+		select {
+		case <-ctx.Done():
+			console.SetStatus("")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func statusLine(q *Queue, now time.Time) string {
+	width := 0
+	if w, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil {
+		width = w
+	}
+	line := fmt.Sprintf(msg.T("statusline.line"),
+		strings.Join(workerSummaries(now), " | "), q.Len(), q.Cap(),
+		totals.ProcessedCount(), totals.FailedCount(), totals.OCRRequestCount(), totals.TokenCount())
+	// Перенос строки сломал бы перерисовку, поэтому лишнее обрезается
+	if r := []rune(line); width > 0 && len(r) >= width {
+		line = string(r[:width-1])
+	}
+	return line
+}
+
+func workerSummaries(now time.Time) []string {
+	var out []string
+	for _, w := range activity.Snapshot() {
+		if w.Stage == activityIdle {
+			out = append(out, activityIdle)
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s %s %ds", w.Stage, filepath.Base(w.File), int(now.Sub(w.Since).Seconds())))
+	}
+	return out
+}
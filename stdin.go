@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// stdinExtensions форматы, распознаваемые по сигнатуре данных из stdin
+var stdinExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// stdinName имя для изображения без исходного файла; по нему же
+// называется результат
+func stdinName(now time.Time, ext string) string {
+	return "stdin-" + now.Format("20060102-150405") + ext
+}
+
+// readStdinImage читает изображение и определяет формат по сигнатуре
+func readStdinImage(r io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) == 0 {
+		return nil, "", errors.New("stdin пуст, ожидается изображение")
+	}
+	ext, ok := stdinExtensions[http.DetectContentType(data)]
+	if !ok {
+		return nil, "", errors.New("данные из stdin не похожи на PNG, JPEG или GIF")
+	}
+	return data, ext, nil
+}
+
+// processStdin обрабатывает изображение из stdin и печатает ответ в stdout.
+// Результат также записывается в директорию результатов, как для файла.
+func processStdin(ctx context.Context, req apppipeline.Request) int {
+	data, ext, err := readStdinImage(os.Stdin)
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+
+	dir, err := os.MkdirTemp("", "hack_interview-stdin-*")
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	req.Path = filepath.Join(dir, stdinName(time.Now(), ext))
+	if err := os.WriteFile(req.Path, data, 0600); err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+
+	out, err := processFile(ctx, req)
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+	if req.DryRun {
+		return 0
+	}
+
+	answer, err := os.ReadFile(out)
+	if err != nil {
+		slog.Error(err.Error())
+		return 1
+	}
+	fmt.Print(string(answer))
+	return 0
+}
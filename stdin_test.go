@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+func TestStdinName(t *testing.T) {
+	now := time.Date(2026, 3, 7, 9, 5, 1, 0, time.UTC)
+	if got := stdinName(now, ".png"); got != "stdin-20260307-090501.png" {
+		t.Errorf("stdinName = %q", got)
+	}
+}
+
+func TestReadStdinImage(t *testing.T) {
+	for _, tc := range []struct {
+		name, data, ext, err string
+	}{
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", ".png", ""},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF\x00", ".jpg", ""},
+		{"gif", "GIF89a\x01\x00\x01\x00", ".gif", ""},
+		{"empty", "", "", "stdin пуст"},
+		{"text", "просто текст", "", "PNG, JPEG или GIF"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, ext, err := readStdinImage(strings.NewReader(tc.data))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err = %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil || ext != tc.ext {
+				t.Errorf("ext = %q, err = %v; want %q", ext, err, tc.ext)
+			}
+		})
+	}
+}
+
+// Результат изображения из stdin называется stdin-<время>.md
+func TestProcessStdinOutputName(t *testing.T) {
+	testEnv(t, "")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	t.Cleanup(func() { os.Stdin, os.Stdout = oldStdin, oldStdout })
+	os.Stdin = r
+	w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+	w.Close()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+
+	if code := processStdin(context.Background(), apppipeline.Request{Prompt: config.PROMPT}); code != 0 {
+		t.Fatalf("processStdin = %d", code)
+	}
+	matches, _ := filepath.Glob(filepath.Join(config.OutputDir, "stdin-*.md"))
+	if len(matches) != 1 || !regexp.MustCompile(`^stdin-\d{8}-\d{6}\.md$`).MatchString(filepath.Base(matches[0])) {
+		t.Fatalf("результаты: %v", matches)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil || !bytes.Contains(data, []byte(config.Mock.Answer)) {
+		t.Errorf("результат %q, err = %v", data, err)
+	}
+}
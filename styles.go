@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	appconfig "hack_interview/internal/config"
+)
+
+// styleFor выбирает стиль ответа для файла: override, если задан, затем
+// по styleDirs, если файл лежит во входной директории, иначе style из
+// конфигурации
+func styleFor(path, override string) (string, appconfig.Style) {
+	name := config.Style
+	if override != "" {
+		name = override
+	} else if dirName, ok := matchDirs(path, config.StyleDirs); ok {
+		name = dirName
+	}
+	style, _ := config.StyleByName(name)
+	return name, style
+}
+
+// matchDirs значение dirs для файла во входной директории по первому
+// подходящему шаблону пути относительно InputDir в порядке шаблонов по
+// алфавиту
+func matchDirs(path string, dirs map[string]string) (string, bool) {
+	if len(dirs) == 0 {
+		return "", false
+	}
+	rel, ok := stateName(path)
+	if !ok {
+		return "", false
+	}
+	patterns := make([]string, 0, len(dirs))
+	for p := range dirs {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return dirs[p], true
+		}
+	}
+	return "", false
+}
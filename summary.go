@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+)
+
+// summaryMaxRunes предел длины краткого ответа; длиннее обрезается
+const summaryMaxRunes = 160
+
+// cheatSummary просит модель одним предложением сказать главное из ответа.
+// Пустая строка, если запрос не удался: ответ сохраняется и без неё.
+func (p *Pipeline) cheatSummary(ctx context.Context, logger *slog.Logger, question, answer string) string {
+	prompt := "Сформулируй одним коротким предложением, что нужно сказать интервьюеру первым, " +
+		"по ответу ниже. Без вступлений, кода и форматирования.\n\nВопрос:\n" + question +
+		"\n\nОтвет:\n" + answer
+	resp, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt, MaxOutputTokens: 80})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("summary.failed"), "error", err)
+		return ""
+	}
+	return capSummary(resp)
+}
+
+// capSummary оставляет первую строку без разметки и обрезает её до
+// summaryMaxRunes
+func capSummary(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	s = strings.Trim(s, "*_#> ")
+	if r := []rune(s); len(r) > summaryMaxRunes {
+		s = strings.TrimSpace(string(r[:summaryMaxRunes-1])) + "…"
+	}
+	return s
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	appconfig "hack_interview/internal/config"
+)
+
+// testEnv собирает конфигурацию из extra поверх провайдеров-заготовок во
+// временной директории и загружает её, как loadConfig и prepareDirs:
+// задаёт config, pipeline и state. Рабочая директория на время теста —
+// временная, глобальное состояние восстанавливается после теста.
+func testEnv(t *testing.T, extra string) string {
+	t.Helper()
+	return loadTestEnv(t, "ocrProvider: mock\nllmProvider: mock\n"+
+		"mock:\n  text: Что такое горутина?\n  answer: Лёгкий поток.\n"+extra)
+}
+
+// loadTestEnv то же, что testEnv, но провайдеры задаются в yml целиком;
+// входная и выходная директории и промпт уже заданы
+func loadTestEnv(t *testing.T, yml string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, d := range []string{"in", "out"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yml = "inputDir: in\noutputDir: out\nPROMPT: Ответь\n" + yml
+	path := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(path, []byte(yml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	oldConfig, oldPipeline, oldState, oldTotals, oldGroups := config, pipeline, state, totals, groups
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		config, pipeline, state, totals, groups = oldConfig, oldPipeline, oldState, oldTotals, oldGroups
+	})
+
+	c, err := appconfig.Load("config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config = c
+	p, err := newPipeline(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pipeline = p
+	totals = &Totals{Started: time.Now(), Failures: make(map[string]string)}
+	groups = &grouper{groups: make(map[string]*pendingGroup), held: make(map[string]string)}
+	if err := loadState(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writeInput создаёт во входной директории файлы names
+func writeInput(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(config.InputDir, name), []byte("png"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/output"
+)
+
+// flagNoTests отключает генерацию тестов на этот запуск
+var flagNoTests bool
+
+// generateTests вторым запросом к модели получает модульные тесты для кода
+// из ответа и сохраняет код в <имя>.<ext>, а тесты — в <имя>_test.<ext>. Ошибки только
+// пишутся в лог: основной ответ уже сохранён и остаётся успешным.
+func (p *Pipeline) generateTests(ctx context.Context, logger *slog.Logger, resultPath, answer string) {
+	lang := strings.ToLower(config.Tests.Language)
+	code := output.ExtractCode(answer, lang)
+	if code == "" {
+		logger.Debug(msg.T("tests.no-code"), "language", lang)
+		return
+	}
+	ext := codeExtensions[lang]
+	if ext == "" {
+		ext = lang
+	}
+	codePath, err := output.SaveCode(resultPath, "."+ext, code)
+	if err != nil {
+		logger.Warn(msg.T("code.save-failed"), "error", err)
+		return
+	}
+	timingsFrom(ctx).AddOutput(codePath)
+
+	prompt := fmt.Sprintf("%s\n\n```%s\n%s\n```", testsPrompt(lang), lang, code)
+	testsAnswer, err := p.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("tests.failed"), "error", err)
+		return
+	}
+	tests := output.ExtractCode(testsAnswer, lang)
+	if tests == "" {
+		logger.Warn(msg.T("tests.no-block"), "language", lang)
+		return
+	}
+	path, err := output.SaveCode(resultPath, "_test."+ext, tests)
+	if err != nil {
+		logger.Warn(msg.T("tests.save-failed"), "error", err)
+		return
+	}
+	timingsFrom(ctx).AddOutput(path)
+	logger.Info(msg.T("tests.saved"), "output", path)
+}
+
+func testsPrompt(lang string) string {
+	if config.Tests.Prompt != "" {
+		return config.Tests.Prompt
+	}
+	if lang == "go" {
+		return "Напиши табличные тесты на Go (пакет testing, t.Run для каждого случая) для решения ниже: " +
+			"обычные и крайние случаи. Ответь одним блоком ```go с полным файлом _test.go."
+	}
+	return fmt.Sprintf("Напиши модульные тесты для решения ниже в принятом для %s стиле и фреймворке: "+
+		"обычные и крайние случаи. Ответь одним блоком ```%s с полным файлом тестов.", lang, lang)
+}
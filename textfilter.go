@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"hack_interview/internal/classify"
+	appconfig "hack_interview/internal/config"
+	"hack_interview/internal/msg"
+)
+
+// filterReason причина не отправлять распознанный текст модели или пустая
+// строка: текст слишком короткий или не похож на вопрос
+func filterReason(logger *slog.Logger, text string) string {
+	if reason := shortTextReason(text); reason != "" {
+		return reason
+	}
+	return questionFilterReason(logger, text)
+}
+
+// shortTextReason причина пропуска слишком короткого текста или пустая
+// строка. Символы считаются после схлопывания пробелов, словом считается
+// фрагмент хотя бы с одной буквой или цифрой, чтобы рамки и «|» из OCR
+// не добавляли слов.
+func shortTextReason(text string) string {
+	normalized := strings.Join(strings.Fields(text), " ")
+	chars := utf8.RuneCountInString(normalized)
+	words := 0
+	for _, f := range strings.Fields(normalized) {
+		if strings.IndexFunc(f, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	if limit := *config.MinText.Chars; chars < limit {
+		return fmt.Sprintf("мало символов: %d из %d", chars, limit)
+	}
+	if limit := *config.MinText.Words; words < limit {
+		return fmt.Sprintf("мало слов: %d из %d", words, limit)
+	}
+	return ""
+}
+
+// questionFilterReason причина пропуска текста, не похожего на вопрос,
+// или пустая строка. В режиме warn оценка только пишется в лог.
+func questionFilterReason(logger *slog.Logger, text string) string {
+	mode := config.QuestionFilter.Mode
+	if mode == appconfig.QuestionFilterOff {
+		return ""
+	}
+	score, reasons := classify.QuestionScore(text)
+	threshold := *config.QuestionFilter.Threshold
+	if score >= threshold {
+		logger.Debug(msg.T("filter.looks-like-question"), "score", score, "reasons", strings.Join(reasons, ", "))
+		return ""
+	}
+	reason := fmt.Sprintf("не похоже на вопрос: оценка %d при пороге %d", score, threshold)
+	if len(reasons) > 0 {
+		reason += " (" + strings.Join(reasons, ", ") + ")"
+	}
+	if mode == appconfig.QuestionFilterWarn {
+		logger.Warn(msg.T("filter.not-question-sent"), "score", score, "threshold", threshold, "reasons", strings.Join(reasons, ", "))
+		return ""
+	}
+	return reason
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// countingLLM считает запросы к модели и запоминает промпты
+type countingLLM struct {
+	next    llm.Answerer
+	calls   int
+	prompts []string
+}
+
+func (c *countingLLM) Answer(ctx context.Context, req llm.Request) (string, error) {
+	c.calls++
+	c.prompts = append(c.prompts, req.Prompt)
+	return c.next.Answer(ctx, req)
+}
+
+// Пороги по умолчанию консервативные: короткие, но настоящие вопросы
+// проходят, а заголовок окна и рамки слайда — нет
+func TestShortTextReason(t *testing.T) {
+	testEnv(t, "")
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"Reverse a linked list", ""},
+		{"  Reverse\n a\tlinked   list \n", ""},
+		{"Two Sum", ""},
+		{"Что такое горутина?", ""},
+		{"", "мало символов: 0 из 6"},
+		{"Trash", "мало символов: 5 из 6"},
+		// «|» и рамки не считаются словами
+		{"Trash | ---", "мало слов: 1 из 2"},
+		{"Terminal", "мало слов: 1 из 2"},
+	} {
+		if got := shortTextReason(tc.text); got != tc.want {
+			t.Errorf("shortTextReason(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestShortTextReasonDisabled(t *testing.T) {
+	testEnv(t, "minText:\n  chars: 0\n  words: 0\n")
+	if got := shortTextReason(""); got != "" {
+		t.Errorf("проверка отключена, но %q", got)
+	}
+}
+
+// Снимок с коротким текстом пропускается без запроса к модели: причина и
+// текст в состоянии, без повторов; «Reverse a linked list» обрабатывается
+func TestShortTextSkipsLLM(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		status string
+		calls  int
+	}{
+		{"Trash |", statusSkipped, 0},
+		{"Reverse a linked list", statusDone, 1},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			testEnv(t, "maxAttempts: 3\nmock:\n  text: "+tc.text+"\n  answer: Ответ.\n")
+			counter := &countingLLM{next: pipeline.LLM}
+			pipeline.LLM = counter
+			writeInput(t, "shot.png")
+
+			q := newQueue(config.QueueSize)
+			if _, err := scanDirectory(context.Background(), q); err != nil {
+				t.Fatal(err)
+			}
+			ctx, stop := context.WithCancel(context.Background())
+			wg := startWorkers(ctx, context.Background(), q, 1)
+			q.WaitIdle(context.Background())
+			stop()
+			wg.Wait()
+
+			fs, _ := state.Get("shot.png")
+			if fs.Status != tc.status || counter.calls != tc.calls {
+				t.Fatalf("%s (%s), запросов к модели %d", fs.Status, fs.LastError, counter.calls)
+			}
+			if tc.status == statusSkipped {
+				if fs.Text != tc.text || !strings.Contains(fs.LastError, "мало слов") || fs.Attempts > 1 {
+					t.Errorf("состояние %+v", fs)
+				}
+				if totals.FailedCount() != 0 {
+					t.Errorf("пропуск посчитан неудачей")
+				}
+			}
+		})
+	}
+}
+
+// reprocess --force отправляет модели и слишком короткий текст
+func TestShortTextForce(t *testing.T) {
+	testEnv(t, "mock:\n  text: Trash |\n  answer: Ответ.\n")
+	counter := &countingLLM{next: pipeline.LLM}
+	pipeline.LLM = counter
+	writeInput(t, "shot.png")
+	path := filepath.Join(config.InputDir, "shot.png")
+
+	if _, err := processFile(context.Background(), apppipeline.Request{Path: path, Prompt: config.PROMPT}); err == nil {
+		t.Fatal("короткий текст отправлен без --force")
+	}
+	out, err := processFile(context.Background(), apppipeline.Request{Path: path, Prompt: config.PROMPT, Force: true})
+	if err != nil || out == "" || counter.calls != 1 {
+		t.Errorf("--force: %q, %v, запросов к модели %d", out, err, counter.calls)
+	}
+}
+
+// warn пишет оценку в лог, но пропускает текст к модели; skip отсеивает
+// его с оценкой и причинами; off не проверяет
+func TestQuestionFilterModes(t *testing.T) {
+	const chat = "Иван 10:02 привет\nМария 10:05 да\nИван 10:06 ок"
+	for _, tc := range []struct {
+		mode, want string
+		logged     bool
+	}{
+		{"off", "", false},
+		{"warn", "", true},
+		{"skip", "не похоже на вопрос: оценка -2 при пороге 2 (много меток времени)", false},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			testEnv(t, "questionFilter:\n  mode: "+tc.mode+"\n")
+			buf := captureLog(t)
+			if got := filterReason(slog.Default(), chat); got != tc.want {
+				t.Errorf("причина %q, want %q", got, tc.want)
+			}
+			if logged := strings.Contains(buf.String(), msg.T("filter.not-question-sent")); logged != tc.logged {
+				t.Errorf("предупреждение в логе: %v\n%s", logged, buf)
+			}
+			if got := filterReason(slog.Default(), "Reverse a linked list"); got != "" {
+				t.Errorf("вопрос отсеян: %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Этапы обработки файла для замеров времени
+const (
+	stageWait     = apppipeline.StageWait // от постановки в очередь до начала обработки
+	stageOCR      = apppipeline.StageOCR
+	stageLLM      = "llm"
+	stageCheck    = "check" // проверка кода из ответа
+	stageOutput   = "output"
+	stageDelivery = "delivery" // подтверждение источнику (S3, почта)
+)
+
+var stageOrder = []string{stageWait, stageOCR, stageLLM, stageCheck, stageOutput, stageDelivery}
+
+// stageTimings замеры одного файла: длительности этапов, этап ошибки и
+// расход токенов, а для отчёта — интервалы этапов, провайдеры и файлы
+// результатов
+type stageTimings struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	failed    string
+	tokensIn  int
+	tokensOut int
+	spans     []ReportStage
+	providers []ReportProvider
+	outputs   []string
+	inverts   []ReportInvert
+}
+
+type timingsKey struct{}
+
+// withTimings передаёт замеры файла провайдерам через контекст запросов
+func withTimings(ctx context.Context, t *stageTimings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+func timingsFrom(ctx context.Context) *stageTimings {
+	t, _ := ctx.Value(timingsKey{}).(*stageTimings)
+	return t
+}
+
+func newStageTimings() *stageTimings {
+	return &stageTimings{durations: make(map[string]time.Duration)}
+}
+
+// Track выполняет этап и записывает его длительность, в том числе при
+// ошибке, а первый этап с ошибкой запоминается. Длительность также
+// попадает в сводку сессии.
+func (t *stageTimings) Track(stage string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	t.Span(stage, start, time.Now(), err)
+	if err != nil {
+		t.mu.Lock()
+		if t.failed == "" {
+			t.failed = stage
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// Span записывает этап, прошедший с start до end, с итогом err
+func (t *stageTimings) Span(stage string, start, end time.Time, err error) {
+	span := ReportStage{Name: stage, Start: start, End: end, DurationMs: end.Sub(start).Milliseconds(), Outcome: outcomeOK}
+	if err != nil {
+		span.Outcome, span.Error = outcomeError, redactSecrets(err.Error())
+	}
+	t.Add(stage, end.Sub(start))
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+}
+
+// UseProvider отмечает провайдера и модель, ответившие на этапе; повтор
+// не дублируется
+func (t *stageTimings) UseProvider(stage, provider, model string) {
+	if t == nil {
+		return
+	}
+	use := ReportProvider{Stage: stage, Provider: provider, Model: model}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range t.providers {
+		if p == use {
+			return
+		}
+	}
+	t.providers = append(t.providers, use)
+}
+
+// AddInvert отмечает решение об инверсии снимка
+func (t *stageTimings) AddInvert(r ReportInvert) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.inverts = append(t.inverts, r)
+	t.mu.Unlock()
+}
+
+// AddOutput отмечает записанный файл результата
+func (t *stageTimings) AddOutput(path string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.outputs = append(t.outputs, path)
+	t.mu.Unlock()
+}
+
+// AddTokens учитывает расход токенов одного ответа модели
+func (t *stageTimings) AddTokens(prompt, candidates int) {
+	t.mu.Lock()
+	t.tokensIn += prompt
+	t.tokensOut += candidates
+	t.mu.Unlock()
+}
+
+// Record переносит замеры в запись состояния
+func (t *stageTimings) Record(fs *FileState) {
+	if t == nil {
+		return
+	}
+	fs.TimingsMs = t.Millis()
+	t.mu.Lock()
+	fs.FailedStage = t.failed
+	fs.TokensIn, fs.TokensOut = t.tokensIn, t.tokensOut
+	t.mu.Unlock()
+}
+
+func (t *stageTimings) Add(stage string, d time.Duration) {
+	t.mu.Lock()
+	t.durations[stage] += d
+	t.mu.Unlock()
+	sessionStages.Add(stage, d)
+}
+
+// Millis длительности в миллисекундах для сохранения в состоянии
+func (t *stageTimings) Millis() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.durations) == 0 {
+		return nil
+	}
+	out := make(map[string]int64, len(t.durations))
+	for stage, d := range t.durations {
+		out[stage] = d.Milliseconds()
+	}
+	return out
+}
+
+// LogAttrs атрибуты лога в порядке этапов
+func (t *stageTimings) LogAttrs() []any {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var attrs []any
+	for _, stage := range stageOrder {
+		if d, ok := t.durations[stage]; ok {
+			attrs = append(attrs, slog.Duration(stage, d.Round(time.Millisecond)))
+		}
+	}
+	return attrs
+}
+
+// stageStats длительности этапов за сессию
+type stageStats struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+var sessionStages = &stageStats{samples: make(map[string][]time.Duration)}
+
+func (s *stageStats) Add(stage string, d time.Duration) {
+	s.mu.Lock()
+	s.samples[stage] = append(s.samples[stage], d)
+	s.mu.Unlock()
+}
+
+// percentile значение p-го процентиля методом ближайшего ранга; sorted
+// должен быть отсортирован по возрастанию
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Summary строки сводки p50/p95 по этапам, у которых были замеры
+func (s *stageStats) Summary() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lines []string
+	for _, stage := range stageOrder {
+		samples := append([]time.Duration(nil), s.samples[stage]...)
+		if len(samples) == 0 {
+			continue
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		lines = append(lines, fmt.Sprintf(msg.T("stats.stage-line"), stage,
+			percentile(samples, 50).Round(time.Millisecond), percentile(samples, 95).Round(time.Millisecond), len(samples)))
+	}
+	return lines
+}
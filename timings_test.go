@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+func ms(values ...int) []time.Duration {
+	out := make([]time.Duration, len(values))
+	for i, v := range values {
+		out[i] = time.Duration(v) * time.Millisecond
+	}
+	return out
+}
+
+// Процентиль методом ближайшего ранга: rank = ⌈p/100·n⌉
+func TestPercentile(t *testing.T) {
+	for _, tc := range []struct {
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{nil, 50, 0},
+		{ms(7), 50, 7 * time.Millisecond},
+		{ms(7), 95, 7 * time.Millisecond},
+		{ms(1, 2), 50, 1 * time.Millisecond},
+		{ms(1, 2), 95, 2 * time.Millisecond},
+		{ms(1, 2, 3, 4), 50, 2 * time.Millisecond},
+		{ms(1, 2, 3, 4, 5), 50, 3 * time.Millisecond},
+		{ms(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), 95, 100 * time.Millisecond},
+		{ms(10, 20, 30, 40, 50, 60, 70, 80, 90, 100), 90, 90 * time.Millisecond},
+		{ms(1, 2, 3), 0, 1 * time.Millisecond},
+		{ms(1, 2, 3), 100, 3 * time.Millisecond},
+	} {
+		if got := percentile(tc.sorted, tc.p); got != tc.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tc.sorted, tc.p, got, tc.want)
+		}
+	}
+
+	// Двадцать замеров 1..20 мс: p50 — 10-й, p95 — 19-й
+	var samples []int
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, i)
+	}
+	if p50, p95 := percentile(ms(samples...), 50), percentile(ms(samples...), 95); p50 != 10*time.Millisecond || p95 != 19*time.Millisecond {
+		t.Errorf("p50 = %v, p95 = %v", p50, p95)
+	}
+}
+
+// Сводка сортирует замеры, идёт в порядке этапов и пропускает этапы без
+// замеров
+func TestStageStatsSummary(t *testing.T) {
+	s := &stageStats{samples: map[string][]time.Duration{
+		stageLLM:  ms(900, 100, 500, 300, 700),
+		stageWait: ms(2),
+		"unknown": ms(1),
+	}}
+	want := []string{
+		fmt.Sprintf(msg.T("stats.stage-line"), stageWait, 2*time.Millisecond, 2*time.Millisecond, 1),
+		fmt.Sprintf(msg.T("stats.stage-line"), stageLLM, 500*time.Millisecond, 900*time.Millisecond, 5),
+	}
+	if got := s.Summary(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("сводка:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := (&stageStats{samples: map[string][]time.Duration{}}).Summary(); len(got) != 0 {
+		t.Errorf("пустая сводка %v", got)
+	}
+}
+
+// Длительности повторённого этапа складываются, а этап ошибки —
+// первый упавший
+func TestStageTimingsRecord(t *testing.T) {
+	tm := newStageTimings()
+	tm.Add(stageOCR, 40*time.Millisecond)
+	tm.Add(stageOCR, 60*time.Millisecond)
+	tm.Track(stageLLM, func() error { return errors.New("503") })
+	tm.Track(stageOutput, func() error { return errors.New("диск") })
+	tm.AddTokens(10, 3)
+	tm.AddTokens(5, 2)
+
+	var fs FileState
+	tm.Record(&fs)
+	if fs.TimingsMs[stageOCR] != 100 || fs.FailedStage != stageLLM || fs.TokensIn != 15 || fs.TokensOut != 5 {
+		t.Errorf("записано %+v", fs)
+	}
+	var keys []string
+	for _, a := range tm.LogAttrs() {
+		keys = append(keys, fmt.Sprint(a))
+	}
+	if len(keys) != 3 || !strings.HasPrefix(keys[0], "ocr=100ms") {
+		t.Errorf("атрибуты лога %v", keys)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/bmatcuk/doublestar/v4"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+)
+
+// languageNames названия языков для промпта перевода
+var languageNames = map[string]string{
+	"en": "английский",
+	"ru": "русский",
+	"de": "немецкий",
+	"fr": "французский",
+	"es": "испанский",
+}
+
+// translatedRe результаты перевода вида name.en.md, которые --all
+// пропускает
+var translatedRe = regexp.MustCompile(`\.[a-z]{2}\.md$`)
+
+// runTranslate переводит сохранённые ответы в <имя>.<язык>.md
+func runTranslate(args []string) int {
+	fset := flag.NewFlagSet("translate", flag.ContinueOnError)
+	to := fset.String("to", "en", "язык перевода: en, ru, de, fr, es")
+	all := fset.Bool("all", false, "перевести все ответы в outputDir")
+	force := fset.Bool("force", false, "переводить заново уже переведённые")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+	lang, ok := languageNames[*to]
+	if !ok || (fset.NArg() == 0 && !*all) {
+		fmt.Fprintln(os.Stderr, msg.T("translate.usage"))
+		return 2
+	}
+
+	loadConfig()
+	files, err := translateTargets(fset.Args(), *all)
+	if err != nil {
+		slog.Error(err.Error())
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed, done, skipped := 0, 0, 0
+	for _, f := range files {
+		dst := strings.TrimSuffix(f, ".md") + "." + *to + ".md"
+		if _, err := os.Stat(dst); err == nil && !*force {
+			skipped++
+			continue
+		}
+		if err := translateFile(ctx, f, dst, lang); err != nil {
+			slog.Error(msg.T("translate.failed"), "file", f, "error", err)
+			failed++
+		} else {
+			fmt.Println(dst)
+			done++
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	fmt.Printf(msg.T("translate.totals"), done, skipped, failed, totals.TokenCount())
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func translateTargets(args []string, all bool) ([]string, error) {
+	if all {
+		args = []string{filepath.Join(config.OutputDir, "*.md")}
+	}
+	var files []string
+	for _, arg := range args {
+		matches, err := doublestar.FilepathGlob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный шаблон %q: %w", arg, err)
+		}
+		if len(matches) == 0 && !strings.ContainsRune(arg, filepath.Separator) {
+			matches, _ = doublestar.FilepathGlob(filepath.Join(config.OutputDir, arg))
+		}
+		for _, m := range matches {
+			if strings.HasSuffix(m, ".md") && !(all && translatedRe.MatchString(m)) {
+				files = append(files, m)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("ответы не найдены: %s", strings.Join(args, " "))
+	}
+	return files, nil
+}
+
+func translateFile(ctx context.Context, src, dst, lang string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	text, blocks := protectCode(string(data))
+	prompt := "Переведи текст ниже на " + lang + " язык. Сохрани разметку Markdown как есть. " +
+		"Строки вида " + codePlaceholder(0) + " — это блоки кода: оставь их без изменений на своих местах. " +
+		"Не переводи то, что в `обратных кавычках`. Ответь только переводом.\n\n" + text
+	resp, err := pipeline.LLM.Answer(ctx, llm.Request{Prompt: prompt})
+	if err != nil {
+		return err
+	}
+	out, err := restoreCode(resp, blocks)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(out), 0644)
+}
+
+// protectCode заменяет блоки кода метками, чтобы модель их не трогала, и
+// возвращает блоки для restoreCode. Блок закрывает строка из тех же
+// символов ограждения (` или ~) не короче открывающей, поэтому вложенный
+// ```go внутри ````markdown остаётся частью внешнего блока.
+func protectCode(md string) (string, []string) {
+	var out, block []string
+	var blocks []string
+	fence := ""
+	for _, line := range strings.Split(md, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && codeFence(trimmed) != "":
+			fence = codeFence(trimmed)
+			block = []string{line}
+		case fence != "":
+			block = append(block, line)
+			if f := codeFence(trimmed); f != "" && f == trimmed && f[0] == fence[0] && len(f) >= len(fence) {
+				fence = ""
+				out = append(out, codePlaceholder(len(blocks)))
+				blocks = append(blocks, strings.Join(block, "\n"))
+			}
+		default:
+			out = append(out, line)
+		}
+	}
+	if fence != "" {
+		// незакрытый блок остаётся как есть
+		out = append(out, codePlaceholder(len(blocks)))
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+	return strings.Join(out, "\n"), blocks
+}
+
+// codeFence ограждение блока кода в начале строки: три и больше ` или ~
+func codeFence(line string) string {
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return ""
+	}
+	n := 0
+	for n < len(line) && line[n] == line[0] {
+		n++
+	}
+	return line[:n]
+}
+
+func codePlaceholder(n int) string {
+	return fmt.Sprintf("@@CODE_%d@@", n)
+}
+
+// restoreCode возвращает блоки кода на место меток; потерянная моделью
+// метка — ошибка, чтобы не сохранить перевод без кода
+func restoreCode(text string, blocks []string) (string, error) {
+	for i, b := range blocks {
+		p := codePlaceholder(i)
+		if !strings.Contains(text, p) {
+			return "", fmt.Errorf("в переводе потерян блок кода %d", i+1)
+		}
+		text = strings.Replace(text, p, b, 1)
+	}
+	return text, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"hack_interview/internal/llm"
+)
+
+// cyrillicRe слова, которые «переводит» fakeTranslator
+var cyrillicRe = regexp.MustCompile(`\p{Cyrillic}+`)
+
+// fakeTranslator заменяет каждое русское слово на EN: попади код в
+// перевод, он бы изменился
+type fakeTranslator struct {
+	prompts []string
+	drop    bool
+}
+
+func (f *fakeTranslator) Answer(_ context.Context, req llm.Request) (string, error) {
+	f.prompts = append(f.prompts, req.Prompt)
+	_, text, _ := strings.Cut(req.Prompt, "\n\n")
+	if f.drop {
+		text = strings.Replace(text, codePlaceholder(1), "", 1)
+	}
+	return cyrillicRe.ReplaceAllString(text, "EN"), nil
+}
+
+// translateBlocks блоки кода testdata/translate-answer.md в порядке
+// появления
+var translateBlocks = []string{
+	"```go\nfunc reverse(s []int) {\n\tfor i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {\n\t\ts[i], s[j] = s[j], s[i] // меняем местами   \n\t}\n}\n```",
+	"   ```python\n   print(\"Привет, мир\")  \n   ```",
+	"````markdown\nТекст ответа\n```go\nfmt.Println(\"не переводить\")\n```\n````",
+	"~~~sql\nSELECT имя FROM сотрудники; -- комментарий\n~~~",
+	"```text\r\nстрока с CRLF\r\n```\r",
+}
+
+// Блоки кода ответа — с табуляцией, пробелами в конце строк, отступом в
+// списке, вложенным ограждением, ~~~ и CRLF — проходят перевод байт в байт,
+// а модель их не видит
+func TestTranslateKeepsCodeFences(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "translate-answer.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEnv(t, "")
+	src := filepath.Join(config.OutputDir, "answer.md")
+	if err := os.WriteFile(src, fixture, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr := &fakeTranslator{}
+	pipeline.LLM = tr
+	dst := filepath.Join(config.OutputDir, "answer.en.md")
+	if err := translateFile(context.Background(), src, dst, "английский"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rest := string(out)
+	for i, b := range translateBlocks {
+		if !strings.Contains(string(fixture), b) {
+			t.Fatalf("блока %d нет в эталонном ответе", i+1)
+		}
+		if !strings.Contains(rest, b) {
+			t.Errorf("блок %d изменён:\n%q\nперевод:\n%s", i+1, b, out)
+		}
+		if strings.Contains(tr.prompts[0], b) {
+			t.Errorf("блок %d отправлен модели", i+1)
+		}
+		rest = strings.Replace(rest, b, "", 1)
+	}
+	if cyrillicRe.MatchString(rest) || !strings.Contains(rest, "## EN") || !strings.Contains(rest, "`append`") {
+		t.Errorf("текст вне кода не переведён:\n%s", rest)
+	}
+}
+
+// Перевод, в котором модель потеряла блок кода, не сохраняется
+func TestTranslateRejectsLostBlock(t *testing.T) {
+	testEnv(t, "")
+	src := filepath.Join(config.OutputDir, "answer.md")
+	os.WriteFile(src, []byte("Текст\n\n```go\na()\n```\n\nЕщё\n\n```go\nb()\n```\n"), 0644)
+	pipeline.LLM = &fakeTranslator{drop: true}
+	dst := filepath.Join(config.OutputDir, "answer.en.md")
+	if err := translateFile(context.Background(), src, dst, "английский"); err == nil || !strings.Contains(err.Error(), "блок кода 2") {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := os.Stat(dst); err == nil {
+		t.Error("перевод без блока кода сохранён")
+	}
+}
+
+func TestProtectCodeUnclosed(t *testing.T) {
+	md := "До\n```go\nfunc f() {\n"
+	text, blocks := protectCode(md)
+	if text != "До\n"+codePlaceholder(0) || len(blocks) != 1 || blocks[0] != "```go\nfunc f() {\n" {
+		t.Errorf("text %q, blocks %q", text, blocks)
+	}
+	if out, err := restoreCode(text, blocks); err != nil || out != md {
+		t.Errorf("restoreCode = %q, %v", out, err)
+	}
+}
+
+// --all пропускает уже переведённые ответы
+func TestTranslateTargetsAll(t *testing.T) {
+	testEnv(t, "")
+	for _, name := range []string{"a.md", "a.en.md", "b.md", "b.ocr.txt"} {
+		os.WriteFile(filepath.Join(config.OutputDir, name), []byte("x"), 0644)
+	}
+	files, err := translateTargets(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "a.md" || filepath.Base(files[1]) != "b.md" {
+		t.Errorf("файлы %v", files)
+	}
+	// Явно указанный перевод берётся
+	if files, _ := translateTargets([]string{"a.en.md"}, false); len(files) != 1 {
+		t.Errorf("по имени: %v", files)
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/lipgloss"
+
+	"hack_interview/internal/msg"
+)
+
+// Полноэкранный интерфейс (--tui): список файлов, просмотр ответа, панели
+// обработчиков и очереди. Данные приходят из шины событий, как и в
+// headless-режиме; логи на время работы TUI пишутся только в logFile.
+
+const (
+	tuiListWidth  = 36
+	tuiMaxPreview = 256 << 10 // длиннее показываем только начало ответа
+)
+
+var (
+	tuiTitle    = lipgloss.NewStyle().Bold(true)
+	tuiSelected = lipgloss.NewStyle().Reverse(true)
+	tuiDim      = lipgloss.NewStyle().Faint(true)
+	tuiBorder   = lipgloss.NewStyle().Border(lipgloss.NormalBorder())
+)
+
+type tuiItem struct {
+	Name  string
+	State FileState
+}
+
+type tuiModel struct {
+	q     *Queue
+	items []tuiItem
+
+	selected int
+	width    int
+	height   int
+	preview  viewport.Model
+	// previewFor для какого файла и ширины отрисован просмотр
+	previewFor string
+	// style тема glamour; фон терминала определяется до запуска TUI, пока
+	// запрос к терминалу не мешает отрисовке
+	style   string
+	message string
+}
+
+type tuiEventMsg Event
+type tuiTickMsg time.Time
+
+// runTUI показывает интерфейс до выхода пользователя, затем вызывает stop.
+// Сигнал завершения отменяет ctx и закрывает интерфейс.
+func runTUI(ctx context.Context, q *Queue, stop func()) error {
+	events, unsubscribe := bus.Subscribe(256)
+	defer unsubscribe()
+
+	// Текстовые логи испортили бы экран
+	console.mu.Lock()
+	prev := console.w
+	console.w = io.Discard
+	console.mu.Unlock()
+	defer func() {
+		console.mu.Lock()
+		console.w = prev
+		console.mu.Unlock()
+	}()
+
+	m := &tuiModel{q: q, preview: viewport.New(0, 0), style: "light"}
+	if lipgloss.HasDarkBackground() {
+		m.style = "dark"
+	}
+	for name, fs := range state.Snapshot() {
+		m.items = append(m.items, tuiItem{Name: name, State: fs})
+	}
+	m.sortItems()
+
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))
+	go func() {
+		for e := range events {
+			p.Send(tuiEventMsg(e))
+		}
+	}()
+	_, err := p.Run()
+	stop()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func tuiTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tuiTickMsg(t) })
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tuiTick()
+}
+
+// sortItems новые сверху
+func (m *tuiModel) sortItems() {
+	sort.SliceStable(m.items, func(i, j int) bool {
+		return m.items[i].State.UpdatedAt.After(m.items[j].State.UpdatedAt)
+	})
+}
+
+func (m *tuiModel) current() (tuiItem, bool) {
+	if m.selected < 0 || m.selected >= len(m.items) {
+		return tuiItem{}, false
+	}
+	return m.items[m.selected], true
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.previewFor = ""
+	case tuiTickMsg:
+		return m, tuiTick()
+	case tuiEventMsg:
+		m.applyEvent(Event(msg))
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.items)-1 {
+				m.selected++
+			}
+		case "o", "enter":
+			m.message = m.openSelected()
+		case "c":
+			m.message = m.copySelected()
+		case "r":
+			m.message = m.reprocessSelected()
+		default:
+			// Остальные клавиши (pgup/pgdown, стрелки с shift) листают ответ
+			var cmd tea.Cmd
+			m.preview, cmd = m.preview.Update(msg)
+			return m, cmd
+		}
+	}
+	m.refreshPreview()
+	return m, nil
+}
+
+func (m *tuiModel) applyEvent(e Event) {
+	if e.Kind == EventStage {
+		return // панель обработчиков читает activity при отрисовке
+	}
+	var selectedName string
+	if it, ok := m.current(); ok {
+		selectedName = it.Name
+	}
+	fs := e.State
+	if e.Kind == EventQueued {
+		fs = FileState{Status: statusProcessing, UpdatedAt: e.Time}
+	}
+	found := false
+	for i := range m.items {
+		if m.items[i].Name == e.Name {
+			m.items[i].State = fs
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.items = append(m.items, tuiItem{Name: e.Name, State: fs})
+	}
+	m.sortItems()
+	// Выделение остаётся на том же файле
+	for i := range m.items {
+		if m.items[i].Name == selectedName {
+			m.selected = i
+		}
+	}
+	if e.Kind == EventDone && e.Name == selectedName {
+		m.previewFor = ""
+	}
+}
+
+func (m *tuiModel) refreshPreview() {
+	w := m.width - tuiListWidth - 4
+	h := m.height - 9
+	if w < 10 || h < 3 {
+		return
+	}
+	m.preview.Width, m.preview.Height = w, h
+
+	it, ok := m.current()
+	key := fmt.Sprintf("%s|%d|%s", it.Name, w, it.State.Output)
+	if key == m.previewFor {
+		return
+	}
+	m.previewFor = key
+	if !ok || it.State.Output == "" {
+		m.preview.SetContent(tuiDim.Render(msg.T("tui.no-answer")))
+		return
+	}
+	data, err := os.ReadFile(it.State.Output)
+	if err != nil {
+		m.preview.SetContent(err.Error())
+		return
+	}
+	truncated := len(data) > tuiMaxPreview
+	if truncated {
+		data = data[:tuiMaxPreview]
+	}
+	text := string(data)
+	r, err := glamour.NewTermRenderer(glamour.WithStandardStyle(m.style), glamour.WithWordWrap(w-2))
+	if err == nil {
+		if out, err := r.Render(text); err == nil {
+			text = out
+		}
+	}
+	if truncated {
+		text += "\n" + tuiDim.Render(msg.T("tui.preview-truncated"))
+	}
+	m.preview.SetContent(text)
+	m.preview.GotoTop()
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case statusDone:
+		return "✓"
+	case statusFailed:
+		return "✗"
+	case statusRetry:
+		return "↻"
+	case statusProcessing:
+		return "…"
+	case statusSkipped:
+		return "-"
+	}
+	return "?"
+}
+
+func (m *tuiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	listHeight := m.height - 9
+	if listHeight < 1 {
+		listHeight = 1
+	}
+
+	// Прокрутка списка так, чтобы выделение было видно
+	first := 0
+	if m.selected >= listHeight {
+		first = m.selected - listHeight + 1
+	}
+	var rows []string
+	for i := first; i < len(m.items) && i < first+listHeight; i++ {
+		it := m.items[i]
+		row := truncateRunes(statusIcon(it.State.Status)+" "+it.Name, tuiListWidth-2)
+		if i == m.selected {
+			row = tuiSelected.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		rows = append(rows, tuiDim.Render(msg.T("tui.no-files")))
+	}
+	list := tuiBorder.Width(tuiListWidth).Height(listHeight).Render(strings.Join(rows, "\n"))
+	preview := tuiBorder.Width(m.preview.Width).Height(listHeight).Render(m.preview.View())
+
+	workers := strings.Join(workerSummaries(time.Now()), " | ")
+	panes := tuiBorder.Width(m.width - 2).Render(
+		tuiTitle.Render(msg.T("tui.workers")) + truncateRunes(workers, m.width-20) + "\n" +
+			fmt.Sprintf(msg.T("tui.counters"),
+				tuiTitle.Render(msg.T("tui.queue")), m.q.Len(), m.q.Cap(),
+				totals.ProcessedCount(), totals.FailedCount(), totals.OCRRequestCount(), totals.TokenCount()))
+
+	help := tuiDim.Render(msg.T("tui.help"))
+	if m.message != "" {
+		help = m.message + "  " + help
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, list, preview), panes, truncateRunes(help, m.width))
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func (m *tuiModel) openSelected() string {
+	it, ok := m.current()
+	if !ok || it.State.Output == "" {
+		return msg.T("tui.no-output")
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", it.State.Output)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", it.State.Output)
+	default:
+		cmd = exec.Command("xdg-open", it.State.Output)
+	}
+	if err := cmd.Start(); err != nil {
+		return msg.T("tui.open-failed") + err.Error()
+	}
+	go cmd.Wait()
+	return msg.T("tui.opened") + filepath.Base(it.State.Output)
+}
+
+func (m *tuiModel) copySelected() string {
+	it, ok := m.current()
+	if !ok || it.State.Output == "" {
+		return msg.T("tui.no-output")
+	}
+	data, err := os.ReadFile(it.State.Output)
+	if err != nil {
+		return err.Error()
+	}
+	if err := writeClipboardText(data); err != nil {
+		return err.Error()
+	}
+	return msg.T("tui.copied")
+}
+
+// reprocessSelected ставит файл в очередь заново. Файлы из S3 и почты
+// после обработки удаляются, их повторная обработка здесь недоступна.
+func (m *tuiModel) reprocessSelected() string {
+	it, ok := m.current()
+	if !ok {
+		return ""
+	}
+	if strings.Contains(it.Name, "://") {
+		return msg.T("tui.reprocess-local-only")
+	}
+	path := filepath.Join(config.InputDir, it.Name)
+	if it.State.MovedTo != "" {
+		path = it.State.MovedTo
+	}
+	if err := state.Reset([]string{it.Name}); err != nil {
+		return err.Error()
+	}
+	if !m.q.TryPush(Job{Name: it.Name, Path: path}) {
+		return msg.T("tui.reprocess-next-scan")
+	}
+	return msg.T("tui.queued") + it.Name
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+
+	"hack_interview/internal/msg"
+	apppipeline "hack_interview/internal/pipeline"
+)
+
+// Веб-интерфейс /ui/: список сессий и вопросов, ответ рядом с исходным
+// снимком, копирование и повторная обработка. Новые ответы приходят через
+// SSE (/ui/events).
+
+//go:embed web
+var webFiles embed.FS
+
+var uiTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"pathEscape": url.PathEscape,
+	"timeFormat": func(t time.Time) string { return t.Local().Format("2006-01-02 15:04:05") },
+}).ParseFS(webFiles, "web/*.html"))
+
+// uiMarkdown markdown с подсветкой кода встроенными стилями: странице не
+// нужны внешние таблицы стилей
+var uiMarkdown = goldmark.New(goldmark.WithExtensions(
+	extension.GFM,
+	highlighting.NewHighlighting(
+		highlighting.WithStyle("github"),
+		highlighting.WithFormatOptions(chromahtml.WithClasses(false)),
+	),
+))
+
+// uiTokenCookie хранит токен после входа по ссылке /ui/?token=...
+const uiTokenCookie = "hack_interview_token"
+
+func registerUI(ctx context.Context, mux *http.ServeMux) {
+	static, _ := fs.Sub(webFiles, "web")
+	mux.Handle("GET /ui/static/", requireUIToken(http.StripPrefix("/ui/static/", http.FileServerFS(static))))
+	mux.Handle("GET /ui/{$}", requireUIToken(http.HandlerFunc(handleUIIndex)))
+	mux.Handle("GET /ui/file/{name...}", requireUIToken(http.HandlerFunc(handleUIFile)))
+	mux.Handle("GET /ui/image/{name...}", requireUIToken(http.HandlerFunc(handleUIImage)))
+	mux.Handle("POST /ui/reprocess/{name...}", requireUIToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleUIReprocess(ctx, w, r)
+	})))
+	mux.Handle("GET /ui/events", requireUIToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleUIEvents(ctx, w, r)
+	})))
+	slog.Info(msg.T("ui.available"), "url", "http://"+config.Server.Listen+"/ui/?token=<server.token>")
+}
+
+// requireUIToken принимает токен в заголовке Authorization, в cookie или
+// в параметре token: тогда он переносится в cookie, а адрес очищается,
+// чтобы токен не оставался в истории браузера
+func requireUIToken(next http.Handler) http.Handler {
+	want := []byte(config.Server.Token)
+	valid := func(s string) bool { return subtle.ConstantTimeCompare([]byte(s), want) == 1 }
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get("token"); token != "" && r.Method == http.MethodGet {
+			if !valid(token) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: uiTokenCookie, Value: token, Path: "/ui/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+			u := *r.URL
+			q := u.Query()
+			q.Del("token")
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusSeeOther)
+			return
+		}
+		if c, err := r.Cookie(uiTokenCookie); err == nil && valid(c.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if valid(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "unauthorized: откройте /ui/?token=<server.token>", http.StatusUnauthorized)
+	})
+}
+
+// uiItem вопрос в списке
+type uiItem struct {
+	Name    string
+	Title   string
+	Kind    string
+	State   FileState
+	Updated time.Time
+}
+
+type uiSession struct {
+	ID    string
+	Items []uiItem
+}
+
+func handleUIIndex(w http.ResponseWriter, r *http.Request) {
+	if err := state.Refresh(); err != nil {
+		slog.Error(msg.T("state.read-failed"), "error", err)
+	}
+	bySession := make(map[string]*uiSession)
+	for name, fs := range state.Snapshot() {
+		id := fs.Session
+		if id == "" {
+			id = fs.UpdatedAt.Local().Format("2006-01-02")
+		}
+		s, ok := bySession[id]
+		if !ok {
+			s = &uiSession{ID: id}
+			bySession[id] = s
+		}
+		item := uiItem{Name: name, Title: name, State: fs, Updated: fs.UpdatedAt}
+		if question, ok := uiQuestion(fs); ok {
+			item.Title = questionTitle(question, name)
+			item.Kind = kindLabel(classifyText(question))
+		}
+		s.Items = append(s.Items, item)
+	}
+	sessions := make([]*uiSession, 0, len(bySession))
+	for _, s := range bySession {
+		sort.Slice(s.Items, func(i, j int) bool { return s.Items[i].Updated.After(s.Items[j].Updated) })
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Items[0].Updated.After(sessions[j].Items[0].Updated) })
+	renderUI(w, "index.html", map[string]any{"Sessions": sessions})
+}
+
+// uiQuestion распознанный текст вопроса: из <имя>.ocr.txt рядом с ответом
+// или из записи пропущенного файла
+func uiQuestion(fs FileState) (string, bool) {
+	if fs.Output != "" {
+		if data, err := os.ReadFile(filepath.Join(filepath.Dir(fs.Output), ocrTextName(fs.Output))); err == nil {
+			return strings.TrimSpace(string(data)), true
+		}
+	}
+	if fs.Text != "" {
+		return fs.Text, true
+	}
+	return "", false
+}
+
+func handleUIFile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	fs, ok := state.Get(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	data := map[string]any{"Name": name, "State": fs, "HasImage": uiImagePath(name, fs) != ""}
+	if question, ok := uiQuestion(fs); ok {
+		data["Question"] = question
+		data["Title"] = questionTitle(question, name)
+	}
+	if fs.Output != "" {
+		raw, err := os.ReadFile(fs.Output)
+		if err != nil {
+			http.Error(w, "результат недоступен", http.StatusInternalServerError)
+			return
+		}
+		answer, err := renderAnswerHTML(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data["Raw"] = string(raw)
+		data["Answer"] = answer
+	}
+	renderUI(w, "file.html", data)
+}
+
+// renderAnswerHTML HTML результата для страницы файла. HTML goldmark без
+// unsafe экранирует вставки в markdown ответа модели, поэтому вывод можно
+// не экранировать повторно.
+func renderAnswerHTML(raw []byte) (template.HTML, error) {
+	var html strings.Builder
+	if err := uiMarkdown.Convert(raw, &html); err != nil {
+		return "", err
+	}
+	return template.HTML(html.String()), nil
+}
+
+// uiImagePath исходный снимок файла: во входной директории или в errorsDir
+func uiImagePath(name string, fs FileState) string {
+	for _, path := range []string{fs.MovedTo, filepath.Join(config.InputDir, name)} {
+		if path == "" {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func handleUIImage(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	fs, ok := state.Get(name)
+	path := uiImagePath(name, fs)
+	if !ok || path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// uiReprocessing файлы, повторная обработка которых уже запущена
+var uiReprocessing sync.Map
+
+// handleUIReprocess обрабатывает файл заново, как команда reprocess:
+// результат сохраняется под новым именем, итог приходит событием SSE
+func handleUIReprocess(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	fs, ok := state.Get(name)
+	path := uiImagePath(name, fs)
+	if !ok || path == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "исходный файл не найден"})
+		return
+	}
+	if _, busy := uiReprocessing.LoadOrStore(name, true); busy {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "файл уже обрабатывается"})
+		return
+	}
+	if err := state.Set(name, FileState{Status: statusProcessing, Owner: ownInstance(), Attempts: fs.Attempts}); err != nil {
+		slog.Error(msg.T("state.save-failed"), "error", err)
+	}
+	slog.Info(msg.T("ui.reprocess"), "file", name)
+	go func() {
+		defer uiReprocessing.Delete(name)
+		tm := newStageTimings()
+		out, err := processFile(ctx, apppipeline.Request{
+			Path:      path,
+			Prompt:    config.PROMPT,
+			Versioned: true,
+			ReuseOCR:  config.ReprocessReuseOCR,
+			Timings:   tm,
+		})
+		if err != nil && ctx.Err() != nil {
+			// Запись processing останется и будет восстановлена при запуске
+			return
+		}
+		recordResult(Job{Name: name, Path: path, Timings: tm}, out, err)
+	}()
+	writeJSON(w, http.StatusAccepted, map[string]string{"file": name, "status": statusProcessing})
+}
+
+// handleUIEvents отправляет события конвейера в формате Server-Sent Events
+func handleUIEvents(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming не поддерживается", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	events, unsubscribe := bus.Subscribe(64)
+	defer unsubscribe()
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			switch e.Kind {
+			case EventQueued:
+				fmt.Fprintf(w, "event: queued\ndata: %s\n\n", uiEventJSON(map[string]string{"name": e.Name}))
+			case EventDone:
+				fmt.Fprintf(w, "event: done\ndata: %s\n\n", uiEventJSON(map[string]string{"name": e.Name, "status": e.State.Status}))
+			default:
+				continue
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func uiEventJSON(v map[string]string) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
+
+func renderUI(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := uiTemplates.ExecuteTemplate(w, name, data); err != nil {
+		slog.Error(msg.T("ui.template-failed"), "template", name, "error", err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"hack_interview/internal/msg"
+)
+
+// version версия сборки, задаётся при выпуске:
+// go build -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+const (
+	releasesURL = "https://api.github.com/repos/seregin7272/hack_interview/releases/latest"
+	// checksumsAsset файл выпуска со строками "<sha256>  <имя файла>"
+	checksumsAsset = "checksums.txt"
+)
+
+type release struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r release) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+// releaseAssetName имя исполняемого файла выпуска для текущей платформы
+func releaseAssetName() string {
+	name := fmt.Sprintf("hack_interview_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// runUpdate проверяет последний выпуск на GitHub и заменяет исполняемый
+// файл. При любой ошибке текущий файл остаётся нетронутым.
+func runUpdate(args []string) int {
+	fset := flag.NewFlagSet("update", flag.ContinueOnError)
+	check := fset.Bool("check", false, "только сообщить, есть ли новая версия")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	rel, err := latestRelease(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, msg.T("update.check-failed"), err)
+		return 1
+	}
+	if !newerVersion(rel.TagName, version) {
+		fmt.Printf(msg.T("update.latest"), version)
+		return 0
+	}
+	fmt.Printf(msg.T("update.available"), rel.TagName, version)
+	if *check {
+		return 0
+	}
+
+	if err := applyUpdate(ctx, rel); err != nil {
+		fmt.Fprintln(os.Stderr, msg.T("update.failed"), err)
+		return 1
+	}
+	fmt.Printf(msg.T("update.done"), rel.TagName)
+	return 0
+}
+
+func latestRelease(ctx context.Context) (release, error) {
+	var rel release
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, releasesURL, nil)
+	if err != nil {
+		return rel, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return rel, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return rel, fmt.Errorf("GitHub API: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return rel, err
+	}
+	if rel.TagName == "" {
+		return rel, errors.New("в ответе GitHub нет версии выпуска")
+	}
+	return rel, nil
+}
+
+// newerVersion сравнивает версии вида v1.2.3; сборка без версии (dev)
+// считается старше любого выпуска
+func newerVersion(latest, current string) bool {
+	if current == "dev" || current == "" {
+		return true
+	}
+	l, c := parseVersion(latest), parseVersion(current)
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(v string) [3]int {
+	var out [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	for i, part := range strings.SplitN(v, ".", 3) {
+		out[i], _ = strconv.Atoi(part)
+	}
+	return out
+}
+
+// applyUpdate скачивает файл рядом с текущим исполняемым, сверяет
+// контрольную сумму и только затем подменяет исполняемый файл
+func applyUpdate(ctx context.Context, rel release) error {
+	asset := releaseAssetName()
+	binURL, sumsURL := rel.assetURL(asset), rel.assetURL(checksumsAsset)
+	if binURL == "" {
+		return fmt.Errorf("в выпуске %s нет файла %s", rel.TagName, asset)
+	}
+	if sumsURL == "" {
+		return fmt.Errorf("в выпуске %s нет %s, проверить файл невозможно", rel.TagName, checksumsAsset)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+
+	want, err := expectedChecksum(ctx, sumsURL, asset)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".hack_interview-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if err := download(ctx, binURL, io.MultiWriter(tmp, h)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("контрольная сумма не совпадает: ожидалась %s, получена %s", want, got)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return replaceExecutable(exe, tmp.Name())
+}
+
+// replaceExecutable подменяет файл переименованием. Windows не даёт
+// перезаписать запущенный файл, но позволяет переименовать его, поэтому
+// текущий файл сначала откладывается в .old.
+func replaceExecutable(exe, newPath string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(newPath, exe)
+	}
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		return err
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		// Возвращаем прежний файл на место
+		os.Rename(old, exe)
+		return err
+	}
+	return nil
+}
+
+func expectedChecksum(ctx context.Context, url, asset string) (string, error) {
+	var buf strings.Builder
+	if err := download(ctx, url, &buf); err != nil {
+		return "", err
+	}
+	sc := bufio.NewScanner(strings.NewReader(buf.String()))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("в %s нет контрольной суммы для %s", checksumsAsset, asset)
+}
+
+func download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("загрузка %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
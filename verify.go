@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"hack_interview/internal/llm"
+	"hack_interview/internal/msg"
+)
+
+// flagNoVerify отключает проверку ответа на этот запуск
+var flagNoVerify bool
+
+// verifyOK первая строка ответа проверки, если замечаний нет
+const verifyOK = "LOOKS CORRECT"
+
+// defaultVerifyPrompt промпт проверки ответа
+const defaultVerifyPrompt = "Проверь ответ на вопрос с собеседования. Найди ошибки в коде и логике, " +
+	"крайние случаи, которые решение не учитывает, и фактические неточности. Если ошибок нет, " +
+	"ответь одной строкой " + verifyOK + ". Иначе ответь списком замечаний в Markdown, " +
+	"без вступлений и без исправленного решения."
+
+// verifier модель проверки ответа
+func (p *Pipeline) verifier() llm.Answerer {
+	if p.Verifier != nil {
+		return p.Verifier
+	}
+	return p.LLM
+}
+
+// review просит модель проверки найти ошибки в ответе. Возвращает
+// замечания ("" — ответ выглядит верным) и false, если проверить не
+// удалось.
+func (p *Pipeline) review(ctx context.Context, logger *slog.Logger, question, answer string) (string, bool) {
+	prompt := config.Verify.Prompt
+	if prompt == "" {
+		prompt = defaultVerifyPrompt
+	}
+	prompt += "\n\nВопрос:\n" + question + "\n\nОтвет:\n" + answer
+	resp, err := p.verifier().Answer(ctx, llm.Request{Prompt: prompt})
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("verify.failed"), "error", err)
+		return "", false
+	}
+	resp = strings.TrimSpace(resp)
+	first, _, _ := strings.Cut(resp, "\n")
+	if strings.Contains(strings.ToUpper(first), verifyOK) {
+		return "", true
+	}
+	return resp, true
+}
+
+// verifyAnswer проверяет ответ и возвращает его вместе с текстом раздела
+// «## Review». При verify.regenerate и найденных замечаниях ответ один раз
+// запрашивается заново с ними и проверяется ещё раз; при ошибке повторного
+// запроса остаётся исходный ответ. Ошибки проверки только пишутся в лог.
+func (p *Pipeline) verifyAnswer(ctx context.Context, logger *slog.Logger, req llm.Request, question, answer string) (string, string) {
+	issues, ok := p.review(ctx, logger, question, answer)
+	if !ok {
+		return answer, ""
+	}
+	if issues == "" {
+		logger.Info(msg.T("verify.ok"))
+		return answer, "Замечаний нет."
+	}
+	logger.Info(msg.T("verify.issues"))
+	if !config.Verify.Regenerate {
+		return answer, issues
+	}
+
+	req.Prompt += "\n\nПредыдущий ответ:\n" + answer +
+		"\n\nПри проверке в нём найдены замечания:\n" + issues +
+		"\n\nДай исправленный ответ целиком с учётом замечаний."
+	fixed, err := p.LLM.Answer(ctx, req)
+	providers.Record(err)
+	if err != nil {
+		logger.Warn(msg.T("verify.regenerate-failed"), "error", err)
+		return answer, issues
+	}
+	logger.Info(msg.T("verify.regenerated"))
+	again, ok := p.review(ctx, logger, question, fixed)
+	switch {
+	case !ok:
+		return fixed, "Ответ исправлен по замечаниям:\n\n" + issues
+	case again == "":
+		return fixed, "Ответ исправлен по замечаниям, повторная проверка замечаний не нашла:\n\n" + issues
+	}
+	return fixed, "Ответ исправлен по замечаниям:\n\n" + issues + "\n\nЗамечания к исправленному ответу:\n\n" + again
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"hack_interview/internal/metrics"
+	"hack_interview/internal/msg"
+	"hack_interview/internal/watch"
+)
+
+// dirSource источник файлов из входной директории
+type dirSource struct{}
+
+// Run наблюдает за входной директорией; если она недоступна дольше
+// InputDirGracePeriod, процесс завершается
+func (dirSource) Run(ctx context.Context, q *Queue) {
+	if err := newWatcher(q).Run(ctx); err != nil {
+		fatal(msg.T("watch.input-unavailable"), "dir", config.InputDir,
+			"gracePeriod", config.InputDirGracePeriod, "error", err)
+	}
+}
+
+// scanDirectory один проход по входной директории. Возвращает число файлов,
+// отложенных из-за заполненной очереди, или ошибку чтения директории.
+func scanDirectory(ctx context.Context, q *Queue) (int, error) {
+	return newWatcher(q).Scan(ctx)
+}
+
+// newWatcher наблюдение за входной директорией по конфигурации с
+// состоянием state, очередью q и группами groups
+func newWatcher(q *Queue) *watch.Watcher {
+	return &watch.Watcher{
+		Dir:         config.InputDir,
+		Store:       watchStore{},
+		Queue:       watchQueue{q},
+		Groups:      watchGroups{q},
+		Toggle:      groupToggleActive,
+		Accept:      acceptFile,
+		Sort:        func(files []os.FileInfo, now time.Time) { sortFiles(files, config.SortBy, now) },
+		MaxFileAge:  config.MaxFileAge,
+		GracePeriod: config.InputDirGracePeriod,
+		OnScan:      markTick,
+		OnSkipped: func(names []string, _ string) {
+			totals.AddSkipped(len(names))
+			for range names {
+				metrics.FileOutcome(statusSkipped)
+			}
+		},
+	}
+}
+
+// watchStore состояние state для наблюдения; state берётся при каждом
+// вызове, потому что хранилище может быть заменено
+type watchStore struct{}
+
+func (watchStore) Refresh() error                           { return state.Refresh() }
+func (watchStore) Eligible(name string, now time.Time) bool { return state.Eligible(name, now) }
+func (watchStore) MarkSkipped(names []string, reason string) error {
+	return state.MarkSkipped(names, reason)
+}
+
+func (watchStore) Seen(name string) bool {
+	_, seen := state.Get(name)
+	return seen
+}
+
+// watchQueue очередь заданий для наблюдения
+type watchQueue struct{ q *Queue }
+
+func (w watchQueue) Has(name string) bool  { return w.q.Has(name) }
+func (w watchQueue) Overflow(deferred int) { w.q.Overflow(deferred) }
+func (w watchQueue) Push(name, path string) bool {
+	return w.q.TryPush(Job{Name: name, Path: path})
+}
+
+// watchGroups группы снимков groups, собранные группы ставятся в q
+type watchGroups struct{ q *Queue }
+
+func (w watchGroups) Hold(name string, toggle bool, now time.Time) bool {
+	return groups.Hold(name, toggle, now)
+}
+
+func (w watchGroups) Ready(toggle bool, now time.Time) int {
+	return groups.Ready(w.q, toggle, now)
+}
@@ -0,0 +1,51 @@
+// Живые обновления через SSE, копирование ответа и повторная обработка
+(function () {
+  const page = document.body.dataset.page;
+  const name = document.body.dataset.name;
+  const live = document.getElementById("live");
+  const notice = document.getElementById("notice");
+  const say = (text) => { if (notice) notice.textContent = text; };
+
+  const events = new EventSource("/ui/events");
+  events.onopen = () => { live.textContent = "● онлайн"; };
+  events.onerror = () => { live.textContent = "○ нет связи, переподключение…"; };
+  events.addEventListener("queued", (e) => {
+    const data = JSON.parse(e.data);
+    live.textContent = "● в очереди: " + data.name;
+  });
+  events.addEventListener("done", (e) => {
+    const data = JSON.parse(e.data);
+    if (page === "index" || data.name === name) {
+      // Страница строится на сервере: проще перечитать её целиком
+      location.reload();
+    }
+  });
+
+  const copy = document.getElementById("copy");
+  if (copy) {
+    copy.addEventListener("click", async () => {
+      const raw = document.getElementById("raw").value;
+      try {
+        await navigator.clipboard.writeText(raw);
+      } catch (err) {
+        const area = document.getElementById("raw");
+        area.hidden = false;
+        area.select();
+        document.execCommand("copy");
+        area.hidden = true;
+      }
+      say("Скопировано");
+    });
+  }
+
+  const reprocess = document.getElementById("reprocess");
+  if (reprocess) {
+    reprocess.addEventListener("click", async () => {
+      reprocess.disabled = true;
+      const resp = await fetch("/ui/reprocess/" + encodeURIComponent(name), { method: "POST" });
+      const data = await resp.json().catch(() => ({}));
+      say(resp.ok ? "Обработка запущена, страница обновится по готовности" : (data.error || resp.statusText));
+      if (!resp.ok) reprocess.disabled = false;
+    });
+  }
+})();